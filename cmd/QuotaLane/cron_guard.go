@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// Cron job names used for panic metrics and alerts.
+const (
+	cronJobUnifiedRefresh     = "unified_oauth_refresh"
+	cronJobAutoRefresh        = "auto_refresh"
+	cronJobHealthCheck        = "openai_health_check"
+	cronJobConcurrencyCleanup = "concurrency_cleanup"
+	cronJobInactivePurge      = "inactive_account_purge"
+	cronJobHealthScoreRepair  = "health_score_repair"
+	cronJobMissingProvider    = "missing_provider_reconcile"
+	cronJobCredentialBinding  = "credential_binding_migration"
+)
+
+// cronPanicRecordTimeout bounds the Redis writes made when recording a panic.
+const cronPanicRecordTimeout = 5 * time.Second
+
+// cronPanicRecorder records recovered cron panics (implemented by biz.AccountUsecase).
+type cronPanicRecorder interface {
+	RecordCronPanic(ctx context.Context, job string, recovered any)
+}
+
+// safeCronJob wraps a cron func so that a panic is recovered, logged and recorded
+// (quotalane_cron_panics_total{job} plus an alert:cron_panic:{job} marker) instead of
+// silently skipping the run. The scheduler and later runs of the job are unaffected.
+func safeCronJob(job string, recorder cronPanicRecorder, logger log.Logger, fn func()) func() {
+	helper := log.NewHelper(logger)
+	return func() {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			helper.Errorw("panic in cron job", "job", job, "panic", r)
+
+			ctx, cancel := context.WithTimeout(context.Background(), cronPanicRecordTimeout)
+			defer cancel()
+			recorder.RecordCronPanic(ctx, job, r)
+		}()
+
+		fn()
+	}
+}
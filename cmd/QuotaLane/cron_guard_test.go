@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePanicRecorder struct {
+	mu     sync.Mutex
+	panics map[string][]any
+}
+
+func (f *fakePanicRecorder) RecordCronPanic(_ context.Context, job string, recovered any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.panics == nil {
+		f.panics = make(map[string][]any)
+	}
+	f.panics[job] = append(f.panics[job], recovered)
+}
+
+func (f *fakePanicRecorder) count(job string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.panics[job])
+}
+
+func TestSafeCronJob_RecoversAndRecords(t *testing.T) {
+	recorder := &fakePanicRecorder{}
+
+	job := safeCronJob("boom", recorder, log.DefaultLogger, func() { panic("bad state") })
+	require.NotPanics(t, job)
+	require.NotPanics(t, job)
+
+	assert.Equal(t, 2, recorder.count("boom"))
+	assert.Equal(t, "bad state", recorder.panics["boom"][0])
+}
+
+func TestSafeCronJob_NoPanicNotRecorded(t *testing.T) {
+	recorder := &fakePanicRecorder{}
+	ran := false
+
+	safeCronJob("ok", recorder, log.DefaultLogger, func() { ran = true })()
+
+	assert.True(t, ran)
+	assert.Zero(t, recorder.count("ok"))
+}
+
+func TestSafeCronJob_SchedulerKeepsRunning(t *testing.T) {
+	recorder := &fakePanicRecorder{}
+	var healthyRuns atomic.Int32
+
+	c := cron.New(cron.WithSeconds())
+	_, err := c.AddFunc("* * * * * *", safeCronJob("boom", recorder, log.DefaultLogger, func() { panic("bad state") }))
+	require.NoError(t, err)
+	_, err = c.AddFunc("* * * * * *", safeCronJob("ok", recorder, log.DefaultLogger, func() { healthyRuns.Add(1) }))
+	require.NoError(t, err)
+
+	c.Start()
+	defer func() { <-c.Stop().Done() }()
+
+	assert.Eventually(t, func() bool {
+		return recorder.count("boom") >= 2 && healthyRuns.Load() >= 2
+	}, 5*time.Second, 50*time.Millisecond, "panicking job runs again and other jobs keep running")
+}
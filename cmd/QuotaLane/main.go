@@ -63,6 +63,11 @@ func main() {
 		log.Fatalf("failed to load configuration: %v", err)
 	}
 
+	// Check every critical field up front so a bad deployment fails once with the full list of problems
+	if err := bc.Validate(); err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+
 	// Initialize Zap logger from configuration
 	zapLog, err := zapLogger.NewZapLogger(bc.Log)
 	if err != nil {
@@ -91,16 +96,131 @@ func main() {
 		"log.format", bc.Log.Format,
 	)
 
-	appComponents, cleanup, err := wireApp(bc.Server, bc.Data, bc.Auth, logger)
+	appComponents, cleanup, err := wireApp(bc.Server, bc.Data, bc.Auth, bc.Oauth, logger)
 	if err != nil {
 		panic(err)
 	}
 	defer cleanup()
 
+	// Apply per-deployment business options
+	appComponents.AccountUC.SetStrictPageSize(bc.Pagination.GetStrictPageSize())
+	appComponents.AccountGroupUC.SetRejectDuplicateMembers(bc.AccountGroup.GetRejectDuplicateMembers())
+	appComponents.AccountGroupUC.SetMinHealthScore(int(bc.AccountGroup.GetMinHealthScore()))
+
+	// Skip or return accounts stored without a provider; the reconcile cron job marks them as error
+	missingProviderPolicy, err := data.ParseMissingProviderPolicy(bc.Data.GetMissingProviderPolicy())
+	if err != nil {
+		panic(err)
+	}
+	appComponents.AccountRepo.SetMissingProviderPolicy(missingProviderPolicy)
+
+	// Share one provider concurrency limit across all background provider-calling jobs
+	providerLimiter := biz.NewProviderCallLimiter(bc.Jobs.GetProviderConcurrency())
+	appComponents.AccountUC.SetProviderCallLimiter(providerLimiter)
+	appComponents.OAuthRefreshTask.SetProviderCallLimiter(providerLimiter)
+	appComponents.AccountUC.SetHealthCheckSampleSize(int(bc.Jobs.GetHealthCheckSampleSize()))
+
+	// Per-account deadline inside batch token refresh so one hung provider call can't starve the batch
+	appComponents.AccountUC.SetRefreshAccountTimeout(bc.Jobs.GetRefreshAccountTimeout().AsDuration())
+	appComponents.OAuthRefreshTask.SetRefreshAccountTimeout(bc.Jobs.GetRefreshAccountTimeout().AsDuration())
+	// Window of the unified refresh job; the usecase only reports it in the runtime config
+	appComponents.AccountUC.SetExpiringRefreshThreshold(bc.Jobs.GetExpiringRefreshThreshold().AsDuration())
+	appComponents.OAuthRefreshTask.SetExpiringRefreshThreshold(bc.Jobs.GetExpiringRefreshThreshold().AsDuration())
+	// Refresh failures far from expiry don't count toward marking the account as error
+	appComponents.AccountUC.SetRefreshFailureGraceWindow(bc.Jobs.GetRefreshFailureGraceWindow().AsDuration())
+	// Every other background provider call (e.g. health checks) gets its own deadline as well
+	appComponents.AccountUC.SetProviderCallTimeout(bc.Jobs.GetProviderCallTimeout().AsDuration())
+	appComponents.AccountUC.SetMarkNeedsReauth(bc.Jobs.GetMarkNeedsReauth())
+	appComponents.OAuthRefreshTask.SetMarkNeedsReauth(bc.Jobs.GetMarkNeedsReauth())
+	appComponents.AccountUC.SetProviderDownHealthPenalty(int(bc.Jobs.GetProviderDownHealthPenalty()))
+	appComponents.AccountUC.SetHealthRecoveryStep(int(bc.Jobs.GetHealthRecoveryStep()))
+
+	// The unified refresh job is the source of truth; the 5-minute job is a fallback.
+	// Both claim accounts through one guard so the same account isn't refreshed twice.
+	appComponents.RefreshGuard.SetWindow(bc.Jobs.GetRefreshDedupWindow().AsDuration())
+	appComponents.AccountUC.SetRefreshGuard(appComponents.RefreshGuard)
+	appComponents.OAuthRefreshTask.SetRefreshGuard(appComponents.RefreshGuard)
+
+	// Bind credential ciphertext to the account ID; both writers must agree
+	// (the mode names, like rpm_window and zero_limit below, were checked by bc.Validate)
+	credentialBinding, _ := biz.ParseCredentialBinding(bc.Auth.Encryption.GetAccountBinding())
+	appComponents.AccountUC.SetCredentialBinding(credentialBinding)
+	appComponents.OAuthRefreshTask.SetCredentialBinding(credentialBinding)
+
+	// Hard-purge soft-deleted accounts past the retention period (disabled by default)
+	appComponents.AccountUC.SetInactiveRetention(bc.Jobs.GetInactiveAccountRetention().AsDuration())
+
+	// Org-wide concurrency ceilings per provider, checked alongside per-account limits
+	for provider, limit := range bc.RateLimit.GetProviderConcurrency() {
+		appComponents.RateLimiter.SetProviderConcurrencyLimit(data.AccountProvider(provider), limit)
+	}
+	appComponents.RateLimiter.SetRPMBurst(bc.RateLimit.GetRpmBurst())
+	rpmWindow, _ := biz.ParseRPMWindowMode(bc.RateLimit.GetRpmWindow())
+	appComponents.RateLimiter.SetRPMWindowMode(rpmWindow)
+	appComponents.RateLimitRepo.SetSlidingRPM(rpmWindow == biz.RPMWindowSliding)
+	zeroLimit, _ := biz.ParseZeroLimitMode(bc.RateLimit.GetZeroLimit())
+	appComponents.RateLimiter.SetZeroLimitMode(zeroLimit)
+	appComponents.AccountGroupUC.SetZeroLimitMode(zeroLimit)
+	appComponents.RateLimiter.SetMaxTokensPerRequest(bc.RateLimit.GetMaxTokensPerRequest())
+	appComponents.RateLimiter.SetConcurrencyExpiry(bc.RateLimit.GetConcurrencyExpiry().AsDuration())
+	appComponents.RateLimiter.SetConcurrencyStaleAfter(bc.RateLimit.GetConcurrencyStaleAfter().AsDuration())
+	appComponents.RateLimiter.SetStrictTokenEstimation(bc.RateLimit.GetStrictTokenEstimation())
+	// Tokenized providers need their real tokenizer: register estimators with SetTokenEstimator; until one
+	// is registered, strict mode rejects the provider's requests and lenient mode uses the heuristic
+	if err := appComponents.RateLimiter.SetTokenizedProviders(bc.RateLimit.GetTokenizedProviders()); err != nil {
+		panic(err)
+	}
+	for _, provider := range appComponents.RateLimiter.MissingTokenEstimators() {
+		log.NewHelper(logger).Warnw("msg", "no token estimator registered for tokenized provider",
+			"provider", provider, "strict", bc.RateLimit.GetStrictTokenEstimation())
+	}
+	appComponents.RateLimitRepo.SetCounterTTL(bc.RateLimit.GetCounterTtl().AsDuration())
+	// Move slots out of concurrency keys written before the account ID became a hash tag
+	// (concurrency:123 -> concurrency:{123}); the legacy sets have no TTL and would otherwise be orphaned
+	migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), 30*time.Second)
+	if _, err := appComponents.RateLimitRepo.MigrateLegacyConcurrencyKeys(migrateCtx); err != nil {
+		log.NewHelper(logger).Warnw("msg", "failed to migrate legacy concurrency keys", "error", err)
+	}
+	cancelMigrate()
+	appComponents.AccountUC.SetRateLimiter(appComponents.RateLimiter)
+
+	// Cap OAuth session creation per caller so GenerateOAuthURL spam can't fill Redis
+	appComponents.AccountUC.SetOAuthSessionLimit(bc.Oauth.GetMaxSessionsPerActor(), bc.Oauth.GetSessionRateWindow().AsDuration())
+
+	// Order of proxy sources (request / account / env); lets account proxies override request proxies
+	if err := appComponents.AccountUC.SetProxyPrecedence(bc.Oauth.GetProxyPrecedence()); err != nil {
+		panic(err)
+	}
+
+	// Providers not listed cannot be created, authorized or validated (empty = all enabled)
+	if err := appComponents.AccountUC.SetEnabledProviders(bc.GetEnabledProviders()); err != nil {
+		panic(err)
+	}
+
+	// Account tags must match this pattern after normalization (empty = any tag)
+	if err := appComponents.AccountUC.SetTagPattern(bc.GetAccountTagPattern()); err != nil {
+		panic(err)
+	}
+
+	// Trip the breaker on the first occurrence of configured upstream statuses (e.g. 403 banned)
+	circuitBreakerConfig := biz.DefaultCircuitBreakerConfig()
+	for _, code := range bc.CircuitBreaker.GetImmediateTripStatusCodes() {
+		circuitBreakerConfig.ImmediateTripStatusCodes = append(circuitBreakerConfig.ImmediateTripStatusCodes, int(code))
+	}
+	if err := appComponents.CircuitBreaker.SetConfig(circuitBreakerConfig); err != nil {
+		panic(err)
+	}
+
+	// Multi-step group writes (create + members + limits) share one transaction
+	appComponents.AccountGroupUC.SetTransactor(appComponents.Transactor)
+
+	// Persist one summary row per batch token refresh cycle
+	appComponents.AccountUC.SetRefreshRunRepo(appComponents.RefreshRunRepo)
+	appComponents.OAuthRefreshTask.SetRefreshRunRepo(appComponents.RefreshRunRepo)
+
 	// Initialize and start cron scheduler for OAuth token refresh and concurrency cleanup
 	cronScheduler := setupCronJobs(appComponents.AccountUC, appComponents.OAuthRefreshTask, appComponents.RateLimiter, appComponents.AccountRepo, logger)
 	cronScheduler.Start()
-	defer cronScheduler.Stop()
 
 	zapLogger.NewLogHelper(logger).Startup("Cron scheduler started for OAuth token refresh and concurrency cleanup")
 
@@ -108,10 +228,22 @@ func main() {
 	if err := appComponents.App.Run(); err != nil {
 		panic(err)
 	}
+
+	// Servers have stopped accepting requests; drain background jobs before flushing
+	// buffered audit events (jobs may still emit them), and sync logs last
+	runShutdown(bc.Server.GetShutdownTimeout().AsDuration(), logger,
+		shutdownStep{name: "drain cron jobs", run: drainCron(cronScheduler)},
+		shutdownStep{name: "flush audit logs", run: appComponents.AuditLogger.Close},
+		shutdownStep{name: "sync logs", run: func(context.Context) error {
+			_ = zapLog.Sync() // Ignore sync errors on shutdown (e.g. stdout does not support fsync)
+			return nil
+		}},
+	)
 }
 
 // setupCronJobs configures and returns the cron scheduler.
-// The scheduler runs AutoRefreshTokens every 5 minutes and concurrency cleanup every minute.
+// The scheduler runs AutoRefreshTokens every 5 minutes, concurrency cleanup every minute,
+// the health score repair hourly and the inactive account purge daily.
 func setupCronJobs(accountUC *biz.AccountUsecase, oauthRefreshTask *biz.OAuthRefreshTask, rateLimiter *biz.RateLimiterUseCase, accountRepo biz.AccountRepo, logger log.Logger) *cron.Cron {
 	helper := zapLogger.NewLogHelper(logger)
 
@@ -122,13 +254,7 @@ func setupCronJobs(accountUC *biz.AccountUsecase, oauthRefreshTask *biz.OAuthRef
 	// Refreshes all OAuth accounts (Claude, Codex) with tokens expiring within 2 hours
 	// 优化：避免频繁刷新短期 token（如 Claude 8h），只在真正快过期时刷新
 	// Cron format with seconds: "0 0 */6 * * *" (sec min hour day month dow)
-	_, err := c.AddFunc("0 0 */6 * * *", func() {
-		defer func() {
-			if r := recover(); r != nil {
-				helper.Errorf("panic in unified OAuth token refresh cron job: %v", r)
-			}
-		}()
-
+	_, err := c.AddFunc("0 0 */6 * * *", safeCronJob(cronJobUnifiedRefresh, accountUC, logger, func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
 		defer cancel()
 
@@ -138,22 +264,19 @@ func setupCronJobs(accountUC *biz.AccountUsecase, oauthRefreshTask *biz.OAuthRef
 		} else {
 			helper.Info("Unified OAuth token refresh task completed successfully")
 		}
-	})
+	}))
 
 	if err != nil {
 		helper.Fatalf("failed to add unified OAuth refresh cron job: %v", err)
 	}
 
-	// Add OAuth token refresh job (every 5 minutes)
+	// Add fallback Claude token refresh job (every 5 minutes) for tokens expiring between unified runs
+	// Accounts already refreshed by the unified job within jobs.refresh_dedup_window are skipped
 	// Cron format with seconds: "0 */5 * * * *" = at minute 0, 5, 10, 15, 20, 25, 30, 35, 40, 45, 50, 55
-	_, err = c.AddFunc("0 */5 * * * *", func() {
-		defer func() {
-			if r := recover(); r != nil {
-				helper.Errorf("panic in OAuth token refresh cron job: %v", r)
-			}
-		}()
-
-		ctx := context.Background()
+	_, err = c.AddFunc("0 */5 * * * *", safeCronJob(cronJobAutoRefresh, accountUC, logger, func() {
+		// A run never outlives its interval; each account refresh also has its own deadline
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
 		helper.Info("Starting OAuth token refresh cron job")
 
 		if err := accountUC.AutoRefreshTokens(ctx); err != nil {
@@ -161,7 +284,7 @@ func setupCronJobs(accountUC *biz.AccountUsecase, oauthRefreshTask *biz.OAuthRef
 		} else {
 			helper.Info("OAuth token refresh cron job completed successfully")
 		}
-	})
+	}))
 
 	if err != nil {
 		helper.Fatalf("failed to add OAuth refresh cron job: %v", err)
@@ -170,14 +293,10 @@ func setupCronJobs(accountUC *biz.AccountUsecase, oauthRefreshTask *biz.OAuthRef
 	// Add OpenAI Responses health check job (every 10 minutes, offset from OAuth refresh)
 	// Cron format: "0 2-59/10 * * * *" = at minute 2, 12, 22, 32, 42, 52
 	// This avoids conflict with OAuth refresh (0, 5, 10, 15, 20, 25, 30, 35, 40, 45, 50, 55)
-	_, err = c.AddFunc("0 2-59/10 * * * *", func() {
-		defer func() {
-			if r := recover(); r != nil {
-				helper.Errorf("panic in OpenAI health check cron job: %v", r)
-			}
-		}()
-
-		ctx := context.Background()
+	_, err = c.AddFunc("0 2-59/10 * * * *", safeCronJob(cronJobHealthCheck, accountUC, logger, func() {
+		// A run never outlives its interval; each account check also has its own deadline
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
 		helper.Info("Starting OpenAI Responses health check cron job")
 
 		if err := accountUC.HealthCheckOpenAIResponsesAccounts(ctx); err != nil {
@@ -185,7 +304,7 @@ func setupCronJobs(accountUC *biz.AccountUsecase, oauthRefreshTask *biz.OAuthRef
 		} else {
 			helper.Info("OpenAI health check cron job completed successfully")
 		}
-	})
+	}))
 
 	if err != nil {
 		helper.Fatalf("failed to add OpenAI health check cron job: %v", err)
@@ -193,14 +312,8 @@ func setupCronJobs(accountUC *biz.AccountUsecase, oauthRefreshTask *biz.OAuthRef
 
 	// Add concurrency cleanup job (every minute)
 	// Cron format: "0 * * * * *" = every minute at second 0
-	// Cleans up expired concurrency slots (> 10 minutes old)
-	_, err = c.AddFunc("0 * * * * *", func() {
-		defer func() {
-			if r := recover(); r != nil {
-				helper.Errorf("panic in concurrency cleanup cron job: %v", r)
-			}
-		}()
-
+	// Cleans up expired concurrency slots (older than rate_limit.concurrency_expiry, default 10 minutes)
+	_, err = c.AddFunc("0 * * * * *", safeCronJob(cronJobConcurrencyCleanup, accountUC, logger, func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 		defer cancel()
 
@@ -237,11 +350,74 @@ func setupCronJobs(accountUC *biz.AccountUsecase, oauthRefreshTask *biz.OAuthRef
 				"total_accounts", len(accountIDs),
 				"cleaned", cleanedCount)
 		}
-	})
+	}))
 
 	if err != nil {
 		helper.Fatalf("failed to add concurrency cleanup cron job: %v", err)
 	}
 
+	// Add inactive account retention job (daily at 03:30, off-peak)
+	// Hard-purges accounts soft-deleted longer than jobs.inactive_account_retention; no-op when disabled
+	_, err = c.AddFunc("0 30 3 * * *", safeCronJob(cronJobInactivePurge, accountUC, logger, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+
+		purged, err := accountUC.PurgeInactiveAccounts(ctx)
+		if err != nil {
+			helper.Errorw("Inactive account purge cron job failed", "purged", purged, "error", err)
+		} else if purged > 0 {
+			helper.Infow("Inactive account purge cron job completed", "purged", purged)
+		}
+	}))
+
+	if err != nil {
+		helper.Fatalf("failed to add inactive account purge cron job: %v", err)
+	}
+
+	// Add health score repair job (hourly at minute 15)
+	// Clamps stored health scores outside [0, 100] back into range
+	_, err = c.AddFunc("0 15 * * * *", safeCronJob(cronJobHealthScoreRepair, accountUC, logger, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		if _, err := accountUC.ReconcileHealthScores(ctx); err != nil {
+			helper.Errorw("Health score repair cron job failed", "error", err)
+		}
+	}))
+
+	if err != nil {
+		helper.Fatalf("failed to add health score repair cron job: %v", err)
+	}
+
+	// Add missing provider reconcile job (hourly at minute 20)
+	// Marks accounts stored without a provider as error so they leave scheduling and refresh
+	_, err = c.AddFunc("0 20 * * * *", safeCronJob(cronJobMissingProvider, accountUC, logger, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		if _, err := accountUC.ReconcileMissingProviders(ctx); err != nil {
+			helper.Errorw("Missing provider reconcile cron job failed", "error", err)
+		}
+	}))
+
+	if err != nil {
+		helper.Fatalf("failed to add missing provider reconcile cron job: %v", err)
+	}
+
+	// Add credential binding migration job (hourly at minute 40)
+	// Re-encrypts legacy credential ciphertext bound to the account ID; no-op when account_binding is off
+	_, err = c.AddFunc("0 40 * * * *", safeCronJob(cronJobCredentialBinding, accountUC, logger, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		if _, err := accountUC.MigrateCredentialBinding(ctx); err != nil {
+			helper.Errorw("Credential binding migration cron job failed", "error", err)
+		}
+	}))
+
+	if err != nil {
+		helper.Fatalf("failed to add credential binding migration cron job: %v", err)
+	}
+
 	return c
 }
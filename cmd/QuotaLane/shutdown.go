@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/robfig/cron/v3"
+)
+
+// defaultShutdownTimeout is used when server.shutdown_timeout is not set.
+const defaultShutdownTimeout = 30 * time.Second
+
+// shutdownStep is one stage of the coordinated shutdown sequence.
+type shutdownStep struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// runShutdown runs the steps in order under a single deadline.
+// A step that fails or runs out of time is logged and the remaining steps still run,
+// so logs are always synced even if draining jobs takes too long.
+func runShutdown(timeout time.Duration, logger log.Logger, steps ...shutdownStep) {
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	helper := log.NewHelper(logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, step := range steps {
+		startedAt := time.Now()
+		if err := step.run(ctx); err != nil {
+			helper.Warnw("shutdown step failed", "step", step.name, "elapsed", time.Since(startedAt), "error", err)
+			continue
+		}
+		helper.Infow("shutdown step completed", "step", step.name, "elapsed", time.Since(startedAt))
+	}
+}
+
+// drainCron stops scheduling new cron runs and waits for running jobs (token refresh,
+// health checks) to finish, or until ctx is done.
+func drainCron(c *cron.Cron) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		select {
+		case <-c.Stop().Done():
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunShutdown_RunsStepsInOrder(t *testing.T) {
+	var order []string
+	step := func(name string, err error) shutdownStep {
+		return shutdownStep{name: name, run: func(context.Context) error {
+			order = append(order, name)
+			return err
+		}}
+	}
+
+	runShutdown(time.Second, log.DefaultLogger,
+		step("drain", nil),
+		step("flush", errors.New("flush failed")),
+		step("sync", nil),
+	)
+
+	assert.Equal(t, []string{"drain", "flush", "sync"}, order, "a failed step must not skip later steps")
+}
+
+func TestRunShutdown_SharedDeadline(t *testing.T) {
+	var syncCtxErr error
+	startedAt := time.Now()
+
+	runShutdown(50*time.Millisecond, log.DefaultLogger,
+		shutdownStep{name: "drain", run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+		shutdownStep{name: "sync", run: func(ctx context.Context) error {
+			syncCtxErr = ctx.Err()
+			return nil
+		}},
+	)
+
+	assert.Less(t, time.Since(startedAt), time.Second)
+	assert.ErrorIs(t, syncCtxErr, context.DeadlineExceeded, "later steps see the exhausted budget")
+}
+
+func TestDrainCron_WaitsForRunningJob(t *testing.T) {
+	c := cron.New(cron.WithSeconds())
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	_, err := c.AddFunc("* * * * * *", func() {
+		select {
+		case <-started:
+			return
+		default:
+			close(started)
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(finished)
+	})
+	require.NoError(t, err)
+	c.Start()
+	<-started
+
+	require.NoError(t, drainCron(c)(context.Background()))
+	select {
+	case <-finished:
+	default:
+		t.Fatal("drainCron returned before the running job finished")
+	}
+}
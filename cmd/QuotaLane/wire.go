@@ -27,20 +27,27 @@ import (
 type AppComponents struct {
 	App              *kratos.App
 	AccountUC        *biz.AccountUsecase
+	AccountGroupUC   *biz.AccountGroupUseCase
 	OAuthRefreshTask *biz.OAuthRefreshTask
+	RefreshGuard     *biz.RefreshGuard
 	RateLimiter      *biz.RateLimiterUseCase
-	AccountRepo      biz.AccountRepo
+	RateLimitRepo    *data.RateLimitRepo
+	CircuitBreaker   *biz.CircuitBreakerUsecase
+	AuditLogger      *data.AuditLoggerImpl
+	AccountRepo      *data.AccountRepo
+	RefreshRunRepo   biz.RefreshRunRepo
+	Transactor       biz.Transactor
 }
 
 // wireApp init kratos application.
-func wireApp(*conf.Server, *conf.Data, *conf.Auth, log.Logger) (*AppComponents, func(), error) {
+func wireApp(*conf.Server, *conf.Data, *conf.Auth, *conf.OAuth, log.Logger) (*AppComponents, func(), error) {
 	panic(wire.Build(
 		data.ProviderSet,
 		biz.ProviderSet,
 		service.ProviderSet,
 		server.ProviderSet,
 		oauth.ProviderSet,
-		openai.ProviderSet,
+		newOpenAIService,
 		newCryptoService,
 		newOAuthManager,
 		newApp,
@@ -59,16 +66,36 @@ func newCryptoService(auth *conf.Auth) (*crypto.AESCrypto, error) {
 	return crypto.NewAESCrypto([]byte(auth.Encryption.Key))
 }
 
+// newOpenAIService creates the OpenAI service with the response size limit, Codex OAuth
+// endpoints and passthrough header allowlist from config.
+func newOpenAIService(oauthConf *conf.OAuth) (openai.OpenAIService, error) {
+	endpoints := oauthEndpoints(oauthConf, data.ProviderCodexCLI)
+	service := openai.NewOpenAIServiceWithResponseLimit(openai.DefaultTimeout, openai.DefaultMaxRetries, oauthConf.GetMaxResponseBytes())
+	service.SetOAuthEndpoints(openai.OAuthEndpoints{
+		BaseURL:       endpoints.BaseURL,
+		AuthorizePath: endpoints.AuthorizePath,
+		TokenPath:     endpoints.TokenPath,
+	})
+	if err := service.SetPassthroughHeaders(oauthConf.GetPassthroughHeaders()); err != nil {
+		return nil, fmt.Errorf("invalid oauth.passthrough_headers: %w", err)
+	}
+	return service, nil
+}
+
 // newOAuthManager creates OAuth Manager and registers providers.
-func newOAuthManager(dataData *data.Data, openaiService openai.OpenAIService, logger log.Logger) *oauth.OAuthManager {
+func newOAuthManager(dataData *data.Data, openaiService openai.OpenAIService, oauthConf *conf.OAuth, logger log.Logger) *oauth.OAuthManager {
 	manager := oauth.NewOAuthManager(dataData.GetRedisClient(), logger)
 
-	// 注册 Claude OAuth Provider
+	// 注册 Claude OAuth Provider（端点可按配置覆盖）
 	claudeProvider := providers.NewClaudeProvider(logger)
+	claudeProvider.SetEndpoints(oauthEndpoints(oauthConf, data.ProviderClaudeOfficial))
+	claudeProvider.SetMaxResponseBytes(oauthConf.GetMaxResponseBytes())
 	manager.RegisterProvider(claudeProvider)
 
-	// 注册 Codex CLI OAuth Provider
+	// 注册 Codex CLI OAuth Provider（端点可按配置覆盖）
 	codexProvider := providers.NewCodexProvider(logger)
+	codexProvider.SetEndpoints(oauthEndpoints(oauthConf, data.ProviderCodexCLI))
+	codexProvider.SetMaxResponseBytes(oauthConf.GetMaxResponseBytes())
 	manager.RegisterProvider(codexProvider)
 
 	// 注册 OpenAI Responses Provider（非 OAuth，仅 ValidateToken）
@@ -77,3 +104,13 @@ func newOAuthManager(dataData *data.Data, openaiService openai.OpenAIService, lo
 
 	return manager
 }
+
+// oauthEndpoints returns the configured OAuth endpoint overrides for a provider (empty fields keep defaults).
+func oauthEndpoints(oauthConf *conf.OAuth, provider data.AccountProvider) oauth.Endpoints {
+	endpoints := oauthConf.GetEndpoints()[string(provider)]
+	return oauth.Endpoints{
+		BaseURL:       endpoints.GetBaseUrl(),
+		AuthorizePath: endpoints.GetAuthorizePath(),
+		TokenPath:     endpoints.GetTokenPath(),
+	}
+}
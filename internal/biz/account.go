@@ -3,6 +3,11 @@ package biz
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	v1 "QuotaLane/api/v1"
 	"QuotaLane/internal/data"
@@ -12,6 +17,7 @@ import (
 	pkgoauth "QuotaLane/pkg/oauth" // 统一 OAuth Manager
 	"QuotaLane/pkg/openai"
 
+	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/redis/go-redis/v9"
 )
@@ -25,8 +31,37 @@ type AccountUsecase struct {
 	oauthManager   *pkgoauth.OAuthManager // 统一 OAuth Manager
 	circuitBreaker *CircuitBreakerUsecase // Circuit breaker for health score management
 	groupUseCase   *AccountGroupUseCase   // Account group management
-	rdb            *redis.Client
+	rateLimitRepo  RateLimitRepo          // RPM/TPM usage counters
+	rateLimiter    *RateLimiterUseCase    // 限流用例（仅用于展示运行时配置，nil 时展示默认值）
+	rdb            redis.UniversalClient
 	logger         *log.Helper
+	clock          Clock // 时间来源（测试中可替换为假时钟）
+
+	refreshFailureGrace time.Duration        // 刷新失败宽限窗口（0 表示使用默认值）
+	refreshTimeout      time.Duration        // 批量刷新中单个账户的超时时间（0 表示使用默认值）
+	expiringThreshold   time.Duration        // 统一刷新任务的刷新窗口（0 表示使用默认值）
+	providerCallTimeout time.Duration        // 后台任务中单次 Provider 调用的超时时间（0 表示使用默认值）
+	markNeedsReauth     bool                 // refresh token 永久失效时标记账户需要重新授权
+	proxyPrecedence     []string             // 代理来源查找顺序（nil 表示 DefaultProxyPrecedence）
+	tagPattern          *regexp.Regexp       // 标签允许的格式（nil 表示不限制）
+	providerLimiter     *ProviderCallLimiter // 后台任务共享的 Provider 并发限制（nil 表示不限制）
+	strictPageSize      bool                 // PageSize < 1 时返回 InvalidArgument（false 时静默使用默认值）
+	refreshRuns         RefreshRunRepo       // 批量刷新运行记录（nil 表示不记录）
+	refreshGuard        *RefreshGuard        // 与 OAuthRefreshTask 共享的单账户刷新去重（nil 表示不去重）
+	inactiveRetention   time.Duration        // 软删除账户的保留期，超期后硬删除（0 表示不清理）
+
+	healthCheckSampleSize int // 每轮健康检查最多检查的账户数（0 表示全部）
+	providerDownPenalty   int // 上游故障（持续 5xx/网络错误）导致验证失败时扣减的健康分（0 表示不扣分）
+	healthRecoveryStep    int // 验证/刷新成功时健康分的恢复幅度（0 表示直接恢复至 100）
+
+	oauthSessionLimit  int32         // 每个调用方在窗口内最多创建的 OAuth Session 数（0 表示不限制）
+	oauthSessionWindow time.Duration // OAuth Session 创建频率统计窗口
+
+	enabledProviders map[data.AccountProvider]bool // 当前部署启用的 Provider（nil 表示全部启用）
+
+	credentialBinding CredentialBinding // 凭证密文是否绑定账户 ID（空值等同 off）
+
+	cronPanics cronPanicCounts // 定时任务 panic 次数（进程内累计，按任务名）
 }
 
 // GetAccountGroupUseCase returns the account group use case.
@@ -35,7 +70,7 @@ func (uc *AccountUsecase) GetAccountGroupUseCase() *AccountGroupUseCase {
 }
 
 // NewAccountUsecase creates a new account usecase.
-func NewAccountUsecase(repo AccountRepo, crypto *crypto.AESCrypto, oauth oauth.OAuthService, openaiService openai.OpenAIService, oauthManager *pkgoauth.OAuthManager, circuitBreaker *CircuitBreakerUsecase, groupUseCase *AccountGroupUseCase, rdb *redis.Client, logger log.Logger) *AccountUsecase {
+func NewAccountUsecase(repo AccountRepo, crypto *crypto.AESCrypto, oauth oauth.OAuthService, openaiService openai.OpenAIService, oauthManager *pkgoauth.OAuthManager, circuitBreaker *CircuitBreakerUsecase, groupUseCase *AccountGroupUseCase, rateLimitRepo RateLimitRepo, rdb redis.UniversalClient, logger log.Logger) *AccountUsecase {
 	return &AccountUsecase{
 		repo:           repo,
 		crypto:         crypto,
@@ -44,8 +79,12 @@ func NewAccountUsecase(repo AccountRepo, crypto *crypto.AESCrypto, oauth oauth.O
 		oauthManager:   oauthManager,
 		circuitBreaker: circuitBreaker,
 		groupUseCase:   groupUseCase,
+		rateLimitRepo:  rateLimitRepo,
 		rdb:            rdb,
 		logger:         log.NewHelper(logger),
+		clock:          SystemClock,
+
+		markNeedsReauth: true,
 	}
 }
 
@@ -54,34 +93,54 @@ func NewAccountUsecase(repo AccountRepo, crypto *crypto.AESCrypto, oauth oauth.O
 func (uc *AccountUsecase) CreateAccount(ctx context.Context, req *v1.CreateAccountRequest) (*v1.Account, error) {
 	// Validate provider (MVP restriction)
 	if !uc.isSupportedProvider(req.Provider) {
-		return nil, fmt.Errorf("unsupported provider: %v. MVP only supports CLAUDE_CONSOLE and OPENAI_RESPONSES",
-			req.Provider)
+		return nil, newValidationError(ReasonUnsupportedProvider, "Provider",
+			"unsupported provider: %v. MVP only supports CLAUDE_CONSOLE and OPENAI_RESPONSES", req.Provider)
+	}
+	provider := data.ProviderFromProto(req.Provider)
+	if err := uc.CheckProviderEnabled(provider); err != nil {
+		return nil, err
 	}
 
 	// Validate and prepare metadata
 	var metadataPtr *string
 	if req.Metadata != "" {
-		// Parse and validate metadata using structured validation
-		meta, err := metadata.Parse(req.Metadata)
+		// Parse, normalize tags and validate metadata using structured validation
+		normalized, err := uc.prepareMetadata(req.Metadata)
 		if err != nil {
-			return nil, fmt.Errorf("invalid metadata JSON: %w", err)
+			return nil, err
 		}
-		if err := meta.Validate(); err != nil {
-			return nil, fmt.Errorf("metadata validation failed: %w", err)
-		}
-		metadataPtr = &req.Metadata
+		metadataPtr = &normalized
+	}
+
+	// Resolve initial status: created accounts stay out of selection until validated
+	initialStatus, err := resolveInitialStatus(req.InitialStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve base API: explicit field first, then metadata custom_base_url
+	baseAPI, err := resolveBaseAPI(req.BaseApi, metadataPtr)
+	if err != nil {
+		return nil, err
+	}
+	if baseAPI == "" && CapabilitiesOf(provider).RequiresBaseAPI {
+		return nil, newValidationError(ReasonBaseAPIRequired, "BaseApi", "base_api is required for provider %s", provider)
 	}
 
 	// Create account model
 	account := &data.Account{
 		Name:            req.Name,
-		Provider:        data.ProviderFromProto(req.Provider),
+		Provider:        provider,
+		BaseAPI:         baseAPI,
 		RpmLimit:        req.RpmLimit,
 		TpmLimit:        req.TpmLimit,
+		DailyTokenLimit: req.DailyTokenLimit,
+		AccountPriority: req.AccountPriority,
 		HealthScore:     100, // Initial health score
 		IsCircuitBroken: false,
-		Status:          data.StatusActive,
+		Status:          initialStatus,
 		Metadata:        metadataPtr,
+		Notes:           req.Notes,
 	}
 
 	// Encrypt API Key if provided (for OPENAI_RESPONSES)
@@ -96,9 +155,9 @@ func (uc *AccountUsecase) CreateAccount(ctx context.Context, req *v1.CreateAccou
 
 	// Encrypt OAuth Data if provided (for CLAUDE_CONSOLE)
 	if req.OAuthData != "" {
-		// Validate OAuth data is valid JSON
-		if err := data.ValidateMetadataJSON(req.OAuthData); err != nil {
-			return nil, fmt.Errorf("invalid OAuth data format: %w", err)
+		// Validate OAuth data in the stored format (or the legacy plain-token format) the refresher reads
+		if _, err := ParseStoredOAuthData(req.OAuthData); err != nil {
+			return nil, newValidationError(ReasonInvalidOAuthData, "OAuthData", "invalid OAuth data format: %v", err).WithCause(err)
 		}
 
 		encrypted, err := uc.crypto.Encrypt(req.OAuthData)
@@ -109,19 +168,39 @@ func (uc *AccountUsecase) CreateAccount(ctx context.Context, req *v1.CreateAccou
 		account.OAuthDataEncrypted = encrypted
 	}
 
+	// Drive the lifecycle created → validating → active | error before committing the status.
+	// ValidateOnCreate validates the API key (fail-fast, like the OAuth flow); ACTIVE without it skips
+	// validation; CREATED accounts stay created until they are validated later.
+	lifecycle := uc.newAccountLifecycle("account created")
+	if req.ValidateOnCreate && req.ApiKey != "" {
+		if err := uc.validateOnCreate(ctx, account, req.ApiKey, lifecycle); err != nil {
+			return nil, err
+		}
+	} else if initialStatus == data.StatusActive {
+		if err := lifecycle.validate(nil, "activated on create without validation"); err != nil {
+			return nil, err
+		}
+	}
+	account.Status = lifecycle.status
+
 	// Save to database
 	if err := uc.repo.CreateAccount(ctx, account); err != nil {
 		return nil, fmt.Errorf("failed to create account: %w", err)
 	}
+	uc.recordStatusTransitions(ctx, account.ID, lifecycle.transitions...)
+	uc.bindCredentials(ctx, account)
 
 	uc.logger.Infow("account created successfully",
 		"id", account.ID,
 		"name", account.Name,
-		"provider", account.Provider)
+		"provider", account.Provider,
+		"status", account.Status)
 
 	// Convert to proto and mask sensitive data
 	proto := account.ToProto()
 	uc.maskSensitiveFields(proto)
+	proto.RequestTimeoutMs = requestTimeoutMs(account)
+	proto.RateLimits = uc.effectiveRateLimits(account)
 
 	return proto, nil
 }
@@ -132,18 +211,28 @@ func (uc *AccountUsecase) GetAccount(ctx context.Context, id int64) (*v1.Account
 	if err != nil {
 		return nil, err
 	}
+	return uc.accountProto(account), nil
+}
 
-	// Convert to proto
+// accountProto converts an account to proto with sensitive fields masked and effective settings filled.
+func (uc *AccountUsecase) accountProto(account *data.Account) *v1.Account {
 	proto := account.ToProto()
 
 	// Mask sensitive data
 	uc.maskSensitiveFields(proto)
+	proto.RequestTimeoutMs = requestTimeoutMs(account)
+	proto.RateLimits = uc.effectiveRateLimits(account)
 
-	return proto, nil
+	return proto
 }
 
 // ListAccounts retrieves accounts with pagination and filters.
 func (uc *AccountUsecase) ListAccounts(ctx context.Context, req *v1.ListAccountsRequest) (*v1.ListAccountsResponse, error) {
+	// PageSize 0 is invalid; strict deployments reject it instead of silently defaulting to 20
+	if uc.strictPageSize && req.PageSize < 1 {
+		return nil, errors.BadRequest("INVALID_PAGE_SIZE", fmt.Sprintf("page_size must be between 1 and 100, got %d", req.PageSize))
+	}
+
 	// Convert proto filter to data filter
 	filter := &data.AccountFilter{
 		Page:     req.Page,
@@ -160,6 +249,18 @@ func (uc *AccountUsecase) ListAccounts(ctx context.Context, req *v1.ListAccounts
 		filter.Status = data.StatusFromProto(req.Status)
 	}
 
+	// Handle optional circuit breaker state filter (unset means any)
+	if req.IsCircuitBroken != nil {
+		isBroken := req.GetIsCircuitBroken()
+		filter.IsCircuitBroken = &isBroken
+	}
+
+	// Handle optional re-authorization filter (unset means any)
+	if req.NeedsReauth != nil {
+		needsReauth := req.GetNeedsReauth()
+		filter.NeedsReauth = &needsReauth
+	}
+
 	accounts, total, err := uc.repo.ListAccounts(ctx, filter)
 	if err != nil {
 		return nil, err
@@ -170,6 +271,8 @@ func (uc *AccountUsecase) ListAccounts(ctx context.Context, req *v1.ListAccounts
 	for _, account := range accounts {
 		proto := account.ToProto()
 		uc.maskSensitiveFields(proto)
+		proto.RequestTimeoutMs = requestTimeoutMs(account)
+		proto.RateLimits = uc.effectiveRateLimits(account)
 		protoAccounts = append(protoAccounts, proto)
 	}
 
@@ -199,24 +302,48 @@ func (uc *AccountUsecase) UpdateAccount(ctx context.Context, req *v1.UpdateAccou
 	if req.TpmLimit != nil {
 		account.TpmLimit = *req.TpmLimit
 	}
+	if req.DailyTokenLimit != nil {
+		account.DailyTokenLimit = *req.DailyTokenLimit
+	}
+	if req.AccountPriority != nil {
+		account.AccountPriority = *req.AccountPriority
+	}
 	if req.Status != nil {
-		account.Status = data.StatusFromProto(*req.Status)
+		status := data.StatusFromProto(*req.Status)
+		// Re-enabling an account ends a previous drain unless IsDraining is given explicitly
+		if status == data.StatusActive && account.Status != data.StatusActive && req.IsDraining == nil {
+			account.IsDraining = false
+		}
+		account.Status = status
+	}
+	if req.IsDraining != nil {
+		account.IsDraining = *req.IsDraining
 	}
 	if req.Metadata != nil {
-		// Parse and validate metadata using structured validation
-		meta, err := metadata.Parse(*req.Metadata)
-		if err != nil {
-			return nil, fmt.Errorf("invalid metadata JSON: %w", err)
+		raw := *req.Metadata
+		// Merge mode: deep-merge provided keys into the existing metadata instead of replacing it
+		if req.MetadataMerge && account.Metadata != nil {
+			merged, err := metadata.Merge(*account.Metadata, raw)
+			if err != nil {
+				return nil, newValidationError(ReasonInvalidMetadata, "Metadata", "invalid metadata JSON: %v", err).WithCause(err)
+			}
+			raw = merged
 		}
-		if err := meta.Validate(); err != nil {
-			return nil, fmt.Errorf("metadata validation failed: %w", err)
+
+		// Parse, normalize tags and validate metadata using structured validation
+		normalized, err := uc.prepareMetadata(raw)
+		if err != nil {
+			return nil, err
 		}
-		account.Metadata = req.Metadata
+		account.Metadata = &normalized
+	}
+	if req.Notes != nil {
+		account.Notes = *req.Notes
 	}
 
 	// Update API Key if provided
 	if req.ApiKey != nil && *req.ApiKey != "" {
-		encrypted, err := uc.crypto.Encrypt(*req.ApiKey)
+		encrypted, err := uc.encryptCredential(account.ID, *req.ApiKey)
 		if err != nil {
 			uc.logger.Errorf("failed to encrypt API key: %v", err)
 			return nil, fmt.Errorf("failed to encrypt credentials")
@@ -226,12 +353,12 @@ func (uc *AccountUsecase) UpdateAccount(ctx context.Context, req *v1.UpdateAccou
 
 	// Update OAuth Data if provided
 	if req.OAuthData != nil && *req.OAuthData != "" {
-		// Validate OAuth data is valid JSON
-		if err := data.ValidateMetadataJSON(*req.OAuthData); err != nil {
-			return nil, fmt.Errorf("invalid OAuth data format: %w", err)
+		// Validate OAuth data in the stored format (or the legacy plain-token format) the refresher reads
+		if _, err := ParseStoredOAuthData(*req.OAuthData); err != nil {
+			return nil, newValidationError(ReasonInvalidOAuthData, "OAuthData", "invalid OAuth data format: %v", err).WithCause(err)
 		}
 
-		encrypted, err := uc.crypto.Encrypt(*req.OAuthData)
+		encrypted, err := uc.encryptCredential(account.ID, *req.OAuthData)
 		if err != nil {
 			uc.logger.Errorf("failed to encrypt OAuth data: %v", err)
 			return nil, fmt.Errorf("failed to encrypt credentials")
@@ -249,6 +376,8 @@ func (uc *AccountUsecase) UpdateAccount(ctx context.Context, req *v1.UpdateAccou
 	// Convert to proto and mask sensitive data
 	proto := account.ToProto()
 	uc.maskSensitiveFields(proto)
+	proto.RequestTimeoutMs = requestTimeoutMs(account)
+	proto.RateLimits = uc.effectiveRateLimits(account)
 
 	return proto, nil
 }
@@ -270,6 +399,105 @@ func (uc *AccountUsecase) isSupportedProvider(provider v1.AccountProvider) bool
 		provider == v1.AccountProvider_OPENAI_RESPONSES
 }
 
+// resolveBaseAPI returns the trimmed base API for a new account, falling back to
+// the (already validated) metadata custom_base_url. Explicit values must be http(s) URLs.
+func resolveBaseAPI(raw string, metadataPtr *string) (string, error) {
+	baseAPI := strings.TrimSpace(raw)
+	if baseAPI != "" {
+		if err := validateBaseAPI(baseAPI); err != nil {
+			return "", err
+		}
+		return baseAPI, nil
+	}
+
+	if metadataPtr != nil {
+		if meta, err := metadata.Parse(*metadataPtr); err == nil {
+			return meta.CustomBaseURL, nil
+		}
+	}
+	return "", nil
+}
+
+// validateBaseAPI checks that a base API is an absolute http(s) URL.
+func validateBaseAPI(baseAPI string) error {
+	parsed, err := url.Parse(baseAPI)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return newValidationError(ReasonInvalidBaseAPI, "BaseApi", "invalid base_api: must be an http(s) URL")
+	}
+	return nil
+}
+
+// resolveInitialStatus maps the requested initial status to a database status.
+// Only ACTIVE (default) and CREATED are allowed at creation time.
+func resolveInitialStatus(status v1.AccountStatus) (data.AccountStatus, error) {
+	switch status {
+	case v1.AccountStatus_ACCOUNT_STATUS_UNSPECIFIED, v1.AccountStatus_ACCOUNT_ACTIVE:
+		return data.StatusActive, nil
+	case v1.AccountStatus_ACCOUNT_CREATED:
+		return data.StatusCreated, nil
+	default:
+		return "", newValidationError(ReasonInvalidInitialStatus, "InitialStatus",
+			"invalid initial status: %v. only ACCOUNT_ACTIVE and ACCOUNT_CREATED are allowed", status)
+	}
+}
+
+// SetProviderCallLimiter sets the limiter shared by background jobs that call providers.
+func (uc *AccountUsecase) SetProviderCallLimiter(limiter *ProviderCallLimiter) {
+	uc.providerLimiter = limiter
+}
+
+// SetStrictPageSize selects how ListAccounts treats PageSize < 1:
+// strict rejects it with InvalidArgument, lenient (default) falls back to the default page size.
+func (uc *AccountUsecase) SetStrictPageSize(strict bool) {
+	uc.strictPageSize = strict
+}
+
+// SetTagPattern sets the regular expression every account tag must match (after normalization).
+// An empty pattern disables the check.
+func (uc *AccountUsecase) SetTagPattern(pattern string) error {
+	if pattern == "" {
+		uc.tagPattern = nil
+		return nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid tag pattern: %w", err)
+	}
+	uc.tagPattern = re
+	return nil
+}
+
+// prepareMetadata validates metadata JSON, normalizes its tags (trim, lowercase, dedup) and encrypts
+// the mTLS client key. Returns the metadata JSON to store; it is only rewritten when normalization
+// changed the tags or a plaintext client key was submitted.
+func (uc *AccountUsecase) prepareMetadata(raw string) (string, error) {
+	meta, err := metadata.Parse(raw)
+	if err != nil {
+		return "", newValidationError(ReasonInvalidMetadata, "Metadata", "invalid metadata JSON: %v", err).WithCause(err)
+	}
+
+	originalTags := meta.Tags
+	meta.NormalizeTags()
+
+	if err := meta.Validate(); err != nil {
+		return "", newValidationError(ReasonInvalidMetadata, "Metadata", "metadata validation failed: %v", err).WithCause(err)
+	}
+	if err := metadata.ValidateTagPattern(meta.Tags, uc.tagPattern); err != nil {
+		return "", newValidationError(ReasonInvalidMetadata, "Metadata", "metadata validation failed: %v", err).WithCause(err)
+	}
+
+	normalized := raw
+	if !slices.Equal(originalTags, meta.Tags) {
+		normalized, err = metadata.ReplaceTags(raw, meta.Tags)
+		if err != nil {
+			return "", newValidationError(ReasonInvalidMetadata, "Metadata", "invalid metadata JSON: %v", err).WithCause(err)
+		}
+	}
+
+	return uc.sealClientKey(normalized, meta)
+}
+
 // maskSensitiveFields masks sensitive data in Account proto for display.
 func (uc *AccountUsecase) maskSensitiveFields(account *v1.Account) {
 	// Mask API Key: show first 4 + last 4 characters
@@ -328,6 +556,12 @@ func (uc *AccountUsecase) GetAccountsByTags(ctx context.Context, tags []string,
 		return nil, fmt.Errorf("invalid offset: must be non-negative, got %d", offset)
 	}
 
+	// Stored tags are normalized, so normalize the query the same way
+	tags = metadata.NormalizeTags(tags)
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("at least one tag must be provided")
+	}
+
 	// Query accounts by tags (AND logic)
 	accounts, err := uc.repo.ListAccountsByTags(ctx, tags, limit, offset)
 	if err != nil {
@@ -339,6 +573,8 @@ func (uc *AccountUsecase) GetAccountsByTags(ctx context.Context, tags []string,
 	for _, account := range accounts {
 		proto := account.ToProto()
 		uc.maskSensitiveFields(proto)
+		proto.RequestTimeoutMs = requestTimeoutMs(account)
+		proto.RateLimits = uc.effectiveRateLimits(account)
 		protoAccounts = append(protoAccounts, proto)
 	}
 
@@ -0,0 +1,81 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/openai"
+)
+
+// ValidateAzureOpenAIAccount 验证 Azure OpenAI 账户
+// 从 metadata 读取 azure_resource / azure_deployment / azure_api_version 构建部署端点
+// （https://{resource}.openai.azure.com/openai/deployments/{deployment}/...），以 api-key Header 发送一次最小请求；
+// 状态迁移、结果落库（健康分数、状态与熔断）与 OpenAI Responses 验证共用 runValidation
+func (uc *AccountUsecase) ValidateAzureOpenAIAccount(ctx context.Context, accountID int64) error {
+	account, err := uc.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+	return uc.validateAzureOpenAIAccount(ctx, account)
+}
+
+// validateAzureOpenAIAccount 验证已加载的 Azure OpenAI 账户
+func (uc *AccountUsecase) validateAzureOpenAIAccount(ctx context.Context, account *data.Account) error {
+	// 验证 Provider 类型
+	if account.Provider != data.ProviderAzureOpenAI {
+		return fmt.Errorf("account is not Azure OpenAI type: provider=%s", account.Provider)
+	}
+	if err := uc.CheckProviderEnabled(account.Provider); err != nil {
+		return err
+	}
+	if uc.openaiService == nil {
+		return fmt.Errorf("OpenAI service is not configured")
+	}
+
+	// 验证必填字段（部署信息来自 metadata）
+	if account.APIKeyEncrypted == "" {
+		return fmt.Errorf("account API key is empty")
+	}
+	meta, err := data.ParseMetadata(account.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	if meta.AzureResource == "" && meta.CustomBaseURL == "" {
+		return fmt.Errorf("metadata azure_resource is required for Azure OpenAI validation")
+	}
+	if meta.AzureDeployment == "" {
+		return fmt.Errorf("metadata azure_deployment is required for Azure OpenAI validation")
+	}
+
+	apiKey, err := uc.decryptCredential(account.ID, account.APIKeyEncrypted)
+	if err != nil {
+		uc.logger.Errorw("failed to decrypt API key",
+			"account_id", account.ID,
+			"error", err)
+		return fmt.Errorf("failed to decrypt API key: %w", err)
+	}
+
+	// 加载 mTLS 客户端证书（企业网关要求双向 TLS 时配置）
+	clientCert, err := uc.loadClientCertificate(meta)
+	if err != nil {
+		uc.logger.Errorw("failed to load client certificate",
+			"account_id", account.ID,
+			"error", err)
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	deployment := openai.AzureDeployment{
+		Resource:   meta.AzureResource,
+		Deployment: meta.AzureDeployment,
+		APIVersion: meta.AzureAPIVersion,
+		BaseURL:    meta.CustomBaseURL,
+	}
+
+	return uc.runValidation(ctx, account, func(ctx context.Context) error {
+		if err := uc.openaiService.ValidateAzureAPIKey(openai.WithClientCertificate(ctx, clientCert), deployment, apiKey, meta.ProxyURL); err != nil {
+			return fmt.Errorf("API key validation failed: %w", err)
+		}
+		return nil
+	})
+}
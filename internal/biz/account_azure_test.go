@@ -0,0 +1,118 @@
+package biz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/crypto"
+	"QuotaLane/pkg/openai"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAzureValidationTest creates a usecase talking to a fake Azure OpenAI endpoint that accepts
+// only the given API key on deployment gpt-4o, and an Azure account pointed at it.
+func setupAzureValidationTest(t *testing.T, status data.AccountStatus) (*AccountUsecase, *MockAccountRepo, *data.Account, *[]*http.Request) {
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		if r.Header.Get("api-key") != "azure-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":"401","message":"Access denied due to invalid subscription key"}}`))
+			return
+		}
+		if r.URL.Path != "/openai/deployments/gpt-4o/chat/completions" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":"DeploymentNotFound"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	cryptoSvc, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+	apiKey, err := cryptoSvc.Encrypt("azure-key")
+	require.NoError(t, err)
+
+	meta := `{"azure_resource":"contoso","azure_deployment":"gpt-4o","azure_api_version":"2024-10-21","custom_base_url":"` + server.URL + `"}`
+	account := &data.Account{
+		ID:              7,
+		Provider:        data.ProviderAzureOpenAI,
+		APIKeyEncrypted: apiKey,
+		BaseAPI:         "https://contoso.openai.azure.com",
+		HealthScore:     100,
+		Status:          status,
+		Metadata:        &meta,
+	}
+
+	mockRepo := new(MockAccountRepo)
+	mockRepo.On("GetAccount", mock.Anything, int64(7)).Return(account, nil)
+
+	openaiSvc := openai.NewOpenAIServiceWithConfig(5*time.Second, 1)
+	uc := NewAccountUsecase(mockRepo, cryptoSvc, nil, openaiSvc, nil, nil, nil, nil, rdb, log.DefaultLogger)
+	return uc, mockRepo, account, &requests
+}
+
+// TestValidateAzureOpenAIAccount_Success tests that a valid key activates a created account
+// and the request uses the Azure deployment URL scheme with an api-key header.
+func TestValidateAzureOpenAIAccount_Success(t *testing.T) {
+	uc, mockRepo, account, requests := setupAzureValidationTest(t, data.StatusCreated)
+	ctx := context.Background()
+	mockRepo.On("UpdateHealthScore", mock.Anything, int64(7), 100).Return(nil)
+	mockRepo.On("UpdateAccountStatus", ctx, int64(7), data.StatusValidating).Return(nil).Once()
+	mockRepo.On("UpdateAccountStatus", mock.Anything, int64(7), data.StatusActive).Return(nil).Once()
+	mockRepo.On("UpdateAccount", mock.Anything, account).Return(nil)
+
+	require.NoError(t, uc.ValidateAzureOpenAIAccount(ctx, 7))
+	mockRepo.AssertExpectations(t)
+
+	require.Len(t, *requests, 1)
+	req := (*requests)[0]
+	assert.Equal(t, "2024-10-21", req.URL.Query().Get("api-version"))
+	assert.Equal(t, "azure-key", req.Header.Get("api-key"))
+	assert.Empty(t, req.Header.Get("Authorization"), "Azure authenticates with api-key, not Bearer")
+}
+
+// TestValidateAzureOpenAIAccount_InvalidKey tests that a rejected key deducts health and marks the account as error.
+func TestValidateAzureOpenAIAccount_InvalidKey(t *testing.T) {
+	uc, mockRepo, account, _ := setupAzureValidationTest(t, data.StatusActive)
+	wrongKey, err := uc.crypto.Encrypt("wrong-key")
+	require.NoError(t, err)
+	account.APIKeyEncrypted = wrongKey
+
+	ctx := context.Background()
+	mockRepo.On("UpdateHealthScore", mock.Anything, int64(7), 80).Return(nil)
+	mockRepo.On("UpdateAccountStatus", mock.Anything, int64(7), data.StatusError).Return(nil)
+	mockRepo.On("UpdateAccount", mock.Anything, account).Return(nil)
+
+	err = uc.ValidateAzureOpenAIAccount(ctx, 7)
+	var authErr *openai.AuthError
+	require.ErrorAs(t, err, &authErr)
+	mockRepo.AssertExpectations(t)
+	assert.EqualValues(t, 1, account.ConsecutiveErrors)
+}
+
+// TestValidateAzureOpenAIAccount_MissingDeployment tests that accounts without deployment metadata are rejected before any call.
+func TestValidateAzureOpenAIAccount_MissingDeployment(t *testing.T) {
+	uc, _, account, requests := setupAzureValidationTest(t, data.StatusActive)
+	meta := `{"azure_resource":"contoso"}`
+	account.Metadata = &meta
+
+	err := uc.ValidateAzureOpenAIAccount(context.Background(), 7)
+	assert.ErrorContains(t, err, "azure_deployment is required")
+	assert.Empty(t, *requests)
+}
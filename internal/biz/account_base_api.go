@@ -0,0 +1,37 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"QuotaLane/internal/data"
+)
+
+// UpdateBaseAPIByProvider 将指定 Provider 下 base_api 为 oldBase 的账户迁移到 newBase，返回迁移的账户数
+// 用于上游镜像地址变更：newBase 需通过与创建账户相同的 base_api 校验（http(s) URL），
+// oldBase 不能为空，避免误把所有未设置 base_api 的账户一并改写
+func (uc *AccountUsecase) UpdateBaseAPIByProvider(ctx context.Context, provider data.AccountProvider, oldBase, newBase string) (int64, error) {
+	oldBase = strings.TrimSpace(oldBase)
+	newBase = strings.TrimSpace(newBase)
+	if provider == "" {
+		return 0, fmt.Errorf("provider is required")
+	}
+	if oldBase == "" {
+		return 0, fmt.Errorf("old base_api is required")
+	}
+	if err := validateBaseAPI(newBase); err != nil {
+		return 0, err
+	}
+	if oldBase == newBase {
+		return 0, nil
+	}
+
+	updated, err := uc.repo.UpdateBaseAPIByProvider(ctx, provider, oldBase, newBase)
+	if err != nil {
+		return 0, fmt.Errorf("failed to migrate base_api: %w", err)
+	}
+
+	uc.logger.Infow("base_api migrated", "provider", provider, "old_base_api", oldBase, "new_base_api", newBase, "count", updated)
+	return updated, nil
+}
@@ -0,0 +1,72 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateBaseAPIByProvider(t *testing.T) {
+	ctx := context.Background()
+	const oldBase, newBase = "https://old-mirror.example.com", "https://new-mirror.example.com"
+
+	newUsecase := func() (*AccountUsecase, *MockAccountRepo) {
+		repo := new(MockAccountRepo)
+		return &AccountUsecase{repo: repo, logger: log.NewHelper(log.DefaultLogger)}, repo
+	}
+
+	t.Run("migrates matching accounts", func(t *testing.T) {
+		uc, repo := newUsecase()
+		repo.On("UpdateBaseAPIByProvider", ctx, data.ProviderOpenAIResponses, oldBase, newBase).Return(int64(3), nil).Once()
+
+		updated, err := uc.UpdateBaseAPIByProvider(ctx, data.ProviderOpenAIResponses, " "+oldBase, newBase+" ")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), updated)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("rejects invalid input without touching the repo", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			provider data.AccountProvider
+			oldBase  string
+			newBase  string
+			wantErr  string
+		}{
+			{name: "missing provider", oldBase: oldBase, newBase: newBase, wantErr: "provider is required"},
+			{name: "empty old base", provider: data.ProviderOpenAIResponses, newBase: newBase, wantErr: "old base_api is required"},
+			{name: "non-http new base", provider: data.ProviderOpenAIResponses, oldBase: oldBase, newBase: "ftp://mirror.example.com", wantErr: "invalid base_api"},
+			{name: "relative new base", provider: data.ProviderOpenAIResponses, oldBase: oldBase, newBase: "mirror.example.com", wantErr: "invalid base_api"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				uc, repo := newUsecase()
+				_, err := uc.UpdateBaseAPIByProvider(ctx, tt.provider, tt.oldBase, tt.newBase)
+				assert.ErrorContains(t, err, tt.wantErr)
+				repo.AssertNotCalled(t, "UpdateBaseAPIByProvider")
+			})
+		}
+	})
+
+	t.Run("same base is a no-op", func(t *testing.T) {
+		uc, repo := newUsecase()
+		updated, err := uc.UpdateBaseAPIByProvider(ctx, data.ProviderOpenAIResponses, oldBase, oldBase)
+		require.NoError(t, err)
+		assert.Zero(t, updated)
+		repo.AssertNotCalled(t, "UpdateBaseAPIByProvider")
+	})
+
+	t.Run("repo error", func(t *testing.T) {
+		uc, repo := newUsecase()
+		repo.On("UpdateBaseAPIByProvider", ctx, data.ProviderOpenAIResponses, oldBase, newBase).Return(int64(0), errors.New("database error")).Once()
+
+		_, err := uc.UpdateBaseAPIByProvider(ctx, data.ProviderOpenAIResponses, oldBase, newBase)
+		assert.ErrorContains(t, err, "failed to migrate base_api")
+	})
+}
@@ -0,0 +1,71 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+)
+
+// GetCapacity returns the aggregate RPM/TPM capacity of all active accounts of a provider.
+// Limits come from a single query; current usage of the accounts with a limit is read from the
+// rate limit counters in one Redis pipeline. Counter read failures degrade to zero usage.
+func (uc *AccountUsecase) GetCapacity(ctx context.Context, provider v1.AccountProvider) (*v1.ProviderCapacity, error) {
+	dataProvider := data.ProviderFromProto(provider)
+
+	stats, err := uc.repo.GetCapacityStats(ctx, dataProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get capacity stats: %w", err)
+	}
+
+	// Only accounts with a limit count toward usage: unlimited accounts add no capacity,
+	// so their traffic must not eat into the headroom of the limited ones
+	var currentRPM, currentTPM int64
+	if uc.rateLimitRepo != nil && (len(stats.RPMLimitedAccountIDs) > 0 || len(stats.TPMLimitedAccountIDs) > 0) {
+		currentRPM, currentTPM, err = uc.rateLimitRepo.SumUsageCounts(ctx, stats.RPMLimitedAccountIDs, stats.TPMLimitedAccountIDs)
+		if err != nil {
+			uc.logger.Warnw("failed to read usage counts, reporting zero usage",
+				"provider", dataProvider,
+				"error", err)
+			currentRPM, currentTPM = 0, 0
+		}
+	}
+
+	capacity := computeCapacity(stats, currentRPM, currentTPM, uc.zeroLimitMode())
+	capacity.Provider = provider
+	return capacity, nil
+}
+
+// computeCapacity derives headroom and utilization from aggregated limits and usage.
+// Headroom is clamped at 0; utilization is 0 when the total limit is 0 (all unlimited).
+// In ZeroLimitBlocked mode accounts with a 0 limit admit no traffic: they are reported as
+// blocked rather than unlimited and contribute no capacity.
+func computeCapacity(stats *data.CapacityStats, currentRPM, currentTPM int64, zeroLimit ZeroLimitMode) *v1.ProviderCapacity {
+	capacity := &v1.ProviderCapacity{
+		AccountCount:         stats.AccountCount,
+		TotalRpmLimit:        stats.TotalRPMLimit,
+		TotalTpmLimit:        stats.TotalTPMLimit,
+		CurrentRpm:           currentRPM,
+		CurrentTpm:           currentTPM,
+		RpmHeadroom:          max(stats.TotalRPMLimit-currentRPM, 0),
+		TpmHeadroom:          max(stats.TotalTPMLimit-currentTPM, 0),
+		RpmUtilization:       utilization(currentRPM, stats.TotalRPMLimit),
+		TpmUtilization:       utilization(currentTPM, stats.TotalTPMLimit),
+		UnlimitedRpmAccounts: stats.UnlimitedRPMAccounts,
+		UnlimitedTpmAccounts: stats.UnlimitedTPMAccounts,
+	}
+	if zeroLimit == ZeroLimitBlocked {
+		capacity.BlockedRpmAccounts, capacity.UnlimitedRpmAccounts = capacity.UnlimitedRpmAccounts, 0
+		capacity.BlockedTpmAccounts, capacity.UnlimitedTpmAccounts = capacity.UnlimitedTpmAccounts, 0
+	}
+	return capacity
+}
+
+// utilization returns used/limit, or 0 when there is no limit.
+func utilization(used, limit int64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return float64(used) / float64(limit)
+}
@@ -0,0 +1,189 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeCapacity(t *testing.T) {
+	tests := []struct {
+		name       string
+		stats      *data.CapacityStats
+		currentRPM int64
+		currentTPM int64
+		zeroLimit  ZeroLimitMode
+		want       *v1.ProviderCapacity
+	}{
+		{
+			name:       "partial utilization",
+			stats:      &data.CapacityStats{AccountCount: 3, TotalRPMLimit: 300, TotalTPMLimit: 90000},
+			currentRPM: 75,
+			currentTPM: 30000,
+			want: &v1.ProviderCapacity{
+				AccountCount: 3, TotalRpmLimit: 300, TotalTpmLimit: 90000,
+				CurrentRpm: 75, CurrentTpm: 30000,
+				RpmHeadroom: 225, TpmHeadroom: 60000,
+				RpmUtilization: 0.25, TpmUtilization: 1.0 / 3,
+			},
+		},
+		{
+			name:       "over limit clamps headroom",
+			stats:      &data.CapacityStats{AccountCount: 1, TotalRPMLimit: 100, TotalTPMLimit: 1000},
+			currentRPM: 120,
+			currentTPM: 1000,
+			want: &v1.ProviderCapacity{
+				AccountCount: 1, TotalRpmLimit: 100, TotalTpmLimit: 1000,
+				CurrentRpm: 120, CurrentTpm: 1000,
+				RpmHeadroom: 0, TpmHeadroom: 0,
+				RpmUtilization: 1.2, TpmUtilization: 1,
+			},
+		},
+		{
+			name:       "all unlimited",
+			stats:      &data.CapacityStats{AccountCount: 2, UnlimitedRPMAccounts: 2, UnlimitedTPMAccounts: 2},
+			currentRPM: 40,
+			currentTPM: 500,
+			want: &v1.ProviderCapacity{
+				AccountCount: 2, CurrentRpm: 40, CurrentTpm: 500,
+				UnlimitedRpmAccounts: 2, UnlimitedTpmAccounts: 2,
+			},
+		},
+		{
+			name:       "zero limits are blocked, not unlimited",
+			stats:      &data.CapacityStats{AccountCount: 3, TotalRPMLimit: 100, TotalTPMLimit: 1000, UnlimitedRPMAccounts: 2, UnlimitedTPMAccounts: 1},
+			currentRPM: 25,
+			currentTPM: 500,
+			zeroLimit:  ZeroLimitBlocked,
+			want: &v1.ProviderCapacity{
+				AccountCount: 3, TotalRpmLimit: 100, TotalTpmLimit: 1000,
+				CurrentRpm: 25, CurrentTpm: 500,
+				RpmHeadroom: 75, TpmHeadroom: 500,
+				RpmUtilization: 0.25, TpmUtilization: 0.5,
+				BlockedRpmAccounts: 2, BlockedTpmAccounts: 1,
+			},
+		},
+		{
+			name:  "no accounts",
+			stats: &data.CapacityStats{},
+			want:  &v1.ProviderCapacity{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeCapacity(tt.stats, tt.currentRPM, tt.currentTPM, tt.zeroLimit)
+			assert.Equal(t, tt.want.AccountCount, got.AccountCount)
+			assert.Equal(t, tt.want.TotalRpmLimit, got.TotalRpmLimit)
+			assert.Equal(t, tt.want.TotalTpmLimit, got.TotalTpmLimit)
+			assert.Equal(t, tt.want.CurrentRpm, got.CurrentRpm)
+			assert.Equal(t, tt.want.CurrentTpm, got.CurrentTpm)
+			assert.Equal(t, tt.want.RpmHeadroom, got.RpmHeadroom)
+			assert.Equal(t, tt.want.TpmHeadroom, got.TpmHeadroom)
+			assert.InDelta(t, tt.want.RpmUtilization, got.RpmUtilization, 1e-9)
+			assert.InDelta(t, tt.want.TpmUtilization, got.TpmUtilization, 1e-9)
+			assert.Equal(t, tt.want.UnlimitedRpmAccounts, got.UnlimitedRpmAccounts)
+			assert.Equal(t, tt.want.UnlimitedTpmAccounts, got.UnlimitedTpmAccounts)
+			assert.Equal(t, tt.want.BlockedRpmAccounts, got.BlockedRpmAccounts)
+			assert.Equal(t, tt.want.BlockedTpmAccounts, got.BlockedTpmAccounts)
+		})
+	}
+}
+
+func TestGetCapacity(t *testing.T) {
+	ctx := context.Background()
+	provider := data.ProviderClaudeConsole
+
+	setup := func() (*AccountUsecase, *MockAccountRepo, *MockRateLimitRepo) {
+		repo := new(MockAccountRepo)
+		rateLimitRepo := new(MockRateLimitRepo)
+		uc := &AccountUsecase{repo: repo, rateLimitRepo: rateLimitRepo, logger: log.NewHelper(log.DefaultLogger)}
+		return uc, repo, rateLimitRepo
+	}
+
+	t.Run("aggregates limits and usage", func(t *testing.T) {
+		uc, repo, rateLimitRepo := setup()
+		repo.On("GetCapacityStats", ctx, provider).
+			Return(&data.CapacityStats{
+				AccountCount: 2, TotalRPMLimit: 200, TotalTPMLimit: 20000,
+				RPMLimitedAccountIDs: []int64{1, 2}, TPMLimitedAccountIDs: []int64{1, 2},
+			}, nil)
+		rateLimitRepo.On("SumUsageCounts", ctx, []int64{1, 2}, []int64{1, 2}).Return(int64(50), int64(5000), nil)
+
+		capacity, err := uc.GetCapacity(ctx, v1.AccountProvider_CLAUDE_CONSOLE)
+		require.NoError(t, err)
+		assert.Equal(t, v1.AccountProvider_CLAUDE_CONSOLE, capacity.Provider)
+		assert.Equal(t, int64(150), capacity.RpmHeadroom)
+		assert.Equal(t, int64(15000), capacity.TpmHeadroom)
+		assert.InDelta(t, 0.25, capacity.RpmUtilization, 1e-9)
+		repo.AssertNotCalled(t, "ListAccountsByProvider", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("mixed limited and unlimited accounts count only limited usage", func(t *testing.T) {
+		uc, repo, rateLimitRepo := setup()
+		// Account 1 limits both, 2 only RPM, 3 only TPM, 4 neither
+		repo.On("GetCapacityStats", ctx, provider).
+			Return(&data.CapacityStats{
+				AccountCount: 4, TotalRPMLimit: 200, TotalTPMLimit: 20000,
+				UnlimitedRPMAccounts: 2, UnlimitedTPMAccounts: 2,
+				RPMLimitedAccountIDs: []int64{1, 2}, TPMLimitedAccountIDs: []int64{1, 3},
+			}, nil)
+		rateLimitRepo.On("SumUsageCounts", ctx, []int64{1, 2}, []int64{1, 3}).Return(int64(150), int64(5000), nil)
+
+		capacity, err := uc.GetCapacity(ctx, v1.AccountProvider_CLAUDE_CONSOLE)
+		require.NoError(t, err)
+		assert.Equal(t, int64(150), capacity.CurrentRpm)
+		assert.Equal(t, int64(50), capacity.RpmHeadroom)
+		assert.Equal(t, int64(15000), capacity.TpmHeadroom)
+		assert.Equal(t, int64(2), capacity.UnlimitedRpmAccounts)
+	})
+
+	t.Run("only unlimited accounts skips counter read", func(t *testing.T) {
+		uc, repo, rateLimitRepo := setup()
+		repo.On("GetCapacityStats", ctx, provider).
+			Return(&data.CapacityStats{AccountCount: 2, UnlimitedRPMAccounts: 2, UnlimitedTPMAccounts: 2}, nil)
+
+		capacity, err := uc.GetCapacity(ctx, v1.AccountProvider_CLAUDE_CONSOLE)
+		require.NoError(t, err)
+		assert.Zero(t, capacity.CurrentRpm)
+		rateLimitRepo.AssertNotCalled(t, "SumUsageCounts", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("no active accounts skips counter read", func(t *testing.T) {
+		uc, repo, rateLimitRepo := setup()
+		repo.On("GetCapacityStats", ctx, provider).Return(&data.CapacityStats{}, nil)
+
+		capacity, err := uc.GetCapacity(ctx, v1.AccountProvider_CLAUDE_CONSOLE)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), capacity.AccountCount)
+		rateLimitRepo.AssertNotCalled(t, "SumUsageCounts", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("redis failure reports zero usage", func(t *testing.T) {
+		uc, repo, rateLimitRepo := setup()
+		repo.On("GetCapacityStats", ctx, provider).
+			Return(&data.CapacityStats{AccountCount: 1, TotalRPMLimit: 100, RPMLimitedAccountIDs: []int64{1}, UnlimitedTPMAccounts: 1}, nil)
+		rateLimitRepo.On("SumUsageCounts", ctx, []int64{1}, []int64(nil)).Return(int64(0), int64(0), errors.New("redis down"))
+
+		capacity, err := uc.GetCapacity(ctx, v1.AccountProvider_CLAUDE_CONSOLE)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), capacity.RpmHeadroom)
+	})
+
+	t.Run("stats query error", func(t *testing.T) {
+		uc, repo, _ := setup()
+		repo.On("GetCapacityStats", ctx, provider).Return(nil, errors.New("database error"))
+
+		_, err := uc.GetCapacity(ctx, v1.AccountProvider_CLAUDE_CONSOLE)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get capacity stats")
+	})
+}
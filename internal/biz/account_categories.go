@@ -0,0 +1,25 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+
+	"QuotaLane/internal/data"
+)
+
+// CheckAccountAllowsCategory reports whether the account may serve requests of the given category.
+// The allowlist comes from metadata.allowed_categories; an empty list allows all categories,
+// and an empty category is allowed by every account.
+func (uc *AccountUsecase) CheckAccountAllowsCategory(ctx context.Context, accountID int64, category string) (bool, error) {
+	account, err := uc.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	meta, err := data.ParseMetadata(account.Metadata)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse account metadata: %w", err)
+	}
+
+	return meta.AllowsCategory(category), nil
+}
@@ -0,0 +1,53 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAccountAllowsCategory(t *testing.T) {
+	ctx := context.Background()
+	pinned := `{"allowed_categories":["batch","embeddings"]}`
+	empty := `{"region":"us-east"}`
+
+	tests := []struct {
+		name     string
+		metadata *string
+		category string
+		want     bool
+	}{
+		{"allowed category", &pinned, "batch", true},
+		{"disallowed category", &pinned, "chat", false},
+		{"uncategorized request", &pinned, "", true},
+		{"empty list allows all", &empty, "chat", true},
+		{"no metadata allows all", nil, "chat", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockAccountRepo)
+			uc := &AccountUsecase{repo: repo, logger: log.NewHelper(log.DefaultLogger)}
+			repo.On("GetAccount", ctx, int64(1)).Return(&data.Account{ID: 1, Metadata: tt.metadata}, nil)
+
+			allowed, err := uc.CheckAccountAllowsCategory(ctx, 1, tt.category)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, allowed)
+		})
+	}
+
+	t.Run("account not found", func(t *testing.T) {
+		repo := new(MockAccountRepo)
+		uc := &AccountUsecase{repo: repo, logger: log.NewHelper(log.DefaultLogger)}
+		repo.On("GetAccount", ctx, int64(2)).Return(nil, errors.New("account not found"))
+
+		_, err := uc.CheckAccountAllowsCategory(ctx, 2, "batch")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,62 @@
+package biz
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"QuotaLane/pkg/metadata"
+)
+
+// sealClientKey 校验元数据中的 mTLS 客户端证书，并将明文私钥加密为 client_key_encrypted
+// 返回可存储的元数据 JSON；未提交明文私钥时原样返回（仅更新证书时用已存储的私钥校验配对）
+func (uc *AccountUsecase) sealClientKey(raw string, meta *metadata.AccountMetadata) (string, error) {
+	if !meta.HasClientCertificate() {
+		return raw, nil
+	}
+
+	if meta.ClientKeyPEM == "" {
+		if _, err := uc.loadClientCertificate(meta); err != nil {
+			return "", newValidationError(ReasonInvalidMetadata, "Metadata", "metadata validation failed: %v", err).WithCause(err)
+		}
+		return raw, nil
+	}
+
+	if uc.crypto == nil {
+		return "", fmt.Errorf("failed to encrypt client key: encryption is not configured")
+	}
+	encrypted, err := uc.crypto.Encrypt(meta.ClientKeyPEM)
+	if err != nil {
+		uc.logger.Errorf("failed to encrypt client key: %v", err)
+		return "", fmt.Errorf("failed to encrypt credentials")
+	}
+
+	sealed, err := metadata.SealClientKey(raw, encrypted)
+	if err != nil {
+		return "", newValidationError(ReasonInvalidMetadata, "Metadata", "invalid metadata JSON: %v", err).WithCause(err)
+	}
+	return sealed, nil
+}
+
+// loadClientCertificate 解密账户元数据中的 mTLS 客户端证书，未配置证书时返回 nil
+func (uc *AccountUsecase) loadClientCertificate(meta *metadata.AccountMetadata) (*tls.Certificate, error) {
+	if !meta.HasClientCertificate() {
+		return nil, nil
+	}
+
+	keyPEM := meta.ClientKeyPEM
+	if keyPEM == "" {
+		if meta.ClientKeyEncrypted == metadata.MaskedClientKey {
+			return nil, fmt.Errorf("client key is masked: resubmit client_key_pem together with client_cert_pem")
+		}
+		if uc.crypto == nil {
+			return nil, fmt.Errorf("failed to decrypt client key: encryption is not configured")
+		}
+		decrypted, err := uc.crypto.Decrypt(meta.ClientKeyEncrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt client key: %w", err)
+		}
+		keyPEM = decrypted
+	}
+
+	return metadata.ParseClientCertificate(meta.ClientCertPEM, keyPEM)
+}
@@ -0,0 +1,125 @@
+package biz
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/metadata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// generateClientCertPEM creates a self-signed client certificate and its PEM private key.
+func generateClientCertPEM(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "quotalane-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+func clientCertMetadata(t *testing.T, certPEM, keyPEM string) string {
+	t.Helper()
+	raw, err := json.Marshal(map[string]string{
+		"custom_base_url": "https://gateway.example.com",
+		"client_cert_pem": certPEM,
+		"client_key_pem":  keyPEM,
+	})
+	require.NoError(t, err)
+	return string(raw)
+}
+
+// TestCreateAccount_ClientCertificate tests that the client key is stored encrypted and the
+// certificate is attached when the API key is validated.
+func TestCreateAccount_ClientCertificate(t *testing.T) {
+	uc, mockRepo, validator := setupValidateOnCreate(t, nil)
+	ctx := context.Background()
+	certPEM, keyPEM := generateClientCertPEM(t)
+
+	var saved *data.Account
+	mockRepo.On("CreateAccount", ctx, mock.AnythingOfType("*data.Account")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*data.Account) }).
+		Return(nil).Once()
+
+	req := validateOnCreateRequest()
+	req.Metadata = clientCertMetadata(t, certPEM, keyPEM)
+	result, err := uc.CreateAccount(ctx, req)
+	require.NoError(t, err)
+
+	require.NotNil(t, saved.Metadata)
+	assert.NotContains(t, *saved.Metadata, "PRIVATE KEY", "the private key must never be stored in plaintext")
+	meta, err := metadata.Parse(*saved.Metadata)
+	require.NoError(t, err)
+	assert.Empty(t, meta.ClientKeyPEM)
+	require.NotEmpty(t, meta.ClientKeyEncrypted)
+	decrypted, err := uc.crypto.Decrypt(meta.ClientKeyEncrypted)
+	require.NoError(t, err)
+	assert.Equal(t, keyPEM, decrypted)
+
+	require.NotNil(t, validator.gotMetadata.ClientCertificate)
+	block, _ := pem.Decode([]byte(certPEM))
+	assert.Equal(t, block.Bytes, validator.gotMetadata.ClientCertificate.Certificate[0])
+
+	assert.NotContains(t, result.Metadata, meta.ClientKeyEncrypted, "the encrypted key is masked in responses")
+	assert.Contains(t, result.Metadata, metadata.MaskedClientKey)
+}
+
+// TestCreateAccount_ClientCertificateMismatch tests that a key not matching the certificate is rejected on save.
+func TestCreateAccount_ClientCertificateMismatch(t *testing.T) {
+	uc, mockRepo, _ := setupValidateOnCreate(t, nil)
+	certPEM, _ := generateClientCertPEM(t)
+	_, otherKeyPEM := generateClientCertPEM(t)
+
+	req := validateOnCreateRequest()
+	req.Metadata = clientCertMetadata(t, certPEM, otherKeyPEM)
+	_, err := uc.CreateAccount(context.Background(), req)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid client certificate/key pair")
+	mockRepo.AssertNotCalled(t, "CreateAccount", mock.Anything, mock.Anything)
+}
+
+// TestLoadClientCertificate_EncryptedKey tests loading a stored certificate and rejecting a masked key.
+func TestLoadClientCertificate_EncryptedKey(t *testing.T) {
+	uc, _, _ := setupTestUsecase(t)
+	certPEM, keyPEM := generateClientCertPEM(t)
+	encrypted, err := uc.crypto.Encrypt(keyPEM)
+	require.NoError(t, err)
+
+	cert, err := uc.loadClientCertificate(&metadata.AccountMetadata{ClientCertPEM: certPEM, ClientKeyEncrypted: encrypted})
+	require.NoError(t, err)
+	assert.NotNil(t, cert)
+
+	_, err = uc.loadClientCertificate(&metadata.AccountMetadata{ClientCertPEM: certPEM, ClientKeyEncrypted: metadata.MaskedClientKey})
+	assert.ErrorContains(t, err, "client key is masked")
+
+	cert, err = uc.loadClientCertificate(&metadata.AccountMetadata{})
+	require.NoError(t, err)
+	assert.Nil(t, cert)
+}
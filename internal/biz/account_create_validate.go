@@ -0,0 +1,66 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/metadata"
+	"QuotaLane/pkg/oauth"
+)
+
+// validateOnCreate 在新建 API Key 账户保存前调用 Provider 验证器，驱动生命周期 created → validating → active | error
+// 验证通过：状态置为 ACTIVE；验证失败：状态置为 ERROR 并记录 LastError（账户仍会创建，但不参与调度）
+// 仅当无法执行验证时返回错误
+func (uc *AccountUsecase) validateOnCreate(ctx context.Context, account *data.Account, apiKey string, lifecycle *accountLifecycle) error {
+	var provider oauth.OAuthProvider
+	if uc.oauthManager != nil {
+		provider = uc.oauthManager.GetProvider(account.Provider)
+	}
+	if provider == nil {
+		return fmt.Errorf("validate on create is not supported for provider %s", account.Provider)
+	}
+
+	// 代理与 mTLS 客户端证书取自元数据（已在 prepareMetadata 中校验），Base API 已在创建时解析
+	accountMetadata := &oauth.AccountMetadata{BaseAPI: account.BaseAPI}
+	if account.Metadata != nil {
+		if meta, err := metadata.Parse(*account.Metadata); err == nil {
+			accountMetadata.ProxyURL = meta.ProxyURL
+			clientCert, err := uc.loadClientCertificate(meta)
+			if err != nil {
+				return fmt.Errorf("failed to load client certificate: %w", err)
+			}
+			accountMetadata.ClientCertificate = clientCert
+		}
+	}
+
+	validationErr := lifecycle.validate(func() error {
+		return provider.ValidateToken(ctx, apiKey, accountMetadata)
+	}, "API key validated on create")
+	account.Status = lifecycle.status
+	if validationErr == nil {
+		return nil
+	}
+
+	now := uc.now()
+	errorRecord := ErrorRecord{
+		Code:       extractErrorCode(validationErr),
+		Message:    validationErr.Error(),
+		BaseAPI:    accountMetadata.BaseAPI,
+		OccurredAt: now,
+	}
+	errorJSON, _ := json.Marshal(errorRecord)
+	errorStr := string(errorJSON)
+
+	account.LastError = &errorStr
+	account.LastErrorAt = &now
+	account.ConsecutiveErrors = 1
+
+	uc.logger.Warnw("API key validation failed on create",
+		"account_name", account.Name,
+		"provider", account.Provider,
+		"error", validationErr)
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+	pkgoauth "QuotaLane/pkg/oauth"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// stubKeyValidator is an OpenAI Responses provider whose ValidateToken result is fixed.
+type stubKeyValidator struct {
+	mockOAuthProvider
+	gotToken    string
+	gotMetadata *pkgoauth.AccountMetadata
+}
+
+func (s *stubKeyValidator) ValidateToken(ctx context.Context, token string, metadata *pkgoauth.AccountMetadata) error {
+	s.gotToken = token
+	s.gotMetadata = metadata
+	return s.err
+}
+
+func (s *stubKeyValidator) ProviderType() data.AccountProvider {
+	return data.ProviderOpenAIResponses
+}
+
+func setupValidateOnCreate(t *testing.T, validationErr error) (*AccountUsecase, *MockAccountRepo, *stubKeyValidator) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+
+	validator := &stubKeyValidator{mockOAuthProvider: mockOAuthProvider{err: validationErr}}
+	uc.oauthManager = pkgoauth.NewOAuthManager(nil, log.DefaultLogger)
+	uc.oauthManager.RegisterProvider(validator)
+
+	return uc, mockRepo, validator
+}
+
+func validateOnCreateRequest() *v1.CreateAccountRequest {
+	return &v1.CreateAccountRequest{
+		Name:             "OpenAI validated",
+		Provider:         v1.AccountProvider_OPENAI_RESPONSES,
+		ApiKey:           "sk-test-1234567890abcdef",
+		Metadata:         `{"proxy_url":"http://proxy:8080","custom_base_url":"https://api.example.com"}`,
+		InitialStatus:    v1.AccountStatus_ACCOUNT_CREATED,
+		ValidateOnCreate: true,
+	}
+}
+
+func TestCreateAccount_ValidateOnCreate_Valid(t *testing.T) {
+	uc, mockRepo, validator := setupValidateOnCreate(t, nil)
+	ctx := context.Background()
+
+	var saved *data.Account
+	mockRepo.On("CreateAccount", ctx, mock.AnythingOfType("*data.Account")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*data.Account) }).
+		Return(nil).Once()
+
+	result, err := uc.CreateAccount(ctx, validateOnCreateRequest())
+	require.NoError(t, err)
+
+	assert.Equal(t, v1.AccountStatus_ACCOUNT_ACTIVE, result.Status)
+	assert.Equal(t, data.StatusActive, saved.Status)
+	assert.Nil(t, saved.LastError)
+
+	assert.Equal(t, "sk-test-1234567890abcdef", validator.gotToken)
+	assert.Equal(t, "http://proxy:8080", validator.gotMetadata.ProxyURL)
+	assert.Equal(t, "https://api.example.com", validator.gotMetadata.BaseAPI)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateAccount_ValidateOnCreate_Invalid(t *testing.T) {
+	uc, mockRepo, _ := setupValidateOnCreate(t, errors.New("invalid API key (HTTP 401)"))
+	ctx := context.Background()
+
+	var saved *data.Account
+	mockRepo.On("CreateAccount", ctx, mock.AnythingOfType("*data.Account")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*data.Account) }).
+		Return(nil).Once()
+
+	result, err := uc.CreateAccount(ctx, validateOnCreateRequest())
+	require.NoError(t, err)
+
+	assert.Equal(t, v1.AccountStatus_ACCOUNT_ERROR, result.Status)
+	assert.Equal(t, data.StatusError, saved.Status)
+	require.NotNil(t, saved.LastError)
+	assert.Contains(t, *saved.LastError, `"code":401`)
+	assert.NotNil(t, saved.LastErrorAt)
+	assert.Equal(t, int32(1), saved.ConsecutiveErrors)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateAccount_ValidateOnCreate_NoValidator(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	ctx := context.Background()
+
+	_, err := uc.CreateAccount(ctx, validateOnCreateRequest())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "validate on create is not supported")
+	mockRepo.AssertNotCalled(t, "CreateAccount", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,181 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/crypto"
+)
+
+// CredentialBinding 控制账户凭证密文是否绑定账户 ID（AES-GCM AAD）
+// 绑定后，把一个账户的 api_key_encrypted / oauth_data_encrypted 复制到另一行将无法解密
+type CredentialBinding string
+
+const (
+	// CredentialBindingOff 写入旧版（不绑定）密文，读取两种格式（默认，便于回滚）
+	CredentialBindingOff CredentialBinding = "off"
+	// CredentialBindingBind 写入绑定账户 ID 的密文，仍可读取旧版密文（迁移期间使用）
+	CredentialBindingBind CredentialBinding = "bind"
+	// CredentialBindingRequire 写入绑定密文并拒绝旧版密文（迁移完成后使用）
+	CredentialBindingRequire CredentialBinding = "require"
+)
+
+// credentialMigrationBatchSize 凭证绑定迁移每批扫描的账户数
+const credentialMigrationBatchSize = 100
+
+// ErrUnboundCredential 在 require 模式下读取到未绑定账户 ID 的旧版密文
+var ErrUnboundCredential = errors.New("credential ciphertext is not bound to its account")
+
+// ParseCredentialBinding 解析 auth.encryption.account_binding；空字符串表示 CredentialBindingOff
+func ParseCredentialBinding(mode string) (CredentialBinding, error) {
+	switch CredentialBinding(mode) {
+	case "", CredentialBindingOff:
+		return CredentialBindingOff, nil
+	case CredentialBindingBind:
+		return CredentialBindingBind, nil
+	case CredentialBindingRequire:
+		return CredentialBindingRequire, nil
+	default:
+		return "", fmt.Errorf("unknown credential binding %q (want off, bind or require)", mode)
+	}
+}
+
+// credentialAAD 返回账户凭证密文绑定的附加认证数据
+func credentialAAD(accountID int64) []byte {
+	return []byte(fmt.Sprintf("quotalane:account:%d", accountID))
+}
+
+// encryptAccountCredential 加密账户凭证；off 模式或账户尚未创建（accountID <= 0）时写入旧版密文
+func encryptAccountCredential(c *crypto.AESCrypto, mode CredentialBinding, accountID int64, plaintext string) (string, error) {
+	if mode == CredentialBindingOff || mode == "" || accountID <= 0 {
+		return c.Encrypt(plaintext)
+	}
+	return c.EncryptWithAAD(plaintext, credentialAAD(accountID))
+}
+
+// decryptAccountCredential 解密账户凭证：绑定密文必须与 accountID 匹配（任何模式下都可读取，便于回滚），
+// 旧版密文仅在 require 以外的模式下解密
+func decryptAccountCredential(c *crypto.AESCrypto, mode CredentialBinding, accountID int64, ciphertext string) (string, error) {
+	if crypto.IsAADCiphertext(ciphertext) {
+		return c.DecryptWithAAD(ciphertext, credentialAAD(accountID))
+	}
+	if mode == CredentialBindingRequire && ciphertext != "" {
+		return "", fmt.Errorf("%w: account %d", ErrUnboundCredential, accountID)
+	}
+	return c.Decrypt(ciphertext)
+}
+
+// SetCredentialBinding 设置账户凭证密文的绑定模式
+func (uc *AccountUsecase) SetCredentialBinding(mode CredentialBinding) {
+	uc.credentialBinding = mode
+}
+
+// encryptCredential 按当前绑定模式加密 accountID 的凭证
+func (uc *AccountUsecase) encryptCredential(accountID int64, plaintext string) (string, error) {
+	return encryptAccountCredential(uc.crypto, uc.credentialBinding, accountID, plaintext)
+}
+
+// decryptCredential 按当前绑定模式解密 accountID 的凭证
+func (uc *AccountUsecase) decryptCredential(accountID int64, ciphertext string) (string, error) {
+	return decryptAccountCredential(uc.crypto, uc.credentialBinding, accountID, ciphertext)
+}
+
+// bindCredentials 在账户创建后（ID 已分配）将创建时写入的旧版密文重新加密为绑定密文；off 模式下不做处理
+// 失败只记录日志：账户已创建，旧版密文由 MigrateCredentialBinding 后续补绑
+func (uc *AccountUsecase) bindCredentials(ctx context.Context, account *data.Account) {
+	if uc.credentialBinding == CredentialBindingOff || uc.credentialBinding == "" {
+		return
+	}
+
+	apiKey, oauthData, err := uc.rebindCredentials(account)
+	if err != nil {
+		uc.logger.Warnw("failed to bind credentials to account", "account_id", account.ID, "error", err)
+		return
+	}
+
+	replaced, err := uc.repo.ReplaceCredentials(ctx, account.ID, account.APIKeyEncrypted, apiKey, account.OAuthDataEncrypted, oauthData)
+	if err != nil || !replaced {
+		uc.logger.Warnw("failed to bind credentials to account", "account_id", account.ID, "replaced", replaced, "error", err)
+		return
+	}
+	account.APIKeyEncrypted = apiKey
+	account.OAuthDataEncrypted = oauthData
+}
+
+// rebindCredentials 返回账户凭证的绑定密文：旧版密文解密后以账户 ID 为 AAD 重新加密，已绑定的密文保持不变
+func (uc *AccountUsecase) rebindCredentials(account *data.Account) (apiKey, oauthData string, err error) {
+	rebind := func(ciphertext string) (string, error) {
+		if ciphertext == "" || crypto.IsAADCiphertext(ciphertext) {
+			return ciphertext, nil
+		}
+		plaintext, err := uc.crypto.Decrypt(ciphertext)
+		if err != nil {
+			return "", err
+		}
+		return uc.crypto.EncryptWithAAD(plaintext, credentialAAD(account.ID))
+	}
+
+	if apiKey, err = rebind(account.APIKeyEncrypted); err != nil {
+		return "", "", fmt.Errorf("failed to rebind API key: %w", err)
+	}
+	if oauthData, err = rebind(account.OAuthDataEncrypted); err != nil {
+		return "", "", fmt.Errorf("failed to rebind OAuth data: %w", err)
+	}
+	return apiKey, oauthData, nil
+}
+
+// MigrateCredentialBinding 将仍为旧版密文的账户凭证重新加密为绑定账户 ID 的密文，返回迁移的账户数
+// off 模式下不做处理；无法解密的账户记录日志后跳过，期间凭证被刷新修改的账户留待下一轮
+// 部署 bind 模式并运行迁移直至返回 0 后，即可切换到 require 模式
+func (uc *AccountUsecase) MigrateCredentialBinding(ctx context.Context) (int, error) {
+	if uc.credentialBinding == CredentialBindingOff || uc.credentialBinding == "" {
+		return 0, nil
+	}
+
+	migrated := 0
+	var afterID int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return migrated, err
+		}
+
+		accounts, err := uc.repo.ListUnboundCredentialAccounts(ctx, afterID, credentialMigrationBatchSize)
+		if err != nil {
+			return migrated, fmt.Errorf("failed to list accounts with unbound credentials: %w", err)
+		}
+
+		for _, account := range accounts {
+			afterID = account.ID
+
+			apiKey, oauthData, err := uc.rebindCredentials(account)
+			if err != nil {
+				uc.logger.Warnw("skipping credential binding for account", "account_id", account.ID, "error", err)
+				continue
+			}
+
+			replaced, err := uc.repo.ReplaceCredentials(ctx, account.ID, account.APIKeyEncrypted, apiKey, account.OAuthDataEncrypted, oauthData)
+			if err != nil {
+				return migrated, fmt.Errorf("failed to bind credentials of account %d: %w", account.ID, err)
+			}
+			if replaced {
+				migrated++
+			}
+		}
+
+		if len(accounts) < credentialMigrationBatchSize {
+			break
+		}
+	}
+
+	if migrated > 0 {
+		uc.logger.Infow("account credentials bound to account ID", "count", migrated)
+	}
+	return migrated, nil
+}
+
+// SetCredentialBinding 设置账户凭证密文的绑定模式（需与 AccountUsecase 一致）
+func (t *OAuthRefreshTask) SetCredentialBinding(mode CredentialBinding) {
+	t.credentialBinding = mode
+}
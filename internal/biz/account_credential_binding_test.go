@@ -0,0 +1,182 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/crypto"
+	"QuotaLane/pkg/oauth"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCredentialBinding(t *testing.T) {
+	mode, err := ParseCredentialBinding("")
+	require.NoError(t, err)
+	assert.Equal(t, CredentialBindingOff, mode)
+
+	mode, err = ParseCredentialBinding("require")
+	require.NoError(t, err)
+	assert.Equal(t, CredentialBindingRequire, mode)
+
+	_, err = ParseCredentialBinding("strict")
+	assert.Error(t, err)
+}
+
+// TestDecryptCredential_CrossAccountRejected tests that a bound ciphertext copied to another
+// account's row fails to decrypt, in every mode.
+func TestDecryptCredential_CrossAccountRejected(t *testing.T) {
+	uc, _, _ := setupTestUsecase(t)
+	uc.SetCredentialBinding(CredentialBindingBind)
+
+	ciphertext, err := uc.encryptCredential(1, "sk-account-1")
+	require.NoError(t, err)
+	assert.True(t, crypto.IsAADCiphertext(ciphertext))
+
+	plaintext, err := uc.decryptCredential(1, ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-account-1", plaintext)
+
+	for _, mode := range []CredentialBinding{CredentialBindingOff, CredentialBindingBind, CredentialBindingRequire} {
+		uc.SetCredentialBinding(mode)
+		_, err = uc.decryptCredential(2, ciphertext)
+		assert.ErrorIs(t, err, crypto.ErrDecryptionFailed, "mode %s", mode)
+	}
+}
+
+func TestDecryptCredential_LegacyCiphertext(t *testing.T) {
+	uc, _, cryptoSvc := setupTestUsecase(t)
+	legacy, err := cryptoSvc.Encrypt("sk-legacy")
+	require.NoError(t, err)
+
+	// off 模式写入旧版密文
+	written, err := uc.encryptCredential(1, "sk-legacy")
+	require.NoError(t, err)
+	assert.False(t, crypto.IsAADCiphertext(written))
+
+	uc.SetCredentialBinding(CredentialBindingBind)
+	plaintext, err := uc.decryptCredential(1, legacy)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-legacy", plaintext)
+
+	uc.SetCredentialBinding(CredentialBindingRequire)
+	_, err = uc.decryptCredential(1, legacy)
+	assert.ErrorIs(t, err, ErrUnboundCredential)
+}
+
+func TestBindCredentials_AfterCreate(t *testing.T) {
+	uc, mockRepo, cryptoSvc := setupTestUsecase(t)
+	uc.SetCredentialBinding(CredentialBindingBind)
+	ctx := context.Background()
+
+	legacy, err := cryptoSvc.Encrypt("sk-new")
+	require.NoError(t, err)
+	account := &data.Account{ID: 7, APIKeyEncrypted: legacy}
+
+	mockRepo.On("ReplaceCredentials", ctx, int64(7), legacy, mock.MatchedBy(crypto.IsAADCiphertext), "", "").
+		Return(true, nil).Once()
+
+	uc.bindCredentials(ctx, account)
+
+	mockRepo.AssertExpectations(t)
+	plaintext, err := uc.decryptCredential(7, account.APIKeyEncrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-new", plaintext)
+}
+
+func TestMigrateCredentialBinding(t *testing.T) {
+	uc, mockRepo, cryptoSvc := setupTestUsecase(t)
+	ctx := context.Background()
+
+	// off 模式不扫描
+	migrated, err := uc.MigrateCredentialBinding(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, migrated)
+	mockRepo.AssertNotCalled(t, "ListUnboundCredentialAccounts", mock.Anything, mock.Anything, mock.Anything)
+
+	uc.SetCredentialBinding(CredentialBindingRequire)
+	apiKey, err := cryptoSvc.Encrypt("sk-1")
+	require.NoError(t, err)
+	oauthData, err := cryptoSvc.Encrypt(`{"refresh_token":"r"}`)
+	require.NoError(t, err)
+	otherKey, err := crypto.NewAESCrypto([]byte("abcdefghijklmnopqrstuvwxyz012345"))
+	require.NoError(t, err)
+	corrupt, err := otherKey.Encrypt("sk-2")
+	require.NoError(t, err)
+
+	accounts := []*data.Account{
+		{ID: 1, APIKeyEncrypted: apiKey},
+		{ID: 2, APIKeyEncrypted: corrupt},
+		{ID: 3, OAuthDataEncrypted: oauthData},
+	}
+	mockRepo.On("ListUnboundCredentialAccounts", ctx, int64(0), credentialMigrationBatchSize).Return(accounts, nil).Once()
+
+	var boundOAuth string
+	mockRepo.On("ReplaceCredentials", ctx, int64(1), apiKey, mock.MatchedBy(crypto.IsAADCiphertext), "", "").
+		Return(true, nil).Once()
+	mockRepo.On("ReplaceCredentials", ctx, int64(3), "", "", oauthData, mock.MatchedBy(crypto.IsAADCiphertext)).
+		Run(func(args mock.Arguments) { boundOAuth = args.String(5) }).
+		Return(true, nil).Once()
+
+	migrated, err = uc.MigrateCredentialBinding(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, migrated, "undecryptable account is skipped")
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "ReplaceCredentials", mock.Anything, int64(2), mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	plaintext, err := uc.decryptCredential(3, boundOAuth)
+	require.NoError(t, err)
+	assert.Equal(t, `{"refresh_token":"r"}`, plaintext)
+}
+
+func TestMigrateCredentialBinding_ListError(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	uc.SetCredentialBinding(CredentialBindingBind)
+	ctx := context.Background()
+
+	mockRepo.On("ListUnboundCredentialAccounts", ctx, int64(0), credentialMigrationBatchSize).Return(nil, errors.New("db down"))
+
+	_, err := uc.MigrateCredentialBinding(ctx)
+	assert.Error(t, err)
+}
+
+// TestRefreshAccountToken_CrossAccountOAuthData tests that the refresh task refuses OAuth data
+// copied from another account's row instead of refreshing it under the wrong account.
+func TestRefreshAccountToken_CrossAccountOAuthData(t *testing.T) {
+	cryptoHelper, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+
+	source := expiringOAuthAccount(t, cryptoHelper, 1)
+	plaintext, err := cryptoHelper.Decrypt(source.OAuthDataEncrypted)
+	require.NoError(t, err)
+	bound, err := cryptoHelper.EncryptWithAAD(plaintext, credentialAAD(1))
+	require.NoError(t, err)
+
+	oauthManager := oauth.NewOAuthManager(nil, log.DefaultLogger)
+	oauthManager.RegisterProvider(&mockOAuthProvider{tokenResp: &oauth.ExtendedTokenResponse{
+		AccessToken:  "new-access",
+		RefreshToken: "new-refresh",
+		ExpiresIn:    28800,
+	}})
+	mockRepo := new(MockAccountRepo)
+	mockRepo.On("UpdateOAuthData", mock.Anything, int64(1), mock.MatchedBy(crypto.IsAADCiphertext), mock.Anything).Return(nil).Once()
+
+	task := NewOAuthRefreshTask(mockRepo, oauthManager, cryptoHelper, nil, log.DefaultLogger)
+	task.SetCredentialBinding(CredentialBindingBind)
+
+	// 复制到账户 2 的密文无法解密
+	copied := &data.Account{ID: 2, Name: "claude", Provider: data.ProviderClaudeOfficial, OAuthDataEncrypted: bound}
+	err = task.refreshAccountToken(context.Background(), copied)
+	assert.ErrorIs(t, err, errCredentialDecrypt)
+
+	// 原账户正常刷新，写回的密文仍绑定账户 ID
+	source.OAuthDataEncrypted = bound
+	require.NoError(t, task.refreshAccountToken(context.Background(), source))
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "UpdateOAuthData", mock.Anything, int64(2), mock.Anything, mock.Anything)
+}
@@ -0,0 +1,60 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+
+	v1 "QuotaLane/api/v1"
+)
+
+// DrainAccount 将账户置为排空状态（幂等）
+// 排空中的账户不再被账户组选中处理新请求，进行中的请求照常释放并发槽位；
+// 通过 GetDrainStatus 查询 Complete 后即可安全停用账户。
+// 取消排空：UpdateAccount 传 IsDraining=false，或将账户状态重新改为 active
+func (uc *AccountUsecase) DrainAccount(ctx context.Context, accountID int64) (*v1.DrainStatus, error) {
+	account, err := uc.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	if !account.IsDraining {
+		if err := uc.repo.SetDraining(ctx, accountID, true); err != nil {
+			return nil, fmt.Errorf("failed to mark account draining: %w", err)
+		}
+		uc.logger.Infow("account draining started",
+			"account_id", accountID,
+			"account_name", account.Name)
+	}
+
+	return uc.drainStatus(ctx, accountID, true)
+}
+
+// GetDrainStatus 查询账户排空进度（只读）
+func (uc *AccountUsecase) GetDrainStatus(ctx context.Context, accountID int64) (*v1.DrainStatus, error) {
+	account, err := uc.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	return uc.drainStatus(ctx, accountID, account.IsDraining)
+}
+
+// drainStatus 读取当前并发数并组装排空进度
+// 并发计数读取失败时返回错误，避免在无法确认时误报排空完成
+func (uc *AccountUsecase) drainStatus(ctx context.Context, accountID int64, draining bool) (*v1.DrainStatus, error) {
+	var inFlight int32
+	if uc.rateLimitRepo != nil {
+		count, err := uc.rateLimitRepo.GetConcurrencyCount(ctx, accountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get in-flight request count: %w", err)
+		}
+		inFlight = count
+	}
+
+	return &v1.DrainStatus{
+		AccountId: accountID,
+		Draining:  draining,
+		InFlight:  inFlight,
+		Complete:  draining && inFlight == 0,
+	}, nil
+}
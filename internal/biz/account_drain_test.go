@@ -0,0 +1,128 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainAccount_ExcludesFromSelectionUntilComplete(t *testing.T) {
+	// a has more headroom and would normally win
+	a := &data.Account{ID: 1, Status: data.StatusActive, RpmLimit: 100, TpmLimit: 10000}
+	b := &data.Account{ID: 2, Status: data.StatusActive, RpmLimit: 100, TpmLimit: 10000}
+	groupUC, accountRepo, rateLimitRepo := setupSelectTest(a, b)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(1)).Return(int32(0), int32(0), nil)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(2)).Return(int32(50), int32(5000), nil)
+	// Only the is_draining column is written; the mock flips the shared account like the DB would
+	accountRepo.On("SetDraining", mock.Anything, int64(1), true).Run(func(mock.Arguments) {
+		a.IsDraining = true
+	}).Return(nil).Once()
+
+	uc := NewAccountUsecase(accountRepo, nil, nil, nil, nil, nil, groupUC, rateLimitRepo, nil, log.DefaultLogger)
+	ctx := context.Background()
+
+	selected, err := groupUC.SelectAccount(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), selected.ID)
+
+	// Two requests still in flight on a
+	rateLimitRepo.On("GetConcurrencyCount", mock.Anything, int64(1)).Return(int32(2), nil).Twice()
+
+	status, err := uc.DrainAccount(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, status.Draining)
+	assert.Equal(t, int32(2), status.InFlight)
+	assert.False(t, status.Complete)
+
+	for i := 0; i < 5; i++ {
+		selected, err := groupUC.SelectAccount(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), selected.ID, "draining account must not receive new requests")
+	}
+
+	status, err = uc.GetDrainStatus(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, status.Complete, "in-flight requests are still running")
+
+	// In-flight requests released their slots
+	rateLimitRepo.On("GetConcurrencyCount", mock.Anything, int64(1)).Return(int32(0), nil)
+
+	status, err = uc.GetDrainStatus(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, status.Draining)
+	assert.Zero(t, status.InFlight)
+	assert.True(t, status.Complete)
+
+	// Draining again is idempotent
+	_, err = uc.DrainAccount(ctx, 1)
+	require.NoError(t, err)
+	accountRepo.AssertNumberOfCalls(t, "SetDraining", 1)
+}
+
+// TestUpdateAccount_Undrain tests that a drained account returns to selection when the drain is
+// cancelled explicitly or the account is re-enabled.
+func TestUpdateAccount_Undrain(t *testing.T) {
+	f := false
+	active := v1.AccountStatus_ACCOUNT_ACTIVE
+	tests := []struct {
+		name         string
+		status       data.AccountStatus
+		req          *v1.UpdateAccountRequest
+		wantDraining bool
+	}{
+		{"explicit IsDraining=false", data.StatusActive, &v1.UpdateAccountRequest{Id: 1, IsDraining: &f}, false},
+		{"re-enabled after disable", data.StatusInactive, &v1.UpdateAccountRequest{Id: 1, Status: &active}, false},
+		{"unrelated update keeps draining", data.StatusActive, &v1.UpdateAccountRequest{Id: 1, Status: &active}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := &data.Account{ID: 1, Status: tt.status, IsDraining: true}
+			accountRepo := new(MockAccountRepo)
+			accountRepo.On("GetAccount", mock.Anything, int64(1)).Return(account, nil)
+			accountRepo.On("UpdateAccount", mock.Anything, account).Return(nil)
+			uc := NewAccountUsecase(accountRepo, nil, nil, nil, nil, nil, nil, nil, nil, log.DefaultLogger)
+
+			resp, err := uc.UpdateAccount(context.Background(), tt.req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDraining, account.IsDraining)
+			assert.Equal(t, tt.wantDraining, resp.IsDraining)
+		})
+	}
+}
+
+func TestGetDrainStatus(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("not draining is never complete", func(t *testing.T) {
+		accountRepo := new(MockAccountRepo)
+		rateLimitRepo := new(MockRateLimitRepo)
+		accountRepo.On("GetAccount", ctx, int64(1)).Return(&data.Account{ID: 1, Status: data.StatusActive}, nil)
+		rateLimitRepo.On("GetConcurrencyCount", ctx, int64(1)).Return(int32(0), nil)
+		uc := NewAccountUsecase(accountRepo, nil, nil, nil, nil, nil, nil, rateLimitRepo, nil, log.DefaultLogger)
+
+		status, err := uc.GetDrainStatus(ctx, 1)
+		require.NoError(t, err)
+		assert.False(t, status.Draining)
+		assert.False(t, status.Complete)
+	})
+
+	t.Run("concurrency count unavailable", func(t *testing.T) {
+		accountRepo := new(MockAccountRepo)
+		rateLimitRepo := new(MockRateLimitRepo)
+		accountRepo.On("GetAccount", ctx, int64(1)).Return(&data.Account{ID: 1, IsDraining: true}, nil)
+		rateLimitRepo.On("GetConcurrencyCount", ctx, int64(1)).Return(int32(0), errors.New("redis down"))
+		uc := NewAccountUsecase(accountRepo, nil, nil, nil, nil, nil, nil, rateLimitRepo, nil, log.DefaultLogger)
+
+		_, err := uc.GetDrainStatus(ctx, 1)
+		assert.Error(t, err, "completion must not be reported when in-flight count is unknown")
+	})
+}
@@ -0,0 +1,104 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/metadata"
+)
+
+// GetEffectiveConfig resolves the limits, proxy and timeout that actually apply to an account,
+// together with where each value comes from. It is a pure read: nothing is written back.
+//
+// Resolution order per setting:
+//   - RPM/TPM: account rpm_limit/tpm_limit, otherwise unlimited (blocked when rate_limit.zero_limit=blocked)
+//   - Concurrency: DefaultConcurrencyLimit (no per-account override yet)
+//   - Proxy: metadata.proxy_url when metadata.proxy_enabled, otherwise direct connection
+//   - Timeout: metadata.request_timeout_ms, then the provider default, then DefaultRequestTimeout
+func (uc *AccountUsecase) GetEffectiveConfig(ctx context.Context, accountID int64) (*v1.EffectiveConfig, error) {
+	account, err := uc.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	return uc.effectiveConfig(account)
+}
+
+// effectiveConfig implements GetEffectiveConfig for an already loaded account.
+func (uc *AccountUsecase) effectiveConfig(account *data.Account) (*v1.EffectiveConfig, error) {
+	meta, err := data.ParseMetadata(account.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse account metadata: %w", err)
+	}
+
+	limits := uc.effectiveRateLimits(account)
+	cfg := &v1.EffectiveConfig{
+		AccountId:              account.ID,
+		Provider:               data.ProviderToProto(account.Provider),
+		RpmLimit:               limits.RpmLimit,
+		RpmLimitSource:         limits.RpmLimitSource,
+		TpmLimit:               limits.TpmLimit,
+		TpmLimitSource:         limits.TpmLimitSource,
+		ConcurrencyLimit:       limits.ConcurrencyLimit,
+		ConcurrencyLimitSource: limits.ConcurrencyLimitSource,
+		RpmBlocked:             limits.RpmBlocked,
+		TpmBlocked:             limits.TpmBlocked,
+	}
+	cfg.ProxyUrl, cfg.ProxySource = effectiveProxy(meta)
+	cfg.RequestTimeoutMs, cfg.RequestTimeoutSource = effectiveRequestTimeout(account.Provider, meta)
+
+	return cfg, nil
+}
+
+// effectiveRateLimits resolves the RPM/TPM/concurrency limits of an account and their sources.
+// Shared by GetEffectiveConfig and the Account responses so both always agree.
+func (uc *AccountUsecase) effectiveRateLimits(account *data.Account) *v1.RateLimitConfig {
+	zeroLimit := uc.zeroLimitMode()
+	limits := &v1.RateLimitConfig{
+		ConcurrencyLimit:       DefaultConcurrencyLimit,
+		ConcurrencyLimitSource: v1.ConfigSource_CONFIG_SOURCE_DEFAULT,
+		RpmBlocked:             limitBlocked(account.RpmLimit, zeroLimit),
+		TpmBlocked:             limitBlocked(account.TpmLimit, zeroLimit),
+	}
+	limits.RpmLimit, limits.RpmLimitSource = effectiveLimit(account.RpmLimit)
+	limits.TpmLimit, limits.TpmLimitSource = effectiveLimit(account.TpmLimit)
+	return limits
+}
+
+// zeroLimitMode returns the rate limiter's interpretation of a 0 limit (unlimited without a limiter).
+func (uc *AccountUsecase) zeroLimitMode() ZeroLimitMode {
+	if uc.rateLimiter == nil {
+		return ZeroLimitUnlimited
+	}
+	return uc.rateLimiter.ZeroLimitMode()
+}
+
+// effectiveLimit returns an explicit account limit, or 0 from the default (unlimited, or blocked
+// in ZeroLimitBlocked mode; see limitBlocked).
+func effectiveLimit(limit int32) (int32, v1.ConfigSource) {
+	if limit > 0 {
+		return limit, v1.ConfigSource_CONFIG_SOURCE_EXPLICIT
+	}
+	return 0, v1.ConfigSource_CONFIG_SOURCE_DEFAULT
+}
+
+// effectiveProxy returns the masked proxy URL when the metadata proxy is enabled.
+func effectiveProxy(meta *metadata.AccountMetadata) (string, v1.ConfigSource) {
+	if meta.ProxyEnabled && meta.ProxyURL != "" {
+		return meta.MaskSensitive().ProxyURL, v1.ConfigSource_CONFIG_SOURCE_EXPLICIT
+	}
+	return "", v1.ConfigSource_CONFIG_SOURCE_DEFAULT
+}
+
+// effectiveRequestTimeout mirrors ResolveRequestTimeout and reports which level won.
+func effectiveRequestTimeout(provider data.AccountProvider, meta *metadata.AccountMetadata) (int32, v1.ConfigSource) {
+	source := v1.ConfigSource_CONFIG_SOURCE_DEFAULT
+	switch {
+	case meta.RequestTimeoutMs > 0:
+		source = v1.ConfigSource_CONFIG_SOURCE_EXPLICIT
+	case CapabilitiesOf(provider).RequestTimeout > 0:
+		source = v1.ConfigSource_CONFIG_SOURCE_PROVIDER_DEFAULT
+	}
+	return int32(ResolveRequestTimeout(provider, meta).Milliseconds()), source // #nosec G115 -- bounded by MaxRequestTimeoutMs / provider defaults
+}
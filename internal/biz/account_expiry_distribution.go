@@ -0,0 +1,35 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+)
+
+// GetExpiryDistribution returns how many OAuth accounts fall into each token-expiry bucket
+// (expired, <1h, <6h, <24h, >24h) relative to now, optionally narrowed to a set of providers.
+// Buckets come from a single conditional-aggregation query, so no account rows are loaded.
+func (uc *AccountUsecase) GetExpiryDistribution(ctx context.Context, providers []v1.AccountProvider) (*v1.ExpiryDistribution, error) {
+	var filter []data.AccountProvider
+	for _, p := range providers {
+		if provider := data.ProviderFromProto(p); provider != "" { // UNSPECIFIED 映射为空，不过滤
+			filter = append(filter, provider)
+		}
+	}
+
+	dist, err := uc.repo.GetExpiryDistribution(ctx, filter, uc.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expiry distribution: %w", err)
+	}
+
+	return &v1.ExpiryDistribution{
+		Expired:       dist.Expired,
+		Within1Hour:   dist.Within1Hour,
+		Within6Hours:  dist.Within6Hours,
+		Within24Hours: dist.Within24Hours,
+		Beyond24Hours: dist.Beyond24Hours,
+		Total:         dist.Expired + dist.Within1Hour + dist.Within6Hours + dist.Within24Hours + dist.Beyond24Hours,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetExpiryDistribution(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*AccountUsecase, *MockAccountRepo, *fakeClock) {
+		repo := new(MockAccountRepo)
+		clock := newFakeClock()
+		uc := &AccountUsecase{repo: repo, logger: log.NewHelper(log.DefaultLogger)}
+		uc.SetClock(clock)
+		return uc, repo, clock
+	}
+
+	t.Run("maps providers and sums buckets", func(t *testing.T) {
+		uc, repo, clock := setup()
+		providers := []data.AccountProvider{data.ProviderClaudeOfficial, data.ProviderCodexCLI}
+		repo.On("GetExpiryDistribution", ctx, providers, clock.Now()).Return(&data.ExpiryDistribution{
+			Expired: 2, Within1Hour: 1, Within6Hours: 3, Within24Hours: 4, Beyond24Hours: 5,
+		}, nil)
+
+		dist, err := uc.GetExpiryDistribution(ctx, []v1.AccountProvider{
+			v1.AccountProvider_CLAUDE_OFFICIAL,
+			v1.AccountProvider_ACCOUNT_PROVIDER_UNSPECIFIED,
+			v1.AccountProvider_CODEX_CLI,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &v1.ExpiryDistribution{
+			Expired: 2, Within1Hour: 1, Within6Hours: 3, Within24Hours: 4, Beyond24Hours: 5, Total: 15,
+		}, dist)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("no providers is not filtered", func(t *testing.T) {
+		uc, repo, clock := setup()
+		repo.On("GetExpiryDistribution", ctx, []data.AccountProvider(nil), clock.Now()).Return(&data.ExpiryDistribution{}, nil)
+
+		dist, err := uc.GetExpiryDistribution(ctx, nil)
+		require.NoError(t, err)
+		assert.Zero(t, dist.Total)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		uc, repo, clock := setup()
+		repo.On("GetExpiryDistribution", ctx, []data.AccountProvider(nil), clock.Now()).Return(nil, errors.New("database error"))
+
+		_, err := uc.GetExpiryDistribution(ctx, nil)
+		assert.ErrorContains(t, err, "failed to get expiry distribution")
+	})
+}
@@ -0,0 +1,52 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+)
+
+// GetFleetHealth returns the aggregate health of the account fleet, optionally narrowed to
+// a provider and/or an account group. Counts and averages come from a single GROUP BY status
+// query, so no account rows are loaded.
+func (uc *AccountUsecase) GetFleetHealth(ctx context.Context, provider v1.AccountProvider, groupID int64) (*v1.FleetHealth, error) {
+	filter := data.FleetHealthFilter{
+		Provider: data.ProviderFromProto(provider), // UNSPECIFIED 映射为空，不过滤
+		GroupID:  groupID,
+	}
+
+	stats, err := uc.repo.GetFleetHealthStats(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fleet health stats: %w", err)
+	}
+
+	return computeFleetHealth(stats), nil
+}
+
+// computeFleetHealth combines per-status aggregates into fleet totals.
+// The average health score is weighted by account count; an account is healthy when it is
+// ACTIVE and not circuit broken. Fractions and averages are 0 for an empty fleet.
+func computeFleetHealth(stats []*data.FleetStatusStats) *v1.FleetHealth {
+	health := &v1.FleetHealth{}
+	var scoreSum float64
+	for _, s := range stats {
+		health.TotalAccounts += s.AccountCount
+		health.CircuitBrokenCount += s.CircuitBrokenCount
+		scoreSum += s.AvgHealthScore * float64(s.AccountCount)
+		health.StatusCounts = append(health.StatusCounts, &v1.FleetStatusCount{
+			Status: data.StatusToProto(s.Status),
+			Count:  s.AccountCount,
+		})
+		if s.Status == data.StatusActive {
+			health.HealthyCount += max(s.AccountCount-s.CircuitBrokenCount, 0)
+		}
+	}
+
+	if health.TotalAccounts > 0 {
+		health.AvgHealthScore = scoreSum / float64(health.TotalAccounts)
+		health.HealthyFraction = float64(health.HealthyCount) / float64(health.TotalAccounts)
+	}
+	return health
+}
@@ -0,0 +1,110 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeFleetHealth(t *testing.T) {
+	tests := []struct {
+		name              string
+		stats             []*data.FleetStatusStats
+		wantTotal         int64
+		wantAvgScore      float64
+		wantCircuitBroken int64
+		wantHealthy       int64
+		wantFraction      float64
+	}{
+		{
+			name: "mixed statuses",
+			stats: []*data.FleetStatusStats{
+				{Status: data.StatusActive, AccountCount: 6, AvgHealthScore: 90, CircuitBrokenCount: 1},
+				{Status: data.StatusError, AccountCount: 2, AvgHealthScore: 20, CircuitBrokenCount: 2},
+				{Status: data.StatusCreated, AccountCount: 2, AvgHealthScore: 100},
+			},
+			wantTotal:         10,
+			wantAvgScore:      (6*90 + 2*20 + 2*100) / 10.0,
+			wantCircuitBroken: 3,
+			wantHealthy:       5,
+			wantFraction:      0.5,
+		},
+		{
+			name: "all healthy",
+			stats: []*data.FleetStatusStats{
+				{Status: data.StatusActive, AccountCount: 4, AvgHealthScore: 100},
+			},
+			wantTotal:    4,
+			wantAvgScore: 100,
+			wantHealthy:  4,
+			wantFraction: 1,
+		},
+		{
+			name: "empty fleet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeFleetHealth(tt.stats)
+			assert.Equal(t, tt.wantTotal, got.TotalAccounts)
+			assert.InDelta(t, tt.wantAvgScore, got.AvgHealthScore, 1e-9)
+			assert.Equal(t, tt.wantCircuitBroken, got.CircuitBrokenCount)
+			assert.Equal(t, tt.wantHealthy, got.HealthyCount)
+			assert.InDelta(t, tt.wantFraction, got.HealthyFraction, 1e-9)
+			assert.Len(t, got.StatusCounts, len(tt.stats))
+		})
+	}
+}
+
+func TestGetFleetHealth(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*AccountUsecase, *MockAccountRepo) {
+		repo := new(MockAccountRepo)
+		return &AccountUsecase{repo: repo, logger: log.NewHelper(log.DefaultLogger)}, repo
+	}
+
+	t.Run("passes provider and group filter", func(t *testing.T) {
+		uc, repo := setup()
+		filter := data.FleetHealthFilter{Provider: data.ProviderClaudeConsole, GroupID: 7}
+		repo.On("GetFleetHealthStats", ctx, filter).Return([]*data.FleetStatusStats{
+			{Status: data.StatusActive, AccountCount: 3, AvgHealthScore: 80},
+			{Status: data.StatusError, AccountCount: 1, AvgHealthScore: 40, CircuitBrokenCount: 1},
+		}, nil)
+
+		health, err := uc.GetFleetHealth(ctx, v1.AccountProvider_CLAUDE_CONSOLE, 7)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), health.TotalAccounts)
+		assert.InDelta(t, 0.75, health.HealthyFraction, 1e-9)
+		assert.Equal(t, []*v1.FleetStatusCount{
+			{Status: v1.AccountStatus_ACCOUNT_ACTIVE, Count: 3},
+			{Status: v1.AccountStatus_ACCOUNT_ERROR, Count: 1},
+		}, health.StatusCounts)
+	})
+
+	t.Run("unspecified provider is not filtered", func(t *testing.T) {
+		uc, repo := setup()
+		repo.On("GetFleetHealthStats", ctx, data.FleetHealthFilter{}).Return([]*data.FleetStatusStats{}, nil)
+
+		health, err := uc.GetFleetHealth(ctx, v1.AccountProvider_ACCOUNT_PROVIDER_UNSPECIFIED, 0)
+		require.NoError(t, err)
+		assert.Zero(t, health.HealthyFraction)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("stats query error", func(t *testing.T) {
+		uc, repo := setup()
+		repo.On("GetFleetHealthStats", ctx, data.FleetHealthFilter{}).Return(nil, errors.New("database error"))
+
+		_, err := uc.GetFleetHealth(ctx, v1.AccountProvider_ACCOUNT_PROVIDER_UNSPECIFIED, 0)
+		assert.ErrorContains(t, err, "failed to get fleet health stats")
+	})
+}
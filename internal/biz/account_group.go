@@ -2,6 +2,7 @@ package biz
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"QuotaLane/internal/data"
@@ -20,30 +21,90 @@ type AccountGroupRepo interface {
 	CreateGroup(ctx context.Context, name string, description string, priority int32, accountIDs []int64) (int64, error)
 	GetGroup(ctx context.Context, id int64) (*data.AccountGroupData, error)
 	ListGroups(ctx context.Context, page, pageSize int32) ([]*data.AccountGroupData, int64, error)
+	CountGroupMembers(ctx context.Context, groupIDs []int64) (map[int64]int64, error)
 	UpdateGroup(ctx context.Context, id int64, name string, description string, priority int32, accountIDs []int64) error
+	UpdateGroupRateLimits(ctx context.Context, id int64, rpmLimit, tpmLimit int32) error
+	UpdateGroupConcurrencyLimit(ctx context.Context, id int64, limit int32) error
+	UpdateGroupStrategy(ctx context.Context, id int64, strategy string) error
 	DeleteGroup(ctx context.Context, id int64) error
+	DeleteGroupWithReassign(ctx context.Context, id, targetGroupID int64) error
 	GetAccountGroups(ctx context.Context, accountID int64) ([]*data.AccountGroupData, error)
 	GetAllGroupedAccountIDs(ctx context.Context) ([]int64, error)
 }
 
+// Transactor runs a multi-step operation in a single database transaction.
+// Repository calls made with the ctx passed to fn share the transaction.
+// Implementation is in data layer (data.Transactor).
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 // AccountGroupUseCase handles account group business logic.
 type AccountGroupUseCase struct {
-	repo        AccountGroupRepo
-	accountRepo AccountRepo
-	log         *log.Helper
+	repo          AccountGroupRepo
+	accountRepo   AccountRepo
+	rateLimitRepo RateLimitRepo
+	rand          RandSource // Random source for weighted random selection
+	log           *log.Helper
+
+	rejectDuplicateMembers bool // true: 成员 ID 重复时返回校验错误；false（默认）: 静默去重
+	minHealthScore         int  // 可被选中的最低健康分（0 表示不限制）
+
+	zeroLimit ZeroLimitMode // 账户限额为 0 时的语义，与限流器保持一致（空值表示不限制）
+
+	tx Transactor // 多步写操作的事务（nil 表示不使用事务，逐步写入）
 }
 
 // NewAccountGroupUseCase creates a new account group use case.
 func NewAccountGroupUseCase(
 	repo AccountGroupRepo,
 	accountRepo AccountRepo,
+	rateLimitRepo RateLimitRepo,
 	logger log.Logger,
 ) *AccountGroupUseCase {
 	return &AccountGroupUseCase{
-		repo:        repo,
-		accountRepo: accountRepo,
-		log:         log.NewHelper(log.With(logger, "module", "biz/account-group")),
+		repo:          repo,
+		accountRepo:   accountRepo,
+		rateLimitRepo: rateLimitRepo,
+		rand:          globalRandSource{},
+		log:           log.NewHelper(log.With(logger, "module", "biz/account-group")),
+	}
+}
+
+// SetRejectDuplicateMembers configures how duplicate account IDs in create/update requests
+// are handled: true rejects the request, false (default) silently dedupes.
+func (uc *AccountGroupUseCase) SetRejectDuplicateMembers(reject bool) {
+	uc.rejectDuplicateMembers = reject
+}
+
+// SetMinHealthScore sets the lowest health score an account may have and still be selected
+// from a group (0, the default, disables the check; circuit-broken accounts are always skipped).
+func (uc *AccountGroupUseCase) SetMinHealthScore(score int) {
+	uc.minHealthScore = max(score, 0)
+}
+
+// normalizeMemberIDs removes duplicate account IDs, keeping first-occurrence order.
+// Duplicates would violate the members composite primary key and roll back the whole
+// insert, so they are either removed or rejected up front.
+func (uc *AccountGroupUseCase) normalizeMemberIDs(accountIDs []int64) ([]int64, error) {
+	seen := make(map[int64]struct{}, len(accountIDs))
+	deduped := make([]int64, 0, len(accountIDs))
+	for _, id := range accountIDs {
+		if _, ok := seen[id]; ok {
+			if uc.rejectDuplicateMembers {
+				return nil, NewValidationError(fmt.Sprintf("账户 ID 重复: %d", id))
+			}
+			continue
+		}
+		seen[id] = struct{}{}
+		deduped = append(deduped, id)
+	}
+
+	if len(deduped) < len(accountIDs) {
+		uc.log.Infof("removed %d duplicate account IDs from group members", len(accountIDs)-len(deduped))
 	}
+
+	return deduped, nil
 }
 
 // CreateAccountGroup creates a new account group.
@@ -66,6 +127,11 @@ func (uc *AccountGroupUseCase) CreateAccountGroup(
 		}
 	}
 
+	accountIDs, err = uc.normalizeMemberIDs(accountIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate account IDs exist
 	if len(accountIDs) > 0 {
 		for _, accountID := range accountIDs {
@@ -98,6 +164,76 @@ func (uc *AccountGroupUseCase) CreateAccountGroup(
 	return group, nil
 }
 
+// SetZeroLimitMode sets how an account RPM/TPM limit of 0 is interpreted by selection. It must
+// match the rate limiter's mode: in ZeroLimitBlocked mode such accounts have no headroom and are
+// never selected.
+func (uc *AccountGroupUseCase) SetZeroLimitMode(mode ZeroLimitMode) {
+	uc.zeroLimit = mode
+}
+
+// SetTransactor 设置多步写操作使用的事务（nil 表示不使用事务）
+func (uc *AccountGroupUseCase) SetTransactor(tx Transactor) {
+	uc.tx = tx
+}
+
+// inTransaction runs fn in a transaction when a Transactor is set, otherwise directly.
+func (uc *AccountGroupUseCase) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if uc.tx == nil {
+		return fn(ctx)
+	}
+	return uc.tx.WithTransaction(ctx, fn)
+}
+
+// CreateAccountGroupWithLimits creates a group with its members, initial group-wide
+// RPM/TPM and concurrency caps and selection strategy in one transaction, so a failure while
+// applying them leaves no half-configured group behind. Zero limits and an empty strategy are left unset.
+func (uc *AccountGroupUseCase) CreateAccountGroupWithLimits(
+	ctx context.Context,
+	name string,
+	description string,
+	priority int32,
+	accountIDs []int64,
+	rpmLimit, tpmLimit, concurrencyLimit int32,
+	strategy string,
+) (*AccountGroup, error) {
+	var group *AccountGroup
+	err := uc.inTransaction(ctx, func(ctx context.Context) error {
+		created, err := uc.CreateAccountGroup(ctx, name, description, priority, accountIDs)
+		if err != nil {
+			return err
+		}
+
+		if rpmLimit != 0 || tpmLimit != 0 {
+			if err := uc.SetAccountGroupRateLimits(ctx, created.ID, rpmLimit, tpmLimit); err != nil {
+				return err
+			}
+			created.RpmLimit = rpmLimit
+			created.TpmLimit = tpmLimit
+		}
+
+		if concurrencyLimit != 0 {
+			if err := uc.SetAccountGroupConcurrencyLimit(ctx, created.ID, concurrencyLimit); err != nil {
+				return err
+			}
+			created.ConcurrencyLimit = concurrencyLimit
+		}
+
+		if strategy != "" {
+			if err := uc.SetAccountGroupStrategy(ctx, created.ID, strategy); err != nil {
+				return err
+			}
+			created.Strategy = strategy
+		}
+
+		group = created
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
 // GetAccountGroup retrieves a group by ID with full details.
 func (uc *AccountGroupUseCase) GetAccountGroup(ctx context.Context, id int64) (*AccountGroup, error) {
 	group, err := uc.repo.GetGroup(ctx, id)
@@ -109,8 +245,27 @@ func (uc *AccountGroupUseCase) GetAccountGroup(ctx context.Context, id int64) (*
 }
 
 // ListAccountGroups retrieves a paginated list of groups.
-func (uc *AccountGroupUseCase) ListAccountGroups(ctx context.Context, page, pageSize int32) ([]*AccountGroup, int64, error) {
-	return uc.repo.ListGroups(ctx, page, pageSize)
+// When includeMemberCounts is set, member counts are attached with one grouped query for the page.
+func (uc *AccountGroupUseCase) ListAccountGroups(ctx context.Context, page, pageSize int32, includeMemberCounts bool) ([]*AccountGroup, int64, error) {
+	groups, total, err := uc.repo.ListGroups(ctx, page, pageSize)
+	if err != nil || !includeMemberCounts || len(groups) == 0 {
+		return groups, total, err
+	}
+
+	groupIDs := make([]int64, len(groups))
+	for i, g := range groups {
+		groupIDs[i] = g.ID
+	}
+	counts, err := uc.repo.CountGroupMembers(ctx, groupIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, g := range groups {
+		count := counts[g.ID]
+		g.MemberCount = &count
+	}
+
+	return groups, total, nil
 }
 
 // UpdateAccountGroup updates an existing group.
@@ -141,6 +296,11 @@ func (uc *AccountGroupUseCase) UpdateAccountGroup(
 		}
 	}
 
+	accountIDs, err = uc.normalizeMemberIDs(accountIDs)
+	if err != nil {
+		return err
+	}
+
 	// Validate new account IDs
 	if len(accountIDs) > 0 {
 		for _, accountID := range accountIDs {
@@ -162,6 +322,53 @@ func (uc *AccountGroupUseCase) UpdateAccountGroup(
 	return nil
 }
 
+// SetAccountGroupRateLimits sets the group-wide RPM/TPM caps shared by all member accounts.
+// The caps apply on top of each account's own limits; 0 removes a cap.
+func (uc *AccountGroupUseCase) SetAccountGroupRateLimits(ctx context.Context, id int64, rpmLimit, tpmLimit int32) error {
+	if rpmLimit < 0 || tpmLimit < 0 {
+		return NewValidationError("账户组限流上限不能为负数")
+	}
+
+	if err := uc.repo.UpdateGroupRateLimits(ctx, id, rpmLimit, tpmLimit); err != nil {
+		return err
+	}
+
+	uc.log.Infof("updated account group rate limits: id=%d, rpm_limit=%d, tpm_limit=%d", id, rpmLimit, tpmLimit)
+	return nil
+}
+
+// SetAccountGroupConcurrencyLimit sets the group-wide cap on in-flight requests shared by
+// all member accounts. The cap applies on top of each account's own slot limit; 0 removes it.
+func (uc *AccountGroupUseCase) SetAccountGroupConcurrencyLimit(ctx context.Context, id int64, limit int32) error {
+	if limit < 0 {
+		return NewValidationError("账户组并发上限不能为负数")
+	}
+
+	if err := uc.repo.UpdateGroupConcurrencyLimit(ctx, id, limit); err != nil {
+		return err
+	}
+
+	uc.log.Infof("updated account group concurrency limit: id=%d, concurrency_limit=%d", id, limit)
+	return nil
+}
+
+// SetAccountGroupStrategy sets the strategy SelectAccountByStrategy uses to pick an account
+// from the group. An empty strategy restores the default (least_loaded).
+func (uc *AccountGroupUseCase) SetAccountGroupStrategy(ctx context.Context, id int64, strategy string) error {
+	if strategy != "" {
+		if _, err := ParseSelectionStrategy(strategy); err != nil {
+			return NewValidationError(err.Error())
+		}
+	}
+
+	if err := uc.repo.UpdateGroupStrategy(ctx, id, strategy); err != nil {
+		return err
+	}
+
+	uc.log.Infof("updated account group selection strategy: id=%d, strategy=%q", id, strategy)
+	return nil
+}
+
 // DeleteAccountGroup soft deletes a group.
 func (uc *AccountGroupUseCase) DeleteAccountGroup(ctx context.Context, id int64) error {
 	// Verify group exists
@@ -179,6 +386,29 @@ func (uc *AccountGroupUseCase) DeleteAccountGroup(ctx context.Context, id int64)
 	return nil
 }
 
+// DeleteAccountGroupWithReassign soft deletes a group and moves its members to another group
+// in the same transaction, so accounts are not left ungrouped.
+func (uc *AccountGroupUseCase) DeleteAccountGroupWithReassign(ctx context.Context, id, targetGroupID int64) error {
+	if targetGroupID == id {
+		return NewValidationError("目标账户组不能是被删除的账户组")
+	}
+
+	// Verify group exists
+	group, err := uc.repo.GetGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.DeleteGroupWithReassign(ctx, id, targetGroupID); err != nil {
+		return err
+	}
+
+	uc.log.Infof("deleted account group: id=%d, name=%s, members=%d reassigned to group %d",
+		id, group.Name, len(group.AccountIDs), targetGroupID)
+
+	return nil
+}
+
 // GetAccountsByGroup retrieves all accounts in a group.
 func (uc *AccountGroupUseCase) GetAccountsByGroup(ctx context.Context, groupID int64) ([]*Account, error) {
 	group, err := uc.repo.GetGroup(ctx, groupID)
@@ -0,0 +1,374 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"QuotaLane/internal/data"
+)
+
+// ErrNoAvailableAccount is returned when no account in a group can serve a request.
+var ErrNoAvailableAccount = errors.New("no available account in group")
+
+// ErrEmptyGroup is returned when selecting from a group without members. It wraps
+// ErrNoAvailableAccount so callers checking for that keep working.
+var ErrEmptyGroup = fmt.Errorf("%w: group has no members", ErrNoAvailableAccount)
+
+// SelectionStrategy determines how an account is picked among the available accounts of a group.
+type SelectionStrategy string
+
+const (
+	// StrategyLeastLoaded picks the account with the most combined RPM + TPM headroom.
+	StrategyLeastLoaded SelectionStrategy = "least_loaded"
+	// StrategyWeightedRandom picks a random account weighted by its RpmLimit.
+	StrategyWeightedRandom SelectionStrategy = "weighted_random"
+	// StrategyHealthWeighted picks a random account weighted by its live health score,
+	// so healthier accounts receive proportionally more traffic.
+	StrategyHealthWeighted SelectionStrategy = "health_weighted"
+	// StrategyRoundRobin rotates through the available accounts in group order, giving each
+	// equal traffic regardless of health or load. The rotation is shared across instances via Redis.
+	StrategyRoundRobin SelectionStrategy = "round_robin"
+)
+
+// ParseSelectionStrategy parses a group's selection strategy; empty means StrategyLeastLoaded.
+func ParseSelectionStrategy(strategy string) (SelectionStrategy, error) {
+	switch s := SelectionStrategy(strategy); s {
+	case "":
+		return StrategyLeastLoaded, nil
+	case StrategyLeastLoaded, StrategyWeightedRandom, StrategyHealthWeighted, StrategyRoundRobin:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unknown selection strategy: %s", strategy)
+	}
+}
+
+// RandSource is the random number source used by weighted random selection.
+// *rand.Rand from math/rand/v2 satisfies it; inject a seeded one for reproducible selection.
+type RandSource interface {
+	Int64N(n int64) int64
+}
+
+// globalRandSource uses the math/rand/v2 global generator (ChaCha8, seeded from the OS).
+type globalRandSource struct{}
+
+func (globalRandSource) Int64N(n int64) int64 {
+	return rand.Int64N(n)
+}
+
+// SetRandSource replaces the random source used by weighted random selection.
+// Passing nil restores the default global source.
+func (uc *AccountGroupUseCase) SetRandSource(r RandSource) {
+	if r == nil {
+		r = globalRandSource{}
+	}
+	uc.rand = r
+}
+
+// selectionCandidate is an available account together with its headroom score.
+type selectionCandidate struct {
+	account *data.Account
+	score   float64
+}
+
+// SelectAccount selects the least-loaded account from a group.
+// Load is measured as RPM and TPM headroom (remaining/limit); the account with the
+// most combined headroom wins. Accounts rejected by isSelectable and exhausted accounts
+// (zero headroom on either dimension) are skipped.
+func (uc *AccountGroupUseCase) SelectAccount(ctx context.Context, groupID int64) (*data.Account, error) {
+	return uc.SelectAccountWithStrategy(ctx, groupID, StrategyLeastLoaded)
+}
+
+// SelectAccountWithStrategy selects an account from a group using the given strategy.
+// The same availability rules (isSelectable) apply to every strategy.
+func (uc *AccountGroupUseCase) SelectAccountWithStrategy(ctx context.Context, groupID int64, strategy SelectionStrategy) (*data.Account, error) {
+	return uc.SelectAccountForCategory(ctx, groupID, strategy, "")
+}
+
+// SelectHealthWeighted selects an account from a group at random, weighted by health score.
+// Circuit-broken and otherwise unselectable accounts are skipped; ErrEmptyGroup is returned for a
+// group without members and ErrNoAvailableAccount when no member is healthy enough to serve.
+func (uc *AccountGroupUseCase) SelectHealthWeighted(ctx context.Context, groupID int64) (*data.Account, error) {
+	return uc.SelectAccountWithStrategy(ctx, groupID, StrategyHealthWeighted)
+}
+
+// SelectAccountByStrategy selects an account from a group using the strategy configured on the
+// group (least_loaded when unset).
+func (uc *AccountGroupUseCase) SelectAccountByStrategy(ctx context.Context, groupID int64) (*data.Account, error) {
+	group, err := uc.repo.GetGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, err := ParseSelectionStrategy(group.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	return uc.selectFromGroup(ctx, group, strategy, "")
+}
+
+// SelectAccountForCategory selects an account for a request of the given category.
+// Only accounts whose metadata.allowed_categories permits the category are considered
+// (an empty list permits all); an empty category applies no restriction.
+func (uc *AccountGroupUseCase) SelectAccountForCategory(ctx context.Context, groupID int64, strategy SelectionStrategy, category string) (*data.Account, error) {
+	if _, err := ParseSelectionStrategy(string(strategy)); err != nil || strategy == "" {
+		return nil, fmt.Errorf("unknown selection strategy: %s", strategy)
+	}
+
+	group, err := uc.repo.GetGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.selectFromGroup(ctx, group, strategy, category)
+}
+
+// selectFromGroup picks an account of group for a request of the given category using strategy.
+func (uc *AccountGroupUseCase) selectFromGroup(ctx context.Context, group *AccountGroup, strategy SelectionStrategy, category string) (*data.Account, error) {
+	candidates, err := uc.availableAccounts(ctx, group, category)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoAvailableAccount
+	}
+
+	switch strategy {
+	case StrategyWeightedRandom:
+		return uc.pickWeightedRandom(candidates), nil
+	case StrategyHealthWeighted:
+		return uc.pickHealthWeighted(candidates)
+	case StrategyRoundRobin:
+		return uc.pickRoundRobin(ctx, group.ID, candidates), nil
+	default:
+		return pickLeastLoaded(candidates), nil
+	}
+}
+
+// availableAccounts returns the accounts of a group that can currently serve requests of the given category.
+func (uc *AccountGroupUseCase) availableAccounts(ctx context.Context, group *AccountGroup, category string) ([]selectionCandidate, error) {
+	groupID := group.ID
+	if len(group.AccountIDs) == 0 {
+		return nil, ErrEmptyGroup
+	}
+
+	candidates := make([]selectionCandidate, 0, len(group.AccountIDs))
+	for _, accountID := range group.AccountIDs {
+		account, err := uc.accountRepo.GetAccount(ctx, accountID)
+		if err != nil {
+			uc.log.Warnf("failed to get account %d: %v", accountID, err)
+			continue // Skip missing accounts (might be deleted)
+		}
+
+		if !uc.isSelectable(account) {
+			continue
+		}
+
+		if !uc.allowsCategory(account, category) {
+			uc.log.Debugw("account skipped: category not allowed",
+				"group_id", groupID,
+				"account_id", account.ID,
+				"category", category)
+			continue
+		}
+
+		score, ok := uc.headroomScore(ctx, account)
+		if !ok {
+			uc.log.Debugw("account skipped: rate limit exhausted",
+				"group_id", groupID,
+				"account_id", account.ID)
+			continue
+		}
+
+		candidates = append(candidates, selectionCandidate{account: account, score: score})
+	}
+
+	return candidates, nil
+}
+
+// isSelectable reports whether an account may receive new requests at all, regardless of load.
+// It is the single availability rule shared by every selection strategy: the account must be
+// active (inactive accounts are disabled, error/created/validating accounts are not serving),
+// not circuit-broken, not draining, not awaiting re-authorization, and at or above the
+// configured minimum health score.
+func (uc *AccountGroupUseCase) isSelectable(account *data.Account) bool {
+	return account.Status == data.StatusActive &&
+		!account.IsCircuitBroken &&
+		!account.IsDraining &&
+		!account.NeedsReauth &&
+		account.HealthScore >= uc.minHealthScore
+}
+
+// allowsCategory reports whether the account permits the request category.
+// Unparseable metadata is treated as unrestricted so a bad metadata blob does not take the account out of rotation.
+func (uc *AccountGroupUseCase) allowsCategory(account *data.Account, category string) bool {
+	if category == "" {
+		return true
+	}
+
+	meta, err := data.ParseMetadata(account.Metadata)
+	if err != nil {
+		uc.log.Warnf("failed to parse metadata of account %d, ignoring category restriction: %v", account.ID, err)
+		return true
+	}
+	return meta.AllowsCategory(category)
+}
+
+// pickLeastLoaded returns the candidate with the highest headroom score.
+// Ties are broken by health score, then by AccountPriority (higher wins), then by group order.
+func pickLeastLoaded(candidates []selectionCandidate) *data.Account {
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if betterLeastLoaded(c, best) {
+			best = c
+		}
+	}
+	return best.account
+}
+
+// betterLeastLoaded reports whether c should be preferred over best by least-loaded selection.
+func betterLeastLoaded(c, best selectionCandidate) bool {
+	if c.score != best.score {
+		return c.score > best.score
+	}
+	if c.account.HealthScore != best.account.HealthScore {
+		return c.account.HealthScore > best.account.HealthScore
+	}
+	return c.account.AccountPriority > best.account.AccountPriority
+}
+
+// pickWeightedRandom returns a random candidate with probability proportional to its RpmLimit.
+// Unlimited accounts (RpmLimit <= 0) are weighted like the largest limited account
+// among the candidates, or 1 if every candidate is unlimited. Accounts blocked by a 0 limit
+// never get here: availableAccounts drops them for having no headroom.
+func (uc *AccountGroupUseCase) pickWeightedRandom(candidates []selectionCandidate) *data.Account {
+	var maxWeight int64 = 1
+	for _, c := range candidates {
+		maxWeight = max(maxWeight, int64(c.account.RpmLimit))
+	}
+
+	weights := make([]int64, len(candidates))
+	for i, c := range candidates {
+		weight := int64(c.account.RpmLimit)
+		if weight <= 0 {
+			weight = maxWeight
+		}
+		weights[i] = weight
+	}
+
+	return uc.pickWeighted(candidates, weights)
+}
+
+// pickRoundRobin returns the next candidate in the group's rotation. The rotation index is a
+// Redis INCR counter per group taken modulo the number of candidates, so unavailable (e.g.
+// circuit-broken) members are skipped and the rotation advances past them.
+// Redis degradation: if the counter cannot be advanced, a random candidate is picked.
+func (uc *AccountGroupUseCase) pickRoundRobin(ctx context.Context, groupID int64, candidates []selectionCandidate) *data.Account {
+	r := uc.rand
+	if r == nil {
+		r = globalRandSource{}
+	}
+
+	if uc.rateLimitRepo == nil {
+		return candidates[r.Int64N(int64(len(candidates)))].account
+	}
+
+	n, err := uc.rateLimitRepo.IncrementGroupRoundRobin(ctx, groupID)
+	if err != nil {
+		uc.log.Warnw("failed to advance round-robin counter, picking a random account",
+			"group_id", groupID,
+			"error", err)
+		return candidates[r.Int64N(int64(len(candidates)))].account
+	}
+
+	idx := (n - 1) % int64(len(candidates))
+	if idx < 0 {
+		idx += int64(len(candidates))
+	}
+	return candidates[idx].account
+}
+
+// pickHealthWeighted returns a random candidate with probability proportional to its health score
+// (clamped to 0-100). Candidates at health 0 are never picked; if no candidate has a positive
+// score, ErrNoAvailableAccount is returned.
+func (uc *AccountGroupUseCase) pickHealthWeighted(candidates []selectionCandidate) (*data.Account, error) {
+	weights := make([]int64, len(candidates))
+	var total int64
+	for i, c := range candidates {
+		weights[i] = int64(data.ClampHealthScore(c.account.HealthScore))
+		total += weights[i]
+	}
+	if total == 0 {
+		return nil, ErrNoAvailableAccount
+	}
+
+	return uc.pickWeighted(candidates, weights), nil
+}
+
+// pickWeighted returns a random candidate with probability proportional to weights[i].
+// The total weight must be positive.
+func (uc *AccountGroupUseCase) pickWeighted(candidates []selectionCandidate, weights []int64) *data.Account {
+	var total int64
+	for _, weight := range weights {
+		total += weight
+	}
+
+	r := uc.rand
+	if r == nil {
+		r = globalRandSource{}
+	}
+
+	n := r.Int64N(total)
+	for i, weight := range weights {
+		if n < weight {
+			return candidates[i].account
+		}
+		n -= weight
+	}
+
+	return candidates[len(candidates)-1].account
+}
+
+// headroomScore returns the combined RPM + TPM headroom of an account (0-2).
+// ok is false when either dimension is exhausted, or blocked by a 0 limit in ZeroLimitBlocked mode.
+// Redis degradation: if usage counters cannot be read, the account is treated as idle.
+func (uc *AccountGroupUseCase) headroomScore(ctx context.Context, account *data.Account) (score float64, ok bool) {
+	var rpm, tpm int32
+	if uc.rateLimitRepo != nil {
+		var err error
+		rpm, tpm, err = uc.rateLimitRepo.GetUsageCounts(ctx, account.ID)
+		if err != nil {
+			uc.log.Warnw("failed to read usage counts, assuming full headroom",
+				"account_id", account.ID,
+				"error", err)
+			rpm, tpm = 0, 0
+		}
+	}
+
+	rpmHeadroom := headroom(rpm, account.RpmLimit, uc.zeroLimit)
+	tpmHeadroom := headroom(tpm, account.TpmLimit, uc.zeroLimit)
+	if rpmHeadroom <= 0 || tpmHeadroom <= 0 {
+		return 0, false
+	}
+
+	return rpmHeadroom + tpmHeadroom, true
+}
+
+// headroom returns the remaining fraction of a limit (0-1).
+// A limit <= 0 means unlimited and always yields full headroom, except a limit of exactly 0 in
+// ZeroLimitBlocked mode, which admits no requests and yields none.
+func headroom(used, limit int32, zeroLimit ZeroLimitMode) float64 {
+	if limitBlocked(limit, zeroLimit) {
+		return 0
+	}
+	if limit <= 0 {
+		return 1
+	}
+
+	remaining := limit - used
+	if remaining <= 0 {
+		return 0
+	}
+
+	return float64(remaining) / float64(limit)
+}
@@ -0,0 +1,595 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"testing"
+
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAccountGroupRepo is a mock implementation of AccountGroupRepo for testing.
+type MockAccountGroupRepo struct {
+	mock.Mock
+}
+
+func (m *MockAccountGroupRepo) CreateGroup(ctx context.Context, name string, description string, priority int32, accountIDs []int64) (int64, error) {
+	args := m.Called(ctx, name, description, priority, accountIDs)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountGroupRepo) GetGroup(ctx context.Context, id int64) (*data.AccountGroupData, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*data.AccountGroupData), args.Error(1)
+}
+
+func (m *MockAccountGroupRepo) ListGroups(ctx context.Context, page, pageSize int32) ([]*data.AccountGroupData, int64, error) {
+	args := m.Called(ctx, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*data.AccountGroupData), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockAccountGroupRepo) CountGroupMembers(ctx context.Context, groupIDs []int64) (map[int64]int64, error) {
+	args := m.Called(ctx, groupIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int64]int64), args.Error(1)
+}
+
+func (m *MockAccountGroupRepo) UpdateGroup(ctx context.Context, id int64, name string, description string, priority int32, accountIDs []int64) error {
+	args := m.Called(ctx, id, name, description, priority, accountIDs)
+	return args.Error(0)
+}
+
+func (m *MockAccountGroupRepo) UpdateGroupRateLimits(ctx context.Context, id int64, rpmLimit, tpmLimit int32) error {
+	args := m.Called(ctx, id, rpmLimit, tpmLimit)
+	return args.Error(0)
+}
+
+func (m *MockAccountGroupRepo) UpdateGroupConcurrencyLimit(ctx context.Context, id int64, limit int32) error {
+	args := m.Called(ctx, id, limit)
+	return args.Error(0)
+}
+
+func (m *MockAccountGroupRepo) UpdateGroupStrategy(ctx context.Context, id int64, strategy string) error {
+	args := m.Called(ctx, id, strategy)
+	return args.Error(0)
+}
+
+func (m *MockAccountGroupRepo) DeleteGroup(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAccountGroupRepo) DeleteGroupWithReassign(ctx context.Context, id, targetGroupID int64) error {
+	args := m.Called(ctx, id, targetGroupID)
+	return args.Error(0)
+}
+
+func (m *MockAccountGroupRepo) GetAccountGroups(ctx context.Context, accountID int64) ([]*data.AccountGroupData, error) {
+	args := m.Called(ctx, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*data.AccountGroupData), args.Error(1)
+}
+
+func (m *MockAccountGroupRepo) GetAllGroupedAccountIDs(ctx context.Context) ([]int64, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+// setupSelectTest creates an AccountGroupUseCase with a single group containing the given accounts.
+func setupSelectTest(accounts ...*data.Account) (*AccountGroupUseCase, *MockAccountRepo, *MockRateLimitRepo) {
+	groupRepo := new(MockAccountGroupRepo)
+	accountRepo := new(MockAccountRepo)
+	rateLimitRepo := new(MockRateLimitRepo)
+
+	ids := make([]int64, 0, len(accounts))
+	for _, a := range accounts {
+		ids = append(ids, a.ID)
+		accountRepo.On("GetAccount", mock.Anything, a.ID).Return(a, nil)
+	}
+	groupRepo.On("GetGroup", mock.Anything, int64(1)).Return(&data.AccountGroupData{ID: 1, AccountIDs: ids}, nil)
+
+	uc := NewAccountGroupUseCase(groupRepo, accountRepo, rateLimitRepo, log.DefaultLogger)
+	return uc, accountRepo, rateLimitRepo
+}
+
+func TestSelectAccount_MostCombinedHeadroom(t *testing.T) {
+	a := &data.Account{ID: 1, Status: data.StatusActive, RpmLimit: 100, TpmLimit: 10000}
+	b := &data.Account{ID: 2, Status: data.StatusActive, RpmLimit: 100, TpmLimit: 10000}
+	uc, _, rateLimitRepo := setupSelectTest(a, b)
+
+	// a: 50% RPM + 90% TPM = 1.4, b: 80% RPM + 40% TPM = 1.2
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(1)).Return(int32(50), int32(1000), nil)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(2)).Return(int32(20), int32(6000), nil)
+
+	selected, err := uc.SelectAccount(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), selected.ID)
+}
+
+func TestSelectAccount_TieBrokenByAccountPriority(t *testing.T) {
+	// Equal load and health: the higher account priority wins regardless of group order
+	low := &data.Account{ID: 1, Status: data.StatusActive, HealthScore: 90, AccountPriority: 1}
+	high := &data.Account{ID: 2, Status: data.StatusActive, HealthScore: 90, AccountPriority: 5}
+	unset := &data.Account{ID: 3, Status: data.StatusActive, HealthScore: 90}
+	uc, _, rateLimitRepo := setupSelectTest(low, high, unset)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, mock.Anything).Return(int32(0), int32(0), nil)
+
+	selected, err := uc.SelectAccount(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), selected.ID)
+}
+
+func TestSelectAccount_TieBreakOrder(t *testing.T) {
+	a := &data.Account{ID: 1, Status: data.StatusActive, RpmLimit: 100, HealthScore: 80, AccountPriority: 10}
+	b := &data.Account{ID: 2, Status: data.StatusActive, RpmLimit: 100, HealthScore: 100}
+	c := &data.Account{ID: 3, Status: data.StatusActive, RpmLimit: 100, HealthScore: 100}
+
+	// Equal load: health beats priority, and equal health/priority keeps group order
+	uc, _, rateLimitRepo := setupSelectTest(a, b, c)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, mock.Anything).Return(int32(10), int32(0), nil)
+	selected, err := uc.SelectAccount(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), selected.ID)
+
+	// More headroom beats both health and priority
+	uc, _, rateLimitRepo = setupSelectTest(a, b, c)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(1)).Return(int32(0), int32(0), nil)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, mock.Anything).Return(int32(10), int32(0), nil)
+	selected, err = uc.SelectAccount(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), selected.ID)
+}
+
+func TestSelectAccount_SkipsExhaustedTPM(t *testing.T) {
+	// Lowest RPM usage, but TPM is exhausted
+	idleRPM := &data.Account{ID: 1, Status: data.StatusActive, RpmLimit: 100, TpmLimit: 10000}
+	busy := &data.Account{ID: 2, Status: data.StatusActive, RpmLimit: 100, TpmLimit: 10000}
+	uc, _, rateLimitRepo := setupSelectTest(idleRPM, busy)
+
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(1)).Return(int32(1), int32(10000), nil)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(2)).Return(int32(90), int32(9000), nil)
+
+	selected, err := uc.SelectAccount(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), selected.ID)
+}
+
+func TestSelectAccount_SkipsUnavailableAccounts(t *testing.T) {
+	inactive := &data.Account{ID: 1, Status: data.StatusInactive}
+	broken := &data.Account{ID: 2, Status: data.StatusActive, IsCircuitBroken: true}
+	exhaustedRPM := &data.Account{ID: 3, Status: data.StatusActive, RpmLimit: 10}
+	unverified := &data.Account{ID: 4, Status: data.StatusCreated}
+	uc, _, rateLimitRepo := setupSelectTest(inactive, broken, exhaustedRPM, unverified)
+
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(3)).Return(int32(10), int32(0), nil)
+
+	selected, err := uc.SelectAccount(context.Background(), 1)
+	assert.Nil(t, selected)
+	assert.True(t, errors.Is(err, ErrNoAvailableAccount))
+	rateLimitRepo.AssertNotCalled(t, "GetUsageCounts", mock.Anything, int64(1))
+	rateLimitRepo.AssertNotCalled(t, "GetUsageCounts", mock.Anything, int64(2))
+	rateLimitRepo.AssertNotCalled(t, "GetUsageCounts", mock.Anything, int64(4))
+}
+
+func TestSelectAccount_RedisFailureDegrades(t *testing.T) {
+	a := &data.Account{ID: 1, Status: data.StatusActive, RpmLimit: 100, TpmLimit: 10000}
+	uc, _, rateLimitRepo := setupSelectTest(a)
+
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(1)).Return(int32(0), int32(0), errors.New("redis down"))
+
+	selected, err := uc.SelectAccount(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), selected.ID)
+}
+
+func TestSelectAccountWithStrategy_WeightedRandomDistribution(t *testing.T) {
+	a := &data.Account{ID: 1, Status: data.StatusActive, RpmLimit: 100}
+	b := &data.Account{ID: 2, Status: data.StatusActive, RpmLimit: 300}
+	c := &data.Account{ID: 3, Status: data.StatusActive, RpmLimit: 600}
+	uc, _, rateLimitRepo := setupSelectTest(a, b, c)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, mock.Anything).Return(int32(0), int32(0), nil)
+	uc.SetRandSource(rand.New(rand.NewPCG(42, 1024)))
+
+	const n = 10000
+	counts := make(map[int64]int)
+	for i := 0; i < n; i++ {
+		selected, err := uc.SelectAccountWithStrategy(context.Background(), 1, StrategyWeightedRandom)
+		require.NoError(t, err)
+		counts[selected.ID]++
+	}
+
+	assert.InDelta(t, 0.1, float64(counts[1])/n, 0.02)
+	assert.InDelta(t, 0.3, float64(counts[2])/n, 0.02)
+	assert.InDelta(t, 0.6, float64(counts[3])/n, 0.02)
+}
+
+func TestSelectAccountWithStrategy_WeightedRandomReproducible(t *testing.T) {
+	accounts := []*data.Account{
+		{ID: 1, Status: data.StatusActive, RpmLimit: 10},
+		{ID: 2, Status: data.StatusActive, RpmLimit: 20},
+		{ID: 3, Status: data.StatusActive}, // unlimited, weighted like the largest (20)
+	}
+	uc, _, rateLimitRepo := setupSelectTest(accounts...)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, mock.Anything).Return(int32(0), int32(0), nil)
+
+	run := func() []int64 {
+		uc.SetRandSource(rand.New(rand.NewPCG(7, 7)))
+		ids := make([]int64, 0, 50)
+		for i := 0; i < 50; i++ {
+			selected, err := uc.SelectAccountWithStrategy(context.Background(), 1, StrategyWeightedRandom)
+			require.NoError(t, err)
+			ids = append(ids, selected.ID)
+		}
+		return ids
+	}
+
+	first := run()
+	assert.Equal(t, first, run())
+	assert.Contains(t, first, int64(3))
+}
+
+func TestSelectAccountWithStrategy_WeightedRandomSkipsUnavailable(t *testing.T) {
+	broken := &data.Account{ID: 1, Status: data.StatusActive, RpmLimit: 1000, IsCircuitBroken: true}
+	ok := &data.Account{ID: 2, Status: data.StatusActive, RpmLimit: 1}
+	uc, _, rateLimitRepo := setupSelectTest(broken, ok)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(2)).Return(int32(0), int32(0), nil)
+	uc.SetRandSource(rand.New(rand.NewPCG(1, 2)))
+
+	for i := 0; i < 20; i++ {
+		selected, err := uc.SelectAccountWithStrategy(context.Background(), 1, StrategyWeightedRandom)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), selected.ID)
+	}
+}
+
+func TestSelectHealthWeighted_Distribution(t *testing.T) {
+	a := &data.Account{ID: 1, Status: data.StatusActive, HealthScore: 20}
+	b := &data.Account{ID: 2, Status: data.StatusActive, HealthScore: 80}
+	broken := &data.Account{ID: 3, Status: data.StatusActive, HealthScore: 100, IsCircuitBroken: true}
+	uc, _, rateLimitRepo := setupSelectTest(a, b, broken)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, mock.Anything).Return(int32(0), int32(0), nil)
+	uc.SetRandSource(rand.New(rand.NewPCG(42, 1024)))
+
+	const n = 10000
+	counts := make(map[int64]int)
+	for i := 0; i < n; i++ {
+		selected, err := uc.SelectHealthWeighted(context.Background(), 1)
+		require.NoError(t, err)
+		counts[selected.ID]++
+	}
+
+	assert.InDelta(t, 0.2, float64(counts[1])/n, 0.02)
+	assert.InDelta(t, 0.8, float64(counts[2])/n, 0.02)
+	assert.Zero(t, counts[3])
+}
+
+func TestSelectHealthWeighted_SkipsZeroHealth(t *testing.T) {
+	dead := &data.Account{ID: 1, Status: data.StatusActive, HealthScore: 0}
+	alive := &data.Account{ID: 2, Status: data.StatusActive, HealthScore: 1}
+	uc, _, rateLimitRepo := setupSelectTest(dead, alive)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, mock.Anything).Return(int32(0), int32(0), nil)
+	uc.SetRandSource(rand.New(rand.NewPCG(3, 5)))
+
+	for i := 0; i < 20; i++ {
+		selected, err := uc.SelectHealthWeighted(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), selected.ID)
+	}
+}
+
+func TestSelectHealthWeighted_AllUnhealthy(t *testing.T) {
+	a := &data.Account{ID: 1, Status: data.StatusActive, HealthScore: 0}
+	b := &data.Account{ID: 2, Status: data.StatusActive, HealthScore: 100, IsCircuitBroken: true}
+	uc, _, rateLimitRepo := setupSelectTest(a, b)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(1)).Return(int32(0), int32(0), nil)
+
+	selected, err := uc.SelectHealthWeighted(context.Background(), 1)
+	assert.Nil(t, selected)
+	assert.ErrorIs(t, err, ErrNoAvailableAccount)
+	assert.NotErrorIs(t, err, ErrEmptyGroup)
+}
+
+func TestSelectAccountWithStrategy_EmptyGroup(t *testing.T) {
+	for _, strategy := range allStrategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			uc, _, _ := setupSelectTest()
+
+			selected, err := uc.SelectAccountWithStrategy(context.Background(), 1, strategy)
+			assert.Nil(t, selected)
+			assert.ErrorIs(t, err, ErrEmptyGroup)
+			assert.ErrorIs(t, err, ErrNoAvailableAccount)
+		})
+	}
+}
+
+func TestSelectAccountByStrategy_RoundRobinRotates(t *testing.T) {
+	a := &data.Account{ID: 1, Status: data.StatusActive, HealthScore: 100}
+	b := &data.Account{ID: 2, Status: data.StatusActive, HealthScore: 10}
+	c := &data.Account{ID: 3, Status: data.StatusActive, HealthScore: 100}
+	groupRepo := new(MockAccountGroupRepo)
+	accountRepo := new(MockAccountRepo)
+	rateLimitRepo := new(MockRateLimitRepo)
+	for _, acc := range []*data.Account{a, b, c} {
+		accountRepo.On("GetAccount", mock.Anything, acc.ID).Return(acc, nil)
+		rateLimitRepo.On("GetUsageCounts", mock.Anything, acc.ID).Return(int32(0), int32(0), nil)
+	}
+	groupRepo.On("GetGroup", mock.Anything, int64(1)).Return(&data.AccountGroupData{
+		ID: 1, AccountIDs: []int64{1, 2, 3}, Strategy: string(StrategyRoundRobin),
+	}, nil)
+	for n := int64(1); n <= 6; n++ {
+		rateLimitRepo.On("IncrementGroupRoundRobin", mock.Anything, int64(1)).Return(n, nil).Once()
+	}
+	uc := NewAccountGroupUseCase(groupRepo, accountRepo, rateLimitRepo, log.DefaultLogger)
+
+	// Health and load are ignored: each member gets an equal turn in group order
+	var got []int64
+	for i := 0; i < 6; i++ {
+		selected, err := uc.SelectAccountByStrategy(context.Background(), 1)
+		require.NoError(t, err)
+		got = append(got, selected.ID)
+	}
+	assert.Equal(t, []int64{1, 2, 3, 1, 2, 3}, got)
+}
+
+func TestSelectAccountByStrategy_RoundRobinSkipsBroken(t *testing.T) {
+	a := &data.Account{ID: 1, Status: data.StatusActive, HealthScore: 100}
+	broken := &data.Account{ID: 2, Status: data.StatusActive, HealthScore: 100, IsCircuitBroken: true}
+	c := &data.Account{ID: 3, Status: data.StatusActive, HealthScore: 100}
+	groupRepo := new(MockAccountGroupRepo)
+	accountRepo := new(MockAccountRepo)
+	rateLimitRepo := new(MockRateLimitRepo)
+	for _, acc := range []*data.Account{a, broken, c} {
+		accountRepo.On("GetAccount", mock.Anything, acc.ID).Return(acc, nil)
+		rateLimitRepo.On("GetUsageCounts", mock.Anything, acc.ID).Return(int32(0), int32(0), nil)
+	}
+	groupRepo.On("GetGroup", mock.Anything, int64(1)).Return(&data.AccountGroupData{
+		ID: 1, AccountIDs: []int64{1, 2, 3}, Strategy: string(StrategyRoundRobin),
+	}, nil)
+	for n := int64(1); n <= 4; n++ {
+		rateLimitRepo.On("IncrementGroupRoundRobin", mock.Anything, int64(1)).Return(n, nil).Once()
+	}
+	uc := NewAccountGroupUseCase(groupRepo, accountRepo, rateLimitRepo, log.DefaultLogger)
+
+	var got []int64
+	for i := 0; i < 4; i++ {
+		selected, err := uc.SelectAccountByStrategy(context.Background(), 1)
+		require.NoError(t, err)
+		got = append(got, selected.ID)
+	}
+	assert.Equal(t, []int64{1, 3, 1, 3}, got)
+}
+
+func TestSelectAccountByStrategy_RoundRobinRedisFailureDegrades(t *testing.T) {
+	a := &data.Account{ID: 1, Status: data.StatusActive, HealthScore: 100}
+	uc, _, rateLimitRepo := setupSelectTest(a)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(1)).Return(int32(0), int32(0), nil)
+	rateLimitRepo.On("IncrementGroupRoundRobin", mock.Anything, int64(1)).Return(int64(0), errors.New("redis down"))
+
+	selected, err := uc.SelectAccountForCategory(context.Background(), 1, StrategyRoundRobin, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), selected.ID)
+}
+
+func TestSelectAccountByStrategy_DefaultsToLeastLoaded(t *testing.T) {
+	a := &data.Account{ID: 1, Status: data.StatusActive, RpmLimit: 100}
+	b := &data.Account{ID: 2, Status: data.StatusActive, RpmLimit: 100}
+	uc, _, rateLimitRepo := setupSelectTest(a, b)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(1)).Return(int32(90), int32(0), nil)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(2)).Return(int32(10), int32(0), nil)
+
+	selected, err := uc.SelectAccountByStrategy(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), selected.ID)
+	rateLimitRepo.AssertNotCalled(t, "IncrementGroupRoundRobin", mock.Anything, mock.Anything)
+}
+
+func TestParseSelectionStrategy(t *testing.T) {
+	s, err := ParseSelectionStrategy("")
+	require.NoError(t, err)
+	assert.Equal(t, StrategyLeastLoaded, s)
+
+	s, err = ParseSelectionStrategy("round_robin")
+	require.NoError(t, err)
+	assert.Equal(t, StrategyRoundRobin, s)
+
+	_, err = ParseSelectionStrategy("fastest")
+	assert.Error(t, err)
+}
+
+// allStrategies lists every selection strategy; availability tests run against each of them
+// so the rules cannot drift between strategies.
+var allStrategies = []SelectionStrategy{StrategyLeastLoaded, StrategyWeightedRandom, StrategyHealthWeighted, StrategyRoundRobin}
+
+func TestIsSelectable(t *testing.T) {
+	uc, _, _ := setupSelectTest()
+	uc.SetMinHealthScore(50)
+
+	healthy := data.Account{Status: data.StatusActive, HealthScore: 100}
+	tests := []struct {
+		name   string
+		mutate func(a *data.Account)
+		want   bool
+	}{
+		{"healthy active account", func(a *data.Account) {}, true},
+		{"at min health", func(a *data.Account) { a.HealthScore = 50 }, true},
+		{"below min health", func(a *data.Account) { a.HealthScore = 49 }, false},
+		{"circuit broken", func(a *data.Account) { a.IsCircuitBroken = true }, false},
+		{"draining", func(a *data.Account) { a.IsDraining = true }, false},
+		{"needs reauth", func(a *data.Account) { a.NeedsReauth = true }, false},
+		{"inactive", func(a *data.Account) { a.Status = data.StatusInactive }, false},
+		{"error", func(a *data.Account) { a.Status = data.StatusError }, false},
+		{"created", func(a *data.Account) { a.Status = data.StatusCreated }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := healthy
+			tt.mutate(&account)
+			assert.Equal(t, tt.want, uc.isSelectable(&account))
+		})
+	}
+}
+
+func TestSelectAccountWithStrategy_NeverSelectsUnselectable(t *testing.T) {
+	// Unselectable accounts get far more weight and headroom than the healthy one,
+	// so any strategy that ignored the availability rules would pick them.
+	unselectable := []*data.Account{
+		{ID: 1, Status: data.StatusActive, HealthScore: 100, RpmLimit: 10000, IsCircuitBroken: true},
+		{ID: 2, Status: data.StatusError, HealthScore: 100, RpmLimit: 10000},
+		{ID: 3, Status: data.StatusActive, HealthScore: 100, RpmLimit: 10000, IsDraining: true},
+		{ID: 4, Status: data.StatusActive, HealthScore: 100, RpmLimit: 10000, NeedsReauth: true},
+		{ID: 5, Status: data.StatusActive, HealthScore: 10, RpmLimit: 10000},
+		{ID: 6, Status: data.StatusInactive, HealthScore: 100, RpmLimit: 10000},
+	}
+	healthy := &data.Account{ID: 7, Status: data.StatusActive, HealthScore: 100, RpmLimit: 1}
+
+	for _, strategy := range allStrategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			uc, _, rateLimitRepo := setupSelectTest(append(unselectable, healthy)...)
+			uc.SetMinHealthScore(30)
+			uc.SetRandSource(rand.New(rand.NewPCG(11, 13)))
+			rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(7)).Return(int32(0), int32(0), nil)
+			rateLimitRepo.On("IncrementGroupRoundRobin", mock.Anything, int64(1)).Return(int64(1), nil).Maybe()
+
+			for i := 0; i < 50; i++ {
+				selected, err := uc.SelectAccountWithStrategy(context.Background(), 1, strategy)
+				require.NoError(t, err)
+				assert.Equal(t, int64(7), selected.ID)
+			}
+		})
+	}
+}
+
+func TestSelectAccountWithStrategy_OnlyBrokenAccounts(t *testing.T) {
+	for _, strategy := range allStrategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			broken := &data.Account{ID: 1, Status: data.StatusActive, HealthScore: 100, IsCircuitBroken: true}
+			uc, _, rateLimitRepo := setupSelectTest(broken)
+
+			selected, err := uc.SelectAccountWithStrategy(context.Background(), 1, strategy)
+			assert.Nil(t, selected)
+			assert.True(t, errors.Is(err, ErrNoAvailableAccount))
+			rateLimitRepo.AssertNotCalled(t, "GetUsageCounts", mock.Anything, int64(1))
+		})
+	}
+}
+
+func TestSelectAccountForCategory_FiltersByAllowedCategories(t *testing.T) {
+	batchOnly := `{"allowed_categories":["batch"]}`
+	chatOnly := `{"allowed_categories":["chat"]}`
+	accounts := []*data.Account{
+		{ID: 1, Status: data.StatusActive, HealthScore: 100, RpmLimit: 1000, Metadata: &batchOnly},
+		{ID: 2, Status: data.StatusActive, HealthScore: 100, RpmLimit: 1000, Metadata: &chatOnly},
+		{ID: 3, Status: data.StatusActive, HealthScore: 100, RpmLimit: 1}, // no restriction
+	}
+
+	for _, strategy := range allStrategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			uc, _, rateLimitRepo := setupSelectTest(accounts...)
+			rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(1)).Return(int32(0), int32(0), nil)
+			rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(2)).Return(int32(0), int32(0), nil)
+			rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(3)).Return(int32(0), int32(0), nil)
+			rateLimitRepo.On("IncrementGroupRoundRobin", mock.Anything, int64(1)).Return(int64(1), nil).Maybe()
+			uc.SetRandSource(rand.New(rand.NewPCG(3, 5)))
+
+			seen := make(map[int64]bool)
+			for i := 0; i < 50; i++ {
+				selected, err := uc.SelectAccountForCategory(context.Background(), 1, strategy, "batch")
+				require.NoError(t, err)
+				seen[selected.ID] = true
+			}
+			assert.False(t, seen[2], "chat-only account must not serve batch requests")
+			assert.True(t, seen[1], "batch-pinned account serves batch requests")
+		})
+	}
+}
+
+func TestSelectAccountForCategory_NoAccountPermitsCategory(t *testing.T) {
+	batchOnly := `{"allowed_categories":["batch"]}`
+	a := &data.Account{ID: 1, Status: data.StatusActive, Metadata: &batchOnly}
+	uc, _, rateLimitRepo := setupSelectTest(a)
+
+	selected, err := uc.SelectAccountForCategory(context.Background(), 1, StrategyLeastLoaded, "chat")
+	assert.Nil(t, selected)
+	assert.True(t, errors.Is(err, ErrNoAvailableAccount))
+	rateLimitRepo.AssertNotCalled(t, "GetUsageCounts", mock.Anything, int64(1))
+}
+
+func TestSelectAccountForCategory_IncludesPinnedAccount(t *testing.T) {
+	batchOnly := `{"allowed_categories":["Batch"]}`
+	pinned := &data.Account{ID: 1, Status: data.StatusActive, Metadata: &batchOnly}
+	uc, _, rateLimitRepo := setupSelectTest(pinned)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, int64(1)).Return(int32(0), int32(0), nil)
+
+	selected, err := uc.SelectAccountForCategory(context.Background(), 1, StrategyLeastLoaded, "batch")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), selected.ID)
+
+	// Requests without a category are not constrained by the pin
+	selected, err = uc.SelectAccount(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), selected.ID)
+}
+
+func TestSelectAccountWithStrategy_UnknownStrategy(t *testing.T) {
+	uc, _, _ := setupSelectTest()
+
+	_, err := uc.SelectAccountWithStrategy(context.Background(), 1, "fastest")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown selection strategy")
+}
+
+func TestHeadroom(t *testing.T) {
+	assert.Equal(t, 1.0, headroom(50, 0, ZeroLimitUnlimited), "unlimited")
+	assert.Equal(t, 0.5, headroom(50, 100, ZeroLimitUnlimited))
+	assert.Equal(t, 0.0, headroom(100, 100, ZeroLimitUnlimited))
+	assert.Equal(t, 0.0, headroom(150, 100, ZeroLimitUnlimited))
+
+	assert.Equal(t, 0.0, headroom(0, 0, ZeroLimitBlocked), "a 0 limit admits nothing in blocked mode")
+	assert.Equal(t, 1.0, headroom(50, -1, ZeroLimitBlocked), "negative limits stay unlimited")
+	assert.Equal(t, 0.5, headroom(50, 100, ZeroLimitBlocked))
+}
+
+func TestSelectAccountWithStrategy_ZeroLimitBlocked(t *testing.T) {
+	// In blocked mode the limiter rejects every request of an account with a 0 limit, so selection
+	// must never pick it, even though it would otherwise look idle and get the largest weight
+	blockedRPM := &data.Account{ID: 1, Status: data.StatusActive, RpmLimit: 0, TpmLimit: 10000, HealthScore: 100}
+	blockedTPM := &data.Account{ID: 2, Status: data.StatusActive, RpmLimit: 600, TpmLimit: 0, HealthScore: 100}
+	limited := &data.Account{ID: 3, Status: data.StatusActive, RpmLimit: 100, TpmLimit: 10000, HealthScore: 100}
+	uc, _, rateLimitRepo := setupSelectTest(blockedRPM, blockedTPM, limited)
+	rateLimitRepo.On("GetUsageCounts", mock.Anything, mock.Anything).Return(int32(90), int32(9000), nil)
+	uc.SetZeroLimitMode(ZeroLimitBlocked)
+
+	for _, strategy := range []SelectionStrategy{StrategyLeastLoaded, StrategyWeightedRandom, StrategyHealthWeighted} {
+		for i := 0; i < 20; i++ {
+			selected, err := uc.SelectAccountWithStrategy(context.Background(), 1, strategy)
+			require.NoError(t, err, strategy)
+			assert.Equal(t, int64(3), selected.ID, strategy)
+		}
+	}
+
+	// Unlimited mode keeps treating the 0 limits as unlimited
+	uc.SetZeroLimitMode(ZeroLimitUnlimited)
+	selected, err := uc.SelectAccount(context.Background(), 1)
+	require.NoError(t, err)
+	assert.NotEqual(t, int64(3), selected.ID)
+}
@@ -0,0 +1,221 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupGroupMemberTest() (*AccountGroupUseCase, *MockAccountGroupRepo) {
+	groupRepo := new(MockAccountGroupRepo)
+	accountRepo := new(MockAccountRepo)
+	accountRepo.On("GetAccount", mock.Anything, mock.Anything).Return(&data.Account{}, nil)
+	groupRepo.On("ListGroups", mock.Anything, int32(1), int32(1000)).Return([]*data.AccountGroupData{}, int64(0), nil).Maybe()
+	groupRepo.On("GetGroup", mock.Anything, int64(1)).Return(&data.AccountGroupData{ID: 1, Name: "group"}, nil).Maybe()
+
+	uc := NewAccountGroupUseCase(groupRepo, accountRepo, new(MockRateLimitRepo), log.DefaultLogger)
+	return uc, groupRepo
+}
+
+func TestCreateAccountGroup_DedupesMembers(t *testing.T) {
+	uc, groupRepo := setupGroupMemberTest()
+	ctx := context.Background()
+	groupRepo.On("CreateGroup", ctx, "group", "", int32(0), []int64{10, 20}).Return(int64(1), nil)
+
+	group, err := uc.CreateAccountGroup(ctx, "group", "", 0, []int64{10, 10, 20})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{10, 20}, group.AccountIDs)
+	groupRepo.AssertExpectations(t)
+}
+
+func TestUpdateAccountGroup_DedupesMembers(t *testing.T) {
+	uc, groupRepo := setupGroupMemberTest()
+	ctx := context.Background()
+	groupRepo.On("UpdateGroup", ctx, int64(1), "group", "", int32(0), []int64{10, 20}).Return(nil)
+
+	require.NoError(t, uc.UpdateAccountGroup(ctx, 1, "group", "", 0, []int64{10, 10, 20}))
+	groupRepo.AssertExpectations(t)
+}
+
+func TestAccountGroup_RejectDuplicateMembers(t *testing.T) {
+	uc, groupRepo := setupGroupMemberTest()
+	uc.SetRejectDuplicateMembers(true)
+	ctx := context.Background()
+
+	_, err := uc.CreateAccountGroup(ctx, "group", "", 0, []int64{10, 10, 20})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Contains(t, err.Error(), "10")
+
+	err = uc.UpdateAccountGroup(ctx, 1, "group", "", 0, []int64{10, 10, 20})
+	assert.ErrorAs(t, err, &validationErr)
+
+	groupRepo.AssertNotCalled(t, "CreateGroup", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	groupRepo.AssertNotCalled(t, "UpdateGroup", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestListAccountGroups_MemberCounts(t *testing.T) {
+	ctx := context.Background()
+	newGroups := func() []*data.AccountGroupData {
+		return []*data.AccountGroupData{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}
+	}
+
+	t.Run("off by default", func(t *testing.T) {
+		groupRepo := new(MockAccountGroupRepo)
+		uc := NewAccountGroupUseCase(groupRepo, new(MockAccountRepo), new(MockRateLimitRepo), log.DefaultLogger)
+		groupRepo.On("ListGroups", ctx, int32(1), int32(20)).Return(newGroups(), int64(2), nil)
+
+		groups, total, err := uc.ListAccountGroups(ctx, 1, 20, false)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		for _, g := range groups {
+			assert.Nil(t, g.MemberCount)
+		}
+		groupRepo.AssertNotCalled(t, "CountGroupMembers", mock.Anything, mock.Anything)
+	})
+
+	t.Run("attaches counts including empty groups", func(t *testing.T) {
+		groupRepo := new(MockAccountGroupRepo)
+		uc := NewAccountGroupUseCase(groupRepo, new(MockAccountRepo), new(MockRateLimitRepo), log.DefaultLogger)
+		groupRepo.On("ListGroups", ctx, int32(1), int32(20)).Return(newGroups(), int64(2), nil)
+		groupRepo.On("CountGroupMembers", ctx, []int64{1, 2}).Return(map[int64]int64{1: 5}, nil).Once()
+
+		groups, _, err := uc.ListAccountGroups(ctx, 1, 20, true)
+		require.NoError(t, err)
+		require.NotNil(t, groups[0].MemberCount)
+		assert.Equal(t, int64(5), *groups[0].MemberCount)
+		require.NotNil(t, groups[1].MemberCount)
+		assert.Equal(t, int64(0), *groups[1].MemberCount)
+		groupRepo.AssertExpectations(t)
+	})
+}
+
+func TestDeleteAccountGroupWithReassign(t *testing.T) {
+	t.Run("delegates to repo", func(t *testing.T) {
+		uc, groupRepo := setupGroupMemberTest()
+		ctx := context.Background()
+		groupRepo.On("DeleteGroupWithReassign", ctx, int64(1), int64(2)).Return(nil).Once()
+
+		require.NoError(t, uc.DeleteAccountGroupWithReassign(ctx, 1, 2))
+		groupRepo.AssertExpectations(t)
+	})
+
+	t.Run("same group rejected", func(t *testing.T) {
+		uc, groupRepo := setupGroupMemberTest()
+
+		err := uc.DeleteAccountGroupWithReassign(context.Background(), 1, 1)
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		groupRepo.AssertNotCalled(t, "DeleteGroupWithReassign", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// fakeTransactor records transactions and marks the ctx passed to fn, standing in for data.Transactor.
+type fakeTransactor struct {
+	calls int
+	err   error // error from the most recent transaction (nil = committed)
+}
+
+type fakeTxKey struct{}
+
+func (f *fakeTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	f.calls++
+	f.err = fn(context.WithValue(ctx, fakeTxKey{}, true))
+	return f.err
+}
+
+func inFakeTx(ctx context.Context) bool {
+	inTx, _ := ctx.Value(fakeTxKey{}).(bool)
+	return inTx
+}
+
+func TestCreateAccountGroupWithLimits(t *testing.T) {
+	t.Run("all steps share one transaction", func(t *testing.T) {
+		uc, groupRepo := setupGroupMemberTest()
+		tx := &fakeTransactor{}
+		uc.SetTransactor(tx)
+		inTx := mock.MatchedBy(inFakeTx)
+		groupRepo.On("CreateGroup", inTx, "group", "", int32(0), []int64{10}).Return(int64(1), nil).Once()
+		groupRepo.On("UpdateGroupRateLimits", inTx, int64(1), int32(100), int32(0)).Return(nil).Once()
+		groupRepo.On("UpdateGroupConcurrencyLimit", inTx, int64(1), int32(5)).Return(nil).Once()
+
+		group, err := uc.CreateAccountGroupWithLimits(context.Background(), "group", "", 0, []int64{10}, 100, 0, 5, "")
+		require.NoError(t, err)
+		assert.Equal(t, int32(100), group.RpmLimit)
+		assert.Equal(t, int32(5), group.ConcurrencyLimit)
+		assert.Equal(t, 1, tx.calls)
+		groupRepo.AssertExpectations(t)
+	})
+
+	t.Run("later step failure fails the transaction", func(t *testing.T) {
+		uc, groupRepo := setupGroupMemberTest()
+		tx := &fakeTransactor{}
+		uc.SetTransactor(tx)
+		groupRepo.On("CreateGroup", mock.Anything, "group", "", int32(0), []int64{10}).Return(int64(1), nil).Once()
+		groupRepo.On("UpdateGroupConcurrencyLimit", mock.Anything, int64(1), int32(5)).Return(errors.New("lock wait timeout")).Once()
+
+		group, err := uc.CreateAccountGroupWithLimits(context.Background(), "group", "", 0, []int64{10}, 0, 0, 5, "")
+		require.Error(t, err)
+		assert.Nil(t, group)
+		assert.ErrorContains(t, tx.err, "lock wait timeout", "the error is returned from the transaction so it rolls back")
+		groupRepo.AssertNotCalled(t, "UpdateGroupRateLimits", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid limit rolls back the created group", func(t *testing.T) {
+		uc, groupRepo := setupGroupMemberTest()
+		tx := &fakeTransactor{}
+		uc.SetTransactor(tx)
+		groupRepo.On("CreateGroup", mock.Anything, "group", "", int32(0), mock.Anything).Return(int64(1), nil).Once()
+
+		_, err := uc.CreateAccountGroupWithLimits(context.Background(), "group", "", 0, nil, -1, 0, 0, "")
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.ErrorAs(t, tx.err, &validationErr)
+	})
+
+	t.Run("without transactor", func(t *testing.T) {
+		uc, groupRepo := setupGroupMemberTest()
+		groupRepo.On("CreateGroup", mock.Anything, "group", "", int32(0), []int64{10}).Return(int64(1), nil).Once()
+
+		group, err := uc.CreateAccountGroupWithLimits(context.Background(), "group", "", 0, []int64{10}, 0, 0, 0, "")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), group.ID)
+		groupRepo.AssertNotCalled(t, "UpdateGroupRateLimits", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		groupRepo.AssertNotCalled(t, "UpdateGroupConcurrencyLimit", mock.Anything, mock.Anything, mock.Anything)
+		groupRepo.AssertNotCalled(t, "UpdateGroupStrategy", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("strategy applied in the transaction", func(t *testing.T) {
+		uc, groupRepo := setupGroupMemberTest()
+		tx := &fakeTransactor{}
+		uc.SetTransactor(tx)
+		inTx := mock.MatchedBy(inFakeTx)
+		groupRepo.On("CreateGroup", inTx, "group", "", int32(0), []int64{10}).Return(int64(1), nil).Once()
+		groupRepo.On("UpdateGroupStrategy", inTx, int64(1), "round_robin").Return(nil).Once()
+
+		group, err := uc.CreateAccountGroupWithLimits(context.Background(), "group", "", 0, []int64{10}, 0, 0, 0, "round_robin")
+		require.NoError(t, err)
+		assert.Equal(t, "round_robin", group.Strategy)
+		groupRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown strategy rolls back the created group", func(t *testing.T) {
+		uc, groupRepo := setupGroupMemberTest()
+		tx := &fakeTransactor{}
+		uc.SetTransactor(tx)
+		groupRepo.On("CreateGroup", mock.Anything, "group", "", int32(0), mock.Anything).Return(int64(1), nil).Once()
+
+		_, err := uc.CreateAccountGroupWithLimits(context.Background(), "group", "", 0, nil, 0, 0, 0, "fastest")
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.ErrorAs(t, tx.err, &validationErr)
+		groupRepo.AssertNotCalled(t, "UpdateGroupStrategy", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
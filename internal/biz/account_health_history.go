@@ -0,0 +1,109 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// HealthHistoryKeyPrefix Redis 健康历史环形缓冲区前缀（health_history:{id}）
+	HealthHistoryKeyPrefix = "health_history:"
+
+	// HealthHistoryMaxEntries 每个账户保留的最大历史条数
+	HealthHistoryMaxEntries = 50
+
+	// HealthHistoryTTL 健康历史 TTL（7 天无新记录后自动清理）
+	HealthHistoryTTL = 7 * 24 * time.Hour
+
+	// HealthHistorySourceValidation 来源：API Key 验证
+	HealthHistorySourceValidation = "validation"
+
+	// HealthHistorySourceRefresh 来源：OAuth Token 刷新
+	HealthHistorySourceRefresh = "refresh"
+
+	// HealthHistorySourceProbe 来源：运维手动试探（ProbeAccount）
+	HealthHistorySourceProbe = "probe"
+)
+
+// HealthHistoryEntry 单次健康检查/刷新结果（存储在 Redis list 中）
+type HealthHistoryEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMs int64     `json:"latency_ms"`
+}
+
+// recordHealthHistory 将一次验证/刷新结果写入账户的健康历史
+func (uc *AccountUsecase) recordHealthHistory(ctx context.Context, accountID int64, source string, startedAt time.Time, resultErr error) {
+	recordHealthHistory(ctx, uc.rdb, uc.logger, accountID, source, startedAt, resultErr)
+}
+
+// recordHealthHistory 将一次验证/刷新结果写入账户的健康历史（AccountUsecase 与 OAuthRefreshTask 共用）
+// 使用 LPUSH + LTRIM 维护固定长度的环形缓冲区，超出上限时淘汰最旧的记录
+// 写入失败只记录日志，不影响主流程
+func recordHealthHistory(ctx context.Context, rdb redis.UniversalClient, logger *log.Helper, accountID int64, source string, startedAt time.Time, resultErr error) {
+	if rdb == nil {
+		return
+	}
+
+	entry := HealthHistoryEntry{
+		Timestamp: startedAt.UTC(),
+		Source:    source,
+		Success:   resultErr == nil,
+		LatencyMs: time.Since(startedAt).Milliseconds(),
+	}
+	if resultErr != nil {
+		entry.Error = resultErr.Error()
+	}
+
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		logger.Warnw("failed to marshal health history entry", "account_id", accountID, "error", err)
+		return
+	}
+
+	key := fmt.Sprintf("%s%d", HealthHistoryKeyPrefix, accountID)
+	pipe := rdb.TxPipeline()
+	pipe.LPush(ctx, key, payload)
+	pipe.LTrim(ctx, key, 0, HealthHistoryMaxEntries-1)
+	pipe.Expire(ctx, key, HealthHistoryTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Warnw("failed to record health history", "account_id", accountID, "error", err)
+	}
+}
+
+// GetAccountHealthHistory 查询账户最近的健康历史（最新在前）
+// limit <= 0 或超过上限时返回全部已保留的记录
+func (uc *AccountUsecase) GetAccountHealthHistory(ctx context.Context, accountID int64, limit int) ([]*HealthHistoryEntry, error) {
+	if uc.rdb == nil {
+		return nil, fmt.Errorf("redis client is nil")
+	}
+
+	if limit <= 0 || limit > HealthHistoryMaxEntries {
+		limit = HealthHistoryMaxEntries
+	}
+
+	key := fmt.Sprintf("%s%d", HealthHistoryKeyPrefix, accountID)
+	values, err := uc.rdb.LRange(ctx, key, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read health history: %w", err)
+	}
+
+	entries := make([]*HealthHistoryEntry, 0, len(values))
+	for _, v := range values {
+		var entry HealthHistoryEntry
+		if err := json.Unmarshal([]byte(v), &entry); err != nil {
+			uc.logger.Warnw("skipping malformed health history entry", "account_id", accountID, "error", err)
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, nil
+}
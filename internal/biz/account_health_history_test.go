@@ -0,0 +1,77 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupHealthHistoryTest(t *testing.T) (*AccountUsecase, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	uc := NewAccountUsecase(new(MockAccountRepo), nil, nil, nil, nil, nil, nil, nil, rdb, log.DefaultLogger)
+	return uc, mr
+}
+
+func TestRecordHealthHistory_EvictsOldestPastCap(t *testing.T) {
+	uc, mr := setupHealthHistoryTest(t)
+	ctx := context.Background()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	total := HealthHistoryMaxEntries + 5
+	for i := 0; i < total; i++ {
+		var err error
+		if i%2 == 1 {
+			err = fmt.Errorf("failure %d", i)
+		}
+		uc.recordHealthHistory(ctx, 7, HealthHistorySourceValidation, base.Add(time.Duration(i)*time.Minute), err)
+	}
+
+	values, err := mr.List("health_history:7")
+	require.NoError(t, err)
+	assert.Len(t, values, HealthHistoryMaxEntries)
+
+	entries, err := uc.GetAccountHealthHistory(ctx, 7, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, HealthHistoryMaxEntries)
+
+	// Newest first; the 5 oldest entries were evicted
+	assert.Equal(t, base.Add(time.Duration(total-1)*time.Minute), entries[0].Timestamp)
+	assert.Equal(t, base.Add(5*time.Minute), entries[len(entries)-1].Timestamp)
+	assert.True(t, entries[0].Success)
+	assert.Empty(t, entries[0].Error)
+	assert.False(t, entries[1].Success)
+	assert.Equal(t, fmt.Sprintf("failure %d", total-2), entries[1].Error)
+	assert.True(t, mr.TTL("health_history:7") > 0)
+}
+
+func TestGetAccountHealthHistory_Limit(t *testing.T) {
+	uc, _ := setupHealthHistoryTest(t)
+	ctx := context.Background()
+
+	uc.recordHealthHistory(ctx, 1, HealthHistorySourceRefresh, time.Now(), nil)
+	uc.recordHealthHistory(ctx, 1, HealthHistorySourceRefresh, time.Now(), errors.New("timeout"))
+	uc.recordHealthHistory(ctx, 1, HealthHistorySourceRefresh, time.Now(), nil)
+
+	entries, err := uc.GetAccountHealthHistory(ctx, 1, 2)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.True(t, entries[0].Success)
+	assert.Equal(t, "timeout", entries[1].Error)
+	assert.Equal(t, HealthHistorySourceRefresh, entries[1].Source)
+
+	// Unknown account has no history
+	entries, err = uc.GetAccountHealthHistory(ctx, 999, 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
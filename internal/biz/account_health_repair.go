@@ -0,0 +1,36 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+)
+
+// ReconcileHealthScores 修复库中超出 [0, 100] 的健康分数，返回修复的账户数
+// 写入路径（UpdateHealthScore、UpdateAccount 等）都会限制范围，读取时 ToProto 也会再次限制；
+// 本任务定期兜底修复直接改库或历史数据留下的越界值，使排序、统计等 SQL 查询与展示一致
+func (uc *AccountUsecase) ReconcileHealthScores(ctx context.Context) (int64, error) {
+	repaired, err := uc.repo.RepairHealthScores(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to repair health scores: %w", err)
+	}
+
+	if repaired > 0 {
+		uc.logger.Warnw("health scores out of [0, 100] repaired", "count", repaired)
+	}
+	return repaired, nil
+}
+
+// ReconcileMissingProviders 将 provider 为空（NULL 或空字符串）的损坏账户标记为 error 状态，返回被标记的账户数
+// 读取路径会按 data.missing_provider_policy 跳过或原样返回此类账户并记录告警；本任务定期将其移出
+// 调度、刷新和健康检查，避免按 Provider 分派时落入默认分支，修正 provider 后需人工恢复状态
+func (uc *AccountUsecase) ReconcileMissingProviders(ctx context.Context) (int, error) {
+	flagged, err := uc.repo.FlagAccountsMissingProvider(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to flag accounts without provider: %w", err)
+	}
+
+	if len(flagged) > 0 {
+		uc.logger.Warnw("accounts without provider flagged as error", "count", len(flagged), "account_ids", flagged)
+	}
+	return len(flagged), nil
+}
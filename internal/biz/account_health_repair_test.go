@@ -0,0 +1,59 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileHealthScores(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reports repaired accounts", func(t *testing.T) {
+		repo := new(MockAccountRepo)
+		uc := &AccountUsecase{repo: repo, logger: log.NewHelper(log.DefaultLogger)}
+		repo.On("RepairHealthScores", ctx).Return(int64(2), nil).Once()
+
+		repaired, err := uc.ReconcileHealthScores(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), repaired)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("repair error", func(t *testing.T) {
+		repo := new(MockAccountRepo)
+		uc := &AccountUsecase{repo: repo, logger: log.NewHelper(log.DefaultLogger)}
+		repo.On("RepairHealthScores", ctx).Return(int64(0), errors.New("database error")).Once()
+
+		_, err := uc.ReconcileHealthScores(ctx)
+		assert.ErrorContains(t, err, "failed to repair health scores")
+	})
+}
+
+func TestReconcileMissingProviders(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reports flagged accounts", func(t *testing.T) {
+		repo := new(MockAccountRepo)
+		uc := &AccountUsecase{repo: repo, logger: log.NewHelper(log.DefaultLogger)}
+		repo.On("FlagAccountsMissingProvider", ctx).Return([]int64{4, 9}, nil).Once()
+
+		flagged, err := uc.ReconcileMissingProviders(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, flagged)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("flag error", func(t *testing.T) {
+		repo := new(MockAccountRepo)
+		uc := &AccountUsecase{repo: repo, logger: log.NewHelper(log.DefaultLogger)}
+		repo.On("FlagAccountsMissingProvider", ctx).Return(nil, errors.New("database error")).Once()
+
+		_, err := uc.ReconcileMissingProviders(ctx)
+		assert.ErrorContains(t, err, "failed to flag accounts without provider")
+	})
+}
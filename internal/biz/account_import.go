@@ -0,0 +1,168 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/oauth"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ImportOAuthAccount 通过导出的加密 refresh token 导入 OAuth 账户（迁移场景）
+// 账户先以 created 状态创建，随后立即刷新 Token：成功则激活，失败则标记为 error
+// refresh token 使用与导出端相同的加密密钥加密；任何 Token 明文均不写入日志
+func (uc *AccountUsecase) ImportOAuthAccount(ctx context.Context, req *v1.ImportOAuthAccountRequest) (*v1.ImportOAuthAccountResponse, error) {
+	provider, err := protoProviderToDataProvider(req.Provider)
+	if err != nil {
+		return nil, errors.BadRequest(ReasonUnsupportedProvider, err.Error())
+	}
+	if err := uc.CheckProviderEnabled(provider); err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := uc.crypto.Decrypt(req.RefreshTokenEncrypted)
+	if err != nil || refreshToken == "" {
+		return nil, errors.BadRequest("INVALID_REFRESH_TOKEN", "failed to decrypt refresh token")
+	}
+
+	// 初始 OAuth 数据只包含 refresh token，access token 由导入时的刷新获得
+	oauthDataEncrypted, err := uc.encryptStoredOAuthData(0, StoredOAuthData{
+		RefreshTokenEncrypted: req.RefreshTokenEncrypted,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var metadataPtr *string
+	if len(req.Metadata) > 0 {
+		metadataBytes, err := json.Marshal(req.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		metadataJSON := string(metadataBytes)
+		metadataPtr = &metadataJSON
+	}
+
+	account := &data.Account{
+		Name:               req.Name,
+		Description:        req.GetDescription(),
+		Provider:           provider,
+		OAuthDataEncrypted: oauthDataEncrypted,
+		Metadata:           metadataPtr,
+		RpmLimit:           req.GetRpmLimit(),
+		TpmLimit:           req.GetTpmLimit(),
+		HealthScore:        100,
+		Status:             data.StatusCreated,
+	}
+
+	if err := uc.repo.CreateAccount(ctx, account); err != nil {
+		return nil, fmt.Errorf("failed to create account: %w", err)
+	}
+	uc.recordStatusTransitions(ctx, account.ID, StatusTransition{Timestamp: uc.now().UTC(), To: data.StatusCreated, Reason: "account imported"})
+	uc.bindCredentials(ctx, account)
+
+	uc.logger.Infow("imported OAuth account created, refreshing token",
+		"account_id", account.ID,
+		"name", account.Name,
+		"provider", account.Provider)
+
+	resp := &v1.ImportOAuthAccountResponse{
+		AccountId:   account.ID,
+		AccountName: account.Name,
+	}
+
+	// 导入后立即刷新即凭证验证：created → validating → active | error
+	if err := uc.transitionStatus(ctx, account, data.StatusValidating, "refreshing imported token"); err != nil {
+		return nil, err
+	}
+
+	expiresAt, refreshErr := uc.refreshImportedAccount(ctx, account, refreshToken, req.Metadata["proxy_url"])
+	if refreshErr != nil {
+		uc.logger.Warnw("imported account token refresh failed",
+			"account_id", account.ID,
+			"provider", account.Provider,
+			"error", refreshErr)
+
+		if err := uc.transitionStatus(ctx, account, data.StatusError, refreshErr.Error()); err != nil {
+			return nil, err
+		}
+
+		resp.Status = v1.AccountStatus_ACCOUNT_ERROR
+		resp.Message = fmt.Sprintf("account imported but token refresh failed: %v", refreshErr)
+		return resp, nil
+	}
+
+	if err := uc.transitionStatus(ctx, account, data.StatusActive, "imported token refreshed"); err != nil {
+		return nil, fmt.Errorf("failed to activate account: %w", err)
+	}
+
+	uc.logger.Infow("imported OAuth account activated",
+		"account_id", account.ID,
+		"provider", account.Provider,
+		"expires_at", expiresAt)
+
+	resp.Status = v1.AccountStatus_ACCOUNT_ACTIVE
+	resp.Message = "Account imported and token refreshed successfully"
+	resp.TokenExpiresAt = timestamppb.New(expiresAt)
+	return resp, nil
+}
+
+// refreshImportedAccount 使用导入的 refresh token 刷新并保存新的 OAuth 数据，返回新的过期时间
+func (uc *AccountUsecase) refreshImportedAccount(ctx context.Context, account *data.Account, refreshToken, proxyURL string) (time.Time, error) {
+	startedAt := time.Now()
+	tokenResp, err := uc.oauthManager.RefreshToken(ctx, account.Provider, refreshToken, &oauth.AccountMetadata{ProxyURL: proxyURL})
+	uc.recordHealthHistory(ctx, account.ID, HealthHistorySourceRefresh, startedAt, err)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	accessTokenEncrypted, err := uc.crypto.Encrypt(tokenResp.AccessToken)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to encrypt access token: %w", err)
+	}
+
+	refreshTokenEncrypted, err := uc.crypto.Encrypt(tokenResp.RefreshToken)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to encrypt refresh token: %w", err)
+	}
+
+	expiresAt := uc.now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	oauthDataEncrypted, err := uc.encryptStoredOAuthData(account.ID, StoredOAuthData{
+		AccessTokenEncrypted:  accessTokenEncrypted,
+		RefreshTokenEncrypted: refreshTokenEncrypted,
+		IDToken:               tokenResp.IDToken,
+		Scopes:                tokenResp.Scopes,
+		Organizations:         tokenResp.Organizations,
+		AccountID:             tokenResp.AccountID,
+		ExpiresAt:             expiresAt,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err := uc.repo.UpdateOAuthData(ctx, account.ID, oauthDataEncrypted, expiresAt); err != nil {
+		return time.Time{}, fmt.Errorf("failed to update OAuth data: %w", err)
+	}
+	return expiresAt, nil
+}
+
+// encryptStoredOAuthData 序列化并加密 OAuth 数据
+// accountID 为 0 表示账户尚未创建，写入旧版密文，创建后由 bindCredentials 绑定
+func (uc *AccountUsecase) encryptStoredOAuthData(accountID int64, oauthData StoredOAuthData) (string, error) {
+	oauthDataJSON, err := json.Marshal(oauthData)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal OAuth data: %w", err)
+	}
+
+	encrypted, err := uc.encryptCredential(accountID, string(oauthDataJSON))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt OAuth data: %w", err)
+	}
+	return encrypted, nil
+}
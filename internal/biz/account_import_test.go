@@ -0,0 +1,114 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/crypto"
+	"QuotaLane/pkg/oauth"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupImportTest(t *testing.T, prov *mockOAuthProvider) (*AccountUsecase, *MockAccountRepo, *crypto.AESCrypto) {
+	cryptoHelper, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+
+	oauthManager := oauth.NewOAuthManager(nil, log.DefaultLogger)
+	oauthManager.RegisterProvider(prov)
+
+	mockRepo := new(MockAccountRepo)
+	mockRepo.On("CreateAccount", mock.Anything, mock.MatchedBy(func(acc *data.Account) bool {
+		return acc.Status == data.StatusCreated && acc.Provider == data.ProviderClaudeOfficial
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*data.Account).ID = 42
+	}).Return(nil).Once()
+
+	uc := NewAccountUsecase(mockRepo, cryptoHelper, nil, nil, oauthManager, nil, nil, nil, nil, log.DefaultLogger)
+	return uc, mockRepo, cryptoHelper
+}
+
+func importRequest(t *testing.T, cryptoHelper *crypto.AESCrypto) *v1.ImportOAuthAccountRequest {
+	encrypted, err := cryptoHelper.Encrypt("exported-refresh-token")
+	require.NoError(t, err)
+	return &v1.ImportOAuthAccountRequest{
+		Provider:              v1.AccountProvider_CLAUDE_OFFICIAL,
+		RefreshTokenEncrypted: encrypted,
+		Name:                  "migrated",
+	}
+}
+
+func TestImportOAuthAccount_RefreshActivates(t *testing.T) {
+	prov := &mockOAuthProvider{tokenResp: &oauth.ExtendedTokenResponse{
+		AccessToken:  "fresh-access",
+		RefreshToken: "rotated-refresh",
+		ExpiresIn:    3600,
+	}}
+	uc, mockRepo, cryptoHelper := setupImportTest(t, prov)
+
+	var storedOAuthData string
+	mockRepo.On("UpdateOAuthData", mock.Anything, int64(42), mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { storedOAuthData = args.String(2) }).
+		Return(nil).Once()
+	mockRepo.On("UpdateAccountStatus", mock.Anything, int64(42), data.StatusValidating).Return(nil).Once()
+	mockRepo.On("UpdateAccountStatus", mock.Anything, int64(42), data.StatusActive).Return(nil).Once()
+
+	resp, err := uc.ImportOAuthAccount(context.Background(), importRequest(t, cryptoHelper))
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), resp.AccountId)
+	assert.Equal(t, v1.AccountStatus_ACCOUNT_ACTIVE, resp.Status)
+	require.NotNil(t, resp.TokenExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), resp.TokenExpiresAt.AsTime(), time.Minute)
+
+	// Stored OAuth data holds the refreshed tokens, encrypted
+	decrypted, err := cryptoHelper.Decrypt(storedOAuthData)
+	require.NoError(t, err)
+	oauthData, err := ParseStoredOAuthData(decrypted)
+	require.NoError(t, err)
+	accessToken, err := cryptoHelper.Decrypt(oauthData.AccessTokenEncrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "fresh-access", accessToken)
+	refreshToken, err := cryptoHelper.Decrypt(oauthData.RefreshTokenEncrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "rotated-refresh", refreshToken)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportOAuthAccount_RefreshFailureLeavesError(t *testing.T) {
+	prov := &mockOAuthProvider{err: errors.New("invalid_grant")}
+	uc, mockRepo, cryptoHelper := setupImportTest(t, prov)
+	mockRepo.On("UpdateAccountStatus", mock.Anything, int64(42), data.StatusValidating).Return(nil).Once()
+	mockRepo.On("UpdateAccountStatus", mock.Anything, int64(42), data.StatusError).Return(nil).Once()
+
+	resp, err := uc.ImportOAuthAccount(context.Background(), importRequest(t, cryptoHelper))
+	require.NoError(t, err, "the account is created even when the refresh fails")
+	assert.Equal(t, int64(42), resp.AccountId)
+	assert.Equal(t, v1.AccountStatus_ACCOUNT_ERROR, resp.Status)
+	assert.Contains(t, resp.Message, "invalid_grant")
+	assert.Nil(t, resp.TokenExpiresAt)
+
+	mockRepo.AssertNotCalled(t, "UpdateOAuthData", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdateAccountStatus", mock.Anything, int64(42), data.StatusActive)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportOAuthAccount_RejectsUndecryptableToken(t *testing.T) {
+	uc, mockRepo, _ := setupImportTest(t, &mockOAuthProvider{})
+
+	_, err := uc.ImportOAuthAccount(context.Background(), &v1.ImportOAuthAccountRequest{
+		Provider:              v1.AccountProvider_CLAUDE_OFFICIAL,
+		RefreshTokenEncrypted: "plaintext-refresh-token",
+		Name:                  "migrated",
+	})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "plaintext-refresh-token")
+	mockRepo.AssertNotCalled(t, "CreateAccount", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,188 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// StatusHistoryKeyPrefix Redis 账户状态迁移记录前缀（status_history:{id}）
+	StatusHistoryKeyPrefix = "status_history:"
+
+	// StatusHistoryMaxEntries 每个账户保留的最大迁移记录条数
+	StatusHistoryMaxEntries = 50
+
+	// StatusHistoryTTL 状态迁移记录 TTL（30 天无新记录后自动清理）
+	StatusHistoryTTL = 30 * 24 * time.Hour
+)
+
+// accountLifecycleTransitions 账户创建生命周期允许的状态迁移：created → validating → active | error
+// error 账户可重新验证；active 账户后续的状态变化（健康检查失败、停用等）不属于创建生命周期
+var accountLifecycleTransitions = map[data.AccountStatus][]data.AccountStatus{
+	data.StatusCreated:    {data.StatusValidating},
+	data.StatusValidating: {data.StatusActive, data.StatusError},
+	data.StatusError:      {data.StatusValidating},
+}
+
+// StatusTransition 一次账户状态迁移（From 为空表示账户创建）
+type StatusTransition struct {
+	Timestamp time.Time          `json:"timestamp"`
+	From      data.AccountStatus `json:"from,omitempty"`
+	To        data.AccountStatus `json:"to"`
+	Reason    string             `json:"reason,omitempty"`
+}
+
+// ToProto 转换为 API 结构（账户创建记录的 From 为 UNSPECIFIED）
+func (t *StatusTransition) ToProto() *v1.StatusTransition {
+	transition := &v1.StatusTransition{
+		Timestamp: timestamppb.New(t.Timestamp),
+		To:        data.StatusToProto(t.To),
+		Reason:    t.Reason,
+	}
+	if t.From != "" {
+		transition.From = data.StatusToProto(t.From)
+	}
+	return transition
+}
+
+// CanTransition 判断生命周期状态迁移是否合法
+func CanTransition(from, to data.AccountStatus) bool {
+	return slices.Contains(accountLifecycleTransitions[from], to)
+}
+
+// accountLifecycle 在账户落库前驱动生命周期状态机，并暂存迁移记录（账户 ID 生成后再写入）
+type accountLifecycle struct {
+	clock       Clock
+	status      data.AccountStatus
+	transitions []StatusTransition
+}
+
+// newAccountLifecycle 从 created 状态开始一个新账户的生命周期
+func (uc *AccountUsecase) newAccountLifecycle(reason string) *accountLifecycle {
+	return &accountLifecycle{
+		clock:       uc.clock,
+		status:      data.StatusCreated,
+		transitions: []StatusTransition{{Timestamp: uc.now().UTC(), To: data.StatusCreated, Reason: reason}},
+	}
+}
+
+// advance 迁移到下一个状态，非法迁移返回错误
+func (l *accountLifecycle) advance(to data.AccountStatus, reason string) error {
+	if !CanTransition(l.status, to) {
+		return fmt.Errorf("invalid account status transition: %s -> %s", l.status, to)
+	}
+	l.transitions = append(l.transitions, StatusTransition{Timestamp: clockNow(l.clock).UTC(), From: l.status, To: to, Reason: reason})
+	l.status = to
+	return nil
+}
+
+// validate 执行 created → validating → active | error；validateFn 为 nil 表示跳过验证直接激活
+// 返回验证错误（验证失败时账户进入 error 状态）
+func (l *accountLifecycle) validate(validateFn func() error, successReason string) error {
+	if validateFn == nil {
+		if err := l.advance(data.StatusValidating, "validation skipped"); err != nil {
+			return err
+		}
+		return l.advance(data.StatusActive, successReason)
+	}
+
+	if err := l.advance(data.StatusValidating, "validating credentials"); err != nil {
+		return err
+	}
+	if validationErr := validateFn(); validationErr != nil {
+		if err := l.advance(data.StatusError, validationErr.Error()); err != nil {
+			return err
+		}
+		return validationErr
+	}
+	return l.advance(data.StatusActive, successReason)
+}
+
+// transitionStatus 将已存在的账户迁移到新状态：校验合法性、持久化并记录迁移
+func (uc *AccountUsecase) transitionStatus(ctx context.Context, account *data.Account, to data.AccountStatus, reason string) error {
+	from := account.Status
+	if !CanTransition(from, to) {
+		return fmt.Errorf("invalid account status transition: %s -> %s", from, to)
+	}
+	if err := uc.repo.UpdateAccountStatus(ctx, account.ID, to); err != nil {
+		return fmt.Errorf("failed to update account status: %w", err)
+	}
+	account.Status = to
+	uc.recordStatusTransitions(ctx, account.ID, StatusTransition{Timestamp: uc.now().UTC(), From: from, To: to, Reason: reason})
+	return nil
+}
+
+// completeValidation 记录生命周期验证结果（validating → active | error）；调用方已持久化新状态
+// 账户不在 validating 状态（如 ACTIVE 账户的定时健康检查）时不属于生命周期迁移，不做记录
+func (uc *AccountUsecase) completeValidation(ctx context.Context, account *data.Account, to data.AccountStatus, reason string) {
+	if account.Status != data.StatusValidating {
+		return
+	}
+	account.Status = to
+	uc.recordStatusTransitions(ctx, account.ID, StatusTransition{Timestamp: uc.now().UTC(), From: data.StatusValidating, To: to, Reason: reason})
+}
+
+// recordStatusTransitions 写入账户状态迁移记录（LPUSH + LTRIM 固定长度，最新在前）
+// 写入失败只记录日志，不影响主流程
+func (uc *AccountUsecase) recordStatusTransitions(ctx context.Context, accountID int64, transitions ...StatusTransition) {
+	if uc.rdb == nil || len(transitions) == 0 {
+		return
+	}
+
+	payloads := make([]interface{}, 0, len(transitions))
+	for _, transition := range transitions {
+		payload, err := json.Marshal(transition)
+		if err != nil {
+			uc.logger.Warnw("failed to marshal status transition", "account_id", accountID, "error", err)
+			return
+		}
+		payloads = append(payloads, payload)
+	}
+
+	key := fmt.Sprintf("%s%d", StatusHistoryKeyPrefix, accountID)
+	pipe := uc.rdb.TxPipeline()
+	pipe.LPush(ctx, key, payloads...)
+	pipe.LTrim(ctx, key, 0, StatusHistoryMaxEntries-1)
+	pipe.Expire(ctx, key, StatusHistoryTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		uc.logger.Warnw("failed to record status transitions", "account_id", accountID, "error", err)
+	}
+}
+
+// GetAccountStatusHistory 查询账户最近的状态迁移记录（最新在前）
+// limit <= 0 或超过上限时返回全部已保留的记录
+func (uc *AccountUsecase) GetAccountStatusHistory(ctx context.Context, accountID int64, limit int) ([]*StatusTransition, error) {
+	if uc.rdb == nil {
+		return nil, fmt.Errorf("redis client is nil")
+	}
+
+	if limit <= 0 || limit > StatusHistoryMaxEntries {
+		limit = StatusHistoryMaxEntries
+	}
+
+	key := fmt.Sprintf("%s%d", StatusHistoryKeyPrefix, accountID)
+	values, err := uc.rdb.LRange(ctx, key, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status history: %w", err)
+	}
+
+	transitions := make([]*StatusTransition, 0, len(values))
+	for _, v := range values {
+		var transition StatusTransition
+		if err := json.Unmarshal([]byte(v), &transition); err != nil {
+			uc.logger.Warnw("skipping malformed status transition", "account_id", accountID, "error", err)
+			continue
+		}
+		transitions = append(transitions, &transition)
+	}
+
+	return transitions, nil
+}
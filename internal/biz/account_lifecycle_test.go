@@ -0,0 +1,166 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/crypto"
+	pkgoauth "QuotaLane/pkg/oauth"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// withStatusHistory attaches a miniredis-backed client so transitions are recorded.
+func withStatusHistory(t *testing.T, uc *AccountUsecase) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	uc.rdb = rdb
+}
+
+// statusPath returns the recorded transitions oldest first as "from->to" pairs.
+func statusPath(t *testing.T, uc *AccountUsecase, accountID int64) []string {
+	history, err := uc.GetAccountStatusHistory(context.Background(), accountID, 0)
+	require.NoError(t, err)
+
+	path := make([]string, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		path = append(path, string(history[i].From)+"->"+string(history[i].To))
+	}
+	return path
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to data.AccountStatus
+		want     bool
+	}{
+		{data.StatusCreated, data.StatusValidating, true},
+		{data.StatusValidating, data.StatusActive, true},
+		{data.StatusValidating, data.StatusError, true},
+		{data.StatusError, data.StatusValidating, true},
+		{data.StatusCreated, data.StatusActive, false},
+		{data.StatusCreated, data.StatusError, false},
+		{data.StatusActive, data.StatusValidating, false},
+		{data.StatusInactive, data.StatusValidating, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CanTransition(tt.from, tt.to), "%s -> %s", tt.from, tt.to)
+	}
+}
+
+// TestAccountLifecycle_APIKeyOnCreate walks an API-key account through validation on create.
+func TestAccountLifecycle_APIKeyOnCreate(t *testing.T) {
+	t.Run("valid key", func(t *testing.T) {
+		uc, mockRepo, _ := setupValidateOnCreate(t, nil)
+		withStatusHistory(t, uc)
+		mockRepo.On("CreateAccount", mock.Anything, mock.AnythingOfType("*data.Account")).
+			Run(func(args mock.Arguments) { args.Get(1).(*data.Account).ID = 7 }).
+			Return(nil).Once()
+
+		result, err := uc.CreateAccount(context.Background(), validateOnCreateRequest())
+		require.NoError(t, err)
+		assert.Equal(t, v1.AccountStatus_ACCOUNT_ACTIVE, result.Status)
+		assert.Equal(t, []string{"->created", "created->validating", "validating->active"}, statusPath(t, uc, 7))
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		uc, mockRepo, _ := setupValidateOnCreate(t, errors.New("invalid api key"))
+		withStatusHistory(t, uc)
+		mockRepo.On("CreateAccount", mock.Anything, mock.AnythingOfType("*data.Account")).
+			Run(func(args mock.Arguments) { args.Get(1).(*data.Account).ID = 7 }).
+			Return(nil).Once()
+
+		result, err := uc.CreateAccount(context.Background(), validateOnCreateRequest())
+		require.NoError(t, err)
+		assert.Equal(t, v1.AccountStatus_ACCOUNT_ERROR, result.Status)
+		assert.Equal(t, []string{"->created", "created->validating", "validating->error"}, statusPath(t, uc, 7))
+
+		history, err := uc.GetAccountStatusHistory(context.Background(), 7, 1)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, "invalid api key", history[0].Reason)
+	})
+}
+
+// TestAccountLifecycle_OAuthImport walks an imported OAuth account through the token refresh.
+func TestAccountLifecycle_OAuthImport(t *testing.T) {
+	t.Run("refresh succeeds", func(t *testing.T) {
+		prov := &mockOAuthProvider{tokenResp: &pkgoauth.ExtendedTokenResponse{AccessToken: "fresh-access", ExpiresIn: 3600}}
+		uc, mockRepo, cryptoHelper := setupImportTest(t, prov)
+		withStatusHistory(t, uc)
+		mockRepo.On("UpdateOAuthData", mock.Anything, int64(42), mock.Anything, mock.Anything).Return(nil).Once()
+		mockRepo.On("UpdateAccountStatus", mock.Anything, int64(42), data.StatusValidating).Return(nil).Once()
+		mockRepo.On("UpdateAccountStatus", mock.Anything, int64(42), data.StatusActive).Return(nil).Once()
+
+		resp, err := uc.ImportOAuthAccount(context.Background(), importRequest(t, cryptoHelper))
+		require.NoError(t, err)
+		assert.Equal(t, v1.AccountStatus_ACCOUNT_ACTIVE, resp.Status)
+		assert.Equal(t, []string{"->created", "created->validating", "validating->active"}, statusPath(t, uc, 42))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("refresh fails", func(t *testing.T) {
+		uc, mockRepo, cryptoHelper := setupImportTest(t, &mockOAuthProvider{err: errors.New("invalid_grant")})
+		withStatusHistory(t, uc)
+		mockRepo.On("UpdateAccountStatus", mock.Anything, int64(42), data.StatusValidating).Return(nil).Once()
+		mockRepo.On("UpdateAccountStatus", mock.Anything, int64(42), data.StatusError).Return(nil).Once()
+
+		resp, err := uc.ImportOAuthAccount(context.Background(), importRequest(t, cryptoHelper))
+		require.NoError(t, err)
+		assert.Equal(t, v1.AccountStatus_ACCOUNT_ERROR, resp.Status)
+		assert.Equal(t, []string{"->created", "created->validating", "validating->error"}, statusPath(t, uc, 42))
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// TestAccountLifecycle_OAuthExchange tests that a code exchange records the full lifecycle.
+func TestAccountLifecycle_OAuthExchange(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	cryptoHelper, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+	oauthManager := pkgoauth.NewOAuthManager(rdb, log.DefaultLogger)
+	oauthManager.RegisterProvider(&mockOAuthProvider{
+		authURL:      "https://console.anthropic.com/v1/oauth/authorize",
+		codeVerifier: "verifier",
+		tokenResp:    &pkgoauth.ExtendedTokenResponse{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 3600},
+	})
+	repo := &mockAccountRepo{}
+	uc := NewAccountUsecase(repo, cryptoHelper, nil, nil, oauthManager, nil, nil, nil, rdb, log.DefaultLogger)
+	ctx := context.Background()
+
+	_, sessionID, _, err := uc.GenerateOAuthURL(ctx, v1.AccountProvider_CLAUDE_OFFICIAL, "", "", nil, nil)
+	require.NoError(t, err)
+
+	accountID, _, status, _, err := uc.ExchangeOAuthCode(ctx, sessionID, "test-auth-code", "Claude", "", 0, 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "active", status)
+	require.Len(t, repo.accounts, 1)
+	assert.Equal(t, data.StatusActive, repo.accounts[0].Status)
+	assert.Equal(t, []string{"->created", "created->validating", "validating->active"}, statusPath(t, uc, accountID))
+}
+
+// TestTransitionStatus_RejectsInvalid tests that out-of-lifecycle transitions are not persisted.
+func TestTransitionStatus_RejectsInvalid(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	withStatusHistory(t, uc)
+	account := &data.Account{ID: 7, Status: data.StatusActive}
+
+	err := uc.transitionStatus(context.Background(), account, data.StatusValidating, "revalidate")
+	require.Error(t, err)
+	assert.Equal(t, data.StatusActive, account.Status)
+	mockRepo.AssertNotCalled(t, "UpdateAccountStatus", mock.Anything, mock.Anything, mock.Anything)
+	assert.Empty(t, statusPath(t, uc, 7))
+}
@@ -0,0 +1,29 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"QuotaLane/internal/data"
+)
+
+// CheckModelAllowed 检查账户是否允许服务指定模型，路由在向账户分发请求前调用
+// 白名单取自 metadata.allowed_models：列表为空时允许所有模型；匹配为精确匹配，忽略首尾空白与大小写
+// accountID: 账户 ID
+// model: 请求的模型名称
+// 返回是否允许，以及路由对该账户应使用的上游请求超时（见 ResolveRequestTimeout）；
+// 账户不存在或 metadata 无法解析时返回错误
+func (uc *AccountUsecase) CheckModelAllowed(ctx context.Context, accountID int64, model string) (bool, time.Duration, error) {
+	account, err := uc.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	meta, err := data.ParseMetadata(account.Metadata)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to parse account metadata: %w", err)
+	}
+
+	return meta.IsModelAllowed(model), ResolveRequestTimeout(account.Provider, meta), nil
+}
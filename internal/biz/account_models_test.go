@@ -0,0 +1,52 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckModelAllowed(t *testing.T) {
+	ctx := context.Background()
+	allowlist := `{"allowed_models":["gpt-4o","gpt-4o-mini"]}`
+	empty := `{"region":"us-east"}`
+
+	tests := []struct {
+		name     string
+		metadata *string
+		model    string
+		want     bool
+	}{
+		{"allowed model", &allowlist, "gpt-4o", true},
+		{"disallowed model", &allowlist, "o1-preview", false},
+		{"empty allowlist allows all", &empty, "o1-preview", true},
+		{"no metadata allows all", nil, "o1-preview", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockAccountRepo)
+			uc := &AccountUsecase{repo: repo, logger: log.NewHelper(log.DefaultLogger)}
+			repo.On("GetAccount", ctx, int64(1)).Return(&data.Account{ID: 1, Metadata: tt.metadata}, nil)
+
+			allowed, _, err := uc.CheckModelAllowed(ctx, 1, tt.model)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, allowed)
+		})
+	}
+
+	t.Run("account not found", func(t *testing.T) {
+		repo := new(MockAccountRepo)
+		uc := &AccountUsecase{repo: repo, logger: log.NewHelper(log.DefaultLogger)}
+		repo.On("GetAccount", ctx, int64(2)).Return(nil, errors.New("account not found"))
+
+		_, _, err := uc.CheckModelAllowed(ctx, 2, "gpt-4o")
+		assert.Error(t, err)
+	})
+}
@@ -9,9 +9,13 @@ import (
 
 	v1 "QuotaLane/api/v1"
 	"QuotaLane/internal/data"
+	pkglog "QuotaLane/pkg/log"
 	"QuotaLane/pkg/oauth"
 )
 
+// ErrOAuthSessionNotFound OAuth 会话不存在或已过期（调用方使用 errors.Is 判断）
+var ErrOAuthSessionNotFound = oauth.ErrSessionNotFound
+
 // GenerateOAuthURL 生成 OAuth 授权 URL
 func (uc *AccountUsecase) GenerateOAuthURL(
 	ctx context.Context,
@@ -26,6 +30,14 @@ func (uc *AccountUsecase) GenerateOAuthURL(
 	if err != nil {
 		return "", "", "", fmt.Errorf("unsupported provider: %w", err)
 	}
+	if err := uc.CheckProviderEnabled(dataProvider); err != nil {
+		return "", "", "", err
+	}
+
+	// 限制单个调用方的 Session 创建频率，防止刷满 Redis
+	if err := uc.checkOAuthSessionLimit(ctx); err != nil {
+		return "", "", "", err
+	}
 
 	// 构建 OAuth 参数
 	params := &oauth.OAuthParams{
@@ -33,6 +45,8 @@ func (uc *AccountUsecase) GenerateOAuthURL(
 		RedirectURI: redirectURI,
 		Scopes:      scopes,
 		Metadata:    metadata,
+		CreatorIP:   pkglog.GetClientIP(ctx),
+		CreatedBy:   pkglog.GetKeyName(ctx),
 	}
 
 	// 调用 OAuthManager 生成授权 URL
@@ -44,7 +58,14 @@ func (uc *AccountUsecase) GenerateOAuthURL(
 	return resp.AuthURL, resp.SessionID, resp.State, nil
 }
 
+// GetOAuthSessionStatus 查询 OAuth 会话的 Provider 与剩余有效期，供 UI 展示授权倒计时
+// 只返回非敏感字段（不含 code verifier、state）；会话不存在或已过期时返回 ErrOAuthSessionNotFound
+func (uc *AccountUsecase) GetOAuthSessionStatus(ctx context.Context, sessionID string) (*oauth.SessionStatus, error) {
+	return uc.oauthManager.GetSessionStatus(ctx, sessionID)
+}
+
 // ExchangeOAuthCode 交换 OAuth 授权码并创建账户
+// 授权码交换即凭证验证：created → validating（交换中）→ active；交换失败时不创建账户
 func (uc *AccountUsecase) ExchangeOAuthCode(
 	ctx context.Context,
 	sessionID string,
@@ -56,10 +77,19 @@ func (uc *AccountUsecase) ExchangeOAuthCode(
 	metadata map[string]string,
 ) (accountID int64, accountName string, status string, tokenExpiresAt *time.Time, err error) {
 	// 调用 OAuthManager 交换授权码
-	tokenResp, err := uc.oauthManager.ExchangeCode(ctx, sessionID, code)
-	if err != nil {
+	lifecycle := uc.newAccountLifecycle("OAuth authorization completed")
+	var tokenResp *oauth.ExtendedTokenResponse
+	if err := lifecycle.validate(func() error {
+		var exchangeErr error
+		tokenResp, exchangeErr = uc.oauthManager.ExchangeCode(ctx, sessionID, code)
+		return exchangeErr
+	}, "OAuth code exchanged"); err != nil {
 		return 0, "", "", nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
+	// The provider may have been disabled after the authorization URL was generated
+	if err := uc.CheckProviderEnabled(tokenResp.Provider); err != nil {
+		return 0, "", "", nil, err
+	}
 
 	// 加密存储 access_token 和 refresh_token
 	accessTokenEncrypted, err := uc.crypto.Encrypt(tokenResp.AccessToken)
@@ -73,17 +103,17 @@ func (uc *AccountUsecase) ExchangeOAuthCode(
 	}
 
 	// 计算 token 过期时间
-	expiresAt := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	expiresAt := uc.now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
 
 	// 构建 OAuth 数据（包含 ID Token、Organizations 等额外信息）
-	oauthData := map[string]interface{}{
-		"access_token_encrypted":  accessTokenEncrypted,
-		"refresh_token_encrypted": refreshTokenEncrypted,
-		"id_token":                tokenResp.IDToken,
-		"scopes":                  tokenResp.Scopes,
-		"organizations":           tokenResp.Organizations,
-		"account_id":              tokenResp.AccountID, // Codex CLI ChatGPT Account ID
-		"expires_at":              expiresAt.Format(time.RFC3339),
+	oauthData := StoredOAuthData{
+		AccessTokenEncrypted:  accessTokenEncrypted,
+		RefreshTokenEncrypted: refreshTokenEncrypted,
+		IDToken:               tokenResp.IDToken,
+		Scopes:                tokenResp.Scopes,
+		Organizations:         tokenResp.Organizations,
+		AccountID:             tokenResp.AccountID, // Codex CLI ChatGPT Account ID
+		ExpiresAt:             expiresAt,
 	}
 
 	oauthDataJSON, err := json.Marshal(oauthData)
@@ -124,13 +154,15 @@ func (uc *AccountUsecase) ExchangeOAuthCode(
 		RpmLimit:           rpmLimit,
 		TpmLimit:           tpmLimit,
 		HealthScore:        100,
-		Status:             data.StatusActive,
+		Status:             lifecycle.status,
 	}
 
 	// 保存到数据库
 	if err := uc.repo.CreateAccount(ctx, account); err != nil {
 		return 0, "", "", nil, fmt.Errorf("failed to create account: %w", err)
 	}
+	uc.recordStatusTransitions(ctx, account.ID, lifecycle.transitions...)
+	uc.bindCredentials(ctx, account)
 
 	uc.logger.Infof("OAuth account created successfully: id=%d, name=%s, provider=%s",
 		account.ID, account.Name, account.Provider)
@@ -138,32 +170,50 @@ func (uc *AccountUsecase) ExchangeOAuthCode(
 	return account.ID, account.Name, string(account.Status), &expiresAt, nil
 }
 
-// getProxyConfig 获取代理配置（三层优先级）
+// getProxyConfig 获取代理配置（按 proxyPrecedence 依次查找，默认：请求级 > 账户级 > 全局环境变量）
 func (uc *AccountUsecase) getProxyConfig(accountMetadata string, requestProxy string) string {
-	// 优先级 1: 请求级代理（RPC 参数）
-	if requestProxy != "" {
-		return requestProxy
+	precedence := uc.proxyPrecedence
+	if len(precedence) == 0 {
+		precedence = DefaultProxyPrecedence
+	}
+
+	for _, tier := range precedence {
+		if proxyURL := resolveProxyTier(tier, accountMetadata, requestProxy); proxyURL != "" {
+			return proxyURL
+		}
 	}
 
-	// 优先级 2: 账户级代理（从 Account.Metadata 读取）
-	if accountMetadata != "" {
+	// 无代理
+	return ""
+}
+
+// resolveProxyTier 返回单个优先级层级配置的代理（未配置时返回空）
+func resolveProxyTier(tier, accountMetadata, requestProxy string) string {
+	switch tier {
+	case ProxyTierRequest:
+		// 请求级代理（RPC 参数）
+		return requestProxy
+
+	case ProxyTierAccount:
+		// 账户级代理（从 Account.Metadata 读取）
+		if accountMetadata == "" {
+			return ""
+		}
 		var meta map[string]interface{}
 		if err := json.Unmarshal([]byte(accountMetadata), &meta); err == nil {
-			if proxyURL, ok := meta["proxy_url"].(string); ok && proxyURL != "" {
+			if proxyURL, ok := meta["proxy_url"].(string); ok {
 				return proxyURL
 			}
 		}
-	}
+		return ""
 
-	// 优先级 3: 全局代理（环境变量）
-	if httpProxy := os.Getenv("HTTP_PROXY"); httpProxy != "" {
-		return httpProxy
-	}
-	if httpsProxy := os.Getenv("HTTPS_PROXY"); httpsProxy != "" {
-		return httpsProxy
+	case ProxyTierEnv:
+		// 全局代理（环境变量）
+		if httpProxy := os.Getenv("HTTP_PROXY"); httpProxy != "" {
+			return httpProxy
+		}
+		return os.Getenv("HTTPS_PROXY")
 	}
-
-	// 无代理
 	return ""
 }
 
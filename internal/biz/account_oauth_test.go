@@ -43,6 +43,10 @@ func (m *mockAccountRepo) ListAccounts(ctx context.Context, filter *data.Account
 	return nil, 0, nil
 }
 
+func (m *mockAccountRepo) CountAccounts(ctx context.Context, filter *data.AccountFilter) (int64, error) {
+	return 0, nil
+}
+
 func (m *mockAccountRepo) UpdateAccount(ctx context.Context, account *data.Account) error {
 	return nil
 }
@@ -62,7 +66,15 @@ func (m *mockAccountRepo) ListAccountsByProvider(ctx context.Context, provider d
 	return nil, nil
 }
 
-func (m *mockAccountRepo) ListCodexCLIAccountsNeedingRefresh(ctx context.Context) ([]*data.Account, error) {
+func (m *mockAccountRepo) ListPurgeableAccounts(ctx context.Context, cutoff time.Time, limit int) ([]*data.Account, error) {
+	return nil, nil
+}
+
+func (m *mockAccountRepo) PurgeAccount(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (m *mockAccountRepo) ListCodexCLIAccountsNeedingRefresh(ctx context.Context, window time.Duration) ([]*data.Account, error) {
 	return nil, nil
 }
 
@@ -73,6 +85,26 @@ func (m *mockAccountRepo) UpdateOAuthData(ctx context.Context, accountID int64,
 	return nil
 }
 
+func (m *mockAccountRepo) SetLastCheckedAt(ctx context.Context, accountID int64, checkedAt time.Time) error {
+	return nil
+}
+
+func (m *mockAccountRepo) SetDraining(ctx context.Context, accountID int64, draining bool) error {
+	return nil
+}
+
+func (m *mockAccountRepo) SetNextRefreshAttempt(ctx context.Context, accountID int64, nextAttempt *time.Time) error {
+	return nil
+}
+
+func (m *mockAccountRepo) MarkNeedsReauth(ctx context.Context, accountID int64) error {
+	return nil
+}
+
+func (m *mockAccountRepo) MarkDecryptError(ctx context.Context, accountID int64, detail string) error {
+	return nil
+}
+
 func (m *mockAccountRepo) UpdateHealthScore(ctx context.Context, accountID int64, score int) error {
 	return nil
 }
@@ -85,12 +117,47 @@ func (m *mockAccountRepo) ListAccountsByTags(ctx context.Context, tags []string,
 	return nil, nil
 }
 
+func (m *mockAccountRepo) GetCapacityStats(ctx context.Context, provider data.AccountProvider) (*data.CapacityStats, error) {
+	return &data.CapacityStats{}, nil
+}
+
+func (m *mockAccountRepo) GetFleetHealthStats(ctx context.Context, filter data.FleetHealthFilter) ([]*data.FleetStatusStats, error) {
+	return nil, nil
+}
+
+func (m *mockAccountRepo) GetExpiryDistribution(ctx context.Context, providers []data.AccountProvider, now time.Time) (*data.ExpiryDistribution, error) {
+	return &data.ExpiryDistribution{}, nil
+}
+
+func (m *mockAccountRepo) RepairHealthScores(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockAccountRepo) FlagAccountsMissingProvider(ctx context.Context) ([]int64, error) {
+	return nil, nil
+}
+
+func (m *mockAccountRepo) UpdateBaseAPIByProvider(ctx context.Context, provider data.AccountProvider, oldBase, newBase string) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockAccountRepo) ListUnboundCredentialAccounts(ctx context.Context, afterID int64, limit int) ([]*data.Account, error) {
+	return nil, nil
+}
+
+func (m *mockAccountRepo) ReplaceCredentials(ctx context.Context, accountID int64, oldAPIKey, newAPIKey, oldOAuthData, newOAuthData string) (bool, error) {
+	return true, nil
+}
+
 // mockOAuthProvider implements oauth.OAuthProvider for testing
 type mockOAuthProvider struct {
 	authURL      string
 	codeVerifier string
 	tokenResp    *oauth.ExtendedTokenResponse
 	err          error
+	provider     data.AccountProvider // 默认 claude-official
+
+	lastRefreshToken string // 最近一次 RefreshToken 收到的 refresh token
 }
 
 func (m *mockOAuthProvider) GenerateAuthURL(ctx context.Context, params *oauth.OAuthParams) (*oauth.OAuthURLResponse, error) {
@@ -111,6 +178,7 @@ func (m *mockOAuthProvider) ExchangeCode(ctx context.Context, code string, sessi
 }
 
 func (m *mockOAuthProvider) RefreshToken(ctx context.Context, refreshToken string, metadata *oauth.AccountMetadata) (*oauth.ExtendedTokenResponse, error) {
+	m.lastRefreshToken = refreshToken
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -122,6 +190,9 @@ func (m *mockOAuthProvider) ValidateToken(ctx context.Context, token string, met
 }
 
 func (m *mockOAuthProvider) ProviderType() data.AccountProvider {
+	if m.provider != "" {
+		return m.provider
+	}
 	return data.ProviderClaudeOfficial
 }
 
@@ -352,6 +423,51 @@ func TestAccountUsecase_GetProxyConfig(t *testing.T) {
 	})
 }
 
+func TestAccountUsecase_GetProxyConfig_Precedence(t *testing.T) {
+	uc, _, _ := setupTestOAuth(t)
+	metadata := `{"proxy_url":"http://account-proxy:8080"}`
+	t.Setenv("HTTP_PROXY", "http://global-proxy:8080")
+
+	t.Run("Account before request", func(t *testing.T) {
+		require.NoError(t, uc.SetProxyPrecedence([]string{ProxyTierAccount, ProxyTierRequest, ProxyTierEnv}))
+		defer uc.SetProxyPrecedence(nil)
+
+		assert.Equal(t, "http://account-proxy:8080", uc.getProxyConfig(metadata, "socks5://request-proxy:1080"),
+			"Account-level proxy should win over request-level proxy")
+		assert.Equal(t, "socks5://request-proxy:1080", uc.getProxyConfig("", "socks5://request-proxy:1080"),
+			"Request-level proxy is used when the account has none")
+	})
+
+	t.Run("Env first", func(t *testing.T) {
+		require.NoError(t, uc.SetProxyPrecedence([]string{ProxyTierEnv, ProxyTierRequest}))
+		defer uc.SetProxyPrecedence(nil)
+
+		assert.Equal(t, "http://global-proxy:8080", uc.getProxyConfig(metadata, "socks5://request-proxy:1080"))
+	})
+
+	t.Run("Omitted tier is never consulted", func(t *testing.T) {
+		require.NoError(t, uc.SetProxyPrecedence([]string{ProxyTierAccount}))
+		defer uc.SetProxyPrecedence(nil)
+
+		assert.Empty(t, uc.getProxyConfig("", "socks5://request-proxy:1080"))
+	})
+
+	t.Run("Empty order restores default", func(t *testing.T) {
+		require.NoError(t, uc.SetProxyPrecedence([]string{ProxyTierAccount, ProxyTierRequest}))
+		require.NoError(t, uc.SetProxyPrecedence(nil))
+
+		assert.Equal(t, "socks5://request-proxy:1080", uc.getProxyConfig(metadata, "socks5://request-proxy:1080"))
+	})
+
+	t.Run("Invalid order rejected", func(t *testing.T) {
+		assert.ErrorContains(t, uc.SetProxyPrecedence([]string{ProxyTierAccount, "global"}), "unknown proxy precedence tier")
+		assert.ErrorContains(t, uc.SetProxyPrecedence([]string{ProxyTierAccount, ProxyTierAccount}), "duplicate proxy precedence tier")
+
+		assert.Equal(t, "socks5://request-proxy:1080", uc.getProxyConfig(metadata, "socks5://request-proxy:1080"),
+			"A rejected order leaves the current precedence unchanged")
+	})
+}
+
 func TestProtoProviderToDataProvider(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -2,12 +2,15 @@ package biz
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"QuotaLane/internal/data"
 	"QuotaLane/pkg/oauth"
+	"QuotaLane/pkg/openai"
 )
 
 const (
@@ -33,20 +36,49 @@ type ErrorRecord struct {
 	OccurredAt time.Time `json:"occurred_at"`
 }
 
+// SetProviderDownHealthPenalty 设置上游故障（持续 5xx、网络/代理错误）导致验证失败时扣减的健康分
+// 这类失败通常是 Provider 宕机而非账户失效，默认 0 表示不扣分；401/403 等凭证错误始终按固定分值扣减
+func (uc *AccountUsecase) SetProviderDownHealthPenalty(penalty int) {
+	if penalty < 0 {
+		penalty = 0
+	}
+	uc.providerDownPenalty = penalty
+}
+
+// SetHealthRecoveryStep 设置验证或刷新成功时健康分的恢复方式
+// 0（默认）：直接恢复至 100；N > 0：每次成功增加 N 分（最高 100），避免频繁抖动的账户一次成功即完全恢复
+func (uc *AccountUsecase) SetHealthRecoveryStep(step int) {
+	uc.healthRecoveryStep = min(max(step, 0), 100)
+}
+
+// recoveredHealthScore 返回一次成功后的健康分
+func (uc *AccountUsecase) recoveredHealthScore(current int) int {
+	if uc.healthRecoveryStep <= 0 {
+		return 100
+	}
+	return min(max(current, 0)+uc.healthRecoveryStep, 100)
+}
+
 // ValidateOpenAIResponsesAccount 验证 OpenAI Responses 账户
 // accountID: 账户 ID
 // 返回: 验证成功返回 nil，失败返回错误
 func (uc *AccountUsecase) ValidateOpenAIResponsesAccount(ctx context.Context, accountID int64) error {
-	// 1. 从 Repo 读取账户信息
 	account, err := uc.repo.GetAccount(ctx, accountID)
 	if err != nil {
 		return fmt.Errorf("failed to get account: %w", err)
 	}
+	return uc.validateOpenAIResponsesAccount(ctx, account)
+}
 
+// validateOpenAIResponsesAccount 验证已加载的 OpenAI Responses 账户
+func (uc *AccountUsecase) validateOpenAIResponsesAccount(ctx context.Context, account *data.Account) error {
 	// 验证 Provider 类型
 	if account.Provider != data.ProviderOpenAIResponses {
 		return fmt.Errorf("account is not OpenAI Responses type: provider=%s", account.Provider)
 	}
+	if err := uc.CheckProviderEnabled(account.Provider); err != nil {
+		return err
+	}
 
 	// 验证必填字段
 	if account.APIKeyEncrypted == "" {
@@ -57,10 +89,10 @@ func (uc *AccountUsecase) ValidateOpenAIResponsesAccount(ctx context.Context, ac
 	}
 
 	// 2. 解密 API Key
-	apiKey, err := uc.crypto.Decrypt(account.APIKeyEncrypted)
+	apiKey, err := uc.decryptCredential(account.ID, account.APIKeyEncrypted)
 	if err != nil {
 		uc.logger.Errorw("failed to decrypt API key",
-			"account_id", accountID,
+			"account_id", account.ID,
 			"error", err)
 		return fmt.Errorf("failed to decrypt API key: %w", err)
 	}
@@ -71,7 +103,7 @@ func (uc *AccountUsecase) ValidateOpenAIResponsesAccount(ctx context.Context, ac
 		var metadata map[string]interface{}
 		if err := json.Unmarshal([]byte(*account.Metadata), &metadata); err != nil {
 			uc.logger.Warnw("failed to parse metadata JSON, skipping proxy",
-				"account_id", accountID,
+				"account_id", account.ID,
 				"error", err)
 		} else if proxy, ok := metadata["proxy_url"].(string); ok {
 			proxyURL = proxy
@@ -84,28 +116,75 @@ func (uc *AccountUsecase) ValidateOpenAIResponsesAccount(ctx context.Context, ac
 		return fmt.Errorf("OpenAI Responses provider not registered")
 	}
 
+	// 加载 mTLS 客户端证书（企业网关要求双向 TLS 时配置；元数据解析失败时已在上方告警）
+	var clientCert *tls.Certificate
+	if meta, parseErr := data.ParseMetadata(account.Metadata); parseErr == nil {
+		clientCert, err = uc.loadClientCertificate(meta)
+		if err != nil {
+			uc.logger.Errorw("failed to load client certificate",
+				"account_id", account.ID,
+				"error", err)
+			return fmt.Errorf("failed to load client certificate: %w", err)
+		}
+	}
+
 	// 构建 AccountMetadata
 	accountMetadata := &oauth.AccountMetadata{
-		ProxyURL: proxyURL,
-		BaseAPI:  account.BaseAPI,
+		ProxyURL:          proxyURL,
+		BaseAPI:           account.BaseAPI,
+		ClientCertificate: clientCert,
 	}
 
-	// 调用 Provider 验证 API Key
-	err = provider.ValidateToken(ctx, apiKey, accountMetadata)
+	return uc.runValidation(ctx, account, func(ctx context.Context) error {
+		return provider.ValidateToken(ctx, apiKey, accountMetadata)
+	})
+}
 
-	if err != nil {
+// runValidation 执行一次凭证验证：进入 validating → 调用 Provider → 落库成功/失败结果
+// 未激活（created）或验证失败（error）的账户进入生命周期验证：→ validating → active | error。
+// 调用超时或被取消后仍需落库验证结果，后续写入不受调用方截止时间影响；
+// 落库失败导致账户仍停留在 validating 时兜底迁移到 error，保证任何路径都会离开 validating
+func (uc *AccountUsecase) runValidation(ctx context.Context, account *data.Account, call func(ctx context.Context) error) (err error) {
+	if CanTransition(account.Status, data.StatusValidating) {
+		if err := uc.transitionStatus(ctx, account, data.StatusValidating, "validating API key"); err != nil {
+			return err
+		}
+	}
+
+	bookkeepingCtx := context.WithoutCancel(ctx)
+	defer func() {
+		if account.Status != data.StatusValidating {
+			return
+		}
+		reason := "validation result could not be recorded"
+		if err != nil {
+			reason = err.Error()
+		}
+		if transitionErr := uc.transitionStatus(bookkeepingCtx, account, data.StatusError, reason); transitionErr != nil {
+			uc.logger.Errorw("failed to leave validating status",
+				"account_id", account.ID,
+				"error", transitionErr)
+		}
+	}()
+
+	startedAt := time.Now()
+	callErr := call(ctx)
+	uc.recordHealthHistory(bookkeepingCtx, account.ID, HealthHistorySourceValidation, startedAt, callErr)
+
+	if callErr != nil {
 		// 验证失败：记录错误、减分、更新状态
-		return uc.handleValidationFailure(ctx, account, err)
+		return uc.handleValidationFailure(bookkeepingCtx, account, callErr)
 	}
 
 	// 5. 验证成功：恢复健康分数、更新状态、清除错误记录
-	return uc.handleValidationSuccess(ctx, account)
+	return uc.handleValidationSuccess(bookkeepingCtx, account)
 }
 
 // handleValidationSuccess 处理验证成功的情况
 func (uc *AccountUsecase) handleValidationSuccess(ctx context.Context, account *data.Account) error {
-	// 更新健康分数为 100
-	if err := uc.repo.UpdateHealthScore(ctx, account.ID, 100); err != nil {
+	// 恢复健康分数（直接恢复至 100 或按配置逐步恢复）
+	healthScore := uc.recoveredHealthScore(account.HealthScore)
+	if err := uc.repo.UpdateHealthScore(ctx, account.ID, healthScore); err != nil {
 		uc.logger.Errorw("failed to update health score after success",
 			"account_id", account.ID,
 			"error", err)
@@ -119,8 +198,10 @@ func (uc *AccountUsecase) handleValidationSuccess(ctx context.Context, account *
 			"error", err)
 		return err
 	}
+	uc.completeValidation(ctx, account, data.StatusActive, "API key validated")
 
-	// 清除连续失败计数和错误记录
+	// 清除连续失败计数和错误记录（同步内存中的健康分，避免整行保存时覆盖）
+	account.HealthScore = healthScore
 	account.ConsecutiveErrors = 0
 	account.LastError = nil
 	account.LastErrorAt = nil
@@ -149,20 +230,25 @@ func (uc *AccountUsecase) handleValidationSuccess(ctx context.Context, account *
 			uc.logger.Infow("circuit breaker recovered",
 				"account_id", account.ID,
 				"account_name", account.Name,
-				"health_score", 100)
+				"health_score", healthScore)
 		}
 	}
 
 	uc.logger.Infow("OpenAI account validation succeeded",
 		"account_id", account.ID,
 		"account_name", account.Name,
-		"health_score", 100)
+		"health_score", healthScore)
 
 	return nil
 }
 
 // handleValidationFailure 处理验证失败的情况
 func (uc *AccountUsecase) handleValidationFailure(ctx context.Context, account *data.Account, validationErr error) error {
+	// 上游故障（网络/代理错误、重试耗尽的 5xx）无法说明凭证无效：记录错误，按配置扣分，不改状态
+	if isProviderDownError(validationErr) {
+		return uc.recordProviderDownFailure(ctx, account, validationErr)
+	}
+
 	// 减少健康分数 20 分（与 Story 2.2 保持一致）
 	newScore := account.HealthScore - 20
 	if err := uc.repo.UpdateHealthScore(ctx, account.ID, newScore); err != nil {
@@ -179,6 +265,12 @@ func (uc *AccountUsecase) handleValidationFailure(ctx context.Context, account *
 			"error", err)
 		return err
 	}
+	uc.completeValidation(ctx, account, data.StatusError, validationErr.Error())
+
+	// 命中立即熔断状态码（如 403 账户被封禁）时直接熔断，不等待健康分下降
+	if uc.tripOnImmediateFailure(ctx, account.ID, validationErr) {
+		account.IsCircuitBroken = true
+	}
 
 	// 记录错误信息
 	errorRecord := ErrorRecord{
@@ -186,12 +278,12 @@ func (uc *AccountUsecase) handleValidationFailure(ctx context.Context, account *
 		Message:    validationErr.Error(),
 		RetryCount: 3, // OpenAI 服务默认重试 3 次
 		BaseAPI:    account.BaseAPI,
-		OccurredAt: time.Now(),
+		OccurredAt: uc.now(),
 	}
 	errorJSON, _ := json.Marshal(errorRecord)
 	errorStr := string(errorJSON)
 
-	now := time.Now()
+	now := uc.now()
 	account.LastError = &errorStr
 	account.LastErrorAt = &now
 	account.ConsecutiveErrors++
@@ -246,11 +338,30 @@ func (uc *AccountUsecase) handleValidationFailure(ctx context.Context, account *
 	return validationErr
 }
 
-// HealthCheckOpenAIResponsesAccounts 批量健康检查所有 ACTIVE 状态的 OpenAI Responses 账户
-// 定时任务调用此方法
+// tripOnImmediateFailure 失败状态码命中熔断器的立即熔断配置时直接打开熔断器，返回是否已熔断
+func (uc *AccountUsecase) tripOnImmediateFailure(ctx context.Context, accountID int64, failureErr error) bool {
+	if uc.circuitBreaker == nil {
+		return false
+	}
+	tripped, err := uc.circuitBreaker.TripOnStatus(ctx, accountID, openai.StatusCode(failureErr))
+	if err != nil {
+		uc.logger.Warnw("failed to trip circuit breaker on status",
+			"account_id", accountID,
+			"error", err)
+	}
+	return tripped
+}
+
+// HealthCheckOpenAIResponsesAccounts 批量健康检查 ACTIVE 状态的 OpenAI Responses 账户
+// 定时任务调用此方法；配置了抽样数量时每轮只检查最久未检查的 N 个账户
 func (uc *AccountUsecase) HealthCheckOpenAIResponsesAccounts(ctx context.Context) error {
 	startTime := time.Now()
 
+	if !uc.ProviderEnabled(data.ProviderOpenAIResponses) {
+		uc.logger.Infow("OpenAI Responses provider is disabled, skipping health check")
+		return nil
+	}
+
 	// 查询所有 ACTIVE 状态的 OpenAI Responses 账户
 	accounts, err := uc.repo.ListAccountsByProvider(ctx, data.ProviderOpenAIResponses, data.StatusActive)
 	if err != nil {
@@ -258,15 +369,24 @@ func (uc *AccountUsecase) HealthCheckOpenAIResponsesAccounts(ctx context.Context
 		return fmt.Errorf("failed to list accounts: %w", err)
 	}
 
-	totalCount := len(accounts)
-	if totalCount == 0 {
+	if len(accounts) == 0 {
 		uc.logger.Infow("no active OpenAI Responses accounts to check")
 		return nil
 	}
 
+	activeCount := len(accounts)
+	accounts = uc.selectHealthCheckSample(accounts)
+	totalCount := len(accounts)
+
 	uc.logger.Infow("starting OpenAI Responses health check",
+		"active_accounts", activeCount,
 		"total_accounts", totalCount)
 
+	validator, ok := uc.ValidatorFor(data.ProviderOpenAIResponses)
+	if !ok {
+		return fmt.Errorf("no validator registered for provider %s", data.ProviderOpenAIResponses)
+	}
+
 	// 使用 semaphore 限制并发数为 5
 	semaphore := make(chan struct{}, MaxConcurrentHealthCheck)
 	results := make(chan error, totalCount)
@@ -278,8 +398,24 @@ func (uc *AccountUsecase) HealthCheckOpenAIResponsesAccounts(ctx context.Context
 		go func(acc *data.Account) {
 			defer func() { <-semaphore }() // 释放信号量
 
-			// 执行健康检查
-			err := uc.ValidateOpenAIResponsesAccount(ctx, acc.ID)
+			// 获取全局 Provider 调用名额（与其他后台任务共享）
+			if err := uc.providerLimiter.Acquire(ctx); err != nil {
+				results <- err
+				return
+			}
+			defer uc.providerLimiter.Release()
+
+			// 执行健康检查（单次调用独立超时，避免挂起的上游拖住整轮检查）
+			callCtx, cancel := withProviderDeadline(ctx, uc.providerCallTimeout)
+			_, err := validator.Validate(callCtx, acc)
+			cancel()
+
+			// 记录检查时间（无论成功与否），供下一轮抽样轮换
+			if markErr := uc.repo.SetLastCheckedAt(ctx, acc.ID, uc.now().UTC()); markErr != nil {
+				uc.logger.Warnw("failed to record last checked time",
+					"account_id", acc.ID,
+					"error", markErr)
+			}
 			results <- err
 		}(account)
 	}
@@ -307,8 +443,69 @@ func (uc *AccountUsecase) HealthCheckOpenAIResponsesAccounts(ctx context.Context
 	return nil
 }
 
-// extractErrorCode 从错误消息中提取 HTTP 状态码
+// isProviderDownError 判断验证失败是否由上游故障导致（网络/代理错误或持续 5xx），而非账户凭证无效
+func isProviderDownError(err error) bool {
+	var serverErr *openai.ServerError
+	return openai.IsNetworkError(err) || errors.As(err, &serverErr)
+}
+
+// recordProviderDownFailure 记录上游故障类验证失败（保留 last_error，状态不变，健康分按 providerDownPenalty 扣减）
+// 生命周期验证中（validating）的账户无法完成验证，进入 error 等待重新验证
+func (uc *AccountUsecase) recordProviderDownFailure(ctx context.Context, account *data.Account, validationErr error) error {
+	if account.Status == data.StatusValidating {
+		if err := uc.transitionStatus(ctx, account, data.StatusError, validationErr.Error()); err != nil {
+			uc.logger.Warnw("failed to leave validating status after provider failure",
+				"account_id", account.ID,
+				"error", err)
+		}
+	}
+
+	errorRecord := ErrorRecord{
+		Code:       extractErrorCode(validationErr),
+		Message:    validationErr.Error(),
+		RetryCount: 3,
+		BaseAPI:    account.BaseAPI,
+		OccurredAt: uc.now(),
+	}
+	errorJSON, _ := json.Marshal(errorRecord)
+	errorStr := string(errorJSON)
+
+	now := uc.now()
+	account.LastError = &errorStr
+	account.LastErrorAt = &now
+	if err := uc.repo.UpdateAccount(ctx, account); err != nil {
+		uc.logger.Warnw("failed to update error records",
+			"account_id", account.ID,
+			"error", err)
+	}
+
+	newScore := account.HealthScore
+	if uc.providerDownPenalty > 0 {
+		newScore -= uc.providerDownPenalty
+		if err := uc.repo.UpdateHealthScore(ctx, account.ID, newScore); err != nil {
+			uc.logger.Errorw("failed to update health score after provider failure",
+				"account_id", account.ID,
+				"error", err)
+			return err
+		}
+	}
+
+	uc.logger.Warnw("OpenAI account validation failed with provider error",
+		"account_id", account.ID,
+		"account_name", account.Name,
+		"error", validationErr,
+		"health_penalty", uc.providerDownPenalty,
+		"new_health_score", newScore)
+
+	return validationErr
+}
+
+// extractErrorCode 从错误中提取 HTTP 状态码
+// 优先使用 pkg/openai 类型化错误中的状态码，其次从错误消息中解析
 func extractErrorCode(err error) int {
+	if code := openai.StatusCode(err); code != 0 {
+		return code
+	}
 	errMsg := err.Error()
 	// 简单的状态码提取逻辑
 	if errMsg == "" {
@@ -0,0 +1,128 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+const (
+	// ProbeLockKeyPrefix 试探进行中标记前缀，同一账户同时只允许一个试探
+	ProbeLockKeyPrefix = "probe_lock:"
+
+	// ProbeLockTTL 试探标记 TTL（覆盖一次验证/刷新的最长耗时）
+	ProbeLockTTL = 2 * time.Minute
+)
+
+// ProbeResult 一次账户试探的结果
+type ProbeResult struct {
+	Success             bool   // 验证是否成功
+	Error               string // 验证失败原因
+	PreviousHealthScore int    // 试探前的健康分数
+	HealthScore         int    // 试探后的健康分数
+	CircuitBroken       bool   // 试探后是否仍处于熔断状态
+}
+
+// ProbeAccount 对账户执行一次试探性验证，用于确认修复后账户是否真正恢复
+// 验证直接调用 Provider，不受熔断器影响；成功时解除熔断（健康分数保持验证后的结果，不同于 ResetHealthScore 直接重置为 100），
+// 失败时恢复试探前的健康分数、状态和熔断状态，避免一次试探造成永久影响。每次试探记录到账户健康历史（来源 probe）
+func (uc *AccountUsecase) ProbeAccount(ctx context.Context, id int64) (*ProbeResult, error) {
+	if uc.circuitBreaker == nil {
+		return nil, fmt.Errorf("circuit breaker is not configured")
+	}
+
+	account, err := uc.repo.GetAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录试探前的状态快照（验证过程会修改健康分数、状态）
+	prior := *account
+
+	// API Key 账户调用验证接口，OAuth 账户执行一次 Token 刷新
+	validator, ok := uc.ValidatorFor(account.Provider)
+	if !ok {
+		return nil, errors.BadRequest("PROBE_NOT_SUPPORTED",
+			fmt.Sprintf("account %d (provider: %s) does not support probing", id, account.Provider))
+	}
+
+	if uc.rdb != nil {
+		lockKey := fmt.Sprintf("%s%d", ProbeLockKeyPrefix, id)
+		acquired, err := uc.rdb.SetNX(ctx, lockKey, uc.now().UTC().Format(time.RFC3339), ProbeLockTTL).Result()
+		if err != nil {
+			uc.logger.Warnf("failed to set probe lock for account %d: %v (probe allowed)", id, err)
+		} else if !acquired {
+			return nil, errors.Conflict("PROBE_IN_PROGRESS", fmt.Sprintf("account %d is already being probed", id))
+		} else {
+			defer func() {
+				if err := uc.rdb.Del(context.WithoutCancel(ctx), lockKey).Err(); err != nil {
+					uc.logger.Warnf("failed to release probe lock for account %d: %v", id, err)
+				}
+			}()
+		}
+	}
+
+	startedAt := time.Now()
+	_, probeErr := validator.Validate(ctx, account)
+	// 验证超时或调用方取消后仍需完成状态恢复
+	bookkeepingCtx := context.WithoutCancel(ctx)
+	uc.recordHealthHistory(bookkeepingCtx, id, HealthHistorySourceProbe, startedAt, probeErr)
+
+	result := &ProbeResult{
+		Success:             probeErr == nil,
+		PreviousHealthScore: prior.HealthScore,
+	}
+	if probeErr == nil {
+		err = uc.circuitBreaker.ClearAfterProbe(bookkeepingCtx, id)
+	} else {
+		result.Error = probeErr.Error()
+		err = uc.restoreAfterProbe(bookkeepingCtx, &prior)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply probe outcome for account %d: %w", id, err)
+	}
+
+	result.HealthScore = prior.HealthScore
+	result.CircuitBroken = prior.IsCircuitBroken
+	if current, err := uc.circuitBreaker.repo.GetAccount(bookkeepingCtx, id); err == nil {
+		result.HealthScore = current.HealthScore
+		result.CircuitBroken = current.IsCircuitBroken
+	}
+
+	uc.logger.Infow("account probe completed",
+		"account_id", id,
+		"provider", prior.Provider,
+		"success", result.Success,
+		"error", result.Error,
+		"previous_health_score", result.PreviousHealthScore,
+		"health_score", result.HealthScore,
+		"circuit_broken", result.CircuitBroken)
+	return result, nil
+}
+
+// restoreAfterProbe 试探失败后恢复试探前的健康分数、账户状态和熔断状态
+func (uc *AccountUsecase) restoreAfterProbe(ctx context.Context, prior *data.Account) error {
+	current, err := uc.repo.GetAccount(ctx, prior.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+
+	if current.HealthScore != prior.HealthScore {
+		if err := uc.repo.UpdateHealthScore(ctx, prior.ID, prior.HealthScore); err != nil {
+			return fmt.Errorf("failed to restore health score: %w", err)
+		}
+	}
+	if current.Status != prior.Status {
+		if err := uc.repo.UpdateAccountStatus(ctx, prior.ID, prior.Status); err != nil {
+			return fmt.Errorf("failed to restore account status: %w", err)
+		}
+	}
+	if err := uc.circuitBreaker.RestoreCircuitState(ctx, prior.ID, prior.IsCircuitBroken, prior.CircuitBrokenAt); err != nil {
+		return fmt.Errorf("failed to restore circuit breaker: %w", err)
+	}
+	return nil
+}
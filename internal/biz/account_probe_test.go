@@ -0,0 +1,153 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/oauth"
+	"QuotaLane/pkg/openai"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupProbeTest wires a circuit-broken Codex account into the on-demand refresh test usecase.
+func setupProbeTest(t *testing.T, provider *mockOAuthProvider) (*refreshAccountTest, *fakeCircuitBreakerRepo, *data.Account) {
+	provider.provider = data.ProviderCodexCLI
+	s := setupRefreshAccountTest(t, provider)
+
+	cb, cbRepo := setupCircuitBreakerModeTest(t, DefaultCircuitBreakerConfig())
+	brokenAt := s.clock.Now().Add(-time.Hour)
+	cbRepo.account = data.Account{ID: 5, HealthScore: 20, IsCircuitBroken: true, CircuitBrokenAt: &brokenAt}
+	s.uc.circuitBreaker = cb
+
+	accessToken, err := s.crypto.Encrypt("old-access")
+	require.NoError(t, err)
+	refreshToken, err := s.crypto.Encrypt("fixed-refresh")
+	require.NoError(t, err)
+	expiresAt := s.clock.Now().Add(-time.Hour)
+	account := &data.Account{
+		ID:              5,
+		Name:            "codex",
+		Provider:        data.ProviderCodexCLI,
+		Status:          data.StatusActive,
+		HealthScore:     20,
+		IsCircuitBroken: true,
+		CircuitBrokenAt: &brokenAt,
+		OAuthExpiresAt:  &expiresAt,
+		OAuthDataEncrypted: s.encrypt(t, StoredOAuthData{
+			AccessTokenEncrypted:  accessToken,
+			RefreshTokenEncrypted: refreshToken,
+			ExpiresAt:             expiresAt,
+		}),
+	}
+	s.repo.On("GetAccount", mock.Anything, int64(5)).Return(account, nil)
+	// Health score writes land on the stored account so restore sees the probe's changes
+	s.repo.On("UpdateHealthScore", mock.Anything, int64(5), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		account.HealthScore = args.Int(2)
+	})
+	return s, cbRepo, account
+}
+
+func probeHistory(t *testing.T, s *refreshAccountTest, id int64) []*HealthHistoryEntry {
+	history, err := s.uc.GetAccountHealthHistory(context.Background(), id, 0)
+	require.NoError(t, err)
+	return history
+}
+
+// TestProbeAccount_SuccessClearsBreaker tests that a successful probe closes the breaker without
+// resetting the health score to 100.
+func TestProbeAccount_SuccessClearsBreaker(t *testing.T) {
+	s, cbRepo, account := setupProbeTest(t, &mockOAuthProvider{
+		tokenResp: &oauth.ExtendedTokenResponse{AccessToken: "new-access", RefreshToken: "new-refresh", ExpiresIn: 3600},
+	})
+	s.uc.SetHealthRecoveryStep(30)
+	s.repo.On("UpdateOAuthData", mock.Anything, int64(5), mock.Anything, mock.Anything).Return(nil)
+
+	result, err := s.uc.ProbeAccount(context.Background(), 5)
+	require.NoError(t, err)
+
+	assert.True(t, result.Success)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, 20, result.PreviousHealthScore)
+	assert.False(t, result.CircuitBroken)
+	assert.False(t, cbRepo.isBroken(), "breaker is cleared")
+	assert.Equal(t, 50, account.HealthScore, "health recovers by one step instead of a full reset")
+	assert.False(t, s.mr.Exists(ProbeLockKeyPrefix+"5"), "probe lock is released")
+
+	history := probeHistory(t, s, 5)
+	require.NotEmpty(t, history)
+	assert.Equal(t, HealthHistorySourceProbe, history[0].Source)
+	assert.True(t, history[0].Success)
+}
+
+// TestProbeAccount_FailureRestoresState tests that a failed probe keeps the breaker open and
+// undoes the health score penalty and status change the validation applied.
+func TestProbeAccount_FailureRestoresState(t *testing.T) {
+	s, cbRepo, account := setupProbeTest(t, &mockOAuthProvider{
+		err: openai.NewHTTPError(http.StatusUnauthorized, nil, `{"error":"unauthorized"}`),
+	})
+	s.uc.SetMarkNeedsReauth(false)
+	s.repo.On("SetNextRefreshAttempt", mock.Anything, int64(5), mock.Anything).Return(nil)
+	brokenAt := *cbRepo.account.CircuitBrokenAt
+
+	result, err := s.uc.ProbeAccount(context.Background(), 5)
+	require.NoError(t, err)
+
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "OAuth refresh failed")
+	assert.True(t, result.CircuitBroken)
+	assert.True(t, cbRepo.isBroken(), "breaker stays open")
+	assert.Equal(t, brokenAt, *cbRepo.account.CircuitBrokenAt, "break time is kept so the backoff is not restarted")
+
+	s.repo.AssertCalled(t, "UpdateHealthScore", mock.Anything, int64(5), 0)
+	assert.Equal(t, 20, account.HealthScore, "probe penalty is rolled back")
+	s.repo.AssertNotCalled(t, "UpdateOAuthData", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	history := probeHistory(t, s, 5)
+	require.NotEmpty(t, history)
+	assert.Equal(t, HealthHistorySourceProbe, history[0].Source)
+	assert.False(t, history[0].Success)
+}
+
+// TestProbeAccount_Concurrent tests that a second probe of the same account is rejected while one runs.
+func TestProbeAccount_Concurrent(t *testing.T) {
+	s, _, _ := setupProbeTest(t, &mockOAuthProvider{err: errors.New("must not be called")})
+	require.NoError(t, s.mr.Set(ProbeLockKeyPrefix+"5", "2026-01-01T00:00:00Z"))
+
+	_, err := s.uc.ProbeAccount(context.Background(), 5)
+	require.Error(t, err)
+	assert.Equal(t, "PROBE_IN_PROGRESS", kerrors.FromError(err).Reason)
+}
+
+// TestProbeAccount_UnsupportedProvider tests that providers without a validation path are rejected.
+func TestProbeAccount_UnsupportedProvider(t *testing.T) {
+	s := setupRefreshAccountTest(t, &mockOAuthProvider{})
+	cb, _ := setupCircuitBreakerModeTest(t, DefaultCircuitBreakerConfig())
+	s.uc.circuitBreaker = cb
+	s.repo.On("GetAccount", mock.Anything, int64(6)).Return(&data.Account{ID: 6, Provider: data.ProviderBedrock}, nil)
+
+	_, err := s.uc.ProbeAccount(context.Background(), 6)
+	require.Error(t, err)
+	assert.Equal(t, "PROBE_NOT_SUPPORTED", kerrors.FromError(err).Reason)
+}
+
+// TestRestoreCircuitState tests that a breaker closed during a failed probe is reopened with its original break time.
+func TestRestoreCircuitState(t *testing.T) {
+	cb, cbRepo := setupCircuitBreakerModeTest(t, DefaultCircuitBreakerConfig())
+	ctx := context.Background()
+	brokenAt := time.Now().Add(-time.Hour).UTC()
+
+	require.NoError(t, cb.RestoreCircuitState(ctx, 1, true, &brokenAt))
+	assert.True(t, cbRepo.isBroken())
+	assert.Equal(t, brokenAt, *cbRepo.account.CircuitBrokenAt)
+
+	require.NoError(t, cb.RestoreCircuitState(ctx, 1, false, nil))
+	assert.False(t, cbRepo.isBroken())
+}
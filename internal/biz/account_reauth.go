@@ -0,0 +1,59 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+
+	"QuotaLane/pkg/openai"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// ReauthAlertKeyPrefix Redis 重新授权告警标记前缀
+const ReauthAlertKeyPrefix = "alert:reauth:"
+
+// SetMarkNeedsReauth 设置 refresh token 永久失效（invalid_grant）时的处理策略
+// true（默认）：标记账户需要重新授权并停止定时刷新；false：按普通刷新失败处理（退避重试、连续失败后置为 error）
+func (uc *AccountUsecase) SetMarkNeedsReauth(enabled bool) {
+	uc.markNeedsReauth = enabled
+}
+
+// SetMarkNeedsReauth 设置 refresh token 永久失效时是否标记账户需要重新授权，与 AccountUsecase 保持一致
+func (t *OAuthRefreshTask) SetMarkNeedsReauth(enabled bool) {
+	t.markNeedsReauth = enabled
+}
+
+// handleInvalidGrant 在策略开启且刷新错误为 invalid_grant 时标记账户需要重新授权并设置告警
+// 返回 true 表示已处理，调用方不再走普通失败流程（扣分、计数、退避重试）
+func (uc *AccountUsecase) handleInvalidGrant(ctx context.Context, accountID int64, refreshErr error) bool {
+	if !uc.markNeedsReauth || !openai.IsInvalidGrant(refreshErr) {
+		return false
+	}
+
+	if !markNeedsReauth(ctx, uc.repo, uc.logger, accountID, refreshErr) {
+		return false
+	}
+
+	if uc.rdb != nil {
+		alertKey := fmt.Sprintf("%s%d", ReauthAlertKeyPrefix, accountID)
+		alertMsg := fmt.Sprintf("Account %d needs re-authorization: refresh token is invalid or revoked. Last error: %v",
+			accountID, refreshErr)
+		if err := uc.rdb.Set(ctx, alertKey, alertMsg, AlertTTL).Err(); err != nil {
+			uc.logger.Warnf("failed to set re-auth alert marker: %v", err)
+		}
+	}
+	return true
+}
+
+// markNeedsReauth 将账户标记为需要重新授权，失败时返回 false 以便调用方按普通失败处理
+func markNeedsReauth(ctx context.Context, repo AccountRepo, logger *log.Helper, accountID int64, refreshErr error) bool {
+	if err := repo.MarkNeedsReauth(ctx, accountID); err != nil {
+		logger.Warnf("failed to mark account %d as needing re-auth: %v", accountID, err)
+		return false
+	}
+
+	logger.Errorw("account needs re-authorization: refresh token permanently rejected",
+		"account_id", accountID,
+		"error", refreshErr)
+	return true
+}
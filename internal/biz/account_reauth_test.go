@@ -0,0 +1,130 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/crypto"
+	"QuotaLane/pkg/oauth"
+	"QuotaLane/pkg/openai"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// invalidGrantErr mimics a provider rejecting a revoked refresh token.
+func invalidGrantErr() error {
+	return fmt.Errorf("OAuth error: %w", openai.NewHTTPError(http.StatusBadRequest, nil, `{"error":"invalid_grant"}`))
+}
+
+// TestHandleRefreshFailure_InvalidGrantMarksNeedsReauth tests that a revoked refresh token
+// flags the account for re-auth and raises an alert instead of scheduling retries.
+func TestHandleRefreshFailure_InvalidGrantMarksNeedsReauth(t *testing.T) {
+	uc, mockRepo, mr := setupRefreshFailureTest(t, 10*time.Minute)
+	mockRepo.On("MarkNeedsReauth", mock.Anything, int64(1)).Return(nil).Once()
+
+	require.NoError(t, uc.handleRefreshFailure(context.Background(), 1, invalidGrantErr()))
+
+	mockRepo.AssertCalled(t, "MarkNeedsReauth", mock.Anything, int64(1))
+	mockRepo.AssertNotCalled(t, "UpdateHealthScore", mock.Anything, int64(1), mock.Anything)
+	assert.Empty(t, nextRefreshAttempts(mockRepo), "accounts awaiting re-auth are not retried")
+	assert.False(t, mr.Exists("refresh_failure:1"))
+
+	alert, err := mr.Get(ReauthAlertKeyPrefix + "1")
+	require.NoError(t, err)
+	assert.Contains(t, alert, "needs re-authorization")
+}
+
+// TestHandleRefreshFailure_InvalidGrantPolicyDisabled tests that invalid_grant falls back to
+// the regular failure path when the policy is turned off.
+func TestHandleRefreshFailure_InvalidGrantPolicyDisabled(t *testing.T) {
+	uc, mockRepo, mr := setupRefreshFailureTest(t, 10*time.Minute)
+	uc.SetMarkNeedsReauth(false)
+
+	require.NoError(t, uc.handleRefreshFailure(context.Background(), 1, invalidGrantErr()))
+
+	mockRepo.AssertNotCalled(t, "MarkNeedsReauth", mock.Anything, mock.Anything)
+	mockRepo.AssertCalled(t, "UpdateHealthScore", mock.Anything, int64(1), 80)
+	assert.True(t, mr.Exists("refresh_failure:1"))
+	assert.Len(t, nextRefreshAttempts(mockRepo), 1)
+}
+
+// TestHandleRefreshFailure_OtherClientErrorsNotReauth tests that only invalid_grant triggers re-auth.
+func TestHandleRefreshFailure_OtherClientErrorsNotReauth(t *testing.T) {
+	uc, mockRepo, _ := setupRefreshFailureTest(t, 10*time.Minute)
+	err := openai.NewHTTPError(http.StatusBadRequest, nil, `{"error":"invalid_request"}`)
+
+	require.NoError(t, uc.handleRefreshFailure(context.Background(), 1, err))
+
+	mockRepo.AssertNotCalled(t, "MarkNeedsReauth", mock.Anything, mock.Anything)
+	mockRepo.AssertCalled(t, "UpdateHealthScore", mock.Anything, int64(1), 80)
+}
+
+// TestHandleRefreshFailure_MarkNeedsReauthFails tests that a failed flag write still backs off the account.
+func TestHandleRefreshFailure_MarkNeedsReauthFails(t *testing.T) {
+	uc, mockRepo, mr := setupRefreshFailureTest(t, 10*time.Minute)
+	mockRepo.On("MarkNeedsReauth", mock.Anything, int64(1)).Return(errors.New("db down")).Once()
+
+	require.NoError(t, uc.handleRefreshFailure(context.Background(), 1, invalidGrantErr()))
+
+	mockRepo.AssertCalled(t, "UpdateHealthScore", mock.Anything, int64(1), 80)
+	assert.Len(t, nextRefreshAttempts(mockRepo), 1)
+	assert.False(t, mr.Exists(ReauthAlertKeyPrefix+"1"))
+}
+
+// TestOAuthRefreshTask_InvalidGrantMarksNeedsReauth tests that the unified refresh job flags revoked accounts.
+func TestOAuthRefreshTask_InvalidGrantMarksNeedsReauth(t *testing.T) {
+	cryptoHelper, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+
+	oauthManager := oauth.NewOAuthManager(nil, log.DefaultLogger)
+	oauthManager.RegisterProvider(&mockOAuthProvider{err: invalidGrantErr()})
+
+	accessToken, err := cryptoHelper.Encrypt("access")
+	require.NoError(t, err)
+	refreshToken, err := cryptoHelper.Encrypt("revoked-refresh")
+	require.NoError(t, err)
+	oauthJSON, err := json.Marshal(StoredOAuthData{
+		AccessTokenEncrypted:  accessToken,
+		RefreshTokenEncrypted: refreshToken,
+		ExpiresAt:             time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	oauthDataEncrypted, err := cryptoHelper.Encrypt(string(oauthJSON))
+	require.NoError(t, err)
+
+	account := &data.Account{ID: 7, Name: "revoked", Provider: data.ProviderClaudeOfficial, OAuthDataEncrypted: oauthDataEncrypted}
+
+	t.Run("marks account", func(t *testing.T) {
+		mockRepo := new(MockAccountRepo)
+		mockRepo.On("ListExpiringAccounts", mock.Anything, mock.Anything).Return([]*data.Account{account}, nil)
+		mockRepo.On("ListCodexCLIAccountsNeedingRefresh", mock.Anything, ExpiringRefreshThreshold).Return([]*data.Account{}, nil)
+		mockRepo.On("MarkNeedsReauth", mock.Anything, int64(7)).Return(nil).Once()
+
+		task := NewOAuthRefreshTask(mockRepo, oauthManager, cryptoHelper, nil, log.DefaultLogger)
+		require.NoError(t, task.RefreshExpiringTokens(context.Background()))
+
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "UpdateOAuthData", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("policy disabled", func(t *testing.T) {
+		mockRepo := new(MockAccountRepo)
+		mockRepo.On("ListExpiringAccounts", mock.Anything, mock.Anything).Return([]*data.Account{account}, nil)
+		mockRepo.On("ListCodexCLIAccountsNeedingRefresh", mock.Anything, ExpiringRefreshThreshold).Return([]*data.Account{}, nil)
+
+		task := NewOAuthRefreshTask(mockRepo, oauthManager, cryptoHelper, nil, log.DefaultLogger)
+		task.SetMarkNeedsReauth(false)
+		require.NoError(t, task.RefreshExpiringTokens(context.Background()))
+
+		mockRepo.AssertNotCalled(t, "MarkNeedsReauth", mock.Anything, mock.Anything)
+	})
+}
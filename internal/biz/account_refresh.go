@@ -2,13 +2,13 @@ package biz
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
 
 	"QuotaLane/internal/data"
 	pkgoauth "QuotaLane/pkg/oauth"
+	"QuotaLane/pkg/openai"
 
 	"github.com/go-kratos/kratos/v2/errors"
 )
@@ -31,14 +31,27 @@ const (
 
 	// AlertTTL 告警标记 TTL（24 小时）
 	AlertTTL = 24 * time.Hour
-)
 
-// OAuthData represents the decrypted OAuth data structure.
-type OAuthData struct {
-	AccessToken  string    `json:"access_token"`
-	RefreshToken string    `json:"refresh_token"`
-	ExpiresAt    time.Time `json:"expires_at"`
-}
+	// DefaultRefreshFailureGraceWindow 默认过期前宽限窗口（1 小时）
+	// Token 剩余有效期超过该窗口时，刷新失败不计入连续失败次数
+	DefaultRefreshFailureGraceWindow = time.Hour
+
+	// RefreshBackoffBase 刷新失败后的首次退避时间（与定时任务间隔一致，5 分钟）
+	RefreshBackoffBase = 5 * time.Minute
+
+	// RefreshBackoffMax 刷新退避时间上限（1 小时）
+	RefreshBackoffMax = time.Hour
+
+	// DefaultRefreshAccountTimeout 批量刷新中单个账户刷新的默认超时时间
+	// 防止单个上游调用挂起耗尽整个定时任务的执行时间
+	DefaultRefreshAccountTimeout = 30 * time.Second
+
+	// AutoRefreshThreshold 兜底刷新任务（AutoRefreshTokens）选取 Token 在该时间内过期的账户
+	AutoRefreshThreshold = 10 * time.Minute
+
+	// ExpiringRefreshThreshold 统一刷新任务（OAuthRefreshTask）默认选取 Token 在该时间内过期的账户
+	ExpiringRefreshThreshold = 2 * time.Hour
+)
 
 // RefreshClaudeToken 刷新指定账户的 Claude OAuth Token
 // accountID: 账户 ID
@@ -55,89 +68,53 @@ func (uc *AccountUsecase) RefreshClaudeToken(ctx context.Context, accountID int6
 		return fmt.Errorf("account %d is not a Claude account (provider: %s)", accountID, account.Provider)
 	}
 
-	// 2. 解密 OAuth 数据
-	if account.OAuthDataEncrypted == "" {
-		return fmt.Errorf("account %d has no OAuth data", accountID)
-	}
-
-	decrypted, err := uc.crypto.Decrypt(account.OAuthDataEncrypted)
-	if err != nil {
-		uc.logger.Errorf("failed to decrypt OAuth data for account %d: %v", accountID, err)
-		return fmt.Errorf("failed to decrypt OAuth data")
-	}
-
-	var oauthData OAuthData
-	if err := json.Unmarshal([]byte(decrypted), &oauthData); err != nil {
-		uc.logger.Errorf("failed to parse OAuth data for account %d: %v", accountID, err)
-		return fmt.Errorf("failed to parse OAuth data")
-	}
-
-	// 3. 提取 refresh_token
-	refreshToken := oauthData.RefreshToken
-	if refreshToken == "" {
-		return fmt.Errorf("account %d has no refresh_token", accountID)
-	}
+	_, err = uc.refreshStoredOAuthAccount(ctx, account)
+	return err
+}
 
-	// 4. 解析 metadata 并转换为 OAuth metadata 格式
-	var oauthMeta *pkgoauth.AccountMetadata
-	if account.Metadata != nil && *account.Metadata != "" {
-		// 使用 metadata 包解析
-		meta, err := data.ParseMetadata(account.Metadata)
-		if err != nil {
-			uc.logger.Warnf("failed to parse account metadata for account %d: %v", accountID, err)
-		} else if !meta.IsEmpty() {
-			// 转换为 OAuth metadata 格式
-			oauthMeta = &pkgoauth.AccountMetadata{
-				ProxyURL: meta.ProxyURL,
-			}
-			// 如果代理未启用，清空 proxy_url
-			if !meta.ProxyEnabled {
-				oauthMeta.ProxyURL = ""
-			}
-		}
+// refreshOAuthMetadata 从账户 metadata 中提取刷新请求使用的代理配置（代理未启用时不使用代理）
+func (uc *AccountUsecase) refreshOAuthMetadata(account *data.Account) *pkgoauth.AccountMetadata {
+	if account.Metadata == nil || *account.Metadata == "" {
+		return nil
 	}
 
-	// 5. 调用统一 OAuth Manager 刷新 Token
-	tokenResp, err := uc.oauthManager.RefreshToken(ctx, account.Provider, refreshToken, oauthMeta)
+	// 使用 metadata 包解析
+	meta, err := data.ParseMetadata(account.Metadata)
 	if err != nil {
-		uc.logger.Errorf("OAuth refresh failed for account %d: %v", accountID, err)
-
-		// 处理刷新失败
-		if err := uc.handleRefreshFailure(ctx, accountID, err); err != nil {
-			uc.logger.Warnf("failed to handle refresh failure: %v", err)
-		}
-
-		return fmt.Errorf("OAuth refresh failed: %w", err)
+		uc.logger.Warnf("failed to parse account metadata for account %d: %v", account.ID, err)
+		return nil
 	}
-
-	// 6. 构建新的 OAuth 数据
-	newExpiresAt := time.Now().UTC().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
-	newOAuthData := OAuthData{
-		AccessToken:  tokenResp.AccessToken,
-		RefreshToken: tokenResp.RefreshToken,
-		ExpiresAt:    newExpiresAt,
+	if meta.IsEmpty() {
+		return nil
 	}
 
-	// 7. 加密新的 OAuth 数据
-	newJSON, err := json.Marshal(newOAuthData)
-	if err != nil {
-		return fmt.Errorf("failed to marshal OAuth data: %w", err)
+	// 转换为 OAuth metadata 格式
+	oauthMeta := &pkgoauth.AccountMetadata{
+		ProxyURL: meta.ProxyURL,
 	}
-
-	encrypted, err := uc.crypto.Encrypt(string(newJSON))
-	if err != nil {
-		uc.logger.Errorf("failed to encrypt OAuth data for account %d: %v", accountID, err)
-		return fmt.Errorf("failed to encrypt OAuth data")
+	// 如果代理未启用，清空 proxy_url
+	if !meta.ProxyEnabled {
+		oauthMeta.ProxyURL = ""
 	}
+	return oauthMeta
+}
 
-	// 8. 更新数据库
-	if err := uc.repo.UpdateOAuthData(ctx, accountID, encrypted, newExpiresAt); err != nil {
-		return fmt.Errorf("failed to update OAuth data: %w", err)
+// markRefreshSucceeded 刷新成功后的账户维护：恢复健康分数、created 账户完成验证、清除失败计数器
+func (uc *AccountUsecase) markRefreshSucceeded(ctx context.Context, account *data.Account, validatingAt, newExpiresAt time.Time) {
+	accountID := account.ID
+	if err := uc.repo.UpdateHealthScore(ctx, accountID, uc.recoveredHealthScore(account.HealthScore)); err != nil {
+		uc.logger.Warnf("failed to reset health score for account %d: %v", accountID, err)
 	}
 
-	// 9. 刷新成功，重置健康分数并清除失败计数器
-	if err := uc.repo.UpdateHealthScore(ctx, accountID, 100); err != nil {
-		uc.logger.Warnf("failed to reset health score for account %d: %v", accountID, err)
+	// 未验证账户（created）首次刷新成功即完成验证：created → validating → active
+	if account.Status == data.StatusCreated {
+		if err := uc.repo.UpdateAccountStatus(ctx, accountID, data.StatusActive); err != nil {
+			uc.logger.Warnf("failed to activate account %d: %v", accountID, err)
+		} else {
+			uc.recordStatusTransitions(ctx, accountID,
+				StatusTransition{Timestamp: validatingAt, From: data.StatusCreated, To: data.StatusValidating, Reason: "refreshing OAuth token"},
+				StatusTransition{Timestamp: uc.now().UTC(), From: data.StatusValidating, To: data.StatusActive, Reason: "OAuth token refreshed"})
+		}
 	}
 
 	// 清除失败计数器
@@ -152,12 +129,27 @@ func (uc *AccountUsecase) RefreshClaudeToken(ctx context.Context, accountID int6
 		"account_id", accountID,
 		"name", account.Name,
 		"expires_at", newExpiresAt)
-
-	return nil
 }
 
 // handleRefreshFailure 处理 Token 刷新失败
 func (uc *AccountUsecase) handleRefreshFailure(ctx context.Context, accountID int64, refreshErr error) error {
+	// 网络/代理故障或单账户刷新超时与账户凭证无关：不扣健康分、不计失败次数，退避后重试
+	if openai.IsNetworkError(refreshErr) || errors.Is(refreshErr, context.DeadlineExceeded) {
+		uc.logger.Warnw("refresh failed with transient error, health score unchanged",
+			"account_id", accountID,
+			"error", refreshErr)
+		uc.scheduleRefreshRetry(ctx, accountID, 1)
+		return nil
+	}
+
+	// refresh token 永久失效（invalid_grant）时重试无意义：标记需要重新授权，不再参与定时刷新
+	if uc.handleInvalidGrant(ctx, accountID, refreshErr) {
+		return nil
+	}
+
+	// 命中立即熔断状态码（如 403 账户被封禁）时直接熔断，健康分照常扣减
+	uc.tripOnImmediateFailure(ctx, accountID, refreshErr)
+
 	// 更新健康分数减 20 分
 	account, err := uc.repo.GetAccount(ctx, accountID)
 	if err != nil {
@@ -169,9 +161,21 @@ func (uc *AccountUsecase) handleRefreshFailure(ctx context.Context, accountID in
 		return fmt.Errorf("failed to update health score: %w", err)
 	}
 
+	// Token 仍有充足有效期时，瞬时失败不计入连续失败次数（退避后由定时任务重试）
+	if remaining, ok := uc.inRefreshGracePeriod(account); ok {
+		uc.logger.Warnw("refresh failure ignored: token still valid outside grace window",
+			"account_id", accountID,
+			"remaining", remaining,
+			"grace_window", uc.refreshGraceWindow(),
+			"error", refreshErr)
+		uc.scheduleRefreshRetry(ctx, accountID, 1)
+		return nil
+	}
+
 	// 使用 Redis 跟踪失败次数
 	if uc.rdb == nil {
 		uc.logger.Warn("Redis client is nil, cannot track failure count")
+		uc.scheduleRefreshRetry(ctx, accountID, 1)
 		return nil
 	}
 
@@ -193,6 +197,8 @@ func (uc *AccountUsecase) handleRefreshFailure(ctx context.Context, accountID in
 		"failure_count", failureCount,
 		"error", refreshErr)
 
+	uc.scheduleRefreshRetry(ctx, accountID, failureCount)
+
 	// 检查是否连续失败 3 次
 	if failureCount >= MaxConsecutiveFailures {
 		// 标记账户为 ERROR 状态
@@ -225,13 +231,92 @@ func (uc *AccountUsecase) handleRefreshFailure(ctx context.Context, accountID in
 	return nil
 }
 
+// refreshBackoff 返回第 failures 次连续刷新失败后的退避时间
+// 从 RefreshBackoffBase 开始每次翻倍，最多 RefreshBackoffMax
+func refreshBackoff(failures int64) time.Duration {
+	backoff := RefreshBackoffBase
+	for i := int64(1); i < failures && backoff < RefreshBackoffMax; i++ {
+		backoff *= 2
+	}
+	return min(backoff, RefreshBackoffMax)
+}
+
+// scheduleRefreshRetry 记录下次允许刷新的时间，ListExpiringAccounts 会跳过仍在退避期的账户
+// 写入失败只记录日志，账户会在下个定时周期照常重试
+func (uc *AccountUsecase) scheduleRefreshRetry(ctx context.Context, accountID int64, failures int64) {
+	backoff := refreshBackoff(failures)
+	nextAttempt := uc.now().UTC().Add(backoff)
+	if err := uc.repo.SetNextRefreshAttempt(ctx, accountID, &nextAttempt); err != nil {
+		uc.logger.Warnf("failed to set next refresh attempt for account %d: %v", accountID, err)
+		return
+	}
+
+	uc.logger.Infow("refresh retry scheduled",
+		"account_id", accountID,
+		"failures", failures,
+		"backoff", backoff,
+		"next_attempt_at", nextAttempt)
+}
+
+// SetRefreshFailureGraceWindow 设置过期前宽限窗口
+// Token 剩余有效期大于该窗口时，刷新失败只记录日志，不计入连续失败次数；d <= 0 时恢复默认值
+func (uc *AccountUsecase) SetRefreshFailureGraceWindow(d time.Duration) {
+	uc.refreshFailureGrace = d
+}
+
+// refreshGraceWindow 返回当前生效的宽限窗口
+func (uc *AccountUsecase) refreshGraceWindow() time.Duration {
+	if uc.refreshFailureGrace <= 0 {
+		return DefaultRefreshFailureGraceWindow
+	}
+	return uc.refreshFailureGrace
+}
+
+// SetRefreshAccountTimeout 设置批量刷新中单个账户刷新的超时时间；d <= 0 时恢复默认值
+func (uc *AccountUsecase) SetRefreshAccountTimeout(d time.Duration) {
+	uc.refreshTimeout = d
+}
+
+// refreshAccountTimeout 返回当前生效的单账户刷新超时时间
+func refreshAccountTimeout(d time.Duration) time.Duration {
+	if d <= 0 {
+		return DefaultRefreshAccountTimeout
+	}
+	return d
+}
+
+// SetExpiringRefreshThreshold 设置统一刷新任务的刷新窗口（用于运行时配置展示）；d <= 0 时恢复默认值
+func (uc *AccountUsecase) SetExpiringRefreshThreshold(d time.Duration) {
+	uc.expiringThreshold = d
+}
+
+// expiringRefreshThreshold 返回当前生效的统一刷新任务刷新窗口
+func expiringRefreshThreshold(d time.Duration) time.Duration {
+	if d <= 0 {
+		return ExpiringRefreshThreshold
+	}
+	return d
+}
+
+// inRefreshGracePeriod 判断刷新失败是否处于宽限期（Token 剩余有效期大于宽限窗口）
+// 返回剩余有效期；过期时间未知时视为不在宽限期，失败照常计数
+func (uc *AccountUsecase) inRefreshGracePeriod(account *data.Account) (time.Duration, bool) {
+	if account.OAuthExpiresAt == nil {
+		return 0, false
+	}
+	remaining := account.OAuthExpiresAt.Sub(uc.now())
+	return remaining, remaining > uc.refreshGraceWindow()
+}
+
 // AutoRefreshTokens 自动刷新即将过期的 Claude 账户 Token（定时任务调用）
 // 查询 oauth_expires_at 在未来 10 分钟内的账户并触发刷新
+// 仅作为 OAuthRefreshTask 两次运行之间的兜底；与其共享 RefreshGuard，窗口内已被刷新的账户会被跳过
 func (uc *AccountUsecase) AutoRefreshTokens(ctx context.Context) error {
 	startTime := time.Now()
+	uc.markRefreshCronRun(ctx, startTime)
 
 	// 查询即将过期的账户（未来 10 分钟内）
-	threshold := time.Now().UTC().Add(10 * time.Minute)
+	threshold := uc.now().UTC().Add(AutoRefreshThreshold)
 	accounts, err := uc.repo.ListExpiringAccounts(ctx, threshold)
 	if err != nil {
 		return fmt.Errorf("failed to list expiring accounts: %w", err)
@@ -239,6 +324,7 @@ func (uc *AccountUsecase) AutoRefreshTokens(ctx context.Context) error {
 
 	if len(accounts) == 0 {
 		uc.logger.Info("no expiring accounts found")
+		recordRefreshRun(ctx, uc.refreshRuns, uc.logger, RefreshRunJobAuto, startTime, 0, 0, 0)
 		return nil
 	}
 
@@ -263,8 +349,27 @@ func (uc *AccountUsecase) AutoRefreshTokens(ctx context.Context) error {
 			defer wg.Done()
 			defer func() { <-sem }() // 释放信号量
 
-			// 刷新 Token
-			if err := uc.RefreshClaudeToken(ctx, acc.ID); err != nil {
+			// 窗口内已被统一刷新任务刷新过的账户直接跳过
+			if !uc.refreshGuard.TryClaim(ctx, acc.ID, RefreshRunJobAuto) {
+				return
+			}
+
+			// 获取全局 Provider 调用名额（与其他后台任务共享）
+			if err := uc.providerLimiter.Acquire(ctx); err != nil {
+				uc.refreshGuard.Release(ctx, acc.ID)
+				uc.logger.Errorf("failed to acquire provider slot for account %d: %v", acc.ID, err)
+				mu.Lock()
+				failureCount++
+				mu.Unlock()
+				return
+			}
+			defer uc.providerLimiter.Release()
+
+			// 刷新 Token（每个账户独立超时，避免单个挂起的调用拖垮整批刷新）
+			accountCtx, cancel := context.WithTimeout(ctx, refreshAccountTimeout(uc.refreshTimeout))
+			defer cancel()
+			if err := uc.RefreshClaudeToken(accountCtx, acc.ID); err != nil {
+				uc.refreshGuard.Release(ctx, acc.ID)
 				uc.logger.Errorf("failed to refresh account %d (%s): %v", acc.ID, acc.Name, err)
 				mu.Lock()
 				failureCount++
@@ -288,6 +393,8 @@ func (uc *AccountUsecase) AutoRefreshTokens(ctx context.Context) error {
 		"failure_count", failureCount,
 		"elapsed", elapsed)
 
+	recordRefreshRun(ctx, uc.refreshRuns, uc.logger, RefreshRunJobAuto, startTime, int32(len(accounts)), successCount, failureCount)
+
 	// 如果所有账户都刷新失败，返回错误
 	if failureCount > 0 && successCount == 0 {
 		return errors.InternalServer("AUTO_REFRESH_ALL_FAILED", "all account token refresh attempts failed")
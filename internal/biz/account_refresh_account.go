@@ -0,0 +1,118 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// accountRefresher 单个 Provider 的 Token 刷新函数，返回新的过期时间
+type accountRefresher func(uc *AccountUsecase, ctx context.Context, account *data.Account) (time.Time, error)
+
+// accountRefreshers 支持立即刷新的 Provider 及其刷新函数
+// 注册后的 Provider 同时支持以 Token 刷新方式验证（见 ValidatorFor）
+var accountRefreshers = map[data.AccountProvider]accountRefresher{
+	data.ProviderClaudeOfficial: (*AccountUsecase).refreshStoredOAuthAccount,
+	data.ProviderClaudeConsole:  (*AccountUsecase).refreshStoredOAuthAccount,
+	data.ProviderCodexCLI:       (*AccountUsecase).refreshStoredOAuthAccount,
+}
+
+// RefreshAccount 立即刷新指定账户的 Token（按 Provider 分发到对应的刷新函数），返回新的过期时间
+// 用于运维手动修复 refresh token 后单独触发刷新，无需等待定时任务或批量刷新；
+// 成功时恢复健康分数并清除失败计数，失败时按定时刷新的失败流程扣分、计数并安排重试
+func (uc *AccountUsecase) RefreshAccount(ctx context.Context, id int64) (time.Time, error) {
+	account, err := uc.repo.GetAccount(ctx, id)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	refresh, ok := accountRefreshers[account.Provider]
+	if !ok {
+		return time.Time{}, errors.BadRequest("REFRESH_NOT_SUPPORTED",
+			fmt.Sprintf("account %d (provider: %s) does not support token refresh", id, account.Provider))
+	}
+
+	uc.logger.Infow("refreshing account token on demand",
+		"account_id", id,
+		"provider", account.Provider)
+	return refresh(uc, ctx, account)
+}
+
+// refreshStoredOAuthAccount 刷新以 StoredOAuthData 格式保存凭证的 OAuth 账户（Claude、Codex CLI），返回新的过期时间
+// 保留响应中未返回的 ID Token、Scopes 等字段
+func (uc *AccountUsecase) refreshStoredOAuthAccount(ctx context.Context, account *data.Account) (time.Time, error) {
+	if account.OAuthDataEncrypted == "" {
+		return time.Time{}, fmt.Errorf("account %d has no OAuth data", account.ID)
+	}
+
+	oauthDataJSON, err := uc.decryptCredential(account.ID, account.OAuthDataEncrypted)
+	if err != nil {
+		uc.logger.Errorf("failed to decrypt OAuth data for account %d: %v", account.ID, err)
+		return time.Time{}, fmt.Errorf("failed to decrypt OAuth data")
+	}
+
+	oauthData, err := ParseStoredOAuthData(oauthDataJSON)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse OAuth data: %w", err)
+	}
+	// 历史格式（明文 Token）先转换为加密字段，刷新成功后以新格式保存
+	if err := oauthData.EncryptLegacyTokens(uc.crypto); err != nil {
+		return time.Time{}, err
+	}
+
+	refreshToken, err := uc.crypto.Decrypt(oauthData.RefreshTokenEncrypted)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to decrypt refresh token: %w", err)
+	}
+
+	startedAt := time.Now()
+	validatingAt := uc.now().UTC()
+	tokenResp, err := uc.oauthManager.RefreshToken(ctx, account.Provider, refreshToken, uc.refreshOAuthMetadata(account))
+	bookkeepingCtx := context.WithoutCancel(ctx)
+	uc.recordHealthHistory(bookkeepingCtx, account.ID, HealthHistorySourceRefresh, startedAt, err)
+	if err != nil {
+		uc.logger.Errorf("OAuth refresh failed for account %d: %v", account.ID, err)
+		if err := uc.handleRefreshFailure(bookkeepingCtx, account.ID, err); err != nil {
+			uc.logger.Warnf("failed to handle refresh failure: %v", err)
+		}
+		return time.Time{}, fmt.Errorf("OAuth refresh failed: %w", err)
+	}
+
+	if oauthData.AccessTokenEncrypted, err = uc.crypto.Encrypt(tokenResp.AccessToken); err != nil {
+		return time.Time{}, fmt.Errorf("failed to encrypt access token: %w", err)
+	}
+	if tokenResp.RefreshToken != "" {
+		if oauthData.RefreshTokenEncrypted, err = uc.crypto.Encrypt(tokenResp.RefreshToken); err != nil {
+			return time.Time{}, fmt.Errorf("failed to encrypt refresh token: %w", err)
+		}
+	}
+	if tokenResp.IDToken != "" {
+		oauthData.IDToken = tokenResp.IDToken
+	}
+	if len(tokenResp.Scopes) > 0 {
+		oauthData.Scopes = tokenResp.Scopes
+	}
+	if len(tokenResp.Organizations) > 0 {
+		oauthData.Organizations = tokenResp.Organizations
+	}
+	if tokenResp.AccountID != "" {
+		oauthData.AccountID = tokenResp.AccountID
+	}
+	newExpiresAt := uc.now().UTC().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	oauthData.ExpiresAt = newExpiresAt
+
+	encrypted, err := uc.encryptStoredOAuthData(account.ID, *oauthData)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := uc.repo.UpdateOAuthData(ctx, account.ID, encrypted, newExpiresAt); err != nil {
+		return time.Time{}, fmt.Errorf("failed to update OAuth data: %w", err)
+	}
+
+	uc.markRefreshSucceeded(ctx, account, validatingAt, newExpiresAt)
+	return newExpiresAt, nil
+}
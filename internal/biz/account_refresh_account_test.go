@@ -0,0 +1,260 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/crypto"
+	"QuotaLane/pkg/oauth"
+	"QuotaLane/pkg/openai"
+
+	"github.com/alicebob/miniredis/v2"
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type refreshAccountTest struct {
+	uc       *AccountUsecase
+	repo     *MockAccountRepo
+	crypto   *crypto.AESCrypto
+	mr       *miniredis.Miniredis
+	clock    *fakeClock
+	provider *mockOAuthProvider
+}
+
+func setupRefreshAccountTest(t *testing.T, provider *mockOAuthProvider) *refreshAccountTest {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	cryptoHelper, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+
+	oauthManager := oauth.NewOAuthManager(nil, log.DefaultLogger)
+	oauthManager.RegisterProvider(provider)
+
+	mockRepo := new(MockAccountRepo)
+	uc := NewAccountUsecase(mockRepo, cryptoHelper, nil, nil, oauthManager, nil, nil, nil, rdb, log.DefaultLogger)
+	clock := newFakeClock()
+	uc.SetClock(clock)
+
+	return &refreshAccountTest{uc: uc, repo: mockRepo, crypto: cryptoHelper, mr: mr, clock: clock, provider: provider}
+}
+
+func (s *refreshAccountTest) encrypt(t *testing.T, v interface{}) string {
+	raw, err := json.Marshal(v)
+	require.NoError(t, err)
+	encrypted, err := s.crypto.Encrypt(string(raw))
+	require.NoError(t, err)
+	return encrypted
+}
+
+// storedOAuthData encrypts OAuth data in the stored format with the given refresh token.
+func (s *refreshAccountTest) storedOAuthData(t *testing.T, refreshToken string, expiresAt time.Time) string {
+	accessTokenEncrypted, err := s.crypto.Encrypt("old-access")
+	require.NoError(t, err)
+	refreshTokenEncrypted, err := s.crypto.Encrypt(refreshToken)
+	require.NoError(t, err)
+	return s.encrypt(t, StoredOAuthData{
+		AccessTokenEncrypted:  accessTokenEncrypted,
+		RefreshTokenEncrypted: refreshTokenEncrypted,
+		ExpiresAt:             expiresAt,
+	})
+}
+
+// TestRefreshAccount_Claude tests that a Claude account is refreshed through the Claude refresh path
+// and its health score and failure counter are restored.
+func TestRefreshAccount_Claude(t *testing.T) {
+	s := setupRefreshAccountTest(t, &mockOAuthProvider{
+		tokenResp: &oauth.ExtendedTokenResponse{AccessToken: "new-access", RefreshToken: "new-refresh", ExpiresIn: 3600},
+	})
+	ctx := context.Background()
+	account := &data.Account{
+		ID:                 1,
+		Name:               "claude",
+		Provider:           data.ProviderClaudeOfficial,
+		Status:             data.StatusActive,
+		HealthScore:        40,
+		OAuthDataEncrypted: s.storedOAuthData(t, "fixed-refresh", s.clock.Now().Add(-time.Hour)),
+	}
+	s.mr.Set("refresh_failure:1", "2")
+
+	expected := s.clock.Now().Add(time.Hour)
+	s.repo.On("GetAccount", ctx, int64(1)).Return(account, nil)
+	s.repo.On("UpdateOAuthData", ctx, int64(1), mock.AnythingOfType("string"), expected).Return(nil)
+	s.repo.On("UpdateHealthScore", ctx, int64(1), 100).Return(nil)
+
+	expiresAt, err := s.uc.RefreshAccount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, expected, expiresAt)
+	assert.False(t, s.mr.Exists("refresh_failure:1"))
+	s.repo.AssertExpectations(t)
+
+	stored := s.repo.Calls[1].Arguments.String(2)
+	decrypted, err := s.crypto.Decrypt(stored)
+	require.NoError(t, err)
+	oauthData, err := ParseStoredOAuthData(decrypted)
+	require.NoError(t, err)
+	newRefresh, err := s.crypto.Decrypt(oauthData.RefreshTokenEncrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "new-refresh", newRefresh)
+}
+
+// TestRefreshAccount_ClaudeLegacyPlainBlob tests that a Claude account still stored in the legacy
+// plain {access_token, refresh_token, expires_at} format is refreshed and re-saved in the stored format,
+// keeping the old refresh token when the provider does not rotate it.
+func TestRefreshAccount_ClaudeLegacyPlainBlob(t *testing.T) {
+	provider := &mockOAuthProvider{
+		tokenResp: &oauth.ExtendedTokenResponse{AccessToken: "new-access", ExpiresIn: 3600},
+	}
+	s := setupRefreshAccountTest(t, provider)
+	ctx := context.Background()
+	account := &data.Account{
+		ID:          1,
+		Name:        "claude",
+		Provider:    data.ProviderClaudeOfficial,
+		Status:      data.StatusActive,
+		HealthScore: 100,
+		OAuthDataEncrypted: s.encrypt(t, map[string]interface{}{
+			"access_token":  "legacy-access",
+			"refresh_token": "legacy-refresh",
+			"expires_at":    s.clock.Now().Add(-time.Hour),
+		}),
+	}
+
+	expected := s.clock.Now().Add(time.Hour)
+	s.repo.On("GetAccount", ctx, int64(1)).Return(account, nil)
+	s.repo.On("UpdateOAuthData", ctx, int64(1), mock.AnythingOfType("string"), expected).Return(nil)
+	s.repo.On("UpdateHealthScore", ctx, int64(1), 100).Return(nil)
+
+	_, err := s.uc.RefreshAccount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-refresh", provider.lastRefreshToken)
+	s.repo.AssertExpectations(t)
+
+	stored := s.repo.Calls[1].Arguments.String(2)
+	decrypted, err := s.crypto.Decrypt(stored)
+	require.NoError(t, err)
+	assert.NotContains(t, decrypted, "legacy-refresh", "plaintext tokens are not written back")
+
+	oauthData, err := ParseStoredOAuthData(decrypted)
+	require.NoError(t, err)
+	assert.False(t, oauthData.IsLegacy())
+	accessToken, err := s.crypto.Decrypt(oauthData.AccessTokenEncrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "new-access", accessToken)
+	refreshToken, err := s.crypto.Decrypt(oauthData.RefreshTokenEncrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-refresh", refreshToken)
+}
+
+// TestRefreshAccount_Codex tests that a Codex CLI account is refreshed in the stored OAuth data format,
+// keeping fields the provider did not return, and a created account is activated.
+func TestRefreshAccount_Codex(t *testing.T) {
+	s := setupRefreshAccountTest(t, &mockOAuthProvider{
+		provider:  data.ProviderCodexCLI,
+		tokenResp: &oauth.ExtendedTokenResponse{AccessToken: "new-access", RefreshToken: "new-refresh", ExpiresIn: 7200},
+	})
+	ctx := context.Background()
+
+	accessToken, err := s.crypto.Encrypt("old-access")
+	require.NoError(t, err)
+	refreshToken, err := s.crypto.Encrypt("fixed-refresh")
+	require.NoError(t, err)
+	account := &data.Account{
+		ID:          2,
+		Name:        "codex",
+		Provider:    data.ProviderCodexCLI,
+		Status:      data.StatusCreated,
+		HealthScore: 100,
+		OAuthDataEncrypted: s.encrypt(t, StoredOAuthData{
+			AccessTokenEncrypted:  accessToken,
+			RefreshTokenEncrypted: refreshToken,
+			IDToken:               "id-token",
+			AccountID:             "chatgpt-account",
+			ExpiresAt:             s.clock.Now().Add(-time.Hour),
+		}),
+	}
+
+	expected := s.clock.Now().Add(2 * time.Hour)
+	s.repo.On("GetAccount", ctx, int64(2)).Return(account, nil)
+	s.repo.On("UpdateOAuthData", ctx, int64(2), mock.AnythingOfType("string"), expected).Return(nil)
+	s.repo.On("UpdateHealthScore", ctx, int64(2), 100).Return(nil)
+	s.repo.On("UpdateAccountStatus", ctx, int64(2), data.StatusActive).Return(nil)
+
+	expiresAt, err := s.uc.RefreshAccount(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, expected, expiresAt)
+	s.repo.AssertExpectations(t)
+
+	decrypted, err := s.crypto.Decrypt(s.repo.Calls[1].Arguments.String(2))
+	require.NoError(t, err)
+	stored, err := ParseStoredOAuthData(decrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "id-token", stored.IDToken)
+	assert.Equal(t, "chatgpt-account", stored.AccountID)
+	assert.True(t, expected.Equal(stored.ExpiresAt))
+	newRefresh, err := s.crypto.Decrypt(stored.RefreshTokenEncrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "new-refresh", newRefresh)
+}
+
+// TestRefreshAccount_FailureUpdatesHealth tests that a failed on-demand refresh goes through the
+// regular failure handling and returns the provider error.
+func TestRefreshAccount_FailureUpdatesHealth(t *testing.T) {
+	s := setupRefreshAccountTest(t, &mockOAuthProvider{
+		provider: data.ProviderCodexCLI,
+		err:      openai.NewHTTPError(http.StatusUnauthorized, nil, `{"error":"unauthorized"}`),
+	})
+	s.uc.SetMarkNeedsReauth(false)
+	ctx := context.Background()
+
+	accessToken, err := s.crypto.Encrypt("old-access")
+	require.NoError(t, err)
+	refreshToken, err := s.crypto.Encrypt("still-bad")
+	require.NoError(t, err)
+	expiresAt := s.clock.Now().Add(-time.Hour)
+	account := &data.Account{
+		ID:             3,
+		Provider:       data.ProviderCodexCLI,
+		HealthScore:    100,
+		OAuthExpiresAt: &expiresAt,
+		OAuthDataEncrypted: s.encrypt(t, StoredOAuthData{
+			AccessTokenEncrypted:  accessToken,
+			RefreshTokenEncrypted: refreshToken,
+			ExpiresAt:             expiresAt,
+		}),
+	}
+	s.repo.On("GetAccount", mock.Anything, int64(3)).Return(account, nil)
+	s.repo.On("UpdateHealthScore", mock.Anything, int64(3), 80).Return(nil)
+	s.repo.On("SetNextRefreshAttempt", mock.Anything, int64(3), mock.Anything).Return(nil)
+
+	_, err = s.uc.RefreshAccount(ctx, 3)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, openai.StatusCode(err))
+	s.repo.AssertCalled(t, "UpdateHealthScore", mock.Anything, int64(3), 80)
+	s.repo.AssertNotCalled(t, "UpdateOAuthData", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.True(t, s.mr.Exists("refresh_failure:3"))
+}
+
+// TestRefreshAccount_UnsupportedProvider tests that providers without a refresh flow are rejected.
+func TestRefreshAccount_UnsupportedProvider(t *testing.T) {
+	s := setupRefreshAccountTest(t, &mockOAuthProvider{err: errors.New("must not be called")})
+	ctx := context.Background()
+	s.repo.On("GetAccount", ctx, int64(4)).Return(&data.Account{ID: 4, Provider: data.ProviderGemini}, nil)
+
+	_, err := s.uc.RefreshAccount(ctx, 4)
+	require.Error(t, err)
+	assert.Equal(t, "REFRESH_NOT_SUPPORTED", kerrors.FromError(err).Reason)
+	assert.Equal(t, int32(http.StatusBadRequest), kerrors.FromError(err).Code)
+	s.repo.AssertNotCalled(t, "UpdateHealthScore", mock.Anything, mock.Anything, mock.Anything)
+}
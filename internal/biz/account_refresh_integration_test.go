@@ -37,6 +37,39 @@ type IntegrationTestSuite struct {
 	logger      log.Logger
 }
 
+// storedOAuthJSON builds decrypted OAuth data in the stored format (tokens encrypted inside).
+func (suite *IntegrationTestSuite) storedOAuthJSON(t *testing.T, accessToken, refreshToken string, expiresAt time.Time) []byte {
+	accessTokenEncrypted, err := suite.crypto.Encrypt(accessToken)
+	require.NoError(t, err)
+	refreshTokenEncrypted, err := suite.crypto.Encrypt(refreshToken)
+	require.NoError(t, err)
+	raw, err := json.Marshal(StoredOAuthData{
+		AccessTokenEncrypted:  accessTokenEncrypted,
+		RefreshTokenEncrypted: refreshTokenEncrypted,
+		ExpiresAt:             expiresAt,
+	})
+	require.NoError(t, err)
+	return raw
+}
+
+// decryptedOAuth holds the plaintext tokens of stored OAuth data.
+type decryptedOAuth struct {
+	accessToken  string
+	refreshToken string
+	expiresAt    time.Time
+}
+
+// parseStoredOAuth parses decrypted OAuth data and decrypts its tokens.
+func (suite *IntegrationTestSuite) parseStoredOAuth(t *testing.T, decrypted string) decryptedOAuth {
+	stored, err := ParseStoredOAuthData(decrypted)
+	require.NoError(t, err)
+	accessToken, err := suite.crypto.Decrypt(stored.AccessTokenEncrypted)
+	require.NoError(t, err)
+	refreshToken, err := suite.crypto.Decrypt(stored.RefreshTokenEncrypted)
+	require.NoError(t, err)
+	return decryptedOAuth{accessToken: accessToken, refreshToken: refreshToken, expiresAt: stored.ExpiresAt}
+}
+
 // setupTestSuite creates test infrastructure (MySQL + Redis)
 func setupTestSuite(t *testing.T) *IntegrationTestSuite {
 	t.Helper()
@@ -91,7 +124,7 @@ func setupTestSuite(t *testing.T) *IntegrationTestSuite {
 	t.Cleanup(cleanup) // Ensure cleanup runs after test
 
 	// 8. Create account repository
-	accountRepo := data.NewAccountRepo(dataWrapper, db, logger)
+	accountRepo := data.NewAccountRepo(dataWrapper, db, nil, logger)
 
 	// 9. Create account usecase
 	uc := NewAccountUsecase(accountRepo, cryptoSvc, oauthSvc, nil, nil, nil, rdb, logger)
@@ -158,12 +191,7 @@ func TestRefreshClaudeToken_Success(t *testing.T) {
 	oldRefreshToken := "old_refresh_token_fghij"
 	expiresAt := time.Now().UTC().Add(-10 * time.Minute) // Already expired
 
-	oauthData := OAuthData{
-		AccessToken:  oldAccessToken,
-		RefreshToken: oldRefreshToken,
-		ExpiresAt:    expiresAt,
-	}
-	oauthJSON, _ := json.Marshal(oauthData)
+	oauthJSON := suite.storedOAuthJSON(t, oldAccessToken, oldRefreshToken, expiresAt)
 	encryptedOAuth, err := suite.crypto.Encrypt(string(oauthJSON))
 	require.NoError(t, err)
 
@@ -196,13 +224,11 @@ func TestRefreshClaudeToken_Success(t *testing.T) {
 	decrypted, err := suite.crypto.Decrypt(updatedAccount.OAuthDataEncrypted)
 	require.NoError(t, err)
 
-	var newOAuth OAuthData
-	err = json.Unmarshal([]byte(decrypted), &newOAuth)
-	require.NoError(t, err)
+	newOAuth := suite.parseStoredOAuth(t, decrypted)
 
-	assert.Equal(t, "new_access_token_12345", newOAuth.AccessToken)
-	assert.Equal(t, "new_refresh_token_67890", newOAuth.RefreshToken)
-	assert.True(t, newOAuth.ExpiresAt.After(time.Now().UTC()))
+	assert.Equal(t, "new_access_token_12345", newOAuth.accessToken)
+	assert.Equal(t, "new_refresh_token_67890", newOAuth.refreshToken)
+	assert.True(t, newOAuth.expiresAt.After(time.Now().UTC()))
 
 	// Verify health score reset
 	assert.Equal(t, int32(100), updatedAccount.HealthScore)
@@ -237,12 +263,7 @@ func TestRefreshClaudeToken_Failure(t *testing.T) {
 	suite.uc = NewAccountUsecase(suite.accountRepo, suite.crypto, mockOAuthSvc, nil, nil, nil, suite.rdb, suite.logger)
 
 	// 2. Create test account
-	oauthData := OAuthData{
-		AccessToken:  "access_token",
-		RefreshToken: "invalid_refresh_token",
-		ExpiresAt:    time.Now().UTC().Add(-1 * time.Hour),
-	}
-	oauthJSON, _ := json.Marshal(oauthData)
+	oauthJSON := suite.storedOAuthJSON(t, "access_token", "invalid_refresh_token", time.Now().UTC().Add(-1*time.Hour))
 	encryptedOAuth, _ := suite.crypto.Encrypt(string(oauthJSON))
 
 	expiresAt := time.Now().UTC().Add(-1 * time.Hour)
@@ -303,12 +324,7 @@ func TestRefreshClaudeToken_ConsecutiveFailures(t *testing.T) {
 	suite.uc = NewAccountUsecase(suite.accountRepo, suite.crypto, mockOAuthSvc, nil, nil, nil, suite.rdb, suite.logger)
 
 	// Create test account
-	oauthData := OAuthData{
-		AccessToken:  "access",
-		RefreshToken: "refresh",
-		ExpiresAt:    time.Now().UTC().Add(-1 * time.Hour),
-	}
-	oauthJSON, _ := json.Marshal(oauthData)
+	oauthJSON := suite.storedOAuthJSON(t, "access", "refresh", time.Now().UTC().Add(-1*time.Hour))
 	encryptedOAuth, _ := suite.crypto.Encrypt(string(oauthJSON))
 
 	expiresAt := time.Now().UTC().Add(-1 * time.Hour)
@@ -386,12 +402,7 @@ func TestAutoRefreshTokens_BatchProcessing(t *testing.T) {
 	accountIDs := make([]int64, 10)
 
 	for i := 0; i < 10; i++ {
-		oauthData := OAuthData{
-			AccessToken:  fmt.Sprintf("old_access_%d", i),
-			RefreshToken: fmt.Sprintf("old_refresh_%d", i),
-			ExpiresAt:    expiresAt,
-		}
-		oauthJSON, _ := json.Marshal(oauthData)
+		oauthJSON := suite.storedOAuthJSON(t, fmt.Sprintf("old_access_%d", i), fmt.Sprintf("old_refresh_%d", i), expiresAt)
 		encryptedOAuth, _ := suite.crypto.Encrypt(string(oauthJSON))
 
 		account := &data.Account{
@@ -436,13 +447,11 @@ func TestAutoRefreshTokens_BatchProcessing(t *testing.T) {
 		decrypted, err := suite.crypto.Decrypt(account.OAuthDataEncrypted)
 		require.NoError(t, err)
 
-		var newOAuth OAuthData
-		err = json.Unmarshal([]byte(decrypted), &newOAuth)
-		require.NoError(t, err)
+		newOAuth := suite.parseStoredOAuth(t, decrypted)
 
 		// Verify token was updated
-		assert.Contains(t, newOAuth.AccessToken, "new_access_")
-		assert.Contains(t, newOAuth.RefreshToken, "new_refresh_")
+		assert.Contains(t, newOAuth.accessToken, "new_access_")
+		assert.Contains(t, newOAuth.refreshToken, "new_refresh_")
 
 		// Verify expires_at updated
 		require.NotNil(t, account.OAuthExpiresAt)
@@ -490,12 +499,7 @@ func TestAutoRefreshTokens_PartialFailures(t *testing.T) {
 	// Create 3 accounts: 2 success, 1 failure
 	tokens := []string{"success_1", "fail_token", "success_2"}
 	for _, token := range tokens {
-		oauthData := OAuthData{
-			AccessToken:  "access",
-			RefreshToken: token,
-			ExpiresAt:    expiresAt,
-		}
-		oauthJSON, _ := json.Marshal(oauthData)
+		oauthJSON := suite.storedOAuthJSON(t, "access", token, expiresAt)
 		encryptedOAuth, _ := suite.crypto.Encrypt(string(oauthJSON))
 
 		account := &data.Account{
@@ -603,12 +607,7 @@ func TestListExpiringAccounts(t *testing.T) {
 
 		// Add minimal OAuth data for accounts that should have it
 		if tc.expiresAt != nil {
-			oauthData := OAuthData{
-				AccessToken:  "access",
-				RefreshToken: "refresh",
-				ExpiresAt:    *tc.expiresAt,
-			}
-			oauthJSON, _ := json.Marshal(oauthData)
+			oauthJSON := suite.storedOAuthJSON(t, "access", "refresh", *tc.expiresAt)
 			encrypted, _ := suite.crypto.Encrypt(string(oauthJSON))
 			account.OAuthDataEncrypted = encrypted
 		}
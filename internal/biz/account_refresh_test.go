@@ -0,0 +1,302 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/crypto"
+	"QuotaLane/pkg/oauth"
+	"QuotaLane/pkg/openai"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRefreshFailureTest(t *testing.T, expiresIn time.Duration) (*AccountUsecase, *MockAccountRepo, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	expiresAt := time.Now().Add(expiresIn)
+	mockRepo := new(MockAccountRepo)
+	mockRepo.On("GetAccount", mock.Anything, int64(1)).
+		Return(&data.Account{ID: 1, Name: "claude", HealthScore: 100, OAuthExpiresAt: &expiresAt}, nil)
+	mockRepo.On("UpdateHealthScore", mock.Anything, int64(1), 80).Return(nil)
+	mockRepo.On("SetNextRefreshAttempt", mock.Anything, int64(1), mock.Anything).Return(nil)
+
+	uc := NewAccountUsecase(mockRepo, nil, nil, nil, nil, nil, nil, nil, rdb, log.DefaultLogger)
+	return uc, mockRepo, mr
+}
+
+// TestHandleRefreshFailure_PlentyOfValidity tests that a failure far from expiry
+// does not count toward the consecutive-failure threshold.
+func TestHandleRefreshFailure_PlentyOfValidity(t *testing.T) {
+	uc, mockRepo, mr := setupRefreshFailureTest(t, 6*time.Hour)
+	ctx := context.Background()
+
+	for i := 0; i < MaxConsecutiveFailures; i++ {
+		require.NoError(t, uc.handleRefreshFailure(ctx, 1, errors.New("upstream 503")))
+	}
+
+	assert.False(t, mr.Exists("refresh_failure:1"))
+	mockRepo.AssertNotCalled(t, "UpdateAccountStatus", mock.Anything, int64(1), data.StatusError)
+}
+
+// TestHandleRefreshFailure_NearExpiry tests that failures inside the grace window
+// are counted and eventually mark the account ERROR.
+func TestHandleRefreshFailure_NearExpiry(t *testing.T) {
+	uc, mockRepo, mr := setupRefreshFailureTest(t, 10*time.Minute)
+	mockRepo.On("UpdateAccountStatus", mock.Anything, int64(1), data.StatusError).Return(nil).Once()
+	ctx := context.Background()
+
+	require.NoError(t, uc.handleRefreshFailure(ctx, 1, errors.New("upstream 503")))
+	count, err := mr.Get("refresh_failure:1")
+	require.NoError(t, err)
+	assert.Equal(t, "1", count)
+
+	for i := 1; i < MaxConsecutiveFailures; i++ {
+		require.NoError(t, uc.handleRefreshFailure(ctx, 1, errors.New("upstream 503")))
+	}
+
+	mockRepo.AssertExpectations(t)
+	assert.True(t, mr.Exists("alert:1"))
+}
+
+// TestHandleRefreshFailure_CustomGraceWindow tests that the grace window is configurable.
+func TestHandleRefreshFailure_CustomGraceWindow(t *testing.T) {
+	uc, _, mr := setupRefreshFailureTest(t, 6*time.Hour)
+	uc.SetRefreshFailureGraceWindow(12 * time.Hour)
+
+	require.NoError(t, uc.handleRefreshFailure(context.Background(), 1, errors.New("upstream 503")))
+	assert.True(t, mr.Exists("refresh_failure:1"))
+}
+
+func TestRefreshBackoff(t *testing.T) {
+	assert.Equal(t, 5*time.Minute, refreshBackoff(1))
+	assert.Equal(t, 10*time.Minute, refreshBackoff(2))
+	assert.Equal(t, 20*time.Minute, refreshBackoff(3))
+	assert.Equal(t, RefreshBackoffMax, refreshBackoff(10))
+}
+
+// nextRefreshAttempts returns the backoff times recorded by SetNextRefreshAttempt, in call order.
+func nextRefreshAttempts(mockRepo *MockAccountRepo) []time.Time {
+	var attempts []time.Time
+	for _, call := range mockRepo.Calls {
+		if call.Method == "SetNextRefreshAttempt" {
+			attempts = append(attempts, *call.Arguments.Get(2).(*time.Time))
+		}
+	}
+	return attempts
+}
+
+// TestHandleRefreshFailure_SchedulesBackoff tests that a just-failed account is not retried
+// until its backoff elapses, and that the backoff grows with consecutive failures.
+func TestHandleRefreshFailure_SchedulesBackoff(t *testing.T) {
+	uc, mockRepo, _ := setupRefreshFailureTest(t, 10*time.Minute)
+	ctx := context.Background()
+
+	require.NoError(t, uc.handleRefreshFailure(ctx, 1, errors.New("upstream 503")))
+	require.NoError(t, uc.handleRefreshFailure(ctx, 1, errors.New("upstream 503")))
+
+	attempts := nextRefreshAttempts(mockRepo)
+	require.Len(t, attempts, 2)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), attempts[0], 5*time.Second)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), attempts[1], 5*time.Second)
+}
+
+// TestHandleRefreshFailure_GracePeriodBackoff tests that uncounted failures still back off.
+func TestHandleRefreshFailure_GracePeriodBackoff(t *testing.T) {
+	uc, mockRepo, _ := setupRefreshFailureTest(t, 6*time.Hour)
+
+	require.NoError(t, uc.handleRefreshFailure(context.Background(), 1, errors.New("upstream 503")))
+
+	attempts := nextRefreshAttempts(mockRepo)
+	require.Len(t, attempts, 1)
+	assert.WithinDuration(t, time.Now().Add(RefreshBackoffBase), attempts[0], 5*time.Second)
+}
+
+// TestHandleRefreshFailure_NetworkErrorNotPenalized tests that network/proxy failures
+// leave the health score and failure counter untouched.
+func TestHandleRefreshFailure_NetworkErrorNotPenalized(t *testing.T) {
+	uc, mockRepo, mr := setupRefreshFailureTest(t, 10*time.Minute)
+	netErr := fmt.Errorf("failed to send request: %w", &openai.NetworkError{Err: errors.New("proxyconnect tcp: connection refused")})
+
+	require.NoError(t, uc.handleRefreshFailure(context.Background(), 1, netErr))
+
+	mockRepo.AssertNotCalled(t, "UpdateHealthScore", mock.Anything, int64(1), mock.Anything)
+	assert.False(t, mr.Exists("refresh_failure:1"))
+	assert.Len(t, nextRefreshAttempts(mockRepo), 1)
+}
+
+// TestHandleRefreshFailure_AuthErrorPenalized tests that auth failures deduct health.
+func TestHandleRefreshFailure_AuthErrorPenalized(t *testing.T) {
+	uc, mockRepo, _ := setupRefreshFailureTest(t, 10*time.Minute)
+	authErr := &openai.AuthError{StatusCode: 401, Message: "invalid_grant"}
+
+	require.NoError(t, uc.handleRefreshFailure(context.Background(), 1, authErr))
+
+	mockRepo.AssertCalled(t, "UpdateHealthScore", mock.Anything, int64(1), 80)
+}
+
+// TestHandleValidationFailure_ErrorTaxonomy tests that validation failures are penalized
+// by error type: NetworkError keeps the health score, AuthError deducts it.
+func TestHandleValidationFailure_ErrorTaxonomy(t *testing.T) {
+	t.Run("network error", func(t *testing.T) {
+		uc, mockRepo, _ := setupRefreshFailureTest(t, time.Hour)
+		mockRepo.On("UpdateAccount", mock.Anything, mock.Anything).Return(nil)
+		account := &data.Account{ID: 1, Name: "openai", HealthScore: 100}
+		err := fmt.Errorf("all retry attempts exhausted: %w", &openai.NetworkError{Err: errors.New("i/o timeout")})
+
+		assert.ErrorIs(t, uc.handleValidationFailure(context.Background(), account, err), err)
+
+		mockRepo.AssertNotCalled(t, "UpdateHealthScore", mock.Anything, int64(1), mock.Anything)
+		mockRepo.AssertNotCalled(t, "UpdateAccountStatus", mock.Anything, int64(1), mock.Anything)
+		require.NotNil(t, account.LastError)
+		assert.Contains(t, *account.LastError, "i/o timeout")
+		assert.Equal(t, int32(0), account.ConsecutiveErrors)
+	})
+
+	t.Run("auth error", func(t *testing.T) {
+		uc, mockRepo, _ := setupRefreshFailureTest(t, time.Hour)
+		mockRepo.On("UpdateAccount", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("UpdateAccountStatus", mock.Anything, int64(1), data.StatusError).Return(nil)
+		account := &data.Account{ID: 1, Name: "openai", HealthScore: 100}
+		err := fmt.Errorf("API key validation failed: %w", &openai.AuthError{StatusCode: 401, Reason: "invalid API key"})
+
+		assert.ErrorIs(t, uc.handleValidationFailure(context.Background(), account, err), err)
+
+		mockRepo.AssertCalled(t, "UpdateHealthScore", mock.Anything, int64(1), 80)
+		require.NotNil(t, account.LastError)
+		assert.Contains(t, *account.LastError, `"code":401`)
+	})
+}
+
+// TestHandleValidationFailure_ProviderDownPenalty tests that a provider outage (retries exhausted
+// on 5xx) is not held against the account by default, while an invalid key still is.
+func TestHandleValidationFailure_ProviderDownPenalty(t *testing.T) {
+	serverErr := fmt.Errorf("API key validation failed: all retry attempts exhausted: attempt 3: %w",
+		&openai.ServerError{StatusCode: 503, Message: "upstream unavailable"})
+
+	t.Run("401 penalizes", func(t *testing.T) {
+		uc, mockRepo, _ := setupRefreshFailureTest(t, time.Hour)
+		mockRepo.On("UpdateAccount", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("UpdateAccountStatus", mock.Anything, int64(1), data.StatusError).Return(nil)
+		account := &data.Account{ID: 1, Name: "openai", HealthScore: 100}
+		err := fmt.Errorf("API key validation failed: %w", &openai.AuthError{StatusCode: 401, Reason: "invalid API key"})
+
+		assert.ErrorIs(t, uc.handleValidationFailure(context.Background(), account, err), err)
+
+		mockRepo.AssertCalled(t, "UpdateHealthScore", mock.Anything, int64(1), 80)
+		mockRepo.AssertCalled(t, "UpdateAccountStatus", mock.Anything, int64(1), data.StatusError)
+	})
+
+	t.Run("503 storm not penalized by default", func(t *testing.T) {
+		uc, mockRepo, mr := setupRefreshFailureTest(t, time.Hour)
+		mockRepo.On("UpdateAccount", mock.Anything, mock.Anything).Return(nil)
+		account := &data.Account{ID: 1, Name: "openai", HealthScore: 100}
+
+		for i := 0; i < 5; i++ {
+			assert.ErrorIs(t, uc.handleValidationFailure(context.Background(), account, serverErr), serverErr)
+		}
+
+		mockRepo.AssertNotCalled(t, "UpdateHealthScore", mock.Anything, int64(1), mock.Anything)
+		mockRepo.AssertNotCalled(t, "UpdateAccountStatus", mock.Anything, int64(1), mock.Anything)
+		assert.False(t, account.IsCircuitBroken)
+		assert.False(t, mr.Exists(HealthCheckFailureKeyPrefix+"1"))
+		require.NotNil(t, account.LastError)
+		assert.Contains(t, *account.LastError, `"code":503`)
+	})
+
+	t.Run("503 penalized when configured", func(t *testing.T) {
+		uc, mockRepo, _ := setupRefreshFailureTest(t, time.Hour)
+		uc.SetProviderDownHealthPenalty(5)
+		mockRepo.On("UpdateAccount", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("UpdateHealthScore", mock.Anything, int64(1), 95).Return(nil)
+		account := &data.Account{ID: 1, Name: "openai", HealthScore: 100}
+
+		assert.ErrorIs(t, uc.handleValidationFailure(context.Background(), account, serverErr), serverErr)
+
+		mockRepo.AssertCalled(t, "UpdateHealthScore", mock.Anything, int64(1), 95)
+		mockRepo.AssertNotCalled(t, "UpdateAccountStatus", mock.Anything, int64(1), mock.Anything)
+	})
+}
+
+// hangingOAuthProvider blocks on the "hang" refresh token until the context is done
+type hangingOAuthProvider struct {
+	mockOAuthProvider
+}
+
+func (p *hangingOAuthProvider) RefreshToken(ctx context.Context, refreshToken string, metadata *oauth.AccountMetadata) (*oauth.ExtendedTokenResponse, error) {
+	if refreshToken == "hang" {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return p.tokenResp, nil
+}
+
+// TestAutoRefreshTokens_PerAccountTimeout tests that one hung refresh times out on its own
+// deadline as a transient failure while the other accounts still refresh.
+func TestAutoRefreshTokens_PerAccountTimeout(t *testing.T) {
+	cryptoHelper, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+
+	oauthManager := oauth.NewOAuthManager(nil, log.DefaultLogger)
+	oauthManager.RegisterProvider(&hangingOAuthProvider{mockOAuthProvider{tokenResp: &oauth.ExtendedTokenResponse{
+		AccessToken:  "new-access",
+		RefreshToken: "new-refresh",
+		ExpiresIn:    3600,
+	}}})
+
+	expiresAt := time.Now().Add(5 * time.Minute)
+	newAccount := func(id int64, refreshToken string) *data.Account {
+		accessTokenEncrypted, err := cryptoHelper.Encrypt("old")
+		require.NoError(t, err)
+		refreshTokenEncrypted, err := cryptoHelper.Encrypt(refreshToken)
+		require.NoError(t, err)
+		raw, err := json.Marshal(StoredOAuthData{AccessTokenEncrypted: accessTokenEncrypted, RefreshTokenEncrypted: refreshTokenEncrypted, ExpiresAt: expiresAt})
+		require.NoError(t, err)
+		encrypted, err := cryptoHelper.Encrypt(string(raw))
+		require.NoError(t, err)
+		return &data.Account{
+			ID:                 id,
+			Name:               fmt.Sprintf("claude-%d", id),
+			Provider:           data.ProviderClaudeOfficial,
+			Status:             data.StatusActive,
+			HealthScore:        100,
+			OAuthDataEncrypted: encrypted,
+			OAuthExpiresAt:     &expiresAt,
+		}
+	}
+	accounts := []*data.Account{newAccount(1, "ok-1"), newAccount(2, "hang"), newAccount(3, "ok-3")}
+
+	mockRepo := new(MockAccountRepo)
+	mockRepo.On("ListExpiringAccounts", mock.Anything, mock.Anything).Return(accounts, nil)
+	for _, acc := range accounts {
+		mockRepo.On("GetAccount", mock.Anything, acc.ID).Return(acc, nil)
+	}
+	mockRepo.On("UpdateOAuthData", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(nil).Once()
+	mockRepo.On("UpdateOAuthData", mock.Anything, int64(3), mock.Anything, mock.Anything).Return(nil).Once()
+	mockRepo.On("UpdateHealthScore", mock.Anything, mock.Anything, 100).Return(nil)
+	mockRepo.On("SetNextRefreshAttempt", mock.Anything, int64(2), mock.Anything).Return(nil).Once()
+
+	uc := NewAccountUsecase(mockRepo, cryptoHelper, nil, nil, oauthManager, nil, nil, nil, nil, log.DefaultLogger)
+	uc.SetRefreshAccountTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, uc.AutoRefreshTokens(context.Background()))
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "UpdateOAuthData", mock.Anything, int64(2), mock.Anything, mock.Anything)
+	// Timeout is transient: no health penalty for the hung account
+	mockRepo.AssertNotCalled(t, "UpdateHealthScore", mock.Anything, int64(2), mock.Anything)
+}
@@ -7,6 +7,12 @@ import (
 	"QuotaLane/internal/data"
 )
 
+// ErrAccountNotFound is returned by AccountRepo when an account does not exist; match it with errors.Is.
+var ErrAccountNotFound = data.ErrAccountNotFound
+
+// ErrAccountMissingProvider is returned by AccountRepo.GetAccount for a stored account without a provider.
+var ErrAccountMissingProvider = data.ErrAccountMissingProvider
+
 // AccountRepo defines the account repository interface.
 // Following Kratos v2 DDD architecture, interfaces are defined in biz layer.
 // Implementation is in data layer (data.AccountRepo).
@@ -14,14 +20,32 @@ type AccountRepo interface {
 	CreateAccount(ctx context.Context, account *data.Account) error
 	GetAccount(ctx context.Context, id int64) (*data.Account, error)
 	ListAccounts(ctx context.Context, filter *data.AccountFilter) ([]*data.Account, int32, error)
+	CountAccounts(ctx context.Context, filter *data.AccountFilter) (int64, error)
 	UpdateAccount(ctx context.Context, account *data.Account) error
 	DeleteAccount(ctx context.Context, id int64) error
+	// Data retention: hard purge of accounts soft-deleted before a cutoff
+	ListPurgeableAccounts(ctx context.Context, cutoff time.Time, limit int) ([]*data.Account, error)
+	PurgeAccount(ctx context.Context, id int64) error
 	ListExpiringAccounts(ctx context.Context, expiryThreshold time.Time) ([]*data.Account, error)
 	ListAccountsByProvider(ctx context.Context, provider data.AccountProvider, status data.AccountStatus) ([]*data.Account, error)
-	ListCodexCLIAccountsNeedingRefresh(ctx context.Context) ([]*data.Account, error)
+	ListCodexCLIAccountsNeedingRefresh(ctx context.Context, window time.Duration) ([]*data.Account, error)
 	UpdateOAuthData(ctx context.Context, accountID int64, oauthData string, expiresAt time.Time) error
+	SetNextRefreshAttempt(ctx context.Context, accountID int64, nextAttempt *time.Time) error
+	MarkNeedsReauth(ctx context.Context, accountID int64) error
+	MarkDecryptError(ctx context.Context, accountID int64, detail string) error
+	SetLastCheckedAt(ctx context.Context, accountID int64, checkedAt time.Time) error
 	UpdateHealthScore(ctx context.Context, accountID int64, score int) error
 	UpdateAccountStatus(ctx context.Context, accountID int64, status data.AccountStatus) error
+	SetDraining(ctx context.Context, accountID int64, draining bool) error
 	// Story 2-7: Tag-based account filtering
 	ListAccountsByTags(ctx context.Context, tags []string, limit, offset int) ([]*data.Account, error)
+	GetCapacityStats(ctx context.Context, provider data.AccountProvider) (*data.CapacityStats, error)
+	GetFleetHealthStats(ctx context.Context, filter data.FleetHealthFilter) ([]*data.FleetStatusStats, error)
+	GetExpiryDistribution(ctx context.Context, providers []data.AccountProvider, now time.Time) (*data.ExpiryDistribution, error)
+	RepairHealthScores(ctx context.Context) (int64, error)
+	FlagAccountsMissingProvider(ctx context.Context) ([]int64, error)
+	UpdateBaseAPIByProvider(ctx context.Context, provider data.AccountProvider, oldBase, newBase string) (int64, error)
+	// 凭证绑定迁移：旧版密文重新加密为绑定账户 ID 的密文
+	ListUnboundCredentialAccounts(ctx context.Context, afterID int64, limit int) ([]*data.Account, error)
+	ReplaceCredentials(ctx context.Context, accountID int64, oldAPIKey, newAPIKey, oldOAuthData, newOAuthData string) (bool, error)
 }
@@ -0,0 +1,96 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"QuotaLane/internal/data"
+)
+
+// PurgeBatchSize 保留期清理每批查询的账户数
+const PurgeBatchSize = 500
+
+// SetInactiveRetention 设置软删除账户的保留期：inactive 超过该时长的账户会被定时任务硬删除
+// d <= 0（默认）表示关闭清理，软删除账户永久保留
+func (uc *AccountUsecase) SetInactiveRetention(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	uc.inactiveRetention = d
+}
+
+// PurgeInactiveAccounts 硬删除软删除（inactive）时间超过保留期的账户，返回清理的账户数
+// 数据层在同一事务中删除分组成员关系和账户行（期间被重新激活的账户会被跳过），并清理缓存、限流、并发和熔断 key；
+// 本方法再清理业务层维护的 Redis key（状态历史、健康历史、刷新失败计数、告警标记等）
+// 单个账户清理失败只记录日志，不影响其他账户
+func (uc *AccountUsecase) PurgeInactiveAccounts(ctx context.Context) (int, error) {
+	if uc.inactiveRetention <= 0 {
+		return 0, nil
+	}
+
+	cutoff := uc.now().Add(-uc.inactiveRetention)
+	purged := 0
+	for {
+		accounts, err := uc.repo.ListPurgeableAccounts(ctx, cutoff, PurgeBatchSize)
+		if err != nil {
+			return purged, fmt.Errorf("failed to list purgeable accounts: %w", err)
+		}
+
+		batchPurged := 0
+		for _, account := range accounts {
+			if err := uc.repo.PurgeAccount(ctx, account.ID); err != nil {
+				if errors.Is(err, data.ErrAccountNotPurgeable) {
+					uc.logger.Infow("account reactivated before purge, skipped", "account_id", account.ID)
+				} else {
+					uc.logger.Warnw("failed to purge inactive account", "account_id", account.ID, "error", err)
+				}
+				continue
+			}
+			uc.clearAccountRuntimeKeys(ctx, account.ID)
+			batchPurged++
+		}
+		purged += batchPurged
+
+		// 最后一批，或整批都失败（避免反复查询同一批账户）
+		if len(accounts) < PurgeBatchSize || batchPurged == 0 {
+			break
+		}
+	}
+
+	uc.logger.Infow("inactive account purge completed",
+		"purged", purged,
+		"retention", uc.inactiveRetention,
+		"cutoff", cutoff)
+	return purged, nil
+}
+
+// clearAccountRuntimeKeys 删除业务层为账户维护的 Redis key，失败只记录日志（这些 key 均带 TTL）
+func (uc *AccountUsecase) clearAccountRuntimeKeys(ctx context.Context, accountID int64) {
+	if uc.rdb == nil {
+		return
+	}
+
+	prefixes := []string{
+		StatusHistoryKeyPrefix,
+		HealthHistoryKeyPrefix,
+		RefreshGuardKeyPrefix,
+		RefreshFailureKeyPrefix,
+		AlertKeyPrefix,
+		DecryptAlertKeyPrefix,
+		ReauthAlertKeyPrefix,
+		HealthCheckFailureKeyPrefix,
+		HealthCheckAlertKeyPrefix,
+		ProbeLockKeyPrefix,
+	}
+
+	// 逐个 DEL：Redis Cluster 下多 key DEL 会因跨 slot 失败
+	pipe := uc.rdb.Pipeline()
+	for _, prefix := range prefixes {
+		pipe.Del(ctx, fmt.Sprintf("%s%d", prefix, accountID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		uc.logger.Warnw("failed to delete Redis keys of purged account", "account_id", accountID, "error", err)
+	}
+}
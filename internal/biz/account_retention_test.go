@@ -0,0 +1,102 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRetentionTest(t *testing.T, retention time.Duration) (*AccountUsecase, *MockAccountRepo, *miniredis.Miniredis, *fakeClock) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	mockRepo := new(MockAccountRepo)
+	uc := NewAccountUsecase(mockRepo, nil, nil, nil, nil, nil, nil, nil, rdb, log.DefaultLogger)
+	clock := newFakeClock()
+	uc.SetClock(clock)
+	uc.SetInactiveRetention(retention)
+	return uc, mockRepo, mr, clock
+}
+
+// TestPurgeInactiveAccounts_DisabledByDefault tests that nothing is listed or purged without a retention period
+func TestPurgeInactiveAccounts_DisabledByDefault(t *testing.T) {
+	uc, mockRepo, _, _ := setupRetentionTest(t, 0)
+
+	purged, err := uc.PurgeInactiveAccounts(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	mockRepo.AssertNotCalled(t, "ListPurgeableAccounts", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "PurgeAccount", mock.Anything, mock.Anything)
+}
+
+// TestPurgeInactiveAccounts_PurgesAgedKeepsRecent tests that only accounts inactive longer than the
+// retention period are purged, and that their Redis keys are cleared while other accounts' keys stay.
+func TestPurgeInactiveAccounts_PurgesAgedKeepsRecent(t *testing.T) {
+	retention := 30 * 24 * time.Hour
+	uc, mockRepo, mr, clock := setupRetentionTest(t, retention)
+	cutoff := clock.Now().Add(-retention)
+
+	// The repo filters by cutoff: account 1 (inactive 31 days) qualifies, account 2 (inactive 1 day) does not
+	aged := &data.Account{ID: 1, Status: data.StatusInactive}
+	mockRepo.On("ListPurgeableAccounts", mock.Anything, cutoff, PurgeBatchSize).Return([]*data.Account{aged}, nil).Once()
+	mockRepo.On("PurgeAccount", mock.Anything, int64(1)).Return(nil).Once()
+
+	prefixes := []string{
+		StatusHistoryKeyPrefix, HealthHistoryKeyPrefix, RefreshFailureKeyPrefix, AlertKeyPrefix,
+		DecryptAlertKeyPrefix, ReauthAlertKeyPrefix, HealthCheckAlertKeyPrefix, ProbeLockKeyPrefix,
+	}
+	for _, id := range []int64{1, 2} {
+		for _, prefix := range prefixes {
+			require.NoError(t, mr.Set(fmt.Sprintf("%s%d", prefix, id), "x"))
+		}
+	}
+
+	purged, err := uc.PurgeInactiveAccounts(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "PurgeAccount", mock.Anything, int64(2))
+
+	for _, prefix := range prefixes {
+		assert.False(t, mr.Exists(prefix+"1"), prefix)
+		assert.True(t, mr.Exists(prefix+"2"), prefix)
+	}
+}
+
+// TestPurgeInactiveAccounts_SkipsFailures tests that reactivated accounts and purge errors don't stop the run
+func TestPurgeInactiveAccounts_SkipsFailures(t *testing.T) {
+	uc, mockRepo, mr, _ := setupRetentionTest(t, time.Hour)
+
+	accounts := []*data.Account{{ID: 1}, {ID: 2}, {ID: 3}}
+	mockRepo.On("ListPurgeableAccounts", mock.Anything, mock.Anything, PurgeBatchSize).Return(accounts, nil).Once()
+	mockRepo.On("PurgeAccount", mock.Anything, int64(1)).Return(fmt.Errorf("%w: id=1", data.ErrAccountNotPurgeable))
+	mockRepo.On("PurgeAccount", mock.Anything, int64(2)).Return(errors.New("db down"))
+	mockRepo.On("PurgeAccount", mock.Anything, int64(3)).Return(nil)
+	require.NoError(t, mr.Set(StatusHistoryKeyPrefix+"1", "x"))
+
+	purged, err := uc.PurgeInactiveAccounts(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.True(t, mr.Exists(StatusHistoryKeyPrefix+"1"), "reactivated account keeps its keys")
+}
+
+// TestPurgeInactiveAccounts_ListError tests that a failed query is reported
+func TestPurgeInactiveAccounts_ListError(t *testing.T) {
+	uc, mockRepo, _, _ := setupRetentionTest(t, time.Hour)
+	mockRepo.On("ListPurgeableAccounts", mock.Anything, mock.Anything, PurgeBatchSize).Return(nil, errors.New("db down"))
+
+	_, err := uc.PurgeInactiveAccounts(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,64 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v1 "QuotaLane/api/v1"
+)
+
+// BatchGetAccountStats returns the current RPM/TPM/concurrency usage of the given accounts.
+// All counters are read in one Redis pipeline; accounts without counters report zero usage.
+// With a rate limiter configured, each existing account also reports its daily token usage
+// and remaining daily quota (GetDailyTokenUsage).
+func (uc *AccountUsecase) BatchGetAccountStats(ctx context.Context, ids []int64) (map[int64]*v1.AccountUsageStats, error) {
+	if uc.rateLimitRepo == nil {
+		return nil, fmt.Errorf("rate limit repository is not configured")
+	}
+
+	usage, err := uc.rateLimitRepo.BatchGetUsageStats(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage stats: %w", err)
+	}
+
+	stats := make(map[int64]*v1.AccountUsageStats, len(ids))
+	for _, id := range ids {
+		s := &v1.AccountUsageStats{}
+		if u, ok := usage[id]; ok {
+			s.CurrentRpm = u.RPM
+			s.CurrentTpm = u.TPM
+			s.CurrentConcurrency = u.Concurrency
+		}
+		if err := uc.fillDailyTokenUsage(ctx, id, s); err != nil {
+			return nil, err
+		}
+		stats[id] = s
+	}
+	return stats, nil
+}
+
+// fillDailyTokenUsage fills the daily token fields of stats against the account's DailyTokenLimit.
+// Nothing is filled without a rate limiter or for an account that does not exist.
+func (uc *AccountUsecase) fillDailyTokenUsage(ctx context.Context, accountID int64, stats *v1.AccountUsageStats) error {
+	if uc.rateLimiter == nil {
+		return nil
+	}
+
+	account, err := uc.repo.GetAccount(ctx, accountID)
+	if errors.Is(err, ErrAccountNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get account %d: %w", accountID, err)
+	}
+
+	usage, err := uc.rateLimiter.GetDailyTokenUsage(ctx, accountID, account.DailyTokenLimit)
+	if err != nil {
+		return fmt.Errorf("failed to get daily token usage: %w", err)
+	}
+	stats.DailyTokensUsed = usage.Used
+	stats.DailyTokenLimit = usage.Limit
+	stats.DailyTokensRemaining = usage.Remaining
+	return nil
+}
@@ -0,0 +1,59 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchGetAccountStats(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("fills every requested account", func(t *testing.T) {
+		rateLimitRepo := new(MockRateLimitRepo)
+		uc := NewAccountUsecase(nil, nil, nil, nil, nil, nil, nil, rateLimitRepo, nil, log.DefaultLogger)
+		rateLimitRepo.On("BatchGetUsageStats", ctx, []int64{1, 2}).Return(map[int64]*data.AccountUsageStats{
+			1: {RPM: 3, TPM: 1200, Concurrency: 1},
+		}, nil)
+
+		stats, err := uc.BatchGetAccountStats(ctx, []int64{1, 2})
+		require.NoError(t, err)
+		assert.Equal(t, &v1.AccountUsageStats{CurrentRpm: 3, CurrentTpm: 1200, CurrentConcurrency: 1}, stats[1])
+		assert.Equal(t, &v1.AccountUsageStats{}, stats[2])
+	})
+
+	t.Run("reports daily token usage", func(t *testing.T) {
+		rateLimiter := newGroupRateLimiter(t)
+		repo := new(MockAccountRepo)
+		uc := NewAccountUsecase(repo, nil, nil, nil, nil, nil, nil, rateLimiter.repo, nil, log.DefaultLogger)
+		uc.SetRateLimiter(rateLimiter)
+		repo.On("GetAccount", ctx, int64(1)).Return(&data.Account{ID: 1, DailyTokenLimit: 1000}, nil)
+		repo.On("GetAccount", ctx, int64(2)).Return(&data.Account{ID: 2}, nil)
+		repo.On("GetAccount", ctx, int64(3)).Return(nil, ErrAccountNotFound)
+		require.NoError(t, rateLimiter.CheckDailyTokens(ctx, 1, 1000, 300))
+
+		stats, err := uc.BatchGetAccountStats(ctx, []int64{1, 2, 3})
+		require.NoError(t, err)
+		assert.Equal(t, int64(300), stats[1].DailyTokensUsed)
+		assert.Equal(t, int32(1000), stats[1].DailyTokenLimit)
+		assert.Equal(t, int64(700), stats[1].DailyTokensRemaining)
+		assert.Equal(t, int64(-1), stats[2].DailyTokensRemaining, "no daily quota")
+		assert.Equal(t, &v1.AccountUsageStats{}, stats[3], "unknown accounts report zero usage")
+	})
+
+	t.Run("redis failure", func(t *testing.T) {
+		rateLimitRepo := new(MockRateLimitRepo)
+		uc := NewAccountUsecase(nil, nil, nil, nil, nil, nil, nil, rateLimitRepo, nil, log.DefaultLogger)
+		rateLimitRepo.On("BatchGetUsageStats", ctx, []int64{1}).Return(nil, errors.New("redis down"))
+
+		_, err := uc.BatchGetAccountStats(ctx, []int64{1})
+		assert.Error(t, err)
+	})
+}
@@ -13,10 +13,13 @@ import (
 	pkgoauth "QuotaLane/pkg/oauth"
 	"QuotaLane/pkg/openai"
 
+	"github.com/alicebob/miniredis/v2"
+	kerrors "github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockAccountRepo is a mock implementation of data.AccountRepo for testing.
@@ -45,6 +48,11 @@ func (m *MockAccountRepo) ListAccounts(ctx context.Context, filter *data.Account
 	return args.Get(0).([]*data.Account), args.Get(1).(int32), args.Error(2)
 }
 
+func (m *MockAccountRepo) CountAccounts(ctx context.Context, filter *data.AccountFilter) (int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockAccountRepo) UpdateAccount(ctx context.Context, account *data.Account) error {
 	args := m.Called(ctx, account)
 	return args.Error(0)
@@ -68,6 +76,31 @@ func (m *MockAccountRepo) UpdateOAuthData(ctx context.Context, accountID int64,
 	return args.Error(0)
 }
 
+func (m *MockAccountRepo) SetLastCheckedAt(ctx context.Context, accountID int64, checkedAt time.Time) error {
+	args := m.Called(ctx, accountID, checkedAt)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepo) SetDraining(ctx context.Context, accountID int64, draining bool) error {
+	args := m.Called(ctx, accountID, draining)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepo) SetNextRefreshAttempt(ctx context.Context, accountID int64, nextAttempt *time.Time) error {
+	args := m.Called(ctx, accountID, nextAttempt)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepo) MarkNeedsReauth(ctx context.Context, accountID int64) error {
+	args := m.Called(ctx, accountID)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepo) MarkDecryptError(ctx context.Context, accountID int64, detail string) error {
+	args := m.Called(ctx, accountID, detail)
+	return args.Error(0)
+}
+
 func (m *MockAccountRepo) UpdateHealthScore(ctx context.Context, accountID int64, score int) error {
 	args := m.Called(ctx, accountID, score)
 	return args.Error(0)
@@ -86,8 +119,21 @@ func (m *MockAccountRepo) ListAccountsByProvider(ctx context.Context, provider d
 	return args.Get(0).([]*data.Account), args.Error(1)
 }
 
-func (m *MockAccountRepo) ListCodexCLIAccountsNeedingRefresh(ctx context.Context) ([]*data.Account, error) {
-	args := m.Called(ctx)
+func (m *MockAccountRepo) ListPurgeableAccounts(ctx context.Context, cutoff time.Time, limit int) ([]*data.Account, error) {
+	args := m.Called(ctx, cutoff, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*data.Account), args.Error(1)
+}
+
+func (m *MockAccountRepo) PurgeAccount(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepo) ListCodexCLIAccountsNeedingRefresh(ctx context.Context, window time.Duration) ([]*data.Account, error) {
+	args := m.Called(ctx, window)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -110,6 +156,61 @@ func (m *MockAccountRepo) ListAccountsByTags(ctx context.Context, tags []string,
 	return args.Get(0).([]*data.Account), args.Error(1)
 }
 
+func (m *MockAccountRepo) GetCapacityStats(ctx context.Context, provider data.AccountProvider) (*data.CapacityStats, error) {
+	args := m.Called(ctx, provider)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*data.CapacityStats), args.Error(1)
+}
+
+func (m *MockAccountRepo) GetFleetHealthStats(ctx context.Context, filter data.FleetHealthFilter) ([]*data.FleetStatusStats, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*data.FleetStatusStats), args.Error(1)
+}
+
+func (m *MockAccountRepo) GetExpiryDistribution(ctx context.Context, providers []data.AccountProvider, now time.Time) (*data.ExpiryDistribution, error) {
+	args := m.Called(ctx, providers, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*data.ExpiryDistribution), args.Error(1)
+}
+
+func (m *MockAccountRepo) RepairHealthScores(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountRepo) FlagAccountsMissingProvider(ctx context.Context) ([]int64, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockAccountRepo) UpdateBaseAPIByProvider(ctx context.Context, provider data.AccountProvider, oldBase, newBase string) (int64, error) {
+	args := m.Called(ctx, provider, oldBase, newBase)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountRepo) ListUnboundCredentialAccounts(ctx context.Context, afterID int64, limit int) ([]*data.Account, error) {
+	args := m.Called(ctx, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*data.Account), args.Error(1)
+}
+
+func (m *MockAccountRepo) ReplaceCredentials(ctx context.Context, accountID int64, oldAPIKey, newAPIKey, oldOAuthData, newOAuthData string) (bool, error) {
+	args := m.Called(ctx, accountID, oldAPIKey, newAPIKey, oldOAuthData, newOAuthData)
+	return args.Bool(0), args.Error(1)
+}
+
 // setupTestUsecase creates a test AccountUsecase with mock dependencies.
 func setupTestUsecase(t *testing.T) (*AccountUsecase, *MockAccountRepo, *crypto.AESCrypto) {
 	mockRepo := new(MockAccountRepo)
@@ -130,7 +231,7 @@ func setupTestUsecase(t *testing.T) (*AccountUsecase, *MockAccountRepo, *crypto.
 	var oauthManager *pkgoauth.OAuthManager = nil
 
 	// Create mock Redis client (nil for unit tests)
-	var rdb *redis.Client = nil
+	var rdb redis.UniversalClient = nil
 
 	// Create mock CircuitBreakerUsecase (nil for unit tests - not used in basic account operations)
 	var mockCircuitBreaker *CircuitBreakerUsecase = nil
@@ -138,7 +239,7 @@ func setupTestUsecase(t *testing.T) (*AccountUsecase, *MockAccountRepo, *crypto.
 	// Create mock AccountGroupUseCase (nil for unit tests - not used in basic account operations)
 	var mockAccountGroupUC *AccountGroupUseCase = nil
 
-	uc := NewAccountUsecase(mockRepo, cryptoSvc, oauthSvc, openaiSvc, oauthManager, mockCircuitBreaker, mockAccountGroupUC, rdb, logger)
+	uc := NewAccountUsecase(mockRepo, cryptoSvc, oauthSvc, openaiSvc, oauthManager, mockCircuitBreaker, mockAccountGroupUC, nil, rdb, logger)
 	return uc, mockRepo, cryptoSvc
 }
 
@@ -157,7 +258,7 @@ func TestCreateAccount_Success(t *testing.T) {
 			req: &v1.CreateAccountRequest{
 				Name:      "Test Claude Console",
 				Provider:  v1.AccountProvider_CLAUDE_CONSOLE,
-				OAuthData: `{"access_token":"test_token","refresh_token":"test_refresh"}`,
+				OAuthData: `{"access_token":"test_token","refresh_token":"test_refresh","expires_at":"2030-01-01T00:00:00Z"}`,
 				RpmLimit:  50,
 				TpmLimit:  100000,
 				Metadata:  `{"region":"us-east-1"}`,
@@ -170,6 +271,7 @@ func TestCreateAccount_Success(t *testing.T) {
 				Name:     "Test OpenAI Responses",
 				Provider: v1.AccountProvider_OPENAI_RESPONSES,
 				ApiKey:   "sk-test-1234567890abcdef",
+				BaseApi:  "https://api.openai.com/v1",
 				RpmLimit: 60,
 				TpmLimit: 200000,
 			},
@@ -293,6 +395,230 @@ func TestCreateAccount_RepoError(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// TestCreateAccount_InitialStatus tests the initial_status option.
+func TestCreateAccount_InitialStatus(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	ctx := context.Background()
+
+	t.Run("created status is kept until validation", func(t *testing.T) {
+		req := &v1.CreateAccountRequest{
+			Name:          "Pending Account",
+			Provider:      v1.AccountProvider_OPENAI_RESPONSES,
+			ApiKey:        "sk-test-1234567890abcdef",
+			BaseApi:       "https://api.openai.com/v1",
+			InitialStatus: v1.AccountStatus_ACCOUNT_CREATED,
+		}
+
+		mockRepo.On("CreateAccount", ctx, mock.MatchedBy(func(a *data.Account) bool {
+			return a.Status == data.StatusCreated
+		})).Return(nil).Once()
+
+		result, err := uc.CreateAccount(ctx, req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, v1.AccountStatus_ACCOUNT_CREATED, result.Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid initial status is rejected", func(t *testing.T) {
+		req := &v1.CreateAccountRequest{
+			Name:          "Broken Account",
+			Provider:      v1.AccountProvider_OPENAI_RESPONSES,
+			InitialStatus: v1.AccountStatus_ACCOUNT_ERROR,
+		}
+
+		result, err := uc.CreateAccount(ctx, req)
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		assert.Contains(t, err.Error(), "invalid initial status")
+	})
+}
+
+// openAIValidatorStub is an OpenAI Responses provider stub for validation tests.
+type openAIValidatorStub struct {
+	mockOAuthProvider
+}
+
+func (s *openAIValidatorStub) ProviderType() data.AccountProvider {
+	return data.ProviderOpenAIResponses
+}
+
+// TestValidateOpenAIResponsesAccount_ActivatesCreatedAccount tests that a
+// successful validation flips a created account to active.
+func TestValidateOpenAIResponsesAccount_ActivatesCreatedAccount(t *testing.T) {
+	mockRepo := new(MockAccountRepo)
+	logger := log.DefaultLogger
+	cryptoSvc, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	oauthManager := pkgoauth.NewOAuthManager(rdb, logger)
+	oauthManager.RegisterProvider(&openAIValidatorStub{})
+
+	uc := NewAccountUsecase(mockRepo, cryptoSvc, nil, nil, oauthManager, nil, nil, nil, rdb, logger)
+	ctx := context.Background()
+
+	apiKeyEncrypted, err := cryptoSvc.Encrypt("sk-test-1234567890abcdef")
+	require.NoError(t, err)
+
+	account := &data.Account{
+		ID:              42,
+		Provider:        data.ProviderOpenAIResponses,
+		APIKeyEncrypted: apiKeyEncrypted,
+		BaseAPI:         "https://api.example.com",
+		HealthScore:     100,
+		Status:          data.StatusCreated,
+	}
+
+	mockRepo.On("GetAccount", ctx, int64(42)).Return(account, nil)
+	mockRepo.On("UpdateHealthScore", mock.Anything, int64(42), 100).Return(nil)
+	mockRepo.On("UpdateAccountStatus", ctx, int64(42), data.StatusValidating).Return(nil).Once()
+	mockRepo.On("UpdateAccountStatus", mock.Anything, int64(42), data.StatusActive).Return(nil).Once()
+	mockRepo.On("UpdateAccount", mock.Anything, account).Return(nil)
+
+	err = uc.ValidateOpenAIResponsesAccount(ctx, 42)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestValidateOpenAIResponsesAccount_BookkeepingFailureLeavesValidating tests that an account
+// never stays in validating when recording the validation result fails.
+func TestValidateOpenAIResponsesAccount_BookkeepingFailureLeavesValidating(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(m *MockAccountRepo)
+	}{
+		{
+			name: "health score update fails",
+			setup: func(m *MockAccountRepo) {
+				m.On("UpdateHealthScore", mock.Anything, int64(42), 100).Return(errors.New("db down"))
+			},
+		},
+		{
+			name: "active status update fails",
+			setup: func(m *MockAccountRepo) {
+				m.On("UpdateHealthScore", mock.Anything, int64(42), 100).Return(nil)
+				m.On("UpdateAccountStatus", mock.Anything, int64(42), data.StatusActive).Return(errors.New("db down")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockAccountRepo)
+			logger := log.DefaultLogger
+			cryptoSvc, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+			require.NoError(t, err)
+
+			mr := miniredis.RunT(t)
+			rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			defer rdb.Close()
+
+			oauthManager := pkgoauth.NewOAuthManager(rdb, logger)
+			oauthManager.RegisterProvider(&openAIValidatorStub{})
+			uc := NewAccountUsecase(mockRepo, cryptoSvc, nil, nil, oauthManager, nil, nil, nil, rdb, logger)
+
+			apiKeyEncrypted, err := cryptoSvc.Encrypt("sk-test-1234567890abcdef")
+			require.NoError(t, err)
+			account := &data.Account{
+				ID:              42,
+				Provider:        data.ProviderOpenAIResponses,
+				APIKeyEncrypted: apiKeyEncrypted,
+				BaseAPI:         "https://api.example.com",
+				HealthScore:     100,
+				Status:          data.StatusCreated,
+			}
+
+			ctx := context.Background()
+			mockRepo.On("GetAccount", ctx, int64(42)).Return(account, nil)
+			mockRepo.On("UpdateAccountStatus", ctx, int64(42), data.StatusValidating).Return(nil).Once()
+			tt.setup(mockRepo)
+			mockRepo.On("UpdateAccountStatus", mock.Anything, int64(42), data.StatusError).Return(nil).Once()
+
+			err = uc.ValidateOpenAIResponsesAccount(ctx, 42)
+
+			assert.Error(t, err)
+			assert.Equal(t, data.StatusError, account.Status)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestValidateOpenAIResponsesAccount_HealthRecovery tests both health recovery modes
+// after a successful validation: full reset and incremental gain.
+func TestValidateOpenAIResponsesAccount_HealthRecovery(t *testing.T) {
+	tests := []struct {
+		name      string
+		step      int
+		current   int
+		wantScore int
+	}{
+		{name: "full reset", step: 0, current: 40, wantScore: 100},
+		{name: "incremental gain", step: 10, current: 40, wantScore: 50},
+		{name: "incremental gain capped at 100", step: 10, current: 95, wantScore: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockAccountRepo)
+			cryptoSvc, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+			require.NoError(t, err)
+
+			mr := miniredis.RunT(t)
+			rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			defer rdb.Close()
+
+			oauthManager := pkgoauth.NewOAuthManager(rdb, log.DefaultLogger)
+			oauthManager.RegisterProvider(&openAIValidatorStub{})
+
+			uc := NewAccountUsecase(mockRepo, cryptoSvc, nil, nil, oauthManager, nil, nil, nil, rdb, log.DefaultLogger)
+			uc.SetHealthRecoveryStep(tt.step)
+			ctx := context.Background()
+
+			apiKeyEncrypted, err := cryptoSvc.Encrypt("sk-test-1234567890abcdef")
+			require.NoError(t, err)
+
+			account := &data.Account{
+				ID:              42,
+				Provider:        data.ProviderOpenAIResponses,
+				APIKeyEncrypted: apiKeyEncrypted,
+				BaseAPI:         "https://api.example.com",
+				HealthScore:     tt.current,
+				Status:          data.StatusActive,
+			}
+
+			mockRepo.On("GetAccount", ctx, int64(42)).Return(account, nil)
+			mockRepo.On("UpdateHealthScore", mock.Anything, int64(42), tt.wantScore).Return(nil).Once()
+			mockRepo.On("UpdateAccountStatus", mock.Anything, int64(42), data.StatusActive).Return(nil).Once()
+			mockRepo.On("UpdateAccount", mock.Anything, mock.MatchedBy(func(a *data.Account) bool {
+				return a.HealthScore == tt.wantScore
+			})).Return(nil)
+
+			require.NoError(t, uc.ValidateOpenAIResponsesAccount(ctx, 42))
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestRecoveredHealthScore tests the score applied after a successful validation or refresh.
+func TestRecoveredHealthScore(t *testing.T) {
+	uc := &AccountUsecase{}
+	assert.Equal(t, 100, uc.recoveredHealthScore(80), "default resets to 100")
+
+	uc.SetHealthRecoveryStep(15)
+	assert.Equal(t, 95, uc.recoveredHealthScore(80))
+	assert.Equal(t, 100, uc.recoveredHealthScore(90))
+	assert.Equal(t, 15, uc.recoveredHealthScore(-5))
+
+	uc.SetHealthRecoveryStep(-1)
+	assert.Equal(t, 100, uc.recoveredHealthScore(10), "negative step falls back to full reset")
+}
+
 // TestGetAccount_Success tests successful account retrieval.
 func TestGetAccount_Success(t *testing.T) {
 	uc, mockRepo, cryptoSvc := setupTestUsecase(t)
@@ -310,6 +636,8 @@ func TestGetAccount_Success(t *testing.T) {
 		OAuthDataEncrypted: encryptedOAuth,
 		RpmLimit:           50,
 		TpmLimit:           100000,
+		DailyTokenLimit:    5000000,
+		AccountPriority:    3,
 		HealthScore:        100,
 		Status:             data.StatusActive,
 	}
@@ -322,6 +650,8 @@ func TestGetAccount_Success(t *testing.T) {
 	assert.NotNil(t, result)
 	assert.Equal(t, int64(1), result.Id)
 	assert.Equal(t, "Test Account", result.Name)
+	assert.Equal(t, int32(5000000), result.DailyTokenLimit)
+	assert.Equal(t, int32(3), result.AccountPriority)
 
 	// Verify sensitive data is masked
 	assert.NotEqual(t, encryptedKey, result.ApiKeyEncrypted)
@@ -386,6 +716,105 @@ func TestListAccounts_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// TestListAccounts_ZeroPageSize tests strict and lenient handling of PageSize < 1.
+func TestListAccounts_ZeroPageSize(t *testing.T) {
+	t.Run("lenient passes through to default", func(t *testing.T) {
+		uc, mockRepo, _ := setupTestUsecase(t)
+		ctx := context.Background()
+
+		mockRepo.On("ListAccounts", ctx, mock.MatchedBy(func(filter *data.AccountFilter) bool {
+			return filter.PageSize == 0
+		})).Return([]*data.Account{}, int32(0), nil).Once()
+
+		_, err := uc.ListAccounts(ctx, &v1.ListAccountsRequest{Page: 1, PageSize: 0})
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("strict rejects", func(t *testing.T) {
+		uc, mockRepo, _ := setupTestUsecase(t)
+		uc.SetStrictPageSize(true)
+		ctx := context.Background()
+
+		for _, pageSize := range []int32{0, -1} {
+			_, err := uc.ListAccounts(ctx, &v1.ListAccountsRequest{Page: 1, PageSize: pageSize})
+			require.Error(t, err)
+			assert.Equal(t, "INVALID_PAGE_SIZE", kerrors.Reason(err))
+			assert.Equal(t, 400, kerrors.Code(err))
+		}
+		mockRepo.AssertNotCalled(t, "ListAccounts", mock.Anything, mock.Anything)
+	})
+
+	t.Run("strict accepts valid page size", func(t *testing.T) {
+		uc, mockRepo, _ := setupTestUsecase(t)
+		uc.SetStrictPageSize(true)
+		ctx := context.Background()
+
+		mockRepo.On("ListAccounts", ctx, mock.AnythingOfType("*data.AccountFilter")).
+			Return([]*data.Account{}, int32(0), nil).Once()
+
+		_, err := uc.ListAccounts(ctx, &v1.ListAccountsRequest{Page: 1, PageSize: 1})
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// TestListAccounts_CircuitBrokenFilter tests the tri-state circuit breaker filter translation.
+func TestListAccounts_CircuitBrokenFilter(t *testing.T) {
+	broken := true
+	healthy := false
+
+	tests := []struct {
+		name string
+		req  *bool
+	}{
+		{"unset", nil},
+		{"only broken", &broken},
+		{"only healthy", &healthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, mockRepo, _ := setupTestUsecase(t)
+			ctx := context.Background()
+
+			mockRepo.On("ListAccounts", ctx, mock.MatchedBy(func(filter *data.AccountFilter) bool {
+				if tt.req == nil {
+					return filter.IsCircuitBroken == nil
+				}
+				return filter.IsCircuitBroken != nil && *filter.IsCircuitBroken == *tt.req &&
+					filter.Provider == data.ProviderClaudeConsole
+			})).Return([]*data.Account{}, int32(0), nil).Once()
+
+			_, err := uc.ListAccounts(ctx, &v1.ListAccountsRequest{
+				Page:            1,
+				PageSize:        10,
+				Provider:        v1.AccountProvider_CLAUDE_CONSOLE,
+				IsCircuitBroken: tt.req,
+			})
+			require.NoError(t, err)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestListAccounts_NeedsReauthFilter tests that the re-auth filter is passed through and surfaced.
+func TestListAccounts_NeedsReauthFilter(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	ctx := context.Background()
+	needsReauth := true
+
+	mockRepo.On("ListAccounts", ctx, mock.MatchedBy(func(filter *data.AccountFilter) bool {
+		return filter.NeedsReauth != nil && *filter.NeedsReauth
+	})).Return([]*data.Account{{ID: 1, Name: "revoked", Status: data.StatusError, NeedsReauth: true}}, int32(1), nil).Once()
+
+	resp, err := uc.ListAccounts(ctx, &v1.ListAccountsRequest{Page: 1, PageSize: 10, NeedsReauth: &needsReauth})
+	require.NoError(t, err)
+	require.Len(t, resp.Accounts, 1)
+	assert.True(t, resp.Accounts[0].NeedsReauth)
+	mockRepo.AssertExpectations(t)
+}
+
 // TestUpdateAccount_Success tests successful account update.
 func TestUpdateAccount_Success(t *testing.T) {
 	uc, mockRepo, _ := setupTestUsecase(t)
@@ -404,12 +833,14 @@ func TestUpdateAccount_Success(t *testing.T) {
 	newName := "New Name"
 	newRpmLimit := int32(100)
 	newMetadata := `{"region":"us-west-2"}`
+	newNotes := "customer requested lower limits"
 
 	req := &v1.UpdateAccountRequest{
 		Id:       1,
 		Name:     &newName,
 		RpmLimit: &newRpmLimit,
 		Metadata: &newMetadata,
+		Notes:    &newNotes,
 	}
 
 	mockRepo.On("GetAccount", ctx, int64(1)).Return(existingAccount, nil)
@@ -421,9 +852,56 @@ func TestUpdateAccount_Success(t *testing.T) {
 	assert.NotNil(t, result)
 	assert.Equal(t, newName, result.Name)
 	assert.Equal(t, newRpmLimit, result.RpmLimit)
+	assert.Equal(t, newNotes, result.Notes)
 	mockRepo.AssertExpectations(t)
 }
 
+// TestUpdateAccount_MetadataMerge tests that merge mode preserves keys not in the request.
+func TestUpdateAccount_MetadataMerge(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	ctx := context.Background()
+
+	existingMetadata := `{"region":"us-east","notes":"primary","tags":["prod"]}`
+	mockRepo.On("GetAccount", ctx, int64(1)).Return(&data.Account{
+		ID:       1,
+		Provider: data.ProviderClaudeConsole,
+		Metadata: &existingMetadata,
+	}, nil)
+
+	var stored string
+	mockRepo.On("UpdateAccount", ctx, mock.MatchedBy(func(a *data.Account) bool {
+		stored = *a.Metadata
+		return true
+	})).Return(nil).Once()
+
+	patch := `{"region":"eu-west","tags":["Staging"]}`
+	_, err := uc.UpdateAccount(ctx, &v1.UpdateAccountRequest{Id: 1, Metadata: &patch, MetadataMerge: true})
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"region":"eu-west","notes":"primary","tags":["staging"]}`, stored)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestUpdateAccount_MetadataMergeValidatesResult tests that the merged metadata is validated.
+func TestUpdateAccount_MetadataMergeValidatesResult(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	ctx := context.Background()
+
+	existingMetadata := `{"region":"us-east"}`
+	mockRepo.On("GetAccount", ctx, int64(1)).Return(&data.Account{
+		ID:       1,
+		Provider: data.ProviderClaudeConsole,
+		Metadata: &existingMetadata,
+	}, nil)
+
+	patch := `{"proxy_url":"ftp://proxy.example.com"}`
+	result, err := uc.UpdateAccount(ctx, &v1.UpdateAccountRequest{Id: 1, Metadata: &patch, MetadataMerge: true})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "UpdateAccount", mock.Anything, mock.Anything)
+}
+
 // TestUpdateAccount_InvalidMetadata tests metadata validation on update.
 func TestUpdateAccount_InvalidMetadata(t *testing.T) {
 	uc, mockRepo, _ := setupTestUsecase(t)
@@ -450,6 +928,74 @@ func TestUpdateAccount_InvalidMetadata(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// TestCreateAccount_NormalizesTags tests that metadata tags are trimmed, lowercased and deduplicated.
+func TestCreateAccount_NormalizesTags(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	ctx := context.Background()
+
+	req := &v1.CreateAccountRequest{
+		Name:     "Tagged Account",
+		Provider: v1.AccountProvider_CLAUDE_CONSOLE,
+		ApiKey:   "sk-ant-1234567890abcdef",
+		Metadata: `{"region":"us-east","tags":["Prod"," prod ","Team-A"]}`,
+	}
+
+	var stored string
+	mockRepo.On("CreateAccount", ctx, mock.MatchedBy(func(a *data.Account) bool {
+		stored = *a.Metadata
+		return true
+	})).Return(nil).Once()
+
+	_, err := uc.CreateAccount(ctx, req)
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"region":"us-east","tags":["prod","team-a"]}`, stored)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestUpdateAccount_RejectsTagsNotMatchingPattern tests the configurable tag pattern.
+// TestUpdateAccount_InvalidOAuthData tests that OAuth data the refresher cannot read is rejected
+// with ReasonInvalidOAuthData before anything is saved.
+func TestUpdateAccount_InvalidOAuthData(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	ctx := context.Background()
+
+	mockRepo.On("GetAccount", ctx, int64(1)).Return(&data.Account{ID: 1, Provider: data.ProviderClaudeConsole}, nil)
+
+	oauthData := `{"access_token_encrypted":"a","expires_at":"2030-01-01T00:00:00Z"}`
+	result, err := uc.UpdateAccount(ctx, &v1.UpdateAccountRequest{Id: 1, OAuthData: &oauthData})
+
+	require.Error(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, ReasonInvalidOAuthData, kerrors.Reason(err))
+	assert.Contains(t, err.Error(), "refresh_token_encrypted not found")
+	mockRepo.AssertNotCalled(t, "UpdateAccount", mock.Anything, mock.Anything)
+}
+
+func TestUpdateAccount_RejectsTagsNotMatchingPattern(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	ctx := context.Background()
+	require.NoError(t, uc.SetTagPattern(`^[a-z0-9-]+$`))
+
+	mockRepo.On("GetAccount", ctx, int64(1)).Return(&data.Account{ID: 1, Provider: data.ProviderClaudeConsole}, nil)
+
+	meta := `{"tags":["Prod","team_a"]}`
+	result, err := uc.UpdateAccount(ctx, &v1.UpdateAccountRequest{Id: 1, Metadata: &meta})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "does not match allowed pattern")
+	mockRepo.AssertNotCalled(t, "UpdateAccount", mock.Anything, mock.Anything)
+}
+
+// TestSetTagPattern_Invalid tests that an invalid regex is rejected.
+func TestSetTagPattern_Invalid(t *testing.T) {
+	uc, _, _ := setupTestUsecase(t)
+
+	assert.Error(t, uc.SetTagPattern(`[unclosed`))
+	assert.NoError(t, uc.SetTagPattern(""))
+}
+
 // TestUpdateAccount_NotFound tests update on non-existent account.
 func TestUpdateAccount_NotFound(t *testing.T) {
 	uc, mockRepo, _ := setupTestUsecase(t)
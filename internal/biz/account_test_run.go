@@ -0,0 +1,45 @@
+package biz
+
+import (
+	"context"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+)
+
+// AccountTest 一次账户测试（TestAccount / TestAccountStream）
+// 账户只从数据库读取一次，代理检查与 Provider 验证共用同一份账户数据
+type AccountTest struct {
+	uc      *AccountUsecase
+	account *data.Account
+}
+
+// StartAccountTest 读取账户并开始一次账户测试；账户不存在时返回 ErrAccountNotFound
+func (uc *AccountUsecase) StartAccountTest(ctx context.Context, id int64) (*AccountTest, error) {
+	account, err := uc.repo.GetAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountTest{uc: uc, account: account}, nil
+}
+
+// Account 返回测试开始时的账户信息（敏感字段已脱敏）
+func (t *AccountTest) Account() *v1.Account {
+	return t.uc.accountProto(t.account)
+}
+
+// EffectiveConfig 返回账户生效的代理、限流与超时配置（见 GetEffectiveConfig）
+func (t *AccountTest) EffectiveConfig() (*v1.EffectiveConfig, error) {
+	return t.uc.effectiveConfig(t.account)
+}
+
+// Supported 返回账户的 Provider 是否注册了验证实现（见 ValidatorFor）
+func (t *AccountTest) Supported() bool {
+	_, ok := t.uc.ValidatorFor(t.account.Provider)
+	return ok
+}
+
+// Validate 按 Provider 验证账户凭证（见 ValidateAccount）
+func (t *AccountTest) Validate(ctx context.Context) (ValidationResult, error) {
+	return t.uc.validateAccount(ctx, t.account)
+}
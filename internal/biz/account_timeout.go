@@ -0,0 +1,30 @@
+package biz
+
+import (
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/metadata"
+)
+
+// ResolveRequestTimeout 返回账户的上游请求超时
+// 优先使用 metadata.request_timeout_ms，未设置时回退到 Provider 默认值（见能力矩阵），
+// 未知 Provider 使用 DefaultRequestTimeout
+func ResolveRequestTimeout(provider data.AccountProvider, meta *metadata.AccountMetadata) time.Duration {
+	if meta != nil && meta.RequestTimeoutMs > 0 {
+		return time.Duration(meta.RequestTimeoutMs) * time.Millisecond
+	}
+	if timeout := CapabilitiesOf(provider).RequestTimeout; timeout > 0 {
+		return timeout
+	}
+	return DefaultRequestTimeout
+}
+
+// requestTimeoutMs 返回账户生效的请求超时（毫秒），元数据无法解析时使用 Provider 默认值
+func requestTimeoutMs(account *data.Account) int32 {
+	meta, err := data.ParseMetadata(account.Metadata)
+	if err != nil {
+		meta = nil
+	}
+	return int32(ResolveRequestTimeout(account.Provider, meta).Milliseconds()) // #nosec G115 -- bounded by MaxRequestTimeoutMs / provider defaults
+}
@@ -0,0 +1,69 @@
+package biz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/metadata"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveRequestTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider data.AccountProvider
+		meta     *metadata.AccountMetadata
+		want     time.Duration
+	}{
+		{"metadata override", data.ProviderClaudeConsole, &metadata.AccountMetadata{RequestTimeoutMs: 90000}, 90 * time.Second},
+		{"claude provider default", data.ProviderClaudeConsole, &metadata.AccountMetadata{}, 10 * time.Minute},
+		{"openai provider default", data.ProviderOpenAIResponses, nil, 5 * time.Minute},
+		{"unknown provider fallback", "unknown", nil, DefaultRequestTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ResolveRequestTimeout(tt.provider, tt.meta))
+		})
+	}
+}
+
+func TestCheckModelAllowed_RequestTimeout(t *testing.T) {
+	ctx := context.Background()
+	override := `{"request_timeout_ms":45000}`
+
+	repo := new(MockAccountRepo)
+	uc := &AccountUsecase{repo: repo, logger: log.NewHelper(log.DefaultLogger)}
+	repo.On("GetAccount", ctx, int64(1)).Return(&data.Account{ID: 1, Provider: data.ProviderClaudeConsole, Metadata: &override}, nil)
+	repo.On("GetAccount", ctx, int64(2)).Return(&data.Account{ID: 2, Provider: data.ProviderOpenAIResponses}, nil)
+
+	_, timeout, err := uc.CheckModelAllowed(ctx, 1, "claude-sonnet")
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Second, timeout)
+
+	_, timeout, err = uc.CheckModelAllowed(ctx, 2, "gpt-4o")
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, timeout)
+}
+
+func TestGetAccount_RequestTimeoutMs(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	ctx := context.Background()
+
+	override := `{"request_timeout_ms":30000}`
+	mockRepo.On("GetAccount", ctx, int64(1)).Return(&data.Account{ID: 1, Provider: data.ProviderClaudeConsole, Metadata: &override}, nil)
+	mockRepo.On("GetAccount", ctx, int64(2)).Return(&data.Account{ID: 2, Provider: data.ProviderOpenAIResponses}, nil)
+
+	account, err := uc.GetAccount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(30000), account.RequestTimeoutMs)
+
+	account, err = uc.GetAccount(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int32(300000), account.RequestTimeoutMs)
+}
@@ -0,0 +1,38 @@
+package biz
+
+import (
+	"fmt"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// 账户请求校验失败的原因码（ErrorInfo.reason），客户端据此区分失败类型而无需解析消息文本
+const (
+	ReasonUnsupportedProvider  = "UNSUPPORTED_PROVIDER"
+	ReasonInvalidMetadata      = "INVALID_METADATA"
+	ReasonInvalidOAuthData     = "INVALID_OAUTH_DATA"
+	ReasonInvalidBaseAPI       = "INVALID_BASE_API"
+	ReasonBaseAPIRequired      = "BASE_API_REQUIRED"
+	ReasonInvalidInitialStatus = "INVALID_INITIAL_STATUS"
+)
+
+// ValidationFieldKey is the error metadata key holding the request field (proto field name)
+// that failed validation. The service layer turns it into a google.rpc.BadRequest field violation.
+const ValidationFieldKey = "field"
+
+// newValidationError returns a BadRequest error carrying a machine-readable reason and the
+// offending request field; the message stays human-readable.
+func newValidationError(reason, field, format string, args ...interface{}) *errors.Error {
+	return errors.BadRequest(reason, fmt.Sprintf(format, args...)).
+		WithMetadata(map[string]string{ValidationFieldKey: field})
+}
+
+// ValidationField returns the request field a validation error refers to, if err is one.
+func ValidationField(err error) (string, bool) {
+	var verr *errors.Error
+	if !errors.As(err, &verr) || verr.Code != 400 {
+		return "", false
+	}
+	field, ok := verr.Metadata[ValidationFieldKey]
+	return field, ok && field != ""
+}
@@ -0,0 +1,92 @@
+package biz
+
+import (
+	"context"
+	"testing"
+
+	v1 "QuotaLane/api/v1"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateAccount_ValidationErrors tests that every CreateAccount validation failure carries
+// a reason code and the offending field while keeping the human-readable message.
+func TestCreateAccount_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *v1.CreateAccountRequest
+		wantReason string
+		wantField  string
+		wantMsg    string
+	}{
+		{
+			name:       "unsupported provider",
+			req:        &v1.CreateAccountRequest{Name: "a", Provider: v1.AccountProvider_GEMINI},
+			wantReason: ReasonUnsupportedProvider, wantField: "Provider", wantMsg: "unsupported provider",
+		},
+		{
+			name:       "malformed metadata",
+			req:        &v1.CreateAccountRequest{Name: "a", Provider: v1.AccountProvider_CLAUDE_CONSOLE, Metadata: "{invalid json}"},
+			wantReason: ReasonInvalidMetadata, wantField: "Metadata", wantMsg: "invalid metadata JSON",
+		},
+		{
+			name:       "metadata rule violation",
+			req:        &v1.CreateAccountRequest{Name: "a", Provider: v1.AccountProvider_CLAUDE_CONSOLE, Metadata: `{"custom_base_url":"http://relay.example.com"}`},
+			wantReason: ReasonInvalidMetadata, wantField: "Metadata", wantMsg: "metadata validation failed",
+		},
+		{
+			name:       "invalid initial status",
+			req:        &v1.CreateAccountRequest{Name: "a", Provider: v1.AccountProvider_CLAUDE_CONSOLE, InitialStatus: v1.AccountStatus_ACCOUNT_ERROR},
+			wantReason: ReasonInvalidInitialStatus, wantField: "InitialStatus", wantMsg: "invalid initial status",
+		},
+		{
+			name:       "invalid base_api",
+			req:        &v1.CreateAccountRequest{Name: "a", Provider: v1.AccountProvider_OPENAI_RESPONSES, ApiKey: "sk-test-1234567890abcdef", BaseApi: "not a url"},
+			wantReason: ReasonInvalidBaseAPI, wantField: "BaseApi", wantMsg: "invalid base_api",
+		},
+		{
+			name:       "missing base_api",
+			req:        &v1.CreateAccountRequest{Name: "a", Provider: v1.AccountProvider_OPENAI_RESPONSES, ApiKey: "sk-test-1234567890abcdef"},
+			wantReason: ReasonBaseAPIRequired, wantField: "BaseApi", wantMsg: "base_api is required",
+		},
+		{
+			name:       "invalid OAuth data",
+			req:        &v1.CreateAccountRequest{Name: "a", Provider: v1.AccountProvider_CLAUDE_CONSOLE, OAuthData: "not a json"},
+			wantReason: ReasonInvalidOAuthData, wantField: "OAuthData", wantMsg: "invalid OAuth data format",
+		},
+		{
+			name:       "OAuth data the refresher cannot read",
+			req:        &v1.CreateAccountRequest{Name: "a", Provider: v1.AccountProvider_CLAUDE_CONSOLE, OAuthData: `{"access_token":"t","refresh_token":"r"}`},
+			wantReason: ReasonInvalidOAuthData, wantField: "OAuthData", wantMsg: "expires_at not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, mockRepo, _ := setupTestUsecase(t)
+
+			result, err := uc.CreateAccount(context.Background(), tt.req)
+
+			require.Error(t, err)
+			assert.Nil(t, result)
+			assert.True(t, errors.IsBadRequest(err))
+			assert.Equal(t, tt.wantReason, errors.Reason(err))
+			field, ok := ValidationField(err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.wantField, field)
+			assert.Contains(t, errors.FromError(err).Message, tt.wantMsg)
+			mockRepo.AssertNotCalled(t, "CreateAccount", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestValidationField_NotValidationError(t *testing.T) {
+	_, ok := ValidationField(errors.BadRequest("INVALID_PAGE_SIZE", "page_size must be between 1 and 100"))
+	assert.False(t, ok)
+
+	_, ok = ValidationField(context.Canceled)
+	assert.False(t, ok)
+}
@@ -13,6 +13,7 @@ var ProviderSet = wire.NewSet(
 	NewAccountUsecase,
 	NewAccountGroupUseCase,
 	NewOAuthRefreshTask,
+	NewRefreshGuard,
 	NewRateLimiterUseCase,
 	NewCircuitBreakerUsecase,
 	// Import data layer providers
@@ -20,6 +21,8 @@ var ProviderSet = wire.NewSet(
 	data.NewAccountGroupRepo,
 	data.NewRateLimitRepo,
 	data.NewCircuitBreakerRepo,
+	data.NewRefreshRunRepo,
+	data.NewTransactor,
 	data.NewAuditLogger,
 	data.NewNoopWebhookService,
 	// Bind data layer implementations to biz layer interfaces
@@ -27,6 +30,8 @@ var ProviderSet = wire.NewSet(
 	wire.Bind(new(AccountGroupRepo), new(*data.AccountGroupRepo)),
 	wire.Bind(new(RateLimitRepo), new(*data.RateLimitRepo)),
 	wire.Bind(new(CircuitBreakerRepo), new(*data.CircuitBreakerRepo)),
+	wire.Bind(new(RefreshRunRepo), new(*data.RefreshRunRepo)),
+	wire.Bind(new(Transactor), new(*data.Transactor)),
 	wire.Bind(new(AuditLogger), new(*data.AuditLoggerImpl)),
 	wire.Bind(new(WebhookService), new(*data.NoopWebhookService)),
 )
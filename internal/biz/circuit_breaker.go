@@ -68,7 +68,9 @@ type CircuitBreakerUsecase struct {
 	repo    CircuitBreakerRepo
 	audit   AuditLogger
 	webhook WebhookService
+	config  CircuitBreakerConfig
 	logger  *log.Helper
+	clock   Clock // 时间来源（测试中可替换为假时钟）
 }
 
 // CircuitBreakerRepo defines the data layer interface for circuit breaker
@@ -103,6 +105,15 @@ type CircuitBreakerRepo interface {
 
 	// GetAccount retrieves account info (health_score, is_circuit_broken, etc.)
 	GetAccount(ctx context.Context, accountID int64) (*data.Account, error)
+
+	// IncrementConsecutiveFailures increments the consecutive failure counter and returns new count
+	IncrementConsecutiveFailures(ctx context.Context, accountID int64) (int, error)
+
+	// ResetConsecutiveFailures clears the consecutive failure counter
+	ResetConsecutiveFailures(ctx context.Context, accountID int64) error
+
+	// RecordOutcome appends an outcome to the rolling window and returns failures/total in the window
+	RecordOutcome(ctx context.Context, accountID int64, success bool, windowSize int) (failures int, total int, err error)
 }
 
 // NewCircuitBreakerUsecase creates a new circuit breaker usecase
@@ -111,7 +122,9 @@ func NewCircuitBreakerUsecase(repo CircuitBreakerRepo, audit AuditLogger, webhoo
 		repo:    repo,
 		audit:   audit,
 		webhook: webhook,
+		config:  DefaultCircuitBreakerConfig(),
 		logger:  log.NewHelper(logger),
+		clock:   SystemClock,
 	}
 }
 
@@ -230,7 +243,7 @@ func (uc *CircuitBreakerUsecase) ResetHealthScore(ctx context.Context, accountID
 		if err := uc.repo.ResetCircuitBreaker(ctx, accountID); err != nil {
 			uc.logger.Errorw("failed to reset circuit breaker", "account_id", accountID, "error", err)
 		} else {
-			uc.audit.LogCircuitRecovered(ctx, accountID, time.Since(*account.CircuitBrokenAt), 0)
+			uc.audit.LogCircuitRecovered(ctx, accountID, uc.now().Sub(*account.CircuitBrokenAt), 0)
 		}
 	}
 
@@ -254,7 +267,7 @@ func (uc *CircuitBreakerUsecase) CheckCircuitBreaker(ctx context.Context, accoun
 
 // triggerCircuitBreaker marks account as circuit broken
 func (uc *CircuitBreakerUsecase) triggerCircuitBreaker(ctx context.Context, accountID int64, healthScore int) error {
-	now := time.Now()
+	now := uc.now()
 
 	// Mark as circuit broken in DB
 	if err := uc.repo.SetCircuitBroken(ctx, accountID, now); err != nil {
@@ -314,7 +327,7 @@ func (uc *CircuitBreakerUsecase) IsHalfOpen(ctx context.Context, accountID int64
 	}
 
 	// Not enough time has passed
-	if time.Now().Before(*backoffTime) {
+	if uc.now().Before(*backoffTime) {
 		return false, nil
 	}
 
@@ -404,7 +417,7 @@ func (uc *CircuitBreakerUsecase) RecordProbeFailure(ctx context.Context, account
 		nextBackoff = 10 * time.Minute
 	} else {
 		// Calculate based on how many times we've backed off
-		timeSinceBreak := time.Since(*state.CircuitBrokenAt)
+		timeSinceBreak := uc.now().Sub(*state.CircuitBrokenAt)
 		if timeSinceBreak < 15*time.Minute {
 			// Second failure -> 30 minutes
 			nextBackoff = 30 * time.Minute
@@ -414,7 +427,7 @@ func (uc *CircuitBreakerUsecase) RecordProbeFailure(ctx context.Context, account
 		}
 	}
 
-	nextRetry := time.Now().Add(nextBackoff)
+	nextRetry := uc.now().Add(nextBackoff)
 
 	// Set new backoff time
 	if err := uc.repo.SetBackoffTime(ctx, accountID, nextRetry); err != nil {
@@ -442,7 +455,7 @@ func (uc *CircuitBreakerUsecase) resetCircuitBreakerAfterProbe(ctx context.Conte
 
 	recoverTime := time.Duration(0)
 	if account.CircuitBrokenAt != nil {
-		recoverTime = time.Since(*account.CircuitBrokenAt)
+		recoverTime = uc.now().Sub(*account.CircuitBrokenAt)
 	}
 
 	uc.logger.Infow("circuit breaker recovered after successful probes",
@@ -470,6 +483,41 @@ func (uc *CircuitBreakerUsecase) resetCircuitBreakerAfterProbe(ctx context.Conte
 	return nil
 }
 
+// ClearAfterProbe closes an open circuit after an operator probe (ProbeAccount) succeeded.
+// Unlike ResetHealthScore the health score is left as the probe found it.
+func (uc *CircuitBreakerUsecase) ClearAfterProbe(ctx context.Context, accountID int64) error {
+	account, err := uc.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+	if !account.IsCircuitBroken {
+		return nil
+	}
+	return uc.resetCircuitBreakerAfterProbe(ctx, accountID, 1)
+}
+
+// RestoreCircuitState puts the breaker back into the given state after a failed operator probe,
+// undoing any trip or recovery the probe's validation caused. brokenAt keeps the original
+// break time so the half-open backoff is not restarted.
+func (uc *CircuitBreakerUsecase) RestoreCircuitState(ctx context.Context, accountID int64, broken bool, brokenAt *time.Time) error {
+	account, err := uc.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+	if account.IsCircuitBroken == broken {
+		return nil
+	}
+
+	if !broken {
+		return uc.repo.ResetCircuitBreaker(ctx, accountID)
+	}
+	at := uc.now()
+	if brokenAt != nil {
+		at = *brokenAt
+	}
+	return uc.repo.SetCircuitBroken(ctx, accountID, at)
+}
+
 // RecordAPIError records API error and updates health score
 // Implements AC#1 and handles distinction with Story 2.4 local rate limiting
 func (uc *CircuitBreakerUsecase) RecordAPIError(ctx context.Context, accountID int64, statusCode int, isLocalRateLimit bool) error {
@@ -497,10 +545,24 @@ func (uc *CircuitBreakerUsecase) RecordAPIError(ctx context.Context, accountID i
 		errorType = ErrorTypeServerError
 	}
 
-	return uc.UpdateHealthScore(ctx, accountID, errorType)
+	if err := uc.UpdateHealthScore(ctx, accountID, errorType); err != nil {
+		return err
+	}
+
+	if _, err := uc.TripOnStatus(ctx, accountID, statusCode); err != nil {
+		uc.logger.Errorw("failed to trip circuit breaker on status", "account_id", accountID, "status_code", statusCode, "error", err)
+	}
+
+	uc.recordOutcome(ctx, accountID, false)
+	return nil
 }
 
 // RecordAPISuccess records successful API call
 func (uc *CircuitBreakerUsecase) RecordAPISuccess(ctx context.Context, accountID int64) error {
-	return uc.IncrementHealthScore(ctx, accountID)
+	if err := uc.IncrementHealthScore(ctx, accountID); err != nil {
+		return err
+	}
+
+	uc.recordOutcome(ctx, accountID, true)
+	return nil
 }
@@ -0,0 +1,100 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/openai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func immediateTripConfig() CircuitBreakerConfig {
+	cfg := DefaultCircuitBreakerConfig()
+	cfg.ImmediateTripStatusCodes = []int{403}
+	return cfg
+}
+
+func TestCircuitBreaker_ImmediateTripStatus(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("403 trips on first occurrence", func(t *testing.T) {
+		uc, repo := setupCircuitBreakerModeTest(t, immediateTripConfig())
+
+		require.NoError(t, uc.RecordAPIError(ctx, 1, 403, false))
+		assert.True(t, repo.isBroken())
+		assert.Equal(t, 1, repo.brokenCount)
+	})
+
+	t.Run("429 does not trip", func(t *testing.T) {
+		uc, repo := setupCircuitBreakerModeTest(t, immediateTripConfig())
+
+		require.NoError(t, uc.RecordAPIError(ctx, 1, 429, false))
+		assert.False(t, repo.isBroken())
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		uc, repo := setupCircuitBreakerModeTest(t, DefaultCircuitBreakerConfig())
+
+		require.NoError(t, uc.RecordAPIError(ctx, 1, 403, false))
+		assert.False(t, repo.isBroken())
+	})
+}
+
+func TestCircuitBreakerConfig_ValidateImmediateTripStatusCodes(t *testing.T) {
+	cfg := DefaultCircuitBreakerConfig()
+	cfg.ImmediateTripStatusCodes = []int{403, 451}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.ImmediateTripStatusCodes = []int{4030}
+	assert.Error(t, cfg.Validate())
+}
+
+// setupImmediateTripAccountTest wires a breaker that trips immediately on 403 into the refresh failure test usecase.
+func setupImmediateTripAccountTest(t *testing.T) (*AccountUsecase, *MockAccountRepo, *fakeCircuitBreakerRepo) {
+	uc, mockRepo, _ := setupRefreshFailureTest(t, time.Hour)
+	cb, cbRepo := setupCircuitBreakerModeTest(t, immediateTripConfig())
+	uc.circuitBreaker = cb
+	return uc, mockRepo, cbRepo
+}
+
+var immediateTripCases = []struct {
+	name       string
+	err        error
+	wantBroken bool
+}{
+	{"403 trips immediately", &openai.AuthError{StatusCode: 403}, true},
+	{"429 does not trip", fmt.Errorf("validation failed: %w", &openai.RateLimitError{StatusCode: 429}), false},
+}
+
+func TestHandleValidationFailure_ImmediateTrip(t *testing.T) {
+	for _, tt := range immediateTripCases {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, mockRepo, cbRepo := setupImmediateTripAccountTest(t)
+			mockRepo.On("UpdateAccountStatus", mock.Anything, int64(1), data.StatusError).Return(nil)
+			mockRepo.On("UpdateAccount", mock.Anything, mock.Anything).Return(nil)
+			account := &data.Account{ID: 1, HealthScore: 100}
+
+			assert.ErrorIs(t, uc.handleValidationFailure(context.Background(), account, tt.err), tt.err)
+			assert.Equal(t, tt.wantBroken, cbRepo.isBroken())
+			assert.Equal(t, tt.wantBroken, account.IsCircuitBroken, "saved account must keep the breaker state")
+		})
+	}
+}
+
+func TestHandleRefreshFailure_ImmediateTrip(t *testing.T) {
+	for _, tt := range immediateTripCases {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, mockRepo, cbRepo := setupImmediateTripAccountTest(t)
+
+			require.NoError(t, uc.handleRefreshFailure(context.Background(), 1, tt.err))
+			assert.Equal(t, tt.wantBroken, cbRepo.isBroken())
+			mockRepo.AssertCalled(t, "UpdateHealthScore", mock.Anything, int64(1), 80)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// CircuitBreakerMode selects how request outcomes trip the circuit breaker
+// (in addition to the health score threshold).
+type CircuitBreakerMode string
+
+const (
+	// CircuitBreakerModeConsecutive trips after N consecutive failed requests.
+	CircuitBreakerModeConsecutive CircuitBreakerMode = "consecutive"
+	// CircuitBreakerModeFailureRate trips when the failure rate over the last N requests
+	// reaches the configured fraction.
+	CircuitBreakerModeFailureRate CircuitBreakerMode = "failure_rate"
+)
+
+// CircuitBreakerConfig configures outcome-based tripping.
+type CircuitBreakerConfig struct {
+	Mode CircuitBreakerMode
+
+	// ConsecutiveThreshold is the number of consecutive failures that trips the breaker (consecutive mode).
+	ConsecutiveThreshold int
+
+	// WindowSize is the number of most recent requests kept in the rolling window (failure_rate mode).
+	WindowSize int
+	// MinRequests is the minimum number of requests in the window before the rate is evaluated.
+	MinRequests int
+	// FailureRateThreshold trips the breaker when failures/total >= this fraction (0-1).
+	FailureRateThreshold float64
+
+	// ImmediateTripStatusCodes are upstream HTTP status codes (e.g. 403 for a banned account)
+	// that open the breaker on a single occurrence, in any mode. Empty disables immediate trips.
+	ImmediateTripStatusCodes []int
+}
+
+// DefaultCircuitBreakerConfig returns the default configuration: 3 consecutive failures.
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		Mode:                 CircuitBreakerModeConsecutive,
+		ConsecutiveThreshold: 3,
+		WindowSize:           20,
+		MinRequests:          10,
+		FailureRateThreshold: 0.5,
+	}
+}
+
+// Validate checks that the configuration is usable for its mode.
+func (c CircuitBreakerConfig) Validate() error {
+	switch c.Mode {
+	case CircuitBreakerModeConsecutive:
+		if c.ConsecutiveThreshold <= 0 {
+			return fmt.Errorf("consecutive threshold must be positive, got %d", c.ConsecutiveThreshold)
+		}
+	case CircuitBreakerModeFailureRate:
+		if c.WindowSize <= 0 {
+			return fmt.Errorf("window size must be positive, got %d", c.WindowSize)
+		}
+		if c.MinRequests <= 0 || c.MinRequests > c.WindowSize {
+			return fmt.Errorf("min requests must be between 1 and window size %d, got %d", c.WindowSize, c.MinRequests)
+		}
+		if c.FailureRateThreshold <= 0 || c.FailureRateThreshold > 1 {
+			return fmt.Errorf("failure rate threshold must be in (0, 1], got %v", c.FailureRateThreshold)
+		}
+	default:
+		return fmt.Errorf("unknown circuit breaker mode: %s", c.Mode)
+	}
+	for _, code := range c.ImmediateTripStatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("immediate trip status code must be a valid HTTP status, got %d", code)
+		}
+	}
+	return nil
+}
+
+// tripsImmediately reports whether statusCode is configured to open the breaker on a single occurrence.
+func (c CircuitBreakerConfig) tripsImmediately(statusCode int) bool {
+	return statusCode != 0 && slices.Contains(c.ImmediateTripStatusCodes, statusCode)
+}
+
+// SetConfig replaces the outcome-based tripping configuration.
+func (uc *CircuitBreakerUsecase) SetConfig(cfg CircuitBreakerConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid circuit breaker config: %w", err)
+	}
+	uc.config = cfg
+	return nil
+}
+
+// recordOutcome tracks a request outcome according to the configured mode and trips
+// the breaker when the mode's threshold is reached.
+// Redis degradation: tracking failures are logged and never block the request path.
+func (uc *CircuitBreakerUsecase) recordOutcome(ctx context.Context, accountID int64, success bool) {
+	var shouldTrip bool
+
+	switch uc.config.Mode {
+	case CircuitBreakerModeFailureRate:
+		failures, total, err := uc.repo.RecordOutcome(ctx, accountID, success, uc.config.WindowSize)
+		if err != nil {
+			uc.logger.Warnw("failed to record request outcome (degraded mode)", "account_id", accountID, "error", err)
+			return
+		}
+		if total >= uc.config.MinRequests && float64(failures)/float64(total) >= uc.config.FailureRateThreshold {
+			uc.logger.Warnw("failure rate threshold reached",
+				"account_id", accountID,
+				"failures", failures,
+				"total", total,
+				"threshold", uc.config.FailureRateThreshold)
+			shouldTrip = true
+		}
+
+	default:
+		if success {
+			if err := uc.repo.ResetConsecutiveFailures(ctx, accountID); err != nil {
+				uc.logger.Warnw("failed to reset consecutive failures (degraded mode)", "account_id", accountID, "error", err)
+			}
+			return
+		}
+
+		count, err := uc.repo.IncrementConsecutiveFailures(ctx, accountID)
+		if err != nil {
+			uc.logger.Warnw("failed to increment consecutive failures (degraded mode)", "account_id", accountID, "error", err)
+			return
+		}
+		if count >= uc.config.ConsecutiveThreshold {
+			uc.logger.Warnw("consecutive failure threshold reached",
+				"account_id", accountID,
+				"consecutive_failures", count)
+			shouldTrip = true
+		}
+	}
+
+	if !shouldTrip {
+		return
+	}
+
+	account, err := uc.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		uc.logger.Errorw("failed to get account before tripping circuit breaker", "account_id", accountID, "error", err)
+		return
+	}
+	if account.IsCircuitBroken {
+		return
+	}
+
+	if err := uc.triggerCircuitBreaker(ctx, accountID, account.HealthScore); err != nil {
+		uc.logger.Errorw("failed to trigger circuit breaker", "account_id", accountID, "error", err)
+	}
+}
+
+// TripOnStatus opens the breaker right away when statusCode is one of the configured
+// immediate-trip status codes, instead of waiting for the health score or failure
+// thresholds. Returns true if the status matched (the account is now circuit broken).
+func (uc *CircuitBreakerUsecase) TripOnStatus(ctx context.Context, accountID int64, statusCode int) (bool, error) {
+	if !uc.config.tripsImmediately(statusCode) {
+		return false, nil
+	}
+
+	account, err := uc.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get account: %w", err)
+	}
+	if account.IsCircuitBroken {
+		return true, nil
+	}
+
+	uc.logger.Warnw("immediate trip status received",
+		"account_id", accountID,
+		"status_code", statusCode)
+	if err := uc.triggerCircuitBreaker(ctx, accountID, account.HealthScore); err != nil {
+		return false, err
+	}
+	return true, nil
+}
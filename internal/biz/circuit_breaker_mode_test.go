@@ -0,0 +1,206 @@
+package biz
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/internal/model"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCircuitBreakerRepo is an in-memory CircuitBreakerRepo for testing.
+type fakeCircuitBreakerRepo struct {
+	mu          sync.Mutex
+	account     data.Account
+	consecutive int
+	window      []bool // newest first
+	brokenCount int
+}
+
+func (f *fakeCircuitBreakerRepo) UpdateHealthScore(ctx context.Context, accountID int64, newScore int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.account.HealthScore = newScore
+	return nil
+}
+
+func (f *fakeCircuitBreakerRepo) SetCircuitBroken(ctx context.Context, accountID int64, brokenAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.account.IsCircuitBroken = true
+	f.account.CircuitBrokenAt = &brokenAt
+	f.brokenCount++
+	return nil
+}
+
+func (f *fakeCircuitBreakerRepo) GetCircuitState(ctx context.Context, accountID int64) (*model.CircuitState, error) {
+	return &model.CircuitState{}, nil
+}
+
+func (f *fakeCircuitBreakerRepo) SetHalfOpen(ctx context.Context, accountID int64, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeCircuitBreakerRepo) IncrementSuccessCount(ctx context.Context, accountID int64) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeCircuitBreakerRepo) GetSuccessCount(ctx context.Context, accountID int64) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeCircuitBreakerRepo) ResetCircuitBreaker(ctx context.Context, accountID int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.account.IsCircuitBroken = false
+	f.account.CircuitBrokenAt = nil
+	return nil
+}
+
+func (f *fakeCircuitBreakerRepo) SetBackoffTime(ctx context.Context, accountID int64, nextRetry time.Time) error {
+	return nil
+}
+
+func (f *fakeCircuitBreakerRepo) GetBackoffTime(ctx context.Context, accountID int64) (*time.Time, error) {
+	return nil, nil
+}
+
+func (f *fakeCircuitBreakerRepo) GetAccount(ctx context.Context, accountID int64) (*data.Account, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	account := f.account
+	return &account, nil
+}
+
+func (f *fakeCircuitBreakerRepo) IncrementConsecutiveFailures(ctx context.Context, accountID int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.consecutive++
+	return f.consecutive, nil
+}
+
+func (f *fakeCircuitBreakerRepo) ResetConsecutiveFailures(ctx context.Context, accountID int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.consecutive = 0
+	return nil
+}
+
+func (f *fakeCircuitBreakerRepo) RecordOutcome(ctx context.Context, accountID int64, success bool, windowSize int) (int, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.window = append([]bool{success}, f.window...)
+	if len(f.window) > windowSize {
+		f.window = f.window[:windowSize]
+	}
+	failures := 0
+	for _, ok := range f.window {
+		if !ok {
+			failures++
+		}
+	}
+	return failures, len(f.window), nil
+}
+
+func (f *fakeCircuitBreakerRepo) isBroken() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.account.IsCircuitBroken
+}
+
+type nopAuditLogger struct{}
+
+func (nopAuditLogger) LogHealthScoreChange(ctx context.Context, accountID int64, oldScore, newScore int, reason string) {
+}
+func (nopAuditLogger) LogCircuitBroken(ctx context.Context, accountID int64, healthScore int, brokenAt time.Time) {
+}
+func (nopAuditLogger) LogCircuitRecovered(ctx context.Context, accountID int64, recoverTime time.Duration, probeCount int) {
+}
+func (nopAuditLogger) LogHealthScoreReset(ctx context.Context, accountID int64, operatorID int64, oldScore int) {
+}
+
+type nopWebhookService struct{}
+
+func (nopWebhookService) NotifyCircuitBroken(ctx context.Context, event *model.CircuitBrokenEvent) error {
+	return nil
+}
+func (nopWebhookService) NotifyCircuitRecovered(ctx context.Context, event *model.CircuitRecoveredEvent) error {
+	return nil
+}
+
+func setupCircuitBreakerModeTest(t *testing.T, cfg CircuitBreakerConfig) (*CircuitBreakerUsecase, *fakeCircuitBreakerRepo) {
+	repo := &fakeCircuitBreakerRepo{account: data.Account{ID: 1, HealthScore: 100}}
+	uc := NewCircuitBreakerUsecase(repo, nopAuditLogger{}, nopWebhookService{}, log.DefaultLogger)
+	require.NoError(t, uc.SetConfig(cfg))
+	return uc, repo
+}
+
+// runAlternating sends n fail/success pairs (50% failure rate, never 2 failures in a row).
+func runAlternating(t *testing.T, uc *CircuitBreakerUsecase, n int) {
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		require.NoError(t, uc.RecordAPIError(ctx, 1, 503, false))
+		require.NoError(t, uc.RecordAPISuccess(ctx, 1))
+	}
+}
+
+func TestCircuitBreakerMode_FiftyPercentFailures(t *testing.T) {
+	t.Run("failure rate mode trips", func(t *testing.T) {
+		cfg := DefaultCircuitBreakerConfig()
+		cfg.Mode = CircuitBreakerModeFailureRate
+		cfg.WindowSize = 10
+		cfg.MinRequests = 10
+		cfg.FailureRateThreshold = 0.5
+		uc, repo := setupCircuitBreakerModeTest(t, cfg)
+
+		runAlternating(t, uc, 4)
+		assert.False(t, repo.isBroken(), "window not full yet")
+
+		runAlternating(t, uc, 1)
+		assert.True(t, repo.isBroken())
+		assert.Equal(t, 1, repo.brokenCount)
+	})
+
+	t.Run("consecutive mode does not trip", func(t *testing.T) {
+		uc, repo := setupCircuitBreakerModeTest(t, DefaultCircuitBreakerConfig())
+
+		runAlternating(t, uc, 5)
+		assert.False(t, repo.isBroken())
+	})
+}
+
+func TestCircuitBreakerMode_ConsecutiveFailuresTrip(t *testing.T) {
+	uc, repo := setupCircuitBreakerModeTest(t, DefaultCircuitBreakerConfig())
+	ctx := context.Background()
+
+	require.NoError(t, uc.RecordAPIError(ctx, 1, 503, false))
+	require.NoError(t, uc.RecordAPIError(ctx, 1, 503, false))
+	assert.False(t, repo.isBroken())
+
+	require.NoError(t, uc.RecordAPIError(ctx, 1, 503, false))
+	assert.True(t, repo.isBroken())
+}
+
+func TestCircuitBreakerConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultCircuitBreakerConfig().Validate())
+
+	cfg := DefaultCircuitBreakerConfig()
+	cfg.Mode = "sometimes"
+	assert.Error(t, cfg.Validate())
+
+	cfg = DefaultCircuitBreakerConfig()
+	cfg.Mode = CircuitBreakerModeFailureRate
+	cfg.FailureRateThreshold = 1.5
+	assert.Error(t, cfg.Validate())
+
+	cfg = DefaultCircuitBreakerConfig()
+	cfg.Mode = CircuitBreakerModeFailureRate
+	cfg.MinRequests = cfg.WindowSize + 1
+	assert.Error(t, cfg.Validate())
+}
@@ -0,0 +1,72 @@
+package biz
+
+import "time"
+
+// Clock 时间来源抽象：生产环境使用系统时钟，测试中替换为可手动推进的假时钟，
+// 使 Token 过期、刷新阈值、熔断冷却、限流窗口等时间判断可以确定性地测试
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock 基于 time.Now 的系统时钟
+type systemClock struct{}
+
+// Now 返回当前系统时间
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock 生产环境使用的系统时钟（各 Usecase 的默认时钟）
+var SystemClock Clock = systemClock{}
+
+// clockNow 返回时钟的当前时间；未注入时钟（如测试中直接构造结构体）时回退到系统时间
+func clockNow(clock Clock) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock.Now()
+}
+
+// SetClock 替换账户用例的时钟；nil 时恢复系统时钟
+func (uc *AccountUsecase) SetClock(clock Clock) {
+	uc.clock = orSystemClock(clock)
+}
+
+func (uc *AccountUsecase) now() time.Time {
+	return clockNow(uc.clock)
+}
+
+// SetClock 替换熔断器用例的时钟；nil 时恢复系统时钟
+func (uc *CircuitBreakerUsecase) SetClock(clock Clock) {
+	uc.clock = orSystemClock(clock)
+}
+
+func (uc *CircuitBreakerUsecase) now() time.Time {
+	return clockNow(uc.clock)
+}
+
+// SetClock 替换限流用例的时钟；nil 时恢复系统时钟
+func (uc *RateLimiterUseCase) SetClock(clock Clock) {
+	uc.clock = orSystemClock(clock)
+}
+
+func (uc *RateLimiterUseCase) now() time.Time {
+	return clockNow(uc.clock)
+}
+
+// SetClock 替换 Token 刷新任务的时钟；nil 时恢复系统时钟
+func (t *OAuthRefreshTask) SetClock(clock Clock) {
+	t.clock = orSystemClock(clock)
+}
+
+func (t *OAuthRefreshTask) now() time.Time {
+	return clockNow(t.clock)
+}
+
+// orSystemClock 未指定时钟时使用系统时钟
+func orSystemClock(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock
+	}
+	return clock
+}
@@ -0,0 +1,129 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/internal/model"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced Clock for deterministic time-dependent tests.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// newFakeClock starts at the current wall time so fixtures built relative to time.Now stay valid.
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Now().UTC().Truncate(time.Second)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// cooldownCircuitBreakerRepo reports the circuit state recorded by SetCircuitBroken.
+type cooldownCircuitBreakerRepo struct {
+	fakeCircuitBreakerRepo
+}
+
+func (f *cooldownCircuitBreakerRepo) GetCircuitState(ctx context.Context, accountID int64) (*model.CircuitState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &model.CircuitState{IsCircuitBroken: f.account.IsCircuitBroken, CircuitBrokenAt: f.account.CircuitBrokenAt}, nil
+}
+
+// TestIsHalfOpen_CooldownExpires tests that a broken circuit allows a probe exactly when the cooldown ends.
+func TestIsHalfOpen_CooldownExpires(t *testing.T) {
+	repo := &cooldownCircuitBreakerRepo{fakeCircuitBreakerRepo{account: data.Account{ID: 1, HealthScore: 20}}}
+	uc := NewCircuitBreakerUsecase(repo, nopAuditLogger{}, nopWebhookService{}, log.DefaultLogger)
+	clock := newFakeClock()
+	uc.SetClock(clock)
+	ctx := context.Background()
+
+	require.NoError(t, uc.triggerCircuitBreaker(ctx, 1, 20))
+	assert.Equal(t, clock.Now(), *repo.account.CircuitBrokenAt)
+
+	halfOpen, err := uc.IsHalfOpen(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, halfOpen, "cooldown just started")
+
+	clock.Advance(5*time.Minute - time.Second)
+	halfOpen, err = uc.IsHalfOpen(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, halfOpen, "one second left in the cooldown")
+
+	clock.Advance(time.Second)
+	halfOpen, err = uc.IsHalfOpen(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, halfOpen, "cooldown expired")
+}
+
+// TestHandleRefreshFailure_BackoffUsesClock tests that retry times are computed from the injected clock.
+func TestHandleRefreshFailure_BackoffUsesClock(t *testing.T) {
+	uc, mockRepo, _ := setupRefreshFailureTest(t, 10*time.Minute)
+	clock := newFakeClock()
+	uc.SetClock(clock)
+	ctx := context.Background()
+	start := clock.Now()
+
+	require.NoError(t, uc.handleRefreshFailure(ctx, 1, errors.New("upstream 503")))
+	clock.Advance(time.Minute)
+	require.NoError(t, uc.handleRefreshFailure(ctx, 1, errors.New("upstream 503")))
+
+	attempts := nextRefreshAttempts(mockRepo)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, start.Add(5*time.Minute), attempts[0])
+	assert.Equal(t, start.Add(time.Minute+10*time.Minute), attempts[1])
+}
+
+// TestInRefreshGracePeriod_UsesClock tests that the grace window shrinks as the clock advances.
+func TestInRefreshGracePeriod_UsesClock(t *testing.T) {
+	uc, _, _ := setupTestUsecase(t)
+	clock := newFakeClock()
+	uc.SetClock(clock)
+	expiresAt := clock.Now().Add(2 * time.Hour)
+	account := &data.Account{OAuthExpiresAt: &expiresAt}
+
+	remaining, inGrace := uc.inRefreshGracePeriod(account)
+	assert.Equal(t, 2*time.Hour, remaining)
+	assert.True(t, inGrace)
+
+	clock.Advance(2*time.Hour - DefaultRefreshFailureGraceWindow)
+	remaining, inGrace = uc.inRefreshGracePeriod(account)
+	assert.Equal(t, DefaultRefreshFailureGraceWindow, remaining)
+	assert.False(t, inGrace, "grace ends once the remaining lifetime reaches the window")
+}
+
+// TestCleanupExpiredConcurrency_UsesClock tests that the expiry cutoff follows the injected clock.
+func TestCleanupExpiredConcurrency_UsesClock(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	clock := newFakeClock()
+	uc.SetClock(clock)
+	ctx := context.Background()
+
+	cutoff := clock.Now().Add(-10 * time.Minute).Unix()
+	mockRepo.On("CleanupExpiredConcurrency", ctx, int64(1), cutoff).Return(nil).Once()
+
+	require.NoError(t, uc.CleanupExpiredConcurrency(ctx, 1))
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CleanupExpiredConcurrency", mock.Anything, mock.Anything, mock.MatchedBy(func(v int64) bool { return v != cutoff }))
+}
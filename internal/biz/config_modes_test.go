@@ -0,0 +1,63 @@
+package biz
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"QuotaLane/internal/conf"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseModes_MatchConfigValidation tests that the mode names accepted by conf.Validate are exactly
+// the ones the Parse* functions accept: main relies on validation and ignores the parse errors.
+func TestParseModes_MatchConfigValidation(t *testing.T) {
+	cases := []struct {
+		field string
+		parse func(string) error
+		set   func(*conf.Bootstrap, string)
+	}{
+		{
+			field: "rate_limit.rpm_window",
+			parse: func(v string) error { _, err := ParseRPMWindowMode(v); return err },
+			set:   func(bc *conf.Bootstrap, v string) { bc.RateLimit = &conf.RateLimit{RpmWindow: v} },
+		},
+		{
+			field: "rate_limit.zero_limit",
+			parse: func(v string) error { _, err := ParseZeroLimitMode(v); return err },
+			set:   func(bc *conf.Bootstrap, v string) { bc.RateLimit = &conf.RateLimit{ZeroLimit: v} },
+		},
+		{
+			field: "auth.encryption.account_binding",
+			parse: func(v string) error { _, err := ParseCredentialBinding(v); return err },
+			set: func(bc *conf.Bootstrap, v string) {
+				bc.Auth = &conf.Auth{Encryption: &conf.Auth_Encryption{AccountBinding: v}}
+			},
+		},
+	}
+
+	values := []string{"", "fixed", "sliding", "unlimited", "blocked", "off", "bind", "require", "leaky", "Blocked"}
+	for _, tc := range cases {
+		for _, value := range values {
+			bc := &conf.Bootstrap{}
+			tc.set(bc, value)
+			parseOK := tc.parse(value) == nil
+			assert.Equal(t, parseOK, !hasConfigProblem(conf.Validate(bc), tc.field), "%s=%q", tc.field, value)
+		}
+	}
+}
+
+// hasConfigProblem reports whether err lists a problem with field.
+func hasConfigProblem(err error, field string) bool {
+	var verr *conf.ValidationError
+	if !errors.As(err, &verr) {
+		return false
+	}
+	for _, problem := range verr.Problems {
+		if strings.HasPrefix(problem, field+" ") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+const (
+	// CronPanicAlertKeyPrefix Redis 定时任务 panic 告警标记前缀（alert:cron_panic:{job}）
+	CronPanicAlertKeyPrefix = "alert:cron_panic:"
+
+	// CronPanicCountKeyPrefix Redis 中定时任务 panic 次数计数器前缀，TTL 为 AlertTTL（每次 panic 续期）
+	CronPanicCountKeyPrefix = "cron_panics:"
+)
+
+// cronPanicCounts 定时任务 panic 的进程内累计次数（按任务名）
+type cronPanicCounts struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (c *cronPanicCounts) inc(job string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int64)
+	}
+	c.counts[job]++
+}
+
+func (c *cronPanicCounts) snapshot() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.counts)
+}
+
+// RecordCronPanic 记录一次定时任务 panic：累加进程内计数（quotalane_cron_panics_total{job}），
+// 并在 Redis 中累计 AlertTTL 内的次数、设置 alert:cron_panic:{job} 告警标记，
+// 使持续 panic 的任务能被运维发现。Redis 写入失败只记录日志
+func (uc *AccountUsecase) RecordCronPanic(ctx context.Context, job string, recovered any) {
+	uc.cronPanics.inc(job)
+
+	if uc.rdb == nil {
+		return
+	}
+
+	countKey := CronPanicCountKeyPrefix + job
+	count, err := uc.rdb.Incr(ctx, countKey).Result()
+	if err != nil {
+		uc.logger.Warnf("failed to count cron panic for job %s: %v", job, err)
+		return
+	}
+	if err := uc.rdb.Expire(ctx, countKey, AlertTTL).Err(); err != nil {
+		uc.logger.Warnf("failed to set cron panic counter TTL for job %s: %v", job, err)
+	}
+
+	alertMsg := fmt.Sprintf("Cron job %s panicked %d time(s) within %s. Last panic: %v", job, count, AlertTTL, recovered)
+	if err := uc.rdb.Set(ctx, CronPanicAlertKeyPrefix+job, alertMsg, AlertTTL).Err(); err != nil {
+		uc.logger.Warnf("failed to set cron panic alert marker: %v", err)
+	}
+}
+
+// CronPanicCounts 返回本进程内各定时任务的 panic 累计次数（供 /metrics 暴露）
+func (uc *AccountUsecase) CronPanicCounts() map[string]int64 {
+	return uc.cronPanics.snapshot()
+}
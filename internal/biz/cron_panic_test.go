@@ -0,0 +1,40 @@
+package biz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordCronPanic(t *testing.T) {
+	uc, mr := setupHealthHistoryTest(t)
+	ctx := context.Background()
+
+	uc.RecordCronPanic(ctx, "auto_refresh", "nil pointer")
+	uc.RecordCronPanic(ctx, "auto_refresh", "index out of range")
+	uc.RecordCronPanic(ctx, "concurrency_cleanup", "boom")
+
+	assert.Equal(t, map[string]int64{"auto_refresh": 2, "concurrency_cleanup": 1}, uc.CronPanicCounts())
+
+	count, err := mr.Get(CronPanicCountKeyPrefix + "auto_refresh")
+	require.NoError(t, err)
+	assert.Equal(t, "2", count)
+	assert.Equal(t, AlertTTL, mr.TTL(CronPanicCountKeyPrefix+"auto_refresh"))
+
+	alert, err := mr.Get(CronPanicAlertKeyPrefix + "auto_refresh")
+	require.NoError(t, err)
+	assert.Contains(t, alert, "panicked 2 time(s)")
+	assert.Contains(t, alert, "index out of range")
+	assert.Equal(t, AlertTTL, mr.TTL(CronPanicAlertKeyPrefix+"auto_refresh"))
+}
+
+func TestRecordCronPanic_WithoutRedis(t *testing.T) {
+	uc := &AccountUsecase{logger: log.NewHelper(log.DefaultLogger)}
+
+	uc.RecordCronPanic(context.Background(), "auto_refresh", "boom")
+
+	assert.Equal(t, map[string]int64{"auto_refresh": 1}, uc.CronPanicCounts())
+}
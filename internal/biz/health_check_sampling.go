@@ -0,0 +1,40 @@
+package biz
+
+import (
+	"sort"
+
+	"QuotaLane/internal/data"
+)
+
+// SetHealthCheckSampleSize 设置每轮健康检查最多检查的账户数
+// 每轮优先检查最久未检查（last_checked_at 最早或从未检查）的账户，多轮后所有账户都会被轮换检查到；n <= 0 时每轮检查全部账户
+func (uc *AccountUsecase) SetHealthCheckSampleSize(n int) {
+	uc.healthCheckSampleSize = n
+}
+
+// selectHealthCheckSample 按 last_checked_at 升序（从未检查的优先，同时间按 ID）选出本轮需要检查的账户
+func (uc *AccountUsecase) selectHealthCheckSample(accounts []*data.Account) []*data.Account {
+	if uc.healthCheckSampleSize <= 0 || len(accounts) <= uc.healthCheckSampleSize {
+		return accounts
+	}
+
+	sorted := make([]*data.Account, len(accounts))
+	copy(sorted, accounts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].LastCheckedAt, sorted[j].LastCheckedAt
+		switch {
+		case a == nil && b == nil:
+			return sorted[i].ID < sorted[j].ID
+		case a == nil:
+			return true
+		case b == nil:
+			return false
+		case !a.Equal(*b):
+			return a.Before(*b)
+		default:
+			return sorted[i].ID < sorted[j].ID
+		}
+	})
+
+	return sorted[:uc.healthCheckSampleSize]
+}
@@ -0,0 +1,106 @@
+package biz
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/crypto"
+	pkgoauth "QuotaLane/pkg/oauth"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectHealthCheckSample(t *testing.T) {
+	uc := &AccountUsecase{}
+	old := time.Now().Add(-time.Hour)
+	recent := time.Now()
+	accounts := []*data.Account{
+		{ID: 1, LastCheckedAt: &recent},
+		{ID: 2, LastCheckedAt: &old},
+		{ID: 3},
+		{ID: 4, LastCheckedAt: &recent},
+	}
+
+	assert.Len(t, uc.selectHealthCheckSample(accounts), 4, "sampling disabled checks all accounts")
+
+	uc.SetHealthCheckSampleSize(2)
+	sample := uc.selectHealthCheckSample(accounts)
+	require.Len(t, sample, 2)
+	assert.Equal(t, int64(3), sample[0].ID, "never-checked account comes first")
+	assert.Equal(t, int64(2), sample[1].ID, "then the least recently checked")
+	assert.Equal(t, int64(1), accounts[0].ID, "input order is preserved")
+}
+
+func TestHealthCheckOpenAIResponsesAccounts_SamplingRotation(t *testing.T) {
+	const (
+		accountCount = 10
+		sampleSize   = 3
+	)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	aes, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+	apiKey, err := aes.Encrypt("sk-test")
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	accounts := make([]*data.Account, 0, accountCount)
+	byID := make(map[int64]*data.Account, accountCount)
+	mockRepo := new(MockAccountRepo)
+	for i := 0; i < accountCount; i++ {
+		acc := &data.Account{ID: int64(300 + i), Provider: data.ProviderOpenAIResponses, Status: data.StatusActive, HealthScore: 100, APIKeyEncrypted: apiKey, BaseAPI: "https://api.example.com"}
+		accounts = append(accounts, acc)
+		byID[acc.ID] = acc
+		mockRepo.On("GetAccount", mock.Anything, acc.ID).Return(acc, nil)
+	}
+	mockRepo.On("ListAccountsByProvider", mock.Anything, data.ProviderOpenAIResponses, data.StatusActive).Return(accounts, nil)
+	mockRepo.On("UpdateHealthScore", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("UpdateAccountStatus", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("UpdateAccount", mock.Anything, mock.Anything).Return(nil)
+
+	checked := make(map[int64]int)
+	var cycle time.Time
+	mockRepo.On("SetLastCheckedAt", mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		id := args.Get(1).(int64)
+		checkedAt := cycle
+		byID[id].LastCheckedAt = &checkedAt
+		checked[id]++
+	})
+
+	tracker := &concurrencyTracker{}
+	manager := pkgoauth.NewOAuthManager(rdb, log.DefaultLogger)
+	manager.RegisterProvider(&trackingProvider{providerType: data.ProviderOpenAIResponses, tracker: tracker})
+
+	uc := NewAccountUsecase(mockRepo, aes, nil, nil, manager, nil, nil, nil, rdb, log.DefaultLogger)
+	uc.SetHealthCheckSampleSize(sampleSize)
+
+	ctx := context.Background()
+	cycles := (accountCount + sampleSize - 1) / sampleSize
+	for i := 0; i < cycles; i++ {
+		mu.Lock()
+		cycle = time.Unix(int64(1000+i), 0)
+		mu.Unlock()
+
+		before := tracker.calls
+		require.NoError(t, uc.HealthCheckOpenAIResponsesAccounts(ctx))
+		assert.LessOrEqual(t, tracker.calls-before, sampleSize, "cycle %d exceeded sample size", i)
+	}
+
+	assert.Len(t, checked, accountCount, "every account is checked within ceil(N/sample) cycles")
+	for id, n := range checked {
+		assert.LessOrEqual(t, n, 2, "account %d checked again before others caught up", id)
+	}
+}
@@ -0,0 +1,180 @@
+package biz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"QuotaLane/pkg/crypto"
+)
+
+// ErrInvalidOAuthData OAuth 数据格式错误（所有 OAuthDataError 均可通过 errors.Is 匹配）
+var ErrInvalidOAuthData = errors.New("invalid OAuth data")
+
+// OAuthDataError 描述 OAuth 数据中缺失或类型错误的字段
+type OAuthDataError struct {
+	Field  string // JSON 字段名，整体格式错误时为空
+	Reason string // 错误原因，如 "not found"、"has invalid type"
+	Err    error  // 底层错误（可选）
+}
+
+// Error implements the error interface.
+func (e *OAuthDataError) Error() string {
+	msg := fmt.Sprintf("%s: %s", ErrInvalidOAuthData.Error(), e.Reason)
+	if e.Field != "" {
+		msg = fmt.Sprintf("%s %s in OAuth data", e.Field, e.Reason)
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+// Is 使 errors.Is(err, ErrInvalidOAuthData) 成立
+func (e *OAuthDataError) Is(target error) bool {
+	return target == ErrInvalidOAuthData
+}
+
+// Unwrap 返回底层错误
+func (e *OAuthDataError) Unwrap() error {
+	return e.Err
+}
+
+// StoredOAuthData 账户 oauth_data_encrypted 字段解密后的结构
+// access_token / refresh_token 在结构内部再次单独加密存储
+// 兼容历史格式 {access_token, refresh_token, expires_at}（Token 为明文）：解析后通过 EncryptLegacyTokens
+// 转换为加密字段，下一次成功刷新时以新格式保存
+type StoredOAuthData struct {
+	AccessTokenEncrypted  string                   `json:"access_token_encrypted"`
+	RefreshTokenEncrypted string                   `json:"refresh_token_encrypted"`
+	IDToken               string                   `json:"id_token,omitempty"`
+	Scopes                []string                 `json:"scopes,omitempty"`
+	Organizations         []map[string]interface{} `json:"organizations,omitempty"`
+	AccountID             string                   `json:"account_id,omitempty"` // Codex CLI ChatGPT Account ID
+	ExpiresAt             time.Time                `json:"expires_at"`
+
+	legacyAccessToken  string // 历史格式中的明文 access_token（不会再序列化）
+	legacyRefreshToken string // 历史格式中的明文 refresh_token（不会再序列化）
+}
+
+// storedOAuthDataJSON 序列化格式（expires_at 使用 RFC3339 字符串，兼容历史数据）
+type storedOAuthDataJSON struct {
+	AccessTokenEncrypted  string                   `json:"access_token_encrypted"`
+	RefreshTokenEncrypted string                   `json:"refresh_token_encrypted"`
+	IDToken               string                   `json:"id_token,omitempty"`
+	Scopes                []string                 `json:"scopes,omitempty"`
+	Organizations         []map[string]interface{} `json:"organizations,omitempty"`
+	AccountID             string                   `json:"account_id,omitempty"`
+	ExpiresAt             string                   `json:"expires_at"`
+
+	// 历史格式字段（只读不写）
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// MarshalJSON 将 expires_at 序列化为 RFC3339 字符串
+func (d StoredOAuthData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(storedOAuthDataJSON{
+		AccessTokenEncrypted:  d.AccessTokenEncrypted,
+		RefreshTokenEncrypted: d.RefreshTokenEncrypted,
+		IDToken:               d.IDToken,
+		Scopes:                d.Scopes,
+		Organizations:         d.Organizations,
+		AccountID:             d.AccountID,
+		ExpiresAt:             d.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+// UnmarshalJSON 解析 OAuth 数据，字段类型错误时返回 *OAuthDataError
+func (d *StoredOAuthData) UnmarshalJSON(b []byte) error {
+	var raw storedOAuthDataJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return &OAuthDataError{
+				Field:  typeErr.Field,
+				Reason: fmt.Sprintf("has invalid type (expected %s, got %s)", typeErr.Type, typeErr.Value),
+			}
+		}
+		return &OAuthDataError{Reason: "malformed JSON", Err: err}
+	}
+
+	var expiresAt time.Time
+	if raw.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, raw.ExpiresAt)
+		if err != nil {
+			return &OAuthDataError{Field: "expires_at", Reason: "is not a valid RFC3339 timestamp", Err: err}
+		}
+		expiresAt = t
+	}
+
+	*d = StoredOAuthData{
+		AccessTokenEncrypted:  raw.AccessTokenEncrypted,
+		RefreshTokenEncrypted: raw.RefreshTokenEncrypted,
+		IDToken:               raw.IDToken,
+		Scopes:                raw.Scopes,
+		Organizations:         raw.Organizations,
+		AccountID:             raw.AccountID,
+		ExpiresAt:             expiresAt,
+		legacyAccessToken:     raw.AccessToken,
+		legacyRefreshToken:    raw.RefreshToken,
+	}
+	return nil
+}
+
+// Validate 校验必填字段（历史格式的明文 Token 可替代对应的加密字段）
+func (d *StoredOAuthData) Validate() error {
+	if d.AccessTokenEncrypted == "" && d.legacyAccessToken == "" {
+		return &OAuthDataError{Field: "access_token_encrypted", Reason: "not found"}
+	}
+	if d.RefreshTokenEncrypted == "" && d.legacyRefreshToken == "" {
+		return &OAuthDataError{Field: "refresh_token_encrypted", Reason: "not found"}
+	}
+	if d.ExpiresAt.IsZero() {
+		return &OAuthDataError{Field: "expires_at", Reason: "not found"}
+	}
+	return nil
+}
+
+// IsLegacy 判断数据是否来自历史格式（Token 以明文保存，尚未转换为加密字段）
+func (d *StoredOAuthData) IsLegacy() bool {
+	return d.legacyAccessToken != "" || d.legacyRefreshToken != ""
+}
+
+// EncryptLegacyTokens 将历史格式中的明文 Token 加密写入对应的加密字段，之后序列化即为新格式
+// 非历史格式的数据不做任何修改
+func (d *StoredOAuthData) EncryptLegacyTokens(c *crypto.AESCrypto) error {
+	if d.AccessTokenEncrypted == "" && d.legacyAccessToken != "" {
+		encrypted, err := c.Encrypt(d.legacyAccessToken)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt legacy access token: %w", err)
+		}
+		d.AccessTokenEncrypted = encrypted
+	}
+	if d.RefreshTokenEncrypted == "" && d.legacyRefreshToken != "" {
+		encrypted, err := c.Encrypt(d.legacyRefreshToken)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt legacy refresh token: %w", err)
+		}
+		d.RefreshTokenEncrypted = encrypted
+	}
+	d.legacyAccessToken, d.legacyRefreshToken = "", ""
+	return nil
+}
+
+// ParseStoredOAuthData 解析并校验解密后的 OAuth 数据 JSON
+func ParseStoredOAuthData(raw string) (*StoredOAuthData, error) {
+	var d StoredOAuthData
+	if err := json.Unmarshal([]byte(raw), &d); err != nil {
+		var dataErr *OAuthDataError
+		if errors.As(err, &dataErr) {
+			return nil, dataErr
+		}
+		return nil, &OAuthDataError{Reason: "malformed JSON", Err: err}
+	}
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,138 @@
+package biz
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"QuotaLane/pkg/crypto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStoredOAuthData(t *testing.T) {
+	expiresAt := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+
+	t.Run("Valid blob round trip", func(t *testing.T) {
+		original := StoredOAuthData{
+			AccessTokenEncrypted:  "enc-access",
+			RefreshTokenEncrypted: "enc-refresh",
+			IDToken:               "id-token",
+			Scopes:                []string{"openid"},
+			AccountID:             "acc-123",
+			ExpiresAt:             expiresAt,
+		}
+		raw, err := json.Marshal(original)
+		require.NoError(t, err)
+
+		// expires_at 保持 RFC3339 字符串格式，兼容历史数据
+		var generic map[string]interface{}
+		require.NoError(t, json.Unmarshal(raw, &generic))
+		assert.Equal(t, expiresAt.Format(time.RFC3339), generic["expires_at"])
+
+		parsed, err := ParseStoredOAuthData(string(raw))
+		require.NoError(t, err)
+		assert.Equal(t, original.AccessTokenEncrypted, parsed.AccessTokenEncrypted)
+		assert.Equal(t, original.RefreshTokenEncrypted, parsed.RefreshTokenEncrypted)
+		assert.Equal(t, original.IDToken, parsed.IDToken)
+		assert.Equal(t, original.Scopes, parsed.Scopes)
+		assert.Equal(t, original.AccountID, parsed.AccountID)
+		assert.True(t, expiresAt.Equal(parsed.ExpiresAt))
+	})
+
+	t.Run("Legacy blob with null organizations", func(t *testing.T) {
+		raw := `{"access_token_encrypted":"a","refresh_token_encrypted":"r","organizations":null,"account_id":"","expires_at":"` +
+			expiresAt.Format(time.RFC3339) + `"}`
+
+		parsed, err := ParseStoredOAuthData(raw)
+		require.NoError(t, err)
+		assert.Nil(t, parsed.Organizations)
+	})
+
+	t.Run("Legacy plain token blob", func(t *testing.T) {
+		raw := `{"access_token":"plain-access","refresh_token":"plain-refresh","expires_at":"` +
+			expiresAt.Format(time.RFC3339Nano) + `"}`
+
+		parsed, err := ParseStoredOAuthData(raw)
+		require.NoError(t, err)
+		assert.True(t, parsed.IsLegacy())
+		assert.True(t, expiresAt.Equal(parsed.ExpiresAt))
+
+		cryptoHelper, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+		require.NoError(t, err)
+		require.NoError(t, parsed.EncryptLegacyTokens(cryptoHelper))
+		assert.False(t, parsed.IsLegacy())
+		refreshToken, err := cryptoHelper.Decrypt(parsed.RefreshTokenEncrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "plain-refresh", refreshToken)
+
+		resaved, err := json.Marshal(parsed)
+		require.NoError(t, err)
+		assert.NotContains(t, string(resaved), "plain-")
+	})
+
+	tests := []struct {
+		name          string
+		raw           string
+		expectedField string
+		expectedMsg   string
+	}{
+		{
+			name:        "Not JSON",
+			raw:         "not a json",
+			expectedMsg: "malformed JSON",
+		},
+		{
+			name:          "Missing access token",
+			raw:           `{"refresh_token_encrypted":"r","expires_at":"2030-01-01T00:00:00Z"}`,
+			expectedField: "access_token_encrypted",
+			expectedMsg:   "access_token_encrypted not found",
+		},
+		{
+			name:          "Missing refresh token",
+			raw:           `{"access_token_encrypted":"a","expires_at":"2030-01-01T00:00:00Z"}`,
+			expectedField: "refresh_token_encrypted",
+			expectedMsg:   "refresh_token_encrypted not found",
+		},
+		{
+			name:          "Missing expires_at",
+			raw:           `{"access_token_encrypted":"a","refresh_token_encrypted":"r"}`,
+			expectedField: "expires_at",
+			expectedMsg:   "expires_at not found",
+		},
+		{
+			name:          "Mistyped refresh token",
+			raw:           `{"access_token_encrypted":"a","refresh_token_encrypted":123,"expires_at":"2030-01-01T00:00:00Z"}`,
+			expectedField: "refresh_token_encrypted",
+			expectedMsg:   "has invalid type",
+		},
+		{
+			name:          "Mistyped scopes",
+			raw:           `{"access_token_encrypted":"a","refresh_token_encrypted":"r","scopes":"openid","expires_at":"2030-01-01T00:00:00Z"}`,
+			expectedField: "scopes",
+			expectedMsg:   "has invalid type",
+		},
+		{
+			name:          "Invalid expires_at format",
+			raw:           `{"access_token_encrypted":"a","refresh_token_encrypted":"r","expires_at":"tomorrow"}`,
+			expectedField: "expires_at",
+			expectedMsg:   "not a valid RFC3339 timestamp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseStoredOAuthData(tt.raw)
+			require.Error(t, err)
+			assert.Nil(t, parsed)
+
+			var dataErr *OAuthDataError
+			require.True(t, errors.As(err, &dataErr), "error should be *OAuthDataError")
+			assert.Equal(t, tt.expectedField, dataErr.Field)
+			assert.Contains(t, err.Error(), tt.expectedMsg)
+			assert.True(t, errors.Is(err, ErrInvalidOAuthData))
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+// DecryptAlertKeyPrefix Redis 凭证解密失败告警标记前缀
+const DecryptAlertKeyPrefix = "alert:decrypt:"
+
+// errCredentialDecrypt 标记刷新失败是由存储的凭证无法解密（密钥错误或密文损坏）引起，重试无法恢复
+var errCredentialDecrypt = errors.New("stored credentials cannot be decrypted")
+
+// flagDecryptError 将凭证无法解密的账户标记为 decrypt_error（置为 error 状态，后续刷新扫描不再选中）
+// 并设置告警标记，供人工排查密钥或修复凭证；标记失败只记录日志，不影响同批次其他账户
+func flagDecryptError(ctx context.Context, repo AccountRepo, rdb redis.UniversalClient, logger *log.Helper, account *data.Account, decryptErr error) {
+	if err := repo.MarkDecryptError(ctx, account.ID, decryptErr.Error()); err != nil {
+		logger.Warnf("failed to mark account %d with decrypt error: %v", account.ID, err)
+	}
+
+	logger.Errorw("account credentials cannot be decrypted, excluded from refresh until repaired",
+		"account_id", account.ID,
+		"account_name", account.Name,
+		"provider", account.Provider,
+		"reason", data.DecryptErrorReason,
+		"error", decryptErr)
+
+	if rdb == nil {
+		return
+	}
+	alertKey := fmt.Sprintf("%s%d", DecryptAlertKeyPrefix, account.ID)
+	alertMsg := fmt.Sprintf("Account %d (%s) credentials cannot be decrypted (%s), excluded from refresh: %v",
+		account.ID, account.Name, data.DecryptErrorReason, decryptErr)
+	if err := rdb.Set(ctx, alertKey, alertMsg, AlertTTL).Err(); err != nil {
+		logger.Warnf("failed to set decrypt alert marker: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package biz
+
+import (
+	"context"
+	"testing"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/crypto"
+	"QuotaLane/pkg/oauth"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRefreshExpiringTokens_CorruptAccount tests that one account whose OAuth data cannot be
+// decrypted is flagged with decrypt_error and alerted on, while the rest of the batch is refreshed.
+func TestRefreshExpiringTokens_CorruptAccount(t *testing.T) {
+	cryptoHelper, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+	otherKey, err := crypto.NewAESCrypto([]byte("abcdefghijklmnopqrstuvwxyz012345"))
+	require.NoError(t, err)
+
+	oauthManager := oauth.NewOAuthManager(nil, log.DefaultLogger)
+	oauthManager.RegisterProvider(&mockOAuthProvider{tokenResp: &oauth.ExtendedTokenResponse{
+		AccessToken:  "new-access",
+		RefreshToken: "new-refresh",
+		ExpiresIn:    28800,
+	}})
+
+	// Account 2 was encrypted with a different key, as after a botched key rotation
+	corrupt := expiringOAuthAccount(t, otherKey, 2)
+	accounts := []*data.Account{
+		expiringOAuthAccount(t, cryptoHelper, 1),
+		corrupt,
+		expiringOAuthAccount(t, cryptoHelper, 3),
+	}
+
+	_, mr, rdb := newTestRefreshGuard(t)
+	mockRepo := new(MockAccountRepo)
+	mockRepo.On("ListExpiringAccounts", mock.Anything, mock.Anything).Return(accounts, nil)
+	mockRepo.On("ListCodexCLIAccountsNeedingRefresh", mock.Anything, ExpiringRefreshThreshold).Return([]*data.Account{}, nil)
+	mockRepo.On("UpdateOAuthData", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(nil).Once()
+	mockRepo.On("UpdateOAuthData", mock.Anything, int64(3), mock.Anything, mock.Anything).Return(nil).Once()
+	mockRepo.On("MarkDecryptError", mock.Anything, int64(2), mock.MatchedBy(func(detail string) bool {
+		return assert.Contains(t, detail, "failed to decrypt OAuth data")
+	})).Return(nil).Once()
+
+	task := NewOAuthRefreshTask(mockRepo, oauthManager, cryptoHelper, rdb, log.DefaultLogger)
+	require.NoError(t, task.RefreshExpiringTokens(context.Background()))
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "UpdateOAuthData", mock.Anything, int64(2), mock.Anything, mock.Anything)
+	assert.True(t, mr.Exists(DecryptAlertKeyPrefix+"2"), "decrypt failure raises an alert")
+	assert.False(t, mr.Exists(DecryptAlertKeyPrefix+"1"))
+}
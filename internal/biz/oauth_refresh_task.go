@@ -3,22 +3,37 @@ package biz
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"QuotaLane/internal/data"
 	"QuotaLane/pkg/crypto"
 	"QuotaLane/pkg/oauth"
+	"QuotaLane/pkg/openai"
 
 	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
 )
 
 // OAuthRefreshTask Token 自动刷新任务
+// 所有 OAuth 账户 Token 刷新的唯一权威任务；AutoRefreshTokens 仅作兜底，两者通过 RefreshGuard 去重
 type OAuthRefreshTask struct {
 	repo         AccountRepo
 	oauthManager *oauth.OAuthManager
 	crypto       *crypto.AESCrypto
+	rdb          redis.UniversalClient // 告警标记存储（nil 表示不设置告警标记）
 	logger       *log.Helper
+	clock        Clock // 时间来源（测试中可替换为假时钟）
+
+	limiter     *ProviderCallLimiter // 后台任务共享的 Provider 并发限制（nil 表示不限制）
+	refreshRuns RefreshRunRepo       // 批量刷新运行记录（nil 表示不记录）
+	timeout     time.Duration        // 单个账户刷新的超时时间（0 表示使用默认值）
+	threshold   time.Duration        // 选取 Token 在该时间内过期的账户（0 表示使用默认值）
+	guard       *RefreshGuard        // 与 AutoRefreshTokens 共享的单账户刷新去重（nil 表示不去重）
+
+	markNeedsReauth   bool              // refresh token 永久失效时标记账户需要重新授权
+	credentialBinding CredentialBinding // 凭证密文是否绑定账户 ID（空值等同 off）
 }
 
 // NewOAuthRefreshTask 创建 Token 刷新任务
@@ -26,40 +41,92 @@ func NewOAuthRefreshTask(
 	repo AccountRepo,
 	oauthManager *oauth.OAuthManager,
 	crypto *crypto.AESCrypto,
+	rdb redis.UniversalClient,
 	logger log.Logger,
 ) *OAuthRefreshTask {
 	return &OAuthRefreshTask{
 		repo:         repo,
 		oauthManager: oauthManager,
 		crypto:       crypto,
+		rdb:          rdb,
 		logger:       log.NewHelper(logger),
+		clock:        SystemClock,
+
+		markNeedsReauth: true,
 	}
 }
 
+// SetProviderCallLimiter 设置与其他后台任务共享的 Provider 并发限制器
+func (t *OAuthRefreshTask) SetProviderCallLimiter(limiter *ProviderCallLimiter) {
+	t.limiter = limiter
+}
+
+// SetRefreshAccountTimeout 设置单个账户刷新的超时时间；d <= 0 时恢复默认值
+func (t *OAuthRefreshTask) SetRefreshAccountTimeout(d time.Duration) {
+	t.timeout = d
+}
+
+// SetExpiringRefreshThreshold 设置刷新窗口，选取 Token 在该时间内过期的账户；d <= 0 时恢复默认值
+func (t *OAuthRefreshTask) SetExpiringRefreshThreshold(d time.Duration) {
+	t.threshold = d
+}
+
 // RefreshExpiringTokens 刷新即将过期的 Token
-// 执行策略：每 6 小时运行一次，刷新 2 小时内过期的 Token
+// 执行策略：每 6 小时运行一次，刷新刷新窗口（默认 2 小时）内过期的 Token
 // 优化说明：避免频繁刷新短期 token（如 Claude 8h），只在真正快过期时刷新
 func (t *OAuthRefreshTask) RefreshExpiringTokens(ctx context.Context) error {
-	// 查询 2 小时内过期的账户（优化：从 24h 改为 2h）
-	expiryThreshold := time.Now().Add(2 * time.Hour)
+	startTime := time.Now()
+	threshold := expiringRefreshThreshold(t.threshold)
+
+	// 查询刷新窗口内过期的账户
+	expiryThreshold := t.now().Add(threshold)
 	accounts, err := t.repo.ListExpiringAccounts(ctx, expiryThreshold)
 	if err != nil {
 		return fmt.Errorf("failed to list expiring accounts: %w", err)
 	}
 
+	// Codex CLI 账户按 token_expires_at 选取，使用与 Claude 相同的刷新窗口
+	codexAccounts, err := t.repo.ListCodexCLIAccountsNeedingRefresh(ctx, threshold)
+	if err != nil {
+		return fmt.Errorf("failed to list Codex CLI accounts needing refresh: %w", err)
+	}
+	accounts = appendUniqueAccounts(accounts, codexAccounts)
+
 	if len(accounts) == 0 {
 		t.logger.Info("No accounts need token refresh")
+		recordRefreshRun(ctx, t.refreshRuns, t.logger, RefreshRunJobExpiring, startTime, 0, 0, 0)
 		return nil
 	}
 
-	t.logger.Infof("Found %d accounts with tokens expiring within 2 hours", len(accounts))
+	t.logger.Infof("Found %d accounts with tokens expiring within %s", len(accounts), threshold)
 
 	// 刷新每个账户的 Token
-	successCount := 0
-	errorCount := 0
+	var successCount, errorCount int32
 
 	for _, account := range accounts {
-		if err := t.refreshAccountToken(ctx, account); err != nil {
+		// 窗口内已被兜底任务刷新过的账户直接跳过
+		if !t.guard.TryClaim(ctx, account.ID, RefreshRunJobExpiring) {
+			continue
+		}
+		if err := t.limiter.Acquire(ctx); err != nil {
+			t.guard.Release(ctx, account.ID)
+			recordRefreshRun(ctx, t.refreshRuns, t.logger, RefreshRunJobExpiring, startTime, int32(len(accounts)), successCount, errorCount)
+			return fmt.Errorf("failed to acquire provider slot: %w", err)
+		}
+		// 每个账户独立超时，避免单个挂起的调用耗尽整批刷新的时间
+		accountCtx, cancel := context.WithTimeout(ctx, refreshAccountTimeout(t.timeout))
+		err := t.refreshAccountToken(accountCtx, account)
+		cancel()
+		t.limiter.Release()
+
+		if err != nil {
+			t.guard.Release(ctx, account.ID)
+			// 凭证无法解密：标记账户并告警，其余账户继续刷新
+			if errors.Is(err, errCredentialDecrypt) {
+				flagDecryptError(ctx, t.repo, t.rdb, t.logger, account, err)
+				errorCount++
+				continue
+			}
 			t.logger.Errorw("failed to refresh account token",
 				"account_id", account.ID,
 				"account_name", account.Name,
@@ -76,32 +143,48 @@ func (t *OAuthRefreshTask) RefreshExpiringTokens(ctx context.Context) error {
 		"success", successCount,
 		"error", errorCount)
 
+	recordRefreshRun(ctx, t.refreshRuns, t.logger, RefreshRunJobExpiring, startTime, int32(len(accounts)), successCount, errorCount)
+
 	return nil
 }
 
+// appendUniqueAccounts 将 extra 中尚未出现在 accounts 中的账户追加到末尾（按 ID 去重）
+func appendUniqueAccounts(accounts, extra []*data.Account) []*data.Account {
+	seen := make(map[int64]struct{}, len(accounts))
+	for _, account := range accounts {
+		seen[account.ID] = struct{}{}
+	}
+	for _, account := range extra {
+		if _, ok := seen[account.ID]; ok {
+			continue
+		}
+		seen[account.ID] = struct{}{}
+		accounts = append(accounts, account)
+	}
+	return accounts
+}
+
 // refreshAccountToken 刷新单个账户的 Token
 func (t *OAuthRefreshTask) refreshAccountToken(ctx context.Context, account *data.Account) error {
 	// 解密 OAuth 数据
-	oauthDataJSON, err := t.crypto.Decrypt(account.OAuthDataEncrypted)
+	oauthDataJSON, err := decryptAccountCredential(t.crypto, t.credentialBinding, account.ID, account.OAuthDataEncrypted)
 	if err != nil {
-		return fmt.Errorf("failed to decrypt OAuth data: %w", err)
+		return fmt.Errorf("%w: failed to decrypt OAuth data: %v", errCredentialDecrypt, err)
 	}
 
-	var oauthData map[string]interface{}
-	if err := json.Unmarshal([]byte(oauthDataJSON), &oauthData); err != nil {
-		return fmt.Errorf("failed to unmarshal OAuth data: %w", err)
+	oauthData, err := ParseStoredOAuthData(oauthDataJSON)
+	if err != nil {
+		return fmt.Errorf("failed to parse OAuth data: %w", err)
 	}
-
-	// 提取 refresh_token_encrypted
-	refreshTokenEncrypted, ok := oauthData["refresh_token_encrypted"].(string)
-	if !ok || refreshTokenEncrypted == "" {
-		return fmt.Errorf("refresh_token_encrypted not found in OAuth data")
+	// 历史格式（明文 Token）先转换为加密字段，刷新成功后以新格式保存
+	if err := oauthData.EncryptLegacyTokens(t.crypto); err != nil {
+		return err
 	}
 
 	// 解密 refresh_token
-	refreshToken, err := t.crypto.Decrypt(refreshTokenEncrypted)
+	refreshToken, err := t.crypto.Decrypt(oauthData.RefreshTokenEncrypted)
 	if err != nil {
-		return fmt.Errorf("failed to decrypt refresh token: %w", err)
+		return fmt.Errorf("%w: failed to decrypt refresh token: %v", errCredentialDecrypt, err)
 	}
 
 	// 构建 AccountMetadata（从 account.Metadata 中提取代理配置）
@@ -115,9 +198,16 @@ func (t *OAuthRefreshTask) refreshAccountToken(ctx context.Context, account *dat
 		}
 	}
 
-	// 调用 OAuthManager 刷新 Token
+	// 调用 OAuthManager 刷新 Token，结果与其他刷新路径一样计入健康历史
+	startedAt := time.Now()
 	tokenResp, err := t.oauthManager.RefreshToken(ctx, account.Provider, refreshToken, metadata)
+	bookkeepingCtx := context.WithoutCancel(ctx)
+	recordHealthHistory(bookkeepingCtx, t.rdb, t.logger, account.ID, HealthHistorySourceRefresh, startedAt, err)
 	if err != nil {
+		// refresh token 永久失效：标记需要重新授权，后续扫描不再选中该账户
+		if t.markNeedsReauth && openai.IsInvalidGrant(err) {
+			markNeedsReauth(bookkeepingCtx, t.repo, t.logger, account.ID, err)
+		}
 		return fmt.Errorf("failed to refresh token: %w", err)
 	}
 
@@ -133,21 +223,21 @@ func (t *OAuthRefreshTask) refreshAccountToken(ctx context.Context, account *dat
 	}
 
 	// 更新 OAuth 数据
-	oauthData["access_token_encrypted"] = newAccessTokenEncrypted
-	oauthData["refresh_token_encrypted"] = newRefreshTokenEncrypted
+	oauthData.AccessTokenEncrypted = newAccessTokenEncrypted
+	oauthData.RefreshTokenEncrypted = newRefreshTokenEncrypted
 
 	// 更新过期时间
-	newExpiresAt := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
-	oauthData["expires_at"] = newExpiresAt.Format(time.RFC3339)
+	newExpiresAt := t.now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	oauthData.ExpiresAt = newExpiresAt
 
 	// 如果有新的 ID Token，更新它
 	if tokenResp.IDToken != "" {
-		oauthData["id_token"] = tokenResp.IDToken
+		oauthData.IDToken = tokenResp.IDToken
 	}
 
 	// 如果有新的 Scopes，更新它们
 	if len(tokenResp.Scopes) > 0 {
-		oauthData["scopes"] = tokenResp.Scopes
+		oauthData.Scopes = tokenResp.Scopes
 	}
 
 	// 序列化更新后的 OAuth 数据
@@ -157,7 +247,7 @@ func (t *OAuthRefreshTask) refreshAccountToken(ctx context.Context, account *dat
 	}
 
 	// 加密整个 OAuth 数据
-	updatedOAuthDataEncrypted, err := t.crypto.Encrypt(string(updatedOAuthDataJSON))
+	updatedOAuthDataEncrypted, err := encryptAccountCredential(t.crypto, t.credentialBinding, account.ID, string(updatedOAuthDataJSON))
 	if err != nil {
 		return fmt.Errorf("failed to encrypt updated OAuth data: %w", err)
 	}
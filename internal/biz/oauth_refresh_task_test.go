@@ -3,6 +3,7 @@ package biz
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -10,9 +11,11 @@ import (
 	"QuotaLane/pkg/crypto"
 	"QuotaLane/pkg/oauth"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -58,7 +61,7 @@ func setupTestRefreshTask(t *testing.T) (*OAuthRefreshTask, *mockAccountRepo, *c
 	repo := &mockAccountRepo{}
 
 	// Create task
-	task := NewOAuthRefreshTask(repo, oauthManager, cryptoHelper, logger)
+	task := NewOAuthRefreshTask(repo, oauthManager, cryptoHelper, rdb, logger)
 
 	return task, repo, cryptoHelper
 }
@@ -289,6 +292,29 @@ func TestOAuthRefreshTask_RefreshAccountToken(t *testing.T) {
 		err := task.refreshAccountToken(ctx, account)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "refresh_token_encrypted not found")
+
+		var dataErr *OAuthDataError
+		require.True(t, errors.As(err, &dataErr))
+		assert.Equal(t, "refresh_token_encrypted", dataErr.Field)
+	})
+
+	t.Run("Mistyped expires_at", func(t *testing.T) {
+		oauthDataJSON := `{"access_token_encrypted":"a","refresh_token_encrypted":"r","expires_at":1700000000}`
+		oauthDataEncrypted, _ := cryptoHelper.Encrypt(oauthDataJSON)
+
+		account := &data.Account{
+			ID:                 889,
+			Provider:           data.ProviderClaudeOfficial,
+			OAuthDataEncrypted: oauthDataEncrypted,
+		}
+
+		err := task.refreshAccountToken(ctx, account)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrInvalidOAuthData))
+
+		var dataErr *OAuthDataError
+		require.True(t, errors.As(err, &dataErr))
+		assert.Equal(t, "expires_at", dataErr.Field)
 	})
 
 	t.Run("Decryption failure", func(t *testing.T) {
@@ -372,7 +398,7 @@ func TestNewOAuthRefreshTask(t *testing.T) {
 		oauthManager := oauth.NewOAuthManager(rdb, logger)
 		cryptoHelper, _ := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
 
-		task := NewOAuthRefreshTask(repo, oauthManager, cryptoHelper, logger)
+		task := NewOAuthRefreshTask(repo, oauthManager, cryptoHelper, nil, logger)
 
 		assert.NotNil(t, task)
 		assert.NotNil(t, task.repo)
@@ -411,3 +437,89 @@ func BenchmarkOAuthRefreshTask_RefreshAccountToken(b *testing.B) {
 		_ = task.refreshAccountToken(ctx, account)
 	}
 }
+
+// codexOAuthProvider is a mockOAuthProvider registered for Codex CLI accounts.
+type codexOAuthProvider struct{ *mockOAuthProvider }
+
+func (codexOAuthProvider) ProviderType() data.AccountProvider { return data.ProviderCodexCLI }
+
+// TestOAuthRefreshTask_RefreshesCodexWithSameWindow tests that the unified task lists Codex CLI
+// accounts with its own refresh window and refreshes each account once.
+func TestOAuthRefreshTask_RefreshesCodexWithSameWindow(t *testing.T) {
+	cryptoHelper, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+
+	oauthManager := oauth.NewOAuthManager(nil, log.DefaultLogger)
+	tokenResp := &oauth.ExtendedTokenResponse{AccessToken: "new-access", RefreshToken: "new-refresh", ExpiresIn: 3600}
+	oauthManager.RegisterProvider(&mockOAuthProvider{tokenResp: tokenResp})
+	oauthManager.RegisterProvider(codexOAuthProvider{&mockOAuthProvider{tokenResp: tokenResp}})
+
+	claude := expiringOAuthAccount(t, cryptoHelper, 1)
+	codex := expiringOAuthAccount(t, cryptoHelper, 2)
+	codex.Provider = data.ProviderCodexCLI
+
+	mockRepo := new(MockAccountRepo)
+	mockRepo.On("ListExpiringAccounts", mock.Anything, mock.Anything).Return([]*data.Account{claude}, nil)
+	mockRepo.On("ListCodexCLIAccountsNeedingRefresh", mock.Anything, ExpiringRefreshThreshold).Return([]*data.Account{codex, claude}, nil)
+	mockRepo.On("UpdateOAuthData", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(nil).Once()
+	mockRepo.On("UpdateOAuthData", mock.Anything, int64(2), mock.Anything, mock.Anything).Return(nil).Once()
+
+	task := NewOAuthRefreshTask(mockRepo, oauthManager, cryptoHelper, nil, log.DefaultLogger)
+	require.NoError(t, task.RefreshExpiringTokens(context.Background()))
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNumberOfCalls(t, "UpdateOAuthData", 2)
+}
+
+// TestOAuthRefreshTask_ConfiguredThreshold tests that a configured refresh window selects
+// Claude and Codex CLI accounts with the same window.
+func TestOAuthRefreshTask_ConfiguredThreshold(t *testing.T) {
+	clock := newFakeClock()
+	mockRepo := new(MockAccountRepo)
+	mockRepo.On("ListExpiringAccounts", mock.Anything, clock.Now().Add(4*time.Hour)).Return([]*data.Account{}, nil)
+	mockRepo.On("ListCodexCLIAccountsNeedingRefresh", mock.Anything, 4*time.Hour).Return([]*data.Account{}, nil)
+
+	task := NewOAuthRefreshTask(mockRepo, oauth.NewOAuthManager(nil, log.DefaultLogger), nil, nil, log.DefaultLogger)
+	task.SetClock(clock)
+	task.SetExpiringRefreshThreshold(4 * time.Hour)
+	require.NoError(t, task.RefreshExpiringTokens(context.Background()))
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestOAuthRefreshTask_RecordsHealthHistory tests that the unified task records every refresh
+// attempt in the account's health history, like the other refresh paths.
+func TestOAuthRefreshTask_RecordsHealthHistory(t *testing.T) {
+	cryptoHelper, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	oauthManager := oauth.NewOAuthManager(nil, log.DefaultLogger)
+	provider := &mockOAuthProvider{tokenResp: &oauth.ExtendedTokenResponse{AccessToken: "new-access", RefreshToken: "new-refresh", ExpiresIn: 3600}}
+	oauthManager.RegisterProvider(provider)
+
+	mockRepo := new(MockAccountRepo)
+	mockRepo.On("UpdateOAuthData", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(nil)
+
+	task := NewOAuthRefreshTask(mockRepo, oauthManager, cryptoHelper, rdb, log.DefaultLogger)
+	ctx := context.Background()
+	require.NoError(t, task.refreshAccountToken(ctx, expiringOAuthAccount(t, cryptoHelper, 1)))
+
+	provider.err = errors.New("upstream unavailable")
+	require.Error(t, task.refreshAccountToken(ctx, expiringOAuthAccount(t, cryptoHelper, 1)))
+
+	values, err := rdb.LRange(ctx, HealthHistoryKeyPrefix+"1", 0, -1).Result()
+	require.NoError(t, err)
+	require.Len(t, values, 2)
+
+	var latest, first HealthHistoryEntry
+	require.NoError(t, json.Unmarshal([]byte(values[0]), &latest))
+	require.NoError(t, json.Unmarshal([]byte(values[1]), &first))
+	assert.Equal(t, HealthHistorySourceRefresh, first.Source)
+	assert.True(t, first.Success)
+	assert.Equal(t, HealthHistorySourceRefresh, latest.Source)
+	assert.False(t, latest.Success)
+	assert.Contains(t, latest.Error, "upstream unavailable")
+}
@@ -0,0 +1,79 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pkglog "QuotaLane/pkg/log"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+const (
+	// OAuthSessionRateKeyPrefix Redis 中按调用方统计 OAuth Session 创建次数的 key 前缀
+	OAuthSessionRateKeyPrefix = "oauth_session_rate:"
+
+	// DefaultOAuthSessionWindow 默认统计窗口
+	DefaultOAuthSessionWindow = time.Minute
+)
+
+// SetOAuthSessionLimit 设置每个调用方在窗口内最多创建的 OAuth Session 数
+// limit <= 0 表示不限制；window <= 0 时使用默认窗口（1 分钟）
+func (uc *AccountUsecase) SetOAuthSessionLimit(limit int32, window time.Duration) {
+	if limit < 0 {
+		limit = 0
+	}
+	if window <= 0 {
+		window = DefaultOAuthSessionWindow
+	}
+	uc.oauthSessionLimit = limit
+	uc.oauthSessionWindow = window
+}
+
+// checkOAuthSessionLimit 按调用方（API Key 名称，缺省时使用客户端 IP）限制 OAuth Session 创建频率
+// 使用 Redis 固定窗口计数；Redis 故障时放行（与 RPM 限流一致的降级策略）
+func (uc *AccountUsecase) checkOAuthSessionLimit(ctx context.Context) error {
+	if uc.oauthSessionLimit <= 0 || uc.rdb == nil {
+		return nil
+	}
+
+	actor := oauthSessionActor(ctx)
+	key := OAuthSessionRateKeyPrefix + actor
+
+	count, err := uc.rdb.Incr(ctx, key).Result()
+	if err != nil {
+		uc.logger.Warnf("Redis OAuth session limit check failed for %s: %v (request allowed)", actor, err)
+		return nil
+	}
+	if count == 1 {
+		if err := uc.rdb.Expire(ctx, key, uc.oauthSessionWindow).Err(); err != nil {
+			uc.logger.Warnf("failed to set TTL for OAuth session counter %s: %v", actor, err)
+		}
+	}
+
+	if count > int64(uc.oauthSessionLimit) {
+		uc.logger.Warnw("OAuth session creation rate limited",
+			"actor", actor,
+			"current", count,
+			"limit", uc.oauthSessionLimit,
+			"window", uc.oauthSessionWindow)
+		return errors.New(
+			429, // HTTP 429 Too Many Requests
+			"OAUTH_SESSION_RATE_LIMITED",
+			fmt.Sprintf("too many OAuth sessions: limit=%d per %s", uc.oauthSessionLimit, uc.oauthSessionWindow),
+		)
+	}
+	return nil
+}
+
+// oauthSessionActor 返回限流维度：优先使用 API Key 名称，其次客户端 IP
+func oauthSessionActor(ctx context.Context) string {
+	if keyName := pkglog.GetKeyName(ctx); keyName != "" {
+		return "key:" + keyName
+	}
+	if ip := pkglog.GetClientIP(ctx); ip != "" {
+		return "ip:" + ip
+	}
+	return "anonymous"
+}
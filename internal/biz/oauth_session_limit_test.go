@@ -0,0 +1,81 @@
+package biz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "QuotaLane/api/v1"
+	pkglog "QuotaLane/pkg/log"
+	"QuotaLane/pkg/oauth"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupOAuthSessionLimitTest(t *testing.T) (*AccountUsecase, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	oauthManager := oauth.NewOAuthManager(rdb, log.DefaultLogger)
+	oauthManager.RegisterProvider(&mockOAuthProvider{
+		authURL:      "https://console.anthropic.com/v1/oauth/authorize",
+		codeVerifier: "verifier",
+	})
+
+	uc := NewAccountUsecase(nil, nil, nil, nil, oauthManager, nil, nil, nil, rdb, log.DefaultLogger)
+	return uc, mr
+}
+
+func generateOAuthURL(ctx context.Context, uc *AccountUsecase) error {
+	_, _, _, err := uc.GenerateOAuthURL(ctx, v1.AccountProvider_CLAUDE_OFFICIAL, "", "", nil, nil)
+	return err
+}
+
+func TestGenerateOAuthURL_SessionLimitPerActor(t *testing.T) {
+	uc, mr := setupOAuthSessionLimitTest(t)
+	uc.SetOAuthSessionLimit(2, time.Minute)
+
+	alice := pkglog.WithRequestContext(context.Background(), "req-1", "alice", "key-1", "")
+	bob := pkglog.WithRequestContext(context.Background(), "req-2", "bob", "key-2", "")
+
+	require.NoError(t, generateOAuthURL(alice, uc))
+	require.NoError(t, generateOAuthURL(alice, uc))
+
+	err := generateOAuthURL(alice, uc)
+	require.Error(t, err)
+	assert.Equal(t, int32(429), errors.FromError(err).Code)
+	assert.Equal(t, "OAUTH_SESSION_RATE_LIMITED", errors.FromError(err).Reason)
+
+	// Other callers have their own budget
+	require.NoError(t, generateOAuthURL(bob, uc))
+
+	// Budget resets after the window
+	mr.FastForward(time.Minute + time.Second)
+	require.NoError(t, generateOAuthURL(alice, uc))
+}
+
+func TestGenerateOAuthURL_SessionLimitByClientIP(t *testing.T) {
+	uc, _ := setupOAuthSessionLimitTest(t)
+	uc.SetOAuthSessionLimit(1, time.Minute)
+
+	ctx := pkglog.WithRequestContext(context.Background(), "req-1", "", "", "")
+	pkglog.SetMetadata(ctx, pkglog.MetadataClientIP, "10.0.0.1")
+
+	require.NoError(t, generateOAuthURL(ctx, uc))
+	assert.Error(t, generateOAuthURL(ctx, uc))
+}
+
+func TestGenerateOAuthURL_SessionLimitDisabled(t *testing.T) {
+	uc, mr := setupOAuthSessionLimitTest(t)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, generateOAuthURL(context.Background(), uc))
+	}
+	assert.False(t, mr.Exists(OAuthSessionRateKeyPrefix+"anonymous"))
+}
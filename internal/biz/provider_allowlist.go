@@ -0,0 +1,57 @@
+package biz
+
+import (
+	"fmt"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+)
+
+// ReasonProviderDisabled 请求的 Provider 未在当前部署的 enabled_providers 中启用
+const ReasonProviderDisabled = "PROVIDER_DISABLED"
+
+// SetEnabledProviders 设置当前部署启用的 Provider（如 ["claude-console", "openai-responses"]），
+// 未启用的 Provider 无法创建账户、发起 OAuth 授权、导入或验证；空列表表示全部启用。
+// 未知的 Provider 名称返回错误，不修改当前配置
+func (uc *AccountUsecase) SetEnabledProviders(names []string) error {
+	if len(names) == 0 {
+		uc.enabledProviders = nil
+		return nil
+	}
+
+	enabled := make(map[data.AccountProvider]bool, len(names))
+	for _, name := range names {
+		provider := data.AccountProvider(name)
+		if _, ok := providerCapabilities[provider]; !ok {
+			return fmt.Errorf("unknown provider %q in enabled providers", name)
+		}
+		enabled[provider] = true
+	}
+
+	uc.enabledProviders = enabled
+	return nil
+}
+
+// ProviderEnabled reports whether provider is enabled in this deployment.
+func (uc *AccountUsecase) ProviderEnabled(provider data.AccountProvider) bool {
+	return uc.enabledProviders == nil || uc.enabledProviders[provider]
+}
+
+// CheckProviderEnabled returns a PROVIDER_DISABLED validation error naming the Provider field
+// when provider is not enabled in this deployment.
+func (uc *AccountUsecase) CheckProviderEnabled(provider data.AccountProvider) error {
+	if uc.ProviderEnabled(provider) {
+		return nil
+	}
+	return newValidationError(ReasonProviderDisabled, "Provider",
+		"provider %s is disabled in this deployment (not in enabled_providers)", provider)
+}
+
+// ListProviderCapabilities 返回 Provider 能力矩阵，并按 enabled_providers 标记各 Provider 是否启用
+func (uc *AccountUsecase) ListProviderCapabilities() []*v1.ProviderCapability {
+	caps := ListProviderCapabilities()
+	for _, c := range caps {
+		c.Enabled = uc.ProviderEnabled(data.ProviderFromProto(c.Provider))
+	}
+	return caps
+}
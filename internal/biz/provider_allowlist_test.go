@@ -0,0 +1,85 @@
+package biz
+
+import (
+	"context"
+	"testing"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateAccount_DisabledProvider tests that creating an account of a provider left out of
+// enabled_providers is rejected before anything is stored, while enabled providers still work.
+func TestCreateAccount_DisabledProvider(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	ctx := context.Background()
+	require.NoError(t, uc.SetEnabledProviders([]string{"openai-responses"}))
+
+	result, err := uc.CreateAccount(ctx, &v1.CreateAccountRequest{
+		Name:      "Claude Console",
+		Provider:  v1.AccountProvider_CLAUDE_CONSOLE,
+		OAuthData: `{"access_token":"token"}`,
+	})
+
+	require.Error(t, err)
+	assert.Nil(t, result)
+	assert.True(t, errors.IsBadRequest(err))
+	assert.Equal(t, ReasonProviderDisabled, errors.Reason(err))
+	field, ok := ValidationField(err)
+	assert.True(t, ok)
+	assert.Equal(t, "Provider", field)
+	assert.Contains(t, err.Error(), "claude-console is disabled")
+	mockRepo.AssertNotCalled(t, "CreateAccount", mock.Anything, mock.Anything)
+
+	mockRepo.On("CreateAccount", ctx, mock.AnythingOfType("*data.Account")).Return(nil).Once()
+	result, err = uc.CreateAccount(ctx, &v1.CreateAccountRequest{
+		Name:     "OpenAI",
+		Provider: v1.AccountProvider_OPENAI_RESPONSES,
+		ApiKey:   "sk-test-1234567890abcdef",
+		BaseApi:  "https://api.openai.com",
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, result)
+}
+
+// TestGenerateOAuthURL_DisabledProvider tests that OAuth authorization cannot start for a disabled provider.
+func TestGenerateOAuthURL_DisabledProvider(t *testing.T) {
+	uc, _, _ := setupTestOAuth(t)
+	require.NoError(t, uc.SetEnabledProviders([]string{"codex-cli"}))
+
+	_, _, _, err := uc.GenerateOAuthURL(context.Background(), v1.AccountProvider_CLAUDE_OFFICIAL, "", "", nil, nil)
+
+	require.Error(t, err)
+	assert.Equal(t, ReasonProviderDisabled, errors.Reason(err))
+}
+
+func TestSetEnabledProviders(t *testing.T) {
+	uc := &AccountUsecase{}
+	assert.True(t, uc.ProviderEnabled(data.ProviderGemini), "all providers enabled by default")
+
+	require.NoError(t, uc.SetEnabledProviders([]string{"claude-console", "openai-responses"}))
+	assert.True(t, uc.ProviderEnabled(data.ProviderClaudeConsole))
+	assert.False(t, uc.ProviderEnabled(data.ProviderGemini))
+
+	assert.Error(t, uc.SetEnabledProviders([]string{"claude-console", "claude"}))
+	assert.True(t, uc.ProviderEnabled(data.ProviderClaudeConsole), "invalid list leaves the current setting")
+	assert.False(t, uc.ProviderEnabled(data.ProviderGemini))
+
+	require.NoError(t, uc.SetEnabledProviders(nil))
+	assert.True(t, uc.ProviderEnabled(data.ProviderGemini))
+}
+
+// TestAccountUsecase_ListProviderCapabilities tests that providers outside enabled_providers are marked disabled.
+func TestAccountUsecase_ListProviderCapabilities(t *testing.T) {
+	uc := &AccountUsecase{}
+	require.NoError(t, uc.SetEnabledProviders([]string{"openai-responses"}))
+
+	for _, c := range uc.ListProviderCapabilities() {
+		assert.Equal(t, c.Provider == v1.AccountProvider_OPENAI_RESPONSES, c.Enabled, c.Provider.String())
+	}
+}
@@ -0,0 +1,76 @@
+package biz
+
+import (
+	"sort"
+	"time"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+)
+
+// DefaultRequestTimeout 未知 Provider 的默认上游请求超时
+const DefaultRequestTimeout = 5 * time.Minute
+
+// ProviderCapabilities 描述各 Provider 的接入能力与创建时的必填要求
+type ProviderCapabilities struct {
+	// UsesOAuth 凭证为 OAuth Token（自动刷新），否则为静态 API Key
+	UsesOAuth bool
+	// RequiresBaseAPI 必须配置 API 基础地址（无统一的官方 Endpoint）
+	RequiresBaseAPI bool
+	// Refreshable 支持自动刷新凭证（定时刷新任务会处理该 Provider 的账户）
+	Refreshable bool
+	// Validatable 支持通过 TestAccount 在线验证凭证
+	Validatable bool
+	// RequestTimeout 上游请求默认超时（账户可通过 metadata.request_timeout_ms 覆盖）
+	RequestTimeout time.Duration
+}
+
+// providerCapabilities Provider 能力矩阵
+var providerCapabilities = map[data.AccountProvider]ProviderCapabilities{
+	data.ProviderClaudeOfficial:  {UsesOAuth: true, Refreshable: true, Validatable: true, RequestTimeout: 10 * time.Minute},
+	data.ProviderClaudeConsole:   {UsesOAuth: true, Refreshable: true, Validatable: true, RequestTimeout: 10 * time.Minute},
+	data.ProviderBedrock:         {RequestTimeout: 10 * time.Minute},
+	data.ProviderCCR:             {RequestTimeout: 10 * time.Minute},
+	data.ProviderDroid:           {UsesOAuth: true, RequestTimeout: 10 * time.Minute},
+	data.ProviderGemini:          {UsesOAuth: true, RequestTimeout: 5 * time.Minute},
+	data.ProviderOpenAIResponses: {RequiresBaseAPI: true, Validatable: true, RequestTimeout: 5 * time.Minute},
+	data.ProviderCodexCLI:        {UsesOAuth: true, Refreshable: true, RequestTimeout: 5 * time.Minute},
+	data.ProviderAzureOpenAI:     {RequiresBaseAPI: true, RequestTimeout: 5 * time.Minute},
+}
+
+// CapabilitiesOf 返回 Provider 的能力描述，未知 Provider 返回零值
+func CapabilitiesOf(provider data.AccountProvider) ProviderCapabilities {
+	return providerCapabilities[provider]
+}
+
+// ListProviderCapabilities 返回每个 Provider 枚举值（不含 UNSPECIFIED）的能力矩阵，按枚举值排序；
+// 不考虑 enabled_providers（全部标记为启用），部署相关的结果使用 AccountUsecase.ListProviderCapabilities
+func ListProviderCapabilities() []*v1.ProviderCapability {
+	providers := make([]v1.AccountProvider, 0, len(v1.AccountProvider_name))
+	for value := range v1.AccountProvider_name {
+		if provider := v1.AccountProvider(value); provider != v1.AccountProvider_ACCOUNT_PROVIDER_UNSPECIFIED {
+			providers = append(providers, provider)
+		}
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i] < providers[j] })
+
+	result := make([]*v1.ProviderCapability, 0, len(providers))
+	for _, provider := range providers {
+		caps := CapabilitiesOf(data.ProviderFromProto(provider))
+		timeout := caps.RequestTimeout
+		if timeout <= 0 {
+			timeout = DefaultRequestTimeout
+		}
+		result = append(result, &v1.ProviderCapability{
+			Provider:              provider,
+			UsesOauth:             caps.UsesOAuth,
+			UsesApiKey:            !caps.UsesOAuth,
+			RequiresBaseApi:       caps.RequiresBaseAPI,
+			Refreshable:           caps.Refreshable,
+			Validatable:           caps.Validatable,
+			RequestTimeoutSeconds: int64(timeout.Seconds()),
+			Enabled:               true,
+		})
+	}
+	return result
+}
@@ -0,0 +1,138 @@
+package biz
+
+import (
+	"context"
+	"testing"
+
+	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilitiesOf_RequiresBaseAPI(t *testing.T) {
+	assert.True(t, CapabilitiesOf(data.ProviderOpenAIResponses).RequiresBaseAPI)
+	assert.True(t, CapabilitiesOf(data.ProviderAzureOpenAI).RequiresBaseAPI)
+	assert.False(t, CapabilitiesOf(data.ProviderClaudeConsole).RequiresBaseAPI)
+	assert.True(t, CapabilitiesOf(data.ProviderClaudeConsole).UsesOAuth)
+	assert.Equal(t, ProviderCapabilities{}, CapabilitiesOf("unknown"))
+}
+
+func TestListProviderCapabilities(t *testing.T) {
+	type flags struct {
+		oauth, baseAPI, refreshable, validatable bool
+		timeoutSeconds                           int64
+	}
+	want := map[v1.AccountProvider]flags{
+		v1.AccountProvider_CLAUDE_OFFICIAL:  {oauth: true, refreshable: true, validatable: true, timeoutSeconds: 600},
+		v1.AccountProvider_CLAUDE_CONSOLE:   {oauth: true, refreshable: true, validatable: true, timeoutSeconds: 600},
+		v1.AccountProvider_BEDROCK:          {timeoutSeconds: 600},
+		v1.AccountProvider_CCR:              {timeoutSeconds: 600},
+		v1.AccountProvider_DROID:            {oauth: true, timeoutSeconds: 600},
+		v1.AccountProvider_GEMINI:           {oauth: true, timeoutSeconds: 300},
+		v1.AccountProvider_OPENAI_RESPONSES: {baseAPI: true, validatable: true, timeoutSeconds: 300},
+		v1.AccountProvider_AZURE_OPENAI:     {baseAPI: true, timeoutSeconds: 300},
+		v1.AccountProvider_CODEX_CLI:        {oauth: true, refreshable: true, timeoutSeconds: 300},
+	}
+
+	caps := ListProviderCapabilities()
+
+	// Every enum value except UNSPECIFIED is listed once, in enum order
+	require.Len(t, caps, len(v1.AccountProvider_name)-1)
+	require.Len(t, want, len(caps), "update the expectations when a provider is added")
+	for i, c := range caps {
+		if i > 0 {
+			assert.Less(t, caps[i-1].Provider, c.Provider)
+		}
+		w, ok := want[c.Provider]
+		require.True(t, ok, "unexpected provider %s", c.Provider)
+		assert.Equal(t, w.oauth, c.UsesOauth, c.Provider.String())
+		assert.Equal(t, !w.oauth, c.UsesApiKey, c.Provider.String())
+		assert.Equal(t, w.baseAPI, c.RequiresBaseApi, c.Provider.String())
+		assert.Equal(t, w.refreshable, c.Refreshable, c.Provider.String())
+		assert.Equal(t, w.validatable, c.Validatable, c.Provider.String())
+		assert.Equal(t, w.timeoutSeconds, c.RequestTimeoutSeconds, c.Provider.String())
+	}
+}
+
+func TestCreateAccount_BaseAPIRequired(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	ctx := context.Background()
+
+	result, err := uc.CreateAccount(ctx, &v1.CreateAccountRequest{
+		Name:     "OpenAI without base",
+		Provider: v1.AccountProvider_OPENAI_RESPONSES,
+		ApiKey:   "sk-test-1234567890abcdef",
+	})
+
+	require.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "base_api is required")
+	mockRepo.AssertNotCalled(t, "CreateAccount", mock.Anything, mock.Anything)
+}
+
+func TestCreateAccount_BaseAPINotRequiredForOAuthProvider(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	ctx := context.Background()
+
+	mockRepo.On("CreateAccount", ctx, mock.MatchedBy(func(a *data.Account) bool {
+		return a.BaseAPI == ""
+	})).Return(nil).Once()
+
+	_, err := uc.CreateAccount(ctx, &v1.CreateAccountRequest{
+		Name:      "Claude Console",
+		Provider:  v1.AccountProvider_CLAUDE_CONSOLE,
+		OAuthData: `{"access_token":"test_token","refresh_token":"test_refresh","expires_at":"2030-01-01T00:00:00Z"}`,
+	})
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateAccount_BaseAPIResolution(t *testing.T) {
+	uc, mockRepo, _ := setupTestUsecase(t)
+	ctx := context.Background()
+
+	t.Run("explicit base_api is saved", func(t *testing.T) {
+		mockRepo.On("CreateAccount", ctx, mock.MatchedBy(func(a *data.Account) bool {
+			return a.BaseAPI == "https://api.openai.com/v1"
+		})).Return(nil).Once()
+
+		_, err := uc.CreateAccount(ctx, &v1.CreateAccountRequest{
+			Name:     "OpenAI explicit",
+			Provider: v1.AccountProvider_OPENAI_RESPONSES,
+			ApiKey:   "sk-test-1234567890abcdef",
+			BaseApi:  " https://api.openai.com/v1 ",
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("metadata custom_base_url is used as fallback", func(t *testing.T) {
+		mockRepo.On("CreateAccount", ctx, mock.MatchedBy(func(a *data.Account) bool {
+			return a.BaseAPI == "https://relay.example.com"
+		})).Return(nil).Once()
+
+		_, err := uc.CreateAccount(ctx, &v1.CreateAccountRequest{
+			Name:     "OpenAI metadata",
+			Provider: v1.AccountProvider_OPENAI_RESPONSES,
+			ApiKey:   "sk-test-1234567890abcdef",
+			Metadata: `{"custom_base_url":"https://relay.example.com"}`,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid base_api is rejected", func(t *testing.T) {
+		_, err := uc.CreateAccount(ctx, &v1.CreateAccountRequest{
+			Name:     "OpenAI invalid",
+			Provider: v1.AccountProvider_OPENAI_RESPONSES,
+			ApiKey:   "sk-test-1234567890abcdef",
+			BaseApi:  "not a url",
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid base_api")
+	})
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,24 @@
+package biz
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultProviderCallTimeout 后台任务中单次 Provider 调用的默认超时时间
+const DefaultProviderCallTimeout = 60 * time.Second
+
+// SetProviderCallTimeout 设置后台任务（如 OpenAI Responses 健康检查）中单次 Provider 调用的超时时间；d <= 0 时恢复默认值
+// 批量 Token 刷新使用 SetRefreshAccountTimeout 设置的单账户超时
+func (uc *AccountUsecase) SetProviderCallTimeout(d time.Duration) {
+	uc.providerCallTimeout = d
+}
+
+// withProviderDeadline 为一次后台 Provider 调用派生带超时的 context
+// 父 context 的截止时间更早时以父 context 为准（如定时任务的整体超时）
+func withProviderDeadline(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		d = DefaultProviderCallTimeout
+	}
+	return context.WithTimeout(ctx, d)
+}
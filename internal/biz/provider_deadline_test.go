@@ -0,0 +1,103 @@
+package biz
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/crypto"
+	pkgoauth "QuotaLane/pkg/oauth"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// hangingProvider is a provider whose validate call never returns until its context is done.
+type hangingProvider struct {
+	mockOAuthProvider
+	mu        sync.Mutex
+	deadlines []time.Duration // remaining time until the deadline seen by each call (-1 = none)
+}
+
+func (p *hangingProvider) ValidateToken(ctx context.Context, token string, metadata *pkgoauth.AccountMetadata) error {
+	remaining := time.Duration(-1)
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining = time.Until(deadline)
+	}
+	p.mu.Lock()
+	p.deadlines = append(p.deadlines, remaining)
+	p.mu.Unlock()
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (p *hangingProvider) ProviderType() data.AccountProvider {
+	return data.ProviderOpenAIResponses
+}
+
+func TestHealthCheckOpenAIResponsesAccounts_ProviderCallDeadline(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	aes, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+	apiKey, err := aes.Encrypt("sk-test")
+	require.NoError(t, err)
+
+	account := &data.Account{ID: 1, Provider: data.ProviderOpenAIResponses, Status: data.StatusActive, HealthScore: 100, APIKeyEncrypted: apiKey, BaseAPI: "https://api.example.com"}
+	mockRepo := new(MockAccountRepo)
+	mockRepo.On("ListAccountsByProvider", mock.Anything, data.ProviderOpenAIResponses, data.StatusActive).Return([]*data.Account{account}, nil)
+	// The failure bookkeeping after a timeout must run on a live context, otherwise the writes fail
+	// and a hung upstream never costs the account health
+	liveCtx := mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil })
+	mockRepo.On("UpdateHealthScore", liveCtx, int64(1), 80).Return(nil).Once()
+	mockRepo.On("UpdateAccountStatus", liveCtx, int64(1), data.StatusError).Return(nil).Once()
+	mockRepo.On("UpdateAccount", liveCtx, mock.Anything).Return(nil)
+	mockRepo.On("SetLastCheckedAt", mock.Anything, int64(1), mock.Anything).Return(nil).Once()
+
+	provider := &hangingProvider{}
+	manager := pkgoauth.NewOAuthManager(rdb, log.DefaultLogger)
+	manager.RegisterProvider(provider)
+
+	uc := NewAccountUsecase(mockRepo, aes, nil, nil, manager, nil, nil, nil, rdb, log.DefaultLogger)
+	uc.SetProviderCallTimeout(50 * time.Millisecond)
+
+	// The job context has no deadline, as with context.Background() in a cron job
+	start := time.Now()
+	require.NoError(t, uc.HealthCheckOpenAIResponsesAccounts(context.Background()))
+	assert.Less(t, time.Since(start), 5*time.Second, "hung provider call must be cut off by the injected deadline")
+
+	require.Len(t, provider.deadlines, 1)
+	assert.Greater(t, provider.deadlines[0], time.Duration(0), "provider call must carry a deadline")
+	assert.LessOrEqual(t, provider.deadlines[0], 50*time.Millisecond)
+	mockRepo.AssertExpectations(t)
+	assert.EqualValues(t, 1, account.ConsecutiveErrors)
+	failures, err := rdb.Get(context.Background(), "health_check_failure:1").Int()
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures, "timeout counts as a failed check")
+}
+
+func TestWithProviderDeadline(t *testing.T) {
+	ctx, cancel := withProviderDeadline(context.Background(), 0)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.InDelta(t, DefaultProviderCallTimeout.Seconds(), time.Until(deadline).Seconds(), 1)
+
+	// An earlier parent deadline (e.g. the job's overall timeout) wins
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Second)
+	defer parentCancel()
+	ctx, cancel = withProviderDeadline(parent, time.Hour)
+	defer cancel()
+	deadline, _ = ctx.Deadline()
+	parentDeadline, _ := parent.Deadline()
+	assert.Equal(t, parentDeadline, deadline)
+}
@@ -0,0 +1,53 @@
+package biz
+
+import (
+	"context"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// DefaultProviderConcurrency 后台任务对上游 Provider 的默认全局并发上限
+const DefaultProviderConcurrency = 10
+
+// ProviderCallLimiter 后台任务共享的 Provider 调用并发限制器
+// Token 刷新、健康检查等定时任务各自有并发池，重叠运行时由该限制器保证
+// 在途 Provider 调用总数不超过全局上限。nil 表示不限制。
+type ProviderCallLimiter struct {
+	sem   *semaphore.Weighted
+	limit int64
+}
+
+// NewProviderCallLimiter 创建全局并发限制器（limit <= 0 时使用默认值）
+func NewProviderCallLimiter(limit int64) *ProviderCallLimiter {
+	if limit <= 0 {
+		limit = DefaultProviderConcurrency
+	}
+	return &ProviderCallLimiter{
+		sem:   semaphore.NewWeighted(limit),
+		limit: limit,
+	}
+}
+
+// Acquire 获取一个调用名额，阻塞直到有空闲名额或 ctx 结束
+func (l *ProviderCallLimiter) Acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	return l.sem.Acquire(ctx, 1)
+}
+
+// Release 归还一个调用名额
+func (l *ProviderCallLimiter) Release() {
+	if l == nil {
+		return
+	}
+	l.sem.Release(1)
+}
+
+// Limit 返回全局并发上限（0 表示不限制）
+func (l *ProviderCallLimiter) Limit() int64 {
+	if l == nil {
+		return 0
+	}
+	return l.limit
+}
@@ -0,0 +1,154 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/crypto"
+	pkgoauth "QuotaLane/pkg/oauth"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyTracker records the peak number of in-flight provider calls.
+type concurrencyTracker struct {
+	mu       sync.Mutex
+	inflight int
+	peak     int
+	calls    int
+}
+
+func (c *concurrencyTracker) call() {
+	c.mu.Lock()
+	c.inflight++
+	c.calls++
+	if c.inflight > c.peak {
+		c.peak = c.inflight
+	}
+	c.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	c.mu.Lock()
+	c.inflight--
+	c.mu.Unlock()
+}
+
+// trackingProvider is a provider whose refresh/validate calls are tracked by a shared tracker.
+type trackingProvider struct {
+	mockOAuthProvider
+	providerType data.AccountProvider
+	tracker      *concurrencyTracker
+}
+
+func (p *trackingProvider) RefreshToken(ctx context.Context, refreshToken string, metadata *pkgoauth.AccountMetadata) (*pkgoauth.ExtendedTokenResponse, error) {
+	p.tracker.call()
+	return &pkgoauth.ExtendedTokenResponse{AccessToken: "new-access", RefreshToken: "new-refresh", ExpiresIn: 3600}, nil
+}
+
+func (p *trackingProvider) ValidateToken(ctx context.Context, token string, metadata *pkgoauth.AccountMetadata) error {
+	p.tracker.call()
+	return nil
+}
+
+func (p *trackingProvider) ProviderType() data.AccountProvider {
+	return p.providerType
+}
+
+func TestProviderCallLimiter_SharedAcrossJobs(t *testing.T) {
+	const (
+		accountsPerJob = 10
+		globalLimit    = 3
+	)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	aes, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+	accessToken, err := aes.Encrypt("a")
+	require.NoError(t, err)
+	refreshToken, err := aes.Encrypt("r")
+	require.NoError(t, err)
+	oauthJSON, err := json.Marshal(StoredOAuthData{AccessTokenEncrypted: accessToken, RefreshTokenEncrypted: refreshToken, ExpiresAt: time.Now()})
+	require.NoError(t, err)
+	oauthData, err := aes.Encrypt(string(oauthJSON))
+	require.NoError(t, err)
+	apiKey, err := aes.Encrypt("sk-test")
+	require.NoError(t, err)
+
+	var expiring, openaiAccounts []*data.Account
+	mockRepo := new(MockAccountRepo)
+	for i := 0; i < accountsPerJob; i++ {
+		claude := &data.Account{ID: int64(100 + i), Provider: data.ProviderClaudeConsole, Status: data.StatusActive, HealthScore: 100, OAuthDataEncrypted: oauthData}
+		openaiAcc := &data.Account{ID: int64(200 + i), Provider: data.ProviderOpenAIResponses, Status: data.StatusActive, HealthScore: 100, APIKeyEncrypted: apiKey, BaseAPI: "https://api.example.com"}
+		expiring = append(expiring, claude)
+		openaiAccounts = append(openaiAccounts, openaiAcc)
+		mockRepo.On("GetAccount", mock.Anything, claude.ID).Return(claude, nil)
+		mockRepo.On("GetAccount", mock.Anything, openaiAcc.ID).Return(openaiAcc, nil)
+	}
+	mockRepo.On("ListExpiringAccounts", mock.Anything, mock.Anything).Return(expiring, nil)
+	mockRepo.On("ListAccountsByProvider", mock.Anything, data.ProviderOpenAIResponses, data.StatusActive).Return(openaiAccounts, nil)
+	mockRepo.On("UpdateOAuthData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("UpdateHealthScore", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("UpdateAccountStatus", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("UpdateAccount", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("SetLastCheckedAt", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	tracker := &concurrencyTracker{}
+	manager := pkgoauth.NewOAuthManager(rdb, log.DefaultLogger)
+	manager.RegisterProvider(&trackingProvider{providerType: data.ProviderClaudeConsole, tracker: tracker})
+	manager.RegisterProvider(&trackingProvider{providerType: data.ProviderOpenAIResponses, tracker: tracker})
+
+	uc := NewAccountUsecase(mockRepo, aes, nil, nil, manager, nil, nil, nil, rdb, log.DefaultLogger)
+	uc.SetProviderCallLimiter(NewProviderCallLimiter(globalLimit))
+
+	// Each job alone allows up to 5 concurrent calls; together they must respect the global cap
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, uc.AutoRefreshTokens(ctx))
+	}()
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, uc.HealthCheckOpenAIResponsesAccounts(ctx))
+	}()
+	wg.Wait()
+
+	assert.Equal(t, 2*accountsPerJob, tracker.calls)
+	assert.LessOrEqual(t, tracker.peak, globalLimit)
+	assert.Equal(t, globalLimit, tracker.peak, "limit should be saturated by the overlapping jobs")
+}
+
+func TestProviderCallLimiter_Defaults(t *testing.T) {
+	assert.Equal(t, int64(DefaultProviderConcurrency), NewProviderCallLimiter(0).Limit())
+	assert.Equal(t, int64(4), NewProviderCallLimiter(4).Limit())
+
+	// nil limiter does not limit
+	var limiter *ProviderCallLimiter
+	require.NoError(t, limiter.Acquire(context.Background()))
+	limiter.Release()
+	assert.Equal(t, int64(0), limiter.Limit())
+}
+
+func TestProviderCallLimiter_AcquireHonorsContext(t *testing.T) {
+	limiter := NewProviderCallLimiter(1)
+	require.NoError(t, limiter.Acquire(context.Background()))
+	defer limiter.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, limiter.Acquire(ctx))
+}
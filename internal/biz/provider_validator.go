@@ -0,0 +1,97 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+const (
+	// ValidationMethodAPIKey 调用 Provider 验证接口检查 API Key
+	ValidationMethodAPIKey = "api_key"
+	// ValidationMethodTokenRefresh 执行一次 OAuth Token 刷新，刷新成功即凭证可用
+	ValidationMethodTokenRefresh = "token_refresh"
+)
+
+// ValidationResult 一次 Provider 验证的结果
+type ValidationResult struct {
+	Method    string    // 验证方式（ValidationMethodAPIKey / ValidationMethodTokenRefresh）
+	ExpiresAt time.Time // Token 刷新验证后的新过期时间（API Key 验证为零值）
+}
+
+// ProviderValidator 验证某一 Provider 账户的凭证是否可用
+// 验证失败时返回错误；健康分数、账户状态和熔断按各 Provider 的验证流程更新
+type ProviderValidator interface {
+	Validate(ctx context.Context, account *data.Account) (ValidationResult, error)
+}
+
+// providerValidators 以 API Key 验证的 Provider 及其验证实现
+// 支持 Token 刷新的 OAuth Provider 无需在此注册：accountRefreshers 中的每个 Provider 自动以一次 Token 刷新验证，
+// 新增 OAuth Provider 只需注册刷新函数。TestAccount、ProbeAccount 与批量健康检查均通过 ValidatorFor 分发
+var providerValidators = map[data.AccountProvider]func(uc *AccountUsecase) ProviderValidator{
+	data.ProviderOpenAIResponses: func(uc *AccountUsecase) ProviderValidator { return openAIResponsesValidator{uc: uc} },
+	data.ProviderAzureOpenAI:     func(uc *AccountUsecase) ProviderValidator { return azureOpenAIValidator{uc: uc} },
+}
+
+// ValidatorFor 返回 Provider 对应的验证实现；Provider 不支持验证时返回 false
+func (uc *AccountUsecase) ValidatorFor(provider data.AccountProvider) (ProviderValidator, bool) {
+	if newValidator, ok := providerValidators[provider]; ok {
+		return newValidator(uc), true
+	}
+	if refresh, ok := accountRefreshers[provider]; ok {
+		return tokenRefreshValidator{uc: uc, refresh: refresh}, true
+	}
+	return nil, false
+}
+
+// ValidateAccount 按账户的 Provider 分发到对应的验证实现，验证账户凭证是否可用
+func (uc *AccountUsecase) ValidateAccount(ctx context.Context, id int64) (ValidationResult, error) {
+	account, err := uc.repo.GetAccount(ctx, id)
+	if err != nil {
+		return ValidationResult{}, err
+	}
+	return uc.validateAccount(ctx, account)
+}
+
+// validateAccount 验证已加载的账户，不再重复读取数据库
+func (uc *AccountUsecase) validateAccount(ctx context.Context, account *data.Account) (ValidationResult, error) {
+	validator, ok := uc.ValidatorFor(account.Provider)
+	if !ok {
+		return ValidationResult{}, errors.BadRequest("VALIDATION_NOT_SUPPORTED",
+			fmt.Sprintf("account %d (provider: %s) does not support validation", account.ID, account.Provider))
+	}
+	return validator.Validate(ctx, account)
+}
+
+// openAIResponsesValidator 调用 OpenAI Responses 验证接口检查 API Key
+type openAIResponsesValidator struct {
+	uc *AccountUsecase
+}
+
+func (v openAIResponsesValidator) Validate(ctx context.Context, account *data.Account) (ValidationResult, error) {
+	return ValidationResult{Method: ValidationMethodAPIKey}, v.uc.validateOpenAIResponsesAccount(ctx, account)
+}
+
+// azureOpenAIValidator 调用 Azure OpenAI 部署检查 API Key
+type azureOpenAIValidator struct {
+	uc *AccountUsecase
+}
+
+func (v azureOpenAIValidator) Validate(ctx context.Context, account *data.Account) (ValidationResult, error) {
+	return ValidationResult{Method: ValidationMethodAPIKey}, v.uc.validateAzureOpenAIAccount(ctx, account)
+}
+
+// tokenRefreshValidator 对 OAuth 账户执行一次 Token 刷新（刷新失败按定时刷新的失败流程处理）
+type tokenRefreshValidator struct {
+	uc      *AccountUsecase
+	refresh accountRefresher
+}
+
+func (v tokenRefreshValidator) Validate(ctx context.Context, account *data.Account) (ValidationResult, error) {
+	expiresAt, err := v.refresh(v.uc, ctx, account)
+	return ValidationResult{Method: ValidationMethodTokenRefresh, ExpiresAt: expiresAt}, err
+}
@@ -0,0 +1,94 @@
+package biz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/oauth"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidatorFor tests that each provider resolves to its validator and unsupported providers to none.
+func TestValidatorFor(t *testing.T) {
+	uc := &AccountUsecase{}
+
+	tests := []struct {
+		provider data.AccountProvider
+		want     ProviderValidator
+	}{
+		{data.ProviderOpenAIResponses, openAIResponsesValidator{}},
+		{data.ProviderAzureOpenAI, azureOpenAIValidator{}},
+		{data.ProviderClaudeOfficial, tokenRefreshValidator{}},
+		{data.ProviderClaudeConsole, tokenRefreshValidator{}},
+		{data.ProviderCodexCLI, tokenRefreshValidator{}},
+		{data.ProviderBedrock, nil},
+		{data.ProviderCCR, nil},
+		{data.ProviderDroid, nil},
+		{data.ProviderGemini, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.provider), func(t *testing.T) {
+			validator, ok := uc.ValidatorFor(tt.provider)
+			if tt.want == nil {
+				assert.False(t, ok)
+				assert.Nil(t, validator)
+				return
+			}
+			require.True(t, ok)
+			assert.IsType(t, tt.want, validator)
+		})
+	}
+}
+
+// TestValidatorFor_EveryRefresherValidates tests that registering a token refresher is all an OAuth
+// provider needs to be validated.
+func TestValidatorFor_EveryRefresherValidates(t *testing.T) {
+	uc := &AccountUsecase{}
+	for provider := range accountRefreshers {
+		validator, ok := uc.ValidatorFor(provider)
+		require.True(t, ok, "provider %s", provider)
+		assert.IsType(t, tokenRefreshValidator{}, validator)
+	}
+}
+
+// TestValidateAccount_DispatchesByProvider tests that an OAuth account is validated by a token refresh.
+func TestValidateAccount_DispatchesByProvider(t *testing.T) {
+	s := setupRefreshAccountTest(t, &mockOAuthProvider{
+		tokenResp: &oauth.ExtendedTokenResponse{AccessToken: "new-access", RefreshToken: "new-refresh", ExpiresIn: 3600},
+	})
+	ctx := context.Background()
+	account := &data.Account{
+		ID:                 1,
+		Provider:           data.ProviderClaudeOfficial,
+		Status:             data.StatusActive,
+		HealthScore:        100,
+		OAuthDataEncrypted: s.storedOAuthData(t, "fixed-refresh", s.clock.Now().Add(-time.Hour)),
+	}
+	expected := s.clock.Now().Add(time.Hour)
+	s.repo.On("GetAccount", ctx, int64(1)).Return(account, nil)
+	s.repo.On("UpdateOAuthData", ctx, int64(1), mock.AnythingOfType("string"), expected).Return(nil)
+	s.repo.On("UpdateHealthScore", ctx, int64(1), 100).Return(nil)
+
+	result, err := s.uc.ValidateAccount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, ValidationMethodTokenRefresh, result.Method)
+	assert.Equal(t, expected, result.ExpiresAt)
+	s.repo.AssertExpectations(t)
+}
+
+// TestValidateAccount_UnsupportedProvider tests that providers without a registered validator are rejected.
+func TestValidateAccount_UnsupportedProvider(t *testing.T) {
+	s := setupRefreshAccountTest(t, &mockOAuthProvider{})
+	s.repo.On("GetAccount", mock.Anything, int64(6)).Return(&data.Account{ID: 6, Provider: data.ProviderBedrock}, nil)
+
+	_, err := s.uc.ValidateAccount(context.Background(), 6)
+	require.Error(t, err)
+	assert.Equal(t, "VALIDATION_NOT_SUPPORTED", kerrors.FromError(err).Reason)
+}
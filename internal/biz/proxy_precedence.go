@@ -0,0 +1,41 @@
+package biz
+
+import (
+	"fmt"
+	"slices"
+)
+
+// 代理来源层级（getProxyConfig 按配置的顺序依次查找，取第一个非空代理）
+const (
+	// ProxyTierRequest 请求级代理（RPC 参数）
+	ProxyTierRequest = "request"
+	// ProxyTierAccount 账户级代理（Account.Metadata 中的 proxy_url）
+	ProxyTierAccount = "account"
+	// ProxyTierEnv 全局代理（HTTP_PROXY / HTTPS_PROXY 环境变量）
+	ProxyTierEnv = "env"
+)
+
+// DefaultProxyPrecedence 默认代理优先级：请求级 > 账户级 > 全局环境变量
+var DefaultProxyPrecedence = []string{ProxyTierRequest, ProxyTierAccount, ProxyTierEnv}
+
+// SetProxyPrecedence 设置代理来源的查找顺序，例如 ["account", "request", "env"] 让账户级代理
+// 始终优先于请求级代理以强制出口策略；未列出的层级不参与查找，空列表恢复默认顺序。
+// 未知或重复的层级返回错误，不修改当前配置
+func (uc *AccountUsecase) SetProxyPrecedence(order []string) error {
+	if len(order) == 0 {
+		uc.proxyPrecedence = nil
+		return nil
+	}
+
+	for i, tier := range order {
+		if !slices.Contains(DefaultProxyPrecedence, tier) {
+			return fmt.Errorf("unknown proxy precedence tier %q (must be one of request, account, env)", tier)
+		}
+		if slices.Contains(order[:i], tier) {
+			return fmt.Errorf("duplicate proxy precedence tier %q", tier)
+		}
+	}
+
+	uc.proxyPrecedence = slices.Clone(order)
+	return nil
+}
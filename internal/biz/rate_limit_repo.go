@@ -2,6 +2,9 @@ package biz
 
 import (
 	"context"
+	"time"
+
+	"QuotaLane/internal/data"
 )
 
 // RateLimitRepo defines the interface for rate limiting operations.
@@ -11,14 +14,62 @@ type RateLimitRepo interface {
 	// RPM (Requests Per Minute) operations
 	IncrementRPM(ctx context.Context, accountID int64) (int32, error)
 	GetRPMCount(ctx context.Context, accountID int64) (int32, error)
+	// Sliding-window RPM log (timestamps in Unix milliseconds, empty model = account-wide log);
+	// returns the in-window count and oldest timestamp
+	AddSlidingRPMRequest(ctx context.Context, accountID int64, model, requestID string, timestamp int64) (count int32, oldest int64, err error)
+	RemoveSlidingRPMRequest(ctx context.Context, accountID int64, model, requestID string) error
 
 	// TPM (Tokens Per Minute) operations
 	IncrementTPM(ctx context.Context, accountID int64, tokens int32) (int32, error)
 	GetTPMCount(ctx context.Context, accountID int64) (int32, error)
 
+	// Per-model RPM/TPM counters of an account, isolated from the account-wide counters
+	// (empty model = the account-wide counter)
+	IncrementModelRPM(ctx context.Context, accountID int64, model string) (int32, error)
+	// DecrementModelRPM undoes one IncrementModelRPM of a request rejected by a later check
+	DecrementModelRPM(ctx context.Context, accountID int64, model string) error
+	IncrementModelTPM(ctx context.Context, accountID int64, model string, tokens int32) (int32, error)
+	GetModelTPMCount(ctx context.Context, accountID int64, model string) (int32, error)
+
+	// Daily (UTC) token totals; keys expire at the next UTC midnight
+	IncrementDailyTokens(ctx context.Context, accountID int64, now time.Time, tokens int64) (int64, error)
+	GetDailyTokenCount(ctx context.Context, accountID int64, now time.Time) (int64, error)
+
+	// GetUsageCounts returns RPM and TPM counts in a single round trip
+	GetUsageCounts(ctx context.Context, accountID int64) (rpm int32, tpm int32, err error)
+	// SumUsageCounts returns the total RPM across rpmAccountIDs and TPM across tpmAccountIDs in a single round trip
+	SumUsageCounts(ctx context.Context, rpmAccountIDs, tpmAccountIDs []int64) (rpm int64, tpm int64, err error)
+	// BatchGetUsageStats returns per-account RPM, TPM and concurrency in a single round trip
+	BatchGetUsageStats(ctx context.Context, accountIDs []int64) (map[int64]*data.AccountUsageStats, error)
+
+	// Group-wide RPM/TPM operations (shared by all accounts of a group)
+	IncrementGroupRPM(ctx context.Context, groupID int64) (int32, error)
+	// DecrementGroupRPM undoes one IncrementGroupRPM of a request rejected by a later check
+	DecrementGroupRPM(ctx context.Context, groupID int64) error
+	IncrementGroupTPM(ctx context.Context, groupID int64, tokens int32) (int32, error)
+	GetGroupTPMCount(ctx context.Context, groupID int64) (int32, error)
+
+	// IncrementGroupRoundRobin advances a group's shared round-robin rotation counter
+	IncrementGroupRoundRobin(ctx context.Context, groupID int64) (int64, error)
+
 	// Concurrency control operations
 	AddConcurrencyRequest(ctx context.Context, accountID int64, requestID string, timestamp int64) error
 	RemoveConcurrencyRequest(ctx context.Context, accountID int64, requestID string) error
 	GetConcurrencyCount(ctx context.Context, accountID int64) (int32, error)
 	CleanupExpiredConcurrency(ctx context.Context, accountID int64, expiredBefore int64) error
+	// HeartbeatConcurrencyRequest refreshes a held slot's timestamp in the account set and, when
+	// given, the provider (non-empty) and group (> 0) sets
+	HeartbeatConcurrencyRequest(ctx context.Context, accountID int64, provider data.AccountProvider, groupID int64, requestID string, timestamp int64) error
+
+	// Provider-wide concurrency operations (shared by all accounts of a provider)
+	AddProviderConcurrencyRequest(ctx context.Context, provider data.AccountProvider, requestID string, timestamp int64) error
+	RemoveProviderConcurrencyRequest(ctx context.Context, provider data.AccountProvider, requestID string) error
+	GetProviderConcurrencyCount(ctx context.Context, provider data.AccountProvider) (int32, error)
+	CleanupExpiredProviderConcurrency(ctx context.Context, provider data.AccountProvider, expiredBefore int64) error
+
+	// Group-wide concurrency operations (shared by all accounts of a group)
+	AddGroupConcurrencyRequest(ctx context.Context, groupID int64, requestID string, timestamp int64) error
+	RemoveGroupConcurrencyRequest(ctx context.Context, groupID int64, requestID string) error
+	GetGroupConcurrencyCount(ctx context.Context, groupID int64) (int32, error)
+	CleanupExpiredGroupConcurrency(ctx context.Context, groupID int64, expiredBefore int64) error
 }
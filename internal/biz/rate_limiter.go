@@ -3,18 +3,62 @@ package biz
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
+	"QuotaLane/internal/data"
+
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/log"
 )
 
+// DefaultConcurrencyLimit is the per-account concurrency limit (hardcoded for MVP).
+const DefaultConcurrencyLimit = 10
+
+// DefaultConcurrencyExpiry is how long a concurrency slot may stay held before the cleanup
+// cron reclaims it. It must exceed the longest legitimate (e.g. streaming) request.
+const DefaultConcurrencyExpiry = 10 * time.Minute
+
 // RateLimiterUseCase implements rate limiting business logic for accounts.
 // It provides RPM (Requests Per Minute), TPM (Tokens Per Minute) rate limiting,
 // and concurrency control using Redis-based counters and sorted sets.
 type RateLimiterUseCase struct {
 	repo   RateLimitRepo
 	logger *log.Helper
+	clock  Clock // 时间来源（测试中可替换为假时钟）
+
+	// providerConcurrency 每个 Provider 的全局并发上限（跨该 Provider 下所有账户；未配置表示不限制）
+	providerConcurrency map[data.AccountProvider]int32
+
+	// rpmBurst 固定窗口内允许超出 RPM 限制的额外请求数（0 表示严格限制）
+	rpmBurst int32
+
+	// rpmWindow CheckRPM 使用的窗口算法（空值表示固定窗口）
+	rpmWindow RPMWindowMode
+
+	// zeroLimit 限额为 0 时的语义（空值表示不限制）
+	zeroLimit ZeroLimitMode
+
+	// maxTokensPerRequest 单个请求允许的最大预估 Token 数（0 表示不限制）
+	maxTokensPerRequest int32
+
+	// concurrencyExpiry 并发槽位被清理任务视为过期的占用时长（0 表示使用默认值）
+	concurrencyExpiry time.Duration
+
+	// concurrencyStaleAfter 槽位超过该时长未收到心跳即被清理任务回收（0 表示不启用，仅按 concurrencyExpiry 回收）
+	concurrencyStaleAfter time.Duration
+
+	// concurrencyGroups 占用过组级并发槽位的账户组 ID（清理任务据此回收过期的组级槽位）
+	concurrencyGroups sync.Map
+
+	// tokenEstimators 需要精确分词的 Provider 的 Token 估算器（未注册的 Provider 使用粗略估算）
+	tokenEstimators map[data.AccountProvider]TokenEstimator
+
+	// tokenizedProviders 必须使用精确分词的 Provider（strict 模式下未注册估算器时拒绝请求）
+	tokenizedProviders map[data.AccountProvider]bool
+
+	// strictTokenEstimation 精确估算器不可用时是否拒绝请求（false 表示退回粗略估算）
+	strictTokenEstimation bool
 }
 
 // NewRateLimiterUseCase creates a new rate limiter use case.
@@ -22,6 +66,7 @@ func NewRateLimiterUseCase(repo RateLimitRepo, logger log.Logger) *RateLimiterUs
 	return &RateLimiterUseCase{
 		repo:   repo,
 		logger: log.NewHelper(logger),
+		clock:  SystemClock,
 	}
 }
 
@@ -49,44 +94,104 @@ func newRateLimitExceededError(limitType string, current, limit int32, retryAfte
 	)
 }
 
+// SetRPMBurst sets the burst allowance for CheckRPM: requests are rejected only above
+// rpmLimit + burst within a window. burst <= 0 restores the strict limit.
+func (uc *RateLimiterUseCase) SetRPMBurst(burst int32) {
+	if burst < 0 {
+		burst = 0
+	}
+	uc.rpmBurst = burst
+}
+
+// SetMaxTokensPerRequest sets the largest token estimate CheckTPM accepts for a single request.
+// Larger estimates are rejected before touching the TPM counter. max <= 0 removes the cap.
+func (uc *RateLimiterUseCase) SetMaxTokensPerRequest(max int32) {
+	if max < 0 {
+		max = 0
+	}
+	uc.maxTokensPerRequest = max
+}
+
 // CheckRPM checks if the account has exceeded its RPM (Requests Per Minute) limit.
-// It uses Redis INCR with fixed window rate limiting algorithm.
+// It uses Redis INCR with fixed window rate limiting algorithm, or CheckRPMSliding when the
+// sliding window mode is selected.
+// With a burst allowance configured, requests are rejected only above rpmLimit + burst.
 // Returns error if limit is exceeded, nil otherwise.
 // Redis degradation: on Redis failure, logs warning and allows request (graceful degradation).
 func (uc *RateLimiterUseCase) CheckRPM(ctx context.Context, accountID int64, rpmLimit int32) error {
+	return uc.checkRPM(ctx, accountID, "", rpmLimit)
+}
+
+// checkRPM implements CheckRPM on the counter of model (empty = the account-wide counter).
+func (uc *RateLimiterUseCase) checkRPM(ctx context.Context, accountID int64, model string, rpmLimit int32) error {
+	_, err := uc.reserveRPM(ctx, accountID, model, rpmLimit)
+	return err
+}
+
+// reserveRPM is checkRPM that also returns a release function undoing the admitted request's
+// increment, for callers that must roll it back when a later check rejects the request.
+// release is never nil; it does nothing when nothing was counted.
+func (uc *RateLimiterUseCase) reserveRPM(ctx context.Context, accountID int64, model string, rpmLimit int32) (release func(context.Context), err error) {
 	if rpmLimit <= 0 {
-		// No limit configured, allow request
-		return nil
+		// No limit configured, allow request (or reject everything if 0 means blocked)
+		return noRelease, uc.checkUnsetLimit("RPM", rpmLimit, 60)
+	}
+	if uc.rpmWindow == RPMWindowSliding {
+		return uc.checkRPMSliding(ctx, accountID, model, rpmLimit)
 	}
 
 	// Increment RPM counter
-	count, err := uc.repo.IncrementRPM(ctx, accountID)
+	count, err := uc.incrementRPM(ctx, accountID, model)
 	if err != nil {
 		// Redis failure: log warning and allow request (graceful degradation)
 		uc.logger.Warnf("Redis RPM check failed for account %d: %v (request allowed)", accountID, err)
-		return nil
+		return noRelease, nil
 	}
 
-	// Check if limit exceeded
-	if count > rpmLimit {
+	// Check if limit (plus burst allowance) exceeded
+	if count > rpmLimit+uc.rpmBurst {
 		uc.logger.Warnw("RPM limit exceeded",
 			"account_id", accountID,
+			"model", model,
 			"current", count,
-			"limit", rpmLimit)
-		return newRateLimitExceededError("RPM", count, rpmLimit, 60)
+			"limit", rpmLimit,
+			"burst", uc.rpmBurst)
+		return noRelease, newRateLimitExceededError("RPM", count, rpmLimit, 60)
 	}
 
-	return nil
+	return func(ctx context.Context) {
+		if err := uc.repo.DecrementModelRPM(ctx, accountID, model); err != nil {
+			uc.logger.Warnf("Failed to release RPM reservation for account %d: %v", accountID, err)
+		}
+	}, nil
 }
 
+// noRelease is the release function of a check that counted nothing.
+func noRelease(context.Context) {}
+
 // CheckTPM checks if the account has enough TPM (Tokens Per Minute) quota for the estimated tokens.
 // It uses Redis INCRBY with token estimation before request.
+// Estimates above the configured per-request maximum are rejected with TPM_ESTIMATE_TOO_LARGE.
 // Returns error if limit is exceeded, nil otherwise.
 // Redis degradation: on Redis failure, logs warning and allows request.
 func (uc *RateLimiterUseCase) CheckTPM(ctx context.Context, accountID int64, tpmLimit int32, estimatedTokens int32) error {
+	return uc.checkTPM(ctx, accountID, "", tpmLimit, estimatedTokens)
+}
+
+// checkTPM implements CheckTPM on the counter of model (empty = the account-wide counter).
+func (uc *RateLimiterUseCase) checkTPM(ctx context.Context, accountID int64, model string, tpmLimit int32, estimatedTokens int32) error {
+	if uc.maxTokensPerRequest > 0 && estimatedTokens > uc.maxTokensPerRequest {
+		uc.logger.Warnw("TPM estimate exceeds per-request maximum",
+			"account_id", accountID,
+			"estimated", estimatedTokens,
+			"max", uc.maxTokensPerRequest)
+		return errors.BadRequest("TPM_ESTIMATE_TOO_LARGE",
+			fmt.Sprintf("estimated tokens %d exceed per-request maximum %d", estimatedTokens, uc.maxTokensPerRequest))
+	}
+
 	if tpmLimit <= 0 {
-		// No limit configured, allow request
-		return nil
+		// No limit configured, allow request (or reject everything if 0 means blocked)
+		return uc.checkUnsetLimit("TPM", tpmLimit, 60)
 	}
 
 	if estimatedTokens <= 0 {
@@ -96,17 +201,18 @@ func (uc *RateLimiterUseCase) CheckTPM(ctx context.Context, accountID int64, tpm
 	}
 
 	// Get current TPM count
-	currentCount, err := uc.repo.GetTPMCount(ctx, accountID)
+	currentCount, err := uc.getTPMCount(ctx, accountID, model)
 	if err != nil {
 		// Redis failure: log warning and allow request
 		uc.logger.Warnf("Redis TPM get failed for account %d: %v (request allowed)", accountID, err)
 		return nil
 	}
 
-	// Check if adding estimated tokens would exceed limit
-	if currentCount+estimatedTokens > tpmLimit {
+	// Check if adding estimated tokens would exceed limit (int64 to avoid int32 wrap-around)
+	if int64(currentCount)+int64(estimatedTokens) > int64(tpmLimit) {
 		uc.logger.Warnw("TPM limit would be exceeded",
 			"account_id", accountID,
+			"model", model,
 			"current", currentCount,
 			"estimated", estimatedTokens,
 			"limit", tpmLimit)
@@ -114,7 +220,7 @@ func (uc *RateLimiterUseCase) CheckTPM(ctx context.Context, accountID int64, tpm
 	}
 
 	// Pre-increment TPM counter with estimated tokens
-	newCount, err := uc.repo.IncrementTPM(ctx, accountID, estimatedTokens)
+	newCount, err := uc.incrementTPM(ctx, accountID, model, estimatedTokens)
 	if err != nil {
 		// Redis failure: log warning and allow request
 		uc.logger.Warnf("Redis TPM increment failed for account %d: %v (request allowed)", accountID, err)
@@ -130,10 +236,141 @@ func (uc *RateLimiterUseCase) CheckTPM(ctx context.Context, accountID int64, tpm
 	return nil
 }
 
+// CheckGroupRPM checks the group-wide RPM cap shared by all accounts of a group
+// (a customer's total quota). Unlike CheckRPM, no burst allowance is applied.
+// Redis degradation: on Redis failure, logs warning and allows request.
+func (uc *RateLimiterUseCase) CheckGroupRPM(ctx context.Context, groupID int64, rpmLimit int32) error {
+	_, err := uc.reserveGroupRPM(ctx, groupID, rpmLimit)
+	return err
+}
+
+// reserveGroupRPM is CheckGroupRPM that also returns a release function undoing the admitted
+// request's increment (see reserveRPM). release is never nil.
+func (uc *RateLimiterUseCase) reserveGroupRPM(ctx context.Context, groupID int64, rpmLimit int32) (release func(context.Context), err error) {
+	if rpmLimit <= 0 {
+		return noRelease, uc.checkUnsetLimit("GROUP_RPM", rpmLimit, 60)
+	}
+
+	count, err := uc.repo.IncrementGroupRPM(ctx, groupID)
+	if err != nil {
+		uc.logger.Warnf("Redis group RPM check failed for group %d: %v (request allowed)", groupID, err)
+		return noRelease, nil
+	}
+
+	if count > rpmLimit {
+		uc.logger.Warnw("Group RPM limit exceeded",
+			"group_id", groupID,
+			"current", count,
+			"limit", rpmLimit)
+		return noRelease, newRateLimitExceededError("GROUP_RPM", count, rpmLimit, 60)
+	}
+
+	return func(ctx context.Context) {
+		if err := uc.repo.DecrementGroupRPM(ctx, groupID); err != nil {
+			uc.logger.Warnf("Failed to release group RPM reservation for group %d: %v", groupID, err)
+		}
+	}, nil
+}
+
+// CheckGroupTPM checks whether the group has enough TPM quota for the estimated tokens
+// and pre-increments the group counter when it does.
+// Redis degradation: on Redis failure, logs warning and allows request.
+func (uc *RateLimiterUseCase) CheckGroupTPM(ctx context.Context, groupID int64, tpmLimit int32, estimatedTokens int32) error {
+	if tpmLimit <= 0 {
+		return uc.checkUnsetLimit("GROUP_TPM", tpmLimit, 60)
+	}
+	if estimatedTokens <= 0 {
+		return nil
+	}
+
+	currentCount, err := uc.repo.GetGroupTPMCount(ctx, groupID)
+	if err != nil {
+		uc.logger.Warnf("Redis group TPM get failed for group %d: %v (request allowed)", groupID, err)
+		return nil
+	}
+
+	if int64(currentCount)+int64(estimatedTokens) > int64(tpmLimit) {
+		uc.logger.Warnw("Group TPM limit would be exceeded",
+			"group_id", groupID,
+			"current", currentCount,
+			"estimated", estimatedTokens,
+			"limit", tpmLimit)
+		return newRateLimitExceededError("GROUP_TPM", currentCount, tpmLimit, 60)
+	}
+
+	if _, err := uc.repo.IncrementGroupTPM(ctx, groupID, estimatedTokens); err != nil {
+		uc.logger.Warnf("Redis group TPM increment failed for group %d: %v (request allowed)", groupID, err)
+	}
+
+	return nil
+}
+
+// CheckRequestRPM checks the RPM limits for a request served by accountID.
+// When the request is attributed to a group, the group cap is checked first so a
+// customer over its total quota is rejected regardless of per-account headroom.
+// If the account rejects the request, the group counter is rolled back.
+func (uc *RateLimiterUseCase) CheckRequestRPM(ctx context.Context, group *AccountGroup, accountID int64, rpmLimit int32) error {
+	if group == nil {
+		return uc.CheckRPM(ctx, accountID, rpmLimit)
+	}
+
+	release, err := uc.reserveGroupRPM(ctx, group.ID, group.RpmLimit)
+	if err != nil {
+		return err
+	}
+	if err := uc.CheckRPM(ctx, accountID, rpmLimit); err != nil {
+		release(ctx)
+		return err
+	}
+	return nil
+}
+
+// CheckRequestTPM checks the TPM limits for a request served by accountID, group cap first.
+// If the account rejects the request, the tokens already reserved on the group are released.
+func (uc *RateLimiterUseCase) CheckRequestTPM(ctx context.Context, group *AccountGroup, accountID int64, tpmLimit int32, estimatedTokens int32) error {
+	if group == nil {
+		return uc.CheckTPM(ctx, accountID, tpmLimit, estimatedTokens)
+	}
+
+	if err := uc.CheckGroupTPM(ctx, group.ID, group.TpmLimit, estimatedTokens); err != nil {
+		return err
+	}
+
+	if err := uc.CheckTPM(ctx, accountID, tpmLimit, estimatedTokens); err != nil {
+		if group.TpmLimit > 0 && estimatedTokens > 0 {
+			if _, rollbackErr := uc.repo.IncrementGroupTPM(ctx, group.ID, -estimatedTokens); rollbackErr != nil {
+				uc.logger.Warnf("Failed to release group TPM reservation for group %d: %v", group.ID, rollbackErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateRequestTPM is UpdateTPM for a request admitted by CheckRequestTPM: when the group has a
+// TPM cap, the group counter is corrected by the same difference as the account counter.
+func (uc *RateLimiterUseCase) UpdateRequestTPM(ctx context.Context, group *AccountGroup, accountID int64, actualTokens int32, estimatedTokens int32) error {
+	if group != nil && group.TpmLimit > 0 && actualTokens > 0 {
+		if correction := actualTokens - estimatedTokens; correction != 0 {
+			if _, err := uc.repo.IncrementGroupTPM(ctx, group.ID, correction); err != nil {
+				// Redis failure: correction is best-effort, like the account counter
+				uc.logger.Warnf("Redis group TPM correction failed for group %d: %v (actual=%d estimated=%d)",
+					group.ID, err, actualTokens, estimatedTokens)
+			}
+		}
+	}
+	return uc.UpdateTPM(ctx, accountID, actualTokens, estimatedTokens)
+}
+
 // UpdateTPM updates the TPM counter with the actual token usage after request completion.
 // It calculates the difference between actual and estimated tokens and adjusts the counter.
 // This correction ensures accurate rate limiting based on real API responses.
 func (uc *RateLimiterUseCase) UpdateTPM(ctx context.Context, accountID int64, actualTokens int32, estimatedTokens int32) error {
+	return uc.updateTPM(ctx, accountID, "", actualTokens, estimatedTokens)
+}
+
+// updateTPM implements UpdateTPM on the counter of model (empty = the account-wide counter).
+func (uc *RateLimiterUseCase) updateTPM(ctx context.Context, accountID int64, model string, actualTokens int32, estimatedTokens int32) error {
 	if actualTokens <= 0 {
 		uc.logger.Warnf("Invalid actual tokens for account %d: %d", accountID, actualTokens)
 		return nil
@@ -148,7 +385,7 @@ func (uc *RateLimiterUseCase) UpdateTPM(ctx context.Context, accountID int64, ac
 	}
 
 	// Apply correction to TPM counter
-	_, err := uc.repo.IncrementTPM(ctx, accountID, correction)
+	_, err := uc.incrementTPM(ctx, accountID, model, correction)
 	if err != nil {
 		// Redis failure: log warning but don't return error (correction is best-effort)
 		uc.logger.Warnf("Redis TPM correction failed for account %d: %v (actual=%d estimated=%d)",
@@ -188,15 +425,31 @@ func (uc *RateLimiterUseCase) EstimateTokens(prompt string, maxOutputTokens int3
 	return estimatedTotal
 }
 
+// SetProviderConcurrencyLimit sets the org-wide concurrency ceiling for a provider,
+// shared by all of our accounts on that provider. limit < 0 removes the ceiling; a ceiling of 0
+// is kept and follows the zero-limit mode (no ceiling, or the provider is blocked).
+func (uc *RateLimiterUseCase) SetProviderConcurrencyLimit(provider data.AccountProvider, limit int32) {
+	if limit < 0 {
+		delete(uc.providerConcurrency, provider)
+		return
+	}
+	if uc.providerConcurrency == nil {
+		uc.providerConcurrency = make(map[data.AccountProvider]int32)
+	}
+	uc.providerConcurrency[provider] = limit
+}
+
 // AcquireConcurrencySlot attempts to acquire a concurrency slot for the request.
 // It uses Redis Sorted Set (ZADD + ZCARD) to track concurrent requests.
-// Maximum concurrency is hardcoded to 10 for MVP.
-// Returns error if concurrency limit is exceeded.
-func (uc *RateLimiterUseCase) AcquireConcurrencySlot(ctx context.Context, accountID int64, requestID string) error {
-	const maxConcurrency = 10
+// Maximum per-account concurrency is hardcoded to 10 for MVP. When the provider has a
+// configured ceiling, a provider-wide slot is acquired as well; if the provider rejects,
+// the account slot is rolled back.
+// Returns error if either concurrency limit is exceeded.
+func (uc *RateLimiterUseCase) AcquireConcurrencySlot(ctx context.Context, accountID int64, provider data.AccountProvider, requestID string) error {
+	const maxConcurrency = DefaultConcurrencyLimit
 
 	// Add request to concurrency set with current timestamp
-	timestamp := time.Now().Unix()
+	timestamp := uc.now().Unix()
 	if err := uc.repo.AddConcurrencyRequest(ctx, accountID, requestID, timestamp); err != nil {
 		// Redis failure: log warning and allow request
 		uc.logger.Warnf("Redis concurrency add failed for account %d: %v (request allowed)", accountID, err)
@@ -225,8 +478,15 @@ func (uc *RateLimiterUseCase) AcquireConcurrencySlot(ctx context.Context, accoun
 		return newRateLimitExceededError("Concurrency", count, maxConcurrency, 5)
 	}
 
+	if err := uc.acquireProviderSlot(ctx, provider, requestID, timestamp); err != nil {
+		// Roll back the account slot so a provider rejection does not leak account capacity
+		_ = uc.repo.RemoveConcurrencyRequest(ctx, accountID, requestID)
+		return err
+	}
+
 	uc.logger.Debugw("Concurrency slot acquired",
 		"account_id", accountID,
+		"provider", provider,
 		"request_id", requestID,
 		"current", count,
 		"limit", maxConcurrency)
@@ -234,31 +494,163 @@ func (uc *RateLimiterUseCase) AcquireConcurrencySlot(ctx context.Context, accoun
 	return nil
 }
 
+// acquireProviderSlot acquires a provider-wide slot when the provider has a configured ceiling.
+// Redis degradation: on Redis failure, logs warning and allows request.
+func (uc *RateLimiterUseCase) acquireProviderSlot(ctx context.Context, provider data.AccountProvider, requestID string, timestamp int64) error {
+	limit, ok := uc.providerConcurrency[provider]
+	if !ok {
+		return nil
+	}
+	if limit == 0 {
+		return uc.checkUnsetLimit("ProviderConcurrency", limit, 5)
+	}
+
+	if err := uc.repo.AddProviderConcurrencyRequest(ctx, provider, requestID, timestamp); err != nil {
+		uc.logger.Warnf("Redis provider concurrency add failed for provider %s: %v (request allowed)", provider, err)
+		return nil
+	}
+
+	count, err := uc.repo.GetProviderConcurrencyCount(ctx, provider)
+	if err != nil {
+		uc.logger.Warnf("Redis provider concurrency count failed for provider %s: %v (request allowed)", provider, err)
+		_ = uc.repo.RemoveProviderConcurrencyRequest(ctx, provider, requestID)
+		return nil
+	}
+
+	if count > limit {
+		_ = uc.repo.RemoveProviderConcurrencyRequest(ctx, provider, requestID)
+
+		uc.logger.Warnw("Provider concurrency limit exceeded",
+			"provider", provider,
+			"current", count,
+			"limit", limit)
+		return newRateLimitExceededError("ProviderConcurrency", count, limit, 5)
+	}
+
+	return nil
+}
+
+// AcquireGroupConcurrencySlot acquires the concurrency slots for a request served by accountID
+// on behalf of a group. The group-wide slot is checked first so a group at its cap is rejected
+// regardless of per-account headroom; the per-account (and provider) slot is acquired next and,
+// if it rejects, the group slot is rolled back so the rejection does not leak group capacity.
+// A nil group or a group without a cap behaves like AcquireConcurrencySlot (a cap of 0 rejects
+// every request instead in the blocked zero-limit mode).
+func (uc *RateLimiterUseCase) AcquireGroupConcurrencySlot(ctx context.Context, group *AccountGroup, accountID int64, provider data.AccountProvider, requestID string) error {
+	if group == nil || group.ConcurrencyLimit <= 0 {
+		if group != nil {
+			if err := uc.checkUnsetLimit("GroupConcurrency", group.ConcurrencyLimit, 5); err != nil {
+				return err
+			}
+		}
+		return uc.AcquireConcurrencySlot(ctx, accountID, provider, requestID)
+	}
+
+	if err := uc.acquireGroupSlot(ctx, group.ID, group.ConcurrencyLimit, requestID, uc.now().Unix()); err != nil {
+		return err
+	}
+
+	if err := uc.AcquireConcurrencySlot(ctx, accountID, provider, requestID); err != nil {
+		if rollbackErr := uc.repo.RemoveGroupConcurrencyRequest(ctx, group.ID, requestID); rollbackErr != nil {
+			uc.logger.Warnf("Failed to roll back group concurrency slot for group %d request %s: %v",
+				group.ID, requestID, rollbackErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// acquireGroupSlot acquires a group-wide slot against the group's concurrency cap.
+// Redis degradation: on Redis failure, logs warning and allows request.
+func (uc *RateLimiterUseCase) acquireGroupSlot(ctx context.Context, groupID int64, limit int32, requestID string, timestamp int64) error {
+	uc.concurrencyGroups.Store(groupID, struct{}{})
+
+	if err := uc.repo.AddGroupConcurrencyRequest(ctx, groupID, requestID, timestamp); err != nil {
+		uc.logger.Warnf("Redis group concurrency add failed for group %d: %v (request allowed)", groupID, err)
+		return nil
+	}
+
+	count, err := uc.repo.GetGroupConcurrencyCount(ctx, groupID)
+	if err != nil {
+		uc.logger.Warnf("Redis group concurrency count failed for group %d: %v (request allowed)", groupID, err)
+		_ = uc.repo.RemoveGroupConcurrencyRequest(ctx, groupID, requestID)
+		return nil
+	}
+
+	if count > limit {
+		_ = uc.repo.RemoveGroupConcurrencyRequest(ctx, groupID, requestID)
+
+		uc.logger.Warnw("Group concurrency limit exceeded",
+			"group_id", groupID,
+			"current", count,
+			"limit", limit)
+		return newRateLimitExceededError("GroupConcurrency", count, limit, 5)
+	}
+
+	return nil
+}
+
+// ReleaseGroupConcurrencySlot releases the slots acquired by AcquireGroupConcurrencySlot.
+// The group slot is released whenever a group is given, so lowering or removing the cap
+// while the request is in flight does not leave its slot behind.
+func (uc *RateLimiterUseCase) ReleaseGroupConcurrencySlot(ctx context.Context, group *AccountGroup, accountID int64, provider data.AccountProvider, requestID string) error {
+	if group != nil {
+		if err := uc.repo.RemoveGroupConcurrencyRequest(ctx, group.ID, requestID); err != nil {
+			uc.logger.Warnf("Failed to release group concurrency slot for group %d request %s: %v",
+				group.ID, requestID, err)
+		}
+	}
+	return uc.ReleaseConcurrencySlot(ctx, accountID, provider, requestID)
+}
+
 // ReleaseConcurrencySlot releases a concurrency slot after request completion.
+// The provider-wide slot is released too when the provider has a configured ceiling.
 // This should be called with defer to ensure cleanup even on errors.
-func (uc *RateLimiterUseCase) ReleaseConcurrencySlot(ctx context.Context, accountID int64, requestID string) error {
+func (uc *RateLimiterUseCase) ReleaseConcurrencySlot(ctx context.Context, accountID int64, provider data.AccountProvider, requestID string) error {
 	if err := uc.repo.RemoveConcurrencyRequest(ctx, accountID, requestID); err != nil {
 		// Log error but don't return it (cleanup is best-effort)
 		uc.logger.Warnf("Failed to release concurrency slot for account %d request %s: %v",
 			accountID, requestID, err)
-		return nil
+	}
+
+	if limit, ok := uc.providerConcurrency[provider]; ok && limit > 0 {
+		if err := uc.repo.RemoveProviderConcurrencyRequest(ctx, provider, requestID); err != nil {
+			uc.logger.Warnf("Failed to release provider concurrency slot for provider %s request %s: %v",
+				provider, requestID, err)
+		}
 	}
 
 	uc.logger.Debugw("Concurrency slot released",
 		"account_id", accountID,
+		"provider", provider,
 		"request_id", requestID)
 
 	return nil
 }
 
+// SetConcurrencyExpiry sets how long a concurrency slot may be held before cleanup reclaims it.
+// Slots of requests still running past this age are freed, so keep it above the longest
+// streaming request. d <= 0 restores DefaultConcurrencyExpiry.
+func (uc *RateLimiterUseCase) SetConcurrencyExpiry(d time.Duration) {
+	uc.concurrencyExpiry = d
+}
+
+// ConcurrencyExpiry returns the effective concurrency slot expiry.
+func (uc *RateLimiterUseCase) ConcurrencyExpiry() time.Duration {
+	if uc.concurrencyExpiry <= 0 {
+		return DefaultConcurrencyExpiry
+	}
+	return uc.concurrencyExpiry
+}
+
 // CleanupExpiredConcurrency cleans up expired concurrency requests for an account.
-// Requests older than 10 minutes are considered expired.
+// Requests not acquired or heartbeated within ConcurrencyExpiry (default 10 minutes), or within
+// the shorter stale threshold when heartbeats are enabled, are considered expired.
 // This should be called periodically by a cron job.
 func (uc *RateLimiterUseCase) CleanupExpiredConcurrency(ctx context.Context, accountID int64) error {
-	const expiryMinutes = 10
-
-	// Calculate cutoff timestamp (10 minutes ago)
-	expiredBefore := time.Now().Add(-expiryMinutes * time.Minute).Unix()
+	// Calculate cutoff timestamp
+	expiredBefore := uc.concurrencyCutoff()
 
 	if err := uc.repo.CleanupExpiredConcurrency(ctx, accountID, expiredBefore); err != nil {
 		uc.logger.Warnf("Failed to cleanup expired concurrency for account %d: %v", accountID, err)
@@ -282,6 +674,24 @@ func (uc *RateLimiterUseCase) CleanupExpiredConcurrencyForAllAccounts(ctx contex
 		cleanedCount++
 	}
 
+	// Provider-wide and group-wide sets accumulate stale entries the same way per-account sets do
+	expiredBefore := uc.concurrencyCutoff()
+	for provider, limit := range uc.providerConcurrency {
+		if limit == 0 {
+			continue
+		}
+		if err := uc.repo.CleanupExpiredProviderConcurrency(ctx, provider, expiredBefore); err != nil {
+			uc.logger.Warnf("Failed to cleanup provider %s: %v", provider, err)
+		}
+	}
+	uc.concurrencyGroups.Range(func(key, _ any) bool {
+		groupID := key.(int64)
+		if err := uc.repo.CleanupExpiredGroupConcurrency(ctx, groupID, expiredBefore); err != nil {
+			uc.logger.Warnf("Failed to cleanup group %d: %v", groupID, err)
+		}
+		return true
+	})
+
 	uc.logger.Infow("Concurrency cleanup completed",
 		"total_accounts", len(accountIDs),
 		"cleaned", cleanedCount)
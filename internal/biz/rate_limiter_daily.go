@@ -0,0 +1,120 @@
+package biz
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"QuotaLane/internal/data"
+)
+
+// DailyTokenUsage is an account's token consumption for the current UTC day.
+type DailyTokenUsage struct {
+	Used      int64     // 当日已消耗（含已预占）的 Token 数
+	Limit     int32     // 每日上限（0 表示不限制）
+	Remaining int64     // 当日剩余额度（不限制时为 -1）
+	ResetsAt  time.Time // 下一个 UTC 零点，届时用量清零
+}
+
+// CheckDailyTokens reserves tokens against the account's daily (UTC) token quota and rejects the
+// request with RATE_LIMIT_EXCEEDED_DAILY_TOKENS if it would push the day's total over limit.
+// The day's total is incremented first and rolled back on rejection, so concurrent requests can't
+// both slip under the cap. limit < 0 means no daily quota; limit == 0 follows the zero-limit mode
+// (no quota by default, every request rejected in blocked mode).
+// Redis degradation: on Redis failure, logs warning and allows request.
+func (uc *RateLimiterUseCase) CheckDailyTokens(ctx context.Context, accountID int64, limit int32, tokens int32) error {
+	now := uc.now()
+	if limit <= 0 {
+		return uc.checkUnsetLimit("DAILY_TOKENS", limit, secondsUntilUTCMidnight(now))
+	}
+	if tokens <= 0 {
+		return nil
+	}
+
+	total, err := uc.repo.IncrementDailyTokens(ctx, accountID, now, int64(tokens))
+	if err != nil {
+		uc.logger.Warnf("Redis daily token check failed for account %d: %v (request allowed)", accountID, err)
+		return nil
+	}
+
+	if total <= int64(limit) {
+		return nil
+	}
+
+	if _, err := uc.repo.IncrementDailyTokens(ctx, accountID, now, -int64(tokens)); err != nil {
+		uc.logger.Warnf("Failed to release daily token reservation for account %d: %v", accountID, err)
+	}
+
+	used := total - int64(tokens)
+	uc.logger.Warnw("Daily token quota would be exceeded",
+		"account_id", accountID,
+		"used", used,
+		"requested", tokens,
+		"limit", limit)
+	return newRateLimitExceededError("DAILY_TOKENS", clampInt32(used), limit, secondsUntilUTCMidnight(now))
+}
+
+// secondsUntilUTCMidnight returns the Retry-After of a daily quota rejection: the seconds until the day's total resets.
+func secondsUntilUTCMidnight(now time.Time) int64 {
+	return int64(math.Ceil(data.NextUTCMidnight(now).Sub(now).Seconds()))
+}
+
+// UpdateDailyTokens corrects the day's total of a request admitted by CheckDailyTokens with its
+// actual token usage, like UpdateTPM does for the TPM counter. limit must be the one passed to
+// CheckDailyTokens: without a daily quota nothing was reserved and nothing is corrected.
+// The total never drops below zero (a request reserved before UTC midnight is corrected on the
+// new day). Redis failures are logged; the correction is best-effort.
+func (uc *RateLimiterUseCase) UpdateDailyTokens(ctx context.Context, accountID int64, limit int32, actualTokens int32, estimatedTokens int32) error {
+	if limit <= 0 || actualTokens <= 0 || estimatedTokens <= 0 {
+		return nil
+	}
+	correction := int64(actualTokens) - int64(estimatedTokens)
+	if correction == 0 {
+		return nil
+	}
+
+	now := uc.now()
+	total, err := uc.repo.IncrementDailyTokens(ctx, accountID, now, correction)
+	if err != nil {
+		uc.logger.Warnf("Redis daily token correction failed for account %d: %v (actual=%d estimated=%d)",
+			accountID, err, actualTokens, estimatedTokens)
+		return nil
+	}
+	if total < 0 {
+		if _, err := uc.repo.IncrementDailyTokens(ctx, accountID, now, -total); err != nil {
+			uc.logger.Warnf("Failed to reset negative daily token total for account %d: %v", accountID, err)
+		}
+	}
+	return nil
+}
+
+// GetDailyTokenUsage returns the account's token usage for the current UTC day against limit
+// (the account's DailyTokenLimit), for dashboards showing the remaining quota. A limit of 0 in
+// blocked zero-limit mode reports no remaining quota, matching CheckDailyTokens.
+func (uc *RateLimiterUseCase) GetDailyTokenUsage(ctx context.Context, accountID int64, limit int32) (*DailyTokenUsage, error) {
+	now := uc.now()
+	used, err := uc.repo.GetDailyTokenCount(ctx, accountID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &DailyTokenUsage{Used: used, Limit: limit, Remaining: -1, ResetsAt: data.NextUTCMidnight(now)}
+	if limit > 0 || limitBlocked(limit, uc.zeroLimit) {
+		usage.Remaining = int64(limit) - used
+		if usage.Remaining < 0 {
+			usage.Remaining = 0
+		}
+	}
+	return usage, nil
+}
+
+// clampInt32 clamps v to the int32 range.
+func clampInt32(v int64) int32 {
+	if v > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	if v < math.MinInt32 {
+		return math.MinInt32
+	}
+	return int32(v) // #nosec G115 -- range is checked above
+}
@@ -0,0 +1,122 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"QuotaLane/internal/data"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDailyTokens(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	clock := newFakeClock()
+	uc.SetClock(clock)
+	ctx := context.Background()
+
+	require.NoError(t, uc.CheckDailyTokens(ctx, 1, 1000, 600))
+
+	err := uc.CheckDailyTokens(ctx, 1, 1000, 500)
+	require.Error(t, err)
+	assert.Equal(t, "RATE_LIMIT_EXCEEDED_DAILY_TOKENS", kerrors.Reason(err))
+
+	// The rejected request released its reservation
+	usage, err := uc.GetDailyTokenUsage(ctx, 1, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(600), usage.Used)
+	assert.Equal(t, int64(400), usage.Remaining)
+	assert.Equal(t, data.NextUTCMidnight(clock.Now()), usage.ResetsAt)
+
+	require.NoError(t, uc.CheckDailyTokens(ctx, 1, 1000, 400))
+	assert.Error(t, uc.CheckDailyTokens(ctx, 1, 1000, 1))
+
+	// Other accounts and unlimited accounts are unaffected
+	assert.NoError(t, uc.CheckDailyTokens(ctx, 2, 1000, 1000))
+	assert.NoError(t, uc.CheckDailyTokens(ctx, 1, 0, 5000))
+
+	// The quota resets at the next UTC midnight
+	clock.Advance(data.NextUTCMidnight(clock.Now()).Sub(clock.Now()))
+	usage, err = uc.GetDailyTokenUsage(ctx, 1, 1000)
+	require.NoError(t, err)
+	assert.Zero(t, usage.Used)
+	assert.NoError(t, uc.CheckDailyTokens(ctx, 1, 1000, 900))
+}
+
+func TestUpdateDailyTokens(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	clock := newFakeClock()
+	uc.SetClock(clock)
+	ctx := context.Background()
+
+	// Reserved 600 but only 100 were used: the rest of the day's quota is given back
+	require.NoError(t, uc.CheckDailyTokens(ctx, 1, 1000, 600))
+	require.NoError(t, uc.UpdateDailyTokens(ctx, 1, 1000, 100, 600))
+	usage, err := uc.GetDailyTokenUsage(ctx, 1, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), usage.Used)
+	require.NoError(t, uc.CheckDailyTokens(ctx, 1, 1000, 900))
+
+	// Under-estimates are charged in full
+	require.NoError(t, uc.UpdateDailyTokens(ctx, 1, 1000, 950, 900))
+	usage, err = uc.GetDailyTokenUsage(ctx, 1, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1050), usage.Used)
+	assert.Zero(t, usage.Remaining)
+
+	// Without a daily quota nothing was reserved, so nothing is corrected
+	require.NoError(t, uc.UpdateDailyTokens(ctx, 2, 0, 100, 600))
+	usage, err = uc.GetDailyTokenUsage(ctx, 2, 0)
+	require.NoError(t, err)
+	assert.Zero(t, usage.Used)
+
+	// A reservation from before UTC midnight never drives the new day's total negative
+	require.NoError(t, uc.CheckDailyTokens(ctx, 3, 1000, 600))
+	clock.Advance(data.NextUTCMidnight(clock.Now()).Sub(clock.Now()))
+	require.NoError(t, uc.UpdateDailyTokens(ctx, 3, 1000, 100, 600))
+	usage, err = uc.GetDailyTokenUsage(ctx, 3, 1000)
+	require.NoError(t, err)
+	assert.Zero(t, usage.Used)
+}
+
+func TestGetDailyTokenUsage_Unlimited(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+
+	usage, err := uc.GetDailyTokenUsage(context.Background(), 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), usage.Remaining)
+}
+
+// TestDailyTokens_ZeroLimitBlocked tests that in blocked zero-limit mode a daily quota of 0 rejects every
+// request without reserving tokens and reports no remaining quota, while a negative limit still means no quota.
+func TestDailyTokens_ZeroLimitBlocked(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	uc.SetZeroLimitMode(ZeroLimitBlocked)
+	ctx := context.Background()
+
+	err := uc.CheckDailyTokens(ctx, 1, 0, 100)
+	require.Error(t, err)
+	assert.Equal(t, "RATE_LIMIT_EXCEEDED_DAILY_TOKENS", kerrors.Reason(err))
+	assert.Error(t, uc.CheckDailyTokens(ctx, 1, 0, 0), "even requests without an estimate are rejected")
+	assert.NoError(t, uc.CheckDailyTokens(ctx, 1, -1, 100))
+
+	usage, err := uc.GetDailyTokenUsage(ctx, 1, 0)
+	require.NoError(t, err)
+	assert.Zero(t, usage.Used)
+	assert.Zero(t, usage.Remaining)
+}
+
+func TestCheckDailyTokens_RedisFailureAllowsRequest(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("IncrementDailyTokens", ctx, int64(123), mock.Anything, int64(100)).
+		Return(int64(0), errors.New("redis down"))
+
+	assert.NoError(t, uc.CheckDailyTokens(ctx, 123, 50, 100))
+}
@@ -0,0 +1,251 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newGroupRateLimiter(t *testing.T) *RateLimiterUseCase {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return NewRateLimiterUseCase(data.NewRateLimitRepo(rdb, log.DefaultLogger), log.DefaultLogger)
+}
+
+// TestCheckRequestRPM_GroupCapRejects tests that the group cap rejects requests even though
+// each account is well within its own RPM limit.
+func TestCheckRequestRPM_GroupCapRejects(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	ctx := context.Background()
+	group := &AccountGroup{ID: 7, RpmLimit: 3}
+
+	// Requests spread over two accounts, each with plenty of per-account headroom
+	require.NoError(t, uc.CheckRequestRPM(ctx, group, 1, 100))
+	require.NoError(t, uc.CheckRequestRPM(ctx, group, 2, 100))
+	require.NoError(t, uc.CheckRequestRPM(ctx, group, 1, 100))
+
+	err := uc.CheckRequestRPM(ctx, group, 2, 100)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "RATE_LIMIT_EXCEEDED_GROUP_RPM")
+
+	// The same accounts outside the group are unaffected
+	assert.NoError(t, uc.CheckRequestRPM(ctx, nil, 2, 100))
+}
+
+// TestCheckRequestRPM_NoGroupLimit tests that a group without a cap defers to per-account limits.
+func TestCheckRequestRPM_NoGroupLimit(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	ctx := context.Background()
+	group := &AccountGroup{ID: 7}
+
+	require.NoError(t, uc.CheckRequestRPM(ctx, group, 1, 1))
+	err := uc.CheckRequestRPM(ctx, group, 1, 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "RATE_LIMIT_EXCEEDED_RPM")
+}
+
+// TestCheckRequestRPM_AccountRejectReleasesGroup tests that a request rejected by its account does
+// not count against the group cap.
+func TestCheckRequestRPM_AccountRejectReleasesGroup(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	ctx := context.Background()
+	group := &AccountGroup{ID: 7, RpmLimit: 2}
+
+	require.NoError(t, uc.CheckRequestRPM(ctx, group, 1, 1))
+
+	// Account 1 is at its own limit; the rejected requests must not use up the group quota
+	for i := 0; i < 3; i++ {
+		err := uc.CheckRequestRPM(ctx, group, 1, 1)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "RATE_LIMIT_EXCEEDED_RPM")
+	}
+
+	assert.NoError(t, uc.CheckRequestRPM(ctx, group, 2, 100))
+}
+
+// TestCheckRequestTPM_GroupCapRejects tests the group TPM cap across accounts.
+func TestCheckRequestTPM_GroupCapRejects(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	ctx := context.Background()
+	group := &AccountGroup{ID: 7, TpmLimit: 1000}
+
+	require.NoError(t, uc.CheckRequestTPM(ctx, group, 1, 10000, 600))
+
+	err := uc.CheckRequestTPM(ctx, group, 2, 10000, 600)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "RATE_LIMIT_EXCEEDED_GROUP_TPM")
+
+	assert.NoError(t, uc.CheckRequestTPM(ctx, group, 2, 10000, 400))
+}
+
+// TestCheckRequestTPM_AccountRejectReleasesGroup tests that tokens reserved on the group are
+// released when the account itself rejects the request.
+func TestCheckRequestTPM_AccountRejectReleasesGroup(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	ctx := context.Background()
+	group := &AccountGroup{ID: 7, TpmLimit: 1000}
+
+	mockRepo.On("GetGroupTPMCount", ctx, int64(7)).Return(int32(0), nil)
+	mockRepo.On("IncrementGroupTPM", ctx, int64(7), int32(200)).Return(int32(200), nil).Once()
+	mockRepo.On("GetTPMCount", ctx, int64(1)).Return(int32(90), nil)
+	mockRepo.On("IncrementGroupTPM", ctx, int64(7), int32(-200)).Return(int32(0), nil).Once()
+
+	err := uc.CheckRequestTPM(ctx, group, 1, 100, 200)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "RATE_LIMIT_EXCEEDED_TPM")
+	mockRepo.AssertExpectations(t)
+}
+
+// TestUpdateRequestTPM_CorrectsGroupCounter tests that the actual usage is reconciled on the
+// group counter as well as on the account counter.
+func TestUpdateRequestTPM_CorrectsGroupCounter(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	ctx := context.Background()
+	group := &AccountGroup{ID: 7, TpmLimit: 1000}
+
+	// Estimated 600 but only 100 were used: the group has 900 left, not 400
+	require.NoError(t, uc.CheckRequestTPM(ctx, group, 1, 10000, 600))
+	require.NoError(t, uc.UpdateRequestTPM(ctx, group, 1, 100, 600))
+	assert.NoError(t, uc.CheckRequestTPM(ctx, group, 2, 10000, 900))
+
+	groupCount, err := uc.repo.GetGroupTPMCount(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1000), groupCount)
+	accountCount, err := uc.repo.GetTPMCount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(100), accountCount)
+}
+
+// TestUpdateRequestTPM_NoGroupLimit tests that groups without a TPM cap have no counter to correct.
+func TestUpdateRequestTPM_NoGroupLimit(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("IncrementTPM", ctx, int64(1), int32(-500)).Return(int32(100), nil).Once()
+
+	require.NoError(t, uc.UpdateRequestTPM(ctx, &AccountGroup{ID: 7}, 1, 100, 600))
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "IncrementGroupTPM", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestCheckGroupRPM_RedisError tests graceful degradation when Redis is unavailable.
+func TestCheckGroupRPM_RedisError(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("IncrementGroupRPM", ctx, int64(7)).Return(int32(0), errors.New("connection refused"))
+
+	assert.NoError(t, uc.CheckGroupRPM(ctx, 7, 10))
+	mockRepo.AssertNotCalled(t, "IncrementRPM", mock.Anything, mock.Anything)
+}
+
+// TestAcquireGroupConcurrencySlot_GroupCapRejects tests that the group cap rejects a request even
+// though the serving account still has free slots.
+func TestAcquireGroupConcurrencySlot_GroupCapRejects(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	ctx := context.Background()
+	group := &AccountGroup{ID: 7, ConcurrencyLimit: 2}
+
+	require.NoError(t, uc.AcquireGroupConcurrencySlot(ctx, group, 1, data.ProviderClaudeConsole, "req-1"))
+	require.NoError(t, uc.AcquireGroupConcurrencySlot(ctx, group, 2, data.ProviderClaudeConsole, "req-2"))
+
+	err := uc.AcquireGroupConcurrencySlot(ctx, group, 3, data.ProviderClaudeConsole, "req-3")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "RATE_LIMIT_EXCEEDED_GroupConcurrency")
+
+	// The rejected request holds no account slot; account 3 is still free outside the group
+	require.NoError(t, uc.AcquireConcurrencySlot(ctx, 3, data.ProviderClaudeConsole, "req-4"))
+
+	// Releasing a grouped request frees group capacity
+	require.NoError(t, uc.ReleaseGroupConcurrencySlot(ctx, group, 1, data.ProviderClaudeConsole, "req-1"))
+	assert.NoError(t, uc.AcquireGroupConcurrencySlot(ctx, group, 3, data.ProviderClaudeConsole, "req-3"))
+}
+
+// TestAcquireGroupConcurrencySlot_AccountRejectReleasesGroup tests that the group slot is rolled
+// back when the account itself is at its concurrency limit.
+func TestAcquireGroupConcurrencySlot_AccountRejectReleasesGroup(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	ctx := context.Background()
+	group := &AccountGroup{ID: 7, ConcurrencyLimit: 5}
+
+	mockRepo.On("AddGroupConcurrencyRequest", ctx, int64(7), "req-1", mock.AnythingOfType("int64")).Return(nil)
+	mockRepo.On("GetGroupConcurrencyCount", ctx, int64(7)).Return(int32(1), nil)
+	mockRepo.On("AddConcurrencyRequest", ctx, int64(1), "req-1", mock.AnythingOfType("int64")).Return(nil)
+	mockRepo.On("GetConcurrencyCount", ctx, int64(1)).Return(int32(DefaultConcurrencyLimit+1), nil)
+	mockRepo.On("RemoveConcurrencyRequest", ctx, int64(1), "req-1").Return(nil).Once()
+	mockRepo.On("RemoveGroupConcurrencyRequest", ctx, int64(7), "req-1").Return(nil).Once()
+
+	err := uc.AcquireGroupConcurrencySlot(ctx, group, 1, data.ProviderClaudeConsole, "req-1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "RATE_LIMIT_EXCEEDED_Concurrency")
+	mockRepo.AssertExpectations(t)
+}
+
+// TestAcquireGroupConcurrencySlot_ProviderRejectReleasesBoth tests that a provider rejection rolls
+// back both the account and the group slot.
+func TestAcquireGroupConcurrencySlot_ProviderRejectReleasesBoth(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	ctx := context.Background()
+	uc.SetProviderConcurrencyLimit(data.ProviderClaudeConsole, 1)
+	group := &AccountGroup{ID: 7, ConcurrencyLimit: 5}
+
+	require.NoError(t, uc.AcquireConcurrencySlot(ctx, 9, data.ProviderClaudeConsole, "outside"))
+
+	err := uc.AcquireGroupConcurrencySlot(ctx, group, 1, data.ProviderClaudeConsole, "req-1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "RATE_LIMIT_EXCEEDED_ProviderConcurrency")
+
+	groupCount, err := uc.repo.GetGroupConcurrencyCount(ctx, 7)
+	require.NoError(t, err)
+	assert.Zero(t, groupCount)
+	accountCount, err := uc.repo.GetConcurrencyCount(ctx, 1)
+	require.NoError(t, err)
+	assert.Zero(t, accountCount)
+}
+
+// TestAcquireGroupConcurrencySlot_NoGroupLimit tests that a group without a cap only takes the
+// per-account slot.
+func TestAcquireGroupConcurrencySlot_NoGroupLimit(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("AddConcurrencyRequest", ctx, int64(1), "req-1", mock.AnythingOfType("int64")).Return(nil)
+	mockRepo.On("GetConcurrencyCount", ctx, int64(1)).Return(int32(1), nil)
+
+	require.NoError(t, uc.AcquireGroupConcurrencySlot(ctx, &AccountGroup{ID: 7}, 1, data.ProviderClaudeConsole, "req-1"))
+	mockRepo.AssertNotCalled(t, "AddGroupConcurrencyRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestCleanupExpiredConcurrency_GroupSlots tests that the cleanup job reclaims stale group slots.
+func TestCleanupExpiredConcurrency_GroupSlots(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	clock := newFakeClock()
+	uc.SetClock(clock)
+	ctx := context.Background()
+	group := &AccountGroup{ID: 7, ConcurrencyLimit: 1}
+
+	require.NoError(t, uc.AcquireGroupConcurrencySlot(ctx, group, 1, data.ProviderClaudeConsole, "leaked"))
+	require.Error(t, uc.AcquireGroupConcurrencySlot(ctx, group, 2, data.ProviderClaudeConsole, "req-2"))
+
+	clock.Advance(DefaultConcurrencyExpiry + time.Minute)
+	_, err := uc.CleanupExpiredConcurrencyForAllAccounts(ctx, []int64{1})
+	require.NoError(t, err)
+
+	assert.NoError(t, uc.AcquireGroupConcurrencySlot(ctx, group, 2, data.ProviderClaudeConsole, "req-2"))
+}
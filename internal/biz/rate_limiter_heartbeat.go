@@ -0,0 +1,96 @@
+package biz
+
+import (
+	"context"
+	"time"
+
+	"QuotaLane/internal/data"
+)
+
+// concurrencyHeartbeatsPerStaleWindow 每个 stale 窗口内发送的心跳次数；
+// 允许连续丢失两次心跳（如 Redis 短暂抖动）而不被误回收
+const concurrencyHeartbeatsPerStaleWindow = 3
+
+// SetConcurrencyStaleAfter enables slot heartbeats: a slot whose holder has not heartbeated for d
+// is reclaimed by the cleanup job, so slots leaked by a crashed process are freed long before
+// ConcurrencyExpiry. Requests must then hold their slots with StartConcurrencyHeartbeat; the
+// heartbeat is what tells a long-running request apart from a leaked slot.
+// d <= 0, or d >= ConcurrencyExpiry, disables the stale threshold.
+func (uc *RateLimiterUseCase) SetConcurrencyStaleAfter(d time.Duration) {
+	uc.concurrencyStaleAfter = d
+}
+
+// ConcurrencyStaleAfter returns the effective heartbeat stale threshold (0 = disabled).
+func (uc *RateLimiterUseCase) ConcurrencyStaleAfter() time.Duration {
+	if uc.concurrencyStaleAfter <= 0 || uc.concurrencyStaleAfter >= uc.ConcurrencyExpiry() {
+		return 0
+	}
+	return uc.concurrencyStaleAfter
+}
+
+// ConcurrencyHeartbeatInterval returns how often StartConcurrencyHeartbeat refreshes a slot
+// (0 when heartbeats are disabled).
+func (uc *RateLimiterUseCase) ConcurrencyHeartbeatInterval() time.Duration {
+	return uc.ConcurrencyStaleAfter() / concurrencyHeartbeatsPerStaleWindow
+}
+
+// concurrencyCutoff returns the slot timestamp (Unix seconds) at or below which cleanup reclaims a slot.
+func (uc *RateLimiterUseCase) concurrencyCutoff() int64 {
+	window := uc.ConcurrencyExpiry()
+	if staleAfter := uc.ConcurrencyStaleAfter(); staleAfter > 0 {
+		window = staleAfter
+	}
+	return uc.now().Add(-window).Unix()
+}
+
+// HeartbeatConcurrencySlot refreshes the timestamp of the slots held by requestID (per-account,
+// provider-wide when the provider has a cap, group-wide when group has a cap) so cleanup keeps them.
+// Slots already reclaimed are not re-acquired.
+func (uc *RateLimiterUseCase) HeartbeatConcurrencySlot(ctx context.Context, group *AccountGroup, accountID int64, provider data.AccountProvider, requestID string) error {
+	var heartbeatProvider data.AccountProvider
+	if limit, ok := uc.providerConcurrency[provider]; ok && limit > 0 {
+		heartbeatProvider = provider
+	}
+	var groupID int64
+	if group != nil && group.ConcurrencyLimit > 0 {
+		groupID = group.ID
+	}
+
+	if err := uc.repo.HeartbeatConcurrencyRequest(ctx, accountID, heartbeatProvider, groupID, requestID, uc.now().Unix()); err != nil {
+		uc.logger.Warnf("Failed to heartbeat concurrency slot for account %d request %s: %v", accountID, requestID, err)
+		return err
+	}
+	return nil
+}
+
+// StartConcurrencyHeartbeat heartbeats the slots held by requestID every ConcurrencyHeartbeatInterval
+// until the returned stop func is called or ctx is done. Call it right after acquiring the slot and
+// stop it before releasing. A no-op when heartbeats are disabled.
+func (uc *RateLimiterUseCase) StartConcurrencyHeartbeat(ctx context.Context, group *AccountGroup, accountID int64, provider data.AccountProvider, requestID string) (stop func()) {
+	interval := uc.ConcurrencyHeartbeatInterval()
+	if interval <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// Failures are logged; the next tick retries before the slot goes stale
+				_ = uc.HeartbeatConcurrencySlot(ctx, group, accountID, provider, requestID)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
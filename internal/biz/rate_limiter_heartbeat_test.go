@@ -0,0 +1,81 @@
+package biz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCleanupExpiredConcurrency_StaleThreshold tests that a heartbeated slot survives the stale
+// threshold while a slot that stopped heartbeating is reclaimed long before ConcurrencyExpiry.
+func TestCleanupExpiredConcurrency_StaleThreshold(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	clock := newFakeClock()
+	uc.SetClock(clock)
+	uc.SetConcurrencyStaleAfter(30 * time.Second)
+	ctx := context.Background()
+	accountID := int64(1)
+
+	require.NoError(t, uc.AcquireConcurrencySlot(ctx, accountID, data.ProviderClaudeConsole, "long-running"))
+	require.NoError(t, uc.AcquireConcurrencySlot(ctx, accountID, data.ProviderClaudeConsole, "leaked"))
+
+	// Only the live request heartbeats, each well within the stale threshold
+	for i := 0; i < 4; i++ {
+		clock.Advance(20 * time.Second)
+		require.NoError(t, uc.HeartbeatConcurrencySlot(ctx, nil, accountID, data.ProviderClaudeConsole, "long-running"))
+	}
+	require.NoError(t, uc.CleanupExpiredConcurrency(ctx, accountID))
+
+	count, err := uc.repo.GetConcurrencyCount(ctx, accountID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), count, "leaked slot is reclaimed, heartbeated slot survives")
+
+	// A heartbeat for the reclaimed slot must not bring it back
+	require.NoError(t, uc.HeartbeatConcurrencySlot(ctx, nil, accountID, data.ProviderClaudeConsole, "leaked"))
+	count, err = uc.repo.GetConcurrencyCount(ctx, accountID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+}
+
+// TestCleanupExpiredConcurrency_StaleThresholdGroupSlots tests that heartbeats also keep the group slot.
+func TestCleanupExpiredConcurrency_StaleThresholdGroupSlots(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	clock := newFakeClock()
+	uc.SetClock(clock)
+	uc.SetConcurrencyStaleAfter(30 * time.Second)
+	ctx := context.Background()
+	group := &AccountGroup{ID: 7, ConcurrencyLimit: 1}
+
+	require.NoError(t, uc.AcquireGroupConcurrencySlot(ctx, group, 1, data.ProviderClaudeConsole, "live"))
+	clock.Advance(20 * time.Second)
+	require.NoError(t, uc.HeartbeatConcurrencySlot(ctx, group, 1, data.ProviderClaudeConsole, "live"))
+	clock.Advance(20 * time.Second)
+	_, err := uc.CleanupExpiredConcurrencyForAllAccounts(ctx, []int64{1})
+	require.NoError(t, err)
+	assert.Error(t, uc.AcquireGroupConcurrencySlot(ctx, group, 2, data.ProviderClaudeConsole, "req-2"), "group slot still held")
+
+	clock.Advance(31 * time.Second)
+	_, err = uc.CleanupExpiredConcurrencyForAllAccounts(ctx, []int64{1})
+	require.NoError(t, err)
+	assert.NoError(t, uc.AcquireGroupConcurrencySlot(ctx, group, 2, data.ProviderClaudeConsole, "req-2"))
+}
+
+// TestConcurrencyStaleAfter_Disabled tests that the threshold is ignored unless it is shorter than
+// ConcurrencyExpiry, and that StartConcurrencyHeartbeat is then a no-op.
+func TestConcurrencyStaleAfter_Disabled(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	assert.Zero(t, uc.ConcurrencyStaleAfter())
+
+	uc.SetConcurrencyStaleAfter(DefaultConcurrencyExpiry)
+	assert.Zero(t, uc.ConcurrencyStaleAfter())
+	assert.Zero(t, uc.ConcurrencyHeartbeatInterval())
+	uc.StartConcurrencyHeartbeat(context.Background(), nil, 1, data.ProviderClaudeConsole, "req-1")()
+
+	uc.SetConcurrencyStaleAfter(30 * time.Second)
+	assert.Equal(t, 10*time.Second, uc.ConcurrencyHeartbeatInterval())
+}
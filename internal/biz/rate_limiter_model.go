@@ -0,0 +1,106 @@
+package biz
+
+import (
+	"context"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/metadata"
+)
+
+// ModelRateLimits resolves the per-model RPM/TPM caps of a request for model on account.
+// Models listed in metadata model_limits return their own caps and counterModel, their normalized
+// name, under which they are counted on separate Redis counters. A zero cap means the model has no
+// cap of its own on that dimension. Listed models are counted on the account-wide counters as well,
+// so the account-level RpmLimit/TpmLimit still bound the account's total traffic. Other models, an
+// empty model or unparseable metadata have no per-model caps (counterModel is empty).
+func ModelRateLimits(account *data.Account, model string) (rpm, tpm int32, counterModel string) {
+	meta, err := data.ParseMetadata(account.Metadata)
+	if err != nil {
+		return 0, 0, ""
+	}
+
+	limit, ok := meta.ModelLimitFor(model)
+	if !ok {
+		return 0, 0, ""
+	}
+	return limit.RPM, limit.TPM, metadata.NormalizeModelName(model)
+}
+
+// CheckModelRPM is CheckRPM for a request to model. A model with its own RPM cap (ModelRateLimits)
+// is checked on its counter first and then on the account-wide counter against the account-level
+// limit; if the account rejects the request, the model counter is rolled back. Other models are
+// checked on the account-wide counter only, exactly like CheckRPM.
+func (uc *RateLimiterUseCase) CheckModelRPM(ctx context.Context, account *data.Account, model string) error {
+	modelLimit, _, counterModel := ModelRateLimits(account, model)
+	if counterModel == "" || modelLimit <= 0 {
+		return uc.CheckRPM(ctx, account.ID, account.RpmLimit)
+	}
+
+	release, err := uc.reserveRPM(ctx, account.ID, counterModel, modelLimit)
+	if err != nil {
+		return err
+	}
+	if err := uc.CheckRPM(ctx, account.ID, account.RpmLimit); err != nil {
+		release(ctx)
+		return err
+	}
+	return nil
+}
+
+// CheckModelTPM is CheckTPM for a request to model. A model with its own TPM cap reserves the
+// estimate on its counter and on the account-wide counter (against the account-level limit);
+// if the account rejects the request, the model reservation is released.
+func (uc *RateLimiterUseCase) CheckModelTPM(ctx context.Context, account *data.Account, model string, estimatedTokens int32) error {
+	_, modelLimit, counterModel := ModelRateLimits(account, model)
+	if counterModel == "" || modelLimit <= 0 {
+		return uc.CheckTPM(ctx, account.ID, account.TpmLimit, estimatedTokens)
+	}
+
+	if err := uc.checkTPM(ctx, account.ID, counterModel, modelLimit, estimatedTokens); err != nil {
+		return err
+	}
+	if err := uc.CheckTPM(ctx, account.ID, account.TpmLimit, estimatedTokens); err != nil {
+		if estimatedTokens > 0 {
+			if _, rollbackErr := uc.incrementTPM(ctx, account.ID, counterModel, -estimatedTokens); rollbackErr != nil {
+				uc.logger.Warnf("Failed to release model TPM reservation for account %d: %v", account.ID, rollbackErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateModelTPM is UpdateTPM for a request admitted by CheckModelTPM; it corrects the same counters.
+func (uc *RateLimiterUseCase) UpdateModelTPM(ctx context.Context, account *data.Account, model string, actualTokens int32, estimatedTokens int32) error {
+	_, modelLimit, counterModel := ModelRateLimits(account, model)
+	if counterModel != "" && modelLimit > 0 {
+		if err := uc.updateTPM(ctx, account.ID, counterModel, actualTokens, estimatedTokens); err != nil {
+			return err
+		}
+	}
+	return uc.UpdateTPM(ctx, account.ID, actualTokens, estimatedTokens)
+}
+
+// incrementRPM increments the RPM counter of model (empty = the account-wide counter).
+func (uc *RateLimiterUseCase) incrementRPM(ctx context.Context, accountID int64, model string) (int32, error) {
+	if model == "" {
+		return uc.repo.IncrementRPM(ctx, accountID)
+	}
+	return uc.repo.IncrementModelRPM(ctx, accountID, model)
+}
+
+// incrementTPM increments the TPM counter of model (empty = the account-wide counter).
+func (uc *RateLimiterUseCase) incrementTPM(ctx context.Context, accountID int64, model string, tokens int32) (int32, error) {
+	if model == "" {
+		return uc.repo.IncrementTPM(ctx, accountID, tokens)
+	}
+	return uc.repo.IncrementModelTPM(ctx, accountID, model, tokens)
+}
+
+// getTPMCount reads the TPM counter of model (empty = the account-wide counter).
+func (uc *RateLimiterUseCase) getTPMCount(ctx context.Context, accountID int64, model string) (int32, error) {
+	if model == "" {
+		return uc.repo.GetTPMCount(ctx, accountID)
+	}
+	return uc.repo.GetModelTPMCount(ctx, accountID, model)
+}
@@ -0,0 +1,120 @@
+package biz
+
+import (
+	"context"
+	"testing"
+
+	"QuotaLane/internal/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func modelLimitedAccount(metadataJSON string) *data.Account {
+	return &data.Account{ID: 42, RpmLimit: 3, TpmLimit: 1000, Metadata: &metadataJSON}
+}
+
+func TestModelRateLimits(t *testing.T) {
+	account := modelLimitedAccount(`{"model_limits":{"Claude-Opus-4":{"rpm":1,"tpm":100}}}`)
+
+	rpm, tpm, counterModel := ModelRateLimits(account, "claude-opus-4")
+	assert.Equal(t, int32(1), rpm)
+	assert.Equal(t, int32(100), tpm)
+	assert.Equal(t, "claude-opus-4", counterModel)
+
+	// Unlisted models have no caps of their own
+	rpm, tpm, counterModel = ModelRateLimits(account, "claude-sonnet-4")
+	assert.Zero(t, rpm)
+	assert.Zero(t, tpm)
+	assert.Empty(t, counterModel)
+
+	// Unparseable metadata has no per-model caps
+	rpm, _, counterModel = ModelRateLimits(modelLimitedAccount(`{not json`), "claude-opus-4")
+	assert.Zero(t, rpm)
+	assert.Empty(t, counterModel)
+}
+
+// TestCheckModelRPM_ModelCapAndAccountLimit tests that a listed model is capped on its own counter
+// and also counts against the account-level limit it shares with the other models.
+func TestCheckModelRPM_ModelCapAndAccountLimit(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	ctx := context.Background()
+	account := modelLimitedAccount(`{"model_limits":{"claude-opus-4":{"rpm":1}}}`)
+
+	require.NoError(t, uc.CheckModelRPM(ctx, account, "claude-opus-4"))
+	assert.Error(t, uc.CheckModelRPM(ctx, account, "Claude-Opus-4"), "opus is capped at 1 RPM")
+
+	// The opus request used one of the account's 3 RPM; the rejected one used none
+	for i := 0; i < 2; i++ {
+		require.NoError(t, uc.CheckModelRPM(ctx, account, "claude-sonnet-4"))
+	}
+	assert.Error(t, uc.CheckModelRPM(ctx, account, "claude-sonnet-4"))
+}
+
+// TestCheckModelRPM_AccountRejectionReleasesModelCounter tests that a request admitted by the model
+// cap but rejected by the account limit does not use up the model's budget.
+func TestCheckModelRPM_AccountRejectionReleasesModelCounter(t *testing.T) {
+	for _, window := range []RPMWindowMode{RPMWindowFixed, RPMWindowSliding} {
+		t.Run(string(window), func(t *testing.T) {
+			uc := newGroupRateLimiter(t)
+			uc.SetRPMWindowMode(window)
+			ctx := context.Background()
+			account := modelLimitedAccount(`{"model_limits":{"claude-opus-4":{"rpm":5}}}`)
+			account.RpmLimit = 2
+
+			require.NoError(t, uc.CheckModelRPM(ctx, account, "claude-opus-4"))
+			require.NoError(t, uc.CheckModelRPM(ctx, account, "claude-opus-4"))
+			assert.Error(t, uc.CheckModelRPM(ctx, account, "claude-opus-4"), "the account limit bounds listed models")
+
+			// With the account limit lifted, opus has exactly 3 of its 5 left
+			account.RpmLimit = 100
+			for i := 0; i < 3; i++ {
+				require.NoError(t, uc.CheckModelRPM(ctx, account, "claude-opus-4"))
+			}
+			assert.Error(t, uc.CheckModelRPM(ctx, account, "claude-opus-4"))
+		})
+	}
+}
+
+func TestCheckModelTPM_ModelCapAndAccountLimit(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	ctx := context.Background()
+	account := modelLimitedAccount(`{"model_limits":{"claude-opus-4":{"tpm":100}}}`)
+
+	require.NoError(t, uc.CheckModelTPM(ctx, account, "claude-opus-4", 80))
+	assert.Error(t, uc.CheckModelTPM(ctx, account, "claude-opus-4", 30))
+
+	// Correcting the estimate down frees room on the opus counter and the account counter
+	require.NoError(t, uc.UpdateModelTPM(ctx, account, "claude-opus-4", 50, 80))
+	assert.NoError(t, uc.CheckModelTPM(ctx, account, "claude-opus-4", 30))
+
+	// opus used 80 of the account's 1000 TPM
+	assert.NoError(t, uc.CheckModelTPM(ctx, account, "claude-sonnet-4", 900))
+	assert.Error(t, uc.CheckModelTPM(ctx, account, "claude-sonnet-4", 30))
+}
+
+// TestCheckModelTPM_InheritedDimensionUsesAccountBudget tests that a model listed only with an RPM cap
+// gets no TPM budget of its own: its tokens count against the account-level TPM limit.
+func TestCheckModelTPM_InheritedDimensionUsesAccountBudget(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	ctx := context.Background()
+	account := modelLimitedAccount(`{"model_limits":{"claude-opus-4":{"rpm":1}}}`)
+
+	require.NoError(t, uc.CheckModelTPM(ctx, account, "claude-sonnet-4", 900))
+	assert.Error(t, uc.CheckModelTPM(ctx, account, "claude-opus-4", 200), "opus shares the account's 1000 TPM")
+	assert.NoError(t, uc.CheckModelTPM(ctx, account, "claude-opus-4", 100))
+}
+
+// TestCheckModelTPM_AccountRejectionReleasesModelReservation tests that tokens reserved on the model
+// counter are released when the account limit rejects the request.
+func TestCheckModelTPM_AccountRejectionReleasesModelReservation(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	ctx := context.Background()
+	account := modelLimitedAccount(`{"model_limits":{"claude-opus-4":{"tpm":500}}}`)
+
+	require.NoError(t, uc.CheckModelTPM(ctx, account, "claude-sonnet-4", 900))
+	assert.Error(t, uc.CheckModelTPM(ctx, account, "claude-opus-4", 200))
+
+	account.TpmLimit = 10000
+	assert.NoError(t, uc.CheckModelTPM(ctx, account, "claude-opus-4", 500), "the rejected 200 tokens were released")
+}
@@ -0,0 +1,95 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+
+	"QuotaLane/internal/data"
+	pkglog "QuotaLane/pkg/log"
+)
+
+// RPMWindowMode selects the algorithm CheckRPM uses.
+type RPMWindowMode string
+
+const (
+	// RPMWindowFixed counts requests per fixed 60s window with INCR (default). Cheap, but up to
+	// 2x the limit can pass across a window boundary.
+	RPMWindowFixed RPMWindowMode = "fixed"
+	// RPMWindowSliding keeps a per-account log of request timestamps and counts the last 60s,
+	// so the limit holds for every 60s span at the cost of one sorted-set entry per request.
+	RPMWindowSliding RPMWindowMode = "sliding"
+)
+
+// ParseRPMWindowMode parses rate_limit.rpm_window; empty means RPMWindowFixed.
+func ParseRPMWindowMode(mode string) (RPMWindowMode, error) {
+	switch RPMWindowMode(mode) {
+	case "", RPMWindowFixed:
+		return RPMWindowFixed, nil
+	case RPMWindowSliding:
+		return RPMWindowSliding, nil
+	default:
+		return "", fmt.Errorf("unknown RPM window mode %q (want fixed or sliding)", mode)
+	}
+}
+
+// SetRPMWindowMode selects the algorithm used by CheckRPM.
+func (uc *RateLimiterUseCase) SetRPMWindowMode(mode RPMWindowMode) {
+	uc.rpmWindow = mode
+}
+
+// CheckRPMSliding checks the account's RPM limit over a sliding 60-second window backed by a Redis
+// sorted set of request timestamps: entries older than 60s are removed, this request is recorded, and
+// the request is rejected when the remaining count exceeds rpmLimit (plus the burst allowance).
+// Rejected requests are removed from the log again so retries don't extend the lockout.
+// Redis degradation: on Redis failure, logs warning and allows request.
+func (uc *RateLimiterUseCase) CheckRPMSliding(ctx context.Context, accountID int64, rpmLimit int32) error {
+	_, err := uc.checkRPMSliding(ctx, accountID, "", rpmLimit)
+	return err
+}
+
+// checkRPMSliding implements CheckRPMSliding on the log of model (empty = the account-wide log).
+// The returned release function removes the admitted request from the log again (see reserveRPM).
+func (uc *RateLimiterUseCase) checkRPMSliding(ctx context.Context, accountID int64, model string, rpmLimit int32) (func(context.Context), error) {
+	if rpmLimit <= 0 {
+		// No limit configured, allow request (or reject everything if 0 means blocked)
+		return noRelease, uc.checkUnsetLimit("RPM", rpmLimit, 60)
+	}
+
+	now := uc.now().UnixMilli()
+	// 成员需唯一：同一毫秒内的多个请求不能互相覆盖
+	member := fmt.Sprintf("%d-%s", now, pkglog.GenerateRequestID())
+
+	count, oldest, err := uc.repo.AddSlidingRPMRequest(ctx, accountID, model, member, now)
+	if err != nil {
+		// Redis failure: log warning and allow request (graceful degradation)
+		uc.logger.Warnf("Redis sliding RPM check failed for account %d: %v (request allowed)", accountID, err)
+		return noRelease, nil
+	}
+
+	if count <= rpmLimit+uc.rpmBurst {
+		return func(ctx context.Context) {
+			if err := uc.repo.RemoveSlidingRPMRequest(ctx, accountID, model, member); err != nil {
+				uc.logger.Warnf("Failed to release sliding RPM reservation for account %d: %v", accountID, err)
+			}
+		}, nil
+	}
+
+	if err := uc.repo.RemoveSlidingRPMRequest(ctx, accountID, model, member); err != nil {
+		uc.logger.Warnf("Failed to remove rejected sliding RPM request for account %d: %v", accountID, err)
+	}
+
+	// 最早的请求滑出窗口后即可重试（向上取整到秒，至少 1 秒）
+	retryAfter := (oldest + data.SlidingRPMWindow.Milliseconds() - now + 999) / 1000
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	uc.logger.Warnw("RPM limit exceeded",
+		"account_id", accountID,
+		"model", model,
+		"current", count,
+		"limit", rpmLimit,
+		"burst", uc.rpmBurst,
+		"window", RPMWindowSliding)
+	return noRelease, newRateLimitExceededError("RPM", count, rpmLimit, retryAfter)
+}
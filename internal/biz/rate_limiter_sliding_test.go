@@ -0,0 +1,90 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRPMWindowMode(t *testing.T) {
+	mode, err := ParseRPMWindowMode("")
+	require.NoError(t, err)
+	assert.Equal(t, RPMWindowFixed, mode)
+
+	mode, err = ParseRPMWindowMode("sliding")
+	require.NoError(t, err)
+	assert.Equal(t, RPMWindowSliding, mode)
+
+	_, err = ParseRPMWindowMode("leaky")
+	assert.Error(t, err)
+}
+
+// TestCheckRPMSliding_NoBoundaryBurst tests that the sliding window holds the limit across the
+// point where a fixed window would reset (compare TestCheckRPM_WindowBoundary).
+func TestCheckRPMSliding_NoBoundaryBurst(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	clock := newFakeClock()
+	uc.SetClock(clock)
+	uc.SetRPMWindowMode(RPMWindowSliding)
+	ctx := context.Background()
+
+	// Two requests late in the first minute
+	clock.Advance(50 * time.Second)
+	require.NoError(t, uc.CheckRPM(ctx, 1, 2))
+	require.NoError(t, uc.CheckRPM(ctx, 1, 2))
+
+	// A fixed window would have reset by now; the sliding window still sees both requests
+	clock.Advance(15 * time.Second)
+	err := uc.CheckRPM(ctx, 1, 2)
+	require.Error(t, err)
+	assert.Equal(t, "RATE_LIMIT_EXCEEDED_RPM", kerrors.Reason(err))
+	assert.Contains(t, err.Error(), "retry_after=45s")
+
+	// Rejected requests are not recorded, so the window frees up once the first requests age out
+	clock.Advance(45 * time.Second)
+	require.NoError(t, uc.CheckRPM(ctx, 1, 2))
+	require.NoError(t, uc.CheckRPM(ctx, 1, 2))
+	assert.Error(t, uc.CheckRPM(ctx, 1, 2))
+
+	// Other accounts have their own window
+	assert.NoError(t, uc.CheckRPMSliding(ctx, 2, 2))
+}
+
+func TestCheckRPMSliding_Burst(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	uc.SetRPMBurst(1)
+	ctx := context.Background()
+
+	require.NoError(t, uc.CheckRPMSliding(ctx, 1, 1))
+	require.NoError(t, uc.CheckRPMSliding(ctx, 1, 1))
+	assert.Error(t, uc.CheckRPMSliding(ctx, 1, 1))
+}
+
+func TestCheckRPMSliding_RedisFailureAllowsRequest(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("AddSlidingRPMRequest", ctx, int64(123), "", mock.Anything, mock.Anything).
+		Return(int32(0), int64(0), errors.New("redis down"))
+
+	assert.NoError(t, uc.CheckRPMSliding(ctx, 123, 10))
+	mockRepo.AssertNotCalled(t, "RemoveSlidingRPMRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCheckRPM_FixedWindowByDefault(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("IncrementRPM", ctx, int64(123)).Return(int32(1), nil)
+
+	assert.NoError(t, uc.CheckRPM(ctx, 123, 10))
+	mockRepo.AssertNotCalled(t, "AddSlidingRPMRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
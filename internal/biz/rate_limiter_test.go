@@ -3,12 +3,17 @@ package biz
 import (
 	"context"
 	"errors"
+	"math"
 	"os"
 	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockRateLimitRepo is a mock implementation of RateLimitRepo for testing.
@@ -26,6 +31,36 @@ func (m *MockRateLimitRepo) GetRPMCount(ctx context.Context, accountID int64) (i
 	return args.Get(0).(int32), args.Error(1)
 }
 
+func (m *MockRateLimitRepo) AddSlidingRPMRequest(ctx context.Context, accountID int64, model, requestID string, timestamp int64) (int32, int64, error) {
+	args := m.Called(ctx, accountID, model, requestID, timestamp)
+	return args.Get(0).(int32), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRateLimitRepo) RemoveSlidingRPMRequest(ctx context.Context, accountID int64, model, requestID string) error {
+	args := m.Called(ctx, accountID, model, requestID)
+	return args.Error(0)
+}
+
+func (m *MockRateLimitRepo) IncrementModelRPM(ctx context.Context, accountID int64, model string) (int32, error) {
+	args := m.Called(ctx, accountID, model)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *MockRateLimitRepo) DecrementModelRPM(ctx context.Context, accountID int64, model string) error {
+	args := m.Called(ctx, accountID, model)
+	return args.Error(0)
+}
+
+func (m *MockRateLimitRepo) IncrementModelTPM(ctx context.Context, accountID int64, model string, tokens int32) (int32, error) {
+	args := m.Called(ctx, accountID, model, tokens)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *MockRateLimitRepo) GetModelTPMCount(ctx context.Context, accountID int64, model string) (int32, error) {
+	args := m.Called(ctx, accountID, model)
+	return args.Get(0).(int32), args.Error(1)
+}
+
 func (m *MockRateLimitRepo) IncrementTPM(ctx context.Context, accountID int64, tokens int32) (int32, error) {
 	args := m.Called(ctx, accountID, tokens)
 	return args.Get(0).(int32), args.Error(1)
@@ -36,6 +71,59 @@ func (m *MockRateLimitRepo) GetTPMCount(ctx context.Context, accountID int64) (i
 	return args.Get(0).(int32), args.Error(1)
 }
 
+func (m *MockRateLimitRepo) IncrementDailyTokens(ctx context.Context, accountID int64, now time.Time, tokens int64) (int64, error) {
+	args := m.Called(ctx, accountID, now, tokens)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRateLimitRepo) GetDailyTokenCount(ctx context.Context, accountID int64, now time.Time) (int64, error) {
+	args := m.Called(ctx, accountID, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRateLimitRepo) GetUsageCounts(ctx context.Context, accountID int64) (int32, int32, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(int32), args.Get(1).(int32), args.Error(2)
+}
+
+func (m *MockRateLimitRepo) SumUsageCounts(ctx context.Context, rpmAccountIDs, tpmAccountIDs []int64) (int64, int64, error) {
+	args := m.Called(ctx, rpmAccountIDs, tpmAccountIDs)
+	return args.Get(0).(int64), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRateLimitRepo) BatchGetUsageStats(ctx context.Context, accountIDs []int64) (map[int64]*data.AccountUsageStats, error) {
+	args := m.Called(ctx, accountIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int64]*data.AccountUsageStats), args.Error(1)
+}
+
+func (m *MockRateLimitRepo) IncrementGroupRPM(ctx context.Context, groupID int64) (int32, error) {
+	args := m.Called(ctx, groupID)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *MockRateLimitRepo) DecrementGroupRPM(ctx context.Context, groupID int64) error {
+	args := m.Called(ctx, groupID)
+	return args.Error(0)
+}
+
+func (m *MockRateLimitRepo) IncrementGroupTPM(ctx context.Context, groupID int64, tokens int32) (int32, error) {
+	args := m.Called(ctx, groupID, tokens)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *MockRateLimitRepo) GetGroupTPMCount(ctx context.Context, groupID int64) (int32, error) {
+	args := m.Called(ctx, groupID)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *MockRateLimitRepo) IncrementGroupRoundRobin(ctx context.Context, groupID int64) (int64, error) {
+	args := m.Called(ctx, groupID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockRateLimitRepo) AddConcurrencyRequest(ctx context.Context, accountID int64, requestID string, timestamp int64) error {
 	args := m.Called(ctx, accountID, requestID, timestamp)
 	return args.Error(0)
@@ -56,6 +144,51 @@ func (m *MockRateLimitRepo) CleanupExpiredConcurrency(ctx context.Context, accou
 	return args.Error(0)
 }
 
+func (m *MockRateLimitRepo) HeartbeatConcurrencyRequest(ctx context.Context, accountID int64, provider data.AccountProvider, groupID int64, requestID string, timestamp int64) error {
+	args := m.Called(ctx, accountID, provider, groupID, requestID, timestamp)
+	return args.Error(0)
+}
+
+func (m *MockRateLimitRepo) AddProviderConcurrencyRequest(ctx context.Context, provider data.AccountProvider, requestID string, timestamp int64) error {
+	args := m.Called(ctx, provider, requestID, timestamp)
+	return args.Error(0)
+}
+
+func (m *MockRateLimitRepo) RemoveProviderConcurrencyRequest(ctx context.Context, provider data.AccountProvider, requestID string) error {
+	args := m.Called(ctx, provider, requestID)
+	return args.Error(0)
+}
+
+func (m *MockRateLimitRepo) GetProviderConcurrencyCount(ctx context.Context, provider data.AccountProvider) (int32, error) {
+	args := m.Called(ctx, provider)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *MockRateLimitRepo) CleanupExpiredProviderConcurrency(ctx context.Context, provider data.AccountProvider, expiredBefore int64) error {
+	args := m.Called(ctx, provider, expiredBefore)
+	return args.Error(0)
+}
+
+func (m *MockRateLimitRepo) AddGroupConcurrencyRequest(ctx context.Context, groupID int64, requestID string, timestamp int64) error {
+	args := m.Called(ctx, groupID, requestID, timestamp)
+	return args.Error(0)
+}
+
+func (m *MockRateLimitRepo) RemoveGroupConcurrencyRequest(ctx context.Context, groupID int64, requestID string) error {
+	args := m.Called(ctx, groupID, requestID)
+	return args.Error(0)
+}
+
+func (m *MockRateLimitRepo) GetGroupConcurrencyCount(ctx context.Context, groupID int64) (int32, error) {
+	args := m.Called(ctx, groupID)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *MockRateLimitRepo) CleanupExpiredGroupConcurrency(ctx context.Context, groupID int64, expiredBefore int64) error {
+	args := m.Called(ctx, groupID, expiredBefore)
+	return args.Error(0)
+}
+
 // Helper function to create a test RateLimiterUseCase
 func newTestRateLimiter(repo *MockRateLimitRepo) *RateLimiterUseCase {
 	logger := log.NewStdLogger(os.Stdout)
@@ -97,6 +230,56 @@ func TestCheckRPM_LimitExceeded(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// Test CheckRPM - Burst allowance at limit, limit+burst and limit+burst+1
+func TestCheckRPM_Burst(t *testing.T) {
+	ctx := context.Background()
+	accountID := int64(123)
+	rpmLimit := int32(100)
+	burst := int32(20)
+
+	tests := []struct {
+		name    string
+		count   int32
+		wantErr bool
+	}{
+		{name: "at limit", count: rpmLimit},
+		{name: "at limit plus burst", count: rpmLimit + burst},
+		{name: "above limit plus burst", count: rpmLimit + burst + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRateLimitRepo)
+			uc := newTestRateLimiter(mockRepo)
+			uc.SetRPMBurst(burst)
+			mockRepo.On("IncrementRPM", ctx, accountID).Return(tt.count, nil)
+
+			err := uc.CheckRPM(ctx, accountID, rpmLimit)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "RATE_LIMIT_EXCEEDED_RPM")
+			} else {
+				assert.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// Test CheckRPM - Default burst keeps the strict limit
+func TestCheckRPM_DefaultBurstIsStrict(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	uc.SetRPMBurst(-5) // negative is clamped to 0
+
+	ctx := context.Background()
+	mockRepo.On("IncrementRPM", ctx, int64(123)).Return(int32(101), nil)
+
+	err := uc.CheckRPM(ctx, 123, 100)
+	assert.Error(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
 // Test CheckRPM - Redis error (graceful degradation)
 func TestCheckRPM_RedisError(t *testing.T) {
 	mockRepo := new(MockRateLimitRepo)
@@ -169,6 +352,48 @@ func TestCheckTPM_LimitExceeded(t *testing.T) {
 }
 
 // Test CheckTPM - Redis error (graceful degradation)
+// Test CheckTPM - Estimate above the per-request maximum is rejected without touching the counter
+func TestCheckTPM_EstimateAboveMax(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	uc.SetMaxTokensPerRequest(200000)
+
+	ctx := context.Background()
+	accountID := int64(123)
+
+	// Rejected even when the account has no TPM limit
+	for _, tpmLimit := range []int32{0, 1000000} {
+		err := uc.CheckTPM(ctx, accountID, tpmLimit, 200001)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "TPM_ESTIMATE_TOO_LARGE")
+	}
+	mockRepo.AssertNotCalled(t, "GetTPMCount", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "IncrementTPM", mock.Anything, mock.Anything, mock.Anything)
+
+	// At the maximum is allowed
+	mockRepo.On("GetTPMCount", ctx, accountID).Return(int32(0), nil)
+	mockRepo.On("IncrementTPM", ctx, accountID, int32(200000)).Return(int32(200000), nil)
+	assert.NoError(t, uc.CheckTPM(ctx, accountID, 1000000, 200000))
+	mockRepo.AssertExpectations(t)
+}
+
+// Test CheckTPM - current + estimate beyond int32 must not wrap negative and pass
+func TestCheckTPM_NoOverflowWrap(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+
+	ctx := context.Background()
+	accountID := int64(123)
+
+	// Saturated counter + large estimate would wrap to a negative int32 sum
+	mockRepo.On("GetTPMCount", ctx, accountID).Return(int32(math.MaxInt32), nil)
+
+	err := uc.CheckTPM(ctx, accountID, math.MaxInt32, 1000)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "RATE_LIMIT_EXCEEDED_TPM")
+	mockRepo.AssertNotCalled(t, "IncrementTPM", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestCheckTPM_RedisError(t *testing.T) {
 	mockRepo := new(MockRateLimitRepo)
 	uc := newTestRateLimiter(mockRepo)
@@ -274,7 +499,7 @@ func TestAcquireConcurrencySlot_Success(t *testing.T) {
 	mockRepo.On("AddConcurrencyRequest", ctx, accountID, requestID, mock.AnythingOfType("int64")).Return(nil)
 	mockRepo.On("GetConcurrencyCount", ctx, accountID).Return(int32(5), nil)
 
-	err := uc.AcquireConcurrencySlot(ctx, accountID, requestID)
+	err := uc.AcquireConcurrencySlot(ctx, accountID, data.ProviderClaudeConsole, requestID)
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
@@ -293,7 +518,7 @@ func TestAcquireConcurrencySlot_LimitExceeded(t *testing.T) {
 	mockRepo.On("GetConcurrencyCount", ctx, accountID).Return(int32(11), nil)
 	mockRepo.On("RemoveConcurrencyRequest", ctx, accountID, requestID).Return(nil)
 
-	err := uc.AcquireConcurrencySlot(ctx, accountID, requestID)
+	err := uc.AcquireConcurrencySlot(ctx, accountID, data.ProviderClaudeConsole, requestID)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "RATE_LIMIT_EXCEEDED_Concurrency")
 	mockRepo.AssertExpectations(t)
@@ -312,12 +537,61 @@ func TestAcquireConcurrencySlot_RedisError(t *testing.T) {
 	mockRepo.On("AddConcurrencyRequest", ctx, accountID, requestID, mock.AnythingOfType("int64")).
 		Return(errors.New("redis connection failed"))
 
-	err := uc.AcquireConcurrencySlot(ctx, accountID, requestID)
+	err := uc.AcquireConcurrencySlot(ctx, accountID, data.ProviderClaudeConsole, requestID)
 	// Should NOT return error (graceful degradation)
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
 
+// Test AcquireConcurrencySlot - Provider limit rejects even when the account has room
+func TestAcquireConcurrencySlot_ProviderLimitExceeded(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	uc.SetProviderConcurrencyLimit(data.ProviderClaudeConsole, 20)
+
+	ctx := context.Background()
+	accountID := int64(123)
+	requestID := "req-123"
+
+	// Mock: account count is 2 (within 10), provider count is 21 (exceeds 20)
+	mockRepo.On("AddConcurrencyRequest", ctx, accountID, requestID, mock.AnythingOfType("int64")).Return(nil)
+	mockRepo.On("GetConcurrencyCount", ctx, accountID).Return(int32(2), nil)
+	mockRepo.On("AddProviderConcurrencyRequest", ctx, data.ProviderClaudeConsole, requestID, mock.AnythingOfType("int64")).Return(nil)
+	mockRepo.On("GetProviderConcurrencyCount", ctx, data.ProviderClaudeConsole).Return(int32(21), nil)
+	mockRepo.On("RemoveProviderConcurrencyRequest", ctx, data.ProviderClaudeConsole, requestID).Return(nil)
+	// Account slot must be rolled back
+	mockRepo.On("RemoveConcurrencyRequest", ctx, accountID, requestID).Return(nil)
+
+	err := uc.AcquireConcurrencySlot(ctx, accountID, data.ProviderClaudeConsole, requestID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "RATE_LIMIT_EXCEEDED_ProviderConcurrency")
+	mockRepo.AssertExpectations(t)
+}
+
+// Test AcquireConcurrencySlot - Both account and provider slots acquired and released
+func TestAcquireConcurrencySlot_ProviderLimitWithinLimit(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	uc.SetProviderConcurrencyLimit(data.ProviderClaudeConsole, 20)
+
+	ctx := context.Background()
+	accountID := int64(123)
+	requestID := "req-123"
+
+	mockRepo.On("AddConcurrencyRequest", ctx, accountID, requestID, mock.AnythingOfType("int64")).Return(nil)
+	mockRepo.On("GetConcurrencyCount", ctx, accountID).Return(int32(2), nil)
+	mockRepo.On("AddProviderConcurrencyRequest", ctx, data.ProviderClaudeConsole, requestID, mock.AnythingOfType("int64")).Return(nil)
+	mockRepo.On("GetProviderConcurrencyCount", ctx, data.ProviderClaudeConsole).Return(int32(20), nil)
+
+	assert.NoError(t, uc.AcquireConcurrencySlot(ctx, accountID, data.ProviderClaudeConsole, requestID))
+	mockRepo.AssertNotCalled(t, "RemoveConcurrencyRequest", ctx, accountID, requestID)
+
+	mockRepo.On("RemoveConcurrencyRequest", ctx, accountID, requestID).Return(nil)
+	mockRepo.On("RemoveProviderConcurrencyRequest", ctx, data.ProviderClaudeConsole, requestID).Return(nil)
+	assert.NoError(t, uc.ReleaseConcurrencySlot(ctx, accountID, data.ProviderClaudeConsole, requestID))
+	mockRepo.AssertExpectations(t)
+}
+
 // Test ReleaseConcurrencySlot - Success
 func TestReleaseConcurrencySlot_Success(t *testing.T) {
 	mockRepo := new(MockRateLimitRepo)
@@ -329,7 +603,7 @@ func TestReleaseConcurrencySlot_Success(t *testing.T) {
 
 	mockRepo.On("RemoveConcurrencyRequest", ctx, accountID, requestID).Return(nil)
 
-	err := uc.ReleaseConcurrencySlot(ctx, accountID, requestID)
+	err := uc.ReleaseConcurrencySlot(ctx, accountID, data.ProviderClaudeConsole, requestID)
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
@@ -346,7 +620,7 @@ func TestReleaseConcurrencySlot_RedisError(t *testing.T) {
 	mockRepo.On("RemoveConcurrencyRequest", ctx, accountID, requestID).
 		Return(errors.New("redis connection failed"))
 
-	err := uc.ReleaseConcurrencySlot(ctx, accountID, requestID)
+	err := uc.ReleaseConcurrencySlot(ctx, accountID, data.ProviderClaudeConsole, requestID)
 	// Should NOT return error (best-effort cleanup)
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
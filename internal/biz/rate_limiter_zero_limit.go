@@ -0,0 +1,57 @@
+package biz
+
+import "fmt"
+
+// ZeroLimitMode selects what a configured limit of 0 means for the RPM/TPM, daily token and concurrency checks.
+type ZeroLimitMode string
+
+const (
+	// ZeroLimitUnlimited treats a limit of 0 as "not configured": the check is skipped (default).
+	ZeroLimitUnlimited ZeroLimitMode = "unlimited"
+	// ZeroLimitBlocked treats a limit of 0 as a cap of zero: every request is rejected. Accounts,
+	// groups and providers must then be given explicit limits to receive traffic.
+	ZeroLimitBlocked ZeroLimitMode = "blocked"
+)
+
+// ParseZeroLimitMode parses rate_limit.zero_limit; empty means ZeroLimitUnlimited.
+func ParseZeroLimitMode(mode string) (ZeroLimitMode, error) {
+	switch ZeroLimitMode(mode) {
+	case "", ZeroLimitUnlimited:
+		return ZeroLimitUnlimited, nil
+	case ZeroLimitBlocked:
+		return ZeroLimitBlocked, nil
+	default:
+		return "", fmt.Errorf("unknown zero limit mode %q (want unlimited or blocked)", mode)
+	}
+}
+
+// SetZeroLimitMode selects how a limit of 0 is interpreted by the account RPM/TPM checks
+// (including per-model and sliding-window checks), the account daily token quota, the group
+// RPM/TPM/concurrency caps and the provider-wide concurrency ceilings. Negative limits always mean no limit.
+func (uc *RateLimiterUseCase) SetZeroLimitMode(mode ZeroLimitMode) {
+	uc.zeroLimit = mode
+}
+
+// ZeroLimitMode returns how a limit of 0 is interpreted (ZeroLimitUnlimited when unset).
+func (uc *RateLimiterUseCase) ZeroLimitMode() ZeroLimitMode {
+	if uc.zeroLimit == "" {
+		return ZeroLimitUnlimited
+	}
+	return uc.zeroLimit
+}
+
+// limitBlocked reports whether limit rejects every request under mode: exactly 0 in blocked mode.
+// Selection, capacity and the effective config use it so they agree with the limiter checks.
+func limitBlocked(limit int32, mode ZeroLimitMode) bool {
+	return limit == 0 && mode == ZeroLimitBlocked
+}
+
+// checkUnsetLimit is called by a check whose limit is <= 0 in place of enforcing it. It allows the
+// request unless the limit is exactly 0 in blocked mode, in which case the request is rejected as
+// exceeding limitType with a count of 0.
+func (uc *RateLimiterUseCase) checkUnsetLimit(limitType string, limit int32, retryAfter int64) error {
+	if !limitBlocked(limit, uc.zeroLimit) {
+		return nil
+	}
+	return newRateLimitExceededError(limitType, 0, 0, retryAfter)
+}
@@ -0,0 +1,130 @@
+package biz
+
+import (
+	"context"
+	"testing"
+
+	"QuotaLane/internal/data"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseZeroLimitMode(t *testing.T) {
+	mode, err := ParseZeroLimitMode("")
+	require.NoError(t, err)
+	assert.Equal(t, ZeroLimitUnlimited, mode)
+
+	mode, err = ParseZeroLimitMode("blocked")
+	require.NoError(t, err)
+	assert.Equal(t, ZeroLimitBlocked, mode)
+
+	_, err = ParseZeroLimitMode("deny")
+	assert.Error(t, err)
+}
+
+// TestZeroLimit_Unlimited tests that by default a limit of 0 skips every check without touching Redis.
+func TestZeroLimit_Unlimited(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	ctx := context.Background()
+
+	assert.NoError(t, uc.CheckRPM(ctx, 1, 0))
+	assert.NoError(t, uc.CheckRPMSliding(ctx, 1, 0))
+	assert.NoError(t, uc.CheckTPM(ctx, 1, 0, 100))
+	assert.NoError(t, uc.CheckGroupRPM(ctx, 7, 0))
+	assert.NoError(t, uc.CheckGroupTPM(ctx, 7, 0, 100))
+	assert.NoError(t, uc.CheckDailyTokens(ctx, 1, 0, 100))
+	mockRepo.AssertExpectations(t) // No calls expected
+}
+
+// TestZeroLimit_Blocked tests that in blocked mode a limit of 0 rejects every request without
+// touching Redis, while negative limits still mean no limit.
+func TestZeroLimit_Blocked(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	uc.SetZeroLimitMode(ZeroLimitBlocked)
+	ctx := context.Background()
+
+	cases := []struct {
+		name   string
+		err    error
+		reason string
+	}{
+		{"rpm", uc.CheckRPM(ctx, 1, 0), "RATE_LIMIT_EXCEEDED_RPM"},
+		{"rpm sliding", uc.CheckRPMSliding(ctx, 1, 0), "RATE_LIMIT_EXCEEDED_RPM"},
+		{"tpm", uc.CheckTPM(ctx, 1, 0, 100), "RATE_LIMIT_EXCEEDED_TPM"},
+		{"group rpm", uc.CheckGroupRPM(ctx, 7, 0), "RATE_LIMIT_EXCEEDED_GROUP_RPM"},
+		{"group tpm", uc.CheckGroupTPM(ctx, 7, 0, 100), "RATE_LIMIT_EXCEEDED_GROUP_TPM"},
+		{"daily tokens", uc.CheckDailyTokens(ctx, 1, 0, 100), "RATE_LIMIT_EXCEEDED_DAILY_TOKENS"},
+	}
+	for _, tc := range cases {
+		require.Error(t, tc.err, tc.name)
+		assert.Equal(t, tc.reason, kerrors.Reason(tc.err), tc.name)
+		assert.Equal(t, 429, kerrors.Code(tc.err), tc.name)
+	}
+
+	assert.NoError(t, uc.CheckRPM(ctx, 1, -1))
+	assert.NoError(t, uc.CheckTPM(ctx, 1, -1, 100))
+	mockRepo.AssertExpectations(t) // No calls expected
+}
+
+// TestZeroLimit_BlockedModelLimits tests that per-model limits inherit a zero account limit and are blocked too.
+func TestZeroLimit_BlockedModelLimits(t *testing.T) {
+	uc := newGroupRateLimiter(t)
+	uc.SetZeroLimitMode(ZeroLimitBlocked)
+	ctx := context.Background()
+	account := &data.Account{ID: 1, RpmLimit: 0, TpmLimit: 0}
+
+	err := uc.CheckModelRPM(ctx, account, "gpt-4o")
+	assert.Equal(t, "RATE_LIMIT_EXCEEDED_RPM", kerrors.Reason(err))
+	err = uc.CheckModelTPM(ctx, account, "gpt-4o", 10)
+	assert.Equal(t, "RATE_LIMIT_EXCEEDED_TPM", kerrors.Reason(err))
+}
+
+// TestZeroLimit_GroupConcurrency tests a group concurrency cap of 0 under both interpretations.
+func TestZeroLimit_GroupConcurrency(t *testing.T) {
+	ctx := context.Background()
+	group := &AccountGroup{ID: 7}
+
+	uc := newGroupRateLimiter(t)
+	require.NoError(t, uc.AcquireGroupConcurrencySlot(ctx, group, 1, data.ProviderClaudeConsole, "req-1"))
+
+	uc = newGroupRateLimiter(t)
+	uc.SetZeroLimitMode(ZeroLimitBlocked)
+	err := uc.AcquireGroupConcurrencySlot(ctx, group, 1, data.ProviderClaudeConsole, "req-1")
+	require.Error(t, err)
+	assert.Equal(t, "RATE_LIMIT_EXCEEDED_GroupConcurrency", kerrors.Reason(err))
+
+	// The rejection must not leave an account slot behind
+	count, err := uc.repo.GetConcurrencyCount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+
+	// Accounts outside a group only have the (non-zero) per-account limit
+	assert.NoError(t, uc.AcquireGroupConcurrencySlot(ctx, nil, 1, data.ProviderClaudeConsole, "req-2"))
+}
+
+// TestZeroLimit_ProviderConcurrency tests a provider ceiling of 0 under both interpretations.
+func TestZeroLimit_ProviderConcurrency(t *testing.T) {
+	ctx := context.Background()
+
+	uc := newGroupRateLimiter(t)
+	uc.SetProviderConcurrencyLimit(data.ProviderClaudeConsole, 0)
+	require.NoError(t, uc.AcquireConcurrencySlot(ctx, 1, data.ProviderClaudeConsole, "req-1"))
+	require.NoError(t, uc.ReleaseConcurrencySlot(ctx, 1, data.ProviderClaudeConsole, "req-1"))
+
+	uc = newGroupRateLimiter(t)
+	uc.SetProviderConcurrencyLimit(data.ProviderClaudeConsole, 0)
+	uc.SetZeroLimitMode(ZeroLimitBlocked)
+	err := uc.AcquireConcurrencySlot(ctx, 1, data.ProviderClaudeConsole, "req-1")
+	require.Error(t, err)
+	assert.Equal(t, "RATE_LIMIT_EXCEEDED_ProviderConcurrency", kerrors.Reason(err))
+
+	// The account slot is rolled back, and other providers are unaffected
+	count, err := uc.repo.GetConcurrencyCount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+	assert.NoError(t, uc.AcquireConcurrencySlot(ctx, 1, data.ProviderOpenAIResponses, "req-2"))
+}
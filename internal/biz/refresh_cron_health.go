@@ -0,0 +1,63 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// RefreshCronLastRunKey Redis 中记录 Token 刷新定时任务最近一次启动时间的 key（Unix 秒）
+	RefreshCronLastRunKey = "last_refresh_cron_run"
+
+	// RefreshCronInterval Token 刷新定时任务的调度间隔（与 main.go 中 "0 */5 * * * *" 保持一致）
+	RefreshCronInterval = 5 * time.Minute
+
+	// RefreshCronStaleAfter 超过该时长未运行即视为定时任务失效（2 倍调度间隔）
+	RefreshCronStaleAfter = 2 * RefreshCronInterval
+)
+
+// RefreshCronStatus Token 刷新定时任务的存活状态（dead man's switch）
+type RefreshCronStatus struct {
+	LastRun *time.Time    // 最近一次启动时间（nil 表示从未运行）
+	Age     time.Duration // 距最近一次启动的时长
+	Stale   bool          // 超过 RefreshCronStaleAfter 未运行或从未运行
+}
+
+// markRefreshCronRun 在每次刷新任务启动时写入心跳时间戳
+// 写入失败只记录日志，不影响刷新流程
+func (uc *AccountUsecase) markRefreshCronRun(ctx context.Context, now time.Time) {
+	if uc.rdb == nil {
+		return
+	}
+	if err := uc.rdb.Set(ctx, RefreshCronLastRunKey, now.Unix(), 0).Err(); err != nil {
+		uc.logger.Warnw("failed to record refresh cron heartbeat", "error", err)
+	}
+}
+
+// CheckRefreshCron 检查 Token 刷新定时任务是否仍在运行
+// 最近一次启动早于 2 倍调度间隔（或从未运行）时标记为 Stale，供 /healthz 与告警使用
+func (uc *AccountUsecase) CheckRefreshCron(ctx context.Context, now time.Time) (*RefreshCronStatus, error) {
+	if uc.rdb == nil {
+		return nil, fmt.Errorf("redis client not configured")
+	}
+
+	lastRunUnix, err := uc.rdb.Get(ctx, RefreshCronLastRunKey).Int64()
+	if errors.Is(err, redis.Nil) {
+		return &RefreshCronStatus{Stale: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read refresh cron heartbeat: %w", err)
+	}
+
+	lastRun := time.Unix(lastRunUnix, 0).UTC()
+	age := now.Sub(lastRun)
+	return &RefreshCronStatus{
+		LastRun: &lastRun,
+		Age:     age,
+		Stale:   age > RefreshCronStaleAfter,
+	}, nil
+}
@@ -0,0 +1,80 @@
+package biz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRefreshCron(t *testing.T) {
+	uc, mr := setupHealthHistoryTest(t)
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("never run is stale", func(t *testing.T) {
+		status, err := uc.CheckRefreshCron(ctx, now)
+		require.NoError(t, err)
+		assert.True(t, status.Stale)
+		assert.Nil(t, status.LastRun)
+	})
+
+	t.Run("recent run is healthy", func(t *testing.T) {
+		uc.markRefreshCronRun(ctx, now.Add(-RefreshCronInterval))
+
+		status, err := uc.CheckRefreshCron(ctx, now)
+		require.NoError(t, err)
+		assert.False(t, status.Stale)
+		require.NotNil(t, status.LastRun)
+		assert.Equal(t, now.Add(-RefreshCronInterval), *status.LastRun)
+	})
+
+	t.Run("exactly 2x interval is still healthy", func(t *testing.T) {
+		uc.markRefreshCronRun(ctx, now.Add(-RefreshCronStaleAfter))
+
+		status, err := uc.CheckRefreshCron(ctx, now)
+		require.NoError(t, err)
+		assert.False(t, status.Stale)
+	})
+
+	t.Run("stale timestamp flags the check", func(t *testing.T) {
+		uc.markRefreshCronRun(ctx, now.Add(-RefreshCronStaleAfter-time.Second))
+
+		status, err := uc.CheckRefreshCron(ctx, now)
+		require.NoError(t, err)
+		assert.True(t, status.Stale)
+		assert.Equal(t, RefreshCronStaleAfter+time.Second, status.Age)
+	})
+
+	t.Run("redis error is reported", func(t *testing.T) {
+		mr.SetError("redis down")
+		defer mr.SetError("")
+
+		_, err := uc.CheckRefreshCron(ctx, now)
+		assert.Error(t, err)
+	})
+}
+
+func TestAutoRefreshTokens_RecordsHeartbeat(t *testing.T) {
+	uc, mr := setupHealthHistoryTest(t)
+	repo := uc.repo.(*MockAccountRepo)
+	repo.On("ListExpiringAccounts", mock.Anything, mock.Anything).Return(nil, nil)
+
+	require.NoError(t, uc.AutoRefreshTokens(context.Background()))
+
+	assert.True(t, mr.Exists(RefreshCronLastRunKey))
+	status, err := uc.CheckRefreshCron(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.False(t, status.Stale)
+}
+
+func TestCheckRefreshCron_NoRedis(t *testing.T) {
+	uc := NewAccountUsecase(new(MockAccountRepo), nil, nil, nil, nil, nil, nil, nil, nil, log.DefaultLogger)
+
+	_, err := uc.CheckRefreshCron(context.Background(), time.Now())
+	assert.Error(t, err)
+}
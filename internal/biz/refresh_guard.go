@@ -0,0 +1,94 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// RefreshGuardKeyPrefix Redis 中记录账户最近一次刷新认领的 key 前缀（值为认领的任务名）
+	RefreshGuardKeyPrefix = "refresh_guard:"
+
+	// DefaultRefreshDedupWindow 默认去重窗口：窗口内同一账户只会被一个刷新任务刷新
+	DefaultRefreshDedupWindow = 10 * time.Minute
+)
+
+// RefreshGuard 两个批量刷新任务共享的单账户刷新去重
+//
+// Token 刷新的唯一权威是 OAuthRefreshTask.RefreshExpiringTokens（每 6 小时，覆盖所有 OAuth Provider）；
+// AccountUsecase.AutoRefreshTokens（每 5 分钟）仅作为 Claude 账户在两次统一刷新之间即将过期时的兜底。
+// 两个任务的调度时间会重合（整点同时触发），刷新前先通过 Redis SET NX 认领账户，
+// 窗口内已被任一任务认领的账户直接跳过，避免重复调用 Provider 以及 refresh token 轮换导致的竞态。
+// nil 或窗口为 0 表示不去重；Redis 故障时放行（与限流一致的降级策略）。
+type RefreshGuard struct {
+	rdb    redis.UniversalClient
+	window time.Duration
+	logger *log.Helper
+}
+
+// NewRefreshGuard 创建刷新去重器（默认窗口 DefaultRefreshDedupWindow）
+func NewRefreshGuard(rdb redis.UniversalClient, logger log.Logger) *RefreshGuard {
+	return &RefreshGuard{
+		rdb:    rdb,
+		window: DefaultRefreshDedupWindow,
+		logger: log.NewHelper(logger),
+	}
+}
+
+// SetWindow 设置去重窗口；d <= 0 表示关闭去重，两个任务各自独立刷新
+func (g *RefreshGuard) SetWindow(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	g.window = d
+}
+
+// TryClaim 为 job 认领账户的本次刷新，返回 false 表示窗口内已被其他任务（或本任务）刷新过，应跳过
+func (g *RefreshGuard) TryClaim(ctx context.Context, accountID int64, job string) bool {
+	if g == nil || g.rdb == nil || g.window <= 0 {
+		return true
+	}
+
+	claimed, err := g.rdb.SetNX(ctx, refreshGuardKey(accountID), job, g.window).Result()
+	if err != nil {
+		g.logger.Warnf("refresh guard claim failed for account %d: %v (refresh allowed)", accountID, err)
+		return true
+	}
+	if !claimed {
+		owner, _ := g.rdb.Get(ctx, refreshGuardKey(accountID)).Result()
+		g.logger.Infow("skipping account refreshed by another job within dedup window",
+			"account_id", accountID,
+			"job", job,
+			"claimed_by", owner,
+			"window", g.window)
+	}
+	return claimed
+}
+
+// Release 释放认领（刷新失败时调用），让另一个任务或下一轮按各自的重试策略继续处理
+func (g *RefreshGuard) Release(ctx context.Context, accountID int64) {
+	if g == nil || g.rdb == nil || g.window <= 0 {
+		return
+	}
+	if err := g.rdb.Del(context.WithoutCancel(ctx), refreshGuardKey(accountID)).Err(); err != nil {
+		g.logger.Warnf("failed to release refresh guard for account %d: %v", accountID, err)
+	}
+}
+
+func refreshGuardKey(accountID int64) string {
+	return fmt.Sprintf("%s%d", RefreshGuardKeyPrefix, accountID)
+}
+
+// SetRefreshGuard 设置与 OAuthRefreshTask 共享的刷新去重器（nil 表示不去重）
+func (uc *AccountUsecase) SetRefreshGuard(guard *RefreshGuard) {
+	uc.refreshGuard = guard
+}
+
+// SetRefreshGuard 设置与 AccountUsecase.AutoRefreshTokens 共享的刷新去重器（nil 表示不去重）
+func (t *OAuthRefreshTask) SetRefreshGuard(guard *RefreshGuard) {
+	t.guard = guard
+}
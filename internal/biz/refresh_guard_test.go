@@ -0,0 +1,149 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"QuotaLane/internal/data"
+	"QuotaLane/pkg/crypto"
+	"QuotaLane/pkg/oauth"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRefreshGuard(t *testing.T) (*RefreshGuard, *miniredis.Miniredis, redis.UniversalClient) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return NewRefreshGuard(rdb, log.DefaultLogger), mr, rdb
+}
+
+// expiringOAuthAccount builds a Claude account whose stored OAuth data decrypts with cryptoHelper.
+func expiringOAuthAccount(t *testing.T, cryptoHelper *crypto.AESCrypto, id int64) *data.Account {
+	accessToken, err := cryptoHelper.Encrypt("access")
+	require.NoError(t, err)
+	refreshToken, err := cryptoHelper.Encrypt("refresh")
+	require.NoError(t, err)
+	oauthJSON, err := json.Marshal(StoredOAuthData{
+		AccessTokenEncrypted:  accessToken,
+		RefreshTokenEncrypted: refreshToken,
+		ExpiresAt:             time.Now().Add(5 * time.Minute),
+	})
+	require.NoError(t, err)
+	oauthDataEncrypted, err := cryptoHelper.Encrypt(string(oauthJSON))
+	require.NoError(t, err)
+	return &data.Account{ID: id, Name: "claude", Provider: data.ProviderClaudeOfficial, OAuthDataEncrypted: oauthDataEncrypted}
+}
+
+func TestRefreshGuard_TryClaim(t *testing.T) {
+	guard, mr, _ := newTestRefreshGuard(t)
+	ctx := context.Background()
+
+	assert.True(t, guard.TryClaim(ctx, 1, RefreshRunJobExpiring))
+	assert.False(t, guard.TryClaim(ctx, 1, RefreshRunJobAuto), "second job skips within window")
+	assert.True(t, guard.TryClaim(ctx, 2, RefreshRunJobAuto), "claims are per account")
+
+	owner, err := mr.Get(RefreshGuardKeyPrefix + "1")
+	require.NoError(t, err)
+	assert.Equal(t, RefreshRunJobExpiring, owner)
+
+	mr.FastForward(DefaultRefreshDedupWindow + time.Second)
+	assert.True(t, guard.TryClaim(ctx, 1, RefreshRunJobAuto), "claim expires after the window")
+
+	guard.Release(ctx, 1)
+	assert.True(t, guard.TryClaim(ctx, 1, RefreshRunJobExpiring), "released claims can be retaken")
+}
+
+func TestRefreshGuard_Disabled(t *testing.T) {
+	guard, mr, _ := newTestRefreshGuard(t)
+	guard.SetWindow(0)
+	ctx := context.Background()
+
+	assert.True(t, guard.TryClaim(ctx, 1, RefreshRunJobExpiring))
+	assert.True(t, guard.TryClaim(ctx, 1, RefreshRunJobAuto))
+	assert.False(t, mr.Exists(RefreshGuardKeyPrefix+"1"))
+
+	var nilGuard *RefreshGuard
+	assert.True(t, nilGuard.TryClaim(ctx, 1, RefreshRunJobAuto))
+	nilGuard.Release(ctx, 1)
+}
+
+func TestRefreshGuard_RedisDownAllowsRefresh(t *testing.T) {
+	guard, mr, _ := newTestRefreshGuard(t)
+	mr.Close()
+
+	assert.True(t, guard.TryClaim(context.Background(), 1, RefreshRunJobAuto))
+}
+
+// TestRefreshJobs_NoDoubleRefresh tests that an account refreshed by the unified job is
+// skipped by the fallback job, and vice versa, when both share one guard.
+func TestRefreshJobs_NoDoubleRefresh(t *testing.T) {
+	cryptoHelper, err := crypto.NewAESCrypto([]byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+
+	oauthManager := oauth.NewOAuthManager(nil, log.DefaultLogger)
+	oauthManager.RegisterProvider(&mockOAuthProvider{tokenResp: &oauth.ExtendedTokenResponse{
+		AccessToken:  "new-access",
+		RefreshToken: "new-refresh",
+		ExpiresIn:    28800,
+	}})
+
+	account := expiringOAuthAccount(t, cryptoHelper, 7)
+
+	t.Run("unified job first", func(t *testing.T) {
+		guard, _, rdb := newTestRefreshGuard(t)
+		mockRepo := new(MockAccountRepo)
+		mockRepo.On("ListExpiringAccounts", mock.Anything, mock.Anything).Return([]*data.Account{account}, nil)
+		mockRepo.On("ListCodexCLIAccountsNeedingRefresh", mock.Anything, ExpiringRefreshThreshold).Return([]*data.Account{}, nil)
+		mockRepo.On("UpdateOAuthData", mock.Anything, int64(7), mock.Anything, mock.Anything).Return(nil).Once()
+
+		task := NewOAuthRefreshTask(mockRepo, oauthManager, cryptoHelper, nil, log.DefaultLogger)
+		task.SetRefreshGuard(guard)
+		require.NoError(t, task.RefreshExpiringTokens(context.Background()))
+
+		uc := NewAccountUsecase(mockRepo, cryptoHelper, nil, nil, oauthManager, nil, nil, nil, rdb, log.DefaultLogger)
+		uc.SetRefreshGuard(guard)
+		require.NoError(t, uc.AutoRefreshTokens(context.Background()))
+
+		mockRepo.AssertNumberOfCalls(t, "UpdateOAuthData", 1)
+		mockRepo.AssertNotCalled(t, "GetAccount", mock.Anything, int64(7))
+	})
+
+	t.Run("fallback job first", func(t *testing.T) {
+		guard, _, _ := newTestRefreshGuard(t)
+		require.True(t, guard.TryClaim(context.Background(), 7, RefreshRunJobAuto))
+
+		mockRepo := new(MockAccountRepo)
+		mockRepo.On("ListExpiringAccounts", mock.Anything, mock.Anything).Return([]*data.Account{account}, nil)
+		mockRepo.On("ListCodexCLIAccountsNeedingRefresh", mock.Anything, ExpiringRefreshThreshold).Return([]*data.Account{}, nil)
+
+		task := NewOAuthRefreshTask(mockRepo, oauthManager, cryptoHelper, nil, log.DefaultLogger)
+		task.SetRefreshGuard(guard)
+		require.NoError(t, task.RefreshExpiringTokens(context.Background()))
+
+		mockRepo.AssertNotCalled(t, "UpdateOAuthData", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed refresh releases claim", func(t *testing.T) {
+		guard, mr, _ := newTestRefreshGuard(t)
+		failing := oauth.NewOAuthManager(nil, log.DefaultLogger)
+		failing.RegisterProvider(&mockOAuthProvider{err: assert.AnError})
+
+		mockRepo := new(MockAccountRepo)
+		mockRepo.On("ListExpiringAccounts", mock.Anything, mock.Anything).Return([]*data.Account{account}, nil)
+		mockRepo.On("ListCodexCLIAccountsNeedingRefresh", mock.Anything, ExpiringRefreshThreshold).Return([]*data.Account{}, nil)
+
+		task := NewOAuthRefreshTask(mockRepo, failing, cryptoHelper, nil, log.DefaultLogger)
+		task.SetRefreshGuard(guard)
+		require.NoError(t, task.RefreshExpiringTokens(context.Background()))
+
+		assert.False(t, mr.Exists(RefreshGuardKeyPrefix+"7"), "the other job may retry a failed refresh")
+	})
+}
@@ -0,0 +1,63 @@
+package biz
+
+import (
+	"context"
+	"time"
+
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// Batch refresh job names recorded in refresh_runs.
+const (
+	// RefreshRunJobAuto AccountUsecase.AutoRefreshTokens（每 5 分钟）
+	RefreshRunJobAuto = "auto_refresh"
+	// RefreshRunJobExpiring OAuthRefreshTask.RefreshExpiringTokens（每 6 小时）
+	RefreshRunJobExpiring = "expiring_refresh"
+)
+
+// RefreshRunRepo defines the interface for persisting batch refresh run summaries.
+// Implementation is in data layer (data.RefreshRunRepo).
+type RefreshRunRepo interface {
+	CreateRefreshRun(ctx context.Context, run *data.RefreshRun) error
+	ListRefreshRuns(ctx context.Context, limit int) ([]*data.RefreshRun, error)
+}
+
+// recordRefreshRun persists one batch refresh summary.
+// A nil repo disables recording; write failures are logged and never fail the job.
+func recordRefreshRun(ctx context.Context, repo RefreshRunRepo, logger *log.Helper, job string, startedAt time.Time, total, success, failure int32) {
+	if repo == nil {
+		return
+	}
+
+	run := &data.RefreshRun{
+		Job:        job,
+		StartedAt:  startedAt,
+		Total:      total,
+		Success:    success,
+		Failure:    failure,
+		DurationMs: time.Since(startedAt).Milliseconds(),
+	}
+	if err := repo.CreateRefreshRun(ctx, run); err != nil {
+		logger.Warnw("failed to record refresh run", "job", job, "error", err)
+	}
+}
+
+// SetRefreshRunRepo 设置批量刷新运行记录存储（nil 表示不记录）
+func (uc *AccountUsecase) SetRefreshRunRepo(repo RefreshRunRepo) {
+	uc.refreshRuns = repo
+}
+
+// ListRefreshRuns 查询最近的批量刷新运行记录（最新在前）
+func (uc *AccountUsecase) ListRefreshRuns(ctx context.Context, limit int) ([]*data.RefreshRun, error) {
+	if uc.refreshRuns == nil {
+		return nil, nil
+	}
+	return uc.refreshRuns.ListRefreshRuns(ctx, limit)
+}
+
+// SetRefreshRunRepo 设置批量刷新运行记录存储（nil 表示不记录）
+func (t *OAuthRefreshTask) SetRefreshRunRepo(repo RefreshRunRepo) {
+	t.refreshRuns = repo
+}
@@ -0,0 +1,101 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRefreshRunRepo is an in-memory RefreshRunRepo for testing.
+type fakeRefreshRunRepo struct {
+	runs      []*data.RefreshRun
+	createErr error
+}
+
+func (f *fakeRefreshRunRepo) CreateRefreshRun(ctx context.Context, run *data.RefreshRun) error {
+	if f.createErr != nil {
+		return f.createErr
+	}
+	f.runs = append(f.runs, run)
+	return nil
+}
+
+func (f *fakeRefreshRunRepo) ListRefreshRuns(ctx context.Context, limit int) ([]*data.RefreshRun, error) {
+	runs := make([]*data.RefreshRun, 0, len(f.runs))
+	for i := len(f.runs) - 1; i >= 0 && (limit <= 0 || len(runs) < limit); i-- {
+		runs = append(runs, f.runs[i])
+	}
+	return runs, nil
+}
+
+func TestAutoRefreshTokens_RecordsRun(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("one summary per cycle", func(t *testing.T) {
+		repo := new(MockAccountRepo)
+		repo.On("ListExpiringAccounts", ctx, mock.Anything).
+			Return([]*data.Account{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, nil)
+		repo.On("GetAccount", mock.Anything, mock.Anything).Return(nil, errors.New("account not found"))
+
+		runs := &fakeRefreshRunRepo{}
+		uc := NewAccountUsecase(repo, nil, nil, nil, nil, nil, nil, nil, nil, log.DefaultLogger)
+		uc.SetRefreshRunRepo(runs)
+
+		assert.Error(t, uc.AutoRefreshTokens(ctx))
+		require.Len(t, runs.runs, 1)
+		run := runs.runs[0]
+		assert.Equal(t, RefreshRunJobAuto, run.Job)
+		assert.Equal(t, int32(2), run.Total)
+		assert.Equal(t, int32(0), run.Success)
+		assert.Equal(t, int32(2), run.Failure)
+		assert.False(t, run.StartedAt.IsZero())
+		assert.GreaterOrEqual(t, run.DurationMs, int64(0))
+	})
+
+	t.Run("empty cycle is recorded", func(t *testing.T) {
+		repo := new(MockAccountRepo)
+		repo.On("ListExpiringAccounts", ctx, mock.Anything).Return([]*data.Account{}, nil)
+
+		runs := &fakeRefreshRunRepo{}
+		uc := NewAccountUsecase(repo, nil, nil, nil, nil, nil, nil, nil, nil, log.DefaultLogger)
+		uc.SetRefreshRunRepo(runs)
+
+		require.NoError(t, uc.AutoRefreshTokens(ctx))
+		require.Len(t, runs.runs, 1)
+		assert.Equal(t, int32(0), runs.runs[0].Total)
+	})
+
+	t.Run("record failure does not fail the job", func(t *testing.T) {
+		repo := new(MockAccountRepo)
+		repo.On("ListExpiringAccounts", ctx, mock.Anything).Return([]*data.Account{}, nil)
+
+		uc := NewAccountUsecase(repo, nil, nil, nil, nil, nil, nil, nil, nil, log.DefaultLogger)
+		uc.SetRefreshRunRepo(&fakeRefreshRunRepo{createErr: errors.New("database error")})
+
+		assert.NoError(t, uc.AutoRefreshTokens(ctx))
+	})
+}
+
+func TestListRefreshRuns(t *testing.T) {
+	ctx := context.Background()
+	runs := &fakeRefreshRunRepo{runs: []*data.RefreshRun{
+		{ID: 1, Job: RefreshRunJobAuto},
+		{ID: 2, Job: RefreshRunJobExpiring},
+		{ID: 3, Job: RefreshRunJobAuto},
+	}}
+	uc := NewAccountUsecase(new(MockAccountRepo), nil, nil, nil, nil, nil, nil, nil, nil, log.DefaultLogger)
+	uc.SetRefreshRunRepo(runs)
+
+	got, err := uc.ListRefreshRuns(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, int64(3), got[0].ID)
+	assert.Equal(t, int64(2), got[1].ID)
+}
@@ -0,0 +1,55 @@
+package biz
+
+import (
+	"context"
+	"time"
+
+	v1 "QuotaLane/api/v1"
+)
+
+// SetRateLimiter 设置限流用例，用于在运行时配置中展示并发过期等限流设置
+func (uc *AccountUsecase) SetRateLimiter(rateLimiter *RateLimiterUseCase) {
+	uc.rateLimiter = rateLimiter
+}
+
+// Window 返回当前去重窗口（nil 表示不去重，返回 0）
+func (g *RefreshGuard) Window() time.Duration {
+	if g == nil {
+		return 0
+	}
+	return g.window
+}
+
+// GetRuntimeConfig 返回当前生效的运行时配置（均为非敏感值，已应用默认值）
+func (uc *AccountUsecase) GetRuntimeConfig(ctx context.Context) *v1.RuntimeConfig {
+	cfg := &v1.RuntimeConfig{
+		ConcurrencyExpirySeconds:        int64(DefaultConcurrencyExpiry.Seconds()),
+		AutoRefreshThresholdSeconds:     int64(AutoRefreshThreshold.Seconds()),
+		ExpiringRefreshThresholdSeconds: int64(expiringRefreshThreshold(uc.expiringThreshold).Seconds()),
+		RefreshFailureGraceSeconds:      int64(uc.refreshGraceWindow().Seconds()),
+		RefreshAccountTimeoutSeconds:    int64(refreshAccountTimeout(uc.refreshTimeout).Seconds()),
+		RefreshDedupWindowSeconds:       int64(uc.refreshGuard.Window().Seconds()),
+		MarkNeedsReauth:                 uc.markNeedsReauth,
+		HealthCheckSampleSize:           int32(uc.healthCheckSampleSize),
+		ProviderDownHealthPenalty:       int32(uc.providerDownPenalty),
+		HealthRecoveryStep:              int32(uc.healthRecoveryStep),
+	}
+	if uc.rateLimiter != nil {
+		cfg.ConcurrencyExpirySeconds = int64(uc.rateLimiter.ConcurrencyExpiry().Seconds())
+	}
+
+	breaker := DefaultCircuitBreakerConfig()
+	if uc.circuitBreaker != nil {
+		breaker = uc.circuitBreaker.config
+	}
+	cfg.CircuitBreakerMode = string(breaker.Mode)
+	cfg.CircuitBreakerConsecutiveThreshold = int32(breaker.ConsecutiveThreshold)
+	cfg.CircuitBreakerWindowSize = int32(breaker.WindowSize)
+	cfg.CircuitBreakerMinRequests = int32(breaker.MinRequests)
+	cfg.CircuitBreakerFailureRateThreshold = breaker.FailureRateThreshold
+	for _, code := range breaker.ImmediateTripStatusCodes {
+		cfg.CircuitBreakerImmediateTripStatusCodes = append(cfg.CircuitBreakerImmediateTripStatusCodes, int32(code))
+	}
+
+	return cfg
+}
@@ -0,0 +1,93 @@
+package biz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRuntimeConfig_Defaults(t *testing.T) {
+	uc := NewAccountUsecase(nil, nil, nil, nil, nil, nil, nil, nil, nil, log.DefaultLogger)
+
+	cfg := uc.GetRuntimeConfig(context.Background())
+
+	assert.Equal(t, int64(600), cfg.ConcurrencyExpirySeconds)
+	assert.Equal(t, int64(600), cfg.AutoRefreshThresholdSeconds)
+	assert.Equal(t, int64(7200), cfg.ExpiringRefreshThresholdSeconds)
+	assert.Equal(t, int64(3600), cfg.RefreshFailureGraceSeconds)
+	assert.Equal(t, int64(30), cfg.RefreshAccountTimeoutSeconds)
+	assert.Zero(t, cfg.RefreshDedupWindowSeconds)
+	assert.True(t, cfg.MarkNeedsReauth)
+	assert.Equal(t, "consecutive", cfg.CircuitBreakerMode)
+	assert.Equal(t, int32(3), cfg.CircuitBreakerConsecutiveThreshold)
+}
+
+func TestGetRuntimeConfig_ReflectsConfiguredValues(t *testing.T) {
+	uc := NewAccountUsecase(nil, nil, nil, nil, nil, nil, nil, nil, nil, log.DefaultLogger)
+
+	rateLimiter := newTestRateLimiter(new(MockRateLimitRepo))
+	rateLimiter.SetConcurrencyExpiry(45 * time.Minute)
+	uc.SetRateLimiter(rateLimiter)
+
+	guard := NewRefreshGuard(nil, log.DefaultLogger)
+	guard.SetWindow(15 * time.Minute)
+	uc.SetRefreshGuard(guard)
+
+	breaker := NewCircuitBreakerUsecase(&fakeCircuitBreakerRepo{}, nopAuditLogger{}, nopWebhookService{}, log.DefaultLogger)
+	require.NoError(t, breaker.SetConfig(CircuitBreakerConfig{
+		Mode:                     CircuitBreakerModeFailureRate,
+		WindowSize:               50,
+		MinRequests:              20,
+		FailureRateThreshold:     0.25,
+		ImmediateTripStatusCodes: []int{403},
+	}))
+	uc.circuitBreaker = breaker
+
+	uc.SetRefreshFailureGraceWindow(2 * time.Hour)
+	uc.SetRefreshAccountTimeout(time.Minute)
+	uc.SetExpiringRefreshThreshold(4 * time.Hour)
+	uc.SetMarkNeedsReauth(false)
+	uc.SetHealthCheckSampleSize(25)
+	uc.SetProviderDownHealthPenalty(5)
+	uc.SetHealthRecoveryStep(10)
+
+	cfg := uc.GetRuntimeConfig(context.Background())
+
+	assert.Equal(t, int64(2700), cfg.ConcurrencyExpirySeconds)
+	assert.Equal(t, int64(900), cfg.RefreshDedupWindowSeconds)
+	assert.Equal(t, int64(7200), cfg.RefreshFailureGraceSeconds)
+	assert.Equal(t, int64(60), cfg.RefreshAccountTimeoutSeconds)
+	assert.Equal(t, int64(14400), cfg.ExpiringRefreshThresholdSeconds)
+	assert.False(t, cfg.MarkNeedsReauth)
+	assert.Equal(t, int32(25), cfg.HealthCheckSampleSize)
+	assert.Equal(t, int32(5), cfg.ProviderDownHealthPenalty)
+	assert.Equal(t, int32(10), cfg.HealthRecoveryStep)
+	assert.Equal(t, "failure_rate", cfg.CircuitBreakerMode)
+	assert.Equal(t, int32(50), cfg.CircuitBreakerWindowSize)
+	assert.Equal(t, int32(20), cfg.CircuitBreakerMinRequests)
+	assert.InDelta(t, 0.25, cfg.CircuitBreakerFailureRateThreshold, 1e-9)
+	assert.Equal(t, []int32{403}, cfg.CircuitBreakerImmediateTripStatusCodes)
+}
+
+// TestCleanupExpiredConcurrency_ConfiguredExpiry tests that cleanup uses the configured slot age.
+func TestCleanupExpiredConcurrency_ConfiguredExpiry(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	clock := newFakeClock()
+	uc.SetClock(clock)
+	uc.SetConcurrencyExpiry(30 * time.Minute)
+	ctx := context.Background()
+
+	cutoff := clock.Now().Add(-30 * time.Minute).Unix()
+	mockRepo.On("CleanupExpiredConcurrency", ctx, int64(1), cutoff).Return(nil).Once()
+
+	require.NoError(t, uc.CleanupExpiredConcurrency(ctx, 1))
+	mockRepo.AssertExpectations(t)
+
+	uc.SetConcurrencyExpiry(0)
+	assert.Equal(t, DefaultConcurrencyExpiry, uc.ConcurrencyExpiry())
+}
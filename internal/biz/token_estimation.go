@@ -0,0 +1,124 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"QuotaLane/internal/data"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// errNoTokenEstimator reports a tokenized provider without a registered estimator.
+var errNoTokenEstimator = fmt.Errorf("no token estimator registered")
+
+// TokenEstimator counts prompt tokens with a provider's real tokenizer.
+// CountTokens returns an error when the tokenizer is unavailable (e.g. not loaded).
+type TokenEstimator interface {
+	CountTokens(prompt string) (int32, error)
+}
+
+// SetTokenEstimator registers the accurate estimator for a provider that needs real tokenization.
+// Providers without an estimator use the EstimateTokens heuristic unless they are tokenized
+// (SetTokenizedProviders). nil unregisters it.
+func (uc *RateLimiterUseCase) SetTokenEstimator(provider data.AccountProvider, estimator TokenEstimator) {
+	if estimator == nil {
+		delete(uc.tokenEstimators, provider)
+		return
+	}
+	if uc.tokenEstimators == nil {
+		uc.tokenEstimators = make(map[data.AccountProvider]TokenEstimator)
+	}
+	uc.tokenEstimators[provider] = estimator
+}
+
+// SetTokenizedProviders marks the providers whose requests need their real tokenizer
+// (rate_limit.tokenized_providers). A listed provider without a registered estimator is treated
+// like one whose estimator fails. Unknown provider names are rejected; empty clears the list.
+func (uc *RateLimiterUseCase) SetTokenizedProviders(names []string) error {
+	tokenized := make(map[data.AccountProvider]bool, len(names))
+	for _, name := range names {
+		provider := data.AccountProvider(name)
+		if _, ok := providerCapabilities[provider]; !ok {
+			return fmt.Errorf("unknown provider %q in tokenized providers", name)
+		}
+		tokenized[provider] = true
+	}
+	uc.tokenizedProviders = tokenized
+	return nil
+}
+
+// MissingTokenEstimators returns the tokenized providers without a registered estimator, sorted.
+func (uc *RateLimiterUseCase) MissingTokenEstimators() []data.AccountProvider {
+	var missing []data.AccountProvider
+	for provider := range uc.tokenizedProviders {
+		if _, ok := uc.tokenEstimators[provider]; !ok {
+			missing = append(missing, provider)
+		}
+	}
+	slices.Sort(missing)
+	return missing
+}
+
+// SetStrictTokenEstimation sets what happens when a provider's accurate estimator fails or a
+// tokenized provider has none registered. true: the request is rejected with
+// TOKEN_ESTIMATION_UNAVAILABLE; false (default): the EstimateTokens heuristic is used instead
+// and a warning is logged.
+func (uc *RateLimiterUseCase) SetStrictTokenEstimation(strict bool) {
+	uc.strictTokenEstimation = strict
+}
+
+// EstimateProviderTokens estimates the tokens of a request served by provider.
+// The provider's accurate estimator is used when registered; if it fails, or a tokenized
+// provider has no estimator, strict mode returns an error and lenient mode falls back to EstimateTokens.
+// Other providers without an estimator always use EstimateTokens.
+func (uc *RateLimiterUseCase) EstimateProviderTokens(provider data.AccountProvider, prompt string, maxOutputTokens int32) (int32, error) {
+	estimator, ok := uc.tokenEstimators[provider]
+	if !ok && !uc.tokenizedProviders[provider] {
+		return uc.EstimateTokens(prompt, maxOutputTokens), nil
+	}
+
+	var promptTokens int32
+	err := errNoTokenEstimator
+	if ok {
+		promptTokens, err = estimator.CountTokens(prompt)
+	}
+	if err != nil {
+		if uc.strictTokenEstimation {
+			uc.logger.Warnw("accurate token estimation unavailable, request rejected",
+				"provider", provider,
+				"error", err)
+			return 0, errors.ServiceUnavailable("TOKEN_ESTIMATION_UNAVAILABLE",
+				fmt.Sprintf("token estimation for provider %s is unavailable: %v", provider, err))
+		}
+		uc.logger.Warnw("accurate token estimation unavailable, falling back to heuristic",
+			"provider", provider,
+			"error", err)
+		return uc.EstimateTokens(prompt, maxOutputTokens), nil
+	}
+
+	total := int64(promptTokens) + int64(maxOutputTokens)
+	if total <= 0 {
+		total = 1
+	}
+	if total > 2147483647 {
+		total = 2147483647
+	}
+	return int32(total), nil // #nosec G115 -- clamped above
+}
+
+// CheckPromptTPM estimates the request's tokens for provider and checks them against the
+// account's TPM limit. It returns the estimate so the caller can correct it with UpdateTPM.
+// In strict mode a failed accurate estimation rejects the request before the TPM counter is touched.
+func (uc *RateLimiterUseCase) CheckPromptTPM(ctx context.Context, accountID int64, provider data.AccountProvider, tpmLimit int32, prompt string, maxOutputTokens int32) (int32, error) {
+	estimatedTokens, err := uc.EstimateProviderTokens(provider, prompt, maxOutputTokens)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := uc.CheckTPM(ctx, accountID, tpmLimit, estimatedTokens); err != nil {
+		return 0, err
+	}
+	return estimatedTokens, nil
+}
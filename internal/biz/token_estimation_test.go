@@ -0,0 +1,136 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"QuotaLane/internal/data"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTokenEstimator returns a fixed count or error
+type fakeTokenEstimator struct {
+	tokens int32
+	err    error
+}
+
+func (f *fakeTokenEstimator) CountTokens(prompt string) (int32, error) {
+	return f.tokens, f.err
+}
+
+var errTokenizerMissing = errors.New("tokenizer not loaded")
+
+func TestEstimateProviderTokens_UsesAccurateEstimator(t *testing.T) {
+	uc := newTestRateLimiter(new(MockRateLimitRepo))
+	uc.SetTokenEstimator(data.ProviderClaudeConsole, &fakeTokenEstimator{tokens: 42})
+
+	tokens, err := uc.EstimateProviderTokens(data.ProviderClaudeConsole, "hello world", 100)
+	require.NoError(t, err)
+	assert.Equal(t, int32(142), tokens)
+
+	// Providers without an estimator keep the heuristic
+	tokens, err = uc.EstimateProviderTokens(data.ProviderGemini, "12345678", 100)
+	require.NoError(t, err)
+	assert.Equal(t, int32(102), tokens)
+}
+
+func TestEstimateProviderTokens_EstimatorFails(t *testing.T) {
+	tests := []struct {
+		name       string
+		strict     bool
+		wantTokens int32
+		wantErr    bool
+	}{
+		{name: "lenient falls back to heuristic", strict: false, wantTokens: 102},
+		{name: "strict rejects", strict: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := newTestRateLimiter(new(MockRateLimitRepo))
+			uc.SetTokenEstimator(data.ProviderClaudeConsole, &fakeTokenEstimator{err: errTokenizerMissing})
+			uc.SetStrictTokenEstimation(tt.strict)
+
+			tokens, err := uc.EstimateProviderTokens(data.ProviderClaudeConsole, "12345678", 100)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, "TOKEN_ESTIMATION_UNAVAILABLE", kerrors.FromError(err).Reason)
+				assert.Contains(t, err.Error(), "tokenizer not loaded")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTokens, tokens)
+		})
+	}
+}
+
+// TestEstimateProviderTokens_TokenizedWithoutEstimator tests that a tokenized provider without a
+// registered estimator is rejected in strict mode and falls back to the heuristic in lenient mode.
+func TestEstimateProviderTokens_TokenizedWithoutEstimator(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		uc := newTestRateLimiter(new(MockRateLimitRepo))
+		require.NoError(t, uc.SetTokenizedProviders([]string{"claude-console"}))
+		uc.SetStrictTokenEstimation(strict)
+		assert.Equal(t, []data.AccountProvider{data.ProviderClaudeConsole}, uc.MissingTokenEstimators())
+
+		tokens, err := uc.EstimateProviderTokens(data.ProviderClaudeConsole, "12345678", 100)
+		if strict {
+			require.Error(t, err)
+			assert.Equal(t, "TOKEN_ESTIMATION_UNAVAILABLE", kerrors.FromError(err).Reason)
+			assert.Contains(t, err.Error(), "no token estimator registered")
+		} else {
+			require.NoError(t, err)
+			assert.Equal(t, int32(102), tokens)
+		}
+
+		// Providers that are not tokenized keep the heuristic even in strict mode
+		tokens, err = uc.EstimateProviderTokens(data.ProviderGemini, "12345678", 100)
+		require.NoError(t, err)
+		assert.Equal(t, int32(102), tokens)
+
+		// Registering the estimator satisfies the requirement
+		uc.SetTokenEstimator(data.ProviderClaudeConsole, &fakeTokenEstimator{tokens: 42})
+		assert.Empty(t, uc.MissingTokenEstimators())
+		tokens, err = uc.EstimateProviderTokens(data.ProviderClaudeConsole, "12345678", 100)
+		require.NoError(t, err)
+		assert.Equal(t, int32(142), tokens)
+	}
+}
+
+func TestSetTokenizedProviders_RejectsUnknownProvider(t *testing.T) {
+	uc := newTestRateLimiter(new(MockRateLimitRepo))
+	assert.ErrorContains(t, uc.SetTokenizedProviders([]string{"claude"}), `unknown provider "claude"`)
+}
+
+func TestCheckPromptTPM_StrictRejectsBeforeCounter(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	uc.SetTokenEstimator(data.ProviderClaudeConsole, &fakeTokenEstimator{err: errTokenizerMissing})
+	uc.SetStrictTokenEstimation(true)
+
+	_, err := uc.CheckPromptTPM(context.Background(), 1, data.ProviderClaudeConsole, 1000, "prompt", 100)
+	require.Error(t, err)
+	assert.Equal(t, int32(503), kerrors.FromError(err).Code)
+	mockRepo.AssertNotCalled(t, "GetTPMCount", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "IncrementTPM", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCheckPromptTPM_LenientChecksHeuristic(t *testing.T) {
+	mockRepo := new(MockRateLimitRepo)
+	uc := newTestRateLimiter(mockRepo)
+	uc.SetTokenEstimator(data.ProviderClaudeConsole, &fakeTokenEstimator{err: errTokenizerMissing})
+	ctx := context.Background()
+
+	mockRepo.On("GetTPMCount", ctx, int64(1)).Return(int32(0), nil)
+	mockRepo.On("IncrementTPM", ctx, int64(1), int32(102)).Return(int32(102), nil)
+
+	tokens, err := uc.CheckPromptTPM(ctx, 1, data.ProviderClaudeConsole, 1000, "12345678", 100)
+	require.NoError(t, err)
+	assert.Equal(t, int32(102), tokens)
+	mockRepo.AssertExpectations(t)
+}
@@ -5,10 +5,13 @@ package conf
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+	"golang.org/x/net/http/httpguts"
 	"google.golang.org/protobuf/types/known/durationpb"
 )
 
@@ -43,9 +46,17 @@ func NewBootstrap(configPath string) (*Bootstrap, error) {
 	// Allow direct environment variable names (without QUOTALANE_ prefix) for compatibility
 	// Bind specific environment variables for required fields
 	_ = v.BindEnv("data.database.source", "MYSQL_DSN", "QUOTALANE_DATA_DATABASE_SOURCE")
+	_ = v.BindEnv("data.database.replica_source", "MYSQL_REPLICA_DSN", "QUOTALANE_DATA_DATABASE_REPLICA_SOURCE")
 	_ = v.BindEnv("data.redis.addr", "QUOTALANE_DATA_REDIS_ADDR")
+	_ = v.BindEnv("data.redis.addrs", "QUOTALANE_DATA_REDIS_ADDRS")
 	_ = v.BindEnv("auth.jwt.secret", "JWT_SECRET", "QUOTALANE_AUTH_JWT_SECRET")
 	_ = v.BindEnv("auth.encryption.key", "ENCRYPTION_KEY", "QUOTALANE_AUTH_ENCRYPTION_KEY")
+	_ = v.BindEnv("auth.admin_api_keys", "ADMIN_API_KEYS", "QUOTALANE_AUTH_ADMIN_API_KEYS")
+	_ = v.BindEnv("oauth.proxy_precedence", "QUOTALANE_OAUTH_PROXY_PRECEDENCE")
+	_ = v.BindEnv("oauth.passthrough_headers", "QUOTALANE_OAUTH_PASSTHROUGH_HEADERS")
+	_ = v.BindEnv("log.redact_keys", "QUOTALANE_LOG_REDACT_KEYS")
+	_ = v.BindEnv("enabled_providers", "QUOTALANE_ENABLED_PROVIDERS")
+	_ = v.BindEnv("rate_limit.tokenized_providers", "QUOTALANE_RATE_LIMIT_TOKENIZED_PROVIDERS")
 
 	// Load configuration file
 	if configPath != "" {
@@ -69,18 +80,24 @@ func NewBootstrap(configPath string) (*Bootstrap, error) {
 				Addr:    v.GetString("server.grpc.addr"),
 				Timeout: durationpb.New(v.GetDuration("server.grpc.timeout")),
 			},
+			ShutdownTimeout: durationpb.New(v.GetDuration("server.shutdown_timeout")),
 		},
 		Data: &Data{
 			Database: &Data_Database{
-				Driver: v.GetString("data.database.driver"),
-				Source: v.GetString("data.database.source"),
+				Driver:        v.GetString("data.database.driver"),
+				Source:        v.GetString("data.database.source"),
+				ReplicaSource: v.GetString("data.database.replica_source"),
 			},
 			Redis: &Data_Redis{
 				Network:      v.GetString("data.redis.network"),
 				Addr:         v.GetString("data.redis.addr"),
 				ReadTimeout:  durationpb.New(v.GetDuration("data.redis.read_timeout")),
 				WriteTimeout: durationpb.New(v.GetDuration("data.redis.write_timeout")),
+				Mode:         v.GetString("data.redis.mode"),
+				Addrs:        listValues(v, "data.redis.addrs"),
+				MasterName:   v.GetString("data.redis.master_name"),
 			},
+			MissingProviderPolicy: v.GetString("data.missing_provider_policy"),
 		},
 		Auth: &Auth{
 			Jwt: &Auth_JWT{
@@ -88,13 +105,61 @@ func NewBootstrap(configPath string) (*Bootstrap, error) {
 				Expires: durationpb.New(v.GetDuration("auth.jwt.expires")),
 			},
 			Encryption: &Auth_Encryption{
-				Key: v.GetString("auth.encryption.key"),
+				Key:            v.GetString("auth.encryption.key"),
+				AccountBinding: v.GetString("auth.encryption.account_binding"),
 			},
+			AdminApiKeys: listValues(v, "auth.admin_api_keys"),
 		},
 		Log: &Log{
-			Level:  v.GetString("log.level"),
-			Format: v.GetString("log.format"),
+			Level:      v.GetString("log.level"),
+			Format:     v.GetString("log.format"),
+			RedactKeys: listValues(v, "log.redact_keys"),
 		},
+		Jobs: &Jobs{
+			ProviderConcurrency:       v.GetInt64("jobs.provider_concurrency"),
+			HealthCheckSampleSize:     v.GetInt32("jobs.health_check_sample_size"),
+			RefreshAccountTimeout:     durationpb.New(v.GetDuration("jobs.refresh_account_timeout")),
+			MarkNeedsReauth:           v.GetBool("jobs.mark_needs_reauth"),
+			ProviderDownHealthPenalty: v.GetInt32("jobs.provider_down_health_penalty"),
+			RefreshDedupWindow:        durationpb.New(v.GetDuration("jobs.refresh_dedup_window")),
+			InactiveAccountRetention:  durationpb.New(v.GetDuration("jobs.inactive_account_retention")),
+			HealthRecoveryStep:        v.GetInt32("jobs.health_recovery_step"),
+			ProviderCallTimeout:       durationpb.New(v.GetDuration("jobs.provider_call_timeout")),
+			ExpiringRefreshThreshold:  durationpb.New(v.GetDuration("jobs.expiring_refresh_threshold")),
+			RefreshFailureGraceWindow: durationpb.New(v.GetDuration("jobs.refresh_failure_grace_window")),
+		},
+		Pagination: &Pagination{
+			StrictPageSize: v.GetBool("pagination.strict_page_size"),
+		},
+		RateLimit: &RateLimit{
+			ProviderConcurrency:   providerConcurrencyLimits(v),
+			RpmBurst:              v.GetInt32("rate_limit.rpm_burst"),
+			MaxTokensPerRequest:   v.GetInt32("rate_limit.max_tokens_per_request"),
+			ConcurrencyExpiry:     durationpb.New(v.GetDuration("rate_limit.concurrency_expiry")),
+			StrictTokenEstimation: v.GetBool("rate_limit.strict_token_estimation"),
+			CounterTtl:            durationpb.New(v.GetDuration("rate_limit.counter_ttl")),
+			ConcurrencyStaleAfter: durationpb.New(v.GetDuration("rate_limit.concurrency_stale_after")),
+			RpmWindow:             v.GetString("rate_limit.rpm_window"),
+			ZeroLimit:             v.GetString("rate_limit.zero_limit"),
+			TokenizedProviders:    listValues(v, "rate_limit.tokenized_providers"),
+		},
+		AccountGroup: &AccountGroup{
+			RejectDuplicateMembers: v.GetBool("account_group.reject_duplicate_members"),
+			MinHealthScore:         v.GetInt32("account_group.min_health_score"),
+		},
+		Oauth: &OAuth{
+			MaxSessionsPerActor: v.GetInt32("oauth.max_sessions_per_actor"),
+			SessionRateWindow:   durationpb.New(v.GetDuration("oauth.session_rate_window")),
+			Endpoints:           oauthEndpoints(v),
+			ProxyPrecedence:     listValues(v, "oauth.proxy_precedence"),
+			PassthroughHeaders:  listValues(v, "oauth.passthrough_headers"),
+			MaxResponseBytes:    v.GetInt64("oauth.max_response_bytes"),
+		},
+		CircuitBreaker: &CircuitBreaker{
+			ImmediateTripStatusCodes: immediateTripStatusCodes(v),
+		},
+		EnabledProviders:  listValues(v, "enabled_providers"),
+		AccountTagPattern: v.GetString("account_tag_pattern"),
 	}
 
 	// Validate required fields
@@ -105,6 +170,113 @@ func NewBootstrap(configPath string) (*Bootstrap, error) {
 	return bc, nil
 }
 
+// providerConcurrencyLimits reads rate_limit.provider_concurrency as provider name -> limit.
+func providerConcurrencyLimits(v *viper.Viper) map[string]int32 {
+	raw := v.GetStringMap("rate_limit.provider_concurrency")
+	if len(raw) == 0 {
+		return nil
+	}
+
+	limits := make(map[string]int32, len(raw))
+	for provider := range raw {
+		limits[provider] = v.GetInt32("rate_limit.provider_concurrency." + provider)
+	}
+	return limits
+}
+
+// oauthEndpoints reads oauth.endpoints as a provider -> endpoint overrides map.
+func oauthEndpoints(v *viper.Viper) map[string]*OAuthEndpoints {
+	raw := v.GetStringMap("oauth.endpoints")
+	if len(raw) == 0 {
+		return nil
+	}
+
+	endpoints := make(map[string]*OAuthEndpoints, len(raw))
+	for provider := range raw {
+		prefix := "oauth.endpoints." + provider + "."
+		endpoints[provider] = &OAuthEndpoints{
+			BaseUrl:       v.GetString(prefix + "base_url"),
+			AuthorizePath: v.GetString(prefix + "authorize_path"),
+			TokenPath:     v.GetString(prefix + "token_path"),
+		}
+	}
+	return endpoints
+}
+
+// immediateTripStatusCodes reads circuit_breaker.immediate_trip_status_codes as a list of HTTP status codes.
+func immediateTripStatusCodes(v *viper.Viper) []int32 {
+	raw := v.GetIntSlice("circuit_breaker.immediate_trip_status_codes")
+	if len(raw) == 0 {
+		return nil
+	}
+
+	codes := make([]int32, 0, len(raw))
+	for _, code := range raw {
+		codes = append(codes, int32(code))
+	}
+	return codes
+}
+
+// listValues reads a list option (e.g. auth.admin_api_keys) as a YAML list or a comma/space
+// separated environment variable, dropping blanks.
+func listValues(v *viper.Viper, key string) []string {
+	var values []string
+	for _, entry := range v.GetStringSlice(key) {
+		for _, value := range strings.Split(entry, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// proxyPrecedenceProblems reports unknown or repeated tiers in oauth.proxy_precedence.
+func proxyPrecedenceProblems(order []string) []string {
+	var problems []string
+	for i, tier := range order {
+		switch tier {
+		case "request", "account", "env":
+		default:
+			problems = append(problems, fmt.Sprintf("oauth.proxy_precedence must only contain request, account, env, got %q", tier))
+			continue
+		}
+		if slices.Contains(order[:i], tier) {
+			problems = append(problems, fmt.Sprintf("oauth.proxy_precedence lists %q more than once", tier))
+		}
+	}
+	return problems
+}
+
+// knownProviders are the provider names accepted in enabled_providers and rate_limit.tokenized_providers.
+var knownProviders = []string{
+	"claude-official", "claude-console", "bedrock", "ccr", "droid", "gemini",
+	"openai-responses", "codex-cli", "azure-openai",
+}
+
+// providerListProblems reports unknown providers in the provider list at key.
+func providerListProblems(key string, providers []string) []string {
+	var problems []string
+	for _, provider := range providers {
+		if !slices.Contains(knownProviders, provider) {
+			problems = append(problems, fmt.Sprintf("%s contains unknown provider %q (must be one of %s)",
+				key, provider, strings.Join(knownProviders, ", ")))
+		}
+	}
+	return problems
+}
+
+// passthroughHeaderProblems reports malformed header names in oauth.passthrough_headers.
+func passthroughHeaderProblems(names []string) []string {
+	var problems []string
+	for _, name := range names {
+		if !httpguts.ValidHeaderFieldName(name) {
+			problems = append(problems, fmt.Sprintf("oauth.passthrough_headers contains invalid header name %q", name))
+		}
+	}
+	return problems
+}
+
 // setDefaults sets default configuration values.
 func setDefaults(v *viper.Viper) {
 	// Server defaults
@@ -115,6 +287,7 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.grpc.network", "tcp")
 	v.SetDefault("server.grpc.addr", ":9000")
 	v.SetDefault("server.grpc.timeout", 10*time.Minute)
+	v.SetDefault("server.shutdown_timeout", 30*time.Second)
 
 	// Data defaults
 	v.SetDefault("data.database.driver", "mysql")
@@ -124,6 +297,8 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("data.redis.addr", "127.0.0.1:6379")
 	v.SetDefault("data.redis.read_timeout", 200*time.Millisecond)
 	v.SetDefault("data.redis.write_timeout", 200*time.Millisecond)
+	v.SetDefault("data.redis.mode", "single")
+	v.SetDefault("data.missing_provider_policy", "skip")
 
 	// Auth defaults
 	// Note: auth.jwt.secret and auth.encryption.key are required from environment
@@ -132,11 +307,57 @@ func setDefaults(v *viper.Viper) {
 	// Log defaults
 	v.SetDefault("log.level", "info")
 	v.SetDefault("log.format", "json")
+	v.SetDefault("log.redact_keys", []string{"api_key", "oauth_data", "access_token", "refresh_token", "authorization"})
+
+	// Background job defaults
+	v.SetDefault("jobs.provider_concurrency", 10)
+	v.SetDefault("jobs.health_check_sample_size", 0)
+	v.SetDefault("jobs.refresh_account_timeout", 30*time.Second)
+	v.SetDefault("jobs.mark_needs_reauth", true)
+	v.SetDefault("jobs.provider_down_health_penalty", 0)
+	v.SetDefault("jobs.refresh_dedup_window", 10*time.Minute)
+	v.SetDefault("jobs.inactive_account_retention", 0)
+	v.SetDefault("jobs.health_recovery_step", 0)
+	v.SetDefault("jobs.provider_call_timeout", 60*time.Second)
+	v.SetDefault("jobs.expiring_refresh_threshold", 2*time.Hour)
+	v.SetDefault("jobs.refresh_failure_grace_window", time.Hour)
+
+	// Pagination defaults
+	v.SetDefault("pagination.strict_page_size", false)
+
+	// Rate limit defaults
+	v.SetDefault("rate_limit.rpm_burst", 0)
+	v.SetDefault("rate_limit.concurrency_expiry", 10*time.Minute)
+	v.SetDefault("rate_limit.max_tokens_per_request", 0)
+	v.SetDefault("rate_limit.strict_token_estimation", false)
+	v.SetDefault("rate_limit.counter_ttl", time.Minute)
+	v.SetDefault("rate_limit.concurrency_stale_after", 0)
+	v.SetDefault("rate_limit.rpm_window", "fixed")
+	v.SetDefault("rate_limit.zero_limit", "unlimited")
+	v.SetDefault("auth.encryption.account_binding", "off")
+
+	// Account group defaults
+	v.SetDefault("account_group.reject_duplicate_members", false)
+	v.SetDefault("account_group.min_health_score", 0)
+
+	// OAuth session creation limit defaults
+	v.SetDefault("oauth.max_sessions_per_actor", 0)
+	v.SetDefault("oauth.session_rate_window", time.Minute)
+	v.SetDefault("oauth.proxy_precedence", []string{"request", "account", "env"})
+	v.SetDefault("oauth.passthrough_headers", []string{"X-Request-Id", "X-Correlation-Id"})
+	v.SetDefault("oauth.max_response_bytes", 1<<20)
 }
 
-// Validate checks that all required configuration fields are present and valid.
-// It returns an error listing all missing required fields.
+// Validate checks that all required configuration fields are present and that option values are in range.
+// It returns a *ValidationError listing every problem found; Bootstrap.Validate adds the startup checks
+// (key lengths, addresses, log settings) on top of these.
 func Validate(bc *Bootstrap) error {
+	return newValidationError(loadProblems(bc))
+}
+
+// loadProblems returns the missing required fields and out-of-range options checked when loading configuration.
+func loadProblems(bc *Bootstrap) []string {
+	var problems []string
 	var missingFields []string
 
 	// Check required database configuration
@@ -154,8 +375,123 @@ func Validate(bc *Bootstrap) error {
 	}
 
 	if len(missingFields) > 0 {
-		return fmt.Errorf("missing required configuration fields: %s", strings.Join(missingFields, ", "))
+		problems = append(problems, fmt.Sprintf("missing required configuration fields: %s", strings.Join(missingFields, ", ")))
+	}
+
+	switch redisConf := bc.GetData().GetRedis(); redisConf.GetMode() {
+	case "", "single", "cluster":
+	case "sentinel":
+		if redisConf.GetMasterName() == "" {
+			problems = append(problems, "data.redis.master_name is required when data.redis.mode is sentinel")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("data.redis.mode must be one of single, cluster, sentinel, got %q", redisConf.GetMode()))
+	}
+	switch policy := bc.GetData().GetMissingProviderPolicy(); policy {
+	case "", "skip", "include":
+	default:
+		problems = append(problems, fmt.Sprintf("data.missing_provider_policy must be one of skip, include, got %q", policy))
+	}
+	if timeout := bc.GetServer().GetShutdownTimeout().AsDuration(); timeout < 0 {
+		problems = append(problems, fmt.Sprintf("server.shutdown_timeout must be >= 0, got %s", timeout))
+	}
+	if size := bc.GetJobs().GetHealthCheckSampleSize(); size < 0 {
+		problems = append(problems, fmt.Sprintf("jobs.health_check_sample_size must be >= 0, got %d", size))
+	}
+	if timeout := bc.GetJobs().GetRefreshAccountTimeout().AsDuration(); timeout < 0 {
+		problems = append(problems, fmt.Sprintf("jobs.refresh_account_timeout must be >= 0, got %s", timeout))
+	}
+	if timeout := bc.GetJobs().GetProviderCallTimeout().AsDuration(); timeout < 0 {
+		problems = append(problems, fmt.Sprintf("jobs.provider_call_timeout must be >= 0, got %s", timeout))
+	}
+	if threshold := bc.GetJobs().GetExpiringRefreshThreshold().AsDuration(); threshold < 0 {
+		problems = append(problems, fmt.Sprintf("jobs.expiring_refresh_threshold must be >= 0, got %s", threshold))
+	}
+	if window := bc.GetJobs().GetRefreshFailureGraceWindow().AsDuration(); window < 0 {
+		problems = append(problems, fmt.Sprintf("jobs.refresh_failure_grace_window must be >= 0, got %s", window))
+	}
+	if penalty := bc.GetJobs().GetProviderDownHealthPenalty(); penalty < 0 || penalty > 100 {
+		problems = append(problems, fmt.Sprintf("jobs.provider_down_health_penalty must be between 0 and 100, got %d", penalty))
+	}
+	if step := bc.GetJobs().GetHealthRecoveryStep(); step < 0 || step > 100 {
+		problems = append(problems, fmt.Sprintf("jobs.health_recovery_step must be between 0 and 100, got %d", step))
+	}
+	if score := bc.GetAccountGroup().GetMinHealthScore(); score < 0 || score > 100 {
+		problems = append(problems, fmt.Sprintf("account_group.min_health_score must be between 0 and 100, got %d", score))
+	}
+	if window := bc.GetJobs().GetRefreshDedupWindow().AsDuration(); window < 0 {
+		problems = append(problems, fmt.Sprintf("jobs.refresh_dedup_window must be >= 0, got %s", window))
+	}
+	if retention := bc.GetJobs().GetInactiveAccountRetention().AsDuration(); retention < 0 {
+		problems = append(problems, fmt.Sprintf("jobs.inactive_account_retention must be >= 0, got %s", retention))
+	}
+	for _, provider := range sortedKeys(bc.GetRateLimit().GetProviderConcurrency()) {
+		if limit := bc.GetRateLimit().GetProviderConcurrency()[provider]; limit < 0 {
+			problems = append(problems, fmt.Sprintf("rate_limit.provider_concurrency.%s must be >= 0, got %d", provider, limit))
+		}
+	}
+	if burst := bc.GetRateLimit().GetRpmBurst(); burst < 0 {
+		problems = append(problems, fmt.Sprintf("rate_limit.rpm_burst must be >= 0, got %d", burst))
+	}
+	switch window := bc.GetRateLimit().GetRpmWindow(); window {
+	case "", "fixed", "sliding":
+	default:
+		problems = append(problems, fmt.Sprintf("rate_limit.rpm_window must be one of fixed, sliding, got %q", window))
+	}
+	switch zeroLimit := bc.GetRateLimit().GetZeroLimit(); zeroLimit {
+	case "", "unlimited", "blocked":
+	default:
+		problems = append(problems, fmt.Sprintf("rate_limit.zero_limit must be one of unlimited, blocked, got %q", zeroLimit))
+	}
+	problems = append(problems, providerListProblems("rate_limit.tokenized_providers", bc.GetRateLimit().GetTokenizedProviders())...)
+	switch binding := bc.GetAuth().GetEncryption().GetAccountBinding(); binding {
+	case "", "off", "bind", "require":
+	default:
+		problems = append(problems, fmt.Sprintf("auth.encryption.account_binding must be one of off, bind, require, got %q", binding))
+	}
+	if maxTokens := bc.GetRateLimit().GetMaxTokensPerRequest(); maxTokens < 0 {
+		problems = append(problems, fmt.Sprintf("rate_limit.max_tokens_per_request must be >= 0, got %d", maxTokens))
+	}
+	if expiry := bc.GetRateLimit().GetConcurrencyExpiry().AsDuration(); expiry < 0 {
+		problems = append(problems, fmt.Sprintf("rate_limit.concurrency_expiry must be >= 0, got %s", expiry))
+	}
+	if ttl := bc.GetRateLimit().GetCounterTtl().AsDuration(); ttl < 0 || (ttl > 0 && ttl < time.Second) {
+		problems = append(problems, fmt.Sprintf("rate_limit.counter_ttl must be 0 or >= 1s, got %s", ttl))
+	}
+	if staleAfter := bc.GetRateLimit().GetConcurrencyStaleAfter().AsDuration(); staleAfter != 0 {
+		expiry := bc.GetRateLimit().GetConcurrencyExpiry().AsDuration()
+		if expiry == 0 {
+			expiry = 10 * time.Minute
+		}
+		if staleAfter < 3*time.Second || staleAfter >= expiry {
+			problems = append(problems, fmt.Sprintf("rate_limit.concurrency_stale_after must be 0 or between 3s and rate_limit.concurrency_expiry (%s), got %s", expiry, staleAfter))
+		}
+	}
+	if maxSessions := bc.GetOauth().GetMaxSessionsPerActor(); maxSessions < 0 {
+		problems = append(problems, fmt.Sprintf("oauth.max_sessions_per_actor must be >= 0, got %d", maxSessions))
+	}
+	if window := bc.GetOauth().GetSessionRateWindow().AsDuration(); window < 0 {
+		problems = append(problems, fmt.Sprintf("oauth.session_rate_window must be >= 0, got %s", window))
+	}
+	if maxBytes := bc.GetOauth().GetMaxResponseBytes(); maxBytes < 0 {
+		problems = append(problems, fmt.Sprintf("oauth.max_response_bytes must be >= 0, got %d", maxBytes))
+	}
+	problems = append(problems, proxyPrecedenceProblems(bc.GetOauth().GetProxyPrecedence())...)
+	problems = append(problems, passthroughHeaderProblems(bc.GetOauth().GetPassthroughHeaders())...)
+	for _, provider := range sortedKeys(bc.GetOauth().GetEndpoints()) {
+		problems = append(problems, oauthEndpointProblems(provider, bc.GetOauth().GetEndpoints()[provider])...)
+	}
+	for _, code := range bc.GetCircuitBreaker().GetImmediateTripStatusCodes() {
+		if code < 100 || code > 599 {
+			problems = append(problems, fmt.Sprintf("circuit_breaker.immediate_trip_status_codes must be HTTP status codes, got %d", code))
+		}
+	}
+	problems = append(problems, providerListProblems("enabled_providers", bc.GetEnabledProviders())...)
+	if pattern := bc.GetAccountTagPattern(); pattern != "" {
+		if _, err := regexp.Compile(pattern); err != nil {
+			problems = append(problems, fmt.Sprintf("account_tag_pattern must be a valid regular expression: %v", err))
+		}
 	}
 
-	return nil
+	return problems
 }
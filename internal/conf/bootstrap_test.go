@@ -47,6 +47,7 @@ data:
 	assert.Equal(t, ":9000", bc.Server.Grpc.Addr)
 	assert.Equal(t, "tcp", bc.Server.Grpc.Network)
 	assert.Equal(t, 10*time.Minute, bc.Server.Grpc.Timeout.AsDuration())
+	assert.Equal(t, 30*time.Second, bc.Server.ShutdownTimeout.AsDuration())
 
 	// Verify data defaults
 	assert.Equal(t, "mysql", bc.Data.Database.Driver)
@@ -61,10 +62,19 @@ data:
 	assert.Equal(t, "test-jwt-secret-key", bc.Auth.Jwt.Secret)
 	assert.Equal(t, 24*time.Hour, bc.Auth.Jwt.Expires.AsDuration())
 	assert.Equal(t, "test-encryption-key-12345678", bc.Auth.Encryption.Key)
+	assert.Empty(t, bc.Auth.AdminApiKeys)
 
 	// Verify log defaults
 	assert.Equal(t, "info", bc.Log.Level)
 	assert.Equal(t, "json", bc.Log.Format)
+	assert.Equal(t, int64(10), bc.Jobs.ProviderConcurrency)
+	assert.Equal(t, int32(0), bc.Jobs.HealthCheckSampleSize)
+	assert.False(t, bc.Pagination.StrictPageSize)
+	assert.Empty(t, bc.RateLimit.ProviderConcurrency)
+	assert.Equal(t, int32(0), bc.RateLimit.RpmBurst)
+	assert.Equal(t, int32(0), bc.RateLimit.MaxTokensPerRequest)
+	assert.False(t, bc.RateLimit.StrictTokenEstimation)
+	assert.False(t, bc.AccountGroup.RejectDuplicateMembers)
 }
 
 func TestNewBootstrap_EnvOverrides(t *testing.T) {
@@ -284,6 +294,654 @@ data:
 	assert.Equal(t, ":8888", bc.Server.Http.Addr, "Environment variable should override config file")
 }
 
+func TestNewBootstrap_ProviderConcurrency(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	configContent := `rate_limit:
+  provider_concurrency:
+    claude-console: 50
+    openai-responses: 100
+`
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	require.NoError(t, err)
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int32{"claude-console": 50, "openai-responses": 100}, bc.RateLimit.ProviderConcurrency)
+
+	err = os.WriteFile(configPath, []byte("rate_limit:\n  provider_concurrency:\n    claude-console: -1\n"), 0644)
+	require.NoError(t, err)
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_RPMBurst(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  rpm_burst: 20\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, int32(20), bc.RateLimit.RpmBurst)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  rpm_burst: -1\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_MaxTokensPerRequest(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  max_tokens_per_request: 200000\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, int32(200000), bc.RateLimit.MaxTokensPerRequest)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  max_tokens_per_request: -1\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_StrictTokenEstimation(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  strict_token_estimation: true\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.True(t, bc.RateLimit.StrictTokenEstimation)
+}
+
+func TestNewBootstrap_ConcurrencyExpiry(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, bc.RateLimit.ConcurrencyExpiry.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  concurrency_expiry: 45m\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Minute, bc.RateLimit.ConcurrencyExpiry.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  concurrency_expiry: -1m\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_CounterTTL(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, bc.RateLimit.CounterTtl.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  counter_ttl: 90s\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, bc.RateLimit.CounterTtl.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  counter_ttl: 500ms\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, "rate_limit.counter_ttl")
+}
+
+func TestNewBootstrap_ConcurrencyStaleAfter(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Zero(t, bc.RateLimit.ConcurrencyStaleAfter.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  concurrency_stale_after: 45s\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Second, bc.RateLimit.ConcurrencyStaleAfter.AsDuration())
+
+	for _, body := range []string{
+		"rate_limit:\n  concurrency_stale_after: 1s\n",
+		"rate_limit:\n  concurrency_expiry: 5m\n  concurrency_stale_after: 5m\n",
+	} {
+		require.NoError(t, os.WriteFile(configPath, []byte(body), 0644))
+		_, err = NewBootstrap(configPath)
+		assert.ErrorContains(t, err, "rate_limit.concurrency_stale_after")
+	}
+}
+
+func TestNewBootstrap_RPMWindow(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "fixed", bc.RateLimit.RpmWindow)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  rpm_window: sliding\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "sliding", bc.RateLimit.RpmWindow)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  rpm_window: leaky\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, "rate_limit.rpm_window")
+}
+
+func TestNewBootstrap_AccountBinding(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "off", bc.Auth.Encryption.AccountBinding)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("auth:\n  encryption:\n    account_binding: require\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "require", bc.Auth.Encryption.AccountBinding)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("auth:\n  encryption:\n    account_binding: off\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "off", bc.Auth.Encryption.AccountBinding)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("auth:\n  encryption:\n    account_binding: strict\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, "auth.encryption.account_binding")
+}
+
+func TestNewBootstrap_ZeroLimit(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "unlimited", bc.RateLimit.ZeroLimit)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  zero_limit: blocked\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "blocked", bc.RateLimit.ZeroLimit)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  zero_limit: deny\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, "rate_limit.zero_limit")
+}
+
+func TestNewBootstrap_TokenizedProviders(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Empty(t, bc.RateLimit.TokenizedProviders)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  tokenized_providers: [claude-console, gemini]\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"claude-console", "gemini"}, bc.RateLimit.TokenizedProviders)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("rate_limit:\n  tokenized_providers: [claude]\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, `rate_limit.tokenized_providers contains unknown provider "claude"`)
+}
+
+func TestNewBootstrap_HealthCheckSampleSize(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  health_check_sample_size: 25\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, int32(25), bc.Jobs.HealthCheckSampleSize)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  health_check_sample_size: -1\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_RefreshAccountTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, bc.Jobs.RefreshAccountTimeout.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  refresh_account_timeout: 45s\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Second, bc.Jobs.RefreshAccountTimeout.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  refresh_account_timeout: -1s\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_ProviderCallTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 60*time.Second, bc.Jobs.ProviderCallTimeout.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  provider_call_timeout: 15s\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 15*time.Second, bc.Jobs.ProviderCallTimeout.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  provider_call_timeout: -1s\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_ExpiringRefreshThreshold(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Hour, bc.Jobs.ExpiringRefreshThreshold.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  expiring_refresh_threshold: 4h\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 4*time.Hour, bc.Jobs.ExpiringRefreshThreshold.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  expiring_refresh_threshold: -1h\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, "jobs.expiring_refresh_threshold")
+}
+
+func TestNewBootstrap_RefreshFailureGraceWindow(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, bc.Jobs.RefreshFailureGraceWindow.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  refresh_failure_grace_window: 30m\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, bc.Jobs.RefreshFailureGraceWindow.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  refresh_failure_grace_window: -1m\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, "jobs.refresh_failure_grace_window")
+}
+
+func TestNewBootstrap_MarkNeedsReauth(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.True(t, bc.Jobs.MarkNeedsReauth)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  mark_needs_reauth: false\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.False(t, bc.Jobs.MarkNeedsReauth)
+}
+
+func TestNewBootstrap_RefreshDedupWindow(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, bc.Jobs.RefreshDedupWindow.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  refresh_dedup_window: 0s\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Zero(t, bc.Jobs.RefreshDedupWindow.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  refresh_dedup_window: -1m\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_InactiveAccountRetention(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Zero(t, bc.Jobs.InactiveAccountRetention.AsDuration(), "retention is disabled by default")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  inactive_account_retention: 720h\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, bc.Jobs.InactiveAccountRetention.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  inactive_account_retention: -1h\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_OAuthSessionLimit(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("oauth:\n  max_sessions_per_actor: 10\n  session_rate_window: 30s\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, int32(10), bc.Oauth.MaxSessionsPerActor)
+	assert.Equal(t, 30*time.Second, bc.Oauth.SessionRateWindow.AsDuration())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("oauth:\n  max_sessions_per_actor: -1\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_ProxyPrecedence(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"request", "account", "env"}, bc.Oauth.ProxyPrecedence)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("oauth:\n  proxy_precedence: [account, request, env]\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"account", "request", "env"}, bc.Oauth.ProxyPrecedence)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("oauth:\n  proxy_precedence: [account, global]\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, "oauth.proxy_precedence")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("oauth:\n  proxy_precedence: [account, account]\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, "more than once")
+}
+
+func TestNewBootstrap_EnabledProviders(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Empty(t, bc.EnabledProviders)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("enabled_providers: [claude-console, openai-responses]\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"claude-console", "openai-responses"}, bc.EnabledProviders)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("enabled_providers: [claude-console, openai]\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, "enabled_providers")
+}
+
+func TestNewBootstrap_AccountTagPattern(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Empty(t, bc.AccountTagPattern)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("account_tag_pattern: '^[a-z0-9-]+$'\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "^[a-z0-9-]+$", bc.AccountTagPattern)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("account_tag_pattern: '[a-z'\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, "account_tag_pattern")
+}
+
+func TestNewBootstrap_PassthroughHeaders(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"X-Request-Id", "X-Correlation-Id"}, bc.Oauth.PassthroughHeaders)
+
+	t.Setenv("QUOTALANE_OAUTH_PASSTHROUGH_HEADERS", "X-Trace-Id, X-Request-Id")
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"X-Trace-Id", "X-Request-Id"}, bc.Oauth.PassthroughHeaders)
+
+	t.Setenv("QUOTALANE_OAUTH_PASSTHROUGH_HEADERS", "X-Trace@Id")
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, "oauth.passthrough_headers")
+}
+
+func TestNewBootstrap_MaxResponseBytes(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1<<20), bc.Oauth.MaxResponseBytes)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("oauth:\n  max_response_bytes: 65536\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(65536), bc.Oauth.MaxResponseBytes)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("oauth:\n  max_response_bytes: -1\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, "oauth.max_response_bytes")
+}
+
+func TestNewBootstrap_LogRedactKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api_key", "oauth_data", "access_token", "refresh_token", "authorization"}, bc.Log.RedactKeys)
+
+	t.Setenv("QUOTALANE_LOG_REDACT_KEYS", "api_key, session_id")
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api_key", "session_id"}, bc.Log.RedactKeys)
+}
+
+func TestNewBootstrap_RedisMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "single", bc.Data.Redis.Mode)
+	assert.Empty(t, bc.Data.Redis.Addrs)
+
+	t.Setenv("QUOTALANE_DATA_REDIS_ADDRS", "10.0.0.1:7000, 10.0.0.2:7000")
+	require.NoError(t, os.WriteFile(configPath, []byte("data:\n  redis:\n    mode: cluster\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "cluster", bc.Data.Redis.Mode)
+	assert.Equal(t, []string{"10.0.0.1:7000", "10.0.0.2:7000"}, bc.Data.Redis.Addrs)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("data:\n  redis:\n    mode: sentinel\n    master_name: mymaster\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "mymaster", bc.Data.Redis.MasterName)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("data:\n  redis:\n    mode: sentinel\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, "master_name")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("data:\n  redis:\n    mode: ring\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_CircuitBreakerImmediateTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Empty(t, bc.CircuitBreaker.ImmediateTripStatusCodes)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("circuit_breaker:\n  immediate_trip_status_codes: [403, 451]\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{403, 451}, bc.CircuitBreaker.ImmediateTripStatusCodes)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("circuit_breaker:\n  immediate_trip_status_codes: [4030]\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_AdminAPIKeys(t *testing.T) {
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+	t.Setenv("ADMIN_API_KEYS", "sk-admin-1, sk-admin-2,,")
+
+	bc, err := NewBootstrap("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sk-admin-1", "sk-admin-2"}, bc.Auth.AdminApiKeys)
+}
+
 func TestValidate_AllFieldsPresent(t *testing.T) {
 	bc := &Bootstrap{
 		Server: &Server{
@@ -316,3 +974,121 @@ func TestValidate_NilBootstrap(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "missing required configuration fields")
 }
+
+func TestNewBootstrap_HealthRecoveryStep(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), bc.Jobs.HealthRecoveryStep)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  health_recovery_step: 10\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, int32(10), bc.Jobs.HealthRecoveryStep)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  health_recovery_step: -1\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_ProviderDownHealthPenalty(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), bc.Jobs.ProviderDownHealthPenalty)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  provider_down_health_penalty: 5\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, int32(5), bc.Jobs.ProviderDownHealthPenalty)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("jobs:\n  provider_down_health_penalty: 101\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_MinHealthScore(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), bc.AccountGroup.MinHealthScore)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("account_group:\n  min_health_score: 50\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, int32(50), bc.AccountGroup.MinHealthScore)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("account_group:\n  min_health_score: -1\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.Error(t, err)
+}
+
+func TestNewBootstrap_OAuthEndpoints(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	yaml := "oauth:\n  endpoints:\n    codex-cli:\n      base_url: https://gateway.example.com\n      token_path: /openai/oauth/token\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(yaml), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	require.Contains(t, bc.Oauth.Endpoints, "codex-cli")
+	assert.Equal(t, "https://gateway.example.com", bc.Oauth.Endpoints["codex-cli"].BaseUrl)
+	assert.Equal(t, "/openai/oauth/token", bc.Oauth.Endpoints["codex-cli"].TokenPath)
+	assert.Empty(t, bc.Oauth.Endpoints["codex-cli"].AuthorizePath)
+
+	yaml = "oauth:\n  endpoints:\n    gemini:\n      base_url: gateway.example.com\n      token_path: oauth/token\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(yaml), 0644))
+	_, err = NewBootstrap(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "oauth.endpoints.gemini: provider must be one of")
+	assert.Contains(t, err.Error(), "oauth.endpoints.gemini.base_url")
+	assert.Contains(t, err.Error(), "oauth.endpoints.gemini.token_path")
+}
+
+func TestNewBootstrap_MissingProviderPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	t.Setenv("MYSQL_DSN", "user:pass@tcp(localhost:3306)/testdb")
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+	t.Setenv("ENCRYPTION_KEY", "test-encryption-key")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("log:\n  level: info\n"), 0644))
+	bc, err := NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "skip", bc.Data.MissingProviderPolicy)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("data:\n  missing_provider_policy: include\n"), 0644))
+	bc, err = NewBootstrap(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "include", bc.Data.MissingProviderPolicy)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("data:\n  missing_provider_policy: drop\n"), 0644))
+	_, err = NewBootstrap(configPath)
+	assert.ErrorContains(t, err, "data.missing_provider_policy must be one of skip, include")
+}
@@ -0,0 +1,143 @@
+package conf
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// EncryptionKeyLength is the required length of auth.encryption.key (AES-256).
+const EncryptionKeyLength = 32
+
+// ValidationError lists every configuration problem found, so a bad deployment fails once
+// at startup with the complete list instead of one field at a time (or a panic at runtime).
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0]
+	}
+	return fmt.Sprintf("%d configuration problems: %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// newValidationError returns nil when there are no problems.
+func newValidationError(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// Validate checks every critical field of the bootstrap configuration before the application is wired.
+// In addition to the checks run by NewBootstrap it verifies the encryption key length, server and
+// Redis addresses, timeouts and log settings. It returns a *ValidationError listing all problems.
+func (x *Bootstrap) Validate() error {
+	problems := loadProblems(x)
+	problems = append(problems, startupProblems(x)...)
+	return newValidationError(problems)
+}
+
+// startupProblems returns problems that would otherwise only surface while wiring or running the service.
+func startupProblems(bc *Bootstrap) []string {
+	var problems []string
+
+	if key := bc.GetAuth().GetEncryption().GetKey(); key != "" && len(key) != EncryptionKeyLength {
+		problems = append(problems, fmt.Sprintf("auth.encryption.key must be exactly %d bytes, got %d bytes", EncryptionKeyLength, len(key)))
+	}
+	if expires := bc.GetAuth().GetJwt().GetExpires().AsDuration(); expires <= 0 {
+		problems = append(problems, fmt.Sprintf("auth.jwt.expires must be > 0, got %s", expires))
+	}
+
+	if bc.GetServer().GetHttp().GetAddr() == "" {
+		problems = append(problems, "server.http.addr is required")
+	}
+	if bc.GetServer().GetGrpc().GetAddr() == "" {
+		problems = append(problems, "server.grpc.addr is required")
+	}
+	if timeout := bc.GetServer().GetHttp().GetTimeout().AsDuration(); timeout < 0 {
+		problems = append(problems, fmt.Sprintf("server.http.timeout must be >= 0, got %s", timeout))
+	}
+	if timeout := bc.GetServer().GetGrpc().GetTimeout().AsDuration(); timeout < 0 {
+		problems = append(problems, fmt.Sprintf("server.grpc.timeout must be >= 0, got %s", timeout))
+	}
+
+	if driver := bc.GetData().GetDatabase().GetDriver(); driver != "" && driver != "mysql" {
+		problems = append(problems, fmt.Sprintf("data.database.driver must be mysql, got %q", driver))
+	}
+	redisConf := bc.GetData().GetRedis()
+	if redisConf.GetAddr() == "" && len(redisConf.GetAddrs()) == 0 {
+		problems = append(problems, "data.redis.addr or data.redis.addrs is required")
+	}
+	if timeout := redisConf.GetReadTimeout().AsDuration(); timeout < 0 {
+		problems = append(problems, fmt.Sprintf("data.redis.read_timeout must be >= 0, got %s", timeout))
+	}
+	if timeout := redisConf.GetWriteTimeout().AsDuration(); timeout < 0 {
+		problems = append(problems, fmt.Sprintf("data.redis.write_timeout must be >= 0, got %s", timeout))
+	}
+
+	if level := bc.GetLog().GetLevel(); level != "" {
+		if _, err := zapcore.ParseLevel(level); err != nil {
+			problems = append(problems, fmt.Sprintf("log.level must be one of debug, info, warn, error, got %q", level))
+		}
+	}
+	switch format := strings.ToLower(bc.GetLog().GetFormat()); format {
+	case "", "json", "console":
+	default:
+		problems = append(problems, fmt.Sprintf("log.format must be json or console, got %q", format))
+	}
+
+	if concurrency := bc.GetJobs().GetProviderConcurrency(); concurrency < 0 {
+		problems = append(problems, fmt.Sprintf("jobs.provider_concurrency must be >= 0, got %d", concurrency))
+	}
+
+	return problems
+}
+
+// OAuthEndpointProviders are the providers whose OAuth endpoints can be overridden.
+var OAuthEndpointProviders = []string{"claude-official", "codex-cli"}
+
+// oauthEndpointProblems checks one oauth.endpoints entry: a known provider, an absolute http(s)
+// base URL, and paths that start with "/" or are absolute URLs themselves.
+func oauthEndpointProblems(provider string, endpoints *OAuthEndpoints) []string {
+	var problems []string
+	key := "oauth.endpoints." + provider
+
+	known := false
+	for _, p := range OAuthEndpointProviders {
+		known = known || p == provider
+	}
+	if !known {
+		problems = append(problems, fmt.Sprintf("%s: provider must be one of %s", key, strings.Join(OAuthEndpointProviders, ", ")))
+	}
+
+	if base := endpoints.GetBaseUrl(); base != "" && !isHTTPURL(base) {
+		problems = append(problems, fmt.Sprintf("%s.base_url must be an absolute http(s) URL, got %q", key, base))
+	}
+	for field, path := range map[string]string{"authorize_path": endpoints.GetAuthorizePath(), "token_path": endpoints.GetTokenPath()} {
+		if path != "" && !strings.HasPrefix(path, "/") && !isHTTPURL(path) {
+			problems = append(problems, fmt.Sprintf("%s.%s must start with / or be an absolute http(s) URL, got %q", key, field, path))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// sortedKeys returns map keys in sorted order so problem lists are deterministic.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,142 @@
+package conf
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// validBootstrap returns a configuration that passes Bootstrap.Validate.
+func validBootstrap() *Bootstrap {
+	return &Bootstrap{
+		Server: &Server{
+			Http:            &Server_HTTP{Addr: ":8080", Timeout: durationpb.New(time.Minute)},
+			Grpc:            &Server_GRPC{Addr: ":9000", Timeout: durationpb.New(time.Minute)},
+			ShutdownTimeout: durationpb.New(30 * time.Second),
+		},
+		Data: &Data{
+			Database: &Data_Database{Driver: "mysql", Source: "user:pass@tcp(localhost:3306)/testdb"},
+			Redis:    &Data_Redis{Addr: "127.0.0.1:6379", Mode: "single"},
+		},
+		Auth: &Auth{
+			Jwt:        &Auth_JWT{Secret: "test-jwt-secret", Expires: durationpb.New(24 * time.Hour)},
+			Encryption: &Auth_Encryption{Key: "12345678901234567890123456789012"},
+		},
+		Log:  &Log{Level: "info", Format: "json"},
+		Jobs: &Jobs{ProviderConcurrency: 10},
+	}
+}
+
+func validationProblems(t *testing.T, err error) []string {
+	t.Helper()
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "expected *ValidationError, got %v", err)
+	return validationErr.Problems
+}
+
+func TestBootstrapValidate_Valid(t *testing.T) {
+	assert.NoError(t, validBootstrap().Validate())
+}
+
+func TestBootstrapValidate_ReportsAllProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutate   func(bc *Bootstrap)
+		problems []string
+	}{
+		{
+			name: "short encryption key and bad log settings",
+			mutate: func(bc *Bootstrap) {
+				bc.Auth.Encryption.Key = "test-encryption-key"
+				bc.Log.Level = "verbose"
+				bc.Log.Format = "xml"
+			},
+			problems: []string{
+				"auth.encryption.key must be exactly 32 bytes, got 19 bytes",
+				`log.level must be one of debug, info, warn, error, got "verbose"`,
+				`log.format must be json or console, got "xml"`,
+			},
+		},
+		{
+			name: "missing secrets alongside out-of-range limits",
+			mutate: func(bc *Bootstrap) {
+				bc.Data.Database.Source = ""
+				bc.Auth.Jwt.Secret = ""
+				bc.Jobs.HealthCheckSampleSize = -1
+				bc.RateLimit = &RateLimit{RpmBurst: -5, ProviderConcurrency: map[string]int32{"openai": -1, "claude": -2}}
+			},
+			problems: []string{
+				"missing required configuration fields: data.database.source (MYSQL_DSN), auth.jwt.secret (JWT_SECRET)",
+				"jobs.health_check_sample_size must be >= 0, got -1",
+				"rate_limit.provider_concurrency.claude must be >= 0, got -2",
+				"rate_limit.provider_concurrency.openai must be >= 0, got -1",
+				"rate_limit.rpm_burst must be >= 0, got -5",
+			},
+		},
+		{
+			name: "missing addresses and broken redis",
+			mutate: func(bc *Bootstrap) {
+				bc.Server.Http.Addr = ""
+				bc.Server.Grpc.Addr = ""
+				bc.Data.Redis = &Data_Redis{Mode: "sentinel", ReadTimeout: durationpb.New(-time.Second)}
+				bc.Auth.Jwt.Expires = durationpb.New(0)
+			},
+			problems: []string{
+				"data.redis.master_name is required when data.redis.mode is sentinel",
+				"auth.jwt.expires must be > 0, got 0s",
+				"server.http.addr is required",
+				"server.grpc.addr is required",
+				"data.redis.addr or data.redis.addrs is required",
+				"data.redis.read_timeout must be >= 0, got -1s",
+			},
+		},
+		{
+			name: "invalid driver, status codes and penalty",
+			mutate: func(bc *Bootstrap) {
+				bc.Data.Database.Driver = "postgres"
+				bc.Jobs.ProviderDownHealthPenalty = 150
+				bc.CircuitBreaker = &CircuitBreaker{ImmediateTripStatusCodes: []int32{403, 42}}
+			},
+			problems: []string{
+				"jobs.provider_down_health_penalty must be between 0 and 100, got 150",
+				"circuit_breaker.immediate_trip_status_codes must be HTTP status codes, got 42",
+				`data.database.driver must be mysql, got "postgres"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := validBootstrap()
+			tt.mutate(bc)
+
+			err := bc.Validate()
+			require.Error(t, err)
+			assert.Equal(t, tt.problems, validationProblems(t, err))
+			for _, problem := range tt.problems {
+				assert.Contains(t, err.Error(), problem)
+			}
+		})
+	}
+}
+
+func TestBootstrapValidate_EmptyConfig(t *testing.T) {
+	problems := validationProblems(t, (&Bootstrap{}).Validate())
+
+	assert.Contains(t, problems, "missing required configuration fields: data.database.source (MYSQL_DSN), auth.jwt.secret (JWT_SECRET), auth.encryption.key (ENCRYPTION_KEY)")
+	assert.Contains(t, problems, "server.http.addr is required")
+	assert.Contains(t, problems, "data.redis.addr or data.redis.addrs is required")
+}
+
+func TestValidate_SingleProblemMessage(t *testing.T) {
+	bc := validBootstrap()
+	bc.RateLimit = &RateLimit{RpmBurst: -1}
+
+	err := Validate(bc)
+	require.Error(t, err)
+	assert.Equal(t, "rate_limit.rpm_burst must be >= 0, got -1", err.Error())
+}
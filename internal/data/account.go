@@ -39,10 +39,11 @@ type AccountStatus string
 
 // Account status constants representing the current state of an account.
 const (
-	StatusCreated  AccountStatus = "created" // 账户已创建但未验证
-	StatusActive   AccountStatus = "active"
-	StatusInactive AccountStatus = "inactive"
-	StatusError    AccountStatus = "error"
+	StatusCreated    AccountStatus = "created"    // 账户已创建但未验证
+	StatusValidating AccountStatus = "validating" // 凭证验证中
+	StatusActive     AccountStatus = "active"
+	StatusInactive   AccountStatus = "inactive"
+	StatusError      AccountStatus = "error"
 )
 
 // Account is the GORM model for api_accounts table.
@@ -50,11 +51,16 @@ type Account struct {
 	ID                 int64           `gorm:"primaryKey;column:id"`
 	Name               string          `gorm:"column:name;size:100;not null"`
 	Description        string          `gorm:"column:description;type:text"`
+	Notes              string          `gorm:"column:notes;type:text"` // 运维内部备注（仅管理员可见）
 	Provider           AccountProvider `gorm:"column:provider;type:enum('claude-official','claude-console','bedrock','ccr','droid','gemini','openai-responses','codex-cli','azure-openai');not null"`
 	APIKeyEncrypted    string          `gorm:"column:api_key_encrypted;type:text"`
 	BaseAPI            string          `gorm:"column:base_api;size:255"` // OpenAI Responses 等服务的 API 基础地址
 	OAuthDataEncrypted string          `gorm:"column:oauth_data_encrypted;type:text"`
 	OAuthExpiresAt     *time.Time      `gorm:"column:oauth_expires_at"` // OAuth Token 过期时间（可为 NULL）
+	// NextRefreshAttemptAt Token 刷新失败后的下次允许尝试时间（NULL 表示不退避）
+	NextRefreshAttemptAt *time.Time `gorm:"column:next_refresh_attempt_at"`
+	// LastCheckedAt 最近一次健康检查时间（NULL 表示从未检查），用于健康检查轮换抽样
+	LastCheckedAt *time.Time `gorm:"column:last_checked_at"`
 	// Codex CLI OAuth 相关字段
 	AccessTokenEncrypted  string        `gorm:"column:access_token_encrypted;type:varchar(1024)"`
 	RefreshTokenEncrypted string        `gorm:"column:refresh_token_encrypted;type:varchar(1024)"`
@@ -63,9 +69,14 @@ type Account struct {
 	Organizations         string        `gorm:"column:organizations;type:text"` // JSON array
 	RpmLimit              int32         `gorm:"column:rpm_limit;default:0;not null"`
 	TpmLimit              int32         `gorm:"column:tpm_limit;default:0;not null"`
+	DailyTokenLimit       int32         `gorm:"column:daily_token_limit;default:0;not null"` // 每日（UTC）Token 总数上限，0 表示不限制
+	AccountPriority       int32         `gorm:"column:account_priority;default:0;not null"`  // 账户优先级：组内负载与健康相同时优先选择较高者
 	HealthScore           int           `gorm:"column:health_score;default:100;not null"`
 	IsCircuitBroken       bool          `gorm:"column:is_circuit_broken;default:false;not null"`
-	Status                AccountStatus `gorm:"column:status;type:enum('created','active','inactive','error');default:'active';not null"`
+	IsDraining            bool          `gorm:"column:is_draining;default:false;not null"`  // 排空中：不再接收新请求
+	NeedsReauth           bool          `gorm:"column:needs_reauth;default:false;not null"` // refresh token 已永久失效，需要重新授权
+	Status                AccountStatus `gorm:"column:status;type:enum('created','validating','active','inactive','error');default:'active';not null"`
+	InactivatedAt         *time.Time    `gorm:"column:inactivated_at"`                        // 软删除（置为 inactive）时间，非 inactive 时为 NULL
 	Metadata              *string       `gorm:"column:metadata;type:json"`                    // JSON string (pointer for NULL support)
 	Version               int32         `gorm:"column:version;default:1;not null"`            // 乐观锁版本号
 	CircuitBrokenAt       *time.Time    `gorm:"column:circuit_broken_at"`                     // 熔断触发时间
@@ -144,6 +155,8 @@ func ProviderToProto(p AccountProvider) v1.AccountProvider {
 		return v1.AccountProvider_OPENAI_RESPONSES
 	case ProviderAzureOpenAI:
 		return v1.AccountProvider_AZURE_OPENAI
+	case ProviderCodexCLI:
+		return v1.AccountProvider_CODEX_CLI
 	default:
 		return v1.AccountProvider_ACCOUNT_PROVIDER_UNSPECIFIED
 	}
@@ -168,6 +181,8 @@ func ProviderFromProto(p v1.AccountProvider) AccountProvider {
 		return ProviderOpenAIResponses
 	case v1.AccountProvider_AZURE_OPENAI:
 		return ProviderAzureOpenAI
+	case v1.AccountProvider_CODEX_CLI:
+		return ProviderCodexCLI
 	default:
 		return ""
 	}
@@ -176,6 +191,10 @@ func ProviderFromProto(p v1.AccountProvider) AccountProvider {
 // StatusToProto converts database AccountStatus to Proto enum.
 func StatusToProto(s AccountStatus) v1.AccountStatus {
 	switch s {
+	case StatusCreated:
+		return v1.AccountStatus_ACCOUNT_CREATED
+	case StatusValidating:
+		return v1.AccountStatus_ACCOUNT_VALIDATING
 	case StatusActive:
 		return v1.AccountStatus_ACCOUNT_ACTIVE
 	case StatusInactive:
@@ -190,6 +209,10 @@ func StatusToProto(s AccountStatus) v1.AccountStatus {
 // StatusFromProto converts Proto enum to database AccountStatus.
 func StatusFromProto(s v1.AccountStatus) AccountStatus {
 	switch s {
+	case v1.AccountStatus_ACCOUNT_CREATED:
+		return StatusCreated
+	case v1.AccountStatus_ACCOUNT_VALIDATING:
+		return StatusValidating
 	case v1.AccountStatus_ACCOUNT_ACTIVE:
 		return StatusActive
 	case v1.AccountStatus_ACCOUNT_INACTIVE:
@@ -217,10 +240,15 @@ func (a *Account) ToProto() *v1.Account {
 		OAuthDataEncrypted: a.OAuthDataEncrypted,
 		RpmLimit:           a.RpmLimit,
 		TpmLimit:           a.TpmLimit,
-		HealthScore:        int32(a.HealthScore), // #nosec G115 -- HealthScore is bounded 0-100
+		DailyTokenLimit:    a.DailyTokenLimit,
+		AccountPriority:    a.AccountPriority,
+		HealthScore:        int32(ClampHealthScore(a.HealthScore)), // #nosec G115 -- clamped to 0-100
 		IsCircuitBroken:    a.IsCircuitBroken,
+		IsDraining:         a.IsDraining,
+		NeedsReauth:        a.NeedsReauth,
 		Status:             StatusToProto(a.Status),
 		Metadata:           metadataStr,
+		Notes:              a.Notes,
 		CreatedAt:          timestamppb.New(a.CreatedAt),
 		UpdatedAt:          timestamppb.New(a.UpdatedAt),
 	}
@@ -256,33 +284,87 @@ type AccountFilter struct {
 	PageSize int32           // Page size (1-100)
 	Provider AccountProvider // Filter by provider (optional)
 	Status   AccountStatus   // Filter by status (optional)
+
+	IsCircuitBroken *bool // Filter by circuit breaker state (optional, nil means any)
+	NeedsReauth     *bool // Filter by re-authorization flag (optional, nil means any)
 }
 
 // AccountRepo implements biz.AccountRepo interface.
 // Following Kratos v2 DDD architecture, interface is defined in biz layer.
 type AccountRepo struct {
-	data   *Data
-	db     *gorm.DB
-	cache  CacheClient
-	logger *log.Helper
+	data    *Data
+	db      *gorm.DB
+	replica *gorm.DB // read replica for list queries (nil = use primary)
+	cache   CacheClient
+	logger  *log.Helper
+
+	missingProvider MissingProviderPolicy // 读取到 provider 为空的账户时的处理方式（空 = skip）
 }
 
 // NewAccountRepo creates a new account repository.
-func NewAccountRepo(data *Data, db *gorm.DB, logger log.Logger) *AccountRepo {
-	return &AccountRepo{
+// replica may be nil or empty, in which case all queries use the primary.
+func NewAccountRepo(data *Data, db *gorm.DB, replica *ReplicaDB, logger log.Logger) *AccountRepo {
+	r := &AccountRepo{
 		data:   data,
 		db:     db,
 		cache:  data.GetCache(),
 		logger: log.NewHelper(logger),
 	}
+	if replica != nil {
+		r.replica = replica.DB
+	}
+	return r
+}
+
+// reader returns the DB for read-only list queries: the replica if configured, otherwise the primary.
+// Single-row reads (GetAccount) stay on the primary because they feed read-modify-write paths
+// that must not observe replication lag.
+func (r *AccountRepo) reader() *gorm.DB {
+	if r.replica != nil {
+		return r.replica
+	}
+	return r.db
+}
+
+// conn returns the primary DB, or the transaction carried by ctx (see WithTransaction).
+func (r *AccountRepo) conn(ctx context.Context) *gorm.DB {
+	return dbFor(ctx, r.db)
+}
+
+// ErrAccountNotFound is returned (wrapped with the account ID) when an account does not exist.
+// Callers match it with errors.Is.
+var ErrAccountNotFound = errors.New("account not found")
+
+// classifyConnError classifies connection failures (including pool exhaustion) so upper
+// layers can surface them as retryable; other errors are returned unchanged.
+func (r *AccountRepo) classifyConnError(err error) error {
+	if dbErr := pkgerrors.ClassifyDBError(r.markPoolWait(err)); dbErr.Type == pkgerrors.ErrorTypeConnectionError {
+		return dbErr
+	}
+	return err
+}
+
+// markPoolWait marks a context deadline as a pool wait timeout when the primary pool is saturated,
+// so only deadlines caused by pool exhaustion are classified as connection errors.
+func (r *AccountRepo) markPoolWait(err error) error {
+	if r.db == nil {
+		return err
+	}
+	sqlDB, dbErr := r.db.DB()
+	if dbErr != nil {
+		return err
+	}
+	return pkgerrors.MarkPoolWait(err, sqlDB.Stats())
 }
 
 // CreateAccount creates a new account in the database.
 // Returns classified database errors for better error handling in upper layers.
 func (r *AccountRepo) CreateAccount(ctx context.Context, account *Account) error {
-	if err := r.db.WithContext(ctx).Create(account).Error; err != nil {
+	syncInactivatedAt(account, time.Now())
+	account.HealthScore = ClampHealthScore(account.HealthScore)
+	if err := r.conn(ctx).Create(account).Error; err != nil {
 		// Classify the database error for better error handling
-		dbErr := pkgerrors.ClassifyDBError(err)
+		dbErr := pkgerrors.ClassifyDBError(r.markPoolWait(err))
 
 		// Log with appropriate level based on error type
 		switch dbErr.Type {
@@ -322,24 +404,32 @@ func (r *AccountRepo) GetAccount(ctx context.Context, id int64) (*Account, error
 	var cachedAccount Account
 	if err := r.cache.Get(ctx, cacheKey, &cachedAccount); err == nil {
 		r.logger.Debugw("account cache hit", "id", id)
+		if r.skipMissingProvider(&cachedAccount) {
+			return nil, fmt.Errorf("%w: id=%d", ErrAccountMissingProvider, id)
+		}
 		return &cachedAccount, nil
 	}
 
 	// Cache miss, query from database
 	var account Account
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&account).Error; err != nil {
+	if err := r.conn(ctx).Where("id = ?", id).First(&account).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("account not found: id=%d", id)
+			return nil, fmt.Errorf("%w: id=%d", ErrAccountNotFound, id)
 		}
 		r.logger.Errorf("failed to get account: %v", err)
-		return nil, fmt.Errorf("failed to get account: %w", err)
+		return nil, fmt.Errorf("failed to get account: %w", r.classifyConnError(err))
+	}
+	if r.skipMissingProvider(&account) {
+		return nil, fmt.Errorf("%w: id=%d", ErrAccountMissingProvider, id)
 	}
 
 	// Store in cache (5 minutes TTL)
-	if err := r.cache.Set(ctx, cacheKey, &account, 5*time.Minute); err != nil {
-		r.logger.Warnw("failed to cache account", "id", id, "error", err)
-		// Cache failure doesn't affect the operation
-	}
+	afterCommit(ctx, func() {
+		if err := r.cache.Set(ctx, cacheKey, &account, 5*time.Minute); err != nil {
+			r.logger.Warnw("failed to cache account", "id", id, "error", err)
+			// Cache failure doesn't affect the operation
+		}
+	})
 
 	r.logger.Debugw("account fetched from database", "id", id)
 	return &account, nil
@@ -362,36 +452,25 @@ func (r *AccountRepo) ListAccounts(ctx context.Context, filter *AccountFilter) (
 		filter.PageSize = 100
 	}
 
-	// Build query with soft delete filter (status != inactive)
-	query := r.db.WithContext(ctx).Model(&Account{})
-
-	// Apply filters
-	if filter.Provider != "" {
-		query = query.Where("provider = ?", filter.Provider)
-	}
-	if filter.Status != "" {
-		query = query.Where("status = ?", filter.Status)
-	} else {
-		// Default: exclude inactive accounts (soft delete)
-		query = query.Where("status != ?", StatusInactive)
-	}
+	query := r.filteredAccountsQuery(ctx, filter)
 
 	// Count total records
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
 		r.logger.Errorf("failed to count accounts: %v", err)
-		return nil, 0, fmt.Errorf("failed to count accounts: %w", err)
+		return nil, 0, fmt.Errorf("failed to count accounts: %w", r.classifyConnError(err))
 	}
 
 	// Fetch paginated accounts
 	var accounts []*Account
 	offset := (filter.Page - 1) * filter.PageSize
 	if err := query.Offset(int(offset)).Limit(int(filter.PageSize)).
-		Order("created_at DESC").
+		Order("created_at DESC, id DESC").
 		Find(&accounts).Error; err != nil {
 		r.logger.Errorf("failed to list accounts: %v", err)
-		return nil, 0, fmt.Errorf("failed to list accounts: %w", err)
+		return nil, 0, fmt.Errorf("failed to list accounts: %w", r.classifyConnError(err))
 	}
+	accounts = r.withoutMissingProvider(accounts)
 
 	r.logger.Debugw("accounts listed", "count", len(accounts), "total", total, "page", filter.Page)
 
@@ -402,33 +481,79 @@ func (r *AccountRepo) ListAccounts(ctx context.Context, filter *AccountFilter) (
 	return accounts, int32(total), nil // #nosec G115 -- safe conversion with overflow check
 }
 
+// CountAccounts returns the number of accounts matching the filter (pagination is ignored).
+// Uses the same WHERE conditions as ListAccounts so the count always matches the list total.
+func (r *AccountRepo) CountAccounts(ctx context.Context, filter *AccountFilter) (int64, error) {
+	if filter == nil {
+		filter = &AccountFilter{}
+	}
+
+	var total int64
+	if err := r.filteredAccountsQuery(ctx, filter).Count(&total).Error; err != nil {
+		r.logger.Errorf("failed to count accounts: %v", err)
+		return 0, fmt.Errorf("failed to count accounts: %w", r.classifyConnError(err))
+	}
+	return total, nil
+}
+
+// filteredAccountsQuery builds the account query with the filter's WHERE conditions.
+// Shared by ListAccounts and CountAccounts so they cannot drift apart.
+func (r *AccountRepo) filteredAccountsQuery(ctx context.Context, filter *AccountFilter) *gorm.DB {
+	// Build query with soft delete filter (status != inactive)
+	query := r.reader().WithContext(ctx).Model(&Account{})
+
+	// Apply filters
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	} else {
+		// Default: exclude inactive accounts (soft delete)
+		query = query.Where("status != ?", StatusInactive)
+	}
+	if filter.IsCircuitBroken != nil {
+		query = query.Where("is_circuit_broken = ?", *filter.IsCircuitBroken)
+	}
+	if filter.NeedsReauth != nil {
+		query = query.Where("needs_reauth = ?", *filter.NeedsReauth)
+	}
+	return query
+}
+
 // UpdateAccount updates an account and clears its cache.
 func (r *AccountRepo) UpdateAccount(ctx context.Context, account *Account) error {
 	account.UpdatedAt = time.Now()
+	syncInactivatedAt(account, account.UpdatedAt)
+	account.HealthScore = ClampHealthScore(account.HealthScore)
 
-	if err := r.db.WithContext(ctx).Save(account).Error; err != nil {
+	if err := r.conn(ctx).Save(account).Error; err != nil {
 		r.logger.Errorf("failed to update account: %v", err)
-		return fmt.Errorf("failed to update account: %w", err)
+		return fmt.Errorf("failed to update account: %w", r.classifyConnError(err))
 	}
 
 	// Clear cache
 	cacheKey := fmt.Sprintf("account:%d", account.ID)
-	if err := r.cache.Delete(ctx, cacheKey); err != nil {
-		r.logger.Warnw("failed to delete account cache", "id", account.ID, "error", err)
-	}
+	afterCommit(ctx, func() {
+		if err := r.cache.Delete(ctx, cacheKey); err != nil {
+			r.logger.Warnw("failed to delete account cache", "id", account.ID, "error", err)
+		}
+	})
 
 	r.logger.Infow("account updated", "id", account.ID, "name", account.Name)
 	return nil
 }
 
-// DeleteAccount performs soft delete (sets status to INACTIVE) and clears cache.
+// DeleteAccount performs soft delete (sets status to INACTIVE), records inactivated_at and clears cache.
 func (r *AccountRepo) DeleteAccount(ctx context.Context, id int64) error {
-	result := r.db.WithContext(ctx).
+	now := time.Now()
+	result := r.conn(ctx).
 		Model(&Account{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
-			"status":     StatusInactive,
-			"updated_at": time.Now(),
+			"status":         StatusInactive,
+			"inactivated_at": now,
+			"updated_at":     now,
 		})
 
 	if result.Error != nil {
@@ -437,14 +562,16 @@ func (r *AccountRepo) DeleteAccount(ctx context.Context, id int64) error {
 	}
 
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("account not found: id=%d", id)
+		return fmt.Errorf("%w: id=%d", ErrAccountNotFound, id)
 	}
 
 	// Clear cache
 	cacheKey := fmt.Sprintf("account:%d", id)
-	if err := r.cache.Delete(ctx, cacheKey); err != nil {
-		r.logger.Warnw("failed to delete account cache", "id", id, "error", err)
-	}
+	afterCommit(ctx, func() {
+		if err := r.cache.Delete(ctx, cacheKey); err != nil {
+			r.logger.Warnw("failed to delete account cache", "id", id, "error", err)
+		}
+	})
 
 	r.logger.Infow("account deleted (soft)", "id", id)
 	return nil
@@ -479,19 +606,24 @@ func ValidateMetadataJSON(metadata string) error {
 // ListExpiringAccounts 查询即将过期的 Claude 账户
 // expiryThreshold: 过期时间阈值（如 time.Now().Add(10 * time.Minute)）
 // 返回 oauth_expires_at <= expiryThreshold 的 active 状态 Claude 账户
+// 刷新失败后仍处于退避期（next_refresh_attempt_at 在未来）或需要重新授权的账户会被跳过
 func (r *AccountRepo) ListExpiringAccounts(ctx context.Context, expiryThreshold time.Time) ([]*Account, error) {
 	var accounts []*Account
 
 	// SQL: WHERE provider IN ('claude-official', 'claude-console')
 	//      AND status = 'active'
 	//      AND oauth_expires_at IS NOT NULL
+	//      AND needs_reauth = FALSE
 	//      AND oauth_expires_at <= ?
+	//      AND (next_refresh_attempt_at IS NULL OR next_refresh_attempt_at <= NOW())
 	//      ORDER BY oauth_expires_at ASC
-	err := r.db.WithContext(ctx).
+	err := r.conn(ctx).
 		Where("provider IN (?, ?)", ProviderClaudeOfficial, ProviderClaudeConsole).
 		Where("status = ?", StatusActive).
+		Where("needs_reauth = ?", false).
 		Where("oauth_expires_at IS NOT NULL").
 		Where("oauth_expires_at <= ?", expiryThreshold).
+		Where("next_refresh_attempt_at IS NULL OR next_refresh_attempt_at <= ?", time.Now()).
 		Order("oauth_expires_at ASC").
 		Find(&accounts).Error
 
@@ -508,14 +640,18 @@ func (r *AccountRepo) ListExpiringAccounts(ctx context.Context, expiryThreshold
 // accountID: 账户 ID
 // oauthData: 加密后的 OAuth 数据（Base64 编码）
 // expiresAt: OAuth Token 过期时间
+// 刷新成功即写入新 Token，同时清除刷新退避时间和重新授权标记
 func (r *AccountRepo) UpdateOAuthData(ctx context.Context, accountID int64, oauthData string, expiresAt time.Time) error {
 	updates := map[string]interface{}{
-		"oauth_data_encrypted": oauthData,
-		"oauth_expires_at":     expiresAt,
-		"updated_at":           time.Now(),
+		"oauth_data_encrypted":    oauthData,
+		"oauth_expires_at":        expiresAt,
+		"token_expires_at":        expiresAt, // Codex 账户按 token_expires_at 选取刷新，需保持一致
+		"next_refresh_attempt_at": nil,
+		"needs_reauth":            false,
+		"updated_at":              time.Now(),
 	}
 
-	result := r.db.WithContext(ctx).
+	result := r.conn(ctx).
 		Model(&Account{}).
 		Where("id = ?", accountID).
 		Updates(updates)
@@ -526,19 +662,146 @@ func (r *AccountRepo) UpdateOAuthData(ctx context.Context, accountID int64, oaut
 	}
 
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("account not found: id=%d", accountID)
+		return fmt.Errorf("%w: id=%d", ErrAccountNotFound, accountID)
 	}
 
 	// Clear cache
 	cacheKey := fmt.Sprintf("account:%d", accountID)
-	if err := r.cache.Delete(ctx, cacheKey); err != nil {
-		r.logger.Warnw("failed to delete account cache after OAuth update", "id", accountID, "error", err)
-	}
+	afterCommit(ctx, func() {
+		if err := r.cache.Delete(ctx, cacheKey); err != nil {
+			r.logger.Warnw("failed to delete account cache after OAuth update", "id", accountID, "error", err)
+		}
+	})
 
 	r.logger.Infow("OAuth data updated", "account_id", accountID, "expires_at", expiresAt)
 	return nil
 }
 
+// SetLastCheckedAt 记录账户最近一次健康检查时间
+func (r *AccountRepo) SetLastCheckedAt(ctx context.Context, accountID int64, checkedAt time.Time) error {
+	result := r.conn(ctx).
+		Model(&Account{}).
+		Where("id = ?", accountID).
+		Updates(map[string]interface{}{
+			"last_checked_at": checkedAt,
+			"updated_at":      time.Now(),
+		})
+
+	if result.Error != nil {
+		r.logger.Errorf("failed to set last checked at: %v", result.Error)
+		return fmt.Errorf("failed to set last checked at: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: id=%d", ErrAccountNotFound, accountID)
+	}
+
+	// Clear cache
+	cacheKey := fmt.Sprintf("account:%d", accountID)
+	afterCommit(ctx, func() {
+		if err := r.cache.Delete(ctx, cacheKey); err != nil {
+			r.logger.Warnw("failed to delete account cache after last checked update", "id", accountID, "error", err)
+		}
+	})
+
+	return nil
+}
+
+// SetDraining 设置账户排空标记（只更新 is_draining 列，避免整行保存覆盖并发写入）
+func (r *AccountRepo) SetDraining(ctx context.Context, accountID int64, draining bool) error {
+	result := r.conn(ctx).
+		Model(&Account{}).
+		Where("id = ?", accountID).
+		Updates(map[string]interface{}{
+			"is_draining": draining,
+			"updated_at":  time.Now(),
+		})
+
+	if result.Error != nil {
+		r.logger.Errorf("failed to set draining: %v", result.Error)
+		return fmt.Errorf("failed to set draining: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: id=%d", ErrAccountNotFound, accountID)
+	}
+
+	// Clear cache
+	cacheKey := fmt.Sprintf("account:%d", accountID)
+	afterCommit(ctx, func() {
+		if err := r.cache.Delete(ctx, cacheKey); err != nil {
+			r.logger.Warnw("failed to delete account cache after draining update", "id", accountID, "error", err)
+		}
+	})
+
+	return nil
+}
+
+// SetNextRefreshAttempt 设置 Token 刷新的下次允许尝试时间（刷新失败后的退避）
+// nextAttempt 为 nil 时清除退避
+func (r *AccountRepo) SetNextRefreshAttempt(ctx context.Context, accountID int64, nextAttempt *time.Time) error {
+	result := r.conn(ctx).
+		Model(&Account{}).
+		Where("id = ?", accountID).
+		Updates(map[string]interface{}{
+			"next_refresh_attempt_at": nextAttempt,
+			"updated_at":              time.Now(),
+		})
+
+	if result.Error != nil {
+		r.logger.Errorf("failed to set next refresh attempt: %v", result.Error)
+		return fmt.Errorf("failed to set next refresh attempt: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: id=%d", ErrAccountNotFound, accountID)
+	}
+
+	// Clear cache
+	cacheKey := fmt.Sprintf("account:%d", accountID)
+	afterCommit(ctx, func() {
+		if err := r.cache.Delete(ctx, cacheKey); err != nil {
+			r.logger.Warnw("failed to delete account cache after next refresh attempt update", "id", accountID, "error", err)
+		}
+	})
+
+	return nil
+}
+
+// MarkNeedsReauth 标记账户 refresh token 已永久失效，需要重新授权
+// 账户同时置为 error 状态并清除刷新退避时间；重新授权写入新 Token（UpdateOAuthData）后标记自动清除
+func (r *AccountRepo) MarkNeedsReauth(ctx context.Context, accountID int64) error {
+	result := r.conn(ctx).
+		Model(&Account{}).
+		Where("id = ?", accountID).
+		Updates(map[string]interface{}{
+			"needs_reauth":            true,
+			"status":                  StatusError,
+			"next_refresh_attempt_at": nil,
+			"updated_at":              time.Now(),
+		})
+
+	if result.Error != nil {
+		r.logger.Errorf("failed to mark account as needing re-auth: %v", result.Error)
+		return fmt.Errorf("failed to mark account as needing re-auth: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: id=%d", ErrAccountNotFound, accountID)
+	}
+
+	// Clear cache
+	cacheKey := fmt.Sprintf("account:%d", accountID)
+	afterCommit(ctx, func() {
+		if err := r.cache.Delete(ctx, cacheKey); err != nil {
+			r.logger.Warnw("failed to delete account cache after needs re-auth update", "id", accountID, "error", err)
+		}
+	})
+
+	r.logger.Warnw("account marked as needing re-auth", "account_id", accountID)
+	return nil
+}
+
 // UpdateHealthScore 更新账户的健康分数
 // accountID: 账户 ID
 // score: 新的健康分数（0-100）
@@ -548,7 +811,7 @@ func (r *AccountRepo) UpdateHealthScore(ctx context.Context, accountID int64, sc
 	//      SET health_score = GREATEST(0, LEAST(100, ?)),
 	//          updated_at = NOW()
 	//      WHERE id = ?
-	result := r.db.WithContext(ctx).
+	result := r.conn(ctx).
 		Model(&Account{}).
 		Where("id = ?", accountID).
 		Updates(map[string]interface{}{
@@ -562,14 +825,16 @@ func (r *AccountRepo) UpdateHealthScore(ctx context.Context, accountID int64, sc
 	}
 
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("account not found: id=%d", accountID)
+		return fmt.Errorf("%w: id=%d", ErrAccountNotFound, accountID)
 	}
 
 	// Clear cache
 	cacheKey := fmt.Sprintf("account:%d", accountID)
-	if err := r.cache.Delete(ctx, cacheKey); err != nil {
-		r.logger.Warnw("failed to delete account cache after health score update", "id", accountID, "error", err)
-	}
+	afterCommit(ctx, func() {
+		if err := r.cache.Delete(ctx, cacheKey); err != nil {
+			r.logger.Warnw("failed to delete account cache after health score update", "id", accountID, "error", err)
+		}
+	})
 
 	r.logger.Infow("health score updated", "account_id", accountID, "score", score)
 	return nil
@@ -579,12 +844,14 @@ func (r *AccountRepo) UpdateHealthScore(ctx context.Context, accountID int64, sc
 // accountID: 账户 ID
 // status: 新状态（active/inactive/error）
 func (r *AccountRepo) UpdateAccountStatus(ctx context.Context, accountID int64, status AccountStatus) error {
-	result := r.db.WithContext(ctx).
+	now := time.Now()
+	result := r.conn(ctx).
 		Model(&Account{}).
 		Where("id = ?", accountID).
 		Updates(map[string]interface{}{
-			"status":     status,
-			"updated_at": time.Now(),
+			"status":         status,
+			"inactivated_at": inactivatedAt(status, now),
+			"updated_at":     now,
 		})
 
 	if result.Error != nil {
@@ -593,14 +860,16 @@ func (r *AccountRepo) UpdateAccountStatus(ctx context.Context, accountID int64,
 	}
 
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("account not found: id=%d", accountID)
+		return fmt.Errorf("%w: id=%d", ErrAccountNotFound, accountID)
 	}
 
 	// Clear cache
 	cacheKey := fmt.Sprintf("account:%d", accountID)
-	if err := r.cache.Delete(ctx, cacheKey); err != nil {
-		r.logger.Warnw("failed to delete account cache after status update", "id", accountID, "error", err)
-	}
+	afterCommit(ctx, func() {
+		if err := r.cache.Delete(ctx, cacheKey); err != nil {
+			r.logger.Warnw("failed to delete account cache after status update", "id", accountID, "error", err)
+		}
+	})
 
 	r.logger.Infow("account status updated", "account_id", accountID, "status", status)
 	return nil
@@ -617,7 +886,7 @@ func (r *AccountRepo) ListAccountsByProvider(ctx context.Context, provider Accou
 	//      WHERE provider = ?
 	//      AND status = ?
 	//      ORDER BY id ASC
-	err := r.db.WithContext(ctx).
+	err := r.conn(ctx).
 		Where("provider = ?", provider).
 		Where("status = ?", status).
 		Order("id ASC").
@@ -632,17 +901,160 @@ func (r *AccountRepo) ListAccountsByProvider(ctx context.Context, provider Accou
 	return accounts, nil
 }
 
+// CapacityStats 指定 Provider 下活跃账户的容量汇总
+type CapacityStats struct {
+	AccountCount         int64   // 活跃账户数
+	TotalRPMLimit        int64   // RPM 限额总和（不含无限制账户）
+	TotalTPMLimit        int64   // TPM 限额总和（不含无限制账户）
+	UnlimitedRPMAccounts int64   // RPM 限额为 0（无限制）的账户数
+	UnlimitedTPMAccounts int64   // TPM 限额为 0（无限制）的账户数
+	RPMLimitedAccountIDs []int64 // RPM 限额大于 0 的账户 ID（当前用量只统计这些账户）
+	TPMLimitedAccountIDs []int64 // TPM 限额大于 0 的账户 ID
+}
+
+// GetCapacityStats 通过单条查询读取指定 Provider 下活跃账户的 ID 与 RPM/TPM 限额，并在内存中汇总
+func (r *AccountRepo) GetCapacityStats(ctx context.Context, provider AccountProvider) (*CapacityStats, error) {
+	var rows []struct {
+		ID       int64
+		RpmLimit int32
+		TpmLimit int32
+	}
+
+	// SQL: SELECT id, rpm_limit, tpm_limit FROM api_accounts
+	//      WHERE provider = ? AND status = 'active'
+	err := r.reader().WithContext(ctx).
+		Model(&Account{}).
+		Select("id, rpm_limit, tpm_limit").
+		Where("provider = ?", provider).
+		Where("status = ?", StatusActive).
+		Scan(&rows).Error
+
+	if err != nil {
+		r.logger.Errorf("failed to get capacity stats: %v", err)
+		return nil, fmt.Errorf("failed to get capacity stats: %w", err)
+	}
+
+	stats := &CapacityStats{AccountCount: int64(len(rows))}
+	for _, row := range rows {
+		if row.RpmLimit > 0 {
+			stats.TotalRPMLimit += int64(row.RpmLimit)
+			stats.RPMLimitedAccountIDs = append(stats.RPMLimitedAccountIDs, row.ID)
+		} else {
+			stats.UnlimitedRPMAccounts++
+		}
+		if row.TpmLimit > 0 {
+			stats.TotalTPMLimit += int64(row.TpmLimit)
+			stats.TPMLimitedAccountIDs = append(stats.TPMLimitedAccountIDs, row.ID)
+		} else {
+			stats.UnlimitedTPMAccounts++
+		}
+	}
+
+	return stats, nil
+}
+
+// FleetHealthFilter 账户池健康汇总的过滤条件（零值表示不过滤）
+type FleetHealthFilter struct {
+	Provider AccountProvider // 按 Provider 过滤
+	GroupID  int64           // 按账户组过滤
+}
+
+// FleetStatusStats 单个状态下账户的健康聚合
+type FleetStatusStats struct {
+	Status             AccountStatus // 账户状态
+	AccountCount       int64         // 账户数
+	AvgHealthScore     float64       // 平均健康分数
+	CircuitBrokenCount int64         // 已熔断账户数
+}
+
+// GetFleetHealthStats 通过单条 GROUP BY 聚合查询按状态统计账户数、平均健康分和熔断数
+// 已删除（inactive）账户不计入
+func (r *AccountRepo) GetFleetHealthStats(ctx context.Context, filter FleetHealthFilter) ([]*FleetStatusStats, error) {
+	var stats []*FleetStatusStats
+
+	// SQL: SELECT status, COUNT(*), AVG(health_score), SUM(is_circuit_broken) FROM api_accounts
+	//      WHERE status != 'inactive' [AND provider = ?]
+	//      [AND id IN (SELECT account_id FROM account_group_members WHERE group_id = ?)]
+	//      GROUP BY status
+	query := r.reader().WithContext(ctx).
+		Model(&Account{}).
+		Select("status, "+
+			"COUNT(*) AS account_count, "+
+			"COALESCE(AVG(health_score), 0) AS avg_health_score, "+
+			"COALESCE(SUM(CASE WHEN is_circuit_broken THEN 1 ELSE 0 END), 0) AS circuit_broken_count").
+		Where("status != ?", StatusInactive)
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if filter.GroupID > 0 {
+		query = query.Where("id IN (?)", r.reader().Model(&AccountGroupMember{}).Select("account_id").Where("group_id = ?", filter.GroupID))
+	}
+
+	if err := query.Group("status").Order("status").Scan(&stats).Error; err != nil {
+		r.logger.Errorf("failed to get fleet health stats: %v", err)
+		return nil, fmt.Errorf("failed to get fleet health stats: %w", r.classifyConnError(err))
+	}
+
+	return stats, nil
+}
+
+// ExpiryDistribution 按 Token 剩余有效期分桶的账户数（区间左开右闭）
+type ExpiryDistribution struct {
+	Expired       int64 `gorm:"column:expired"`    // 已过期（expires_at <= now）
+	Within1Hour   int64 `gorm:"column:within_1h"`  // (now, now+1h]
+	Within6Hours  int64 `gorm:"column:within_6h"`  // (now+1h, now+6h]
+	Within24Hours int64 `gorm:"column:within_24h"` // (now+6h, now+24h]
+	Beyond24Hours int64 `gorm:"column:beyond_24h"` // > now+24h
+}
+
+// GetExpiryDistribution 通过单条条件聚合查询统计未删除账户的 Token 过期分布
+// 过期时间取 COALESCE(token_expires_at, oauth_expires_at)（Codex CLI 以 token_expires_at 为准），
+// 两者均为 NULL 的账户（API Key 账户）不计入；providers 为空表示不过滤
+func (r *AccountRepo) GetExpiryDistribution(ctx context.Context, providers []AccountProvider, now time.Time) (*ExpiryDistribution, error) {
+	var dist ExpiryDistribution
+
+	const expiresAt = "COALESCE(token_expires_at, oauth_expires_at)"
+	in1h, in6h, in24h := now.Add(time.Hour), now.Add(6*time.Hour), now.Add(24*time.Hour)
+
+	// SQL: SELECT SUM(CASE WHEN expires_at <= now THEN 1 ELSE 0 END) AS expired, ... FROM api_accounts
+	//      WHERE status != 'inactive' AND (token_expires_at IS NOT NULL OR oauth_expires_at IS NOT NULL)
+	//      [AND provider IN (?)]
+	query := r.reader().WithContext(ctx).
+		Model(&Account{}).
+		Select("COALESCE(SUM(CASE WHEN "+expiresAt+" <= ? THEN 1 ELSE 0 END), 0) AS expired, "+
+			"COALESCE(SUM(CASE WHEN "+expiresAt+" > ? AND "+expiresAt+" <= ? THEN 1 ELSE 0 END), 0) AS within_1h, "+
+			"COALESCE(SUM(CASE WHEN "+expiresAt+" > ? AND "+expiresAt+" <= ? THEN 1 ELSE 0 END), 0) AS within_6h, "+
+			"COALESCE(SUM(CASE WHEN "+expiresAt+" > ? AND "+expiresAt+" <= ? THEN 1 ELSE 0 END), 0) AS within_24h, "+
+			"COALESCE(SUM(CASE WHEN "+expiresAt+" > ? THEN 1 ELSE 0 END), 0) AS beyond_24h",
+			now, now, in1h, in1h, in6h, in6h, in24h, in24h).
+		Where("status != ?", StatusInactive).
+		Where("token_expires_at IS NOT NULL OR oauth_expires_at IS NOT NULL")
+	if len(providers) > 0 {
+		query = query.Where("provider IN ?", providers)
+	}
+
+	if err := query.Scan(&dist).Error; err != nil {
+		r.logger.Errorf("failed to get expiry distribution: %v", err)
+		return nil, fmt.Errorf("failed to get expiry distribution: %w", r.classifyConnError(err))
+	}
+
+	return &dist, nil
+}
+
+// DefaultCodexRefreshWindow ListCodexCLIAccountsNeedingRefresh 的默认刷新窗口
+const DefaultCodexRefreshWindow = 5 * time.Minute
+
 // ListCodexCLIAccountsNeedingRefresh 查询需要刷新 token 的 Codex CLI 账户
-// 查询条件：provider='codex-cli' AND status='active' AND token_expires_at < now() + 5分钟
-func (r *AccountRepo) ListCodexCLIAccountsNeedingRefresh(ctx context.Context) ([]*Account, error) {
+// 查询条件：provider='codex-cli' AND status='active' AND needs_reauth=false AND token_expires_at < now() + window
+// window <= 0 时使用 DefaultCodexRefreshWindow（5 分钟）
+func (r *AccountRepo) ListCodexCLIAccountsNeedingRefresh(ctx context.Context, window time.Duration) ([]*Account, error) {
 	var accounts []*Account
 
-	// Token 即将在 5 分钟内过期
-	threshold := time.Now().Add(5 * time.Minute)
+	threshold := codexRefreshThreshold(time.Now(), window)
 
-	err := r.db.WithContext(ctx).
-		Where("provider = ? AND status = ? AND token_expires_at < ?",
-			ProviderCodexCLI, StatusActive, threshold).
+	err := r.conn(ctx).
+		Where("provider = ? AND status = ? AND needs_reauth = ? AND token_expires_at < ?",
+			ProviderCodexCLI, StatusActive, false, threshold).
 		Order("token_expires_at ASC").
 		Find(&accounts).Error
 
@@ -655,6 +1067,14 @@ func (r *AccountRepo) ListCodexCLIAccountsNeedingRefresh(ctx context.Context) ([
 	return accounts, nil
 }
 
+// codexRefreshThreshold 返回 token_expires_at 的刷新阈值：早于该时间过期的 Codex 账户需要刷新
+func codexRefreshThreshold(now time.Time, window time.Duration) time.Time {
+	if window <= 0 {
+		window = DefaultCodexRefreshWindow
+	}
+	return now.Add(window)
+}
+
 // ParseMetadata parses metadata JSON string into AccountMetadata struct.
 // Returns nil if metadata is nil or empty (no error).
 // Story: 2-7 Account Metadata and Extended Configuration
@@ -680,7 +1100,7 @@ func (r *AccountRepo) ListAccountsByTags(ctx context.Context, tags []string, lim
 	var accounts []*Account
 
 	// Build query: start with base WHERE clause
-	query := r.db.WithContext(ctx).Where("status = ?", StatusActive)
+	query := r.reader().WithContext(ctx).Where("status = ?", StatusActive)
 
 	// Add JSON_CONTAINS condition for each tag (AND logic)
 	// SQL: WHERE JSON_CONTAINS(metadata->'$.tags', '["tag1"]')
@@ -702,6 +1122,7 @@ func (r *AccountRepo) ListAccountsByTags(ctx context.Context, tags []string, lim
 		r.logger.Errorf("failed to list accounts by tags: %v", err)
 		return nil, fmt.Errorf("failed to list accounts by tags: %w", err)
 	}
+	accounts = r.withoutMissingProvider(accounts)
 
 	r.logger.Infow("accounts listed by tags",
 		"tags", tags,
@@ -0,0 +1,49 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// UpdateBaseAPIByProvider 将指定 Provider 下 base_api 等于 oldBase 的账户批量改为 newBase（单条 UPDATE），
+// 清理受影响账户的缓存并返回更新的账户数。用于上游镜像地址迁移，其他 Provider 或其他地址的账户不受影响
+func (r *AccountRepo) UpdateBaseAPIByProvider(ctx context.Context, provider AccountProvider, oldBase, newBase string) (int64, error) {
+	var ids []int64
+	if err := r.conn(ctx).
+		Model(&Account{}).
+		Where("provider = ? AND base_api = ?", provider, oldBase).
+		Pluck("id", &ids).Error; err != nil {
+		r.logger.Errorf("failed to list accounts for base_api migration: %v", err)
+		return 0, fmt.Errorf("failed to list accounts for base_api migration: %w", r.classifyConnError(err))
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// SQL: UPDATE api_accounts SET base_api = ?, updated_at = ? WHERE provider = ? AND base_api = ?
+	result := r.conn(ctx).
+		Model(&Account{}).
+		Where("provider = ? AND base_api = ?", provider, oldBase).
+		Updates(map[string]interface{}{
+			"base_api":   newBase,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		r.logger.Errorf("failed to migrate base_api: %v", result.Error)
+		return 0, fmt.Errorf("failed to migrate base_api: %w", r.classifyConnError(result.Error))
+	}
+
+	// 缓存按 UPDATE 前查到的 ID 清理；期间新增的匹配账户同样被更新，其缓存为空无需清理
+	afterCommit(ctx, func() {
+		for _, id := range ids {
+			if err := r.cache.Delete(ctx, fmt.Sprintf("account:%d", id)); err != nil {
+				r.logger.Warnw("failed to delete account cache after base_api migration", "id", id, "error", err)
+			}
+		}
+	})
+
+	r.logger.Infow("base_api migrated",
+		"provider", provider, "old_base_api", oldBase, "new_base_api", newBase, "count", result.RowsAffected)
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,60 @@
+package data
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAccountRepo_UpdateBaseAPIByProvider tests that the migration only targets rows matching
+// both the provider and the old base_api, and clears only their cache entries.
+func TestAccountRepo_UpdateBaseAPIByProvider(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, mr, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+	ctx := context.Background()
+
+	const oldBase, newBase = "https://old-mirror.example.com", "https://new-mirror.example.com"
+	selectSQL := regexp.QuoteMeta("SELECT `id` FROM `api_accounts` WHERE provider = ? AND base_api = ?")
+
+	t.Run("updates matching provider and base only", func(t *testing.T) {
+		require.NoError(t, mr.Set("account:4", `{"id":4}`))
+		require.NoError(t, mr.Set("account:6", `{"id":6}`))
+		require.NoError(t, mr.Set("account:9", `{"id":9}`)) // same base, other provider
+
+		mock.ExpectQuery(selectSQL).
+			WithArgs(ProviderOpenAIResponses, oldBase).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(6))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `api_accounts` SET `base_api`=?,`updated_at`=? WHERE provider = ? AND base_api = ?")).
+			WithArgs(newBase, sqlmock.AnyArg(), ProviderOpenAIResponses, oldBase).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		updated, err := repo.UpdateBaseAPIByProvider(ctx, ProviderOpenAIResponses, oldBase, newBase)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), updated)
+		assert.False(t, mr.Exists("account:4"))
+		assert.False(t, mr.Exists("account:6"))
+		assert.True(t, mr.Exists("account:9"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no matching accounts", func(t *testing.T) {
+		mock.ExpectQuery(selectSQL).
+			WithArgs(ProviderClaudeConsole, oldBase).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		updated, err := repo.UpdateBaseAPIByProvider(ctx, ProviderClaudeConsole, oldBase, newBase)
+		require.NoError(t, err)
+		assert.Zero(t, updated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,65 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"QuotaLane/pkg/crypto"
+)
+
+// ListUnboundCredentialAccounts 按 ID 升序返回 ID 大于 afterID、仍有旧版（未绑定账户 ID 的）密文凭证的账户，
+// 最多 limit 个（包括已软删除的账户）。供凭证绑定迁移分批扫描，afterID 为上一批最后一个账户的 ID
+func (r *AccountRepo) ListUnboundCredentialAccounts(ctx context.Context, afterID int64, limit int) ([]*Account, error) {
+	unbound := crypto.AADCiphertextPrefix + "%"
+
+	var accounts []*Account
+	// SQL: SELECT * FROM api_accounts WHERE id > ? AND ((api_key_encrypted <> '' AND api_key_encrypted NOT LIKE 'v2:%')
+	//      OR (oauth_data_encrypted <> '' AND oauth_data_encrypted NOT LIKE 'v2:%')) ORDER BY id ASC LIMIT ?
+	err := r.conn(ctx).
+		Where("id > ?", afterID).
+		Where(r.conn(ctx).
+			Where("api_key_encrypted <> '' AND api_key_encrypted NOT LIKE ?", unbound).
+			Or("oauth_data_encrypted <> '' AND oauth_data_encrypted NOT LIKE ?", unbound)).
+		Order("id ASC").
+		Limit(limit).
+		Find(&accounts).Error
+	if err != nil {
+		r.logger.Errorf("failed to list accounts with unbound credentials: %v", err)
+		return nil, fmt.Errorf("failed to list accounts with unbound credentials: %w", r.classifyConnError(err))
+	}
+
+	return accounts, nil
+}
+
+// ReplaceCredentials 将账户的 api_key_encrypted / oauth_data_encrypted 从 old* 替换为 new*（比较并交换）。
+// 仅当两列仍等于 old* 时更新并返回 true；期间凭证已被其他写入（如 Token 刷新）修改时不更新并返回 false
+func (r *AccountRepo) ReplaceCredentials(ctx context.Context, accountID int64, oldAPIKey, newAPIKey, oldOAuthData, newOAuthData string) (bool, error) {
+	// SQL: UPDATE api_accounts SET api_key_encrypted = ?, oauth_data_encrypted = ?, updated_at = ?
+	//      WHERE id = ? AND api_key_encrypted = ? AND oauth_data_encrypted = ?
+	result := r.conn(ctx).
+		Model(&Account{}).
+		Where("id = ? AND api_key_encrypted = ? AND oauth_data_encrypted = ?", accountID, oldAPIKey, oldOAuthData).
+		Updates(map[string]interface{}{
+			"api_key_encrypted":    newAPIKey,
+			"oauth_data_encrypted": newOAuthData,
+			"updated_at":           time.Now(),
+		})
+	if result.Error != nil {
+		r.logger.Errorf("failed to replace credentials: %v", result.Error)
+		return false, fmt.Errorf("failed to replace credentials: %w", r.classifyConnError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	// Clear cache
+	cacheKey := fmt.Sprintf("account:%d", accountID)
+	afterCommit(ctx, func() {
+		if err := r.cache.Delete(ctx, cacheKey); err != nil {
+			r.logger.Warnw("failed to delete account cache after credential update", "id", accountID, "error", err)
+		}
+	})
+
+	return true, nil
+}
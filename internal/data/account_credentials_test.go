@@ -0,0 +1,65 @@
+package data
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountRepo_ListUnboundCredentialAccounts(t *testing.T) {
+	gormDB, mock, cleanup := setupGroupTestDB(t)
+	defer cleanup()
+	repo := NewAccountRepo(&Data{}, gormDB, nil, log.DefaultLogger)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_accounts` WHERE id > ? AND ((api_key_encrypted <> '' AND api_key_encrypted NOT LIKE ?) OR (oauth_data_encrypted <> '' AND oauth_data_encrypted NOT LIKE ?)) ORDER BY id ASC LIMIT ?")).
+		WithArgs(int64(10), "v2:%", "v2:%", 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "api_key_encrypted"}).AddRow(11, "legacy"))
+
+	accounts, err := repo.ListUnboundCredentialAccounts(context.Background(), 10, 100)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	assert.Equal(t, int64(11), accounts[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAccountRepo_ReplaceCredentials tests that credentials are only swapped while both columns
+// still hold the expected ciphertext, and that the account cache is cleared on success.
+func TestAccountRepo_ReplaceCredentials(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, mr, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+	ctx := context.Background()
+
+	updateSQL := regexp.QuoteMeta("UPDATE `api_accounts` SET `api_key_encrypted`=?,`oauth_data_encrypted`=?,`updated_at`=? WHERE id = ? AND api_key_encrypted = ? AND oauth_data_encrypted = ?")
+
+	require.NoError(t, mr.Set("account:1", `{"id":1}`))
+	mock.ExpectBegin()
+	mock.ExpectExec(updateSQL).
+		WithArgs("v2:key", "", sqlmock.AnyArg(), int64(1), "legacy", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	replaced, err := repo.ReplaceCredentials(ctx, 1, "legacy", "v2:key", "", "")
+	require.NoError(t, err)
+	assert.True(t, replaced)
+	assert.False(t, mr.Exists("account:1"), "cache cleared")
+
+	// Credentials changed concurrently (e.g. by a token refresh): nothing is replaced
+	mock.ExpectBegin()
+	mock.ExpectExec(updateSQL).
+		WithArgs("", "v2:oauth", sqlmock.AnyArg(), int64(2), "", "stale").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	replaced, err = repo.ReplaceCredentials(ctx, 2, "", "", "stale", "v2:oauth")
+	require.NoError(t, err)
+	assert.False(t, replaced)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
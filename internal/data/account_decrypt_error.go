@@ -0,0 +1,51 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DecryptErrorReason is the last_error reason recorded for accounts whose stored credentials
+// cannot be decrypted (wrong encryption key or corrupted ciphertext).
+const DecryptErrorReason = "decrypt_error"
+
+// MarkDecryptError 将凭证无法解密的账户置为 error 状态并在 last_error 中记录 decrypt_error 原因，
+// error 状态的账户不再被刷新扫描选中；修复凭证后需人工将状态恢复为 active
+func (r *AccountRepo) MarkDecryptError(ctx context.Context, accountID int64, detail string) error {
+	lastError, err := json.Marshal(map[string]string{"error": DecryptErrorReason, "message": detail})
+	if err != nil {
+		return fmt.Errorf("failed to marshal decrypt error: %w", err)
+	}
+
+	now := time.Now()
+	result := r.conn(ctx).
+		Model(&Account{}).
+		Where("id = ?", accountID).
+		Updates(map[string]interface{}{
+			"status":                  StatusError,
+			"last_error":              string(lastError),
+			"last_error_at":           now,
+			"next_refresh_attempt_at": nil,
+			"updated_at":              now,
+		})
+
+	if result.Error != nil {
+		r.logger.Errorf("failed to mark account decrypt error: %v", result.Error)
+		return fmt.Errorf("failed to mark account decrypt error: %w", r.classifyConnError(result.Error))
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: id=%d", ErrAccountNotFound, accountID)
+	}
+
+	afterCommit(ctx, func() {
+		if err := r.cache.Delete(ctx, fmt.Sprintf("account:%d", accountID)); err != nil {
+			r.logger.Warnw("failed to delete account cache after decrypt error", "id", accountID, "error", err)
+		}
+	})
+
+	r.logger.Warnw("account marked with decrypt error", "account_id", accountID)
+	return nil
+}
@@ -0,0 +1,42 @@
+package data
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAccountRepo_MarkDecryptError tests that an account is set to error with a decrypt_error
+// last_error and its cache entry is cleared.
+func TestAccountRepo_MarkDecryptError(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, mr, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+	ctx := context.Background()
+	updateSQL := regexp.QuoteMeta("UPDATE `api_accounts` SET `last_error`=?,`last_error_at`=?,`next_refresh_attempt_at`=?,`status`=?,`updated_at`=? WHERE id = ?")
+
+	require.NoError(t, mr.Set("account:9", `{"id":9}`))
+	mock.ExpectBegin()
+	mock.ExpectExec(updateSQL).
+		WithArgs(`{"error":"decrypt_error","message":"cipher: message authentication failed"}`,
+			sqlmock.AnyArg(), nil, StatusError, sqlmock.AnyArg(), int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.MarkDecryptError(ctx, 9, "cipher: message authentication failed"))
+	assert.False(t, mr.Exists("account:9"))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(updateSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	assert.ErrorIs(t, repo.MarkDecryptError(ctx, 10, "corrupt"), ErrAccountNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
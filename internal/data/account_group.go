@@ -17,13 +17,17 @@ import (
 
 // AccountGroup is the GORM model for account_groups table.
 type AccountGroup struct {
-	ID          int64      `gorm:"primaryKey;column:id"`
-	Name        string     `gorm:"column:name;size:100;not null;index:idx_name"`
-	Description string     `gorm:"column:description;type:text"`
-	Priority    int32      `gorm:"column:priority;default:0;not null;index:idx_priority"`
-	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime;index:idx_created_at"`
-	UpdatedAt   time.Time  `gorm:"column:updated_at;autoUpdateTime"`
-	DeletedAt   *time.Time `gorm:"column:deleted_at"` // 软删除字段
+	ID               int64      `gorm:"primaryKey;column:id"`
+	Name             string     `gorm:"column:name;size:100;not null;index:idx_name"`
+	Description      string     `gorm:"column:description;type:text"`
+	Priority         int32      `gorm:"column:priority;default:0;not null;index:idx_priority"`
+	RpmLimit         int32      `gorm:"column:rpm_limit;default:0;not null"`                   // 组内所有账户合计每分钟请求数上限（0 表示不限制）
+	TpmLimit         int32      `gorm:"column:tpm_limit;default:0;not null"`                   // 组内所有账户合计每分钟 Token 数上限（0 表示不限制）
+	ConcurrencyLimit int32      `gorm:"column:concurrency_limit;default:0;not null"`           // 组内所有账户合计并发请求数上限（0 表示不限制）
+	Strategy         string     `gorm:"column:selection_strategy;size:32;default:'';not null"` // 组内账户选择策略（空值表示 least_loaded）
+	CreatedAt        time.Time  `gorm:"column:created_at;autoCreateTime;index:idx_created_at"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+	DeletedAt        *time.Time `gorm:"column:deleted_at"` // 软删除字段
 }
 
 // TableName specifies the table name for GORM.
@@ -46,29 +50,53 @@ func (AccountGroupMember) TableName() string {
 // AccountGroupData represents account group data with member IDs.
 // This serves as the domain model used by the biz layer.
 type AccountGroupData struct {
-	ID          int64
-	Name        string
-	Description string
-	Priority    int32
-	AccountIDs  []int64
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID               int64
+	Name             string
+	Description      string
+	Priority         int32
+	RpmLimit         int32  // 组级 RPM 上限（0 表示不限制）
+	TpmLimit         int32  // 组级 TPM 上限（0 表示不限制）
+	ConcurrencyLimit int32  // 组级并发上限（0 表示不限制）
+	Strategy         string // 组内账户选择策略（空值表示 least_loaded）
+	AccountIDs       []int64
+	MemberCount      *int64 // 成员数量（仅在列表请求 include_member_counts 时填充）
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
 }
 
 // AccountGroupRepo implementation using GORM and Redis.
 type AccountGroupRepo struct {
-	data *Data
-	db   *gorm.DB
-	log  *log.Helper
+	data    *Data
+	db      *gorm.DB
+	replica *gorm.DB // read replica for list queries (nil = use primary)
+	log     *log.Helper
 }
 
 // NewAccountGroupRepo creates a new account group repository.
-func NewAccountGroupRepo(data *Data, db *gorm.DB, logger log.Logger) *AccountGroupRepo {
-	return &AccountGroupRepo{
+// replica may be nil or empty, in which case all queries use the primary.
+func NewAccountGroupRepo(data *Data, db *gorm.DB, replica *ReplicaDB, logger log.Logger) *AccountGroupRepo {
+	r := &AccountGroupRepo{
 		data: data,
 		db:   db,
 		log:  log.NewHelper(log.With(logger, "module", "data/account-group")),
 	}
+	if replica != nil {
+		r.replica = replica.DB
+	}
+	return r
+}
+
+// reader returns the DB for read-only list queries: the replica if configured, otherwise the primary.
+func (r *AccountGroupRepo) reader() *gorm.DB {
+	if r.replica != nil {
+		return r.replica
+	}
+	return r.db
+}
+
+// conn returns the primary DB, or the transaction carried by ctx (see WithTransaction).
+func (r *AccountGroupRepo) conn(ctx context.Context) *gorm.DB {
+	return dbFor(ctx, r.db)
 }
 
 // CreateGroup creates a new account group with members in a transaction.
@@ -86,7 +114,7 @@ func (r *AccountGroupRepo) CreateGroup(ctx context.Context, name string, descrip
 	}
 
 	// Start transaction
-	err := r.db.Transaction(func(tx *gorm.DB) error {
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
 		// 1. Insert group
 		if err := tx.Create(dbGroup).Error; err != nil {
 			r.log.Errorf("failed to create account group: %v", err)
@@ -152,7 +180,7 @@ func (r *AccountGroupRepo) GetGroup(ctx context.Context, id int64) (*AccountGrou
 
 	// Query database
 	var dbGroup AccountGroup
-	if err := r.db.Where("id = ? AND deleted_at IS NULL", id).First(&dbGroup).Error; err != nil {
+	if err := r.conn(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&dbGroup).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, &pkgerrors.DatabaseError{
 				Type:        pkgerrors.ErrorTypeNotFound,
@@ -166,7 +194,7 @@ func (r *AccountGroupRepo) GetGroup(ctx context.Context, id int64) (*AccountGrou
 
 	// Query members
 	var members []*AccountGroupMember
-	if err := r.db.Where("group_id = ?", id).Find(&members).Error; err != nil {
+	if err := r.conn(ctx).Where("group_id = ?", id).Find(&members).Error; err != nil {
 		r.log.Errorf("failed to get group members: %v", err)
 		return nil, &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeUnknown, OriginalErr: err, Message: "查询账户组成员失败"}
 	}
@@ -177,13 +205,17 @@ func (r *AccountGroupRepo) GetGroup(ctx context.Context, id int64) (*AccountGrou
 	}
 
 	group := &AccountGroupData{
-		ID:          dbGroup.ID,
-		Name:        dbGroup.Name,
-		Description: dbGroup.Description,
-		Priority:    dbGroup.Priority,
-		AccountIDs:  accountIDs,
-		CreatedAt:   dbGroup.CreatedAt,
-		UpdatedAt:   dbGroup.UpdatedAt,
+		ID:               dbGroup.ID,
+		Name:             dbGroup.Name,
+		Description:      dbGroup.Description,
+		Priority:         dbGroup.Priority,
+		RpmLimit:         dbGroup.RpmLimit,
+		TpmLimit:         dbGroup.TpmLimit,
+		ConcurrencyLimit: dbGroup.ConcurrencyLimit,
+		Strategy:         dbGroup.Strategy,
+		AccountIDs:       accountIDs,
+		CreatedAt:        dbGroup.CreatedAt,
+		UpdatedAt:        dbGroup.UpdatedAt,
 	}
 
 	// Cache the result
@@ -198,15 +230,15 @@ func (r *AccountGroupRepo) ListGroups(ctx context.Context, page, pageSize int32)
 	var total int64
 
 	// Count total
-	if err := r.db.Model(&AccountGroup{}).Where("deleted_at IS NULL").Count(&total).Error; err != nil {
+	if err := r.reader().Model(&AccountGroup{}).Where("deleted_at IS NULL").Count(&total).Error; err != nil {
 		r.log.Errorf("failed to count groups: %v", err)
 		return nil, 0, &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeUnknown, OriginalErr: err, Message: "查询账户组总数失败"}
 	}
 
 	// Query with pagination and sort by priority DESC
 	offset := (page - 1) * pageSize
-	if err := r.db.Where("deleted_at IS NULL").
-		Order("priority DESC, created_at DESC").
+	if err := r.reader().Where("deleted_at IS NULL").
+		Order("priority DESC, created_at DESC, id DESC").
 		Limit(int(pageSize)).
 		Offset(int(offset)).
 		Find(&groups).Error; err != nil {
@@ -218,18 +250,50 @@ func (r *AccountGroupRepo) ListGroups(ctx context.Context, page, pageSize int32)
 	result := make([]*AccountGroupData, len(groups))
 	for i, g := range groups {
 		result[i] = &AccountGroupData{
-			ID:          g.ID,
-			Name:        g.Name,
-			Description: g.Description,
-			Priority:    g.Priority,
-			CreatedAt:   g.CreatedAt,
-			UpdatedAt:   g.UpdatedAt,
+			ID:               g.ID,
+			Name:             g.Name,
+			Description:      g.Description,
+			Priority:         g.Priority,
+			RpmLimit:         g.RpmLimit,
+			TpmLimit:         g.TpmLimit,
+			ConcurrencyLimit: g.ConcurrencyLimit,
+			Strategy:         g.Strategy,
+			CreatedAt:        g.CreatedAt,
+			UpdatedAt:        g.UpdatedAt,
 		}
 	}
 
 	return result, total, nil
 }
 
+// CountGroupMembers returns the member count of each group in a single grouped query.
+// Groups without members are absent from the result.
+func (r *AccountGroupRepo) CountGroupMembers(ctx context.Context, groupIDs []int64) (map[int64]int64, error) {
+	counts := make(map[int64]int64, len(groupIDs))
+	if len(groupIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		GroupID     int64
+		MemberCount int64
+	}
+	if err := r.reader().WithContext(ctx).
+		Model(&AccountGroupMember{}).
+		Select("group_id, COUNT(*) AS member_count").
+		Where("group_id IN ?", groupIDs).
+		Group("group_id").
+		Scan(&rows).Error; err != nil {
+		r.log.Errorf("failed to count group members: %v", err)
+		return nil, &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeUnknown, OriginalErr: err, Message: "查询账户组成员数量失败"}
+	}
+
+	for _, row := range rows {
+		counts[row.GroupID] = row.MemberCount
+	}
+	return counts, nil
+}
+
 // UpdateGroup updates a group and its members in a transaction.
 func (r *AccountGroupRepo) UpdateGroup(ctx context.Context, id int64, name string, description string, priority int32, accountIDs []int64) error {
 	group := &AccountGroupData{
@@ -245,7 +309,7 @@ func (r *AccountGroupRepo) UpdateGroup(ctx context.Context, id int64, name strin
 		return err
 	}
 
-	err = r.db.Transaction(func(tx *gorm.DB) error {
+	err = r.conn(ctx).Transaction(func(tx *gorm.DB) error {
 		// 1. Update group metadata
 		updates := map[string]interface{}{
 			"name":        group.Name,
@@ -299,6 +363,67 @@ func (r *AccountGroupRepo) UpdateGroup(ctx context.Context, id int64, name strin
 	return nil
 }
 
+// UpdateGroupRateLimits sets the group-wide RPM/TPM caps (0 removes a cap).
+func (r *AccountGroupRepo) UpdateGroupRateLimits(ctx context.Context, id int64, rpmLimit, tpmLimit int32) error {
+	result := r.conn(ctx).Model(&AccountGroup{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"rpm_limit":  rpmLimit,
+			"tpm_limit":  tpmLimit,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		r.log.Errorf("failed to update group rate limits: %v", result.Error)
+		return &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeUnknown, OriginalErr: result.Error, Message: "更新账户组限流配置失败"}
+	}
+	if result.RowsAffected == 0 {
+		return &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeNotFound, OriginalErr: gorm.ErrRecordNotFound, Message: "账户组不存在"}
+	}
+
+	r.invalidateGroupCache(ctx, id)
+	return nil
+}
+
+// UpdateGroupConcurrencyLimit sets the group-wide concurrency cap (0 removes the cap).
+func (r *AccountGroupRepo) UpdateGroupConcurrencyLimit(ctx context.Context, id int64, limit int32) error {
+	result := r.conn(ctx).Model(&AccountGroup{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"concurrency_limit": limit,
+			"updated_at":        time.Now(),
+		})
+	if result.Error != nil {
+		r.log.Errorf("failed to update group concurrency limit: %v", result.Error)
+		return &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeUnknown, OriginalErr: result.Error, Message: "更新账户组并发上限失败"}
+	}
+	if result.RowsAffected == 0 {
+		return &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeNotFound, OriginalErr: gorm.ErrRecordNotFound, Message: "账户组不存在"}
+	}
+
+	r.invalidateGroupCache(ctx, id)
+	return nil
+}
+
+// UpdateGroupStrategy sets the group's account selection strategy (empty = least_loaded).
+func (r *AccountGroupRepo) UpdateGroupStrategy(ctx context.Context, id int64, strategy string) error {
+	result := r.db.WithContext(ctx).Model(&AccountGroup{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"selection_strategy": strategy,
+			"updated_at":         time.Now(),
+		})
+	if result.Error != nil {
+		r.log.Errorf("failed to update group selection strategy: %v", result.Error)
+		return &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeUnknown, OriginalErr: result.Error, Message: "更新账户组选择策略失败"}
+	}
+	if result.RowsAffected == 0 {
+		return &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeNotFound, OriginalErr: gorm.ErrRecordNotFound, Message: "账户组不存在"}
+	}
+
+	r.invalidateGroupCache(ctx, id)
+	return nil
+}
+
 // DeleteGroup soft deletes a group (sets deleted_at).
 func (r *AccountGroupRepo) DeleteGroup(ctx context.Context, id int64) error {
 	// Get group first for cache invalidation
@@ -309,7 +434,7 @@ func (r *AccountGroupRepo) DeleteGroup(ctx context.Context, id int64) error {
 
 	// Soft delete (set deleted_at)
 	now := time.Now()
-	if err := r.db.Model(&AccountGroup{}).
+	if err := r.conn(ctx).Model(&AccountGroup{}).
 		Where("id = ? AND deleted_at IS NULL", id).
 		Update("deleted_at", now).Error; err != nil {
 		r.log.Errorf("failed to delete group: %v", err)
@@ -318,7 +443,7 @@ func (r *AccountGroupRepo) DeleteGroup(ctx context.Context, id int64) error {
 
 	// Members are automatically deleted by ON DELETE CASCADE foreign key constraint
 	// But we manually delete them here for clarity
-	if err := r.db.Where("group_id = ?", id).Delete(&AccountGroupMember{}).Error; err != nil {
+	if err := r.conn(ctx).Where("group_id = ?", id).Delete(&AccountGroupMember{}).Error; err != nil {
 		r.log.Warnf("failed to delete members (should be handled by cascade): %v", err)
 	}
 
@@ -331,6 +456,68 @@ func (r *AccountGroupRepo) DeleteGroup(ctx context.Context, id int64) error {
 	return nil
 }
 
+// DeleteGroupWithReassign soft deletes a group and moves its members to targetGroupID
+// in the same transaction. Members already in the target group are kept once.
+func (r *AccountGroupRepo) DeleteGroupWithReassign(ctx context.Context, id, targetGroupID int64) error {
+	if id == targetGroupID {
+		return &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeInvalidValue, OriginalErr: fmt.Errorf("group %d", id), Message: "目标账户组不能是被删除的账户组"}
+	}
+
+	// Get group first for cache invalidation
+	group, err := r.GetGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. Target must exist and not be deleted (checked inside the transaction to avoid races)
+		var targetCount int64
+		if err := tx.Model(&AccountGroup{}).Where("id = ? AND deleted_at IS NULL", targetGroupID).Count(&targetCount).Error; err != nil {
+			r.log.Errorf("failed to check target group: %v", err)
+			return &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeUnknown, OriginalErr: err, Message: "查询目标账户组失败"}
+		}
+		if targetCount == 0 {
+			return &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeNotFound, OriginalErr: gorm.ErrRecordNotFound, Message: "目标账户组不存在"}
+		}
+
+		// 2. Soft delete (set deleted_at)
+		now := time.Now()
+		if err := tx.Model(&AccountGroup{}).
+			Where("id = ? AND deleted_at IS NULL", id).
+			Update("deleted_at", now).Error; err != nil {
+			r.log.Errorf("failed to delete group: %v", err)
+			return &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeUnknown, OriginalErr: err, Message: "删除账户组失败"}
+		}
+
+		// 3. Copy members to the target group (INSERT IGNORE skips accounts already in it)
+		if err := tx.Exec("INSERT IGNORE INTO `account_group_members` (`group_id`, `account_id`, `created_at`) "+
+			"SELECT ?, `account_id`, ? FROM `account_group_members` WHERE `group_id` = ?", targetGroupID, now, id).Error; err != nil {
+			r.log.Errorf("failed to reassign members: %v", err)
+			return &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeUnknown, OriginalErr: err, Message: "迁移账户组成员失败"}
+		}
+
+		// 4. Remove members from the deleted group
+		if err := tx.Where("group_id = ?", id).Delete(&AccountGroupMember{}).Error; err != nil {
+			r.log.Errorf("failed to delete members: %v", err)
+			return &pkgerrors.DatabaseError{Type: pkgerrors.ErrorTypeUnknown, OriginalErr: err, Message: "删除旧成员失败"}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Invalidate caches: both groups and every moved account
+	r.invalidateGroupCache(ctx, id)
+	r.invalidateGroupCache(ctx, targetGroupID)
+	for _, accountID := range group.AccountIDs {
+		r.invalidateAccountGroupsCache(ctx, accountID)
+	}
+
+	return nil
+}
+
 // GetAccountGroups retrieves all groups that an account belongs to.
 func (r *AccountGroupRepo) GetAccountGroups(ctx context.Context, accountID int64) ([]*AccountGroupData, error) {
 	// Try cache first (if Redis is available)
@@ -358,7 +545,7 @@ func (r *AccountGroupRepo) GetAccountGroups(ctx context.Context, accountID int64
 
 	// Query database: JOIN account_groups with account_group_members
 	var dbGroups []*AccountGroup
-	if err := r.db.
+	if err := r.conn(ctx).
 		Joins("JOIN account_group_members ON account_groups.id = account_group_members.group_id").
 		Where("account_group_members.account_id = ? AND account_groups.deleted_at IS NULL", accountID).
 		Order("account_groups.priority DESC").
@@ -372,12 +559,16 @@ func (r *AccountGroupRepo) GetAccountGroups(ctx context.Context, accountID int64
 	groupIDs := make([]int64, len(dbGroups))
 	for i, g := range dbGroups {
 		groups[i] = &AccountGroupData{
-			ID:          g.ID,
-			Name:        g.Name,
-			Description: g.Description,
-			Priority:    g.Priority,
-			CreatedAt:   g.CreatedAt,
-			UpdatedAt:   g.UpdatedAt,
+			ID:               g.ID,
+			Name:             g.Name,
+			Description:      g.Description,
+			Priority:         g.Priority,
+			RpmLimit:         g.RpmLimit,
+			TpmLimit:         g.TpmLimit,
+			ConcurrencyLimit: g.ConcurrencyLimit,
+			Strategy:         g.Strategy,
+			CreatedAt:        g.CreatedAt,
+			UpdatedAt:        g.UpdatedAt,
 		}
 		groupIDs[i] = g.ID
 	}
@@ -386,7 +577,7 @@ func (r *AccountGroupRepo) GetAccountGroups(ctx context.Context, accountID int64
 	if rdb := r.data.GetRedisClient(); rdb != nil {
 		cacheKey := fmt.Sprintf("account:%d:groups", accountID)
 		if data, err := json.Marshal(groupIDs); err == nil {
-			rdb.Set(ctx, cacheKey, data, 10*time.Minute)
+			afterCommit(ctx, func() { rdb.Set(ctx, cacheKey, data, 10*time.Minute) })
 		}
 	}
 
@@ -396,7 +587,7 @@ func (r *AccountGroupRepo) GetAccountGroups(ctx context.Context, accountID int64
 // GetAllGroupedAccountIDs retrieves all account IDs that belong to any group.
 func (r *AccountGroupRepo) GetAllGroupedAccountIDs(ctx context.Context) ([]int64, error) {
 	var members []*AccountGroupMember
-	if err := r.db.
+	if err := r.conn(ctx).
 		Select("DISTINCT account_id").
 		Joins("JOIN account_groups ON account_group_members.group_id = account_groups.id").
 		Where("account_groups.deleted_at IS NULL").
@@ -427,10 +618,12 @@ func (r *AccountGroupRepo) cacheGroup(ctx context.Context, id int64, group *Acco
 		return
 	}
 
-	if err := rdb.Set(ctx, cacheKey, data, 10*time.Minute).Err(); err != nil {
-		// Redis failure is not critical, just log
-		r.log.Warnf("failed to cache group %d: %v", id, err)
-	}
+	afterCommit(ctx, func() {
+		if err := rdb.Set(ctx, cacheKey, data, 10*time.Minute).Err(); err != nil {
+			// Redis failure is not critical, just log
+			r.log.Warnf("failed to cache group %d: %v", id, err)
+		}
+	})
 }
 
 // invalidateGroupCache removes a group from cache.
@@ -441,9 +634,11 @@ func (r *AccountGroupRepo) invalidateGroupCache(ctx context.Context, id int64) {
 	}
 
 	cacheKey := fmt.Sprintf("group:%d", id)
-	if err := rdb.Del(ctx, cacheKey).Err(); err != nil && err != redis.Nil {
-		r.log.Warnf("failed to invalidate group cache: %v", err)
-	}
+	afterCommit(ctx, func() {
+		if err := rdb.Del(ctx, cacheKey).Err(); err != nil && err != redis.Nil {
+			r.log.Warnf("failed to invalidate group cache: %v", err)
+		}
+	})
 }
 
 // invalidateAccountGroupsCache removes account's groups cache.
@@ -454,20 +649,27 @@ func (r *AccountGroupRepo) invalidateAccountGroupsCache(ctx context.Context, acc
 	}
 
 	cacheKey := fmt.Sprintf("account:%d:groups", accountID)
-	if err := rdb.Del(ctx, cacheKey).Err(); err != nil && err != redis.Nil {
-		r.log.Warnf("failed to invalidate account groups cache: %v", err)
-	}
+	afterCommit(ctx, func() {
+		if err := rdb.Del(ctx, cacheKey).Err(); err != nil && err != redis.Nil {
+			r.log.Warnf("failed to invalidate account groups cache: %v", err)
+		}
+	})
 }
 
 // AccountGroupToProto converts AccountGroupData to Proto message.
 func AccountGroupToProto(group *AccountGroupData) *v1.AccountGroup {
 	return &v1.AccountGroup{
-		Id:          group.ID,
-		Name:        group.Name,
-		Description: group.Description,
-		Priority:    group.Priority,
-		AccountIds:  group.AccountIDs,
-		CreatedAt:   timestamppb.New(group.CreatedAt),
-		UpdatedAt:   timestamppb.New(group.UpdatedAt),
+		Id:                group.ID,
+		Name:              group.Name,
+		Description:       group.Description,
+		Priority:          group.Priority,
+		RpmLimit:          group.RpmLimit,
+		TpmLimit:          group.TpmLimit,
+		ConcurrencyLimit:  group.ConcurrencyLimit,
+		SelectionStrategy: group.Strategy,
+		AccountIds:        group.AccountIDs,
+		MemberCount:       group.MemberCount,
+		CreatedAt:         timestamppb.New(group.CreatedAt),
+		UpdatedAt:         timestamppb.New(group.UpdatedAt),
 	}
 }
@@ -3,6 +3,7 @@ package data
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"regexp"
 	"testing"
 	"time"
@@ -63,7 +64,7 @@ func setupAccountGroupRepo(t *testing.T) (*AccountGroupRepo, sqlmock.Sqlmock, *m
 		cache:       nil, // not used in these tests
 	}
 
-	repo := NewAccountGroupRepo(data, gormDB, log.DefaultLogger)
+	repo := NewAccountGroupRepo(data, gormDB, nil, log.DefaultLogger)
 
 	cleanup := func() {
 		dbCleanup()
@@ -88,7 +89,7 @@ func TestCreateGroup(t *testing.T) {
 
 		// Mock INSERT for account_groups (includes deleted_at as NULL)
 		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `account_groups`")).
-			WithArgs("test-group", "Test description", int32(100), sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WithArgs("test-group", "Test description", int32(100), int32(0), int32(0), int32(0), "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		// Mock INSERT for account_group_members
@@ -111,7 +112,7 @@ func TestCreateGroup(t *testing.T) {
 
 		mock.ExpectBegin()
 		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `account_groups`")).
-			WithArgs("test-group-2", "Empty group", int32(50), sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WithArgs("test-group-2", "Empty group", int32(50), int32(0), int32(0), int32(0), "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
 			WillReturnResult(sqlmock.NewResult(2, 1))
 		mock.ExpectCommit()
 
@@ -225,7 +226,7 @@ func TestListGroups(t *testing.T) {
 			AddRow(int64(1), "group1", "desc1", int32(100), now, now, nil).
 			AddRow(int64(2), "group2", "desc2", int32(50), now, now, nil)
 
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_groups` WHERE deleted_at IS NULL ORDER BY priority DESC, created_at DESC LIMIT ?")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_groups` WHERE deleted_at IS NULL ORDER BY priority DESC, created_at DESC, id DESC LIMIT ?")).
 			WithArgs(2).
 			WillReturnRows(groupRows)
 
@@ -239,6 +240,39 @@ func TestListGroups(t *testing.T) {
 		assert.Equal(t, "group2", groups[1].Name)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("same priority and timestamp pages deterministically by id", func(t *testing.T) {
+		createdAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		pages := [][]int64{{4, 3}, {2, 1}}
+
+		seen := make(map[int64]bool)
+		for i, pageIDs := range pages {
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `account_groups` WHERE deleted_at IS NULL")).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(4)))
+
+			rows := sqlmock.NewRows([]string{"id", "name", "priority", "created_at", "updated_at"})
+			for _, id := range pageIDs {
+				rows.AddRow(id, "bulk", int32(10), createdAt, createdAt)
+			}
+			query := "SELECT * FROM `account_groups` WHERE deleted_at IS NULL ORDER BY priority DESC, created_at DESC, id DESC LIMIT ?"
+			args := []driver.Value{2}
+			if i > 0 {
+				query += " OFFSET ?"
+				args = append(args, i*2)
+			}
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(args...).WillReturnRows(rows)
+
+			groups, _, err := repo.ListGroups(ctx, int32(i+1), 2)
+			require.NoError(t, err)
+			for _, g := range groups {
+				assert.False(t, seen[g.ID], "group %d returned on more than one page", g.ID)
+				seen[g.ID] = true
+			}
+		}
+
+		assert.Len(t, seen, 4)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 // TestUpdateGroup tests updating a group
@@ -345,6 +379,205 @@ func TestDeleteGroup(t *testing.T) {
 	})
 }
 
+// expectGetGroup mocks the two queries GetGroup issues on a cache miss
+func expectGetGroup(mock sqlmock.Sqlmock, groupID int64, accountIDs ...int64) {
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_groups` WHERE id = ? AND deleted_at IS NULL")).
+		WithArgs(groupID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "priority", "created_at", "updated_at", "deleted_at"}).
+			AddRow(groupID, "retiring", "", int32(0), now, now, nil))
+
+	memberRows := sqlmock.NewRows([]string{"group_id", "account_id", "created_at"})
+	for _, accountID := range accountIDs {
+		memberRows.AddRow(groupID, accountID, now)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_group_members` WHERE group_id = ?")).
+		WithArgs(groupID).
+		WillReturnRows(memberRows)
+}
+
+// TestDeleteGroupWithReassign tests soft-deleting a group while moving its members to another group
+func TestDeleteGroupWithReassign(t *testing.T) {
+	repo, mock, mr, cleanup := setupAccountGroupRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	t.Run("members moved in one transaction", func(t *testing.T) {
+		mr.FlushAll()
+		expectGetGroup(mock, 1, 10, 11)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `account_groups` WHERE id = ? AND deleted_at IS NULL")).
+			WithArgs(int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `account_groups` SET `deleted_at`=?,`updated_at`=? WHERE id = ? AND deleted_at IS NULL")).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO `account_group_members` (`group_id`, `account_id`, `created_at`) SELECT ?, `account_id`, ? FROM `account_group_members` WHERE `group_id` = ?")).
+			WithArgs(int64(2), sqlmock.AnyArg(), int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `account_group_members` WHERE group_id = ?")).
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		// Stale caches of both groups and the moved accounts
+		require.NoError(t, mr.Set("group:2", "{}"))
+		require.NoError(t, mr.Set("account:10:groups", "[1]"))
+		require.NoError(t, mr.Set("account:11:groups", "[1]"))
+
+		err := repo.DeleteGroupWithReassign(ctx, 1, 2)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.False(t, mr.Exists("group:1"))
+		assert.False(t, mr.Exists("group:2"))
+		assert.False(t, mr.Exists("account:10:groups"))
+		assert.False(t, mr.Exists("account:11:groups"))
+	})
+
+	t.Run("target not found rolls back", func(t *testing.T) {
+		mr.FlushAll()
+		expectGetGroup(mock, 1, 10)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `account_groups` WHERE id = ? AND deleted_at IS NULL")).
+			WithArgs(int64(99)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectRollback()
+
+		err := repo.DeleteGroupWithReassign(ctx, 1, 99)
+
+		require.Error(t, err)
+		var dbErr *errors.DatabaseError
+		require.ErrorAs(t, err, &dbErr)
+		assert.Equal(t, errors.ErrorTypeNotFound, dbErr.Type)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("same group rejected", func(t *testing.T) {
+		err := repo.DeleteGroupWithReassign(ctx, 1, 1)
+
+		require.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet(), "no queries are issued")
+	})
+}
+
+// TestUpdateGroupRateLimits tests setting group-wide rate limits and invalidating the group cache
+func TestUpdateGroupRateLimits(t *testing.T) {
+	repo, mock, mr, cleanup := setupAccountGroupRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	update := regexp.QuoteMeta("UPDATE `account_groups` SET `rpm_limit`=?,`tpm_limit`=?,`updated_at`=? WHERE id = ? AND deleted_at IS NULL")
+
+	t.Run("update limits successfully", func(t *testing.T) {
+		mr.FlushAll()
+		require.NoError(t, mr.Set("group:1", `{"ID":1,"Name":"cached"}`))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(update).
+			WithArgs(int32(600), int32(100000), sqlmock.AnyArg(), int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.UpdateGroupRateLimits(ctx, 1, 600, 100000))
+		assert.False(t, mr.Exists("group:1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("group not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(update).
+			WithArgs(int32(10), int32(0), sqlmock.AnyArg(), int64(999)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := repo.UpdateGroupRateLimits(ctx, 999, 10, 0)
+		var dbErr *errors.DatabaseError
+		require.ErrorAs(t, err, &dbErr)
+		assert.Equal(t, errors.ErrorTypeNotFound, dbErr.Type)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestUpdateGroupConcurrencyLimit tests setting the group-wide concurrency cap and invalidating the group cache
+func TestUpdateGroupConcurrencyLimit(t *testing.T) {
+	repo, mock, mr, cleanup := setupAccountGroupRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	update := regexp.QuoteMeta("UPDATE `account_groups` SET `concurrency_limit`=?,`updated_at`=? WHERE id = ? AND deleted_at IS NULL")
+
+	t.Run("update limit successfully", func(t *testing.T) {
+		mr.FlushAll()
+		require.NoError(t, mr.Set("group:1", `{"ID":1,"Name":"cached"}`))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(update).
+			WithArgs(int32(20), sqlmock.AnyArg(), int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.UpdateGroupConcurrencyLimit(ctx, 1, 20))
+		assert.False(t, mr.Exists("group:1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("group not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(update).
+			WithArgs(int32(0), sqlmock.AnyArg(), int64(999)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := repo.UpdateGroupConcurrencyLimit(ctx, 999, 0)
+		var dbErr *errors.DatabaseError
+		require.ErrorAs(t, err, &dbErr)
+		assert.Equal(t, errors.ErrorTypeNotFound, dbErr.Type)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestUpdateGroupStrategy tests setting the group's selection strategy and invalidating the group cache
+func TestUpdateGroupStrategy(t *testing.T) {
+	repo, mock, mr, cleanup := setupAccountGroupRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	update := regexp.QuoteMeta("UPDATE `account_groups` SET `selection_strategy`=?,`updated_at`=? WHERE id = ? AND deleted_at IS NULL")
+
+	t.Run("update strategy successfully", func(t *testing.T) {
+		mr.FlushAll()
+		require.NoError(t, mr.Set("group:1", `{"ID":1,"Name":"cached"}`))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(update).
+			WithArgs("round_robin", sqlmock.AnyArg(), int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.UpdateGroupStrategy(ctx, 1, "round_robin"))
+		assert.False(t, mr.Exists("group:1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("group not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(update).
+			WithArgs("", sqlmock.AnyArg(), int64(999)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := repo.UpdateGroupStrategy(ctx, 999, "")
+		var dbErr *errors.DatabaseError
+		require.ErrorAs(t, err, &dbErr)
+		assert.Equal(t, errors.ErrorTypeNotFound, dbErr.Type)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 // TestGetAccountGroups tests getting groups for an account
 func TestGetAccountGroups(t *testing.T) {
 	repo, mock, mr, cleanup := setupAccountGroupRepo(t)
@@ -359,11 +592,11 @@ func TestGetAccountGroups(t *testing.T) {
 		now := time.Now()
 
 		// Mock JOIN query (GORM uses explicit column names instead of *)
-		groupRows := sqlmock.NewRows([]string{"id", "name", "description", "priority", "created_at", "updated_at", "deleted_at"}).
-			AddRow(int64(1), "group1", "desc1", int32(100), now, now, nil).
-			AddRow(int64(2), "group2", "desc2", int32(50), now, now, nil)
+		groupRows := sqlmock.NewRows([]string{"id", "name", "description", "priority", "rpm_limit", "tpm_limit", "concurrency_limit", "selection_strategy", "created_at", "updated_at", "deleted_at"}).
+			AddRow(int64(1), "group1", "desc1", int32(100), int32(60), int32(0), int32(20), "round_robin", now, now, nil).
+			AddRow(int64(2), "group2", "desc2", int32(50), int32(0), int32(0), int32(0), "", now, now, nil)
 
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT `account_groups`.`id`,`account_groups`.`name`,`account_groups`.`description`,`account_groups`.`priority`,`account_groups`.`created_at`,`account_groups`.`updated_at`,`account_groups`.`deleted_at` FROM `account_groups` JOIN account_group_members ON account_groups.id = account_group_members.group_id WHERE account_group_members.account_id = ? AND account_groups.deleted_at IS NULL ORDER BY account_groups.priority DESC")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `account_groups`.`id`,`account_groups`.`name`,`account_groups`.`description`,`account_groups`.`priority`,`account_groups`.`rpm_limit`,`account_groups`.`tpm_limit`,`account_groups`.`concurrency_limit`,`account_groups`.`selection_strategy`,`account_groups`.`created_at`,`account_groups`.`updated_at`,`account_groups`.`deleted_at` FROM `account_groups` JOIN account_group_members ON account_groups.id = account_group_members.group_id WHERE account_group_members.account_id = ? AND account_groups.deleted_at IS NULL ORDER BY account_groups.priority DESC")).
 			WithArgs(accountID).
 			WillReturnRows(groupRows)
 
@@ -373,6 +606,9 @@ func TestGetAccountGroups(t *testing.T) {
 		assert.Len(t, groups, 2)
 		assert.Equal(t, "group1", groups[0].Name)
 		assert.Equal(t, int32(100), groups[0].Priority)
+		assert.Equal(t, int32(60), groups[0].RpmLimit)
+		assert.Equal(t, int32(20), groups[0].ConcurrencyLimit)
+		assert.Equal(t, "round_robin", groups[0].Strategy)
 		assert.Equal(t, "group2", groups[1].Name)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -407,3 +643,34 @@ func TestGetAllGroupedAccountIDs(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+// TestCountGroupMembers tests the grouped member count query
+func TestCountGroupMembers(t *testing.T) {
+	repo, mock, _, cleanup := setupAccountGroupRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	t.Run("single grouped query", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"group_id", "member_count"}).
+			AddRow(int64(1), int64(3)).
+			AddRow(int64(2), int64(1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT group_id, COUNT(*) AS member_count FROM `account_group_members` WHERE group_id IN (?,?,?) GROUP BY `group_id`")).
+			WithArgs(int64(1), int64(2), int64(3)).
+			WillReturnRows(rows)
+
+		counts, err := repo.CountGroupMembers(ctx, []int64{1, 2, 3})
+
+		require.NoError(t, err)
+		assert.Equal(t, map[int64]int64{1: 3, 2: 1}, counts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("empty group list skips query", func(t *testing.T) {
+		counts, err := repo.CountGroupMembers(ctx, nil)
+
+		require.NoError(t, err)
+		assert.Empty(t, counts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,62 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 健康分数取值范围
+const (
+	MinHealthScore = 0
+	MaxHealthScore = 100
+)
+
+// ClampHealthScore 将健康分数限制在 [MinHealthScore, MaxHealthScore] 范围内
+func ClampHealthScore(score int) int {
+	return max(MinHealthScore, min(MaxHealthScore, score))
+}
+
+// RepairHealthScores 将库中超出 [0, 100] 的健康分数修正回范围内，返回修正的账户数
+// 正常写入路径都会限制范围，此处兜底修复直接改库或历史数据中的越界值，并清理受影响账户的缓存
+func (r *AccountRepo) RepairHealthScores(ctx context.Context) (int64, error) {
+	var ids []int64
+	if err := r.conn(ctx).
+		Model(&Account{}).
+		Where("health_score < ? OR health_score > ?", MinHealthScore, MaxHealthScore).
+		Pluck("id", &ids).Error; err != nil {
+		r.logger.Errorf("failed to list out-of-range health scores: %v", err)
+		return 0, fmt.Errorf("failed to list out-of-range health scores: %w", r.classifyConnError(err))
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// SQL: UPDATE api_accounts SET health_score = GREATEST(0, LEAST(100, health_score)), updated_at = ?
+	//      WHERE id IN (?) AND (health_score < 0 OR health_score > 100)
+	result := r.conn(ctx).
+		Model(&Account{}).
+		Where("id IN ?", ids).
+		Where("health_score < ? OR health_score > ?", MinHealthScore, MaxHealthScore).
+		Updates(map[string]interface{}{
+			"health_score": gorm.Expr("GREATEST(?, LEAST(?, health_score))", MinHealthScore, MaxHealthScore),
+			"updated_at":   time.Now(),
+		})
+	if result.Error != nil {
+		r.logger.Errorf("failed to repair health scores: %v", result.Error)
+		return 0, fmt.Errorf("failed to repair health scores: %w", r.classifyConnError(result.Error))
+	}
+
+	afterCommit(ctx, func() {
+		for _, id := range ids {
+			if err := r.cache.Delete(ctx, fmt.Sprintf("account:%d", id)); err != nil {
+				r.logger.Warnw("failed to delete account cache after health score repair", "id", id, "error", err)
+			}
+		}
+	})
+
+	r.logger.Warnw("repaired out-of-range health scores", "count", result.RowsAffected, "account_ids", ids)
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,93 @@
+package data
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClampHealthScore(t *testing.T) {
+	tests := []struct {
+		score int
+		want  int
+	}{
+		{score: -20, want: 0},
+		{score: 0, want: 0},
+		{score: 55, want: 55},
+		{score: 100, want: 100},
+		{score: 150, want: 100},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ClampHealthScore(tt.score), "score %d", tt.score)
+	}
+}
+
+// TestAccount_ToProto_ClampsStoredHealthScore tests that an out-of-range score stored in the
+// database is clamped when the account is read.
+func TestAccount_ToProto_ClampsStoredHealthScore(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, _, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+	ctx := context.Background()
+
+	for id, stored := range map[int64]int{1: 150, 2: -20} {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_accounts` WHERE id = ?")).
+			WithArgs(id, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "provider", "health_score", "status"}).
+				AddRow(id, "seeded", ProviderClaudeConsole, stored, StatusActive))
+
+		account, err := repo.GetAccount(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, stored, account.HealthScore, "the model keeps the stored value")
+		assert.Equal(t, int32(ClampHealthScore(stored)), account.ToProto().HealthScore)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAccountRepo_RepairHealthScores tests the repair of stored out-of-range scores.
+func TestAccountRepo_RepairHealthScores(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, mr, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+	ctx := context.Background()
+
+	t.Run("repairs out-of-range rows and clears their cache", func(t *testing.T) {
+		require.NoError(t, mr.Set("account:3", `{"id":3}`))
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id` FROM `api_accounts` WHERE health_score < ? OR health_score > ?")).
+			WithArgs(MinHealthScore, MaxHealthScore).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(8))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `api_accounts` SET `health_score`=GREATEST(?, LEAST(?, health_score)),`updated_at`=? "+
+			"WHERE id IN (?,?) AND (health_score < ? OR health_score > ?)")).
+			WithArgs(MinHealthScore, MaxHealthScore, sqlmock.AnyArg(), int64(3), int64(8), MinHealthScore, MaxHealthScore).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		repaired, err := repo.RepairHealthScores(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), repaired)
+		assert.False(t, mr.Exists("account:3"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("nothing to repair", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id` FROM `api_accounts`")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		repaired, err := repo.RepairHealthScores(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, repaired)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
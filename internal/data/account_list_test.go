@@ -0,0 +1,179 @@
+package data
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListAccounts_CircuitBrokenFilter tests the is_circuit_broken WHERE clause combined with other filters
+func TestListAccounts_CircuitBrokenFilter(t *testing.T) {
+	broken := true
+	healthy := false
+
+	tests := []struct {
+		name      string
+		filter    *AccountFilter
+		where     string
+		args      []interface{}
+		resultIDs []int64
+	}{
+		{
+			name:      "only broken",
+			filter:    &AccountFilter{Page: 1, PageSize: 20, IsCircuitBroken: &broken},
+			where:     "WHERE status != ? AND is_circuit_broken = ?",
+			args:      []interface{}{StatusInactive, true},
+			resultIDs: []int64{3},
+		},
+		{
+			name:      "only healthy with provider and status",
+			filter:    &AccountFilter{Page: 1, PageSize: 20, Provider: ProviderClaudeConsole, Status: StatusActive, IsCircuitBroken: &healthy},
+			where:     "WHERE provider = ? AND status = ? AND is_circuit_broken = ?",
+			args:      []interface{}{ProviderClaudeConsole, StatusActive, false},
+			resultIDs: []int64{1, 2},
+		},
+		{
+			name:      "unset",
+			filter:    &AccountFilter{Page: 1, PageSize: 20},
+			where:     "WHERE status != ?",
+			args:      []interface{}{StatusInactive},
+			resultIDs: []int64{1, 2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock, cleanup := setupGroupTestDB(t)
+			defer cleanup()
+			repo := NewAccountRepo(&Data{}, gormDB, nil, log.DefaultLogger)
+
+			// provider/status are custom Valuer types; only the boolean filter is matched exactly
+			driverArgs := make([]driver.Value, len(tt.args))
+			for i, arg := range tt.args {
+				driverArgs[i] = sqlmock.AnyArg()
+				if b, ok := arg.(bool); ok {
+					driverArgs[i] = b
+				}
+			}
+
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `api_accounts` " + tt.where)).
+				WithArgs(driverArgs...).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(len(tt.resultIDs)))
+
+			rows := sqlmock.NewRows([]string{"id", "name", "provider"})
+			for _, id := range tt.resultIDs {
+				rows.AddRow(id, "account", ProviderClaudeConsole)
+			}
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_accounts` " + tt.where + " ORDER BY created_at DESC, id DESC LIMIT ?")).
+				WithArgs(append(driverArgs, 20)...).
+				WillReturnRows(rows)
+
+			accounts, total, err := repo.ListAccounts(context.Background(), tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, int32(len(tt.resultIDs)), total)
+			require.Len(t, accounts, len(tt.resultIDs))
+			for i, id := range tt.resultIDs {
+				assert.Equal(t, id, accounts[i].ID)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestListAccounts_StablePaginationSameTimestamp tests that bulk-created accounts sharing
+// a created_at are paged deterministically via the id tie-breaker
+func TestListAccounts_StablePaginationSameTimestamp(t *testing.T) {
+	gormDB, mock, cleanup := setupGroupTestDB(t)
+	defer cleanup()
+	repo := NewAccountRepo(&Data{}, gormDB, nil, log.DefaultLogger)
+
+	// 5 accounts created in the same second, ordered as MySQL returns them for created_at DESC, id DESC
+	createdAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	pages := [][]int64{{5, 4}, {3, 2}, {1}}
+
+	seen := make(map[int64]bool)
+	for i, pageIDs := range pages {
+		page := int32(i + 1)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `api_accounts` WHERE status != ?")).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+
+		rows := sqlmock.NewRows([]string{"id", "name", "provider", "created_at"})
+		for _, id := range pageIDs {
+			rows.AddRow(id, "bulk", ProviderClaudeConsole, createdAt)
+		}
+		query := "SELECT * FROM `api_accounts` WHERE status != ? ORDER BY created_at DESC, id DESC LIMIT ?"
+		args := []driver.Value{sqlmock.AnyArg(), 2}
+		if page > 1 {
+			query += " OFFSET ?"
+			args = append(args, int((page-1)*2))
+		}
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(args...).WillReturnRows(rows)
+
+		accounts, total, err := repo.ListAccounts(context.Background(), &AccountFilter{Page: page, PageSize: 2})
+		require.NoError(t, err)
+		assert.Equal(t, int32(5), total)
+		require.Len(t, accounts, len(pageIDs))
+		for _, account := range accounts {
+			assert.False(t, seen[account.ID], "account %d returned on more than one page", account.ID)
+			seen[account.ID] = true
+		}
+	}
+
+	assert.Len(t, seen, 5, "every account appears exactly once across pages")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestCountAccounts_MatchesListTotal tests that CountAccounts applies the same WHERE clause
+// as ListAccounts and returns the same total
+func TestCountAccounts_MatchesListTotal(t *testing.T) {
+	broken := true
+	reauth := false
+
+	tests := []struct {
+		name   string
+		filter *AccountFilter
+		where  string
+		total  int
+	}{
+		{name: "nil filter", filter: nil, where: "WHERE status != ?", total: 7},
+		{name: "provider and status", filter: &AccountFilter{Provider: ProviderClaudeConsole, Status: StatusActive}, where: "WHERE provider = ? AND status = ?", total: 3},
+		{name: "flags", filter: &AccountFilter{IsCircuitBroken: &broken, NeedsReauth: &reauth}, where: "WHERE status != ? AND is_circuit_broken = ? AND needs_reauth = ?", total: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock, cleanup := setupGroupTestDB(t)
+			defer cleanup()
+			repo := NewAccountRepo(&Data{}, gormDB, nil, log.DefaultLogger)
+
+			countSQL := regexp.QuoteMeta("SELECT count(*) FROM `api_accounts` " + tt.where)
+			mock.ExpectQuery(countSQL).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.total))
+			mock.ExpectQuery(countSQL).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.total))
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_accounts` " + tt.where + " ORDER BY created_at DESC, id DESC LIMIT ?")).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+			count, err := repo.CountAccounts(context.Background(), tt.filter)
+			require.NoError(t, err)
+
+			listFilter := &AccountFilter{Page: 1, PageSize: 20}
+			if tt.filter != nil {
+				copied := *tt.filter
+				listFilter = &copied
+			}
+			_, total, err := repo.ListAccounts(context.Background(), listFilter)
+			require.NoError(t, err)
+
+			assert.Equal(t, int64(total), count)
+			assert.Equal(t, int64(tt.total), count)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
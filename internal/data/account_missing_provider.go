@@ -0,0 +1,117 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MissingProviderPolicy 决定读取路径如何处理 provider 为空（NULL 或空字符串）的损坏账户
+// 此类账户通常来自直接改库或非严格模式下写入了非法枚举值，调用方拿到后会在按 Provider 分派时走到默认分支
+type MissingProviderPolicy string
+
+const (
+	// MissingProviderSkip 列表读取跳过该账户，GetAccount 返回 ErrAccountMissingProvider（默认）
+	MissingProviderSkip MissingProviderPolicy = "skip"
+	// MissingProviderInclude 原样返回该账户（旧行为），仅记录数据完整性告警
+	MissingProviderInclude MissingProviderPolicy = "include"
+)
+
+// ErrAccountMissingProvider is returned (wrapped with the account ID) by GetAccount when the
+// stored account has no provider and the skip policy is in effect. Callers match it with errors.Is.
+var ErrAccountMissingProvider = errors.New("account has no provider")
+
+// missingProviderLastError 对账任务标记损坏账户时写入 last_error 的内容
+const missingProviderLastError = `{"error":"account has no provider"}`
+
+// ParseMissingProviderPolicy parses data.missing_provider_policy; empty means MissingProviderSkip.
+func ParseMissingProviderPolicy(policy string) (MissingProviderPolicy, error) {
+	switch MissingProviderPolicy(policy) {
+	case "", MissingProviderSkip:
+		return MissingProviderSkip, nil
+	case MissingProviderInclude:
+		return MissingProviderInclude, nil
+	default:
+		return "", fmt.Errorf("unknown missing provider policy %q (want skip or include)", policy)
+	}
+}
+
+// SetMissingProviderPolicy sets how read paths treat accounts without a provider.
+func (r *AccountRepo) SetMissingProviderPolicy(policy MissingProviderPolicy) {
+	r.missingProvider = policy
+}
+
+// skipMissingProvider reports whether account has no provider and should be withheld from
+// callers. A data-integrity warning is logged for every such account regardless of policy.
+func (r *AccountRepo) skipMissingProvider(account *Account) bool {
+	if account.Provider != "" {
+		return false
+	}
+	r.logger.Warnw("data integrity: account has no provider",
+		"id", account.ID, "name", account.Name, "policy", r.missingProviderPolicy())
+	return r.missingProviderPolicy() == MissingProviderSkip
+}
+
+// withoutMissingProvider drops accounts without a provider from a list read under the skip policy.
+// Totals reported alongside the list still count them until the reconciler flags them.
+func (r *AccountRepo) withoutMissingProvider(accounts []*Account) []*Account {
+	kept := accounts[:0]
+	for _, account := range accounts {
+		if !r.skipMissingProvider(account) {
+			kept = append(kept, account)
+		}
+	}
+	return kept
+}
+
+func (r *AccountRepo) missingProviderPolicy() MissingProviderPolicy {
+	if r.missingProvider == "" {
+		return MissingProviderSkip
+	}
+	return r.missingProvider
+}
+
+// FlagAccountsMissingProvider 将 provider 为空且尚未标记的账户置为 error 状态并记录 last_error，
+// 返回被标记的账户 ID。error 状态的账户不会被调度、刷新或健康检查，需人工修正 provider 后恢复
+func (r *AccountRepo) FlagAccountsMissingProvider(ctx context.Context) ([]int64, error) {
+	var ids []int64
+	if err := r.conn(ctx).
+		Model(&Account{}).
+		Where("(provider IS NULL OR provider = '') AND status <> ?", StatusError).
+		Pluck("id", &ids).Error; err != nil {
+		r.logger.Errorf("failed to list accounts without provider: %v", err)
+		return nil, fmt.Errorf("failed to list accounts without provider: %w", r.classifyConnError(err))
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	// SQL: UPDATE api_accounts SET status = 'error', last_error = ?, last_error_at = ?, updated_at = ?
+	//      WHERE id IN (?) AND (provider IS NULL OR provider = '')
+	if err := r.conn(ctx).
+		Model(&Account{}).
+		Where("id IN ?", ids).
+		Where("provider IS NULL OR provider = ''").
+		Updates(map[string]interface{}{
+			"status":        StatusError,
+			"last_error":    missingProviderLastError,
+			"last_error_at": now,
+			"updated_at":    now,
+		}).Error; err != nil {
+		r.logger.Errorf("failed to flag accounts without provider: %v", err)
+		return nil, fmt.Errorf("failed to flag accounts without provider: %w", r.classifyConnError(err))
+	}
+
+	afterCommit(ctx, func() {
+		for _, id := range ids {
+			if err := r.cache.Delete(ctx, fmt.Sprintf("account:%d", id)); err != nil {
+				r.logger.Warnw("failed to delete account cache after flagging missing provider", "id", id, "error", err)
+			}
+		}
+	})
+
+	r.logger.Warnw("data integrity: flagged accounts without provider", "count", len(ids), "account_ids", ids)
+	return ids, nil
+}
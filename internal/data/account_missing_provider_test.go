@@ -0,0 +1,121 @@
+package data
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMissingProviderPolicy(t *testing.T) {
+	for input, want := range map[string]MissingProviderPolicy{
+		"":        MissingProviderSkip,
+		"skip":    MissingProviderSkip,
+		"include": MissingProviderInclude,
+	} {
+		got, err := ParseMissingProviderPolicy(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	_, err := ParseMissingProviderPolicy("drop")
+	assert.ErrorContains(t, err, "unknown missing provider policy")
+}
+
+// TestAccountRepo_MissingProviderReads tests that a seeded account without a provider is
+// withheld from reads under the skip policy and returned under the include policy.
+func TestAccountRepo_MissingProviderReads(t *testing.T) {
+	listSQL := regexp.QuoteMeta("SELECT * FROM `api_accounts` WHERE status != ? ORDER BY created_at DESC, id DESC LIMIT ?")
+	countSQL := regexp.QuoteMeta("SELECT count(*) FROM `api_accounts` WHERE status != ?")
+	seededRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "provider"}).
+			AddRow(1, "healthy", ProviderClaudeConsole).
+			AddRow(2, "corrupt", nil).
+			AddRow(3, "invalid-enum", "")
+	}
+
+	t.Run("skip", func(t *testing.T) {
+		gormDB, mock, dbCleanup := setupGroupTestDB(t)
+		defer dbCleanup()
+		redisClient, _, redisCleanup := setupGroupTestRedis(t)
+		defer redisCleanup()
+		repo := NewAccountRepo(&Data{cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+
+		mock.ExpectQuery(countSQL).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.ExpectQuery(listSQL).WillReturnRows(seededRows())
+
+		accounts, _, err := repo.ListAccounts(context.Background(), nil)
+		require.NoError(t, err)
+		require.Len(t, accounts, 1)
+		assert.Equal(t, int64(1), accounts[0].ID)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_accounts` WHERE id = ?")).
+			WithArgs(int64(2), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "provider"}).AddRow(2, "corrupt", nil))
+
+		_, err = repo.GetAccount(context.Background(), 2)
+		assert.ErrorIs(t, err, ErrAccountMissingProvider)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("include", func(t *testing.T) {
+		gormDB, mock, cleanup := setupGroupTestDB(t)
+		defer cleanup()
+		repo := NewAccountRepo(&Data{}, gormDB, nil, log.DefaultLogger)
+		repo.SetMissingProviderPolicy(MissingProviderInclude)
+
+		mock.ExpectQuery(countSQL).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.ExpectQuery(listSQL).WillReturnRows(seededRows())
+
+		accounts, total, err := repo.ListAccounts(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), total)
+		assert.Len(t, accounts, 3)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestAccountRepo_FlagAccountsMissingProvider tests that the reconciler marks a seeded
+// empty-provider account as error and clears its cache.
+func TestAccountRepo_FlagAccountsMissingProvider(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, mr, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+	ctx := context.Background()
+
+	t.Run("flags seeded empty-provider row", func(t *testing.T) {
+		require.NoError(t, mr.Set("account:7", `{"id":7}`))
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id` FROM `api_accounts` WHERE (provider IS NULL OR provider = '') AND status <> ?")).
+			WithArgs(StatusError).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `api_accounts` SET `last_error`=?,`last_error_at`=?,`status`=?,`updated_at`=? "+
+			"WHERE id IN (?) AND (provider IS NULL OR provider = '')")).
+			WithArgs(missingProviderLastError, sqlmock.AnyArg(), StatusError, sqlmock.AnyArg(), int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		flagged, err := repo.FlagAccountsMissingProvider(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{7}, flagged)
+		assert.False(t, mr.Exists("account:7"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("nothing to flag", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id` FROM `api_accounts`")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		flagged, err := repo.FlagAccountsMissingProvider(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, flagged)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
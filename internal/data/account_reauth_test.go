@@ -0,0 +1,157 @@
+package data
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMarkNeedsReauth tests that the flag is set together with the error status and the backoff is cleared
+func TestMarkNeedsReauth(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, _, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+
+	update := regexp.QuoteMeta("UPDATE `api_accounts` SET `needs_reauth`=?,`next_refresh_attempt_at`=?,`status`=?,`updated_at`=? WHERE id = ?")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(update).
+		WithArgs(true, nil, StatusError, sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, repo.MarkNeedsReauth(context.Background(), 1))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(update).
+		WithArgs(true, nil, StatusError, sqlmock.AnyArg(), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	assert.ErrorIs(t, repo.MarkNeedsReauth(context.Background(), 2), ErrAccountNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestUpdateOAuthData_ClearsNeedsReauth tests that writing fresh tokens (re-authorization) clears the flag
+func TestUpdateOAuthData_ClearsNeedsReauth(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, _, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `api_accounts` SET `needs_reauth`=?,`next_refresh_attempt_at`=?,`oauth_data_encrypted`=?,`oauth_expires_at`=?,`token_expires_at`=?,`updated_at`=? WHERE id = ?")).
+		WithArgs(false, nil, "encrypted", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.UpdateOAuthData(context.Background(), 1, "encrypted", time.Now()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestListCodexCLIAccountsNeedingRefresh_SkipsNeedsReauth tests that accounts awaiting re-auth are not refreshed
+func TestListCodexCLIAccountsNeedingRefresh_SkipsNeedsReauth(t *testing.T) {
+	gormDB, mock, cleanup := setupGroupTestDB(t)
+	defer cleanup()
+	repo := NewAccountRepo(&Data{}, gormDB, nil, log.DefaultLogger)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_accounts` WHERE provider = ? AND status = ? AND needs_reauth = ? AND token_expires_at < ? ORDER BY token_expires_at ASC")).
+		WithArgs(ProviderCodexCLI, StatusActive, false, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "codex"))
+
+	accounts, err := repo.ListCodexCLIAccountsNeedingRefresh(context.Background(), 0)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// thresholdArg captures the time bound to a query argument.
+type thresholdArg struct{ got *time.Time }
+
+func (a thresholdArg) Match(v driver.Value) bool {
+	t, ok := v.(time.Time)
+	*a.got = t
+	return ok
+}
+
+// TestListCodexCLIAccountsNeedingRefresh_Window tests that the token_expires_at bound follows the window
+func TestListCodexCLIAccountsNeedingRefresh_Window(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT * FROM `api_accounts` WHERE provider = ? AND status = ? AND needs_reauth = ? AND token_expires_at < ? ORDER BY token_expires_at ASC")
+
+	for _, tc := range []struct {
+		name   string
+		window time.Duration
+		want   time.Duration
+	}{
+		{name: "default", window: 0, want: DefaultCodexRefreshWindow},
+		{name: "custom", window: 2 * time.Hour, want: 2 * time.Hour},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gormDB, mock, cleanup := setupGroupTestDB(t)
+			defer cleanup()
+			repo := NewAccountRepo(&Data{}, gormDB, nil, log.DefaultLogger)
+
+			var threshold time.Time
+			mock.ExpectQuery(query).
+				WithArgs(ProviderCodexCLI, StatusActive, false, thresholdArg{got: &threshold}).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+			before := time.Now()
+			_, err := repo.ListCodexCLIAccountsNeedingRefresh(context.Background(), tc.window)
+			require.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			assert.WithinRange(t, threshold, before.Add(tc.want), time.Now().Add(tc.want))
+		})
+	}
+}
+
+// TestCodexRefreshThreshold tests accounts just inside and outside a custom window
+func TestCodexRefreshThreshold(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	window := 30 * time.Minute
+	threshold := codexRefreshThreshold(now, window)
+
+	inside := now.Add(window - time.Second)
+	outside := now.Add(window + time.Second)
+	assert.True(t, inside.Before(threshold), "expiring just inside the window needs refresh")
+	assert.False(t, outside.Before(threshold), "expiring just outside the window does not")
+	assert.False(t, now.Add(window).Before(threshold), "the bound itself is exclusive")
+
+	// A non-positive window falls back to the 5-minute default
+	assert.Equal(t, now.Add(DefaultCodexRefreshWindow), codexRefreshThreshold(now, -time.Minute))
+	assert.True(t, now.Add(4*time.Minute).Before(codexRefreshThreshold(now, 0)))
+	assert.False(t, now.Add(6*time.Minute).Before(codexRefreshThreshold(now, 0)))
+}
+
+// TestListAccounts_NeedsReauthFilter tests filtering the account list by the re-auth flag
+func TestListAccounts_NeedsReauthFilter(t *testing.T) {
+	gormDB, mock, cleanup := setupGroupTestDB(t)
+	defer cleanup()
+	repo := NewAccountRepo(&Data{}, gormDB, nil, log.DefaultLogger)
+
+	needsReauth := true
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `api_accounts` WHERE status != ? AND needs_reauth = ?")).
+		WithArgs(StatusInactive, true).
+		WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_accounts` WHERE status != ? AND needs_reauth = ? ORDER BY created_at DESC, id DESC LIMIT ?")).
+		WithArgs(StatusInactive, true, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "provider", "needs_reauth"}).AddRow(5, "revoked", ProviderCodexCLI, true))
+
+	accounts, total, err := repo.ListAccounts(context.Background(), &AccountFilter{NeedsReauth: &needsReauth})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), total)
+	require.Len(t, accounts, 1)
+	assert.True(t, accounts[0].NeedsReauth)
+	assert.True(t, accounts[0].ToProto().NeedsReauth)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
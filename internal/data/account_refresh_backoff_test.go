@@ -0,0 +1,75 @@
+package data
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nowArg matches a time argument bound close to the moment the query ran.
+type nowArg struct{}
+
+func (nowArg) Match(v driver.Value) bool {
+	t, ok := v.(time.Time)
+	return ok && time.Since(t) < time.Minute
+}
+
+// TestListExpiringAccounts_SkipsBackoff tests that accounts still in refresh backoff are excluded
+func TestListExpiringAccounts_SkipsBackoff(t *testing.T) {
+	gormDB, mock, cleanup := setupGroupTestDB(t)
+	defer cleanup()
+	repo := NewAccountRepo(&Data{}, gormDB, nil, log.DefaultLogger)
+
+	threshold := time.Now().Add(10 * time.Minute)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_accounts` WHERE provider IN (?, ?) AND status = ? AND needs_reauth = ? AND oauth_expires_at IS NOT NULL AND oauth_expires_at <= ? AND (next_refresh_attempt_at IS NULL OR next_refresh_attempt_at <= ?) ORDER BY oauth_expires_at ASC")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), false, threshold, nowArg{}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "due"))
+
+	accounts, err := repo.ListExpiringAccounts(context.Background(), threshold)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	assert.Equal(t, int64(1), accounts[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSetNextRefreshAttempt tests writing and clearing the refresh backoff time
+func TestSetNextRefreshAttempt(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, _, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+
+	nextAttempt := time.Now().Add(5 * time.Minute)
+	update := regexp.QuoteMeta("UPDATE `api_accounts` SET `next_refresh_attempt_at`=?,`updated_at`=? WHERE id = ?")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(update).
+		WithArgs(nextAttempt, sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, repo.SetNextRefreshAttempt(context.Background(), 1, &nextAttempt))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(update).
+		WithArgs(nil, sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, repo.SetNextRefreshAttempt(context.Background(), 1, nil))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(update).
+		WithArgs(nil, sqlmock.AnyArg(), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	assert.Error(t, repo.SetNextRefreshAttempt(context.Background(), 2, nil))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,133 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAccountNotPurgeable is returned by PurgeAccount when the account is no longer inactive
+// (e.g. it was reactivated after being listed for purge).
+var ErrAccountNotPurgeable = errors.New("account is not inactive")
+
+// inactivatedAt returns the inactivated_at value for a status change: now for inactive, NULL otherwise.
+func inactivatedAt(status AccountStatus, now time.Time) *time.Time {
+	if status != StatusInactive {
+		return nil
+	}
+	return &now
+}
+
+// syncInactivatedAt keeps inactivated_at consistent with the status before a full save:
+// set on the first save as inactive, cleared when the account is no longer inactive.
+func syncInactivatedAt(account *Account, now time.Time) {
+	if account.Status != StatusInactive {
+		account.InactivatedAt = nil
+		return
+	}
+	if account.InactivatedAt == nil {
+		account.InactivatedAt = &now
+	}
+}
+
+// ListPurgeableAccounts returns soft-deleted accounts inactivated before cutoff, oldest first.
+// Accounts soft-deleted before inactivated_at existed are backfilled from updated_at by migration 000031.
+func (r *AccountRepo) ListPurgeableAccounts(ctx context.Context, cutoff time.Time, limit int) ([]*Account, error) {
+	var accounts []*Account
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND inactivated_at < ?", StatusInactive, cutoff).
+		Order("inactivated_at ASC").
+		Limit(limit).
+		Find(&accounts).Error
+	if err != nil {
+		r.logger.Errorf("failed to list purgeable accounts: %v", err)
+		return nil, fmt.Errorf("failed to list purgeable accounts: %w", r.classifyConnError(err))
+	}
+
+	return accounts, nil
+}
+
+// PurgeAccount permanently deletes a soft-deleted account.
+// Group memberships are removed in the same transaction and the account row is only deleted while it
+// is still inactive, so an account reactivated in the meantime is left untouched (ErrAccountNotPurgeable).
+// After commit the account, membership and group caches and the account's rate limit, concurrency and
+// circuit breaker keys are removed from Redis.
+func (r *AccountRepo) PurgeAccount(ctx context.Context, id int64) error {
+	var groupIDs []int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&AccountGroupMember{}).Where("account_id = ?", id).Pluck("group_id", &groupIDs).Error; err != nil {
+			return fmt.Errorf("failed to list group memberships: %w", err)
+		}
+		if err := tx.Where("account_id = ?", id).Delete(&AccountGroupMember{}).Error; err != nil {
+			return fmt.Errorf("failed to delete group memberships: %w", err)
+		}
+
+		result := tx.Where("id = ? AND status = ?", id, StatusInactive).Delete(&Account{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete account: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: id=%d", ErrAccountNotPurgeable, id)
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, ErrAccountNotPurgeable) {
+			r.logger.Errorf("failed to purge account %d: %v", id, err)
+		}
+		return err
+	}
+
+	r.clearPurgedAccountKeys(ctx, id, groupIDs)
+	r.logger.Infow("account purged (hard delete)", "id", id, "groups", groupIDs)
+	return nil
+}
+
+// clearPurgedAccountKeys removes every Redis key the data layer keeps for a purged account:
+// cache entries, rate limit (including sliding RPM, per-model and daily token) keys, concurrency and circuit breaker keys.
+// Keys owned by the business layer (health history, refresh failures, alerts) are cleared by the caller.
+// Redis failures are logged only: the keys are caches or expire on their own.
+func (r *AccountRepo) clearPurgedAccountKeys(ctx context.Context, id int64, groupIDs []int64) {
+	if r.data == nil || r.data.GetRedisClient() == nil {
+		return
+	}
+	rdb := r.data.GetRedisClient()
+
+	keys := []string{
+		fmt.Sprintf("account:%d", id),
+		fmt.Sprintf("account:%d:groups", id),
+		getRateLimitKey(id, "rpm"),
+		getRateLimitKey(id, "tpm"),
+		getSlidingRPMKey(id, ""),
+		getConcurrencyKey(id),
+	}
+	keys = append(keys, circuitBreakerKeys(id)...)
+	for _, groupID := range groupIDs {
+		keys = append(keys, fmt.Sprintf("group:%d", groupID))
+	}
+
+	// Per-model and daily keys have open-ended suffixes; their patterns carry the account hash tag
+	for _, pattern := range []string{
+		fmt.Sprintf("rate:{%d}:model:*", id),
+		fmt.Sprintf("daily:{%d}:*", id),
+	} {
+		matched, err := scanKeys(ctx, rdb, pattern)
+		if err != nil {
+			r.logger.Warnw("failed to scan Redis keys of purged account", "id", id, "pattern", pattern, "error", err)
+			continue
+		}
+		keys = append(keys, matched...)
+	}
+
+	// One DEL per key: a multi-key DEL fails with CROSSSLOT in Redis Cluster mode
+	pipe := rdb.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Warnw("failed to delete Redis keys of purged account", "id", id, "error", err)
+	}
+}
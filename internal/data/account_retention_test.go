@@ -0,0 +1,176 @@
+package data
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeleteAccount_RecordsInactivatedAt tests that soft delete stamps the retention clock
+func TestDeleteAccount_RecordsInactivatedAt(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, _, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+
+	var got time.Time
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `api_accounts` SET `inactivated_at`=?,`status`=?,`updated_at`=? WHERE id = ?")).
+		WithArgs(thresholdArg{got: &got}, StatusInactive, sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.DeleteAccount(context.Background(), 1))
+	assert.WithinDuration(t, time.Now(), got, time.Minute)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestUpdateAccountStatus_ClearsInactivatedAt tests that leaving inactive clears the retention clock
+func TestUpdateAccountStatus_ClearsInactivatedAt(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, _, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `api_accounts` SET `inactivated_at`=?,`status`=?,`updated_at`=? WHERE id = ?")).
+		WithArgs(nil, StatusActive, sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.UpdateAccountStatus(context.Background(), 1, StatusActive))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSetDraining_UpdatesOnlyDrainColumn tests that the drain flag is written without saving the whole row
+func TestSetDraining_UpdatesOnlyDrainColumn(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, _, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `api_accounts` SET `is_draining`=?,`updated_at`=? WHERE id = ?")).
+		WithArgs(true, sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.SetDraining(context.Background(), 1, true))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSyncInactivatedAt(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-48 * time.Hour)
+
+	account := &Account{Status: StatusInactive}
+	syncInactivatedAt(account, now)
+	require.NotNil(t, account.InactivatedAt)
+	assert.Equal(t, now, *account.InactivatedAt)
+
+	// Saving an account that is already inactive keeps the original time
+	account = &Account{Status: StatusInactive, InactivatedAt: &earlier}
+	syncInactivatedAt(account, now)
+	assert.Equal(t, earlier, *account.InactivatedAt)
+
+	account = &Account{Status: StatusActive, InactivatedAt: &earlier}
+	syncInactivatedAt(account, now)
+	assert.Nil(t, account.InactivatedAt)
+}
+
+// TestListPurgeableAccounts tests that only inactive accounts past the cutoff are listed, oldest first
+func TestListPurgeableAccounts(t *testing.T) {
+	gormDB, mock, cleanup := setupGroupTestDB(t)
+	defer cleanup()
+	repo := NewAccountRepo(&Data{}, gormDB, nil, log.DefaultLogger)
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_accounts` WHERE status = ? AND inactivated_at < ? ORDER BY inactivated_at ASC LIMIT ?")).
+		WithArgs(StatusInactive, cutoff, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(4, "old"))
+
+	accounts, err := repo.ListPurgeableAccounts(context.Background(), cutoff, 100)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	assert.Equal(t, int64(4), accounts[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestPurgeAccount tests that memberships and the row are deleted together and Redis keys are cleared
+func TestPurgeAccount(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, mr, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{redisClient: redisClient, cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+
+	keys := []string{
+		"account:4", "account:4:groups", "group:7", "rate:{4}:rpm", "rate:{4}:rpm:sliding",
+		"rate:{4}:model:claude-opus:rpm", "rate:{4}:model:claude-opus:rpm:sliding", "daily:{4}:20260101",
+		"concurrency:{4}", "circuit:4:backoff",
+	}
+	for _, key := range keys {
+		require.NoError(t, mr.Set(key, "x"))
+	}
+	require.NoError(t, mr.Set("account:5", "x"))
+	require.NoError(t, mr.Set("rate:{45}:model:claude-opus:rpm", "x"))
+	require.NoError(t, mr.Set("daily:{45}:20260101", "x"))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `group_id` FROM `account_group_members` WHERE account_id = ?")).
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"group_id"}).AddRow(7))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `account_group_members` WHERE account_id = ?")).
+		WithArgs(int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `api_accounts` WHERE id = ? AND status = ?")).
+		WithArgs(int64(4), StatusInactive).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.PurgeAccount(context.Background(), 4))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	for _, key := range keys {
+		assert.False(t, mr.Exists(key), key)
+	}
+	assert.True(t, mr.Exists("account:5"), "other accounts are untouched")
+	assert.True(t, mr.Exists("rate:{45}:model:claude-opus:rpm"), "patterns are scoped to the account hash tag")
+	assert.True(t, mr.Exists("daily:{45}:20260101"), "patterns are scoped to the account hash tag")
+}
+
+// TestPurgeAccount_Reactivated tests that an account reactivated after listing is not purged
+func TestPurgeAccount_Reactivated(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, mr, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{redisClient: redisClient, cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+	require.NoError(t, mr.Set("account:4", "x"))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `group_id` FROM `account_group_members` WHERE account_id = ?")).
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"group_id"}).AddRow(7))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `account_group_members` WHERE account_id = ?")).
+		WithArgs(int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `api_accounts` WHERE id = ? AND status = ?")).
+		WithArgs(int64(4), StatusInactive).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.PurgeAccount(context.Background(), 4)
+	assert.ErrorIs(t, err, ErrAccountNotPurgeable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.True(t, mr.Exists("account:4"))
+}
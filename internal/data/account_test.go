@@ -1,14 +1,19 @@
 package data
 
 import (
+	"context"
 	"database/sql/driver"
+	"regexp"
 	"testing"
 	"time"
 
 	v1 "QuotaLane/api/v1"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 // TestAccountProvider_ScanValue tests enum scanning and value conversion.
@@ -259,6 +264,7 @@ func TestAccount_ToProto(t *testing.T) {
 		IsCircuitBroken:    false,
 		Status:             StatusActive,
 		Metadata:           &metadata,
+		Notes:              "ticket #123",
 		CreatedAt:          now,
 		UpdatedAt:          now,
 	}
@@ -276,6 +282,7 @@ func TestAccount_ToProto(t *testing.T) {
 	assert.False(t, proto.IsCircuitBroken)
 	assert.Equal(t, v1.AccountStatus_ACCOUNT_ACTIVE, proto.Status)
 	assert.Equal(t, `{"region":"us-east-1"}`, proto.Metadata)
+	assert.Equal(t, "ticket #123", proto.Notes)
 	assert.NotNil(t, proto.CreatedAt)
 	assert.NotNil(t, proto.UpdatedAt)
 }
@@ -620,6 +627,7 @@ func TestProviderFromProto_AllCases(t *testing.T) {
 	require.Equal(t, ProviderGemini, ProviderFromProto(v1.AccountProvider_GEMINI))
 	require.Equal(t, ProviderOpenAIResponses, ProviderFromProto(v1.AccountProvider_OPENAI_RESPONSES))
 	require.Equal(t, ProviderAzureOpenAI, ProviderFromProto(v1.AccountProvider_AZURE_OPENAI))
+	require.Equal(t, ProviderCodexCLI, ProviderFromProto(v1.AccountProvider_CODEX_CLI))
 }
 
 // TestProviderToProto_AllCases tests all provider enum conversions to proto.
@@ -633,6 +641,7 @@ func TestProviderToProto_AllCases(t *testing.T) {
 	require.Equal(t, v1.AccountProvider_GEMINI, ProviderToProto(ProviderGemini))
 	require.Equal(t, v1.AccountProvider_OPENAI_RESPONSES, ProviderToProto(ProviderOpenAIResponses))
 	require.Equal(t, v1.AccountProvider_AZURE_OPENAI, ProviderToProto(ProviderAzureOpenAI))
+	require.Equal(t, v1.AccountProvider_CODEX_CLI, ProviderToProto(ProviderCodexCLI))
 }
 
 // TestStatusFromProto_AllCases tests all status enum conversions from proto.
@@ -652,3 +661,129 @@ func TestStatusToProto_AllCases(t *testing.T) {
 // Note: Integration tests for ListAccountsByTags and UpdateAccount cache clearing
 // are covered by biz layer tests with mocked repositories (see account_test.go in biz layer).
 // These provide better test isolation and are less fragile than database mock tests.
+
+// TestAccountRepo_NotFound tests that missing accounts return ErrAccountNotFound.
+func TestAccountRepo_NotFound(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, _, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountRepo(&Data{cache: NewCacheClient(redisClient)}, gormDB, nil, log.DefaultLogger)
+	ctx := context.Background()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_accounts` WHERE id = ?")).
+		WillReturnError(gorm.ErrRecordNotFound)
+	_, err := repo.GetAccount(ctx, 999)
+	assert.ErrorIs(t, err, ErrAccountNotFound)
+	assert.Contains(t, err.Error(), "id=999")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `api_accounts` SET")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	assert.ErrorIs(t, repo.DeleteAccount(ctx, 999), ErrAccountNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAccountRepo_GetFleetHealthStats tests the per-status aggregate query and its filters.
+func TestAccountRepo_GetFleetHealthStats(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	repo := NewAccountRepo(&Data{}, gormDB, nil, log.DefaultLogger)
+	ctx := context.Background()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT status, COUNT(*) AS account_count, COALESCE(AVG(health_score), 0) AS avg_health_score, "+
+		"COALESCE(SUM(CASE WHEN is_circuit_broken THEN 1 ELSE 0 END), 0) AS circuit_broken_count FROM `api_accounts` "+
+		"WHERE status != ? AND provider = ? AND id IN (SELECT `account_id` FROM `account_group_members` WHERE group_id = ?) "+
+		"GROUP BY `status` ORDER BY status")).
+		WithArgs(StatusInactive, ProviderClaudeConsole, int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "account_count", "avg_health_score", "circuit_broken_count"}).
+			AddRow("active", 3, 85.5, 1).
+			AddRow("error", 1, 20.0, 1))
+
+	stats, err := repo.GetFleetHealthStats(ctx, FleetHealthFilter{Provider: ProviderClaudeConsole, GroupID: 7})
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, StatusActive, stats[0].Status)
+	assert.Equal(t, int64(3), stats[0].AccountCount)
+	assert.InDelta(t, 85.5, stats[0].AvgHealthScore, 1e-9)
+	assert.Equal(t, int64(1), stats[1].CircuitBrokenCount)
+
+	mock.ExpectQuery(`SELECT status, .+ WHERE status != \? GROUP BY`).
+		WithArgs(StatusInactive).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "account_count", "avg_health_score", "circuit_broken_count"}))
+
+	stats, err = repo.GetFleetHealthStats(ctx, FleetHealthFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAccountRepo_GetCapacityStats tests that limits are summed over limited accounts only and
+// the limited account IDs are returned per dimension.
+func TestAccountRepo_GetCapacityStats(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	repo := NewAccountRepo(&Data{}, gormDB, nil, log.DefaultLogger)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, rpm_limit, tpm_limit FROM `api_accounts` WHERE provider = ? AND status = ?")).
+		WithArgs(ProviderClaudeConsole, StatusActive).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rpm_limit", "tpm_limit"}).
+			AddRow(1, 100, 10000).
+			AddRow(2, 50, 0).
+			AddRow(3, 0, 5000).
+			AddRow(4, 0, 0))
+
+	stats, err := repo.GetCapacityStats(context.Background(), ProviderClaudeConsole)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), stats.AccountCount)
+	assert.Equal(t, int64(150), stats.TotalRPMLimit)
+	assert.Equal(t, int64(15000), stats.TotalTPMLimit)
+	assert.Equal(t, int64(2), stats.UnlimitedRPMAccounts)
+	assert.Equal(t, int64(2), stats.UnlimitedTPMAccounts)
+	assert.Equal(t, []int64{1, 2}, stats.RPMLimitedAccountIDs)
+	assert.Equal(t, []int64{1, 3}, stats.TPMLimitedAccountIDs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAccountRepo_GetExpiryDistribution tests the conditional-aggregation expiry query, its bucket
+// boundaries and the provider filter.
+func TestAccountRepo_GetExpiryDistribution(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	repo := NewAccountRepo(&Data{}, gormDB, nil, log.DefaultLogger)
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"expired", "within_1h", "within_6h", "within_24h", "beyond_24h"}
+
+	const expiresAt = "COALESCE(token_expires_at, oauth_expires_at)"
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(CASE WHEN "+expiresAt+" <= ? THEN 1 ELSE 0 END), 0) AS expired, "+
+		"COALESCE(SUM(CASE WHEN "+expiresAt+" > ? AND "+expiresAt+" <= ? THEN 1 ELSE 0 END), 0) AS within_1h, "+
+		"COALESCE(SUM(CASE WHEN "+expiresAt+" > ? AND "+expiresAt+" <= ? THEN 1 ELSE 0 END), 0) AS within_6h, "+
+		"COALESCE(SUM(CASE WHEN "+expiresAt+" > ? AND "+expiresAt+" <= ? THEN 1 ELSE 0 END), 0) AS within_24h, "+
+		"COALESCE(SUM(CASE WHEN "+expiresAt+" > ? THEN 1 ELSE 0 END), 0) AS beyond_24h FROM `api_accounts` "+
+		"WHERE status != ? AND (token_expires_at IS NOT NULL OR oauth_expires_at IS NOT NULL) AND provider IN (?,?)")).
+		WithArgs(now,
+			now, now.Add(time.Hour),
+			now.Add(time.Hour), now.Add(6*time.Hour),
+			now.Add(6*time.Hour), now.Add(24*time.Hour),
+			now.Add(24*time.Hour),
+			StatusInactive, ProviderClaudeOfficial, ProviderCodexCLI).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(2, 1, 3, 0, 5))
+
+	dist, err := repo.GetExpiryDistribution(ctx, []AccountProvider{ProviderClaudeOfficial, ProviderCodexCLI}, now)
+	require.NoError(t, err)
+	assert.Equal(t, &ExpiryDistribution{Expired: 2, Within1Hour: 1, Within6Hours: 3, Beyond24Hours: 5}, dist)
+
+	// No provider filter
+	mock.ExpectQuery(`SELECT COALESCE\(SUM.+ WHERE status != \? AND \(token_expires_at IS NOT NULL OR oauth_expires_at IS NOT NULL\)$`).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(0, 0, 0, 0, 0))
+
+	dist, err = repo.GetExpiryDistribution(ctx, nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, &ExpiryDistribution{}, dist)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
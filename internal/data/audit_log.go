@@ -3,6 +3,7 @@ package data
 import (
 	"context"
 	"encoding/json"
+	"sync"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
@@ -28,7 +29,11 @@ func (AuditLog) TableName() string {
 type AuditLoggerImpl struct {
 	db      *gorm.DB
 	logChan chan *AuditLog
+	done    chan struct{} // closed when the background writer has drained logChan
 	logger  *log.Helper
+
+	mu     sync.RWMutex // guards closed; senders hold RLock so Close never races a send
+	closed bool
 }
 
 // NewAuditLogger creates a new audit logger with async channel
@@ -36,6 +41,7 @@ func NewAuditLogger(db *gorm.DB, logger log.Logger) *AuditLoggerImpl {
 	al := &AuditLoggerImpl{
 		db:      db,
 		logChan: make(chan *AuditLog, 1000), // Buffer size 1000 to prevent blocking
+		done:    make(chan struct{}),
 		logger:  log.NewHelper(logger),
 	}
 
@@ -47,6 +53,7 @@ func NewAuditLogger(db *gorm.DB, logger log.Logger) *AuditLoggerImpl {
 
 // start processes audit log events from channel
 func (a *AuditLoggerImpl) start() {
+	defer close(a.done)
 	for event := range a.logChan {
 		ctx := context.Background()
 		if err := a.db.WithContext(ctx).Create(event).Error; err != nil {
@@ -83,15 +90,7 @@ func (a *AuditLoggerImpl) LogHealthScoreChange(ctx context.Context, accountID in
 		OperatorID: 0, // System automatic
 	}
 
-	// Send to channel (non-blocking)
-	select {
-	case a.logChan <- event:
-		// Successfully queued
-	default:
-		a.logger.Warnw("audit log channel full, dropping event",
-			"account_id", accountID,
-			"action_type", event.ActionType)
-	}
+	a.enqueue(event)
 }
 
 // LogCircuitBroken logs circuit breaker triggered event
@@ -114,13 +113,7 @@ func (a *AuditLoggerImpl) LogCircuitBroken(ctx context.Context, accountID int64,
 		OperatorID: 0, // System automatic
 	}
 
-	select {
-	case a.logChan <- event:
-	default:
-		a.logger.Warnw("audit log channel full, dropping event",
-			"account_id", accountID,
-			"action_type", event.ActionType)
-	}
+	a.enqueue(event)
 }
 
 // LogCircuitRecovered logs circuit breaker recovered event
@@ -143,13 +136,7 @@ func (a *AuditLoggerImpl) LogCircuitRecovered(ctx context.Context, accountID int
 		OperatorID: 0, // System automatic
 	}
 
-	select {
-	case a.logChan <- event:
-	default:
-		a.logger.Warnw("audit log channel full, dropping event",
-			"account_id", accountID,
-			"action_type", event.ActionType)
-	}
+	a.enqueue(event)
 }
 
 // LogHealthScoreReset logs manual health score reset event
@@ -172,11 +159,46 @@ func (a *AuditLoggerImpl) LogHealthScoreReset(ctx context.Context, accountID int
 		OperatorID: operatorID, // Admin ID
 	}
 
+	a.enqueue(event)
+}
+
+// enqueue hands an event to the background writer without blocking.
+// Events are dropped when the buffer is full or the logger has been closed.
+func (a *AuditLoggerImpl) enqueue(event *AuditLog) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		a.logger.Warnw("audit logger closed, dropping event",
+			"account_id", event.AccountID,
+			"action_type", event.ActionType)
+		return
+	}
+
 	select {
 	case a.logChan <- event:
 	default:
 		a.logger.Warnw("audit log channel full, dropping event",
-			"account_id", accountID,
+			"account_id", event.AccountID,
 			"action_type", event.ActionType)
 	}
 }
+
+// Close stops accepting events and waits until every buffered event has been written.
+// Returns ctx.Err() if the buffer is not drained before ctx is done; Close is idempotent.
+func (a *AuditLoggerImpl) Close(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.logChan)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		a.logger.Warnw("audit log flush interrupted", "pending", len(a.logChan), "error", ctx.Err())
+		return ctx.Err()
+	}
+}
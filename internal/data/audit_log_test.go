@@ -0,0 +1,62 @@
+package data
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectAuditInsert(mock sqlmock.Sqlmock, delay time.Duration) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `account_audit_logs`")).
+		WillDelayFor(delay).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+}
+
+// TestAuditLogger_CloseFlushesBufferedEvents tests that events still buffered at shutdown are written.
+func TestAuditLogger_CloseFlushesBufferedEvents(t *testing.T) {
+	gormDB, mock, cleanup := setupGroupTestDB(t)
+	defer cleanup()
+
+	// Slow writes keep events in the buffer when Close is called
+	for i := 0; i < 3; i++ {
+		expectAuditInsert(mock, 20*time.Millisecond)
+	}
+
+	audit := NewAuditLogger(gormDB, log.DefaultLogger)
+	ctx := context.Background()
+	audit.LogCircuitBroken(ctx, 1, 20, time.Now())
+	audit.LogCircuitBroken(ctx, 2, 10, time.Now())
+	audit.LogHealthScoreChange(ctx, 3, 100, 80, "ServerError")
+
+	closeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, audit.Close(closeCtx))
+	assert.NoError(t, mock.ExpectationsWereMet(), "all buffered events must be written before Close returns")
+
+	// Events after Close are dropped instead of panicking on the closed channel
+	audit.LogCircuitRecovered(ctx, 1, time.Minute, 3)
+	require.NoError(t, audit.Close(closeCtx), "Close is idempotent")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAuditLogger_CloseRespectsDeadline tests that Close gives up when the flush outlives ctx.
+func TestAuditLogger_CloseRespectsDeadline(t *testing.T) {
+	gormDB, mock, cleanup := setupGroupTestDB(t)
+	defer cleanup()
+	expectAuditInsert(mock, time.Second)
+
+	audit := NewAuditLogger(gormDB, log.DefaultLogger)
+	audit.LogCircuitBroken(context.Background(), 1, 20, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, audit.Close(ctx), context.DeadlineExceeded)
+}
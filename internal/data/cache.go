@@ -66,12 +66,12 @@ type CacheClient interface {
 
 // redisCache is the Redis-based implementation of CacheClient.
 type redisCache struct {
-	client *redis.Client
+	client redis.UniversalClient
 }
 
 // NewCacheClient creates a new Redis-based cache client.
 // If the Redis client is nil, cache operations will gracefully fail.
-func NewCacheClient(rdb *redis.Client) CacheClient {
+func NewCacheClient(rdb redis.UniversalClient) CacheClient {
 	return &redisCache{
 		client: rdb,
 	}
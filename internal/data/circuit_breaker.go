@@ -16,12 +16,12 @@ import (
 // circuitBreakerRepo implements CircuitBreakerRepo interface (defined in biz layer)
 type CircuitBreakerRepo struct {
 	db     *gorm.DB
-	rdb    *redis.Client
+	rdb    redis.UniversalClient
 	logger *log.Helper
 }
 
 // NewCircuitBreakerRepo creates a new circuit breaker repository
-func NewCircuitBreakerRepo(db *gorm.DB, rdb *redis.Client, logger log.Logger) *CircuitBreakerRepo {
+func NewCircuitBreakerRepo(db *gorm.DB, rdb redis.UniversalClient, logger log.Logger) *CircuitBreakerRepo {
 	return &CircuitBreakerRepo{
 		db:     db,
 		rdb:    rdb,
@@ -32,6 +32,7 @@ func NewCircuitBreakerRepo(db *gorm.DB, rdb *redis.Client, logger log.Logger) *C
 // UpdateHealthScore updates account health score using optimistic locking with retry
 func (r *CircuitBreakerRepo) UpdateHealthScore(ctx context.Context, accountID int64, newScore int) error {
 	const maxRetries = 3
+	newScore = ClampHealthScore(newScore)
 
 	for i := 0; i < maxRetries; i++ {
 		// Read current version
@@ -237,13 +238,12 @@ func (r *CircuitBreakerRepo) ResetCircuitBreaker(ctx context.Context, accountID
 	}
 
 	// Clear Redis keys
-	circuitKey := fmt.Sprintf("circuit:%d", accountID)
-	successKey := fmt.Sprintf("circuit:%d:success_count", accountID)
-	halfOpenKey := fmt.Sprintf("circuit:%d:half_open", accountID)
-	backoffKey := fmt.Sprintf("circuit:%d:backoff", accountID)
-
-	keys := []string{circuitKey, successKey, halfOpenKey, backoffKey}
-	if err := r.rdb.Del(ctx, keys...).Err(); err != nil {
+	// One DEL per key: a multi-key DEL fails with CROSSSLOT in Redis Cluster mode
+	pipe := r.rdb.Pipeline()
+	for _, key := range circuitBreakerKeys(accountID) {
+		pipe.Del(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
 		r.logger.Warnw("failed to delete circuit breaker keys from Redis (degraded mode)",
 			"account_id", accountID,
 			"error", err)
@@ -293,6 +293,61 @@ func (r *CircuitBreakerRepo) GetBackoffTime(ctx context.Context, accountID int64
 	return &t, nil
 }
 
+// IncrementConsecutiveFailures increments the consecutive failure counter and returns the new count
+func (r *CircuitBreakerRepo) IncrementConsecutiveFailures(ctx context.Context, accountID int64) (int, error) {
+	consecutiveKey := fmt.Sprintf("circuit:%d:consecutive_failures", accountID)
+
+	pipe := r.rdb.TxPipeline()
+	incrCmd := pipe.Incr(ctx, consecutiveKey)
+	pipe.Expire(ctx, consecutiveKey, 10*time.Minute)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to increment consecutive failures: %w", err)
+	}
+
+	return int(incrCmd.Val()), nil
+}
+
+// ResetConsecutiveFailures clears the consecutive failure counter
+func (r *CircuitBreakerRepo) ResetConsecutiveFailures(ctx context.Context, accountID int64) error {
+	consecutiveKey := fmt.Sprintf("circuit:%d:consecutive_failures", accountID)
+
+	if err := r.rdb.Del(ctx, consecutiveKey).Err(); err != nil {
+		return fmt.Errorf("failed to reset consecutive failures: %w", err)
+	}
+
+	return nil
+}
+
+// RecordOutcome appends a request outcome to the rolling window (capped at windowSize)
+// and returns the number of failures and total outcomes currently in the window
+func (r *CircuitBreakerRepo) RecordOutcome(ctx context.Context, accountID int64, success bool, windowSize int) (int, int, error) {
+	windowKey := fmt.Sprintf("circuit:%d:window", accountID)
+
+	outcome := "0"
+	if success {
+		outcome = "1"
+	}
+
+	pipe := r.rdb.TxPipeline()
+	pipe.LPush(ctx, windowKey, outcome)
+	pipe.LTrim(ctx, windowKey, 0, int64(windowSize-1))
+	pipe.Expire(ctx, windowKey, 1*time.Hour)
+	rangeCmd := pipe.LRange(ctx, windowKey, 0, -1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, 0, fmt.Errorf("failed to record outcome: %w", err)
+	}
+
+	outcomes := rangeCmd.Val()
+	failures := 0
+	for _, o := range outcomes {
+		if o == "0" {
+			failures++
+		}
+	}
+
+	return failures, len(outcomes), nil
+}
+
 // GetAccount retrieves account info (implements both AccountRepo and CircuitBreakerRepo interface)
 func (r *CircuitBreakerRepo) GetAccount(ctx context.Context, accountID int64) (*Account, error) {
 	var account Account
@@ -317,3 +372,15 @@ func (r *CircuitBreakerRepo) clearAccountCache(ctx context.Context, accountID in
 	r.logger.Debugw("account cache cleared", "account_id", accountID)
 	return nil
 }
+
+// circuitBreakerKeys returns every Redis key the circuit breaker keeps for an account.
+func circuitBreakerKeys(accountID int64) []string {
+	return []string{
+		fmt.Sprintf("circuit:%d", accountID),
+		fmt.Sprintf("circuit:%d:success_count", accountID),
+		fmt.Sprintf("circuit:%d:half_open", accountID),
+		fmt.Sprintf("circuit:%d:backoff", accountID),
+		fmt.Sprintf("circuit:%d:consecutive_failures", accountID),
+		fmt.Sprintf("circuit:%d:window", accountID),
+	}
+}
@@ -0,0 +1,57 @@
+package data
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test RecordOutcome keeps only the last windowSize outcomes
+func TestCircuitBreakerRepo_RecordOutcome(t *testing.T) {
+	rdb, mr := setupTestRedis(t)
+	defer rdb.Close()
+
+	repo := NewCircuitBreakerRepo(nil, rdb, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+
+	// 3 failures followed by 4 successes with a window of 5
+	outcomes := []bool{false, false, false, true, true, true, true}
+	var failures, total int
+	var err error
+	for _, success := range outcomes {
+		failures, total, err = repo.RecordOutcome(ctx, 1, success, 5)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 5, total)
+	assert.Equal(t, 1, failures)
+
+	values, err := mr.List("circuit:1:window")
+	require.NoError(t, err)
+	assert.Len(t, values, 5)
+}
+
+// Test consecutive failure counter increments and resets
+func TestCircuitBreakerRepo_ConsecutiveFailures(t *testing.T) {
+	rdb, mr := setupTestRedis(t)
+	defer rdb.Close()
+
+	repo := NewCircuitBreakerRepo(nil, rdb, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+
+	count, err := repo.IncrementConsecutiveFailures(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = repo.IncrementConsecutiveFailures(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.True(t, mr.TTL("circuit:1:consecutive_failures") > 0)
+
+	require.NoError(t, repo.ResetConsecutiveFailures(ctx, 1))
+	assert.False(t, mr.Exists("circuit:1:consecutive_failures"))
+}
@@ -16,6 +16,7 @@ var ProviderSet = wire.NewSet(
 	NewRedisClient,
 	NewCacheClient,
 	NewMySQLClient,
+	NewMySQLReplicaClient,
 	// Note: All repository providers (NewAccountRepo, NewRateLimitRepo, NewCircuitBreakerRepo, etc.)
 	// are provided in biz.ProviderSet along with wire.Bind to follow Kratos v2 DDD architecture
 )
@@ -23,7 +24,7 @@ var ProviderSet = wire.NewSet(
 // Data contains all data layer dependencies.
 type Data struct {
 	// redisClient is the Redis client for caching
-	redisClient *redis.Client
+	redisClient redis.UniversalClient
 	// cache is the cache interface for repository use
 	cache CacheClient
 	// Note: MySQL DB is not stored here, it's injected directly to repositories
@@ -31,7 +32,7 @@ type Data struct {
 
 // NewData creates a new Data instance with all data layer dependencies.
 // Redis connection failure does not prevent application startup (graceful degradation).
-func NewData(_ *conf.Data, logger log.Logger, rdb *redis.Client, cache CacheClient) (*Data, func(), error) {
+func NewData(_ *conf.Data, logger log.Logger, rdb redis.UniversalClient, cache CacheClient) (*Data, func(), error) {
 	helper := log.NewHelper(logger)
 
 	// Check if Redis is available
@@ -59,6 +60,6 @@ func (d *Data) GetCache() CacheClient {
 }
 
 // GetRedisClient returns the Redis client for advanced operations.
-func (d *Data) GetRedisClient() *redis.Client {
+func (d *Data) GetRedisClient() redis.UniversalClient {
 	return d.redisClient
 }
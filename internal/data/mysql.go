@@ -22,6 +22,37 @@ func NewMySQLClient(c *conf.Data, l log.Logger) (*gorm.DB, func(), error) {
 		return nil, nil, fmt.Errorf("database configuration is required")
 	}
 
+	return openMySQL(c.Database.Source, "MySQL", helper)
+}
+
+// ReplicaDB is the optional MySQL read replica used by read-only repository queries.
+// A nil DB means no replica is configured and reads go to the primary.
+type ReplicaDB struct {
+	DB *gorm.DB
+}
+
+// NewMySQLReplicaClient creates the read replica client from data.database.replica_source.
+// An empty replica_source, or a replica that cannot be reached at startup, falls back to
+// the primary (graceful degradation).
+func NewMySQLReplicaClient(c *conf.Data, l log.Logger) (*ReplicaDB, func(), error) {
+	helper := log.NewHelper(l)
+
+	if c.Database == nil || c.Database.ReplicaSource == "" {
+		return &ReplicaDB{}, func() {}, nil
+	}
+
+	db, cleanup, err := openMySQL(c.Database.ReplicaSource, "MySQL replica", helper)
+	if err != nil {
+		helper.Warnf("read replica unavailable, reads will use the primary: %v", err)
+		return &ReplicaDB{}, func() {}, nil
+	}
+
+	return &ReplicaDB{DB: db}, cleanup, nil
+}
+
+// openMySQL opens a GORM MySQL connection with the shared pool settings.
+// name identifies the connection in logs (e.g., "MySQL", "MySQL replica").
+func openMySQL(dsn string, name string, helper *log.Helper) (*gorm.DB, func(), error) {
 	// Parse DSN and create GORM logger
 	gormLogger := logger.New(
 		&gormLogAdapter{helper: helper},
@@ -34,14 +65,14 @@ func NewMySQLClient(c *conf.Data, l log.Logger) (*gorm.DB, func(), error) {
 	)
 
 	// Open MySQL connection
-	db, err := gorm.Open(mysql.Open(c.Database.Source), &gorm.Config{
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
 		Logger:                 gormLogger,
 		SkipDefaultTransaction: true, // Disable default transaction for better performance
 		PrepareStmt:            true, // Prepare statement cache
 	})
 	if err != nil {
-		helper.Errorf("failed to connect to MySQL: %v", err)
-		return nil, nil, fmt.Errorf("failed to connect to MySQL: %w", err)
+		helper.Errorf("failed to connect to %s: %v", name, err)
+		return nil, nil, fmt.Errorf("failed to connect to %s: %w", name, err)
 	}
 
 	// Get underlying sql.DB to configure connection pool
@@ -59,16 +90,16 @@ func NewMySQLClient(c *conf.Data, l log.Logger) (*gorm.DB, func(), error) {
 
 	// Test connection
 	if err := sqlDB.Ping(); err != nil {
-		helper.Errorf("failed to ping MySQL: %v", err)
-		return nil, nil, fmt.Errorf("failed to ping MySQL: %w", err)
+		helper.Errorf("failed to ping %s: %v", name, err)
+		return nil, nil, fmt.Errorf("failed to ping %s: %w", name, err)
 	}
 
-	helper.Info("MySQL connection established successfully")
+	helper.Infof("%s connection established successfully", name)
 
 	cleanup := func() {
-		helper.Info("closing MySQL connection")
+		helper.Infof("closing %s connection", name)
 		if err := sqlDB.Close(); err != nil {
-			helper.Errorf("failed to close MySQL: %v", err)
+			helper.Errorf("failed to close %s: %v", name, err)
 		}
 	}
 
@@ -3,51 +3,97 @@ package data
 import (
 	"context"
 	"fmt"
+	"math"
 	"strconv"
+	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/redis/go-redis/v9"
 )
 
+// DefaultRateLimitCounterTTL is the lifetime of an RPM/TPM counter key (the fixed rate-limit window).
+const DefaultRateLimitCounterTTL = 60 * time.Second
+
 // RateLimitRepo implements biz.RateLimitRepo interface.
 // Following Kratos v2 DDD architecture, interface is defined in biz layer.
 type RateLimitRepo struct {
-	rdb    *redis.Client
-	logger *log.Helper
+	rdb        redis.UniversalClient
+	logger     *log.Helper
+	counterTTL time.Duration
+	slidingRPM bool
 }
 
 // NewRateLimitRepo creates a new rate limit repository.
-func NewRateLimitRepo(rdb *redis.Client, logger log.Logger) *RateLimitRepo {
+func NewRateLimitRepo(rdb redis.UniversalClient, logger log.Logger) *RateLimitRepo {
 	return &RateLimitRepo{
-		rdb:    rdb,
-		logger: log.NewHelper(logger),
+		rdb:        rdb,
+		logger:     log.NewHelper(logger),
+		counterTTL: DefaultRateLimitCounterTTL,
+	}
+}
+
+// SetCounterTTL sets the TTL enforced on RPM/TPM counter keys; d <= 0 restores DefaultRateLimitCounterTTL.
+func (r *RateLimitRepo) SetCounterTTL(d time.Duration) {
+	if d <= 0 {
+		d = DefaultRateLimitCounterTTL
+	}
+	r.counterTTL = d
+}
+
+// SetSlidingRPM makes the usage readers count RPM from the sliding-window log instead of the
+// fixed-window counter. Must match the limiter's rate_limit.rpm_window, which decides where
+// requests are recorded.
+func (r *RateLimitRepo) SetSlidingRPM(enabled bool) {
+	r.slidingRPM = enabled
+}
+
+// incrementCounter adds delta to a counter key and makes sure the key carries a TTL.
+// The TTL is checked on every increment, not only the first one, so a key that lost its
+// expiry (a manual SET, or a crash between INCR and EXPIRE) cannot rate-limit forever.
+// Uses TTL + EXPIRE rather than EXPIRE NX to stay compatible with Redis 6.
+func (r *RateLimitRepo) incrementCounter(ctx context.Context, key string, delta int64) (int64, error) {
+	pipe := r.rdb.Pipeline()
+	incr := pipe.IncrBy(ctx, key, delta)
+	ttl := pipe.TTL(ctx, key)
+	_, _ = pipe.Exec(ctx)
+
+	count, err := incr.Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// TTL reports a negative duration when the key has no expiry
+	if remaining, err := ttl.Result(); err != nil || remaining < 0 {
+		if err := r.rdb.Expire(ctx, key, r.counterTTL).Err(); err != nil {
+			r.logger.Warnf("Failed to set expiration on rate limit key %s: %v", key, err)
+			// Don't return error, counter is still incremented
+		}
 	}
+	return count, nil
 }
 
 // IncrementRPM increments the RPM (Requests Per Minute) counter for an account.
-// Uses Redis INCR with automatic expiration (60 seconds) on first increment.
+// Uses Redis INCR and ensures the key expires after the counter TTL (60 seconds by default).
 // Returns the new count and any error.
 func (r *RateLimitRepo) IncrementRPM(ctx context.Context, accountID int64) (int32, error) {
+	return r.IncrementModelRPM(ctx, accountID, "")
+}
+
+// IncrementModelRPM increments the account's RPM counter for one model, isolated from the
+// account-wide counter and other models. An empty model uses the account-wide counter.
+func (r *RateLimitRepo) IncrementModelRPM(ctx context.Context, accountID int64, model string) (int32, error) {
 	if r.rdb == nil {
 		return 0, fmt.Errorf("redis client is nil")
 	}
 
-	key := getRateLimitKey(accountID, "rpm")
+	key := getModelRateLimitKey(accountID, model, "rpm")
 
 	// Increment counter
-	count, err := r.rdb.Incr(ctx, key).Result()
+	count, err := r.incrementCounter(ctx, key, 1)
 	if err != nil {
 		return 0, fmt.Errorf("failed to increment RPM: %w", err)
 	}
 
-	// Set expiration on first increment (atomic operation)
-	if count == 1 {
-		if err := r.rdb.Expire(ctx, key, 60).Err(); err != nil {
-			r.logger.Warnf("Failed to set RPM expiration for account %d: %v", accountID, err)
-			// Don't return error, counter is still incremented
-		}
-	}
-
 	// Prevent overflow when converting int64 to int32
 	if count > 2147483647 {
 		count = 2147483647
@@ -56,6 +102,31 @@ func (r *RateLimitRepo) IncrementRPM(ctx context.Context, accountID int64) (int3
 	return int32(count), nil // #nosec G115 -- overflow is handled above
 }
 
+// DecrementModelRPM undoes one IncrementModelRPM (empty model = account-wide counter), e.g. for a
+// request admitted by this counter but rejected by a later check. The counter never goes below 0.
+func (r *RateLimitRepo) DecrementModelRPM(ctx context.Context, accountID int64, model string) error {
+	if r.rdb == nil {
+		return fmt.Errorf("redis client is nil")
+	}
+
+	return r.decrementCounter(ctx, getModelRateLimitKey(accountID, model, "rpm"))
+}
+
+// decrementCounter decrements a counter key by one. If the window expired in between, DECR
+// recreates the key at -1 without a TTL; it is deleted so the next window starts from 0.
+func (r *RateLimitRepo) decrementCounter(ctx context.Context, key string) error {
+	count, err := r.rdb.Decr(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to decrement %s: %w", key, err)
+	}
+	if count < 0 {
+		if err := r.rdb.Del(ctx, key).Err(); err != nil {
+			return fmt.Errorf("failed to reset %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
 // GetRPMCount retrieves the current RPM count for an account.
 // Returns 0 if key doesn't exist.
 func (r *RateLimitRepo) GetRPMCount(ctx context.Context, accountID int64) (int32, error) {
@@ -63,69 +134,66 @@ func (r *RateLimitRepo) GetRPMCount(ctx context.Context, accountID int64) (int32
 		return 0, fmt.Errorf("redis client is nil")
 	}
 
-	key := getRateLimitKey(accountID, "rpm")
-
-	count, err := r.rdb.Get(ctx, key).Result()
-	if err == redis.Nil {
-		// Key doesn't exist, return 0
-		return 0, nil
-	}
-	if err != nil {
+	pipe := r.rdb.Pipeline()
+	rpmCmd := r.queueRPMCount(ctx, pipe, accountID)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
 		return 0, fmt.Errorf("failed to get RPM count: %w", err)
 	}
 
-	// Parse count
-	countInt, err := strconv.ParseInt(count, 10, 32)
+	count, err := rpmCmd.count()
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse RPM count: %w", err)
 	}
 
-	return int32(countInt), nil
+	return count, nil
 }
 
 // IncrementTPM increments the TPM (Tokens Per Minute) counter for an account.
-// Uses Redis INCRBY with automatic expiration (60 seconds) on first increment.
+// Uses Redis INCRBY and ensures the key expires after the counter TTL (60 seconds by default).
+// The stored counter saturates at the int32 range so it can never wrap when read back.
 // Returns the new count and any error.
 func (r *RateLimitRepo) IncrementTPM(ctx context.Context, accountID int64, tokens int32) (int32, error) {
+	return r.IncrementModelTPM(ctx, accountID, "", tokens)
+}
+
+// IncrementModelTPM increments the account's TPM counter for one model (empty = account-wide counter).
+func (r *RateLimitRepo) IncrementModelTPM(ctx context.Context, accountID int64, model string, tokens int32) (int32, error) {
 	if r.rdb == nil {
 		return 0, fmt.Errorf("redis client is nil")
 	}
 
-	key := getRateLimitKey(accountID, "tpm")
-
-	// Get current count first to detect first increment
-	_, err := r.rdb.Get(ctx, key).Result()
-	isFirstIncrement := (err == redis.Nil)
+	key := getModelRateLimitKey(accountID, model, "tpm")
 
 	// Increment counter by tokens
-	count, err := r.rdb.IncrBy(ctx, key, int64(tokens)).Result()
+	count, err := r.incrementCounter(ctx, key, int64(tokens))
 	if err != nil {
 		return 0, fmt.Errorf("failed to increment TPM: %w", err)
 	}
 
-	// Set expiration on first increment
-	if isFirstIncrement {
-		if err := r.rdb.Expire(ctx, key, 60).Err(); err != nil {
-			r.logger.Warnf("Failed to set TPM expiration for account %d: %v", accountID, err)
+	// Saturate the stored counter so repeated large increments cannot grow it past int32
+	saturated := saturateInt32(count)
+	if int64(saturated) != count {
+		if err := r.rdb.SetArgs(ctx, key, saturated, redis.SetArgs{KeepTTL: true}).Err(); err != nil {
+			r.logger.Warnf("Failed to saturate TPM counter for account %d: %v", accountID, err)
 		}
 	}
 
-	// Prevent overflow when converting int64 to int32
-	if count > 2147483647 {
-		count = 2147483647
-	}
-
-	return int32(count), nil // #nosec G115 -- overflow is handled above
+	return saturated, nil
 }
 
 // GetTPMCount retrieves the current TPM count for an account.
 // Returns 0 if key doesn't exist.
 func (r *RateLimitRepo) GetTPMCount(ctx context.Context, accountID int64) (int32, error) {
+	return r.GetModelTPMCount(ctx, accountID, "")
+}
+
+// GetModelTPMCount retrieves the account's TPM count for one model (empty = account-wide counter).
+func (r *RateLimitRepo) GetModelTPMCount(ctx context.Context, accountID int64, model string) (int32, error) {
 	if r.rdb == nil {
 		return 0, fmt.Errorf("redis client is nil")
 	}
 
-	key := getRateLimitKey(accountID, "tpm")
+	key := getModelRateLimitKey(accountID, model, "tpm")
 
 	count, err := r.rdb.Get(ctx, key).Result()
 	if err == redis.Nil {
@@ -137,12 +205,137 @@ func (r *RateLimitRepo) GetTPMCount(ctx context.Context, accountID int64) (int32
 	}
 
 	// Parse count
-	countInt, err := strconv.ParseInt(count, 10, 32)
+	countInt, err := strconv.ParseInt(count, 10, 64)
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse TPM count: %w", err)
 	}
 
-	return int32(countInt), nil
+	return saturateInt32(countInt), nil
+}
+
+// IncrementGroupRPM increments the group-wide RPM counter shared by all accounts of a group.
+// Uses Redis INCR and ensures the key expires after the counter TTL (60 seconds by default).
+func (r *RateLimitRepo) IncrementGroupRPM(ctx context.Context, groupID int64) (int32, error) {
+	if r.rdb == nil {
+		return 0, fmt.Errorf("redis client is nil")
+	}
+
+	key := getGroupRateLimitKey(groupID, "rpm")
+
+	count, err := r.incrementCounter(ctx, key, 1)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment group RPM: %w", err)
+	}
+
+	return saturateInt32(count), nil
+}
+
+// DecrementGroupRPM undoes one IncrementGroupRPM of a request rejected by a later check.
+// The counter never goes below 0.
+func (r *RateLimitRepo) DecrementGroupRPM(ctx context.Context, groupID int64) error {
+	if r.rdb == nil {
+		return fmt.Errorf("redis client is nil")
+	}
+
+	return r.decrementCounter(ctx, getGroupRateLimitKey(groupID, "rpm"))
+}
+
+// IncrementGroupTPM increments the group-wide TPM counter by tokens.
+// Uses Redis INCRBY and ensures the key expires after the counter TTL (60 seconds by default).
+func (r *RateLimitRepo) IncrementGroupTPM(ctx context.Context, groupID int64, tokens int32) (int32, error) {
+	if r.rdb == nil {
+		return 0, fmt.Errorf("redis client is nil")
+	}
+
+	key := getGroupRateLimitKey(groupID, "tpm")
+
+	count, err := r.incrementCounter(ctx, key, int64(tokens))
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment group TPM: %w", err)
+	}
+
+	return saturateInt32(count), nil
+}
+
+// GetGroupTPMCount retrieves the current group-wide TPM count. Returns 0 if key doesn't exist.
+func (r *RateLimitRepo) GetGroupTPMCount(ctx context.Context, groupID int64) (int32, error) {
+	if r.rdb == nil {
+		return 0, fmt.Errorf("redis client is nil")
+	}
+
+	count, err := parseCounter(r.rdb.Get(ctx, getGroupRateLimitKey(groupID, "tpm")))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get group TPM count: %w", err)
+	}
+	return count, nil
+}
+
+// GetUsageCounts retrieves the current RPM and TPM counts for an account
+// in a single Redis pipeline round trip. Missing keys are reported as 0.
+func (r *RateLimitRepo) GetUsageCounts(ctx context.Context, accountID int64) (int32, int32, error) {
+	if r.rdb == nil {
+		return 0, 0, fmt.Errorf("redis client is nil")
+	}
+
+	pipe := r.rdb.Pipeline()
+	rpmCmd := r.queueRPMCount(ctx, pipe, accountID)
+	tpmCmd := pipe.Get(ctx, getRateLimitKey(accountID, "tpm"))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return 0, 0, fmt.Errorf("failed to get usage counts: %w", err)
+	}
+
+	rpm, err := rpmCmd.count()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse RPM count: %w", err)
+	}
+	tpm, err := parseCounter(tpmCmd)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse TPM count: %w", err)
+	}
+
+	return rpm, tpm, nil
+}
+
+// SumUsageCounts returns the total RPM count across rpmAccountIDs and the total TPM count
+// across tpmAccountIDs. All counters are read in a single Redis pipeline; missing keys are reported as 0.
+func (r *RateLimitRepo) SumUsageCounts(ctx context.Context, rpmAccountIDs, tpmAccountIDs []int64) (int64, int64, error) {
+	if r.rdb == nil {
+		return 0, 0, fmt.Errorf("redis client is nil")
+	}
+	if len(rpmAccountIDs) == 0 && len(tpmAccountIDs) == 0 {
+		return 0, 0, nil
+	}
+
+	pipe := r.rdb.Pipeline()
+	rpmCmds := make([]rpmCountCmd, 0, len(rpmAccountIDs))
+	for _, accountID := range rpmAccountIDs {
+		rpmCmds = append(rpmCmds, r.queueRPMCount(ctx, pipe, accountID))
+	}
+	tpmCmds := make([]*redis.StringCmd, 0, len(tpmAccountIDs))
+	for _, accountID := range tpmAccountIDs {
+		tpmCmds = append(tpmCmds, pipe.Get(ctx, getRateLimitKey(accountID, "tpm")))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return 0, 0, fmt.Errorf("failed to get usage counts: %w", err)
+	}
+
+	var totalRPM, totalTPM int64
+	for _, cmd := range rpmCmds {
+		rpm, err := cmd.count()
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse RPM count: %w", err)
+		}
+		totalRPM += int64(rpm)
+	}
+	for _, cmd := range tpmCmds {
+		tpm, err := parseCounter(cmd)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse TPM count: %w", err)
+		}
+		totalTPM += int64(tpm)
+	}
+
+	return totalRPM, totalTPM, nil
 }
 
 // AddConcurrencyRequest adds a request to the concurrency tracking sorted set.
@@ -182,6 +375,57 @@ func (r *RateLimitRepo) RemoveConcurrencyRequest(ctx context.Context, accountID
 	return nil
 }
 
+// AccountUsageStats holds the current usage counters of a single account.
+type AccountUsageStats struct {
+	RPM         int32
+	TPM         int32
+	Concurrency int32
+}
+
+// BatchGetUsageStats returns current RPM, TPM and concurrency for many accounts.
+// All counters are read in a single pipeline; missing counters are reported as zero.
+func (r *RateLimitRepo) BatchGetUsageStats(ctx context.Context, accountIDs []int64) (map[int64]*AccountUsageStats, error) {
+	if r.rdb == nil {
+		return nil, fmt.Errorf("redis client is nil")
+	}
+
+	stats := make(map[int64]*AccountUsageStats, len(accountIDs))
+	if len(accountIDs) == 0 {
+		return stats, nil
+	}
+
+	pipe := r.rdb.Pipeline()
+	rpmCmds := make([]rpmCountCmd, 0, len(accountIDs))
+	tpmCmds := make([]*redis.StringCmd, 0, len(accountIDs))
+	concurrencyCmds := make([]*redis.IntCmd, 0, len(accountIDs))
+	for _, accountID := range accountIDs {
+		rpmCmds = append(rpmCmds, r.queueRPMCount(ctx, pipe, accountID))
+		tpmCmds = append(tpmCmds, pipe.Get(ctx, getRateLimitKey(accountID, "tpm")))
+		concurrencyCmds = append(concurrencyCmds, pipe.ZCard(ctx, getConcurrencyKey(accountID)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get usage stats: %w", err)
+	}
+
+	for i, accountID := range accountIDs {
+		rpm, err := rpmCmds[i].count()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RPM count for account %d: %w", accountID, err)
+		}
+		tpm, err := parseCounter(tpmCmds[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse TPM count for account %d: %w", accountID, err)
+		}
+		stats[accountID] = &AccountUsageStats{
+			RPM:         rpm,
+			TPM:         tpm,
+			Concurrency: saturateInt32(concurrencyCmds[i].Val()),
+		}
+	}
+
+	return stats, nil
+}
+
 // GetConcurrencyCount retrieves the current concurrency count for an account.
 // Uses Redis ZCARD to count members in the sorted set.
 func (r *RateLimitRepo) GetConcurrencyCount(ctx context.Context, accountID int64) (int32, error) {
@@ -229,16 +473,285 @@ func (r *RateLimitRepo) CleanupExpiredConcurrency(ctx context.Context, accountID
 	return nil
 }
 
+// AddProviderConcurrencyRequest adds a request to the provider-wide concurrency sorted set.
+// Uses Redis ZADD with the timestamp as score.
+func (r *RateLimitRepo) AddProviderConcurrencyRequest(ctx context.Context, provider AccountProvider, requestID string, timestamp int64) error {
+	if r.rdb == nil {
+		return fmt.Errorf("redis client is nil")
+	}
+
+	key := getProviderConcurrencyKey(provider)
+
+	if err := r.rdb.ZAdd(ctx, key, redis.Z{
+		Score:  float64(timestamp),
+		Member: requestID,
+	}).Err(); err != nil {
+		return fmt.Errorf("failed to add provider concurrency request: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveProviderConcurrencyRequest removes a request from the provider-wide concurrency sorted set.
+// Uses Redis ZREM.
+func (r *RateLimitRepo) RemoveProviderConcurrencyRequest(ctx context.Context, provider AccountProvider, requestID string) error {
+	if r.rdb == nil {
+		return fmt.Errorf("redis client is nil")
+	}
+
+	key := getProviderConcurrencyKey(provider)
+
+	if err := r.rdb.ZRem(ctx, key, requestID).Err(); err != nil {
+		return fmt.Errorf("failed to remove provider concurrency request: %w", err)
+	}
+
+	return nil
+}
+
+// GetProviderConcurrencyCount retrieves the current in-flight request count across all accounts of a provider.
+// Uses Redis ZCARD to count members in the sorted set.
+func (r *RateLimitRepo) GetProviderConcurrencyCount(ctx context.Context, provider AccountProvider) (int32, error) {
+	if r.rdb == nil {
+		return 0, fmt.Errorf("redis client is nil")
+	}
+
+	key := getProviderConcurrencyKey(provider)
+
+	count, err := r.rdb.ZCard(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get provider concurrency count: %w", err)
+	}
+
+	// Prevent overflow when converting int64 to int32
+	if count > 2147483647 {
+		count = 2147483647
+	}
+
+	return int32(count), nil // #nosec G115 -- overflow is handled above
+}
+
+// CleanupExpiredProviderConcurrency removes expired requests from the provider-wide concurrency sorted set.
+// Uses Redis ZREMRANGEBYSCORE to remove requests older than expiredBefore timestamp.
+func (r *RateLimitRepo) CleanupExpiredProviderConcurrency(ctx context.Context, provider AccountProvider, expiredBefore int64) error {
+	if r.rdb == nil {
+		return fmt.Errorf("redis client is nil")
+	}
+
+	key := getProviderConcurrencyKey(provider)
+
+	removedCount, err := r.rdb.ZRemRangeByScore(ctx, key, "0", strconv.FormatInt(expiredBefore, 10)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to cleanup expired provider concurrency: %w", err)
+	}
+
+	if removedCount > 0 {
+		r.logger.Debugw("Cleaned up expired provider concurrency requests",
+			"provider", provider,
+			"removed_count", removedCount)
+	}
+
+	return nil
+}
+
+// AddGroupConcurrencyRequest adds a request to the group-wide concurrency sorted set.
+// Uses Redis ZADD with the timestamp as score.
+func (r *RateLimitRepo) AddGroupConcurrencyRequest(ctx context.Context, groupID int64, requestID string, timestamp int64) error {
+	if r.rdb == nil {
+		return fmt.Errorf("redis client is nil")
+	}
+
+	key := getGroupConcurrencyKey(groupID)
+
+	if err := r.rdb.ZAdd(ctx, key, redis.Z{
+		Score:  float64(timestamp),
+		Member: requestID,
+	}).Err(); err != nil {
+		return fmt.Errorf("failed to add group concurrency request: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveGroupConcurrencyRequest removes a request from the group-wide concurrency sorted set.
+// Uses Redis ZREM.
+func (r *RateLimitRepo) RemoveGroupConcurrencyRequest(ctx context.Context, groupID int64, requestID string) error {
+	if r.rdb == nil {
+		return fmt.Errorf("redis client is nil")
+	}
+
+	if err := r.rdb.ZRem(ctx, getGroupConcurrencyKey(groupID), requestID).Err(); err != nil {
+		return fmt.Errorf("failed to remove group concurrency request: %w", err)
+	}
+
+	return nil
+}
+
+// GetGroupConcurrencyCount retrieves the current in-flight request count across all accounts of a group.
+// Uses Redis ZCARD to count members in the sorted set.
+func (r *RateLimitRepo) GetGroupConcurrencyCount(ctx context.Context, groupID int64) (int32, error) {
+	if r.rdb == nil {
+		return 0, fmt.Errorf("redis client is nil")
+	}
+
+	count, err := r.rdb.ZCard(ctx, getGroupConcurrencyKey(groupID)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get group concurrency count: %w", err)
+	}
+
+	return saturateInt32(count), nil
+}
+
+// CleanupExpiredGroupConcurrency removes expired requests from the group-wide concurrency sorted set.
+// Uses Redis ZREMRANGEBYSCORE to remove requests older than expiredBefore timestamp.
+func (r *RateLimitRepo) CleanupExpiredGroupConcurrency(ctx context.Context, groupID int64, expiredBefore int64) error {
+	if r.rdb == nil {
+		return fmt.Errorf("redis client is nil")
+	}
+
+	removedCount, err := r.rdb.ZRemRangeByScore(ctx, getGroupConcurrencyKey(groupID), "0", strconv.FormatInt(expiredBefore, 10)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to cleanup expired group concurrency: %w", err)
+	}
+
+	if removedCount > 0 {
+		r.logger.Debugw("Cleaned up expired group concurrency requests",
+			"group_id", groupID,
+			"removed_count", removedCount)
+	}
+
+	return nil
+}
+
+// HeartbeatConcurrencyRequest moves the timestamp of a held slot to timestamp in the account set
+// and, when given, the provider-wide (provider != "") and group-wide (groupID > 0) sets, in one pipeline.
+// Uses ZADD XX so a slot that cleanup already reclaimed is not re-added.
+func (r *RateLimitRepo) HeartbeatConcurrencyRequest(ctx context.Context, accountID int64, provider AccountProvider, groupID int64, requestID string, timestamp int64) error {
+	if r.rdb == nil {
+		return fmt.Errorf("redis client is nil")
+	}
+
+	slot := redis.Z{Score: float64(timestamp), Member: requestID}
+	pipe := r.rdb.Pipeline()
+	pipe.ZAddXX(ctx, getConcurrencyKey(accountID), slot)
+	if provider != "" {
+		pipe.ZAddXX(ctx, getProviderConcurrencyKey(provider), slot)
+	}
+	if groupID > 0 {
+		pipe.ZAddXX(ctx, getGroupConcurrencyKey(groupID), slot)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to heartbeat concurrency request: %w", err)
+	}
+
+	return nil
+}
+
+// rpmCountCmd is a queued read of an account's RPM usage: either the fixed-window
+// counter (GET) or the number of sliding-log entries within the window (ZCOUNT).
+type rpmCountCmd struct {
+	fixed   *redis.StringCmd
+	sliding *redis.IntCmd
+}
+
+// queueRPMCount queues the RPM read matching the configured window mode on pipe.
+func (r *RateLimitRepo) queueRPMCount(ctx context.Context, pipe redis.Pipeliner, accountID int64) rpmCountCmd {
+	if !r.slidingRPM {
+		return rpmCountCmd{fixed: pipe.Get(ctx, getRateLimitKey(accountID, "rpm"))}
+	}
+	// 与 AddSlidingRPMRequest 一致：只统计窗口起点之后的请求
+	windowStart := time.Now().UnixMilli() - SlidingRPMWindow.Milliseconds()
+	return rpmCountCmd{sliding: pipe.ZCount(ctx, getSlidingRPMKey(accountID, ""), "("+strconv.FormatInt(windowStart, 10), "+inf")}
+}
+
+// count returns the queued RPM value once the pipeline has executed.
+func (c rpmCountCmd) count() (int32, error) {
+	if c.sliding != nil {
+		n, err := c.sliding.Result()
+		if err != nil {
+			return 0, err
+		}
+		return saturateInt32(n), nil
+	}
+	return parseCounter(c.fixed)
+}
+
+// parseCounter parses a pipelined GET result into an int32 counter.
+// A missing key (redis.Nil) is treated as 0.
+func parseCounter(cmd *redis.StringCmd) (int32, error) {
+	val, err := cmd.Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return saturateInt32(count), nil
+}
+
+// saturateInt32 clamps a Redis counter value to the int32 range.
+func saturateInt32(v int64) int32 {
+	if v > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	if v < math.MinInt32 {
+		return math.MinInt32
+	}
+	return int32(v) // #nosec G115 -- range is checked above
+}
+
 // getRateLimitKey generates a Redis key for rate limiting.
+// The account ID is a hash tag so all keys of one account map to the same Redis Cluster
+// slot and can be used together in pipelines, transactions and scripts.
 // Format: rate:{account_id}:{type}
-// Example: rate:123:rpm or rate:123:tpm
+// Example: rate:{123}:rpm or rate:{123}:tpm
 func getRateLimitKey(accountID int64, limitType string) string {
-	return fmt.Sprintf("rate:%d:%s", accountID, limitType)
+	return fmt.Sprintf("rate:{%d}:%s", accountID, limitType)
+}
+
+// getModelRateLimitKey generates a Redis key for a per-model rate limit counter of an account.
+// The model segment isolates the counter from the account-wide one and other models; an empty
+// model returns the account-wide key. Shares the account hash tag with getRateLimitKey.
+// Format: rate:{account_id}:model:{model}:{type}
+// Example: rate:{123}:model:claude-opus-4:rpm
+func getModelRateLimitKey(accountID int64, model, limitType string) string {
+	if model == "" {
+		return getRateLimitKey(accountID, limitType)
+	}
+	return getRateLimitKey(accountID, "model:"+model+":"+limitType)
+}
+
+// getGroupRateLimitKey generates a Redis key for group-wide rate limiting.
+// The group ID is a hash tag so the RPM and TPM keys of one group share a Redis Cluster slot.
+// Format: rate:group:{group_id}:{type}
+// Example: rate:group:{7}:rpm
+func getGroupRateLimitKey(groupID int64, limitType string) string {
+	return fmt.Sprintf("rate:group:{%d}:%s", groupID, limitType)
 }
 
 // getConcurrencyKey generates a Redis key for concurrency tracking.
+// Shares the account hash tag with getRateLimitKey.
 // Format: concurrency:{account_id}
-// Example: concurrency:123
+// Example: concurrency:{123}
 func getConcurrencyKey(accountID int64) string {
-	return fmt.Sprintf("concurrency:%d", accountID)
+	return fmt.Sprintf("concurrency:{%d}", accountID)
+}
+
+// getProviderConcurrencyKey generates a Redis key for provider-wide concurrency tracking.
+// Format: concurrency:provider:{provider}
+// Example: concurrency:provider:claude-console
+func getProviderConcurrencyKey(provider AccountProvider) string {
+	return fmt.Sprintf("concurrency:provider:%s", provider)
+}
+
+// getGroupConcurrencyKey generates a Redis key for group-wide concurrency tracking.
+// Format: concurrency:group:{group_id}
+// Example: concurrency:group:{7}
+func getGroupConcurrencyKey(groupID int64) string {
+	return fmt.Sprintf("concurrency:group:{%d}", groupID)
 }
@@ -0,0 +1,67 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IncrementDailyTokens adds tokens (negative to release a reservation) to the account's token total
+// for the UTC day containing now. The key expires at the following UTC midnight, so each day starts
+// from zero without a reset job. Returns the day's new total.
+func (r *RateLimitRepo) IncrementDailyTokens(ctx context.Context, accountID int64, now time.Time, tokens int64) (int64, error) {
+	if r.rdb == nil {
+		return 0, fmt.Errorf("redis client is nil")
+	}
+
+	key := getDailyTokenKey(accountID, now)
+
+	pipe := r.rdb.TxPipeline()
+	incr := pipe.IncrBy(ctx, key, tokens)
+	pipe.ExpireAt(ctx, key, NextUTCMidnight(now))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to increment daily tokens: %w", err)
+	}
+
+	return incr.Val(), nil
+}
+
+// GetDailyTokenCount retrieves the account's token total for the UTC day containing now.
+// Returns 0 if key doesn't exist.
+func (r *RateLimitRepo) GetDailyTokenCount(ctx context.Context, accountID int64, now time.Time) (int64, error) {
+	if r.rdb == nil {
+		return 0, fmt.Errorf("redis client is nil")
+	}
+
+	count, err := r.rdb.Get(ctx, getDailyTokenKey(accountID, now)).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get daily token count: %w", err)
+	}
+
+	total, err := strconv.ParseInt(count, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse daily token count: %w", err)
+	}
+
+	return total, nil
+}
+
+// NextUTCMidnight returns the start of the UTC day after now, when daily token totals reset.
+func NextUTCMidnight(now time.Time) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+// getDailyTokenKey generates a Redis key for an account's daily token total.
+// The date is the UTC day; the account ID is a hash tag like the other rate limit keys.
+// Format: daily:{account_id}:{YYYYMMDD}
+// Example: daily:{123}:20260115
+func getDailyTokenKey(accountID int64, now time.Time) string {
+	return fmt.Sprintf("daily:{%d}:%s", accountID, now.UTC().Format("20060102"))
+}
@@ -0,0 +1,48 @@
+package data
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailyTokenCounter(t *testing.T) {
+	rdb, _ := setupTestRedis(t)
+	defer rdb.Close()
+
+	repo := NewRateLimitRepo(rdb, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+	now := time.Now()
+
+	total, err := repo.IncrementDailyTokens(ctx, 123, now, 700)
+	require.NoError(t, err)
+	assert.Equal(t, int64(700), total)
+	total, err = repo.IncrementDailyTokens(ctx, 123, now, -200)
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), total)
+
+	count, err := repo.GetDailyTokenCount(ctx, 123, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), count)
+
+	// The key expires at the next UTC midnight
+	ttl := rdb.TTL(ctx, getDailyTokenKey(123, now)).Val()
+	assert.Greater(t, ttl, time.Duration(0))
+	assert.LessOrEqual(t, ttl, time.Until(NextUTCMidnight(now))+time.Second)
+
+	// The next day starts from zero
+	count, err = repo.GetDailyTokenCount(ctx, 123, now.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestDailyTokenKey(t *testing.T) {
+	at := time.Date(2026, 1, 15, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60))
+	assert.Equal(t, "daily:{123}:20260116", getDailyTokenKey(123, at))
+	assert.Equal(t, time.Date(2026, 1, 17, 0, 0, 0, 0, time.UTC), NextUTCMidnight(at))
+}
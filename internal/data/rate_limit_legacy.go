@@ -0,0 +1,62 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// legacyConcurrencyKeyPrefix is the per-account concurrency key prefix used before the account
+// ID became a hash tag (concurrency:123 instead of concurrency:{123}).
+const legacyConcurrencyKeyPrefix = "concurrency:"
+
+// MigrateLegacyConcurrencyKeys moves the slots of legacy per-account concurrency sets (concurrency:123)
+// into the current hash-tagged sets (concurrency:{123}) and deletes the legacy keys. Returns the number
+// of legacy keys migrated.
+//
+// The legacy sets have no TTL and are no longer read, so without this step they would stay in Redis
+// forever and the slots held by not-yet-upgraded instances would not count against the limit.
+// Slots are added with ZADD NX, so slots already in the new set keep their timestamp; a migrated
+// slot released by an old instance is reclaimed by the concurrency cleanup job once it expires.
+// Safe to run repeatedly: it runs at every startup, so the last instance upgraded in a rolling
+// upgrade sweeps whatever the old instances left behind.
+func (r *RateLimitRepo) MigrateLegacyConcurrencyKeys(ctx context.Context) (int, error) {
+	if r.rdb == nil {
+		return 0, fmt.Errorf("redis client is nil")
+	}
+
+	keys, err := scanKeys(ctx, r.rdb, legacyConcurrencyKeyPrefix+"[0-9]*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan legacy concurrency keys: %w", err)
+	}
+
+	migrated := 0
+	for _, key := range keys {
+		accountID, err := strconv.ParseInt(strings.TrimPrefix(key, legacyConcurrencyKeyPrefix), 10, 64)
+		if err != nil {
+			continue // not a legacy per-account key
+		}
+
+		slots, err := r.rdb.ZRangeWithScores(ctx, key, 0, -1).Result()
+		if err != nil {
+			return migrated, fmt.Errorf("failed to read legacy concurrency key %s: %w", key, err)
+		}
+
+		// The legacy and current keys hash to different cluster slots: copy, then delete, as separate commands
+		if len(slots) > 0 {
+			if err := r.rdb.ZAddNX(ctx, getConcurrencyKey(accountID), slots...).Err(); err != nil {
+				return migrated, fmt.Errorf("failed to migrate legacy concurrency key %s: %w", key, err)
+			}
+		}
+		if err := r.rdb.Del(ctx, key).Err(); err != nil {
+			return migrated, fmt.Errorf("failed to delete legacy concurrency key %s: %w", key, err)
+		}
+		migrated++
+	}
+
+	if migrated > 0 {
+		r.logger.Infow("migrated legacy concurrency keys", "count", migrated)
+	}
+	return migrated, nil
+}
@@ -0,0 +1,43 @@
+package data
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMigrateLegacyConcurrencyKeys tests that legacy slots move to the hash-tagged key and the legacy key is removed
+func TestMigrateLegacyConcurrencyKeys(t *testing.T) {
+	rdb, mr := setupTestRedis(t)
+	defer rdb.Close()
+	repo := NewRateLimitRepo(rdb, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+
+	require.NoError(t, rdb.ZAdd(ctx, "concurrency:123", redis.Z{Score: 100, Member: "req-old"}, redis.Z{Score: 100, Member: "req-both"}).Err())
+	require.NoError(t, rdb.ZAdd(ctx, "concurrency:{123}", redis.Z{Score: 200, Member: "req-both"}).Err())
+	require.NoError(t, rdb.ZAdd(ctx, "concurrency:provider:claude-console", redis.Z{Score: 100, Member: "req-p"}).Err())
+	require.NoError(t, rdb.ZAdd(ctx, "concurrency:group:{7}", redis.Z{Score: 100, Member: "req-g"}).Err())
+
+	migrated, err := repo.MigrateLegacyConcurrencyKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+	assert.False(t, mr.Exists("concurrency:123"))
+
+	slots, err := rdb.ZRangeWithScores(ctx, getConcurrencyKey(123), 0, -1).Result()
+	require.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Score: 100, Member: "req-old"}, {Score: 200, Member: "req-both"}}, slots,
+		"slots already in the new set keep their timestamp")
+
+	assert.True(t, mr.Exists("concurrency:provider:claude-console"), "provider sets are not legacy keys")
+	assert.True(t, mr.Exists("concurrency:group:{7}"), "group sets are not legacy keys")
+
+	// A second run finds nothing left to migrate
+	migrated, err = repo.MigrateLegacyConcurrencyKeys(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, migrated)
+}
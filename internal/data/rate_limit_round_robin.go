@@ -0,0 +1,29 @@
+package data
+
+import (
+	"context"
+	"fmt"
+)
+
+// IncrementGroupRoundRobin advances the group's round-robin rotation counter and returns its new
+// value (1 on first use). The counter is shared by all instances so they rotate through the group
+// together; it has no expiry because its value only matters modulo the member count.
+func (r *RateLimitRepo) IncrementGroupRoundRobin(ctx context.Context, groupID int64) (int64, error) {
+	if r.rdb == nil {
+		return 0, fmt.Errorf("redis client is nil")
+	}
+
+	count, err := r.rdb.Incr(ctx, getGroupRoundRobinKey(groupID)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment group round-robin counter: %w", err)
+	}
+
+	return count, nil
+}
+
+// getGroupRoundRobinKey generates a Redis key for a group's round-robin rotation counter.
+// Format: rr:group:{group_id}
+// Example: rr:group:{7}
+func getGroupRoundRobinKey(groupID int64) string {
+	return fmt.Sprintf("rr:group:{%d}", groupID)
+}
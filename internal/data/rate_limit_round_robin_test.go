@@ -0,0 +1,34 @@
+package data
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupRoundRobinCounter(t *testing.T) {
+	rdb, _ := setupTestRedis(t)
+	defer rdb.Close()
+
+	repo := NewRateLimitRepo(rdb, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+
+	for want := int64(1); want <= 3; want++ {
+		n, err := repo.IncrementGroupRoundRobin(ctx, 7)
+		require.NoError(t, err)
+		assert.Equal(t, want, n)
+	}
+
+	// Other groups rotate independently
+	n, err := repo.IncrementGroupRoundRobin(ctx, 8)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func TestGroupRoundRobinKey(t *testing.T) {
+	assert.Equal(t, "rr:group:{7}", getGroupRoundRobinKey(7))
+}
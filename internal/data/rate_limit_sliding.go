@@ -0,0 +1,66 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SlidingRPMWindow is the span of the sliding RPM window.
+const SlidingRPMWindow = 60 * time.Second
+
+// AddSlidingRPMRequest records a request in the account's sliding RPM log for model (empty = the
+// account-wide log), a sorted set scored by request time (Unix milliseconds). Entries older than SlidingRPMWindow are dropped first, all in one
+// MULTI/EXEC. Returns the number of requests in the window including this one, and the timestamp of
+// the oldest of them (used to compute when the window frees up).
+func (r *RateLimitRepo) AddSlidingRPMRequest(ctx context.Context, accountID int64, model, requestID string, timestamp int64) (int32, int64, error) {
+	if r.rdb == nil {
+		return 0, 0, fmt.Errorf("redis client is nil")
+	}
+
+	key := getSlidingRPMKey(accountID, model)
+	windowStart := timestamp - SlidingRPMWindow.Milliseconds()
+
+	pipe := r.rdb.TxPipeline()
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(windowStart, 10))
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(timestamp), Member: requestID})
+	card := pipe.ZCard(ctx, key)
+	oldest := pipe.ZRangeWithScores(ctx, key, 0, 0)
+	// 整个集合在窗口结束后自动过期，空闲账户不会残留键
+	pipe.PExpire(ctx, key, SlidingRPMWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, 0, fmt.Errorf("failed to record sliding RPM request: %w", err)
+	}
+
+	oldestTimestamp := timestamp
+	if entries := oldest.Val(); len(entries) > 0 {
+		oldestTimestamp = int64(entries[0].Score)
+	}
+
+	return saturateInt32(card.Val()), oldestTimestamp, nil
+}
+
+// RemoveSlidingRPMRequest removes a request from the account's sliding RPM log.
+// Used for rejected requests so they don't keep counting against the window.
+func (r *RateLimitRepo) RemoveSlidingRPMRequest(ctx context.Context, accountID int64, model, requestID string) error {
+	if r.rdb == nil {
+		return fmt.Errorf("redis client is nil")
+	}
+
+	if err := r.rdb.ZRem(ctx, getSlidingRPMKey(accountID, model), requestID).Err(); err != nil {
+		return fmt.Errorf("failed to remove sliding RPM request: %w", err)
+	}
+
+	return nil
+}
+
+// getSlidingRPMKey generates a Redis key for the sliding-window RPM log.
+// Shares the account hash tag with getRateLimitKey.
+// Format: rate:{account_id}:rpm:sliding or rate:{account_id}:model:{model}:rpm:sliding
+// Example: rate:{123}:rpm:sliding
+func getSlidingRPMKey(accountID int64, model string) string {
+	return getModelRateLimitKey(accountID, model, "rpm:sliding")
+}
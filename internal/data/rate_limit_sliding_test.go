@@ -0,0 +1,100 @@
+package data
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddSlidingRPMRequest(t *testing.T) {
+	rdb, _ := setupTestRedis(t)
+	defer rdb.Close()
+
+	repo := NewRateLimitRepo(rdb, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+	accountID := int64(123)
+	start := time.Now().UnixMilli()
+
+	count, oldest, err := repo.AddSlidingRPMRequest(ctx, accountID, "", "req-1", start)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+	assert.Equal(t, start, oldest)
+
+	count, oldest, err = repo.AddSlidingRPMRequest(ctx, accountID, "", "req-2", start+30_000)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+	assert.Equal(t, start, oldest)
+
+	// 60s after the first request it has left the window
+	count, oldest, err = repo.AddSlidingRPMRequest(ctx, accountID, "", "req-3", start+60_000)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+	assert.Equal(t, start+30_000, oldest)
+
+	key := getSlidingRPMKey(accountID, "")
+	assert.Equal(t, []string{"req-2", "req-3"}, rdb.ZRange(ctx, key, 0, -1).Val())
+	ttl := rdb.PTTL(ctx, key).Val()
+	assert.Greater(t, ttl, time.Duration(0))
+	assert.LessOrEqual(t, ttl, SlidingRPMWindow)
+
+	require.NoError(t, repo.RemoveSlidingRPMRequest(ctx, accountID, "", "req-3"))
+	assert.Equal(t, []string{"req-2"}, rdb.ZRange(ctx, key, 0, -1).Val())
+}
+
+func TestSlidingRPMKey_SharesHashTag(t *testing.T) {
+	assert.Equal(t, "rate:{123}:rpm:sliding", getSlidingRPMKey(123, ""))
+	assert.Equal(t, "rate:{123}:model:claude-opus-4:rpm:sliding", getSlidingRPMKey(123, "claude-opus-4"))
+}
+
+func TestUsageReaders_SlidingRPM(t *testing.T) {
+	rdb, _ := setupTestRedis(t)
+	defer rdb.Close()
+
+	repo := NewRateLimitRepo(rdb, log.NewStdLogger(os.Stdout))
+	repo.SetSlidingRPM(true)
+	ctx := context.Background()
+	now := time.Now().UnixMilli()
+
+	for i, id := range []string{"req-1", "req-2", "req-3"} {
+		_, _, err := repo.AddSlidingRPMRequest(ctx, 1, "", id, now-int64(i)*1000)
+		require.NoError(t, err)
+	}
+	_, _, err := repo.AddSlidingRPMRequest(ctx, 2, "", "req-4", now)
+	require.NoError(t, err)
+	// An entry that already left the window is not counted even before it is trimmed
+	require.NoError(t, rdb.ZAdd(ctx, getSlidingRPMKey(1, ""), redis.Z{Score: float64(now - 90_000), Member: "stale"}).Err())
+	_, err = repo.IncrementTPM(ctx, 1, 500)
+	require.NoError(t, err)
+
+	rpm, err := repo.GetRPMCount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), rpm)
+
+	rpm, tpm, err := repo.GetUsageCounts(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), rpm)
+	assert.Equal(t, int32(500), tpm)
+
+	totalRPM, totalTPM, err := repo.SumUsageCounts(ctx, []int64{1, 2}, []int64{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), totalRPM)
+	assert.Equal(t, int64(500), totalTPM)
+
+	stats, err := repo.BatchGetUsageStats(ctx, []int64{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), stats[1].RPM)
+	assert.Equal(t, int32(1), stats[2].RPM)
+	assert.Equal(t, int32(0), stats[3].RPM)
+
+	// Fixed mode keeps reading the INCR counter, which sliding mode never writes
+	repo.SetSlidingRPM(false)
+	rpm, err = repo.GetRPMCount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), rpm)
+}
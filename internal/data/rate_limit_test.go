@@ -2,7 +2,10 @@ package data
 
 import (
 	"context"
+	"math"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -180,6 +183,54 @@ func TestIncrementTPM_NegativeCorrection(t *testing.T) {
 	assert.Equal(t, int32(800), count)
 }
 
+// Test IncrementTPM - Counter saturates at int32 max instead of wrapping
+func TestIncrementTPM_Saturation(t *testing.T) {
+	rdb, mr := setupTestRedis(t)
+	defer rdb.Close()
+
+	logger := log.NewStdLogger(os.Stdout)
+	repo := NewRateLimitRepo(rdb, logger)
+
+	ctx := context.Background()
+	accountID := int64(123)
+
+	_, err := repo.IncrementTPM(ctx, accountID, math.MaxInt32-10)
+	require.NoError(t, err)
+
+	count, err := repo.IncrementTPM(ctx, accountID, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, int32(math.MaxInt32), count)
+
+	// Stored counter is capped and keeps its window TTL
+	stored, err := mr.Get("rate:{123}:tpm")
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(math.MaxInt32), stored)
+	assert.Greater(t, mr.TTL("rate:{123}:tpm"), time.Duration(0))
+
+	count, err = repo.GetTPMCount(ctx, accountID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(math.MaxInt32), count)
+}
+
+// Test GetTPMCount - Out-of-range stored value is saturated, not a parse error
+func TestGetTPMCount_OutOfRange(t *testing.T) {
+	rdb, mr := setupTestRedis(t)
+	defer rdb.Close()
+
+	logger := log.NewStdLogger(os.Stdout)
+	repo := NewRateLimitRepo(rdb, logger)
+
+	require.NoError(t, mr.Set("rate:{7}:tpm", "9999999999"))
+
+	count, err := repo.GetTPMCount(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, int32(math.MaxInt32), count)
+
+	_, tpm, err := repo.GetUsageCounts(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, int32(math.MaxInt32), tpm)
+}
+
 // Test GetTPMCount
 func TestGetTPMCount(t *testing.T) {
 	rdb, _ := setupTestRedis(t)
@@ -205,6 +256,148 @@ func TestGetTPMCount(t *testing.T) {
 	assert.Equal(t, int32(5000), count)
 }
 
+// Test GetUsageCounts - reads RPM and TPM together
+func TestGetUsageCounts(t *testing.T) {
+	rdb, _ := setupTestRedis(t)
+	defer rdb.Close()
+
+	logger := log.NewStdLogger(os.Stdout)
+	repo := NewRateLimitRepo(rdb, logger)
+
+	ctx := context.Background()
+	accountID := int64(123)
+
+	// Both keys missing
+	rpm, tpm, err := repo.GetUsageCounts(ctx, accountID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), rpm)
+	assert.Equal(t, int32(0), tpm)
+
+	// Only TPM present
+	_, err = repo.IncrementTPM(ctx, accountID, 800)
+	require.NoError(t, err)
+
+	rpm, tpm, err = repo.GetUsageCounts(ctx, accountID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), rpm)
+	assert.Equal(t, int32(800), tpm)
+
+	// Both present
+	_, err = repo.IncrementRPM(ctx, accountID)
+	require.NoError(t, err)
+	_, err = repo.IncrementRPM(ctx, accountID)
+	require.NoError(t, err)
+
+	rpm, tpm, err = repo.GetUsageCounts(ctx, accountID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), rpm)
+	assert.Equal(t, int32(800), tpm)
+}
+
+// Test SumUsageCounts
+func TestSumUsageCounts(t *testing.T) {
+	rdb, _ := setupTestRedis(t)
+	defer rdb.Close()
+
+	logger := log.NewStdLogger(os.Stdout)
+	repo := NewRateLimitRepo(rdb, logger)
+
+	ctx := context.Background()
+
+	// Empty input
+	rpm, tpm, err := repo.SumUsageCounts(ctx, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), rpm)
+	assert.Equal(t, int64(0), tpm)
+
+	_, err = repo.IncrementRPM(ctx, 1)
+	require.NoError(t, err)
+	_, err = repo.IncrementTPM(ctx, 1, 500)
+	require.NoError(t, err)
+	_, err = repo.IncrementRPM(ctx, 2)
+	require.NoError(t, err)
+	_, err = repo.IncrementRPM(ctx, 2)
+	require.NoError(t, err)
+
+	// Account 3 has no counters
+	rpm, tpm, err = repo.SumUsageCounts(ctx, []int64{1, 2, 3}, []int64{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), rpm)
+	assert.Equal(t, int64(500), tpm)
+
+	// Each dimension only sums its own accounts
+	rpm, tpm, err = repo.SumUsageCounts(ctx, []int64{2}, []int64{2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), rpm)
+	assert.Equal(t, int64(0), tpm)
+}
+
+// roundTripCounter counts Redis round trips issued by a client.
+type roundTripCounter struct {
+	commands  int
+	pipelines int
+}
+
+func (h *roundTripCounter) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *roundTripCounter) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.commands++
+		return next(ctx, cmd)
+	}
+}
+
+func (h *roundTripCounter) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		h.pipelines++
+		return next(ctx, cmds)
+	}
+}
+
+// Test BatchGetUsageStats - reads all counters for many accounts in one pipeline
+func TestBatchGetUsageStats(t *testing.T) {
+	rdb, _ := setupTestRedis(t)
+	defer rdb.Close()
+
+	logger := log.NewStdLogger(os.Stdout)
+	repo := NewRateLimitRepo(rdb, logger)
+
+	ctx := context.Background()
+
+	_, err := repo.IncrementRPM(ctx, 1)
+	require.NoError(t, err)
+	_, err = repo.IncrementTPM(ctx, 1, 500)
+	require.NoError(t, err)
+	require.NoError(t, repo.AddConcurrencyRequest(ctx, 1, "req-1", time.Now().Unix()))
+	require.NoError(t, repo.AddConcurrencyRequest(ctx, 1, "req-2", time.Now().Unix()))
+	_, err = repo.IncrementRPM(ctx, 2)
+	require.NoError(t, err)
+
+	ids := make([]int64, 0, 50)
+	for i := int64(1); i <= 50; i++ {
+		ids = append(ids, i)
+	}
+
+	counter := &roundTripCounter{}
+	rdb.AddHook(counter)
+
+	stats, err := repo.BatchGetUsageStats(ctx, ids)
+	require.NoError(t, err)
+	assert.Equal(t, 1, counter.pipelines, "all counters are read in a single pipeline")
+	assert.Equal(t, 0, counter.commands, "no individual round trips")
+
+	require.Len(t, stats, 50)
+	assert.Equal(t, &AccountUsageStats{RPM: 1, TPM: 500, Concurrency: 2}, stats[1])
+	assert.Equal(t, &AccountUsageStats{RPM: 1}, stats[2])
+	assert.Equal(t, &AccountUsageStats{}, stats[50], "missing counters are zero")
+
+	// Empty input does not touch Redis
+	stats, err = repo.BatchGetUsageStats(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+	assert.Equal(t, 1, counter.pipelines)
+}
+
 // Test AddConcurrencyRequest
 func TestAddConcurrencyRequest(t *testing.T) {
 	rdb, _ := setupTestRedis(t)
@@ -310,6 +503,34 @@ func TestCleanupExpiredConcurrency(t *testing.T) {
 	assert.Contains(t, members, "req-recent")
 }
 
+// Test HeartbeatConcurrencyRequest
+func TestHeartbeatConcurrencyRequest(t *testing.T) {
+	rdb, _ := setupTestRedis(t)
+	defer rdb.Close()
+
+	logger := log.NewStdLogger(os.Stdout)
+	repo := NewRateLimitRepo(rdb, logger)
+
+	ctx := context.Background()
+	accountID := int64(123)
+	groupID := int64(7)
+	now := time.Now().Unix()
+
+	require.NoError(t, repo.AddConcurrencyRequest(ctx, accountID, "req-live", now-100))
+	require.NoError(t, repo.AddGroupConcurrencyRequest(ctx, groupID, "req-live", now-100))
+
+	err := repo.HeartbeatConcurrencyRequest(ctx, accountID, "", groupID, "req-live", now)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(now), rdb.ZScore(ctx, getConcurrencyKey(accountID), "req-live").Val())
+	assert.Equal(t, float64(now), rdb.ZScore(ctx, getGroupConcurrencyKey(groupID), "req-live").Val())
+
+	// Heartbeating a slot that was already reclaimed must not re-add it
+	err = repo.HeartbeatConcurrencyRequest(ctx, accountID, "", groupID, "req-gone", now)
+	assert.NoError(t, err)
+	assert.NotContains(t, rdb.ZRange(ctx, getConcurrencyKey(accountID), 0, -1).Val(), "req-gone")
+	assert.NotContains(t, rdb.ZRange(ctx, getGroupConcurrencyKey(groupID), 0, -1).Val(), "req-gone")
+}
+
 // Test Redis Key generation
 func TestGetRateLimitKey(t *testing.T) {
 	tests := []struct {
@@ -317,9 +538,9 @@ func TestGetRateLimitKey(t *testing.T) {
 		limitType string
 		expected  string
 	}{
-		{123, "rpm", "rate:123:rpm"},
-		{456, "tpm", "rate:456:tpm"},
-		{789, "rpm", "rate:789:rpm"},
+		{123, "rpm", "rate:{123}:rpm"},
+		{456, "tpm", "rate:{456}:tpm"},
+		{789, "rpm", "rate:{789}:rpm"},
 	}
 
 	for _, tt := range tests {
@@ -334,9 +555,9 @@ func TestGetConcurrencyKey(t *testing.T) {
 		accountID int64
 		expected  string
 	}{
-		{123, "concurrency:123"},
-		{456, "concurrency:456"},
-		{789, "concurrency:789"},
+		{123, "concurrency:{123}"},
+		{456, "concurrency:{456}"},
+		{789, "concurrency:{789}"},
 	}
 
 	for _, tt := range tests {
@@ -345,6 +566,83 @@ func TestGetConcurrencyKey(t *testing.T) {
 	}
 }
 
+// Test all rate limit keys of one account share a Redis Cluster hash tag
+func TestRateLimitKeys_ShareHashTag(t *testing.T) {
+	hashTag := func(key string) string {
+		start := strings.Index(key, "{")
+		end := strings.Index(key[start+1:], "}")
+		require.True(t, start >= 0 && end > 0, "key %q has no hash tag", key)
+		return key[start+1 : start+1+end]
+	}
+
+	keys := []string{getRateLimitKey(42, "rpm"), getRateLimitKey(42, "tpm"), getConcurrencyKey(42),
+		getModelRateLimitKey(42, "claude-opus-4", "rpm"), getSlidingRPMKey(42, "")}
+	for _, key := range keys {
+		assert.Equal(t, "42", hashTag(key), key)
+	}
+}
+
+// Test per-model counters are isolated from the account-wide counters and from each other
+func TestModelRateLimitCounters(t *testing.T) {
+	rdb, _ := setupTestRedis(t)
+	defer rdb.Close()
+
+	repo := NewRateLimitRepo(rdb, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+
+	assert.Equal(t, "rate:{42}:model:claude-opus-4:rpm", getModelRateLimitKey(42, "claude-opus-4", "rpm"))
+	assert.Equal(t, getRateLimitKey(42, "tpm"), getModelRateLimitKey(42, "", "tpm"))
+
+	count, err := repo.IncrementModelRPM(ctx, 42, "claude-opus-4")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+	count, err = repo.IncrementModelRPM(ctx, 42, "claude-sonnet-4")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+	count, err = repo.IncrementRPM(ctx, 42)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+
+	_, err = repo.IncrementModelTPM(ctx, 42, "claude-opus-4", 500)
+	require.NoError(t, err)
+	tpm, err := repo.GetModelTPMCount(ctx, 42, "claude-opus-4")
+	require.NoError(t, err)
+	assert.Equal(t, int32(500), tpm)
+	tpm, err = repo.GetTPMCount(ctx, 42)
+	require.NoError(t, err)
+	assert.Zero(t, tpm)
+	assert.Greater(t, rdb.TTL(ctx, getModelRateLimitKey(42, "claude-opus-4", "tpm")).Val(), time.Duration(0))
+}
+
+// Test provider-wide concurrency tracking is shared across accounts and cleaned up by age
+func TestProviderConcurrency(t *testing.T) {
+	rdb, _ := setupTestRedis(t)
+	defer rdb.Close()
+
+	repo := NewRateLimitRepo(rdb, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+	now := time.Now().Unix()
+
+	require.NoError(t, repo.AddProviderConcurrencyRequest(ctx, ProviderClaudeConsole, "req-old", now-900))
+	require.NoError(t, repo.AddProviderConcurrencyRequest(ctx, ProviderClaudeConsole, "req-1", now))
+	require.NoError(t, repo.AddProviderConcurrencyRequest(ctx, ProviderClaudeConsole, "req-2", now))
+	require.NoError(t, repo.AddProviderConcurrencyRequest(ctx, ProviderOpenAIResponses, "req-3", now))
+
+	count, err := repo.GetProviderConcurrencyCount(ctx, ProviderClaudeConsole)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+
+	require.NoError(t, repo.RemoveProviderConcurrencyRequest(ctx, ProviderClaudeConsole, "req-1"))
+	require.NoError(t, repo.CleanupExpiredProviderConcurrency(ctx, ProviderClaudeConsole, now-600))
+
+	members := rdb.ZRange(ctx, "concurrency:provider:claude-console", 0, -1).Val()
+	assert.Equal(t, []string{"req-2"}, members)
+
+	count, err = repo.GetProviderConcurrencyCount(ctx, ProviderOpenAIResponses)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+}
+
 // Test concurrent RPM increments (race condition test)
 func TestIncrementRPM_Concurrent(t *testing.T) {
 	rdb, _ := setupTestRedis(t)
@@ -441,3 +739,137 @@ func TestRateLimitRepo_NilRedis(t *testing.T) {
 	err = repo.CleanupExpiredConcurrency(ctx, accountID, time.Now().Unix())
 	assert.Error(t, err)
 }
+
+// Test group-wide RPM/TPM counters are separate from account counters and expire with the window
+func TestGroupRateLimitCounters(t *testing.T) {
+	rdb, mr := setupTestRedis(t)
+	defer rdb.Close()
+
+	repo := NewRateLimitRepo(rdb, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+
+	count, err := repo.IncrementGroupRPM(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+	count, err = repo.IncrementGroupRPM(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+	assert.True(t, mr.Exists("rate:group:{7}:rpm"))
+	assert.Equal(t, 60*time.Second, mr.TTL("rate:group:{7}:rpm"))
+
+	require.NoError(t, repo.DecrementGroupRPM(ctx, 7))
+	got, err := mr.Get("rate:group:{7}:rpm")
+	require.NoError(t, err)
+	assert.Equal(t, "1", got)
+
+	tpm, err := repo.GetGroupTPMCount(ctx, 7)
+	require.NoError(t, err)
+	assert.Zero(t, tpm)
+
+	tpm, err = repo.IncrementGroupTPM(ctx, 7, 500)
+	require.NoError(t, err)
+	assert.Equal(t, int32(500), tpm)
+	tpm, err = repo.IncrementGroupTPM(ctx, 7, -200)
+	require.NoError(t, err)
+	assert.Equal(t, int32(300), tpm)
+	assert.Equal(t, 60*time.Second, mr.TTL("rate:group:{7}:tpm"))
+
+	// Group 7 and account 7 do not share counters
+	accountRPM, err := repo.GetRPMCount(ctx, 7)
+	require.NoError(t, err)
+	assert.Zero(t, accountRPM)
+
+	mr.FastForward(61 * time.Second)
+	tpm, err = repo.GetGroupTPMCount(ctx, 7)
+	require.NoError(t, err)
+	assert.Zero(t, tpm)
+}
+
+// Test group-wide concurrency set is separate from the account set and cleaned up by age
+func TestGroupConcurrencyCounters(t *testing.T) {
+	rdb, mr := setupTestRedis(t)
+	defer rdb.Close()
+
+	repo := NewRateLimitRepo(rdb, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+	now := time.Now().Unix()
+
+	require.NoError(t, repo.AddGroupConcurrencyRequest(ctx, 7, "req-1", now-700))
+	require.NoError(t, repo.AddGroupConcurrencyRequest(ctx, 7, "req-2", now))
+	assert.True(t, mr.Exists("concurrency:group:{7}"))
+
+	count, err := repo.GetGroupConcurrencyCount(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+
+	// Group 7 and account 7 do not share slots
+	accountCount, err := repo.GetConcurrencyCount(ctx, 7)
+	require.NoError(t, err)
+	assert.Zero(t, accountCount)
+
+	require.NoError(t, repo.CleanupExpiredGroupConcurrency(ctx, 7, now-600))
+	count, err = repo.GetGroupConcurrencyCount(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+
+	require.NoError(t, repo.RemoveGroupConcurrencyRequest(ctx, 7, "req-2"))
+	count, err = repo.GetGroupConcurrencyCount(ctx, 7)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+// Test that every increment path applies a TTL to a pre-existing counter key that has none
+func TestIncrement_RepairsMissingTTL(t *testing.T) {
+	rdb, mr := setupTestRedis(t)
+	defer rdb.Close()
+
+	repo := NewRateLimitRepo(rdb, log.DefaultLogger)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		key       string
+		increment func() error
+	}{
+		{"rpm", getRateLimitKey(1, "rpm"), func() error { _, err := repo.IncrementRPM(ctx, 1); return err }},
+		{"tpm", getRateLimitKey(1, "tpm"), func() error { _, err := repo.IncrementTPM(ctx, 1, 10); return err }},
+		{"group rpm", getGroupRateLimitKey(7, "rpm"), func() error { _, err := repo.IncrementGroupRPM(ctx, 7); return err }},
+		{"group tpm", getGroupRateLimitKey(7, "tpm"), func() error { _, err := repo.IncrementGroupTPM(ctx, 7, 10); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A key left behind without an expiry (manual SET or an earlier bug)
+			require.NoError(t, mr.Set(tt.key, "1000"))
+			require.Zero(t, mr.TTL(tt.key))
+
+			require.NoError(t, tt.increment())
+			assert.Equal(t, DefaultRateLimitCounterTTL, mr.TTL(tt.key))
+
+			// The counter then expires with the window instead of limiting forever
+			mr.FastForward(DefaultRateLimitCounterTTL + time.Second)
+			assert.False(t, mr.Exists(tt.key))
+		})
+	}
+}
+
+// Test that an existing TTL is kept rather than extended on every increment
+func TestIncrement_KeepsExistingTTL(t *testing.T) {
+	rdb, mr := setupTestRedis(t)
+	defer rdb.Close()
+
+	repo := NewRateLimitRepo(rdb, log.DefaultLogger)
+	repo.SetCounterTTL(30 * time.Second)
+	ctx := context.Background()
+	key := getRateLimitKey(1, "rpm")
+
+	_, err := repo.IncrementRPM(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, mr.TTL(key))
+
+	mr.FastForward(10 * time.Second)
+	count, err := repo.IncrementRPM(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+	assert.Equal(t, 20*time.Second, mr.TTL(key))
+}
@@ -4,6 +4,8 @@ package data
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"QuotaLane/internal/conf"
@@ -12,10 +14,19 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// Redis deployment modes (data.redis.mode)
+const (
+	RedisModeSingle   = "single"   // one node (default)
+	RedisModeCluster  = "cluster"  // Redis Cluster
+	RedisModeSentinel = "sentinel" // Sentinel-managed master/replica failover
+)
+
 // NewRedisClient creates a new Redis client with connection pool configuration.
+// The client type follows data.redis.mode: a plain client (single), a ClusterClient (cluster)
+// or a Sentinel FailoverClient (sentinel); callers only see redis.UniversalClient.
 // It returns the client, a cleanup function, and an error.
 // Connection failure does not prevent application startup (graceful degradation).
-func NewRedisClient(c *conf.Data, logger log.Logger) (*redis.Client, func(), error) {
+func NewRedisClient(c *conf.Data, logger log.Logger) (redis.UniversalClient, func(), error) {
 	helper := log.NewHelper(logger)
 
 	// Validate configuration
@@ -25,29 +36,18 @@ func NewRedisClient(c *conf.Data, logger log.Logger) (*redis.Client, func(), err
 	}
 
 	addr := c.Redis.Addr
-	if addr == "" {
+	if addr == "" && len(c.Redis.Addrs) == 0 {
 		helper.Warn("Redis address is empty, skipping Redis initialization")
 		return nil, func() {}, nil
 	}
 
-	// Create Redis client with connection pool settings
-	// Pool configuration follows acceptance criteria:
-	// - MaxIdleConns = 10 (minimum idle connections)
-	// - PoolSize = 100 (maximum active connections)
-	// - ConnMaxLifetime = 3s (connection timeout)
-	rdb := redis.NewClient(&redis.Options{
-		Addr:         addr,
-		Password:     "", // No password for local development
-		DB:           0,  // Use default DB
-		PoolSize:     100,
-		MinIdleConns: 10,
-		DialTimeout:  3 * time.Second,
-		ReadTimeout:  c.Redis.ReadTimeout.AsDuration(),
-		WriteTimeout: c.Redis.WriteTimeout.AsDuration(),
-		// ConnMaxLifetime is not directly supported in go-redis v9
-		// Use ConnMaxIdleTime instead for idle connection cleanup
-		ConnMaxIdleTime: 5 * time.Minute,
-	})
+	rdb, err := newUniversalClient(c.Redis)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	if len(c.Redis.Addrs) > 0 {
+		addr = strings.Join(c.Redis.Addrs, ",")
+	}
 
 	// Health check: verify connection with ping
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -75,3 +75,78 @@ func NewRedisClient(c *conf.Data, logger log.Logger) (*redis.Client, func(), err
 
 	return rdb, cleanup, nil
 }
+
+// newUniversalClient builds the Redis client for the configured mode.
+// Pool configuration follows acceptance criteria:
+// - MaxIdleConns = 10 (minimum idle connections)
+// - PoolSize = 100 (maximum active connections, per node in cluster mode)
+// - ConnMaxLifetime = 3s (connection timeout)
+func newUniversalClient(c *conf.Data_Redis) (redis.UniversalClient, error) {
+	addrs := c.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{c.Addr}
+	}
+
+	opts := &redis.UniversalOptions{
+		Addrs:        addrs,
+		MasterName:   c.MasterName,
+		Password:     "", // No password for local development
+		DB:           0,  // Use default DB
+		PoolSize:     100,
+		MinIdleConns: 10,
+		DialTimeout:  3 * time.Second,
+		ReadTimeout:  c.ReadTimeout.AsDuration(),
+		WriteTimeout: c.WriteTimeout.AsDuration(),
+		// ConnMaxLifetime is not directly supported in go-redis v9
+		// Use ConnMaxIdleTime instead for idle connection cleanup
+		ConnMaxIdleTime: 5 * time.Minute,
+	}
+
+	switch c.Mode {
+	case "", RedisModeSingle:
+		return redis.NewClient(opts.Simple()), nil
+	case RedisModeCluster:
+		return redis.NewClusterClient(opts.Cluster()), nil
+	case RedisModeSentinel:
+		if c.MasterName == "" {
+			return nil, fmt.Errorf("redis sentinel mode requires master_name")
+		}
+		return redis.NewFailoverClient(opts.Failover()), nil
+	default:
+		return nil, fmt.Errorf("unknown redis mode: %s", c.Mode)
+	}
+}
+
+// scanKeys returns the keys matching pattern. In Redis Cluster mode every master is scanned,
+// since SCAN only walks the node it is sent to.
+func scanKeys(ctx context.Context, rdb redis.UniversalClient, pattern string) ([]string, error) {
+	scanNode := func(ctx context.Context, client redis.Cmdable) ([]string, error) {
+		var keys []string
+		iter := client.Scan(ctx, 0, pattern, 100).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		return keys, iter.Err()
+	}
+
+	cluster, ok := rdb.(*redis.ClusterClient)
+	if !ok {
+		return scanNode(ctx, rdb)
+	}
+
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		nodeKeys, err := scanNode(ctx, node)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		keys = append(keys, nodeKeys...)
+		mu.Unlock()
+		return nil
+	})
+	return keys, err
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -120,7 +121,8 @@ func TestNewRedisClient_PoolConfiguration(t *testing.T) {
 	defer cleanup()
 
 	// Verify pool configuration through Options
-	opts := client.Options()
+	require.IsType(t, &redis.Client{}, client)
+	opts := client.(*redis.Client).Options()
 	assert.Equal(t, 100, opts.PoolSize, "PoolSize should be 100")
 	assert.Equal(t, 10, opts.MinIdleConns, "MinIdleConns should be 10")
 	assert.Equal(t, 3*time.Second, opts.DialTimeout, "DialTimeout should be 3s")
@@ -159,3 +161,89 @@ func TestNewRedisClient_CleanupFunction(t *testing.T) {
 	err = client.Ping(ctx).Err()
 	assert.Error(t, err)
 }
+
+func TestNewUniversalClient_ModeSelection(t *testing.T) {
+	tests := []struct {
+		name     string
+		redis    *conf.Data_Redis
+		wantType redis.UniversalClient
+		wantErr  bool
+	}{
+		{
+			name:     "default mode is single",
+			redis:    &conf.Data_Redis{Addr: "127.0.0.1:6379"},
+			wantType: &redis.Client{},
+		},
+		{
+			name:     "single",
+			redis:    &conf.Data_Redis{Mode: RedisModeSingle, Addr: "127.0.0.1:6379"},
+			wantType: &redis.Client{},
+		},
+		{
+			name:     "cluster",
+			redis:    &conf.Data_Redis{Mode: RedisModeCluster, Addrs: []string{"10.0.0.1:7000", "10.0.0.2:7000"}},
+			wantType: &redis.ClusterClient{},
+		},
+		{
+			name:     "cluster falls back to addr",
+			redis:    &conf.Data_Redis{Mode: RedisModeCluster, Addr: "10.0.0.1:7000"},
+			wantType: &redis.ClusterClient{},
+		},
+		{
+			name:     "sentinel",
+			redis:    &conf.Data_Redis{Mode: RedisModeSentinel, Addrs: []string{"10.0.0.1:26379"}, MasterName: "mymaster"},
+			wantType: &redis.Client{},
+		},
+		{
+			name:    "sentinel without master name",
+			redis:   &conf.Data_Redis{Mode: RedisModeSentinel, Addrs: []string{"10.0.0.1:26379"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown mode",
+			redis:   &conf.Data_Redis{Mode: "ring", Addr: "127.0.0.1:6379"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := newUniversalClient(tt.redis)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer client.Close()
+			assert.IsType(t, tt.wantType, client)
+		})
+	}
+}
+
+func TestNewUniversalClient_ClusterOptions(t *testing.T) {
+	client, err := newUniversalClient(&conf.Data_Redis{
+		Mode:        RedisModeCluster,
+		Addrs:       []string{"10.0.0.1:7000", "10.0.0.2:7000"},
+		ReadTimeout: durationpb.New(200 * time.Millisecond),
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	opts := client.(*redis.ClusterClient).Options()
+	assert.Equal(t, []string{"10.0.0.1:7000", "10.0.0.2:7000"}, opts.Addrs)
+	assert.Equal(t, 100, opts.PoolSize)
+	assert.Equal(t, 200*time.Millisecond, opts.ReadTimeout)
+}
+
+func TestNewUniversalClient_SentinelUsesFailover(t *testing.T) {
+	client, err := newUniversalClient(&conf.Data_Redis{
+		Mode:       RedisModeSentinel,
+		Addrs:      []string{"10.0.0.1:26379"},
+		MasterName: "mymaster",
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	// A failover client resolves the master through sentinels instead of dialing a fixed address
+	assert.NotEqual(t, "10.0.0.1:26379", client.(*redis.Client).Options().Addr)
+}
@@ -0,0 +1,74 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+)
+
+// DefaultRefreshRunListLimit is the number of runs returned when no limit is given.
+const DefaultRefreshRunListLimit = 20
+
+// RefreshRun is the GORM model for refresh_runs table.
+// One row summarizes one batch token refresh cycle.
+type RefreshRun struct {
+	ID         int64     `gorm:"primaryKey;column:id"`
+	Job        string    `gorm:"column:job;type:varchar(50);not null"` // 任务名称
+	StartedAt  time.Time `gorm:"column:started_at;not null"`           // 开始时间
+	Total      int32     `gorm:"column:total;not null"`                // 处理账户总数
+	Success    int32     `gorm:"column:success;not null"`              // 刷新成功数
+	Failure    int32     `gorm:"column:failure;not null"`              // 刷新失败数
+	DurationMs int64     `gorm:"column:duration_ms;not null"`          // 耗时（毫秒）
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM.
+func (RefreshRun) TableName() string {
+	return "refresh_runs"
+}
+
+// RefreshRunRepo persists batch refresh run summaries.
+type RefreshRunRepo struct {
+	db     *gorm.DB
+	logger *log.Helper
+}
+
+// NewRefreshRunRepo creates a new RefreshRunRepo.
+func NewRefreshRunRepo(db *gorm.DB, logger log.Logger) *RefreshRunRepo {
+	return &RefreshRunRepo{
+		db:     db,
+		logger: log.NewHelper(logger),
+	}
+}
+
+// CreateRefreshRun inserts one run summary.
+func (r *RefreshRunRepo) CreateRefreshRun(ctx context.Context, run *RefreshRun) error {
+	if err := r.db.WithContext(ctx).Create(run).Error; err != nil {
+		r.logger.Errorf("failed to create refresh run: %v", err)
+		return fmt.Errorf("failed to create refresh run: %w", err)
+	}
+	return nil
+}
+
+// ListRefreshRuns returns the most recent runs, newest first.
+// limit <= 0 falls back to DefaultRefreshRunListLimit.
+func (r *RefreshRunRepo) ListRefreshRuns(ctx context.Context, limit int) ([]*RefreshRun, error) {
+	if limit <= 0 {
+		limit = DefaultRefreshRunListLimit
+	}
+
+	var runs []*RefreshRun
+	err := r.db.WithContext(ctx).
+		Order("started_at DESC, id DESC").
+		Limit(limit).
+		Find(&runs).Error
+	if err != nil {
+		r.logger.Errorf("failed to list refresh runs: %v", err)
+		return nil, fmt.Errorf("failed to list refresh runs: %w", err)
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,67 @@
+package data
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateRefreshRun tests inserting a batch refresh summary
+func TestCreateRefreshRun(t *testing.T) {
+	gormDB, mock, cleanup := setupGroupTestDB(t)
+	defer cleanup()
+	repo := NewRefreshRunRepo(gormDB, log.DefaultLogger)
+
+	startedAt := time.Now().Add(-3 * time.Second)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `refresh_runs` (`job`,`started_at`,`total`,`success`,`failure`,`duration_ms`,`created_at`) VALUES (?,?,?,?,?,?,?)")).
+		WithArgs("auto_refresh", startedAt, int32(5), int32(4), int32(1), int64(3000), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectCommit()
+
+	run := &RefreshRun{Job: "auto_refresh", StartedAt: startedAt, Total: 5, Success: 4, Failure: 1, DurationMs: 3000}
+	require.NoError(t, repo.CreateRefreshRun(context.Background(), run))
+	assert.Equal(t, int64(7), run.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestListRefreshRuns tests listing runs newest first with the default limit
+func TestListRefreshRuns(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		wantLimit int
+	}{
+		{"explicit limit", 2, 2},
+		{"default limit", 0, DefaultRefreshRunListLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock, cleanup := setupGroupTestDB(t)
+			defer cleanup()
+			repo := NewRefreshRunRepo(gormDB, log.DefaultLogger)
+
+			now := time.Now()
+			rows := sqlmock.NewRows([]string{"id", "job", "started_at", "total", "success", "failure", "duration_ms"}).
+				AddRow(2, "auto_refresh", now, 3, 3, 0, 120).
+				AddRow(1, "expiring_refresh", now.Add(-time.Hour), 2, 1, 1, 450)
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `refresh_runs` ORDER BY started_at DESC, id DESC LIMIT ?")).
+				WithArgs(tt.wantLimit).
+				WillReturnRows(rows)
+
+			runs, err := repo.ListRefreshRuns(context.Background(), tt.limit)
+			require.NoError(t, err)
+			require.Len(t, runs, 2)
+			assert.Equal(t, int64(2), runs[0].ID)
+			assert.Equal(t, int32(1), runs[1].Failure)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
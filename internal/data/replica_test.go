@@ -0,0 +1,83 @@
+package data
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"QuotaLane/internal/conf"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReplica_ReadsHitReplicaWritesHitPrimary tests list queries are routed to the replica
+// while writes stay on the primary
+func TestReplica_ReadsHitReplicaWritesHitPrimary(t *testing.T) {
+	primaryDB, primaryMock, primaryCleanup := setupGroupTestDB(t)
+	defer primaryCleanup()
+	replicaDB, replicaMock, replicaCleanup := setupGroupTestDB(t)
+	defer replicaCleanup()
+	redisClient, _, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+
+	d := &Data{redisClient: redisClient, cache: NewCacheClient(redisClient)}
+	replica := &ReplicaDB{DB: replicaDB}
+	accountRepo := NewAccountRepo(d, primaryDB, replica, log.DefaultLogger)
+	groupRepo := NewAccountGroupRepo(d, primaryDB, replica, log.DefaultLogger)
+	ctx := context.Background()
+
+	// Reads: replica
+	replicaMock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `api_accounts`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	replicaMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_accounts`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "provider"}).AddRow(1, "account", ProviderClaudeConsole))
+	replicaMock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `account_groups`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	replicaMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_groups`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "group"))
+
+	accounts, total, err := accountRepo.ListAccounts(ctx, &AccountFilter{Page: 1, PageSize: 20})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), total)
+	assert.Len(t, accounts, 1)
+
+	groups, groupTotal, err := groupRepo.ListGroups(ctx, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), groupTotal)
+	assert.Len(t, groups, 1)
+
+	// Writes: primary
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectExec(regexp.QuoteMeta("UPDATE `api_accounts` SET `inactivated_at`=?,`status`=?,`updated_at`=? WHERE id = ?")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	primaryMock.ExpectCommit()
+
+	require.NoError(t, accountRepo.UpdateAccountStatus(ctx, 1, StatusError))
+
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+// TestReplica_FallbackToPrimary tests reads use the primary when no replica is configured
+func TestReplica_FallbackToPrimary(t *testing.T) {
+	replica, cleanup, err := NewMySQLReplicaClient(&conf.Data{Database: &conf.Data_Database{Source: "primary-dsn"}}, log.DefaultLogger)
+	require.NoError(t, err)
+	defer cleanup()
+	assert.Nil(t, replica.DB)
+
+	primaryDB, primaryMock, primaryCleanup := setupGroupTestDB(t)
+	defer primaryCleanup()
+	repo := NewAccountRepo(&Data{}, primaryDB, replica, log.DefaultLogger)
+
+	primaryMock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `api_accounts`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	primaryMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_accounts`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, _, err = repo.ListAccounts(context.Background(), &AccountFilter{Page: 1, PageSize: 20})
+	require.NoError(t, err)
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
@@ -0,0 +1,73 @@
+package data
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// txKey is the context key carrying the active transaction.
+type txKey struct{}
+
+// txState is the transaction carried by a context together with the hooks to run after commit.
+type txState struct {
+	tx          *gorm.DB
+	afterCommit []func()
+}
+
+// WithTransaction runs fn inside a single GORM transaction. Repository calls made with the
+// ctx passed to fn share the transaction, so a failure in any step rolls back every earlier
+// write. A nested call joins the outer transaction. Cache writes and invalidations registered
+// during fn run only after the commit and are dropped on rollback.
+func WithTransaction(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	state := &txState{}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		state.tx = tx
+		return fn(context.WithValue(ctx, txKey{}, state))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range state.afterCommit {
+		hook()
+	}
+	return nil
+}
+
+// dbFor returns the transaction carried by ctx, or db bound to ctx outside a transaction.
+func dbFor(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return db.WithContext(ctx)
+}
+
+// afterCommit runs fn once the transaction carried by ctx commits, or immediately outside a
+// transaction. Used for cache updates so a rolled-back write never reaches the cache.
+func afterCommit(ctx context.Context, fn func()) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+// Transactor runs multi-step repository operations in a single transaction on the primary.
+type Transactor struct {
+	db *gorm.DB
+}
+
+// NewTransactor creates a new Transactor.
+func NewTransactor(db *gorm.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithTransaction runs fn inside a single transaction (see the package-level WithTransaction).
+func (t *Transactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return WithTransaction(ctx, t.db, fn)
+}
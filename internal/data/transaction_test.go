@@ -0,0 +1,100 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWithTransaction_LaterStepFailureRollsBack tests that a failing step rolls back the
+// group inserted by an earlier step and that no cache change escapes the transaction.
+func TestWithTransaction_LaterStepFailureRollsBack(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, mr, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountGroupRepo(&Data{redisClient: redisClient}, gormDB, nil, log.DefaultLogger)
+	ctx := context.Background()
+
+	require.NoError(t, mr.Set("group:1", `{"id":1}`))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `account_groups`")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `account_groups` SET `rpm_limit`=?")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `account_groups` SET `concurrency_limit`=?")).
+		WillReturnError(errors.New("lock wait timeout"))
+	mock.ExpectRollback()
+
+	err := NewTransactor(gormDB).WithTransaction(ctx, func(ctx context.Context) error {
+		groupID, err := repo.CreateGroup(ctx, "tx-group", "", 0, nil)
+		if err != nil {
+			return err
+		}
+		if err := repo.UpdateGroupRateLimits(ctx, groupID, 100, 0); err != nil {
+			return err
+		}
+		return repo.UpdateGroupConcurrencyLimit(ctx, groupID, 5)
+	})
+
+	require.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "all steps run in one transaction that is rolled back")
+	assert.True(t, mr.Exists("group:1"), "cache is not invalidated for a rolled-back write")
+}
+
+// TestWithTransaction_CommitRunsCacheHooks tests that cache invalidations run after commit.
+func TestWithTransaction_CommitRunsCacheHooks(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	redisClient, mr, redisCleanup := setupGroupTestRedis(t)
+	defer redisCleanup()
+	repo := NewAccountGroupRepo(&Data{redisClient: redisClient}, gormDB, nil, log.DefaultLogger)
+	ctx := context.Background()
+
+	require.NoError(t, mr.Set("group:7", `{"id":7}`))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `account_groups` SET `rpm_limit`=?")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := WithTransaction(ctx, gormDB, func(ctx context.Context) error {
+		if err := repo.UpdateGroupRateLimits(ctx, 7, 100, 0); err != nil {
+			return err
+		}
+		assert.True(t, mr.Exists("group:7"), "invalidation waits for the commit")
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.False(t, mr.Exists("group:7"))
+}
+
+// TestWithTransaction_Nested tests that a nested call joins the outer transaction.
+func TestWithTransaction_Nested(t *testing.T) {
+	gormDB, mock, dbCleanup := setupGroupTestDB(t)
+	defer dbCleanup()
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	err := WithTransaction(ctx, gormDB, func(outer context.Context) error {
+		return WithTransaction(outer, gormDB, func(inner context.Context) error {
+			assert.Same(t, dbFor(outer, gormDB), dbFor(inner, gormDB))
+			return nil
+		})
+	})
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
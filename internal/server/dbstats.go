@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/json"
+	nethttp "net/http"
+
+	"gorm.io/gorm"
+)
+
+// DBStatsPath exposes MySQL connection pool statistics.
+const DBStatsPath = "/debug/dbstats"
+
+// dbStats is the JSON view of sql.DBStats.
+type dbStats struct {
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDurationMs     int64  `json:"wait_duration_ms"`
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+	Error              string `json:"error,omitempty"`
+}
+
+// newDBStatsHandler returns a handler reporting the connection pool statistics.
+// A growing wait_count / wait_duration_ms indicates pool exhaustion.
+func newDBStatsHandler(db *gorm.DB) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			w.WriteHeader(nethttp.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(dbStats{Error: err.Error()})
+			return
+		}
+
+		stats := sqlDB.Stats()
+		_ = json.NewEncoder(w).Encode(dbStats{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+			MaxIdleClosed:      stats.MaxIdleClosed,
+			MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+			MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+		})
+	}
+}
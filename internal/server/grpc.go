@@ -5,6 +5,7 @@ package server
 import (
 	v1 "QuotaLane/api/v1"
 	"QuotaLane/internal/conf"
+	"QuotaLane/internal/server/middleware"
 	"QuotaLane/internal/service"
 
 	"github.com/go-kratos/kratos/v2/log"
@@ -13,10 +14,11 @@ import (
 )
 
 // NewGRPCServer new a gRPC server.
-func NewGRPCServer(c *conf.Server, accountSvc *service.AccountService, _ log.Logger) *grpc.Server {
+func NewGRPCServer(c *conf.Server, auth *conf.Auth, accountSvc *service.AccountService, _ log.Logger) *grpc.Server {
 	var opts = []grpc.ServerOption{
 		grpc.Middleware(
 			recovery.Recovery(),
+			middleware.AdminFields(auth.GetAdminApiKeys()),
 		),
 	}
 	if c.Grpc.Network != "" {
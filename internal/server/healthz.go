@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"time"
+
+	"QuotaLane/internal/biz"
+)
+
+// HealthzPath exposes liveness of background jobs (refresh cron dead man's switch).
+const HealthzPath = "/healthz"
+
+// refreshCronChecker reports whether the token refresh cron is still running.
+type refreshCronChecker interface {
+	CheckRefreshCron(ctx context.Context, now time.Time) (*biz.RefreshCronStatus, error)
+}
+
+// refreshCronHealth is the JSON view of biz.RefreshCronStatus.
+type refreshCronHealth struct {
+	LastRun    *time.Time `json:"last_run,omitempty"`
+	AgeSeconds int64      `json:"age_seconds"`
+	Stale      bool       `json:"stale"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// healthz is the /healthz response body.
+type healthz struct {
+	Status      string            `json:"status"`
+	RefreshCron refreshCronHealth `json:"refresh_cron"`
+}
+
+// newHealthzHandler returns a handler that responds 503 when the refresh cron
+// has not run for more than biz.RefreshCronStaleAfter, so alerting can catch a dead cron.
+func newHealthzHandler(checker refreshCronChecker) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		resp := healthz{Status: "ok"}
+		status, err := checker.CheckRefreshCron(r.Context(), time.Now())
+		switch {
+		case err != nil:
+			resp.Status = "unhealthy"
+			resp.RefreshCron = refreshCronHealth{Stale: true, Error: err.Error()}
+		default:
+			resp.RefreshCron = refreshCronHealth{
+				LastRun:    status.LastRun,
+				AgeSeconds: int64(status.Age.Seconds()),
+				Stale:      status.Stale,
+			}
+			if status.Stale {
+				resp.Status = "unhealthy"
+			}
+		}
+
+		if resp.Status != "ok" {
+			w.WriteHeader(nethttp.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	v1 "QuotaLane/api/v1"
+	"QuotaLane/internal/biz"
 	"QuotaLane/internal/conf"
 	"QuotaLane/internal/server/middleware"
 	"QuotaLane/internal/service"
@@ -10,10 +11,11 @@ import (
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware/recovery"
 	"github.com/go-kratos/kratos/v2/transport/http"
+	"gorm.io/gorm"
 )
 
 // NewHTTPServer new an HTTP server.
-func NewHTTPServer(c *conf.Server, accountService *service.AccountService, logger log.Logger) *http.Server {
+func NewHTTPServer(c *conf.Server, auth *conf.Auth, accountService *service.AccountService, accountUC *biz.AccountUsecase, db *gorm.DB, logger log.Logger) *http.Server {
 	// 创建增强的日志辅助器
 	logHelper := pkglog.NewLogHelper(logger)
 
@@ -22,6 +24,8 @@ func NewHTTPServer(c *conf.Server, accountService *service.AccountService, logge
 			recovery.Recovery(),
 			middleware.Auth(logHelper),    // 认证中间件：记录 API Key 和 User-Agent
 			middleware.Logging(logHelper), // 请求日志中间件：记录请求方法、路径、耗时
+			// 管理员字段中间件：非管理员不可读写账户备注等字段
+			middleware.AdminFields(auth.GetAdminApiKeys()),
 		),
 	}
 	if c.Http.Network != "" {
@@ -38,5 +42,14 @@ func NewHTTPServer(c *conf.Server, accountService *service.AccountService, logge
 	// Register HTTP services
 	v1.RegisterAccountServiceHTTPServer(srv, accountService)
 
+	// Connection pool statistics (open / in-use / idle / wait count)
+	srv.HandleFunc(DBStatsPath, newDBStatsHandler(db))
+
+	// Refresh cron dead man's switch (503 when the cron has stopped running)
+	srv.HandleFunc(HealthzPath, newHealthzHandler(accountUC))
+
+	// Process counters (cron panics) in Prometheus text format
+	srv.HandleFunc(MetricsPath, newMetricsHandler(accountUC))
+
 	return srv
 }
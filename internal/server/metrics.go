@@ -0,0 +1,39 @@
+package server
+
+import (
+	"fmt"
+	nethttp "net/http"
+	"slices"
+	"strings"
+)
+
+// MetricsPath exposes process counters in the Prometheus text exposition format.
+const MetricsPath = "/metrics"
+
+// cronPanicCounter reports how many times each cron job has panicked in this process.
+type cronPanicCounter interface {
+	CronPanicCounts() map[string]int64
+}
+
+// newMetricsHandler returns a handler exposing quotalane_cron_panics_total{job}.
+// Jobs are sorted so the output is stable between scrapes.
+func newMetricsHandler(counter cronPanicCounter) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		counts := counter.CronPanicCounts()
+		jobs := make([]string, 0, len(counts))
+		for job := range counts {
+			jobs = append(jobs, job)
+		}
+		slices.Sort(jobs)
+
+		var b strings.Builder
+		b.WriteString("# HELP quotalane_cron_panics_total Number of recovered panics per cron job.\n")
+		b.WriteString("# TYPE quotalane_cron_panics_total counter\n")
+		for _, job := range jobs {
+			fmt.Fprintf(&b, "quotalane_cron_panics_total{job=%q} %d\n", job, counts[job])
+		}
+		_, _ = w.Write([]byte(b.String()))
+	}
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// adminContextKey is the context key marking a request authenticated with an admin API key
+const adminContextKey contextKey = "is_admin"
+
+// adminOnlyFields 仅管理员可读写的字段，按消息全名 → 字段名索引
+// 非管理员请求中的这些字段会被忽略，响应中的这些字段会被清空
+var adminOnlyFields = map[protoreflect.FullName]map[protoreflect.Name]bool{
+	"api.v1.Account":              {"Notes": true},
+	"api.v1.CreateAccountRequest": {"Notes": true},
+	"api.v1.UpdateAccountRequest": {"Notes": true},
+}
+
+// AdminFields 返回一个管理员字段可见性中间件
+// 使用 adminKeys 中的 API Key 认证的请求被标记为管理员（见 IsAdmin），
+// 其他请求无法写入也无法读取管理员专属字段（如账户 Notes）
+//
+// 需放在 Auth 中间件之后；gRPC 请求直接从 Authorization / X-API-Key 元数据中读取 API Key
+func AdminFields(adminKeys []string) middleware.Middleware {
+	admins := make(map[string]bool, len(adminKeys))
+	for _, key := range adminKeys {
+		if key != "" {
+			admins[key] = true
+		}
+	}
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if admins[apiKeyFromContext(ctx)] {
+				return handler(context.WithValue(ctx, adminContextKey, true), req)
+			}
+
+			if msg, ok := req.(proto.Message); ok {
+				stripAdminFields(msg.ProtoReflect())
+			}
+			reply, err := handler(ctx, req)
+			if msg, ok := reply.(proto.Message); ok && err == nil {
+				stripAdminFields(msg.ProtoReflect())
+			}
+			return reply, err
+		}
+	}
+}
+
+// IsAdmin 判断当前请求是否使用管理员 API Key 认证
+func IsAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminContextKey).(bool)
+	return admin
+}
+
+// apiKeyFromContext 返回 Auth 中间件注入的 API Key，
+// 不存在时（如 gRPC）回退到传输层请求头
+func apiKeyFromContext(ctx context.Context) string {
+	if apiKey, ok := ctx.Value(apiKeyContextKey).(string); ok && apiKey != "" {
+		return apiKey
+	}
+
+	tr, ok := transport.FromServerContext(ctx)
+	if !ok {
+		return ""
+	}
+	header := tr.RequestHeader()
+	if apiKey := strings.TrimSpace(strings.TrimPrefix(header.Get("Authorization"), "Bearer ")); apiKey != "" {
+		return apiKey
+	}
+	return header.Get("X-API-Key")
+}
+
+// stripAdminFields 递归清空消息及其嵌套消息中的管理员专属字段
+func stripAdminFields(m protoreflect.Message) {
+	if !m.IsValid() {
+		return
+	}
+
+	hidden := adminOnlyFields[m.Descriptor().FullName()]
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case hidden[fd.Name()]:
+			m.Clear(fd)
+		case fd.IsList() && fd.Message() != nil:
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				stripAdminFields(list.Get(i).Message())
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				stripAdminFields(mv.Message())
+				return true
+			})
+		case fd.Message() != nil && !fd.IsMap():
+			stripAdminFields(v.Message())
+		}
+		return true
+	})
+}
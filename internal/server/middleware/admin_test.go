@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	v1 "QuotaLane/api/v1"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// headerCarrier adapts http.Header to transport.Header for tests
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string      { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string)      { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Add(key, value string)      { http.Header(hc).Add(key, value) }
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// fakeTransport is a minimal gRPC-like server transport carrying request headers
+type fakeTransport struct {
+	header headerCarrier
+}
+
+func (t *fakeTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (t *fakeTransport) Endpoint() string                { return "" }
+func (t *fakeTransport) Operation() string               { return "/api.v1.AccountService/GetAccount" }
+func (t *fakeTransport) RequestHeader() transport.Header { return t.header }
+func (t *fakeTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func newAccountWithNotes() *v1.Account {
+	return &v1.Account{Id: 1, Name: "acct", Notes: "customer requested lower limits, ticket #123"}
+}
+
+func TestAdminFields_AdminSeesNotes(t *testing.T) {
+	mw := AdminFields([]string{"sk-admin"})
+	ctx := context.WithValue(context.Background(), apiKeyContextKey, "sk-admin")
+
+	var handlerCtx context.Context
+	var handlerReq *v1.CreateAccountRequest
+	handler := mw(func(ctx context.Context, req interface{}) (interface{}, error) {
+		handlerCtx = ctx
+		handlerReq = req.(*v1.CreateAccountRequest)
+		return &v1.CreateAccountResponse{Account: newAccountWithNotes()}, nil
+	})
+
+	reply, err := handler(ctx, &v1.CreateAccountRequest{Name: "acct", Notes: "internal"})
+	require.NoError(t, err)
+
+	assert.True(t, IsAdmin(handlerCtx))
+	assert.Equal(t, "internal", handlerReq.Notes)
+	assert.Equal(t, "customer requested lower limits, ticket #123", reply.(*v1.CreateAccountResponse).Account.Notes)
+}
+
+func TestAdminFields_NonAdminNotesHidden(t *testing.T) {
+	mw := AdminFields([]string{"sk-admin"})
+	ctx := context.WithValue(context.Background(), apiKeyContextKey, "sk-user")
+
+	notes := "internal"
+	var handlerCtx context.Context
+	var handlerReq *v1.UpdateAccountRequest
+	handler := mw(func(ctx context.Context, req interface{}) (interface{}, error) {
+		handlerCtx = ctx
+		handlerReq = req.(*v1.UpdateAccountRequest)
+		return &v1.UpdateAccountResponse{Account: newAccountWithNotes()}, nil
+	})
+
+	reply, err := handler(ctx, &v1.UpdateAccountRequest{Id: 1, Notes: &notes})
+	require.NoError(t, err)
+
+	assert.False(t, IsAdmin(handlerCtx))
+	assert.Nil(t, handlerReq.Notes, "non-admin must not be able to write notes")
+	account := reply.(*v1.UpdateAccountResponse).Account
+	assert.Empty(t, account.Notes)
+	assert.Equal(t, "acct", account.Name, "other fields are untouched")
+}
+
+func TestAdminFields_NonAdminListHidesNotes(t *testing.T) {
+	mw := AdminFields([]string{"sk-admin"})
+
+	handler := mw(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &v1.ListAccountsResponse{
+			Accounts: []*v1.Account{newAccountWithNotes(), newAccountWithNotes()},
+			Total:    2,
+		}, nil
+	})
+
+	reply, err := handler(context.Background(), &v1.ListAccountsRequest{Page: 1, PageSize: 20})
+	require.NoError(t, err)
+
+	resp := reply.(*v1.ListAccountsResponse)
+	for _, account := range resp.Accounts {
+		assert.Empty(t, account.Notes)
+	}
+	assert.Equal(t, int32(2), resp.Total)
+}
+
+func TestAdminFields_TransportHeader(t *testing.T) {
+	mw := AdminFields([]string{"sk-admin"})
+
+	handler := mw(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &v1.GetAccountResponse{Account: newAccountWithNotes()}, nil
+	})
+
+	tests := []struct {
+		name      string
+		header    http.Header
+		wantNotes bool
+	}{
+		{name: "bearer admin key", header: http.Header{"Authorization": {"Bearer sk-admin"}}, wantNotes: true},
+		{name: "x-api-key admin key", header: http.Header{"X-Api-Key": {"sk-admin"}}, wantNotes: true},
+		{name: "non-admin key", header: http.Header{"Authorization": {"Bearer sk-user"}}, wantNotes: false},
+		{name: "no key", header: http.Header{}, wantNotes: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := transport.NewServerContext(context.Background(), &fakeTransport{header: headerCarrier(tt.header)})
+
+			reply, err := handler(ctx, &v1.GetAccountRequest{Id: 1})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantNotes, reply.(*v1.GetAccountResponse).Account.Notes != "")
+		})
+	}
+}
+
+func TestAdminFields_NoAdminKeysConfigured(t *testing.T) {
+	mw := AdminFields(nil)
+	ctx := context.WithValue(context.Background(), apiKeyContextKey, "")
+
+	handler := mw(func(ctx context.Context, req interface{}) (interface{}, error) {
+		assert.False(t, IsAdmin(ctx))
+		return &v1.GetAccountResponse{Account: newAccountWithNotes()}, nil
+	})
+
+	reply, err := handler(ctx, &v1.GetAccountRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Empty(t, reply.(*v1.GetAccountResponse).Account.Notes)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	pkglog "QuotaLane/pkg/log"
+	"QuotaLane/pkg/openai"
 
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport"
@@ -25,13 +26,14 @@ func Logging(logger *pkglog.LogHelper) middleware.Middleware {
 			startTime := time.Now()
 
 			var (
-				method    string
-				path      string
-				ip        string
-				userAgent string
-				requestID string
-				keyName   string
-				accountID string
+				method        string
+				path          string
+				ip            string
+				userAgent     string
+				requestID     string
+				correlationID string
+				keyName       string
+				accountID     string
 			)
 
 			// 提取请求信息
@@ -59,6 +61,7 @@ func Logging(logger *pkglog.LogHelper) middleware.Middleware {
 					if requestID == "" {
 						requestID = pkglog.GenerateRequestID()
 					}
+					correlationID = httpReq.Header.Get("X-Correlation-ID")
 
 					// 尝试从其他中间件（如 Auth）提取的信息
 					// 这些信息可能在 Context 中已经存在
@@ -72,6 +75,14 @@ func Logging(logger *pkglog.LogHelper) middleware.Middleware {
 			// 将 Request Context 注入到 Context 中
 			// 这样后续的所有日志调用都可以自动提取这些信息
 			ctx = pkglog.WithRequestContext(ctx, requestID, keyName, "", accountID)
+			if ip != "" {
+				pkglog.SetMetadata(ctx, pkglog.MetadataClientIP, ip)
+			}
+			// 关联 ID 透传到上游 Provider 的验证/刷新请求（仅白名单内的 Header 会被发送）
+			ctx = openai.WithPassthroughHeaders(ctx, map[string]string{
+				"X-Request-Id":     requestID,
+				"X-Correlation-Id": correlationID,
+			})
 
 			// 执行实际的处理逻辑
 			reply, err := handler(ctx, req)
@@ -2,13 +2,18 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	v1 "QuotaLane/api/v1"
 	"QuotaLane/internal/biz"
+	"QuotaLane/internal/data"
 	"QuotaLane/internal/service/oauth"
+	pkgerrors "QuotaLane/pkg/errors"
 
+	kerrors "github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/log"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -47,7 +52,7 @@ func (s *AccountService) CreateAccount(ctx context.Context, req *v1.CreateAccoun
 	account, err := s.uc.CreateAccount(ctx, req)
 	if err != nil {
 		s.logger.Errorw("failed to create account", "error", err)
-		return nil, err
+		return nil, mapDBError(err)
 	}
 
 	return &v1.CreateAccountResponse{
@@ -62,7 +67,11 @@ func (s *AccountService) ListAccounts(ctx context.Context, req *v1.ListAccountsR
 	resp, err := s.uc.ListAccounts(ctx, req)
 	if err != nil {
 		s.logger.Errorw("failed to list accounts", "error", err)
-		return nil, err
+		return nil, mapDBError(err)
+	}
+
+	if err := applyAccountFieldMask(req.FieldMask, resp.Accounts...); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	return resp, nil
@@ -75,7 +84,11 @@ func (s *AccountService) GetAccount(ctx context.Context, req *v1.GetAccountReque
 	account, err := s.uc.GetAccount(ctx, req.Id)
 	if err != nil {
 		s.logger.Errorw("failed to get account", "id", req.Id, "error", err)
-		return nil, err
+		return nil, mapDBError(err)
+	}
+
+	if err := applyAccountFieldMask(req.FieldMask, account); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	return &v1.GetAccountResponse{
@@ -90,7 +103,7 @@ func (s *AccountService) UpdateAccount(ctx context.Context, req *v1.UpdateAccoun
 	account, err := s.uc.UpdateAccount(ctx, req)
 	if err != nil {
 		s.logger.Errorw("failed to update account", "id", req.Id, "error", err)
-		return nil, err
+		return nil, mapDBError(err)
 	}
 
 	return &v1.UpdateAccountResponse{
@@ -104,7 +117,7 @@ func (s *AccountService) DeleteAccount(ctx context.Context, req *v1.DeleteAccoun
 
 	if err := s.uc.DeleteAccount(ctx, req.Id); err != nil {
 		s.logger.Errorw("failed to delete account", "id", req.Id, "error", err)
-		return nil, err
+		return nil, mapDBError(err)
 	}
 
 	return &v1.DeleteAccountResponse{
@@ -128,7 +141,7 @@ func (s *AccountService) RefreshToken(ctx context.Context, req *v1.RefreshTokenR
 		return &v1.RefreshTokenResponse{
 			Success: false,
 			Message: err.Error(),
-		}, err
+		}, mapDBError(err)
 	}
 
 	// Fetch updated account to get new expires_at
@@ -149,17 +162,49 @@ func (s *AccountService) RefreshToken(ctx context.Context, req *v1.RefreshTokenR
 	}, nil
 }
 
+// RefreshAccount refreshes one account's token immediately, dispatching on its provider.
+func (s *AccountService) RefreshAccount(ctx context.Context, req *v1.RefreshAccountRequest) (*v1.RefreshAccountResponse, error) {
+	s.logger.Infow("RefreshAccount called", "account_id", req.Id)
+
+	expiresAt, err := s.uc.RefreshAccount(ctx, req.Id)
+	if err != nil {
+		s.logger.Errorw("failed to refresh account", "account_id", req.Id, "error", err)
+		return nil, mapDBError(err)
+	}
+
+	return &v1.RefreshAccountResponse{
+		ExpiresAt: timestamppb.New(expiresAt),
+	}, nil
+}
+
 // TestAccount tests account connectivity and health.
-// Supports multiple provider types: OpenAI Responses, Claude Console, etc.
+// Dispatches to the provider's registered validator (see biz.ProviderValidator).
 func (s *AccountService) TestAccount(ctx context.Context, req *v1.TestAccountRequest) (*v1.TestAccountResponse, error) {
+	return s.runAccountTest(ctx, req.Id, func(v1.TestAccountStage, string) error { return nil })
+}
+
+// testProgressFunc receives the stage events of an account test.
+// Returning an error aborts the test (e.g. the streaming client went away).
+type testProgressFunc func(stage v1.TestAccountStage, message string) error
+
+// runAccountTest runs the account test shared by TestAccount and TestAccountStream,
+// reporting each stage before it starts. The DONE stage is left to the caller.
+func (s *AccountService) runAccountTest(ctx context.Context, id int64, progress testProgressFunc) (*v1.TestAccountResponse, error) {
 	startTime := time.Now()
 
-	// 获取账户信息以确定 Provider 类型
-	account, err := s.uc.GetAccount(ctx, req.Id)
+	if err := progress(v1.TestAccountStage_TEST_ACCOUNT_STAGE_CONNECTING, "Loading account"); err != nil {
+		return nil, err
+	}
+
+	// 获取账户信息以确定 Provider 类型（只读取一次，代理检查与验证共用）
+	test, err := s.uc.StartAccountTest(ctx, id)
 	if err != nil {
 		s.logger.Errorw("failed to get account for testing",
-			"id", req.Id,
+			"id", id,
 			"error", err)
+		if errors.Is(err, biz.ErrAccountNotFound) {
+			return nil, mapDBError(err)
+		}
 		return &v1.TestAccountResponse{
 			Success:        false,
 			Message:        fmt.Sprintf("Failed to get account: %v", err),
@@ -167,46 +212,63 @@ func (s *AccountService) TestAccount(ctx context.Context, req *v1.TestAccountReq
 			ResponseTimeMs: 0,
 		}, nil
 	}
+	account := test.Account()
 
-	var testErr error
-	var message string
-
-	// 根据 Provider 类型调用对应的验证方法
-	switch account.Provider {
-	case v1.AccountProvider_OPENAI_RESPONSES:
-		// OpenAI Responses: 调用 ValidateOpenAIResponsesAccount
-		testErr = s.uc.ValidateOpenAIResponsesAccount(ctx, req.Id)
-		if testErr == nil {
-			message = "OpenAI Responses account test passed"
-		} else {
-			message = fmt.Sprintf("OpenAI Responses account test failed: %v", testErr)
-		}
+	// Providers not enabled in this deployment cannot be validated
+	if err := s.uc.CheckProviderEnabled(data.ProviderFromProto(account.Provider)); err != nil {
+		return nil, mapDBError(err)
+	}
 
-	case v1.AccountProvider_CLAUDE_CONSOLE, v1.AccountProvider_CLAUDE_OFFICIAL:
-		// Claude: 调用 RefreshClaudeToken（Story 2.2 已实现）
-		testErr = s.uc.RefreshClaudeToken(ctx, req.Id)
-		if testErr == nil {
-			message = "Claude account test passed (token refreshed)"
-		} else {
-			message = fmt.Sprintf("Claude account test failed: %v", testErr)
-		}
+	// 解析生效的代理配置（metadata 无效时直接判定失败，Provider 调用同样会失败）
+	cfg, err := test.EffectiveConfig()
+	if err != nil {
+		return &v1.TestAccountResponse{
+			Success:        false,
+			Message:        fmt.Sprintf("Proxy check failed: %v", err),
+			HealthScore:    account.HealthScore,
+			ResponseTimeMs: 0,
+		}, nil
+	}
+	proxyMessage := "Direct connection (no proxy)"
+	if cfg.ProxyUrl != "" {
+		proxyMessage = fmt.Sprintf("Using proxy %s", cfg.ProxyUrl)
+	}
+	if err := progress(v1.TestAccountStage_TEST_ACCOUNT_STAGE_PROXY_CHECK, proxyMessage); err != nil {
+		return nil, err
+	}
 
-	default:
+	// 按 Provider 分发到注册的验证实现（API Key 账户调用验证接口，OAuth 账户执行一次 Token 刷新）
+	provider := data.ProviderFromProto(account.Provider)
+	if !test.Supported() {
 		// 其他类型暂不支持
-		message = fmt.Sprintf("该账户类型暂不支持健康检查: %s", account.Provider.String())
 		return &v1.TestAccountResponse{
 			Success:        false,
-			Message:        message,
+			Message:        fmt.Sprintf("该账户类型暂不支持健康检查: %s", account.Provider.String()),
 			HealthScore:    0,
 			ResponseTimeMs: 0,
 		}, nil
 	}
 
+	if err := progress(v1.TestAccountStage_TEST_ACCOUNT_STAGE_PROVIDER_CALL, fmt.Sprintf("Validating %s credentials", provider)); err != nil {
+		return nil, err
+	}
+	result, testErr := test.Validate(ctx)
+
+	var message string
+	switch {
+	case testErr != nil:
+		message = fmt.Sprintf("%s account test failed: %v", provider, testErr)
+	case result.Method == biz.ValidationMethodTokenRefresh:
+		message = fmt.Sprintf("%s account test passed (token refreshed)", provider)
+	default:
+		message = fmt.Sprintf("%s account test passed", provider)
+	}
+
 	// 测试完成后，重新获取账户信息（健康分数可能已更新）
-	updatedAccount, err := s.uc.GetAccount(ctx, req.Id)
+	updatedAccount, err := s.uc.GetAccount(ctx, id)
 	if err != nil {
 		s.logger.Warnw("failed to get updated account after test",
-			"id", req.Id,
+			"id", id,
 			"error", err)
 		// 使用旧的账户信息
 		updatedAccount = account
@@ -229,7 +291,7 @@ func (s *AccountService) TestAccount(ctx context.Context, req *v1.TestAccountReq
 	}
 
 	s.logger.Infow("account test completed",
-		"id", req.Id,
+		"id", id,
 		"provider", account.Provider.String(),
 		"success", testErr == nil,
 		"response_time_ms", responseTimeMs)
@@ -252,6 +314,9 @@ func (s *AccountService) GenerateOAuthURL(ctx context.Context, req *v1.GenerateO
 	resp, err := s.oauthRegistry.GenerateAuthURL(ctx, req)
 	if err != nil {
 		s.logger.Errorw("failed to generate OAuth URL", "error", err, "provider", req.Provider)
+		if se := kerrors.FromError(err); se.Code == http.StatusTooManyRequests {
+			return nil, status.Error(codes.ResourceExhausted, se.Message)
+		}
 		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to generate OAuth URL: %v", err))
 	}
 
@@ -279,6 +344,34 @@ func (s *AccountService) ExchangeOAuthCode(ctx context.Context, req *v1.Exchange
 	return resp, nil
 }
 
+// GetOAuthSessionStatus returns the provider and remaining lifetime of an OAuth authorization session
+// so a UI can show how long the user has left; unknown or expired sessions return NotFound.
+func (s *AccountService) GetOAuthSessionStatus(ctx context.Context, req *v1.GetOAuthSessionStatusRequest) (*v1.GetOAuthSessionStatusResponse, error) {
+	s.logger.Debugw("GetOAuthSessionStatus called", "session_id", req.SessionId)
+
+	if req.SessionId == "" {
+		return nil, statusError(codes.InvalidArgument, "session_id is required")
+	}
+
+	session, err := s.uc.GetOAuthSessionStatus(ctx, req.SessionId)
+	if err != nil {
+		if errors.Is(err, biz.ErrOAuthSessionNotFound) {
+			return nil, kerrors.NotFound(ReasonOAuthSessionNotFound, "OAuth session not found or expired").WithCause(err)
+		}
+		s.logger.Errorw("failed to get OAuth session status", "error", err, "session_id", req.SessionId)
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to get OAuth session status: %v", err))
+	}
+
+	return &v1.GetOAuthSessionStatusResponse{
+		SessionId:        req.SessionId,
+		Provider:         data.ProviderToProto(session.Provider),
+		Valid:            session.TTL > 0,
+		RemainingSeconds: int64(session.TTL / time.Second),
+		ExpiresAt:        timestamppb.New(time.Now().Add(session.TTL)),
+		CreatedAt:        timestamppb.New(session.CreatedAt),
+	}, nil
+}
+
 // PollOAuthStatus 轮询 OAuth 授权状态（Device Flow 预留接口）
 func (s *AccountService) PollOAuthStatus(ctx context.Context, req *v1.PollOAuthStatusRequest) (*v1.PollOAuthStatusResponse, error) {
 	s.logger.Infow("PollOAuthStatus called", "session_id", req.SessionId)
@@ -323,6 +416,25 @@ func (s *AccountService) ResetHealthScore(ctx context.Context, req *v1.ResetHeal
 	}, nil
 }
 
+// ProbeAccount runs one validation against an account regardless of its circuit breaker.
+func (s *AccountService) ProbeAccount(ctx context.Context, req *v1.ProbeAccountRequest) (*v1.ProbeAccountResponse, error) {
+	s.logger.Infow("ProbeAccount called", "account_id", req.Id)
+
+	result, err := s.uc.ProbeAccount(ctx, req.Id)
+	if err != nil {
+		s.logger.Errorw("failed to probe account", "account_id", req.Id, "error", err)
+		return nil, mapDBError(err)
+	}
+
+	return &v1.ProbeAccountResponse{
+		Success:             result.Success,
+		Message:             result.Error,
+		PreviousHealthScore: int32(result.PreviousHealthScore), // #nosec G115 -- health score is clamped to 0-100
+		HealthScore:         int32(result.HealthScore),         // #nosec G115 -- health score is clamped to 0-100
+		IsCircuitBroken:     result.CircuitBroken,
+	}, nil
+}
+
 // ========== Story 2.6: 账户组管理 RPC 实现 ==========
 
 // CreateAccountGroup creates a new account group (admin operation).
@@ -331,9 +443,15 @@ func (s *AccountService) CreateAccountGroup(ctx context.Context, req *v1.CreateA
 
 	// TODO: Add admin permission check
 
-	group, err := s.uc.GetAccountGroupUseCase().CreateAccountGroup(ctx, req.Name, req.Description, req.Priority, req.AccountIds)
+	// Members, initial group limits and selection strategy are written in one transaction
+	group, err := s.uc.GetAccountGroupUseCase().CreateAccountGroupWithLimits(ctx, req.Name, req.Description, req.Priority, req.AccountIds,
+		req.RpmLimit, req.TpmLimit, req.ConcurrencyLimit, req.SelectionStrategy)
 	if err != nil {
 		s.logger.Errorw("failed to create account group", "name", req.Name, "error", err)
+		var validationErr *biz.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to create account group: %v", err))
 	}
 
@@ -344,9 +462,9 @@ func (s *AccountService) CreateAccountGroup(ctx context.Context, req *v1.CreateA
 
 // ListAccountGroups retrieves a paginated list of account groups.
 func (s *AccountService) ListAccountGroups(ctx context.Context, req *v1.ListAccountGroupsRequest) (*v1.ListAccountGroupsResponse, error) {
-	s.logger.Debugw("ListAccountGroups called", "page", req.Page, "page_size", req.PageSize)
+	s.logger.Debugw("ListAccountGroups called", "page", req.Page, "page_size", req.PageSize, "include_member_counts", req.IncludeMemberCounts)
 
-	groups, total, err := s.uc.GetAccountGroupUseCase().ListAccountGroups(ctx, req.Page, req.PageSize)
+	groups, total, err := s.uc.GetAccountGroupUseCase().ListAccountGroups(ctx, req.Page, req.PageSize, req.IncludeMemberCounts)
 	if err != nil {
 		s.logger.Errorw("failed to list account groups", "error", err)
 		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to list account groups: %v", err))
@@ -430,6 +548,44 @@ func (s *AccountService) UpdateAccountGroup(ctx context.Context, req *v1.UpdateA
 		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to update account group: %v", err))
 	}
 
+	// Rate limits are optional: unset fields keep their current values
+	if req.RpmLimit != nil || req.TpmLimit != nil {
+		current, err := s.uc.GetAccountGroupUseCase().GetAccountGroup(ctx, req.Id)
+		if err != nil {
+			s.logger.Errorw("failed to get account group", "id", req.Id, "error", err)
+			return nil, status.Error(codes.Internal, fmt.Sprintf("failed to get account group: %v", err))
+		}
+		rpmLimit, tpmLimit := current.RpmLimit, current.TpmLimit
+		if req.RpmLimit != nil {
+			rpmLimit = req.GetRpmLimit()
+		}
+		if req.TpmLimit != nil {
+			tpmLimit = req.GetTpmLimit()
+		}
+		if err := s.uc.GetAccountGroupUseCase().SetAccountGroupRateLimits(ctx, req.Id, rpmLimit, tpmLimit); err != nil {
+			s.logger.Errorw("failed to set account group rate limits", "id", req.Id, "error", err)
+			return nil, status.Error(codes.Internal, fmt.Sprintf("failed to set account group rate limits: %v", err))
+		}
+	}
+
+	if req.ConcurrencyLimit != nil {
+		if err := s.uc.GetAccountGroupUseCase().SetAccountGroupConcurrencyLimit(ctx, req.Id, req.GetConcurrencyLimit()); err != nil {
+			s.logger.Errorw("failed to set account group concurrency limit", "id", req.Id, "error", err)
+			return nil, status.Error(codes.Internal, fmt.Sprintf("failed to set account group concurrency limit: %v", err))
+		}
+	}
+
+	if req.SelectionStrategy != nil {
+		if err := s.uc.GetAccountGroupUseCase().SetAccountGroupStrategy(ctx, req.Id, req.GetSelectionStrategy()); err != nil {
+			s.logger.Errorw("failed to set account group selection strategy", "id", req.Id, "error", err)
+			var validationErr *biz.ValidationError
+			if errors.As(err, &validationErr) {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			return nil, status.Error(codes.Internal, fmt.Sprintf("failed to set account group selection strategy: %v", err))
+		}
+	}
+
 	// Get updated group
 	group, err := s.uc.GetAccountGroupUseCase().GetAccountGroup(ctx, req.Id)
 	if err != nil {
@@ -444,13 +600,27 @@ func (s *AccountService) UpdateAccountGroup(ctx context.Context, req *v1.UpdateA
 
 // DeleteAccountGroup soft deletes an account group (admin operation).
 func (s *AccountService) DeleteAccountGroup(ctx context.Context, req *v1.DeleteAccountGroupRequest) (*v1.DeleteAccountGroupResponse, error) {
-	s.logger.Infow("DeleteAccountGroup called", "id", req.Id)
+	s.logger.Infow("DeleteAccountGroup called", "id", req.Id, "reassign_to", req.ReassignToGroupId)
 
 	// TODO: Add admin permission check
 
-	err := s.uc.GetAccountGroupUseCase().DeleteAccountGroup(ctx, req.Id)
+	groupUC := s.uc.GetAccountGroupUseCase()
+	var err error
+	if req.ReassignToGroupId > 0 {
+		err = groupUC.DeleteAccountGroupWithReassign(ctx, req.Id, req.ReassignToGroupId)
+	} else {
+		err = groupUC.DeleteAccountGroup(ctx, req.Id)
+	}
 	if err != nil {
 		s.logger.Errorw("failed to delete account group", "id", req.Id, "error", err)
+		var validationErr *biz.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		var dbErr *pkgerrors.DatabaseError
+		if errors.As(err, &dbErr) && dbErr.Type == pkgerrors.ErrorTypeNotFound {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
 		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to delete account group: %v", err))
 	}
 
@@ -463,13 +633,18 @@ func (s *AccountService) DeleteAccountGroup(ctx context.Context, req *v1.DeleteA
 // convertAccountGroupToProto converts biz.AccountGroup to Proto message.
 func convertAccountGroupToProto(group *biz.AccountGroup) *v1.AccountGroup {
 	return &v1.AccountGroup{
-		Id:          group.ID,
-		Name:        group.Name,
-		Description: group.Description,
-		Priority:    group.Priority,
-		AccountIds:  group.AccountIDs,
-		CreatedAt:   timestamppb.New(group.CreatedAt),
-		UpdatedAt:   timestamppb.New(group.UpdatedAt),
+		Id:                group.ID,
+		Name:              group.Name,
+		Description:       group.Description,
+		Priority:          group.Priority,
+		RpmLimit:          group.RpmLimit,
+		TpmLimit:          group.TpmLimit,
+		ConcurrencyLimit:  group.ConcurrencyLimit,
+		SelectionStrategy: group.Strategy,
+		AccountIds:        group.AccountIDs,
+		MemberCount:       group.MemberCount,
+		CreatedAt:         timestamppb.New(group.CreatedAt),
+		UpdatedAt:         timestamppb.New(group.UpdatedAt),
 	}
 }
 
@@ -511,3 +686,206 @@ func (s *AccountService) ListAccountsByTags(ctx context.Context, req *v1.ListAcc
 		Total:    total, // Note: This is the count of returned accounts, not total matching records
 	}, nil
 }
+
+// GetAccountHealthHistory returns the most recent validation/refresh results of an account.
+func (s *AccountService) GetAccountHealthHistory(ctx context.Context, req *v1.GetAccountHealthHistoryRequest) (*v1.GetAccountHealthHistoryResponse, error) {
+	s.logger.Debugw("GetAccountHealthHistory called", "account_id", req.Id, "limit", req.Limit)
+
+	entries, err := s.uc.GetAccountHealthHistory(ctx, req.Id, int(req.Limit))
+	if err != nil {
+		s.logger.Errorw("failed to get account health history", "account_id", req.Id, "error", err)
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to get account health history: %v", err))
+	}
+
+	protoEntries := make([]*v1.HealthHistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		protoEntries = append(protoEntries, &v1.HealthHistoryEntry{
+			Timestamp: timestamppb.New(entry.Timestamp),
+			Source:    entry.Source,
+			Success:   entry.Success,
+			Error:     entry.Error,
+			LatencyMs: entry.LatencyMs,
+		})
+	}
+
+	return &v1.GetAccountHealthHistoryResponse{
+		Entries: protoEntries,
+	}, nil
+}
+
+// GetAccountStatusHistory returns the lifecycle status transitions of an account (created → validating → active | error).
+func (s *AccountService) GetAccountStatusHistory(ctx context.Context, req *v1.GetAccountStatusHistoryRequest) (*v1.GetAccountStatusHistoryResponse, error) {
+	s.logger.Debugw("GetAccountStatusHistory called", "account_id", req.Id, "limit", req.Limit)
+
+	transitions, err := s.uc.GetAccountStatusHistory(ctx, req.Id, int(req.Limit))
+	if err != nil {
+		s.logger.Errorw("failed to get account status history", "account_id", req.Id, "error", err)
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to get account status history: %v", err))
+	}
+
+	protoTransitions := make([]*v1.StatusTransition, 0, len(transitions))
+	for _, transition := range transitions {
+		protoTransitions = append(protoTransitions, transition.ToProto())
+	}
+
+	return &v1.GetAccountStatusHistoryResponse{
+		Transitions: protoTransitions,
+	}, nil
+}
+
+// GetCapacity returns aggregate RPM/TPM capacity of active accounts for a provider.
+func (s *AccountService) GetCapacity(ctx context.Context, req *v1.GetCapacityRequest) (*v1.GetCapacityResponse, error) {
+	s.logger.Debugw("GetCapacity called", "provider", req.Provider)
+
+	capacity, err := s.uc.GetCapacity(ctx, req.Provider)
+	if err != nil {
+		s.logger.Errorw("failed to get capacity", "provider", req.Provider, "error", err)
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to get capacity: %v", err))
+	}
+
+	return &v1.GetCapacityResponse{
+		Capacity: capacity,
+	}, nil
+}
+
+// BatchGetAccountStats returns the current RPM/TPM/concurrency usage and remaining daily token quota
+// of many accounts in one call.
+func (s *AccountService) BatchGetAccountStats(ctx context.Context, req *v1.BatchGetAccountStatsRequest) (*v1.BatchGetAccountStatsResponse, error) {
+	s.logger.Debugw("BatchGetAccountStats called", "count", len(req.Ids))
+
+	stats, err := s.uc.BatchGetAccountStats(ctx, req.Ids)
+	if err != nil {
+		s.logger.Errorw("failed to get account stats", "count", len(req.Ids), "error", err)
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to get account stats: %v", err))
+	}
+
+	return &v1.BatchGetAccountStatsResponse{
+		Stats: stats,
+	}, nil
+}
+
+// GetFleetHealth returns aggregate health of the account fleet, optionally filtered by provider and group.
+func (s *AccountService) GetFleetHealth(ctx context.Context, req *v1.GetFleetHealthRequest) (*v1.GetFleetHealthResponse, error) {
+	s.logger.Debugw("GetFleetHealth called", "provider", req.Provider, "group_id", req.GroupId)
+
+	health, err := s.uc.GetFleetHealth(ctx, req.Provider, req.GroupId)
+	if err != nil {
+		s.logger.Errorw("failed to get fleet health", "provider", req.Provider, "group_id", req.GroupId, "error", err)
+		return nil, mapDBError(err)
+	}
+
+	return &v1.GetFleetHealthResponse{
+		Health: health,
+	}, nil
+}
+
+// GetExpiryDistribution returns OAuth account counts per token-expiry bucket, optionally filtered by provider.
+func (s *AccountService) GetExpiryDistribution(ctx context.Context, req *v1.GetExpiryDistributionRequest) (*v1.GetExpiryDistributionResponse, error) {
+	s.logger.Debugw("GetExpiryDistribution called", "providers", req.Providers)
+
+	dist, err := s.uc.GetExpiryDistribution(ctx, req.Providers)
+	if err != nil {
+		s.logger.Errorw("failed to get expiry distribution", "providers", req.Providers, "error", err)
+		return nil, mapDBError(err)
+	}
+
+	return &v1.GetExpiryDistributionResponse{
+		Distribution: dist,
+	}, nil
+}
+
+// CheckModelAllowed reports whether an account may serve the given model (per its metadata.allowed_models
+// allowlist) and the upstream request timeout the router should apply to it, in milliseconds.
+// A missing account is returned as NotFound and connection failures as Unavailable (see mapDBError).
+func (s *AccountService) CheckModelAllowed(ctx context.Context, req *v1.CheckModelAllowedRequest) (*v1.CheckModelAllowedResponse, error) {
+	s.logger.Debugw("CheckModelAllowed called", "account_id", req.Id, "model", req.Model)
+
+	allowed, timeout, err := s.uc.CheckModelAllowed(ctx, req.Id, req.Model)
+	if err != nil {
+		s.logger.Errorw("failed to check model allowlist", "account_id", req.Id, "model", req.Model, "error", err)
+		return nil, mapDBError(err)
+	}
+
+	return &v1.CheckModelAllowedResponse{
+		Allowed:          allowed,
+		RequestTimeoutMs: int32(timeout.Milliseconds()), // #nosec G115 -- bounded by MaxRequestTimeoutMs / provider defaults
+	}, nil
+}
+
+// DrainAccount stops an account from receiving new requests while in-flight ones finish.
+func (s *AccountService) DrainAccount(ctx context.Context, req *v1.DrainAccountRequest) (*v1.DrainAccountResponse, error) {
+	s.logger.Infow("DrainAccount called", "account_id", req.Id)
+
+	drainStatus, err := s.uc.DrainAccount(ctx, req.Id)
+	if err != nil {
+		s.logger.Errorw("failed to drain account", "account_id", req.Id, "error", err)
+		return nil, mapDBError(err)
+	}
+
+	return &v1.DrainAccountResponse{
+		Status: drainStatus,
+	}, nil
+}
+
+// GetDrainStatus reports whether a draining account has finished its in-flight requests.
+func (s *AccountService) GetDrainStatus(ctx context.Context, req *v1.GetDrainStatusRequest) (*v1.GetDrainStatusResponse, error) {
+	s.logger.Debugw("GetDrainStatus called", "account_id", req.Id)
+
+	drainStatus, err := s.uc.GetDrainStatus(ctx, req.Id)
+	if err != nil {
+		s.logger.Errorw("failed to get drain status", "account_id", req.Id, "error", err)
+		return nil, mapDBError(err)
+	}
+
+	return &v1.GetDrainStatusResponse{
+		Status: drainStatus,
+	}, nil
+}
+
+// GetEffectiveConfig previews the limits, proxy and timeout that apply to an account and their sources.
+func (s *AccountService) GetEffectiveConfig(ctx context.Context, req *v1.GetEffectiveConfigRequest) (*v1.GetEffectiveConfigResponse, error) {
+	s.logger.Debugw("GetEffectiveConfig called", "account_id", req.Id)
+
+	cfg, err := s.uc.GetEffectiveConfig(ctx, req.Id)
+	if err != nil {
+		s.logger.Errorw("failed to get effective config", "account_id", req.Id, "error", err)
+		return nil, mapDBError(err)
+	}
+
+	return &v1.GetEffectiveConfigResponse{
+		Config: cfg,
+	}, nil
+}
+
+// ImportOAuthAccount imports an OAuth account from an exported encrypted refresh token and refreshes it immediately.
+// A failed refresh still creates the account, in ERROR status, and is reported in the response.
+func (s *AccountService) ImportOAuthAccount(ctx context.Context, req *v1.ImportOAuthAccountRequest) (*v1.ImportOAuthAccountResponse, error) {
+	s.logger.Infow("ImportOAuthAccount called", "provider", req.Provider, "name", req.Name)
+
+	resp, err := s.uc.ImportOAuthAccount(ctx, req)
+	if err != nil {
+		s.logger.Errorw("failed to import OAuth account", "provider", req.Provider, "name", req.Name, "error", err)
+		return nil, mapDBError(err)
+	}
+
+	s.logger.Infow("OAuth account imported", "account_id", resp.AccountId, "status", resp.Status)
+	return resp, nil
+}
+
+// GetRuntimeConfig returns the effective non-secret runtime settings.
+func (s *AccountService) GetRuntimeConfig(ctx context.Context, req *v1.GetRuntimeConfigRequest) (*v1.GetRuntimeConfigResponse, error) {
+	s.logger.Debug("GetRuntimeConfig called")
+
+	return &v1.GetRuntimeConfigResponse{
+		Config: s.uc.GetRuntimeConfig(ctx),
+	}, nil
+}
+
+// ListProviderCapabilities returns the capability matrix of every provider.
+func (s *AccountService) ListProviderCapabilities(ctx context.Context, req *v1.ListProviderCapabilitiesRequest) (*v1.ListProviderCapabilitiesResponse, error) {
+	s.logger.Debug("ListProviderCapabilities called")
+
+	return &v1.ListProviderCapabilitiesResponse{
+		Providers: s.uc.ListProviderCapabilities(),
+	}, nil
+}
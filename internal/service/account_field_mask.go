@@ -0,0 +1,66 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "QuotaLane/api/v1"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// secretAccountFields are never returned when a field mask is applied,
+// even if the mask explicitly requests them.
+var secretAccountFields = map[protoreflect.Name]bool{
+	"ApiKeyEncrypted":    true,
+	"OAuthDataEncrypted": true,
+}
+
+// applyAccountFieldMask keeps only the fields listed in mask on each account.
+// Paths are Account field names matched case-insensitively (e.g. "Name", "HealthScore").
+// Secret fields are always cleared. A nil or empty mask leaves accounts untouched.
+// Returns an error for paths that do not name an Account field.
+func applyAccountFieldMask(mask *fieldmaskpb.FieldMask, accounts ...*v1.Account) error {
+	if len(mask.GetPaths()) == 0 {
+		return nil
+	}
+
+	fields := (&v1.Account{}).ProtoReflect().Descriptor().Fields()
+	keep := make(map[protoreflect.Name]bool, len(mask.GetPaths()))
+	for _, path := range mask.GetPaths() {
+		fd := findAccountField(fields, path)
+		if fd == nil {
+			return fmt.Errorf("unknown field in field mask: %s", path)
+		}
+		if secretAccountFields[fd.Name()] {
+			continue
+		}
+		keep[fd.Name()] = true
+	}
+
+	for _, account := range accounts {
+		if account == nil {
+			continue
+		}
+		m := account.ProtoReflect()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			if !keep[fd.Name()] {
+				m.Clear(fd)
+			}
+		}
+	}
+
+	return nil
+}
+
+// findAccountField looks up an Account field by name, ignoring case.
+func findAccountField(fields protoreflect.FieldDescriptors, path string) protoreflect.FieldDescriptor {
+	for i := 0; i < fields.Len(); i++ {
+		if strings.EqualFold(string(fields.Get(i).Name()), path) {
+			return fields.Get(i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	v1 "QuotaLane/api/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestAccountStream runs the same account test as TestAccount but streams a progress event
+// before each stage (connecting, proxy check, provider call), so clients of slow providers
+// see where the test is instead of waiting on a single blocking call. The final DONE event
+// carries the same result TestAccount would return. gRPC only: server streaming has no HTTP mapping.
+func (s *AccountService) TestAccountStream(req *v1.TestAccountRequest, stream v1.AccountService_TestAccountStreamServer) error {
+	// Streaming RPCs bypass the unary middleware chain, so validate the request here
+	if req.GetId() <= 0 {
+		return status.Error(codes.InvalidArgument, "id must be greater than 0")
+	}
+
+	send := func(stage v1.TestAccountStage, message string) error {
+		return stream.Send(&v1.TestAccountProgress{Stage: stage, Message: message})
+	}
+
+	result, err := s.runAccountTest(stream.Context(), req.GetId(), send)
+	if err != nil {
+		return err
+	}
+
+	return stream.Send(&v1.TestAccountProgress{
+		Stage:   v1.TestAccountStage_TEST_ACCOUNT_STAGE_DONE,
+		Message: result.GetMessage(),
+		Result:  result,
+	})
+}
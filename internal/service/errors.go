@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+
+	"QuotaLane/internal/biz"
+	pkgerrors "QuotaLane/pkg/errors"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReasonDatabaseUnavailable is the error reason for transient database connection failures.
+const ReasonDatabaseUnavailable = "DATABASE_UNAVAILABLE"
+
+// ReasonAccountNotFound is the error reason for requests on an account that does not exist.
+const ReasonAccountNotFound = "ACCOUNT_NOT_FOUND"
+
+// ReasonOAuthSessionNotFound is the error reason for OAuth sessions that are unknown or expired.
+const ReasonOAuthSessionNotFound = "OAUTH_SESSION_NOT_FOUND"
+
+// ReasonAccountMissingProvider is the error reason for requests on a stored account without a provider.
+const ReasonAccountMissingProvider = "ACCOUNT_MISSING_PROVIDER"
+
+// mapDBError maps request validation failures to codes.InvalidArgument (HTTP 400) with error details,
+// missing accounts to codes.NotFound (HTTP 404), accounts stored without a
+// provider to codes.Internal (HTTP 500), and database connection failures (e.g. connection pool exhaustion) to codes.Unavailable (HTTP 503) with a
+// retryable indicator in the error metadata. Other errors are returned unchanged.
+func mapDBError(err error) error {
+	if field, ok := biz.ValidationField(err); ok {
+		return validationStatus(err, field)
+	}
+	if errors.Is(err, biz.ErrAccountNotFound) {
+		return kerrors.NotFound(ReasonAccountNotFound, err.Error()).WithCause(err)
+	}
+	if errors.Is(err, biz.ErrAccountMissingProvider) {
+		return kerrors.InternalServer(ReasonAccountMissingProvider, err.Error()).WithCause(err)
+	}
+
+	var dbErr *pkgerrors.DatabaseError
+	if !errors.As(err, &dbErr) || dbErr.Type != pkgerrors.ErrorTypeConnectionError {
+		return err
+	}
+
+	return kerrors.ServiceUnavailable(ReasonDatabaseUnavailable, "database temporarily unavailable, please retry").
+		WithMetadata(map[string]string{"retryable": "true"}).
+		WithCause(err)
+}
+
+// validationStatus converts a biz validation error into an InvalidArgument status carrying
+// google.rpc.ErrorInfo (reason code, field) and google.rpc.BadRequest (the offending field) details.
+// The ErrorInfo detail keeps the reason when Kratos maps the status back for HTTP clients.
+func validationStatus(err error, field string) error {
+	kerr := kerrors.FromError(err)
+	st, detailErr := status.New(codes.InvalidArgument, kerr.Message).WithDetails(
+		&errdetails.ErrorInfo{Reason: kerr.Reason, Metadata: kerr.Metadata},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: field, Description: kerr.Message},
+		}},
+	)
+	if detailErr != nil {
+		return kerr
+	}
+	return st.Err()
+}
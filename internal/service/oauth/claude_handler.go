@@ -99,18 +99,8 @@ func (h *ClaudeHandler) ExchangeCode(ctx context.Context, req *v1.ExchangeOAuthC
 		return nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
 
-	// Map status
-	var protoStatus v1.AccountStatus
-	switch accountStatus {
-	case "active":
-		protoStatus = v1.AccountStatus_ACCOUNT_ACTIVE
-	case "created":
-		protoStatus = v1.AccountStatus_ACCOUNT_CREATED
-	case "error":
-		protoStatus = v1.AccountStatus_ACCOUNT_ERROR
-	default:
-		protoStatus = v1.AccountStatus_ACCOUNT_STATUS_UNSPECIFIED
-	}
+	// Report the persisted status; the message tells callers to re-check non-active accounts
+	protoStatus, message := exchangeResult(accountStatus)
 
 	h.logger.Infow("OAuth code exchanged successfully",
 		"account_id", accountID,
@@ -126,7 +116,7 @@ func (h *ClaudeHandler) ExchangeCode(ctx context.Context, req *v1.ExchangeOAuthC
 		AccountId:      accountID,
 		AccountName:    accountName,
 		Status:         protoStatus,
-		Message:        "OAuth account created successfully",
+		Message:        message,
 		TokenExpiresAt: tokenExpiresAtProto,
 	}, nil
 }
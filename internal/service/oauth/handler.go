@@ -3,6 +3,7 @@ package oauth
 
 import (
 	"context"
+	"fmt"
 
 	v1 "QuotaLane/api/v1"
 )
@@ -19,3 +20,25 @@ type Handler interface {
 	// ProviderType returns the provider type this handler supports.
 	ProviderType() v1.AccountProvider
 }
+
+// exchangeResult maps the status the account was actually stored with to the API status and a
+// response message. Only active accounts report plain success; any other status (e.g. created when
+// activation did not complete) is returned as-is with a hint so callers re-check the account.
+func exchangeResult(status string) (v1.AccountStatus, string) {
+	var protoStatus v1.AccountStatus
+	switch status {
+	case "active":
+		return v1.AccountStatus_ACCOUNT_ACTIVE, "OAuth account created successfully"
+	case "created":
+		protoStatus = v1.AccountStatus_ACCOUNT_CREATED
+	case "validating":
+		protoStatus = v1.AccountStatus_ACCOUNT_VALIDATING
+	case "error":
+		protoStatus = v1.AccountStatus_ACCOUNT_ERROR
+	case "inactive":
+		protoStatus = v1.AccountStatus_ACCOUNT_INACTIVE
+	default:
+		protoStatus = v1.AccountStatus_ACCOUNT_STATUS_UNSPECIFIED
+	}
+	return protoStatus, fmt.Sprintf("OAuth account created but not active (status: %s); re-check it with GetAccount before use", status)
+}
@@ -0,0 +1,35 @@
+package oauth
+
+import (
+	"testing"
+
+	v1 "QuotaLane/api/v1"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeResult(t *testing.T) {
+	tests := []struct {
+		status     string
+		want       v1.AccountStatus
+		wantActive bool
+	}{
+		{"active", v1.AccountStatus_ACCOUNT_ACTIVE, true},
+		{"created", v1.AccountStatus_ACCOUNT_CREATED, false},
+		{"validating", v1.AccountStatus_ACCOUNT_VALIDATING, false},
+		{"error", v1.AccountStatus_ACCOUNT_ERROR, false},
+		{"bogus", v1.AccountStatus_ACCOUNT_STATUS_UNSPECIFIED, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			status, message := exchangeResult(tt.status)
+			assert.Equal(t, tt.want, status)
+			if tt.wantActive {
+				assert.Equal(t, "OAuth account created successfully", message)
+			} else {
+				assert.Contains(t, message, "not active (status: "+tt.status+")")
+			}
+		})
+	}
+}
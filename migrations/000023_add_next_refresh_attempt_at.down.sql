@@ -0,0 +1,4 @@
+-- QuotaLane: Rollback token refresh backoff from api_accounts
+
+ALTER TABLE `api_accounts`
+DROP COLUMN `next_refresh_attempt_at`;
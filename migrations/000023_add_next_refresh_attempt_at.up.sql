@@ -0,0 +1,5 @@
+-- QuotaLane: Add per-account token refresh backoff to api_accounts
+-- Description: 记录 Token 刷新失败后的下次重试时间,定时任务跳过仍处于退避期的账户
+
+ALTER TABLE `api_accounts`
+ADD COLUMN `next_refresh_attempt_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'Token 刷新下次允许尝试的时间(NULL 表示不退避)' AFTER `oauth_expires_at`;
@@ -0,0 +1,3 @@
+-- QuotaLane: Drop refresh_runs table
+
+DROP TABLE IF EXISTS `refresh_runs`;
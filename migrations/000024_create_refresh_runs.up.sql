@@ -0,0 +1,15 @@
+-- QuotaLane: Create refresh_runs table
+-- Description: Token 批量刷新任务运行记录表，每个周期写入一条汇总（总数、成功、失败、耗时）
+
+CREATE TABLE IF NOT EXISTS `refresh_runs` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '运行记录ID',
+    `job` VARCHAR(50) NOT NULL COMMENT '任务名称（auto_refresh / expiring_refresh）',
+    `started_at` TIMESTAMP(3) NOT NULL COMMENT '开始时间',
+    `total` INT NOT NULL DEFAULT 0 COMMENT '处理账户总数',
+    `success` INT NOT NULL DEFAULT 0 COMMENT '刷新成功数',
+    `failure` INT NOT NULL DEFAULT 0 COMMENT '刷新失败数',
+    `duration_ms` BIGINT NOT NULL DEFAULT 0 COMMENT '耗时（毫秒）',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_started_at` (`started_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Token 批量刷新运行记录表';
@@ -0,0 +1,4 @@
+-- QuotaLane: Rollback internal ops notes from api_accounts
+
+ALTER TABLE `api_accounts`
+DROP COLUMN `notes`;
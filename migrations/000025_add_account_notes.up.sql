@@ -0,0 +1,5 @@
+-- QuotaLane: Add internal ops notes to api_accounts
+-- Description: 运维内部备注,与面向客户的 description 分开存储,仅管理员可见
+
+ALTER TABLE `api_accounts`
+ADD COLUMN `notes` TEXT NULL COMMENT '运维内部备注(仅管理员可见)' AFTER `description`;
@@ -0,0 +1,4 @@
+-- QuotaLane: Rollback health check rotation timestamp from api_accounts
+
+ALTER TABLE `api_accounts`
+DROP COLUMN `last_checked_at`;
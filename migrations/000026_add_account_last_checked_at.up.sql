@@ -0,0 +1,5 @@
+-- QuotaLane: Add health check rotation timestamp to api_accounts
+-- Description: 记录账户最近一次健康检查时间,健康检查定时任务按该字段轮换抽样(优先检查最久未检查的账户)
+
+ALTER TABLE `api_accounts`
+ADD COLUMN `last_checked_at` TIMESTAMP NULL DEFAULT NULL COMMENT '最近一次健康检查时间(NULL 表示从未检查)' AFTER `next_refresh_attempt_at`;
@@ -0,0 +1,4 @@
+-- QuotaLane: Rollback drain flag from api_accounts
+
+ALTER TABLE `api_accounts`
+DROP COLUMN `is_draining`;
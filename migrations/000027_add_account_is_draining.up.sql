@@ -0,0 +1,5 @@
+-- QuotaLane: Add drain flag to api_accounts
+-- Description: 账户下线前先进入 draining 状态:不再被选中处理新请求,进行中的请求自然完成后即可安全停用
+
+ALTER TABLE `api_accounts`
+ADD COLUMN `is_draining` BOOLEAN NOT NULL DEFAULT FALSE COMMENT '是否排空中(不接收新请求)' AFTER `is_circuit_broken`;
@@ -0,0 +1,4 @@
+-- QuotaLane: Rollback re-authorization flag from api_accounts
+
+ALTER TABLE `api_accounts`
+DROP COLUMN `needs_reauth`;
@@ -0,0 +1,5 @@
+-- QuotaLane: Add re-authorization flag to api_accounts
+-- Description: refresh token 永久失效(invalid_grant)的账户需要运维重新授权,标记后不再参与定时刷新扫描
+
+ALTER TABLE `api_accounts`
+ADD COLUMN `needs_reauth` BOOLEAN NOT NULL DEFAULT FALSE COMMENT '是否需要重新授权(refresh token 已失效)' AFTER `is_draining`;
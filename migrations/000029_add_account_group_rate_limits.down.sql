@@ -0,0 +1,5 @@
+-- QuotaLane: Rollback group-wide rate limits from account_groups
+
+ALTER TABLE `account_groups`
+DROP COLUMN `tpm_limit`,
+DROP COLUMN `rpm_limit`;
@@ -0,0 +1,6 @@
+-- QuotaLane: Add group-wide rate limits to account_groups
+-- Description: 组级 RPM/TPM 上限(客户总配额),与单账户限流独立生效;0 表示不限制
+
+ALTER TABLE `account_groups`
+ADD COLUMN `rpm_limit` INT NOT NULL DEFAULT 0 COMMENT '组内账户合计每分钟请求数上限(0=不限制)' AFTER `priority`,
+ADD COLUMN `tpm_limit` INT NOT NULL DEFAULT 0 COMMENT '组内账户合计每分钟Token数上限(0=不限制)' AFTER `rpm_limit`;
@@ -0,0 +1,5 @@
+-- Revert status ENUM: move accounts still being validated back to 'created' first
+UPDATE `api_accounts` SET `status` = 'created' WHERE `status` = 'validating';
+
+ALTER TABLE `api_accounts`
+  MODIFY COLUMN `status` ENUM('created','active','inactive','error') NOT NULL DEFAULT 'active' COMMENT '账户状态';
@@ -0,0 +1,5 @@
+-- QuotaLane: Add 'validating' status to api_accounts
+-- Description: 统一账户创建生命周期 created → validating → active | error,凭证验证进行中的账户处于 validating 状态
+
+ALTER TABLE `api_accounts`
+  MODIFY COLUMN `status` ENUM('created','validating','active','inactive','error') NOT NULL DEFAULT 'active' COMMENT '账户状态';
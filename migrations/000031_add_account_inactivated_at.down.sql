@@ -0,0 +1,5 @@
+-- QuotaLane: Rollback soft-delete timestamp from api_accounts
+
+ALTER TABLE `api_accounts`
+DROP INDEX `idx_status_inactivated_at`,
+DROP COLUMN `inactivated_at`;
@@ -0,0 +1,8 @@
+-- QuotaLane: Add soft-delete timestamp to api_accounts
+-- Description: 记录账户被软删除(置为 inactive)的时间,保留期清理任务据此硬删除超期账户;已软删除的账户以 updated_at 回填
+
+ALTER TABLE `api_accounts`
+ADD COLUMN `inactivated_at` DATETIME NULL COMMENT '软删除时间(非 inactive 时为 NULL)' AFTER `status`,
+ADD INDEX `idx_status_inactivated_at` (`status`, `inactivated_at`);
+
+UPDATE `api_accounts` SET `inactivated_at` = `updated_at` WHERE `status` = 'inactive';
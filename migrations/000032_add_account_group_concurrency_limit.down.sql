@@ -0,0 +1,4 @@
+-- QuotaLane: Rollback group-wide concurrency limit from account_groups
+
+ALTER TABLE `account_groups`
+DROP COLUMN `concurrency_limit`;
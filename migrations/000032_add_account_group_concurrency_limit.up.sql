@@ -0,0 +1,5 @@
+-- QuotaLane: Add group-wide concurrency limit to account_groups
+-- Description: 组级并发上限(组内账户合计在途请求数),与单账户并发限制同时生效;0 表示不限制
+
+ALTER TABLE `account_groups`
+ADD COLUMN `concurrency_limit` INT NOT NULL DEFAULT 0 COMMENT '组内账户合计并发请求数上限(0=不限制)' AFTER `tpm_limit`;
@@ -0,0 +1,4 @@
+-- QuotaLane: Rollback daily token quota from api_accounts
+
+ALTER TABLE `api_accounts`
+DROP COLUMN `daily_token_limit`;
@@ -0,0 +1,5 @@
+-- QuotaLane: Add daily token quota to api_accounts
+-- Description: 每个账户每日(UTC)可消耗的 Token 总数上限,用于控制花费;0 表示不限制
+
+ALTER TABLE `api_accounts`
+ADD COLUMN `daily_token_limit` INT NOT NULL DEFAULT 0 COMMENT '每日(UTC)Token 总数上限(0=不限制)' AFTER `tpm_limit`;
@@ -0,0 +1,4 @@
+-- QuotaLane: Rollback account priority from api_accounts
+
+ALTER TABLE `api_accounts`
+DROP COLUMN `account_priority`;
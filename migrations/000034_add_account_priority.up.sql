@@ -0,0 +1,5 @@
+-- QuotaLane: Add account priority to api_accounts
+-- Description: 账户优先级,组内选择时负载与健康分相同的账户中优先选择较高者;默认 0
+
+ALTER TABLE `api_accounts`
+ADD COLUMN `account_priority` INT NOT NULL DEFAULT 0 COMMENT '账户优先级(负载与健康相同时数字越大越优先,默认0)' AFTER `daily_token_limit`;
@@ -0,0 +1,4 @@
+-- QuotaLane: Rollback account selection strategy from account_groups
+
+ALTER TABLE `account_groups`
+DROP COLUMN `selection_strategy`;
@@ -0,0 +1,5 @@
+-- QuotaLane: Add account selection strategy to account_groups
+-- Description: 组内账户选择策略(least_loaded/weighted_random/health_weighted/round_robin);空字符串表示默认 least_loaded
+
+ALTER TABLE `account_groups`
+ADD COLUMN `selection_strategy` VARCHAR(32) NOT NULL DEFAULT '' COMMENT '组内账户选择策略(空=least_loaded)' AFTER `concurrency_limit`;
@@ -10,8 +10,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 )
 
+// AADCiphertextPrefix 标识 EncryptWithAAD 生成的密文（Base64 字母表不含 ':'，与旧版密文不冲突）
+const AADCiphertextPrefix = "v2:"
+
 var (
 	// ErrInvalidKeySize 密钥长度无效错误
 	ErrInvalidKeySize = errors.New("encryption key must be 32 bytes (256 bits)")
@@ -19,6 +23,8 @@ var (
 	ErrInvalidCiphertext = errors.New("invalid ciphertext: too short or malformed")
 	// ErrDecryptionFailed 解密失败错误
 	ErrDecryptionFailed = errors.New("decryption failed: authentication failed")
+	// ErrMissingAAD 密文为旧版格式（未绑定 AAD）错误
+	ErrMissingAAD = errors.New("ciphertext is not bound to associated data")
 )
 
 // AESCrypto AES-256-GCM 加密服务
@@ -45,72 +51,136 @@ func (a *AESCrypto) Encrypt(plaintext string) (string, error) {
 		return "", nil // 空字符串直接返回
 	}
 
-	// 创建 AES cipher
-	block, err := aes.NewCipher(a.key)
+	sealed, err := a.seal([]byte(plaintext), nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create AES cipher: %w", err)
+		return "", err
 	}
 
-	// 创建 GCM mode
-	gcm, err := cipher.NewGCM(block)
+	// Base64 编码
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 使用 AES-256-GCM 解密密文
+// ciphertext 为 Base64 编码的密文
+func (a *AESCrypto) Decrypt(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil // 空字符串直接返回
+	}
+
+	// Base64 解码
+	decoded, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
-		return "", fmt.Errorf("failed to create GCM: %w", err)
+		return "", fmt.Errorf("failed to decode base64: %w", err)
 	}
 
-	// 生成随机 nonce（12 字节）
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	plaintext, err := a.open(decoded, nil)
+	if err != nil {
+		return "", err
 	}
 
-	// 加密（nonce + ciphertext + tag）
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return string(plaintext), nil
+}
 
-	// Base64 编码
-	encoded := base64.StdEncoding.EncodeToString(ciphertext)
+// EncryptWithAAD 使用 AES-256-GCM 加密明文，并将 aad（附加认证数据）绑定到密文
+// aad 不写入密文，解密时必须提供相同的 aad，否则认证失败（用于把凭证绑定到所属账户等场景）
+// 返回 "v2:" + Base64（nonce + ciphertext + tag），前缀用于区分旧版（无 AAD）密文
+func (a *AESCrypto) EncryptWithAAD(plaintext string, aad []byte) (string, error) {
+	if plaintext == "" {
+		return "", nil // 空字符串直接返回
+	}
+
+	sealed, err := a.seal([]byte(plaintext), aad)
+	if err != nil {
+		return "", err
+	}
 
-	return encoded, nil
+	return AADCiphertextPrefix + base64.StdEncoding.EncodeToString(sealed), nil
 }
 
-// Decrypt 使用 AES-256-GCM 解密密文
-// ciphertext 为 Base64 编码的密文
-func (a *AESCrypto) Decrypt(ciphertext string) (string, error) {
+// DecryptWithAAD 解密 EncryptWithAAD 生成的密文，aad 必须与加密时一致
+// 旧版（无前缀）密文返回 ErrMissingAAD，由调用方决定是否回退到 Decrypt
+func (a *AESCrypto) DecryptWithAAD(ciphertext string, aad []byte) (string, error) {
 	if ciphertext == "" {
 		return "", nil // 空字符串直接返回
 	}
+	if !IsAADCiphertext(ciphertext) {
+		return "", ErrMissingAAD
+	}
 
-	// Base64 解码
-	decoded, err := base64.StdEncoding.DecodeString(ciphertext)
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, AADCiphertextPrefix))
 	if err != nil {
 		return "", fmt.Errorf("failed to decode base64: %w", err)
 	}
 
-	// 创建 AES cipher
-	block, err := aes.NewCipher(a.key)
+	plaintext, err := a.open(decoded, aad)
 	if err != nil {
-		return "", fmt.Errorf("failed to create AES cipher: %w", err)
+		return "", err
 	}
 
-	// 创建 GCM mode
-	gcm, err := cipher.NewGCM(block)
+	return string(plaintext), nil
+}
+
+// IsAADCiphertext 判断密文是否由 EncryptWithAAD 生成（带 "v2:" 前缀）
+// 旧版 Base64 密文不含 ':'，不会被误判
+func IsAADCiphertext(ciphertext string) bool {
+	return strings.HasPrefix(ciphertext, AADCiphertextPrefix)
+}
+
+// seal 加密 plaintext 并返回 nonce + ciphertext + tag
+func (a *AESCrypto) seal(plaintext, aad []byte) ([]byte, error) {
+	gcm, err := a.gcm()
 	if err != nil {
-		return "", fmt.Errorf("failed to create GCM: %w", err)
+		return nil, err
+	}
+
+	// 生成随机 nonce（12 字节）
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// 加密（nonce + ciphertext + tag）
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// open 解密 seal 生成的 nonce + ciphertext + tag 并验证 aad
+func (a *AESCrypto) open(decoded, aad []byte) ([]byte, error) {
+	gcm, err := a.gcm()
+	if err != nil {
+		return nil, err
 	}
 
 	// 验证密文长度（至少包含 nonce + tag）
 	nonceSize := gcm.NonceSize()
 	if len(decoded) < nonceSize {
-		return "", ErrInvalidCiphertext
+		return nil, ErrInvalidCiphertext
 	}
 
 	// 提取 nonce 和 ciphertext
 	nonce, encrypted := decoded[:nonceSize], decoded[nonceSize:]
 
 	// 解密并验证
-	plaintext, err := gcm.Open(nil, nonce, encrypted, nil)
+	plaintext, err := gcm.Open(nil, nonce, encrypted, aad)
 	if err != nil {
-		return "", fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
+		return nil, fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
 	}
 
-	return string(plaintext), nil
+	return plaintext, nil
+}
+
+// gcm 创建 AES-256-GCM AEAD
+func (a *AESCrypto) gcm() (cipher.AEAD, error) {
+	// 创建 AES cipher
+	block, err := aes.NewCipher(a.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+
+	// 创建 GCM mode
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return gcm, nil
 }
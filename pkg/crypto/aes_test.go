@@ -214,6 +214,56 @@ func TestAESCrypto_DecryptWithWrongKey(t *testing.T) {
 	assert.Empty(t, decrypted)
 }
 
+func TestAESCrypto_EncryptDecryptWithAAD(t *testing.T) {
+	key := []byte("12345678901234567890123456789012") // Exactly 32 bytes
+	crypto, err := NewAESCrypto(key)
+	require.NoError(t, err)
+
+	ciphertext, err := crypto.EncryptWithAAD("sk-secret", []byte("account:1"))
+	require.NoError(t, err)
+	assert.True(t, IsAADCiphertext(ciphertext))
+
+	decrypted, err := crypto.DecryptWithAAD(ciphertext, []byte("account:1"))
+	require.NoError(t, err)
+	assert.Equal(t, "sk-secret", decrypted)
+
+	// 空字符串直接返回
+	empty, err := crypto.EncryptWithAAD("", []byte("account:1"))
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestAESCrypto_DecryptWithAAD_Mismatch(t *testing.T) {
+	key := []byte("12345678901234567890123456789012") // Exactly 32 bytes
+	crypto, err := NewAESCrypto(key)
+	require.NoError(t, err)
+
+	ciphertext, err := crypto.EncryptWithAAD("sk-secret", []byte("account:1"))
+	require.NoError(t, err)
+
+	// 不同的 AAD（例如另一个账户）认证失败
+	decrypted, err := crypto.DecryptWithAAD(ciphertext, []byte("account:2"))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+	assert.Empty(t, decrypted)
+
+	// 绑定 AAD 的密文不能当作旧版密文解密
+	_, err = crypto.Decrypt(ciphertext)
+	assert.Error(t, err)
+}
+
+func TestAESCrypto_DecryptWithAAD_LegacyCiphertext(t *testing.T) {
+	key := []byte("12345678901234567890123456789012") // Exactly 32 bytes
+	crypto, err := NewAESCrypto(key)
+	require.NoError(t, err)
+
+	legacy, err := crypto.Encrypt("sk-secret")
+	require.NoError(t, err)
+	assert.False(t, IsAADCiphertext(legacy))
+
+	_, err = crypto.DecryptWithAAD(legacy, []byte("account:1"))
+	assert.ErrorIs(t, err, ErrMissingAAD)
+}
+
 func BenchmarkAESCrypto_Encrypt(b *testing.B) {
 	key := []byte("12345678901234567890123456789012") // Exactly 32 bytes
 	crypto, _ := NewAESCrypto(key)
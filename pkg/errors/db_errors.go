@@ -2,6 +2,9 @@
 package errors
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 
@@ -33,6 +36,24 @@ const (
 	ErrorTypeInvalidValue
 )
 
+// ErrPoolWaitTimeout reports that a context deadline expired while the request was waiting for a
+// free pooled connection. A bare context.DeadlineExceeded is not classified as a connection error:
+// it is as likely a slow query or the caller's own deadline. Use MarkPoolWait to attach it.
+var ErrPoolWaitTimeout = errors.New("timed out waiting for a free database connection")
+
+// MarkPoolWait wraps err with ErrPoolWaitTimeout when it is a context deadline and stats show every
+// pooled connection in use, i.e. the deadline most likely expired while waiting for a connection.
+// Other errors, and deadlines hit while the pool had free connections, are returned unchanged.
+func MarkPoolWait(err error, stats sql.DBStats) error {
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrPoolWaitTimeout) {
+		return err
+	}
+	if stats.MaxOpenConnections <= 0 || stats.InUse < stats.MaxOpenConnections {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrPoolWaitTimeout, err)
+}
+
 // DatabaseError wraps a database error with classification information.
 type DatabaseError struct {
 	Type         DatabaseErrorType
@@ -63,6 +84,8 @@ func (e *DatabaseError) Unwrap() error {
 //   - MySQL 1406 (Data too long) → ErrorTypeDataTooLong
 //   - MySQL 1452 (Foreign key constraint) → ErrorTypeConstraintViolation
 //   - MySQL 1213 (Deadlock) → ErrorTypeDeadlock
+//   - MySQL 1040/1203 (Too many connections) → ErrorTypeConnectionError
+//   - Pool wait timeouts (ErrPoolWaitTimeout), bad/closed connections → ErrorTypeConnectionError
 //   - Connection errors → ErrorTypeConnectionError
 //
 // Example:
@@ -99,6 +122,19 @@ func ClassifyDBError(err error) *DatabaseError {
 		return classifyMySQLError(mysqlErr)
 	}
 
+	// Pool exhaustion surfaces as a context deadline while waiting for a free connection; only
+	// deadlines marked by MarkPoolWait count, a bare deadline may just be a slow query
+	if errors.Is(err, ErrPoolWaitTimeout) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, mysql.ErrInvalidConn) {
+		return &DatabaseError{
+			Type:        ErrorTypeConnectionError,
+			OriginalErr: err,
+			Message:     "database connection error",
+		}
+	}
+
 	// Check for connection errors (common patterns)
 	errMsg := err.Error()
 	if isConnectionError(errMsg) {
@@ -172,6 +208,14 @@ func classifyMySQLError(err *mysql.MySQLError) *DatabaseError {
 			Message:      "deadlock detected",
 		}
 
+	case 1040, 1203: // ER_CON_COUNT_ERROR, ER_TOO_MANY_USER_CONNECTIONS
+		return &DatabaseError{
+			Type:         ErrorTypeConnectionError,
+			OriginalErr:  err,
+			MySQLErrCode: err.Number,
+			Message:      "too many database connections",
+		}
+
 	case 1048: // ER_BAD_NULL_ERROR
 		return &DatabaseError{
 			Type:         ErrorTypeInvalidValue,
@@ -210,6 +254,8 @@ func isConnectionError(errMsg string) bool {
 		"connection lost",
 		"can't connect",
 		"dial tcp",
+		"too many connections",
+		"pool timeout",
 	}
 
 	for _, keyword := range connectionKeywords {
@@ -247,6 +293,12 @@ func contains(str, substr string) bool {
 	return false
 }
 
+// Retryable reports whether the operation may succeed if retried later
+// (connection problems and deadlocks are transient).
+func (e *DatabaseError) Retryable() bool {
+	return e.Type == ErrorTypeConnectionError || e.Type == ErrorTypeDeadlock
+}
+
 // IsDuplicateKeyError checks if the error is a duplicate key constraint violation.
 func IsDuplicateKeyError(err error) bool {
 	dbErr := ClassifyDBError(err)
@@ -276,3 +328,10 @@ func IsDeadlockError(err error) bool {
 	dbErr := ClassifyDBError(err)
 	return dbErr != nil && dbErr.Type == ErrorTypeDeadlock
 }
+
+// IsConnectionError checks if the error is a database connection error
+// (including connection pool exhaustion).
+func IsConnectionError(err error) bool {
+	dbErr := ClassifyDBError(err)
+	return dbErr != nil && dbErr.Type == ErrorTypeConnectionError
+}
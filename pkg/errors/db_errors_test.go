@@ -1,7 +1,11 @@
 package errors
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/go-sql-driver/mysql"
@@ -221,6 +225,94 @@ func TestClassifyDBError_ConnectionError(t *testing.T) {
 	}
 }
 
+func TestClassifyDBError_ConnectionPoolExhausted(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    uint16
+		message string
+	}{
+		{
+			name:    "Pool wait timeout",
+			err:     fmt.Errorf("failed to list accounts: %w", ErrPoolWaitTimeout),
+			message: "database connection error",
+		},
+		{
+			name:    "Bad connection",
+			err:     fmt.Errorf("query failed: %w", driver.ErrBadConn),
+			message: "database connection error",
+		},
+		{
+			name:    "Closed connection",
+			err:     sql.ErrConnDone,
+			message: "database connection error",
+		},
+		{
+			name:    "MySQL too many connections",
+			err:     &mysql.MySQLError{Number: 1040, Message: "Too many connections"},
+			code:    1040,
+			message: "too many database connections",
+		},
+		{
+			name:    "MySQL too many user connections",
+			err:     &mysql.MySQLError{Number: 1203, Message: "User already has more than 'max_user_connections' active connections"},
+			code:    1203,
+			message: "too many database connections",
+		},
+		{
+			name:    "Too many connections message",
+			err:     errors.New("Error 1040: Too many connections"),
+			message: "database connection error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbErr := ClassifyDBError(tt.err)
+
+			assert.NotNil(t, dbErr)
+			assert.Equal(t, ErrorTypeConnectionError, dbErr.Type)
+			assert.Equal(t, tt.code, dbErr.MySQLErrCode)
+			assert.Equal(t, tt.message, dbErr.Message)
+			assert.True(t, dbErr.Retryable())
+			assert.True(t, IsConnectionError(tt.err))
+		})
+	}
+}
+
+func TestClassifyDBError_BareDeadlineIsNotConnectionError(t *testing.T) {
+	err := fmt.Errorf("failed to list accounts: %w", context.DeadlineExceeded)
+
+	dbErr := ClassifyDBError(err)
+	assert.Equal(t, ErrorTypeUnknown, dbErr.Type)
+	assert.False(t, dbErr.Retryable())
+	assert.False(t, IsConnectionError(err))
+}
+
+func TestMarkPoolWait(t *testing.T) {
+	deadline := fmt.Errorf("failed to list accounts: %w", context.DeadlineExceeded)
+	saturated := sql.DBStats{MaxOpenConnections: 10, InUse: 10}
+
+	marked := MarkPoolWait(deadline, saturated)
+	assert.ErrorIs(t, marked, ErrPoolWaitTimeout)
+	assert.ErrorIs(t, marked, context.DeadlineExceeded)
+	assert.True(t, IsConnectionError(marked))
+	assert.Same(t, marked, MarkPoolWait(marked, saturated), "already marked errors are not wrapped twice")
+
+	assert.Same(t, deadline, MarkPoolWait(deadline, sql.DBStats{MaxOpenConnections: 10, InUse: 3}), "free connections: a slow query, not a pool wait")
+	assert.Same(t, deadline, MarkPoolWait(deadline, sql.DBStats{InUse: 50}), "unlimited pool never waits")
+
+	other := errors.New("syntax error")
+	assert.Same(t, other, MarkPoolWait(other, saturated))
+	assert.NoError(t, MarkPoolWait(nil, saturated))
+}
+
+func TestDatabaseError_Retryable(t *testing.T) {
+	assert.True(t, (&DatabaseError{Type: ErrorTypeDeadlock}).Retryable())
+	assert.False(t, (&DatabaseError{Type: ErrorTypeDuplicateKey}).Retryable())
+	assert.False(t, IsConnectionError(errors.New("some random error")))
+}
+
 func TestClassifyDBError_UnknownError(t *testing.T) {
 	err := errors.New("some random error")
 	dbErr := ClassifyDBError(err)
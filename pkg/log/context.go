@@ -12,6 +12,9 @@ type contextKey string
 
 const requestContextKey contextKey = "quotalane_request_context"
 
+// MetadataClientIP 客户端 IP 的元数据键（由 Logging 中间件写入）
+const MetadataClientIP = "client_ip"
+
 // RequestContext 存储请求追踪信息
 // 通过 Context 传递，实现跨函数、跨模块的请求追踪
 type RequestContext struct {
@@ -96,6 +99,17 @@ func GetAccountID(ctx context.Context) string {
 	return GetRequestContext(ctx).AccountID
 }
 
+// GetClientIP 从 Context 中提取客户端 IP
+// 未记录时返回空字符串
+func GetClientIP(ctx context.Context) string {
+	if value, ok := GetMetadata(ctx, MetadataClientIP); ok {
+		if ip, ok := value.(string); ok {
+			return ip
+		}
+	}
+	return ""
+}
+
 // SetMetadata 设置 RequestContext 的元数据
 // 用于在请求处理过程中添加额外的追踪信息
 func SetMetadata(ctx context.Context, key string, value interface{}) {
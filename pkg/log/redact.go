@@ -0,0 +1,161 @@
+package log
+
+import (
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactedValue replaces the value of every redacted field.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactKeys are the keys masked when no redact keys are configured.
+var DefaultRedactKeys = []string{"api_key", "oauth_data", "access_token", "refresh_token", "authorization"}
+
+// redactingCore wraps a zapcore.Core and masks the values of sensitive keys before
+// they reach the encoder, so secrets are hidden no matter which call site logs them.
+type redactingCore struct {
+	zapcore.Core
+	keys map[string]struct{}
+}
+
+// NewRedactingCore wraps core so that fields whose key matches one of keys (case-insensitive)
+// are logged as RedactedValue. Keys nested in map values (e.g. logged metadata) are masked too.
+// An empty keys list falls back to DefaultRedactKeys.
+func NewRedactingCore(core zapcore.Core, keys []string) zapcore.Core {
+	if len(keys) == 0 {
+		keys = DefaultRedactKeys
+	}
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[strings.ToLower(strings.TrimSpace(key))] = struct{}{}
+	}
+	return &redactingCore{Core: core, keys: set}
+}
+
+// With implements zapcore.Core.
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.redact(fields)), keys: c.keys}
+}
+
+// Check implements zapcore.Core; it registers the wrapper rather than the inner core
+// so that Write sees the fields first.
+func (c *redactingCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core.
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, c.redact(fields))
+}
+
+// redact returns fields with sensitive values masked. The input slice is never modified.
+func (c *redactingCore) redact(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, field := range fields {
+		redacted, changed := c.redactField(field)
+		if !changed {
+			if out != nil {
+				out = append(out, field)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, i, len(fields))
+			copy(out, fields[:i])
+		}
+		out = append(out, redacted)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+func (c *redactingCore) redactField(field zapcore.Field) (zapcore.Field, bool) {
+	if c.sensitive(field.Key) {
+		return zapcore.Field{Key: field.Key, Type: zapcore.StringType, String: RedactedValue}, true
+	}
+	if field.Type != zapcore.ReflectType {
+		return field, false
+	}
+	value, changed := c.redactValue(field.Interface)
+	if !changed {
+		return field, false
+	}
+	field.Interface = value
+	return field, true
+}
+
+// redactValue masks sensitive keys inside string-keyed maps, recursing into nested maps
+// and slices. It returns a copy when anything was masked.
+func (c *redactingCore) redactValue(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		var out map[string]interface{}
+		for key, inner := range v {
+			var masked interface{} = RedactedValue
+			changed := true
+			if !c.sensitive(key) {
+				masked, changed = c.redactValue(inner)
+			}
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = make(map[string]interface{}, len(v))
+				for k, val := range v {
+					out[k] = val
+				}
+			}
+			out[key] = masked
+		}
+		if out == nil {
+			return value, false
+		}
+		return out, true
+	case map[string]string:
+		var out map[string]string
+		for key := range v {
+			if !c.sensitive(key) {
+				continue
+			}
+			if out == nil {
+				out = make(map[string]string, len(v))
+				for k, val := range v {
+					out[k] = val
+				}
+			}
+			out[key] = RedactedValue
+		}
+		if out == nil {
+			return value, false
+		}
+		return out, true
+	case []interface{}:
+		var out []interface{}
+		for i, inner := range v {
+			masked, changed := c.redactValue(inner)
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = append([]interface{}(nil), v...)
+			}
+			out[i] = masked
+		}
+		if out == nil {
+			return value, false
+		}
+		return out, true
+	}
+	return value, false
+}
+
+func (c *redactingCore) sensitive(key string) bool {
+	_, ok := c.keys[strings.ToLower(key)]
+	return ok
+}
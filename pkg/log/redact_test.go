@@ -0,0 +1,78 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newRedactingTestLogger returns a logger writing JSON lines to buf through a redacting core.
+func newRedactingTestLogger(buf *bytes.Buffer, keys []string) *zap.Logger {
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+	core := zapcore.NewCore(encoder, zapcore.AddSync(buf), zapcore.DebugLevel)
+	return zap.New(NewRedactingCore(core, keys))
+}
+
+func decodeLogLine(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	return line
+}
+
+func TestRedactingCore_MasksConfiguredKeys(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newRedactingTestLogger(&buf, nil)
+
+	logger.Info("validated",
+		zap.String("api_key", "sk-ant-1234567890abcdef"),
+		zap.String("Authorization", "Bearer secret"),
+		zap.Int64("account_id", 42),
+	)
+
+	line := decodeLogLine(t, &buf)
+	assert.Equal(t, RedactedValue, line["api_key"])
+	assert.Equal(t, RedactedValue, line["Authorization"])
+	assert.Equal(t, float64(42), line["account_id"])
+	assert.NotContains(t, buf.String(), "sk-ant-1234567890abcdef")
+}
+
+func TestRedactingCore_MasksNestedMapKeys(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newRedactingTestLogger(&buf, nil)
+
+	metadata := map[string]interface{}{
+		"region": "us",
+		"oauth_data": map[string]interface{}{
+			"refresh_token": "rt-secret",
+		},
+		"headers": map[string]string{"authorization": "Bearer secret", "x-request-id": "req-1"},
+	}
+	logger.Info("account loaded", zap.Any("metadata", metadata))
+
+	got := decodeLogLine(t, &buf)["metadata"].(map[string]interface{})
+	assert.Equal(t, "us", got["region"])
+	assert.Equal(t, RedactedValue, got["oauth_data"])
+	headers := got["headers"].(map[string]interface{})
+	assert.Equal(t, RedactedValue, headers["authorization"])
+	assert.Equal(t, "req-1", headers["x-request-id"])
+
+	// The caller's map is not modified.
+	assert.Equal(t, "Bearer secret", metadata["headers"].(map[string]string)["authorization"])
+}
+
+func TestRedactingCore_WithFieldsAndCustomKeys(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newRedactingTestLogger(&buf, []string{"session_id"}).With(zap.String("session_id", "abc"))
+
+	logger.Info("request", zap.String("api_key", "not-in-custom-list"))
+
+	line := decodeLogLine(t, &buf)
+	assert.Equal(t, RedactedValue, line["session_id"])
+	assert.Equal(t, "not-in-custom-list", line["api_key"])
+}
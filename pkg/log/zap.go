@@ -109,8 +109,8 @@ func NewZapLogger(cfg *conf.Log) (*zap.Logger, error) {
 		cores = append(cores, fileCore)
 	}
 
-	// Combine all cores using Tee
-	core := zapcore.NewTee(cores...)
+	// Combine all cores using Tee, masking sensitive keys before any of them encodes
+	core := NewRedactingCore(zapcore.NewTee(cores...), cfg.GetRedactKeys())
 
 	// Create logger with caller and stacktrace options
 	logger := zap.New(core,
@@ -0,0 +1,31 @@
+package metadata
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var (
+	// azureResourcePattern matches an Azure resource (custom subdomain) name: 2-64 letters, digits or
+	// hyphens, starting with a letter or digit
+	azureResourcePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9-]{1,63}$`)
+	// azureDeploymentPattern matches an Azure OpenAI deployment name
+	azureDeploymentPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,63}$`)
+	// azureAPIVersionPattern matches a data-plane api-version such as 2024-06-01 or 2024-10-01-preview
+	azureAPIVersionPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(-preview)?$`)
+)
+
+// validateAzure checks that the Azure OpenAI fields can be embedded in the endpoint URL as-is,
+// so a crafted value cannot redirect the API key to another host or path.
+func (m *AccountMetadata) validateAzure() error {
+	if m.AzureResource != "" && !azureResourcePattern.MatchString(m.AzureResource) {
+		return fmt.Errorf("invalid azure_resource: %q", m.AzureResource)
+	}
+	if m.AzureDeployment != "" && !azureDeploymentPattern.MatchString(m.AzureDeployment) {
+		return fmt.Errorf("invalid azure_deployment: %q", m.AzureDeployment)
+	}
+	if m.AzureAPIVersion != "" && !azureAPIVersionPattern.MatchString(m.AzureAPIVersion) {
+		return fmt.Errorf("invalid azure_api_version: %q (want YYYY-MM-DD or YYYY-MM-DD-preview)", m.AzureAPIVersion)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate_Azure(t *testing.T) {
+	tests := []struct {
+		name    string
+		meta    AccountMetadata
+		wantErr string
+	}{
+		{"all set", AccountMetadata{AzureResource: "contoso-eu", AzureDeployment: "gpt-4o.mini_2", AzureAPIVersion: "2024-06-01"}, ""},
+		{"preview api version", AccountMetadata{AzureAPIVersion: "2024-10-01-preview"}, ""},
+		{"resource with host", AccountMetadata{AzureResource: "evil.com/x"}, "invalid azure_resource"},
+		{"resource too short", AccountMetadata{AzureResource: "a"}, "invalid azure_resource"},
+		{"deployment with path", AccountMetadata{AzureDeployment: "../admin"}, "invalid azure_deployment"},
+		{"api version with query", AccountMetadata{AzureAPIVersion: "2024-06-01&x=1"}, "invalid azure_api_version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.meta.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+
+	meta, err := Parse(`{"azure_resource":"contoso","azure_deployment":"gpt-4o"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "contoso", meta.AzureResource)
+	assert.Equal(t, "gpt-4o", meta.AzureDeployment)
+	assert.False(t, meta.IsEmpty())
+}
@@ -0,0 +1,22 @@
+package metadata
+
+import "strings"
+
+// AllowsCategory reports whether the account may serve requests of the given category.
+// An empty allowed_categories list allows every category. A request without a category
+// carries no constraint and is allowed everywhere. Matching is exact, ignoring surrounding
+// whitespace and case.
+func (m *AccountMetadata) AllowsCategory(category string) bool {
+	category = strings.TrimSpace(category)
+	if len(m.AllowedCategories) == 0 || category == "" {
+		return true
+	}
+
+	for _, allowed := range m.AllowedCategories {
+		if strings.EqualFold(strings.TrimSpace(allowed), category) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,41 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowsCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		allowed  []string
+		category string
+		want     bool
+	}{
+		{"empty list allows all", nil, "batch", true},
+		{"empty list allows uncategorized", nil, "", true},
+		{"listed category", []string{"chat", "batch"}, "batch", true},
+		{"case and whitespace insensitive", []string{" Batch "}, "batch", true},
+		{"unlisted category", []string{"batch"}, "chat", false},
+		{"uncategorized request unconstrained", []string{"batch"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &AccountMetadata{AllowedCategories: tt.allowed}
+			assert.Equal(t, tt.want, m.AllowsCategory(tt.category))
+		})
+	}
+}
+
+func TestValidate_AllowedCategories(t *testing.T) {
+	assert.NoError(t, (&AccountMetadata{AllowedCategories: []string{"batch"}}).Validate())
+	assert.Error(t, (&AccountMetadata{AllowedCategories: []string{"batch", " "}}).Validate())
+
+	tooMany := make([]string, 21)
+	for i := range tooMany {
+		tooMany[i] = "category"
+	}
+	assert.Error(t, (&AccountMetadata{AllowedCategories: tooMany}).Validate())
+}
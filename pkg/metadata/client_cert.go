@@ -0,0 +1,83 @@
+package metadata
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+)
+
+// MaskedClientKey replaces the client private key when metadata is returned to API clients.
+const MaskedClientKey = "[ENCRYPTED]"
+
+// HasClientCertificate reports whether the metadata configures an mTLS client certificate.
+func (m *AccountMetadata) HasClientCertificate() bool {
+	return m.ClientCertPEM != ""
+}
+
+// validateClientCertificate checks that the client certificate and key are configured together.
+// A plaintext key is verified against the certificate here; an already encrypted key is verified
+// by the caller after decryption.
+func (m *AccountMetadata) validateClientCertificate() error {
+	hasKey := m.ClientKeyPEM != "" || m.ClientKeyEncrypted != ""
+	if m.ClientCertPEM == "" {
+		if hasKey {
+			return fmt.Errorf("client_cert_pem is required when a client key is set")
+		}
+		return nil
+	}
+	if !hasKey {
+		return fmt.Errorf("client_key_pem is required when client_cert_pem is set")
+	}
+
+	if m.ClientKeyPEM != "" {
+		if _, err := ParseClientCertificate(m.ClientCertPEM, m.ClientKeyPEM); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	block, _ := pem.Decode([]byte(m.ClientCertPEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return fmt.Errorf("invalid client_cert_pem: no PEM certificate found")
+	}
+	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+		return fmt.Errorf("invalid client_cert_pem: %w", err)
+	}
+	return nil
+}
+
+// ParseClientCertificate parses a PEM encoded client certificate and private key into a TLS key pair.
+func ParseClientCertificate(certPEM, keyPEM string) (*tls.Certificate, error) {
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate/key pair: %w", err)
+	}
+	return &cert, nil
+}
+
+// SealClientKey rewrites a metadata JSON object so the plaintext "client_key_pem" is replaced by
+// "client_key_encrypted", keeping all other fields.
+func SealClientKey(jsonStr, encryptedKey string) (string, error) {
+	fields := make(map[string]json.RawMessage)
+	if jsonStr != "" {
+		if err := json.Unmarshal([]byte(jsonStr), &fields); err != nil {
+			return "", fmt.Errorf("failed to parse metadata JSON: %w", err)
+		}
+	}
+
+	raw, err := json.Marshal(encryptedKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal client key: %w", err)
+	}
+	delete(fields, "client_key_pem")
+	fields["client_key_encrypted"] = raw
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal metadata JSON: %w", err)
+	}
+
+	return string(data), nil
+}
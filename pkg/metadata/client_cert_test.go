@@ -0,0 +1,54 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCertPEM = "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+
+func TestValidate_ClientCertificate(t *testing.T) {
+	t.Run("no client certificate", func(t *testing.T) {
+		assert.NoError(t, (&AccountMetadata{}).Validate())
+	})
+
+	t.Run("key without certificate", func(t *testing.T) {
+		err := (&AccountMetadata{ClientKeyEncrypted: "enc"}).Validate()
+		assert.ErrorContains(t, err, "client_cert_pem is required")
+	})
+
+	t.Run("certificate without key", func(t *testing.T) {
+		err := (&AccountMetadata{ClientCertPEM: testCertPEM}).Validate()
+		assert.ErrorContains(t, err, "client_key_pem is required")
+	})
+
+	t.Run("invalid key pair", func(t *testing.T) {
+		err := (&AccountMetadata{ClientCertPEM: testCertPEM, ClientKeyPEM: "not a key"}).Validate()
+		assert.ErrorContains(t, err, "invalid client certificate/key pair")
+	})
+
+	t.Run("invalid certificate with stored key", func(t *testing.T) {
+		err := (&AccountMetadata{ClientCertPEM: "garbage", ClientKeyEncrypted: "enc"}).Validate()
+		assert.ErrorContains(t, err, "invalid client_cert_pem")
+	})
+}
+
+func TestSealClientKey(t *testing.T) {
+	sealed, err := SealClientKey(`{"region":"us-east","client_cert_pem":"cert","client_key_pem":"secret"}`, "enc")
+	require.NoError(t, err)
+
+	assert.NotContains(t, sealed, "secret")
+	assert.JSONEq(t, `{"region":"us-east","client_cert_pem":"cert","client_key_encrypted":"enc"}`, sealed)
+}
+
+func TestMaskSensitive_ClientKey(t *testing.T) {
+	meta := &AccountMetadata{ClientCertPEM: "cert", ClientKeyEncrypted: "enc"}
+
+	masked := meta.MaskSensitive()
+
+	assert.Equal(t, MaskedClientKey, masked.ClientKeyEncrypted)
+	assert.Equal(t, "cert", masked.ClientCertPEM)
+	assert.Equal(t, "enc", meta.ClientKeyEncrypted, "original is not modified")
+}
@@ -0,0 +1,57 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Merge deep-merges the patch metadata JSON object into the base object.
+// Keys only in patch are added, keys in both are overwritten by patch, and keys
+// only in base are preserved. Nested objects are merged recursively; arrays and
+// scalars are replaced as a whole (e.g. "tags" in patch replaces the existing tags).
+// An empty base or patch is treated as an empty object.
+func Merge(baseJSON, patchJSON string) (string, error) {
+	base, err := parseObject(baseJSON)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse existing metadata JSON: %w", err)
+	}
+	patch, err := parseObject(patchJSON)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse metadata patch JSON: %w", err)
+	}
+
+	data, err := json.Marshal(mergeObjects(base, patch))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal metadata JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+// parseObject decodes a JSON object; an empty string yields an empty object.
+func parseObject(jsonStr string) (map[string]interface{}, error) {
+	obj := make(map[string]interface{})
+	if jsonStr == "" {
+		return obj, nil
+	}
+	if err := json.Unmarshal([]byte(jsonStr), &obj); err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		obj = make(map[string]interface{})
+	}
+	return obj, nil
+}
+
+// mergeObjects merges patch into base in place and returns base.
+func mergeObjects(base, patch map[string]interface{}) map[string]interface{} {
+	for key, patchValue := range patch {
+		patchObj, patchIsObj := patchValue.(map[string]interface{})
+		baseObj, baseIsObj := base[key].(map[string]interface{})
+		if patchIsObj && baseIsObj {
+			base[key] = mergeObjects(baseObj, patchObj)
+			continue
+		}
+		base[key] = patchValue
+	}
+	return base
+}
@@ -0,0 +1,54 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name  string
+		base  string
+		patch string
+		want  string
+	}{
+		{
+			"adds and overwrites, preserves untouched keys",
+			`{"region":"us","notes":"old","tags":["prod"]}`,
+			`{"notes":"new","proxy_enabled":true}`,
+			`{"notes":"new","proxy_enabled":true,"region":"us","tags":["prod"]}`,
+		},
+		{
+			"nested objects merge recursively",
+			`{"extra":{"a":1,"b":{"c":2,"d":3}}}`,
+			`{"extra":{"b":{"c":20},"e":5}}`,
+			`{"extra":{"a":1,"b":{"c":20,"d":3},"e":5}}`,
+		},
+		{
+			"arrays are replaced",
+			`{"tags":["prod","eu"]}`,
+			`{"tags":["staging"]}`,
+			`{"tags":["staging"]}`,
+		},
+		{"empty base", "", `{"region":"eu"}`, `{"region":"eu"}`},
+		{"empty patch", `{"region":"eu"}`, "", `{"region":"eu"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Merge(tt.base, tt.patch)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, got)
+		})
+	}
+}
+
+func TestMerge_InvalidJSON(t *testing.T) {
+	_, err := Merge(`{"region":"us"}`, `{invalid`)
+	assert.Error(t, err)
+
+	_, err = Merge(`[1,2]`, `{"region":"us"}`)
+	assert.Error(t, err)
+}
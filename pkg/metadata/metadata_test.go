@@ -240,3 +240,36 @@ func TestIsEmpty(t *testing.T) {
 		assert.False(t, meta.IsEmpty())
 	})
 }
+
+func TestValidate_RequestTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout int32
+		wantErr bool
+	}{
+		{name: "unset uses provider default", timeout: 0},
+		{name: "minimum", timeout: MinRequestTimeoutMs},
+		{name: "maximum", timeout: MaxRequestTimeoutMs},
+		{name: "typical", timeout: 120000},
+		{name: "too small", timeout: 999, wantErr: true},
+		{name: "too large", timeout: MaxRequestTimeoutMs + 1, wantErr: true},
+		{name: "negative", timeout: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&AccountMetadata{RequestTimeoutMs: tt.timeout}).Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "request_timeout_ms out of range")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	meta, err := Parse(`{"request_timeout_ms":90000}`)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(90000), meta.RequestTimeoutMs)
+	assert.False(t, meta.IsEmpty())
+}
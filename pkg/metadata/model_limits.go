@@ -0,0 +1,83 @@
+package metadata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModelLimit overrides the account-level rate limits for one model.
+// A zero field inherits the account-level value.
+type ModelLimit struct {
+	RPM int32 `json:"rpm,omitempty"`
+	TPM int32 `json:"tpm,omitempty"`
+}
+
+// NormalizeModelName returns the canonical form of a model name used for matching
+// model_limits entries and for per-model rate limit counters (trimmed, lower case).
+func NormalizeModelName(model string) string {
+	return strings.ToLower(strings.TrimSpace(model))
+}
+
+// ModelLimitFor returns the model_limits entry for model. Matching is exact,
+// ignoring surrounding whitespace and case (same rules as IsModelAllowed).
+func (m *AccountMetadata) ModelLimitFor(model string) (ModelLimit, bool) {
+	model = NormalizeModelName(model)
+	if model == "" {
+		return ModelLimit{}, false
+	}
+	for name, limit := range m.ModelLimits {
+		if NormalizeModelName(name) == model {
+			return limit, true
+		}
+	}
+	return ModelLimit{}, false
+}
+
+// EffectiveLimits resolves the RPM and TPM limits that apply to requests for model, given the
+// account-level rpmLimit/tpmLimit (<= 0 = unlimited). A listed model is bound by both its own cap and
+// the account-level limit, so the tighter of the two applies; a zero field or an unlisted model
+// leaves the account-level limit. perModel reports whether model has its own entry, i.e. whether it
+// is also counted on a per-model counter in addition to the account's.
+func (m *AccountMetadata) EffectiveLimits(model string, rpmLimit, tpmLimit int32) (rpm, tpm int32, perModel bool) {
+	limit, ok := m.ModelLimitFor(model)
+	if !ok {
+		return rpmLimit, tpmLimit, false
+	}
+	return tighterLimit(limit.RPM, rpmLimit), tighterLimit(limit.TPM, tpmLimit), true
+}
+
+// tighterLimit returns the stricter of a model cap and an account limit (<= 0 = no limit).
+func tighterLimit(modelLimit, accountLimit int32) int32 {
+	if modelLimit <= 0 || (accountLimit > 0 && accountLimit < modelLimit) {
+		return accountLimit
+	}
+	return modelLimit
+}
+
+// validateModelLimits checks model_limits: max 100 entries, names non-empty, max 100 characters
+// and unique after normalization, limits >= 0.
+func (m *AccountMetadata) validateModelLimits() error {
+	if len(m.ModelLimits) > 100 {
+		return fmt.Errorf("too many model_limits: max 100 allowed, got %d", len(m.ModelLimits))
+	}
+
+	seen := make(map[string]string, len(m.ModelLimits))
+	for name, limit := range m.ModelLimits {
+		normalized := NormalizeModelName(name)
+		if normalized == "" {
+			return fmt.Errorf("model_limits has an empty model name")
+		}
+		if len(name) > 100 {
+			return fmt.Errorf("model_limits[%q] model name too long: max 100 characters, got %d", name, len(name))
+		}
+		if other, ok := seen[normalized]; ok {
+			return fmt.Errorf("model_limits[%q] duplicates model_limits[%q]", name, other)
+		}
+		seen[normalized] = name
+		if limit.RPM < 0 || limit.TPM < 0 {
+			return fmt.Errorf("model_limits[%q] limits must be >= 0, got rpm=%d tpm=%d", name, limit.RPM, limit.TPM)
+		}
+	}
+
+	return nil
+}
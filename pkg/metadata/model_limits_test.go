@@ -0,0 +1,47 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectiveLimits(t *testing.T) {
+	meta, err := Parse(`{"model_limits":{"Claude-Opus-4":{"rpm":5,"tpm":20000},"claude-sonnet-4":{"tpm":80000},"claude-haiku-4":{"rpm":500,"tpm":500000}}}`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		model        string
+		wantRPM      int32
+		wantTPM      int32
+		wantPerModel bool
+	}{
+		{"listed model", "claude-opus-4", 5, 20000, true},
+		{"case and whitespace insensitive", " CLAUDE-OPUS-4 ", 5, 20000, true},
+		{"zero field inherits account limit", "claude-sonnet-4", 50, 80000, true},
+		{"account limit still caps a looser model cap", "claude-haiku-4", 50, 100000, true},
+		{"unlisted model falls back", "claude-3-haiku", 50, 100000, false},
+		{"empty model falls back", "", 50, 100000, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rpm, tpm, perModel := meta.EffectiveLimits(tt.model, 50, 100000)
+			assert.Equal(t, tt.wantRPM, rpm)
+			assert.Equal(t, tt.wantTPM, tpm)
+			assert.Equal(t, tt.wantPerModel, perModel)
+		})
+	}
+}
+
+func TestValidate_ModelLimits(t *testing.T) {
+	assert.NoError(t, (&AccountMetadata{ModelLimits: map[string]ModelLimit{"claude-opus-4": {RPM: 5}}}).Validate())
+	assert.Error(t, (&AccountMetadata{ModelLimits: map[string]ModelLimit{" ": {RPM: 5}}}).Validate())
+	assert.Error(t, (&AccountMetadata{ModelLimits: map[string]ModelLimit{"claude-opus-4": {TPM: -1}}}).Validate())
+	assert.Error(t, (&AccountMetadata{ModelLimits: map[string]ModelLimit{
+		"claude-opus-4": {RPM: 5},
+		"Claude-Opus-4": {RPM: 6},
+	}}).Validate())
+}
@@ -0,0 +1,21 @@
+package metadata
+
+import "strings"
+
+// IsModelAllowed reports whether the account may serve the given model.
+// An empty allowed_models list allows every model. Matching is exact,
+// ignoring surrounding whitespace and case.
+func (m *AccountMetadata) IsModelAllowed(model string) bool {
+	if len(m.AllowedModels) == 0 {
+		return true
+	}
+
+	model = strings.TrimSpace(model)
+	for _, allowed := range m.AllowedModels {
+		if strings.EqualFold(strings.TrimSpace(allowed), model) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,40 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsModelAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		model   string
+		want    bool
+	}{
+		{"empty list allows all", nil, "gpt-4o", true},
+		{"listed model", []string{"gpt-4o", "gpt-4o-mini"}, "gpt-4o-mini", true},
+		{"case and whitespace insensitive", []string{" GPT-4o "}, "gpt-4o", true},
+		{"unlisted model", []string{"gpt-4o"}, "o1-preview", false},
+		{"no prefix match", []string{"gpt-4o"}, "gpt-4o-mini", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &AccountMetadata{AllowedModels: tt.allowed}
+			assert.Equal(t, tt.want, m.IsModelAllowed(tt.model))
+		})
+	}
+}
+
+func TestValidate_AllowedModels(t *testing.T) {
+	assert.NoError(t, (&AccountMetadata{AllowedModels: []string{"gpt-4o"}}).Validate())
+	assert.Error(t, (&AccountMetadata{AllowedModels: []string{"gpt-4o", " "}}).Validate())
+
+	tooMany := make([]string, 101)
+	for i := range tooMany {
+		tooMany[i] = "model"
+	}
+	assert.Error(t, (&AccountMetadata{AllowedModels: tooMany}).Validate())
+}
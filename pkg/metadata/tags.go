@@ -0,0 +1,82 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// NormalizeTags trims whitespace, lowercases and removes duplicate tags.
+// Empty tags are dropped; the order of first occurrence is preserved.
+// Example: ["Prod", " prod ", "Team-A"] -> ["prod", "team-a"]
+func NormalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return tags
+	}
+
+	seen := make(map[string]struct{}, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
+
+// NormalizeTags normalizes the metadata tags in place.
+func (m *AccountMetadata) NormalizeTags() {
+	m.Tags = NormalizeTags(m.Tags)
+}
+
+// ValidateTagPattern checks every tag against the allowed pattern.
+// A nil pattern accepts all tags.
+func ValidateTagPattern(tags []string, pattern *regexp.Regexp) error {
+	if pattern == nil {
+		return nil
+	}
+
+	for i, tag := range tags {
+		if !pattern.MatchString(tag) {
+			return fmt.Errorf("tag[%d] %q does not match allowed pattern %s", i, tag, pattern.String())
+		}
+	}
+
+	return nil
+}
+
+// ReplaceTags rewrites the "tags" field of a metadata JSON object, keeping all other fields.
+// Empty tags remove the field.
+func ReplaceTags(jsonStr string, tags []string) (string, error) {
+	fields := make(map[string]json.RawMessage)
+	if jsonStr != "" {
+		if err := json.Unmarshal([]byte(jsonStr), &fields); err != nil {
+			return "", fmt.Errorf("failed to parse metadata JSON: %w", err)
+		}
+	}
+
+	if len(tags) == 0 {
+		delete(fields, "tags")
+	} else {
+		raw, err := json.Marshal(tags)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal tags: %w", err)
+		}
+		fields["tags"] = raw
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal metadata JSON: %w", err)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,58 @@
+package metadata
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{"case and whitespace duplicates", []string{"Prod", "prod", " prod "}, []string{"prod"}},
+		{"preserves first occurrence order", []string{"Team-A", "prod", "TEAM-A"}, []string{"team-a", "prod"}},
+		{"drops blank tags", []string{" ", "", "eu"}, []string{"eu"}},
+		{"nil", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeTags(tt.tags))
+		})
+	}
+}
+
+func TestValidateTagPattern(t *testing.T) {
+	pattern := regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+	assert.NoError(t, ValidateTagPattern([]string{"prod", "team-a"}, pattern))
+	assert.NoError(t, ValidateTagPattern([]string{"anything goes!"}, nil))
+
+	err := ValidateTagPattern([]string{"prod", "team_a"}, pattern)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `tag[1] "team_a" does not match`)
+}
+
+func TestReplaceTags(t *testing.T) {
+	t.Run("keeps other fields", func(t *testing.T) {
+		out, err := ReplaceTags(`{"region":"us-east","tags":["Prod"],"custom":1}`, []string{"prod"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"region":"us-east","tags":["prod"],"custom":1}`, out)
+	})
+
+	t.Run("empty tags removes field", func(t *testing.T) {
+		out, err := ReplaceTags(`{"region":"us-east","tags":[" "]}`, nil)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"region":"us-east"}`, out)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := ReplaceTags(`{invalid`, []string{"prod"})
+		assert.Error(t, err)
+	})
+}
@@ -2,8 +2,11 @@ package oauth
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"QuotaLane/internal/data"
@@ -19,25 +22,47 @@ const (
 
 	// SessionTTL Session 过期时间（10 分钟）
 	SessionTTL = 10 * time.Minute
+
+	// MaxSessionIDAttempts Session ID 冲突时的最大生成次数
+	MaxSessionIDAttempts = 5
 )
 
+// ErrSessionNotFound is returned (wrapped with the session ID) for unknown or expired OAuth sessions.
+var ErrSessionNotFound = errors.New("session not found or expired")
+
+// SessionStatus OAuth Session 的非敏感状态（不包含 code verifier、state 等字段）
+type SessionStatus struct {
+	Provider  data.AccountProvider
+	CreatedAt time.Time
+	TTL       time.Duration // 剩余有效期（取自 Redis 键 TTL）
+}
+
 // OAuthManager OAuth 管理器
 // 负责 Provider 注册、Session 管理、授权 URL 生成、Code 交换
 type OAuthManager struct {
 	providers map[data.AccountProvider]OAuthProvider
-	redis     *redis.Client
+	redis     redis.UniversalClient
 	logger    *log.Helper
+
+	// sessionRand Session ID 随机源，默认 crypto/rand
+	sessionRand io.Reader
 }
 
 // NewOAuthManager 创建 OAuthManager 实例
-func NewOAuthManager(redis *redis.Client, logger log.Logger) *OAuthManager {
+func NewOAuthManager(redis redis.UniversalClient, logger log.Logger) *OAuthManager {
 	return &OAuthManager{
-		providers: make(map[data.AccountProvider]OAuthProvider),
-		redis:     redis,
-		logger:    log.NewHelper(logger),
+		providers:   make(map[data.AccountProvider]OAuthProvider),
+		redis:       redis,
+		logger:      log.NewHelper(logger),
+		sessionRand: rand.Reader,
 	}
 }
 
+// SetSessionRandSource 替换 Session ID 随机源（测试中用于注入固定种子）
+func (m *OAuthManager) SetSessionRandSource(r io.Reader) {
+	m.sessionRand = r
+}
+
 // RegisterProvider 注册 OAuth Provider
 func (m *OAuthManager) RegisterProvider(p OAuthProvider) {
 	m.providers[p.ProviderType()] = p
@@ -57,12 +82,6 @@ func (m *OAuthManager) GenerateAuthURL(ctx context.Context, provider data.Accoun
 		return nil, fmt.Errorf("unsupported OAuth provider: %v", provider)
 	}
 
-	// 生成 Session ID
-	sessionID, err := util.GenerateSessionID()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate session ID: %w", err)
-	}
-
 	// 生成 State（如果未提供）
 	if params.State == "" {
 		state, err := util.GenerateState()
@@ -88,11 +107,14 @@ func (m *OAuthManager) GenerateAuthURL(ctx context.Context, provider data.Accoun
 		RedirectURI:  params.RedirectURI,
 		CreatedAt:    time.Now(),
 		Metadata:     params.Metadata,
+		CreatorIP:    params.CreatorIP,
+		CreatedBy:    params.CreatedBy,
 	}
 
-	// 保存 Session 到 Redis
-	if err := m.SaveSession(ctx, sessionID, session); err != nil {
-		return nil, fmt.Errorf("failed to save session: %w", err)
+	// 生成 Session ID 并保存到 Redis（SET NX，冲突时重新生成）
+	sessionID, err := m.createSession(ctx, session)
+	if err != nil {
+		return nil, err
 	}
 
 	// 填充 SessionID 并返回
@@ -172,13 +194,41 @@ func (m *OAuthManager) SaveSession(ctx context.Context, sessionID string, sessio
 	return nil
 }
 
+// createSession 生成唯一 Session ID 并以 SET NX 保存 Session
+// 极小概率的 ID 冲突不会覆盖已有 Session，而是重新生成 ID
+func (m *OAuthManager) createSession(ctx context.Context, session *OAuthSession) (string, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	for attempt := 1; attempt <= MaxSessionIDAttempts; attempt++ {
+		sessionID, err := util.GenerateSessionIDFrom(m.sessionRand)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate session ID: %w", err)
+		}
+
+		ok, err := m.redis.SetNX(ctx, SessionKeyPrefix+sessionID, data, SessionTTL).Result()
+		if err != nil {
+			return "", fmt.Errorf("failed to save session to Redis: %w", err)
+		}
+		if ok {
+			return sessionID, nil
+		}
+
+		m.logger.Warnf("OAuth session ID collision, regenerating (attempt %d/%d)", attempt, MaxSessionIDAttempts)
+	}
+
+	return "", fmt.Errorf("failed to allocate unique session ID after %d attempts", MaxSessionIDAttempts)
+}
+
 // LoadSession 从 Redis 加载 Session
 func (m *OAuthManager) LoadSession(ctx context.Context, sessionID string) (*OAuthSession, error) {
 	key := SessionKeyPrefix + sessionID
 
 	data, err := m.redis.Get(ctx, key).Result()
 	if err == redis.Nil {
-		return nil, fmt.Errorf("session not found or expired: %s", sessionID)
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
 	} else if err != nil {
 		return nil, fmt.Errorf("failed to load session from Redis: %w", err)
 	}
@@ -191,6 +241,33 @@ func (m *OAuthManager) LoadSession(ctx context.Context, sessionID string) (*OAut
 	return &session, nil
 }
 
+// GetSessionStatus 返回 Session 的 Provider、创建时间与剩余有效期，Session 不存在或已过期时返回 ErrSessionNotFound
+func (m *OAuthManager) GetSessionStatus(ctx context.Context, sessionID string) (*SessionStatus, error) {
+	key := SessionKeyPrefix + sessionID
+
+	pipe := m.redis.Pipeline()
+	getCmd := pipe.Get(ctx, key)
+	ttlCmd := pipe.TTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err == redis.Nil {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load session from Redis: %w", err)
+	}
+
+	// TTL 为 -2 表示键已在两条命令之间过期；Session 总是带 TTL 写入，-1 不应出现
+	ttl := ttlCmd.Val()
+	if ttl <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+
+	var session OAuthSession
+	if err := json.Unmarshal([]byte(getCmd.Val()), &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+
+	return &SessionStatus{Provider: session.Provider, CreatedAt: session.CreatedAt, TTL: ttl}, nil
+}
+
 // DeleteSession 删除 Session
 func (m *OAuthManager) DeleteSession(ctx context.Context, sessionID string) error {
 	key := SessionKeyPrefix + sessionID
@@ -1,13 +1,17 @@
 package oauth
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	mathrand "math/rand"
 	"testing"
 	"time"
 
 	"QuotaLane/internal/data"
+	"QuotaLane/pkg/oauth/util"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
@@ -174,6 +178,77 @@ func TestOAuthManager_GenerateAuthURL(t *testing.T) {
 	})
 }
 
+func TestOAuthManager_GenerateAuthURL_SessionIDCollision(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	manager := NewOAuthManager(rdb, log.DefaultLogger)
+	manager.RegisterProvider(&mockProvider{
+		providerType: data.ProviderClaudeOfficial,
+		authURL:      "https://claude.ai/oauth/authorize",
+		codeVerifier: "new-verifier",
+	})
+	ctx := context.Background()
+
+	// 使用相同种子预先算出第一个 Session ID，并占用该键
+	collidingID, err := util.GenerateSessionIDFrom(mathrand.New(mathrand.NewSource(42)))
+	require.NoError(t, err)
+	existing := &OAuthSession{Provider: data.ProviderCodexCLI, CodeVerifier: "existing-verifier"}
+	require.NoError(t, manager.SaveSession(ctx, collidingID, existing))
+
+	manager.SetSessionRandSource(mathrand.New(mathrand.NewSource(42)))
+
+	resp, err := manager.GenerateAuthURL(ctx, data.ProviderClaudeOfficial, &OAuthParams{
+		State:     "test-state",
+		CreatorIP: "203.0.113.7",
+		CreatedBy: "admin-key",
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, collidingID, resp.SessionID, "colliding session ID must be regenerated")
+
+	// 原有 Session 未被覆盖
+	kept, err := manager.LoadSession(ctx, collidingID)
+	require.NoError(t, err)
+	assert.Equal(t, "existing-verifier", kept.CodeVerifier)
+
+	session, err := manager.LoadSession(ctx, resp.SessionID)
+	require.NoError(t, err)
+	assert.Equal(t, "new-verifier", session.CodeVerifier)
+	assert.Equal(t, "203.0.113.7", session.CreatorIP)
+	assert.Equal(t, "admin-key", session.CreatedBy)
+}
+
+func TestOAuthManager_GenerateAuthURL_SessionIDExhausted(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	manager := NewOAuthManager(rdb, log.DefaultLogger)
+	manager.RegisterProvider(&mockProvider{providerType: data.ProviderClaudeOfficial})
+	ctx := context.Background()
+
+	// 全零随机源每次生成相同 ID
+	collidingID, err := util.GenerateSessionIDFrom(bytes.NewReader(make([]byte, 32)))
+	require.NoError(t, err)
+	require.NoError(t, manager.SaveSession(ctx, collidingID, &OAuthSession{}))
+
+	manager.SetSessionRandSource(zeroReader{})
+
+	_, err = manager.GenerateAuthURL(ctx, data.ProviderClaudeOfficial, &OAuthParams{State: "s"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to allocate unique session ID")
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
 func TestOAuthManager_ExchangeCode(t *testing.T) {
 	rdb := setupTestRedis(t)
 	logger := log.DefaultLogger
@@ -279,6 +354,45 @@ func TestOAuthManager_RefreshToken(t *testing.T) {
 	})
 }
 
+func TestOAuthManager_GetSessionStatus(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	manager := NewOAuthManager(rdb, log.DefaultLogger)
+	ctx := context.Background()
+
+	createdAt := time.Now().Truncate(time.Second)
+	require.NoError(t, manager.SaveSession(ctx, "status-test", &OAuthSession{
+		Provider:     data.ProviderCodexCLI,
+		CodeVerifier: "secret-verifier",
+		CreatedAt:    createdAt,
+	}))
+
+	t.Run("valid session reports positive TTL", func(t *testing.T) {
+		mr.FastForward(3 * time.Minute)
+
+		status, err := manager.GetSessionStatus(ctx, "status-test")
+		require.NoError(t, err)
+		assert.Equal(t, data.ProviderCodexCLI, status.Provider)
+		assert.True(t, status.CreatedAt.Equal(createdAt))
+		assert.Greater(t, status.TTL, time.Duration(0))
+		assert.LessOrEqual(t, status.TTL, SessionTTL-3*time.Minute)
+	})
+
+	t.Run("expired session is not found", func(t *testing.T) {
+		mr.FastForward(SessionTTL)
+
+		_, err := manager.GetSessionStatus(ctx, "status-test")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("unknown session is not found", func(t *testing.T) {
+		_, err := manager.GetSessionStatus(ctx, "missing")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	})
+}
+
 func TestOAuthManager_SessionManagement(t *testing.T) {
 	rdb := setupTestRedis(t)
 	logger := log.DefaultLogger
@@ -6,14 +6,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
+	"QuotaLane/pkg/openai"
+
 	"github.com/google/wire"
 	"golang.org/x/net/proxy"
 )
@@ -147,26 +149,30 @@ func (s *oauthService) RefreshToken(ctx context.Context, refreshToken, proxyURL
 		resp, err := client.Do(req)
 		if err != nil {
 			// 网络错误，可重试
-			lastErr = fmt.Errorf("attempt %d: request failed: %w", attempt+1, err)
+			lastErr = fmt.Errorf("attempt %d: %w", attempt+1, &openai.NetworkError{Err: err})
 			continue
 		}
 
 		// 读取响应
-		body, err := io.ReadAll(resp.Body)
+		body, err := openai.ReadLimitedBody(resp.Body, openai.DefaultMaxResponseBytes)
 		_ = resp.Body.Close() // 忽略 Close 错误，因为已经读取了 body
+		if errors.Is(err, openai.ErrResponseTooLarge) {
+			// 超大响应重试也不会变小，直接返回
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
 		if err != nil {
-			lastErr = fmt.Errorf("attempt %d: failed to read response: %w", attempt+1, err)
+			lastErr = fmt.Errorf("attempt %d: failed to read response: %w", attempt+1, &openai.NetworkError{Err: err})
 			continue
 		}
 
 		// 4xx 客户端错误不重试（如 401 无效 refresh_token）
 		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-			return nil, fmt.Errorf("oauth error (HTTP %d): %s", resp.StatusCode, string(body))
+			return nil, fmt.Errorf("oauth error: %w", openai.NewHTTPError(resp.StatusCode, resp.Header, string(body)))
 		}
 
 		// 5xx 服务器错误可重试
 		if resp.StatusCode >= 500 {
-			lastErr = fmt.Errorf("attempt %d: server error (HTTP %d): %s", attempt+1, resp.StatusCode, string(body))
+			lastErr = fmt.Errorf("attempt %d: %w", attempt+1, openai.NewHTTPError(resp.StatusCode, resp.Header, string(body)))
 			continue
 		}
 
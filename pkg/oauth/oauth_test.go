@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"QuotaLane/pkg/openai"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -108,10 +111,31 @@ func TestRefreshToken_ClientError_NoRetry(t *testing.T) {
 
 	assert.Error(t, err)
 	assert.Nil(t, resp)
-	assert.Contains(t, err.Error(), "oauth error (HTTP 401)")
+	var authErr *openai.AuthError
+	require.ErrorAs(t, err, &authErr)
+	assert.Equal(t, http.StatusUnauthorized, authErr.StatusCode)
 	assert.Equal(t, 1, attempts, "should not retry on 4xx errors")
 }
 
+func TestRefreshToken_OversizedResponse_NoRetry(t *testing.T) {
+	// 超过上限的响应直接拒绝，不重试
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"access_token": "` + strings.Repeat("a", int(openai.DefaultMaxResponseBytes)) + `"}`))
+	}))
+	defer server.Close()
+
+	svc := NewOAuthServiceWithConfig(server.URL, 10*time.Second, 3)
+
+	resp, err := svc.RefreshToken(context.Background(), "refresh_token", "")
+
+	assert.ErrorIs(t, err, openai.ErrResponseTooLarge)
+	assert.Nil(t, resp)
+	assert.Equal(t, 1, attempts, "should not retry oversized responses")
+}
+
 func TestRefreshToken_ServerError_WithRetry(t *testing.T) {
 	// 500 错误应重试
 	attempts := 0
@@ -166,9 +190,27 @@ func TestRefreshToken_AllRetriesExhausted(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, resp)
 	assert.Contains(t, err.Error(), "all retry attempts exhausted")
+	var serverErr *openai.ServerError
+	require.ErrorAs(t, err, &serverErr)
+	assert.Equal(t, http.StatusServiceUnavailable, serverErr.StatusCode)
 	assert.Equal(t, 3, attempts, "should retry maxRetries times")
 }
 
+func TestRefreshToken_NetworkError(t *testing.T) {
+	// 连接失败返回 NetworkError，调用方据此不扣减健康分
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	svc := NewOAuthServiceWithConfig(server.URL, 10*time.Second, 1)
+
+	resp, err := svc.RefreshToken(context.Background(), "test_token", "")
+
+	assert.Nil(t, resp)
+	var netErr *openai.NetworkError
+	assert.ErrorAs(t, err, &netErr)
+	assert.True(t, openai.IsNetworkError(err))
+}
+
 func TestRefreshToken_ContextCanceled(t *testing.T) {
 	// 模拟慢响应
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -2,9 +2,11 @@ package oauth
 
 import (
 	"context"
+	"crypto/tls"
 	"time"
 
 	"QuotaLane/internal/data"
+	"QuotaLane/pkg/oauth/util"
 )
 
 // OAuthProvider 定义通用的 OAuth 授权接口
@@ -39,6 +41,10 @@ type OAuthParams struct {
 	State       string
 	Scopes      []string
 	Metadata    map[string]string
+
+	// 审计信息：发起授权的客户端 IP 与调用方
+	CreatorIP string
+	CreatedBy string
 }
 
 // OAuthURLResponse OAuth 授权 URL 响应
@@ -64,6 +70,8 @@ type OAuthSession struct {
 	RedirectURI  string
 	CreatedAt    time.Time
 	Metadata     map[string]string
+	CreatorIP    string // 发起授权的客户端 IP（审计）
+	CreatedBy    string // 发起授权的调用方（审计）
 }
 
 // ExtendedTokenResponse Token 交换/刷新响应（扩展版，包含更多字段）
@@ -86,4 +94,40 @@ type AccountMetadata struct {
 	Region      string
 	RedirectURI string
 	Extra       map[string]interface{}
+
+	// ClientCertificate mTLS 客户端证书（可选，企业网关要求双向 TLS 时使用）
+	ClientCertificate *tls.Certificate
+}
+
+// Endpoints Provider 的 OAuth 端点（兼容网关或镜像可按 Provider 覆盖）
+// 路径为完整 URL（http:// 或 https:// 开头）时直接使用，不拼接 BaseURL，
+// 用于授权页与 Token 端点不在同一域名的 Provider（如 Claude）
+type Endpoints struct {
+	BaseURL       string // OAuth 服务地址
+	AuthorizePath string // 授权路径
+	TokenPath     string // Token 路径
+}
+
+// WithDefaults 用 def 填充未配置（为空）的字段
+func (e Endpoints) WithDefaults(def Endpoints) Endpoints {
+	if e.BaseURL == "" {
+		e.BaseURL = def.BaseURL
+	}
+	if e.AuthorizePath == "" {
+		e.AuthorizePath = def.AuthorizePath
+	}
+	if e.TokenPath == "" {
+		e.TokenPath = def.TokenPath
+	}
+	return e
+}
+
+// AuthorizeURL 返回授权端点完整地址
+func (e Endpoints) AuthorizeURL() string {
+	return util.JoinEndpoint(e.BaseURL, e.AuthorizePath)
+}
+
+// TokenURL 返回 Token 端点完整地址
+func (e Endpoints) TokenURL() string {
+	return util.JoinEndpoint(e.BaseURL, e.TokenPath)
 }
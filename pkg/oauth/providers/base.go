@@ -6,11 +6,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 
 	"QuotaLane/pkg/oauth/util"
+	"QuotaLane/pkg/openai"
 
 	"github.com/go-kratos/kratos/v2/log"
 )
@@ -18,18 +18,28 @@ import (
 // BaseProvider 提供通用的 OAuth Provider 功能
 // 包含 HTTP 客户端管理、请求发送、重试逻辑等
 type BaseProvider struct {
-	logger  *log.Helper
-	timeout time.Duration
+	logger           *log.Helper
+	timeout          time.Duration
+	maxResponseBytes int64 // 响应体大小上限
 }
 
 // NewBaseProvider 创建 BaseProvider 实例
 func NewBaseProvider(timeout time.Duration, logger log.Logger) *BaseProvider {
 	return &BaseProvider{
-		logger:  log.NewHelper(logger),
-		timeout: timeout,
+		logger:           log.NewHelper(logger),
+		timeout:          timeout,
+		maxResponseBytes: openai.DefaultMaxResponseBytes,
 	}
 }
 
+// SetMaxResponseBytes 设置响应体大小上限，<= 0 时使用默认上限 openai.DefaultMaxResponseBytes
+func (b *BaseProvider) SetMaxResponseBytes(maxResponseBytes int64) {
+	if maxResponseBytes <= 0 {
+		maxResponseBytes = openai.DefaultMaxResponseBytes
+	}
+	b.maxResponseBytes = maxResponseBytes
+}
+
 // DoJSONRequest 发送 JSON 请求并解析响应
 // method: HTTP 方法（GET, POST, PUT, DELETE）
 // url: 请求 URL
@@ -83,19 +93,19 @@ func (b *BaseProvider) DoJSONRequest(
 	// 发送请求
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return fmt.Errorf("failed to send request: %w", &openai.NetworkError{Err: err})
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	// 读取响应体
-	respData, err := io.ReadAll(resp.Body)
+	respData, err := openai.ReadLimitedBody(resp.Body, b.maxResponseBytes)
 	if err != nil {
 		return fmt.Errorf("failed to read response: %w", err)
 	}
 
 	// 检查 HTTP 状态码
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("OAuth error (HTTP %d): %s", resp.StatusCode, string(respData))
+		return fmt.Errorf("OAuth error: %w", openai.NewHTTPError(resp.StatusCode, resp.Header, string(respData)))
 	}
 
 	// 解析响应体
@@ -164,19 +174,19 @@ func (b *BaseProvider) DoFormRequest(
 	// 发送请求
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return fmt.Errorf("failed to send request: %w", &openai.NetworkError{Err: err})
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	// 读取响应体
-	respData, err := io.ReadAll(resp.Body)
+	respData, err := openai.ReadLimitedBody(resp.Body, b.maxResponseBytes)
 	if err != nil {
 		return fmt.Errorf("failed to read response: %w", err)
 	}
 
 	// 检查 HTTP 状态码
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("OAuth error (HTTP %d): %s", resp.StatusCode, string(respData))
+		return fmt.Errorf("OAuth error: %w", openai.NewHTTPError(resp.StatusCode, resp.Header, string(respData)))
 	}
 
 	// 解析响应体
@@ -0,0 +1,39 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"QuotaLane/pkg/openai"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseProvider_OversizedResponseRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"` + strings.Repeat("a", 2048) + `"}`))
+	}))
+	defer server.Close()
+
+	b := NewBaseProvider(5*time.Second, log.DefaultLogger)
+	b.SetMaxResponseBytes(1024)
+
+	var resp map[string]string
+	err := b.DoJSONRequest(context.Background(), http.MethodPost, server.URL, nil, map[string]string{}, &resp, "")
+	assert.ErrorIs(t, err, openai.ErrResponseTooLarge)
+
+	err = b.DoFormRequest(context.Background(), http.MethodPost, server.URL, nil, map[string]string{"grant_type": "refresh_token"}, &resp, "")
+	assert.ErrorIs(t, err, openai.ErrResponseTooLarge)
+
+	// 默认上限可容纳正常大小的响应
+	b.SetMaxResponseBytes(0)
+	require.NoError(t, b.DoJSONRequest(context.Background(), http.MethodPost, server.URL, nil, map[string]string{}, &resp, ""))
+	assert.Len(t, resp["access_token"], 2048)
+}
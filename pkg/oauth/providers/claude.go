@@ -17,8 +17,12 @@ import (
 const (
 	// ClaudeAuthorizeURL is the Claude OAuth authorization endpoint.
 	ClaudeAuthorizeURL = "https://claude.ai/oauth/authorize"
+	// ClaudeOAuthBaseURL is the Claude OAuth token service base URL.
+	ClaudeOAuthBaseURL = "https://console.anthropic.com"
+	// ClaudeTokenPath is the Claude OAuth token path (differs from OpenAI's /oauth/token).
+	ClaudeTokenPath = "/v1/oauth/token"
 	// ClaudeTokenURL is the Claude OAuth token endpoint.
-	ClaudeTokenURL = "https://console.anthropic.com/v1/oauth/token"
+	ClaudeTokenURL = ClaudeOAuthBaseURL + ClaudeTokenPath
 	// ClaudeClientID is the Claude OAuth client ID.
 	ClaudeClientID = "9d1c250a-e61b-44d9-88ed-5944d1962f5e"
 	// ClaudeRedirectURI is the Claude OAuth redirect URI.
@@ -37,18 +41,32 @@ const (
 	ClaudeTokenTimeout = 10 * time.Minute
 )
 
+// ClaudeDefaultEndpoints Claude 默认 OAuth 端点（授权页位于 claude.ai，使用完整 URL）
+var ClaudeDefaultEndpoints = oauth.Endpoints{
+	BaseURL:       ClaudeOAuthBaseURL,
+	AuthorizePath: ClaudeAuthorizeURL,
+	TokenPath:     ClaudeTokenPath,
+}
+
 // ClaudeProvider Claude OAuth Provider 实现
 type ClaudeProvider struct {
 	*BaseProvider // 嵌入 BaseProvider
+	endpoints     oauth.Endpoints
 }
 
 // NewClaudeProvider 创建 Claude Provider 实例
 func NewClaudeProvider(logger log.Logger) *ClaudeProvider {
 	return &ClaudeProvider{
 		BaseProvider: NewBaseProvider(ClaudeTokenTimeout, logger),
+		endpoints:    ClaudeDefaultEndpoints,
 	}
 }
 
+// SetEndpoints 覆盖 OAuth 端点（授权 URL 生成、授权码交换、Token 刷新均使用），未配置的字段使用默认值
+func (p *ClaudeProvider) SetEndpoints(endpoints oauth.Endpoints) {
+	p.endpoints = endpoints.WithDefaults(ClaudeDefaultEndpoints)
+}
+
 // GenerateAuthURL 生成 Claude OAuth 授权 URL
 func (p *ClaudeProvider) GenerateAuthURL(ctx context.Context, params *oauth.OAuthParams) (*oauth.OAuthURLResponse, error) {
 	// 生成 PKCE 参数
@@ -73,7 +91,7 @@ func (p *ClaudeProvider) GenerateAuthURL(ctx context.Context, params *oauth.OAut
 
 	// 构建授权 URL（⚠️ 注意 code=true 参数必须存在）
 	authURL := fmt.Sprintf("%s?%s",
-		ClaudeAuthorizeURL,
+		p.endpoints.AuthorizeURL(),
 		url.Values{
 			"code":                  {"true"},
 			"client_id":             {ClaudeClientID},
@@ -126,7 +144,7 @@ func (p *ClaudeProvider) ExchangeCode(ctx context.Context, code string, session
 	}
 
 	// 使用 BaseProvider 发送 JSON 请求
-	if err := p.DoJSONRequest(ctx, "POST", ClaudeTokenURL, headers, reqBody, &tokenResp, session.ProxyURL); err != nil {
+	if err := p.DoJSONRequest(ctx, "POST", p.endpoints.TokenURL(), headers, reqBody, &tokenResp, session.ProxyURL); err != nil {
 		return nil, err
 	}
 
@@ -184,7 +202,7 @@ func (p *ClaudeProvider) RefreshToken(ctx context.Context, refreshToken string,
 	}
 
 	// 使用 BaseProvider 发送 JSON 请求
-	if err := p.DoJSONRequest(ctx, "POST", ClaudeTokenURL, headers, reqBody, &tokenResp, proxyURL); err != nil {
+	if err := p.DoJSONRequest(ctx, "POST", p.endpoints.TokenURL(), headers, reqBody, &tokenResp, proxyURL); err != nil {
 		return nil, err
 	}
 
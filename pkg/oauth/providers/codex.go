@@ -17,10 +17,16 @@ import (
 )
 
 const (
+	// CodexOAuthBaseURL is the Codex CLI OAuth service base URL.
+	CodexOAuthBaseURL = "https://auth.openai.com"
+	// CodexAuthorizePath is the Codex CLI OAuth authorization path.
+	CodexAuthorizePath = "/oauth/authorize"
+	// CodexTokenPath is the Codex CLI OAuth token path.
+	CodexTokenPath = "/oauth/token"
 	// CodexAuthorizeURL is the Codex CLI OAuth authorization endpoint.
-	CodexAuthorizeURL = "https://auth.openai.com/oauth/authorize"
+	CodexAuthorizeURL = CodexOAuthBaseURL + CodexAuthorizePath
 	// CodexTokenURL is the Codex CLI OAuth token endpoint.
-	CodexTokenURL = "https://auth.openai.com/oauth/token"
+	CodexTokenURL = CodexOAuthBaseURL + CodexTokenPath
 	// CodexClientID is the Codex CLI OAuth client ID.
 	CodexClientID = "app_EMoamEEZ73f0CkXaXp7hrann"
 	// CodexRedirectURI is the Codex CLI OAuth redirect URI.
@@ -39,18 +45,32 @@ const (
 	CodexTokenTimeout = 10 * time.Minute
 )
 
+// CodexDefaultEndpoints Codex CLI 默认 OAuth 端点
+var CodexDefaultEndpoints = oauth.Endpoints{
+	BaseURL:       CodexOAuthBaseURL,
+	AuthorizePath: CodexAuthorizePath,
+	TokenPath:     CodexTokenPath,
+}
+
 // CodexProvider Codex CLI OAuth Provider 实现
 type CodexProvider struct {
 	*BaseProvider // 嵌入 BaseProvider
+	endpoints     oauth.Endpoints
 }
 
 // NewCodexProvider 创建 Codex Provider 实例
 func NewCodexProvider(logger log.Logger) *CodexProvider {
 	return &CodexProvider{
 		BaseProvider: NewBaseProvider(CodexTokenTimeout, logger),
+		endpoints:    CodexDefaultEndpoints,
 	}
 }
 
+// SetEndpoints 覆盖 OAuth 端点（Codex 兼容网关或镜像），未配置的字段使用默认值
+func (p *CodexProvider) SetEndpoints(endpoints oauth.Endpoints) {
+	p.endpoints = endpoints.WithDefaults(CodexDefaultEndpoints)
+}
+
 // GenerateAuthURL 生成 Codex CLI OAuth 授权 URL
 func (p *CodexProvider) GenerateAuthURL(ctx context.Context, params *oauth.OAuthParams) (*oauth.OAuthURLResponse, error) {
 	// 生成 PKCE 参数（64 字节 hex）
@@ -75,7 +95,7 @@ func (p *CodexProvider) GenerateAuthURL(ctx context.Context, params *oauth.OAuth
 
 	// 构建授权 URL（⚠️ 必须包含 Codex 特定参数）
 	authURL := fmt.Sprintf("%s?%s",
-		CodexAuthorizeURL,
+		p.endpoints.AuthorizeURL(),
 		url.Values{
 			"response_type":              {"code"},
 			"client_id":                  {CodexClientID},
@@ -121,7 +141,7 @@ func (p *CodexProvider) ExchangeCode(ctx context.Context, code string, session *
 	}
 
 	// 使用 BaseProvider 发送表单请求
-	if err := p.DoFormRequest(ctx, "POST", CodexTokenURL, nil, formData, &tokenResp, session.ProxyURL); err != nil {
+	if err := p.DoFormRequest(ctx, "POST", p.endpoints.TokenURL(), nil, formData, &tokenResp, session.ProxyURL); err != nil {
 		return nil, err
 	}
 
@@ -168,7 +188,7 @@ func (p *CodexProvider) RefreshToken(ctx context.Context, refreshToken string, m
 	}
 
 	// 使用 BaseProvider 发送表单请求
-	if err := p.DoFormRequest(ctx, "POST", CodexTokenURL, nil, formData, &tokenResp, proxyURL); err != nil {
+	if err := p.DoFormRequest(ctx, "POST", p.endpoints.TokenURL(), nil, formData, &tokenResp, proxyURL); err != nil {
 		return nil, err
 	}
 
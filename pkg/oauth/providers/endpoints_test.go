@@ -0,0 +1,75 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"QuotaLane/pkg/oauth"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTokenServer returns a server that answers every request with a token response
+// and records the request paths it received.
+func newTokenServer(t *testing.T) (*httptest.Server, *[]string) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"at","refresh_token":"rt","expires_in":3600,"scope":"user:inference"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &paths
+}
+
+func TestClaudeProvider_ConfiguredTokenEndpoint(t *testing.T) {
+	server, paths := newTokenServer(t)
+	p := NewClaudeProvider(log.DefaultLogger)
+	p.SetEndpoints(oauth.Endpoints{BaseURL: server.URL, TokenPath: "/gateway/claude/token"})
+
+	_, err := p.RefreshToken(context.Background(), "refresh", nil)
+	require.NoError(t, err)
+	_, err = p.ExchangeCode(context.Background(), "code", &oauth.OAuthSession{CodeVerifier: "verifier"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"/gateway/claude/token", "/gateway/claude/token"}, *paths)
+}
+
+func TestClaudeProvider_DefaultEndpoints(t *testing.T) {
+	p := NewClaudeProvider(log.DefaultLogger)
+	assert.Equal(t, ClaudeTokenURL, p.endpoints.TokenURL())
+
+	resp, err := p.GenerateAuthURL(context.Background(), &oauth.OAuthParams{State: "s"})
+	require.NoError(t, err)
+	authURL, err := url.Parse(resp.AuthURL)
+	require.NoError(t, err)
+	assert.Equal(t, "claude.ai", authURL.Host)
+	assert.Equal(t, "/oauth/authorize", authURL.Path)
+
+	// Overriding only the base URL keeps the default token path and the absolute authorize URL
+	p.SetEndpoints(oauth.Endpoints{BaseURL: "https://mirror.example.com/"})
+	assert.Equal(t, "https://mirror.example.com/v1/oauth/token", p.endpoints.TokenURL())
+	assert.Equal(t, ClaudeAuthorizeURL, p.endpoints.AuthorizeURL())
+}
+
+func TestCodexProvider_ConfiguredEndpoints(t *testing.T) {
+	server, paths := newTokenServer(t)
+	p := NewCodexProvider(log.DefaultLogger)
+	p.SetEndpoints(oauth.Endpoints{BaseURL: server.URL, AuthorizePath: "/auth/start", TokenPath: "/auth/token"})
+
+	_, err := p.ExchangeCode(context.Background(), "code", &oauth.OAuthSession{CodeVerifier: "verifier"})
+	require.NoError(t, err)
+	resp, err := p.RefreshToken(context.Background(), "refresh", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "at", resp.AccessToken)
+	assert.Equal(t, []string{"/auth/token", "/auth/token"}, *paths)
+
+	authResp, err := p.GenerateAuthURL(context.Background(), &oauth.OAuthParams{State: "s"})
+	require.NoError(t, err)
+	assert.Contains(t, authResp.AuthURL, server.URL+"/auth/start?")
+}
@@ -70,6 +70,11 @@ func (p *OpenAIResponsesProvider) ValidateToken(ctx context.Context, token strin
 		proxyURL = metadata.ProxyURL
 	}
 
+	// 挂载 mTLS 客户端证书（可选）
+	if metadata != nil {
+		ctx = openai.WithClientCertificate(ctx, metadata.ClientCertificate)
+	}
+
 	// 调用 OpenAI 服务验证 API Key
 	if err := p.openaiService.ValidateAPIKey(ctx, baseAPI, token, proxyURL); err != nil {
 		return fmt.Errorf("API key validation failed: %w", err)
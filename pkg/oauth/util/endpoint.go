@@ -0,0 +1,12 @@
+package util
+
+import "strings"
+
+// JoinEndpoint 拼接 OAuth 端点地址
+// path 为完整 URL（http:// 或 https:// 开头）时直接使用，否则拼接到 baseURL 之后
+func JoinEndpoint(baseURL, path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return strings.TrimRight(baseURL, "/") + path
+}
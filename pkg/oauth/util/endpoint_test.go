@@ -0,0 +1,27 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinEndpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		path    string
+		want    string
+	}{
+		{"relative path", "https://auth.example.com", "/oauth/token", "https://auth.example.com/oauth/token"},
+		{"trailing slash on base", "https://auth.example.com/", "/oauth/token", "https://auth.example.com/oauth/token"},
+		{"https path used as-is", "https://auth.example.com", "https://token.example.com/oauth/token", "https://token.example.com/oauth/token"},
+		{"http path used as-is", "https://auth.example.com", "http://localhost:8080/token", "http://localhost:8080/token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, JoinEndpoint(tt.baseURL, tt.path))
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"io"
 )
 
 // GenerateCodeVerifier 生成 PKCE code_verifier
@@ -48,8 +49,13 @@ func GenerateState() (string, error) {
 // GenerateSessionID 生成会话 ID
 // 32 字节随机数 → base64url 编码
 func GenerateSessionID() (string, error) {
+	return GenerateSessionIDFrom(rand.Reader)
+}
+
+// GenerateSessionIDFrom 使用指定随机源生成会话 ID（测试中可注入固定种子的随机源）
+func GenerateSessionIDFrom(r io.Reader) (string, error) {
 	bytes := make([]byte, 32)
-	if _, err := rand.Read(bytes); err != nil {
+	if _, err := io.ReadFull(r, bytes); err != nil {
 		return "", fmt.Errorf("failed to generate session ID: %w", err)
 	}
 	return base64.RawURLEncoding.EncodeToString(bytes), nil
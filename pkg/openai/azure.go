@@ -0,0 +1,85 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultAzureAPIVersion Azure OpenAI 数据面 API 默认版本（GA）
+const DefaultAzureAPIVersion = "2024-06-01"
+
+// AzureDeployment 定位一个 Azure OpenAI 部署
+// 端点格式：https://{resource}.openai.azure.com/openai/deployments/{deployment}/...?api-version=...
+type AzureDeployment struct {
+	Resource   string // Azure OpenAI 资源名
+	Deployment string // 部署名
+	APIVersion string // API 版本（为空时使用 DefaultAzureAPIVersion）
+	BaseURL    string // 覆盖默认的 https://{resource}.openai.azure.com（如私有终结点或网关，可选）
+}
+
+// URL 返回部署下 path（如 "chat/completions"）的完整端点，附带 api-version 查询参数
+func (d AzureDeployment) URL(path string) string {
+	base := strings.TrimSuffix(d.BaseURL, "/")
+	if base == "" {
+		base = fmt.Sprintf("https://%s.openai.azure.com", d.Resource)
+	}
+	apiVersion := d.APIVersion
+	if apiVersion == "" {
+		apiVersion = DefaultAzureAPIVersion
+	}
+	return fmt.Sprintf("%s/openai/deployments/%s/%s?api-version=%s",
+		base, url.PathEscape(d.Deployment), strings.TrimPrefix(path, "/"), url.QueryEscape(apiVersion))
+}
+
+// azureValidationRequest 验证用的最小 Chat Completions 请求（仅生成 1 个 token）
+var azureValidationRequest = []byte(`{"messages":[{"role":"user","content":"ping"}],"max_tokens":1}`)
+
+// ValidateAzureAPIKey 验证 Azure OpenAI API Key 及部署是否可用
+// 向部署发送一次最小的 Chat Completions 请求；Azure 使用 api-key Header 认证（而非 Bearer）
+// 部署不存在时返回 404 ClientError，Key 无效时返回 401 AuthError
+func (s *openAIService) ValidateAzureAPIKey(ctx context.Context, deployment AzureDeployment, apiKey, proxyURL string) error {
+	if deployment.Resource == "" && deployment.BaseURL == "" {
+		return fmt.Errorf("azure resource cannot be empty")
+	}
+	if deployment.Deployment == "" {
+		return fmt.Errorf("azure deployment cannot be empty")
+	}
+	if apiKey == "" {
+		return fmt.Errorf("apiKey cannot be empty")
+	}
+
+	endpoint := deployment.URL("chat/completions")
+
+	// 创建 HTTP 客户端（支持代理）
+	client, err := s.createHTTPClient(ctx, proxyURL, s.timeout)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+
+	body, err := s.doWithRetry(ctx, client, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(azureValidationRequest))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("api-key", apiKey)
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 验证响应格式
+	var completion struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return fmt.Errorf("invalid response format: %w", err)
+	}
+	return nil
+}
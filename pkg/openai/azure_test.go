@@ -0,0 +1,83 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAzureDeployment_URL tests the Azure deployment endpoint URL scheme
+func TestAzureDeployment_URL(t *testing.T) {
+	d := AzureDeployment{Resource: "contoso", Deployment: "gpt-4o"}
+	assert.Equal(t, "https://contoso.openai.azure.com/openai/deployments/gpt-4o/chat/completions?api-version="+DefaultAzureAPIVersion,
+		d.URL("chat/completions"))
+
+	d.APIVersion = "2024-10-21"
+	d.BaseURL = "https://gateway.example.com/"
+	assert.Equal(t, "https://gateway.example.com/openai/deployments/gpt-4o/chat/completions?api-version=2024-10-21",
+		d.URL("/chat/completions"))
+}
+
+// TestValidateAzureAPIKey_Success tests that validation calls the deployment with an api-key header
+func TestValidateAzureAPIKey_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/openai/deployments/gpt-4o/chat/completions", r.URL.Path)
+		assert.Equal(t, "2024-10-21", r.URL.Query().Get("api-version"))
+
+		// Azure 使用 api-key Header，不发送 Bearer Token
+		assert.Equal(t, "azure-key", r.Header.Get("api-key"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, string(azureValidationRequest), string(body))
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion"}`))
+	}))
+	defer server.Close()
+
+	service := NewOpenAIService()
+	deployment := AzureDeployment{Resource: "contoso", Deployment: "gpt-4o", APIVersion: "2024-10-21", BaseURL: server.URL}
+
+	assert.NoError(t, service.ValidateAzureAPIKey(context.Background(), deployment, "azure-key", ""))
+}
+
+// TestValidateAzureAPIKey_Errors tests invalid keys, missing deployments and missing configuration
+func TestValidateAzureAPIKey_Errors(t *testing.T) {
+	callCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		if r.Header.Get("api-key") != "azure-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":"401","message":"Access denied due to invalid subscription key"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":"DeploymentNotFound","message":"The API deployment for this resource does not exist."}}`))
+	}))
+	defer server.Close()
+
+	service := NewOpenAIService()
+	ctx := context.Background()
+
+	err := service.ValidateAzureAPIKey(ctx, AzureDeployment{Deployment: "gpt-4o", BaseURL: server.URL}, "wrong-key", "")
+	var authErr *AuthError
+	require.ErrorAs(t, err, &authErr)
+	assert.Equal(t, http.StatusUnauthorized, authErr.StatusCode)
+
+	err = service.ValidateAzureAPIKey(ctx, AzureDeployment{Deployment: "missing", BaseURL: server.URL}, "azure-key", "")
+	var clientErr *ClientError
+	require.ErrorAs(t, err, &clientErr)
+	assert.Equal(t, http.StatusNotFound, clientErr.StatusCode)
+	assert.Equal(t, 2, callCount, "4xx errors are not retried")
+
+	assert.ErrorContains(t, service.ValidateAzureAPIKey(ctx, AzureDeployment{Deployment: "gpt-4o"}, "azure-key", ""), "azure resource cannot be empty")
+	assert.ErrorContains(t, service.ValidateAzureAPIKey(ctx, AzureDeployment{Resource: "contoso"}, "azure-key", ""), "azure deployment cannot be empty")
+	assert.ErrorContains(t, service.ValidateAzureAPIKey(ctx, AzureDeployment{Resource: "contoso", Deployment: "gpt-4o"}, "", ""), "apiKey cannot be empty")
+}
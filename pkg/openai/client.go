@@ -4,7 +4,9 @@ package openai
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -25,8 +27,14 @@ const (
 
 	// UserAgent QuotaLane 的 User-Agent
 	UserAgent = "QuotaLane/1.0"
+
+	// DefaultMaxResponseBytes 默认响应体大小上限（1MB），防止异常代理返回超大响应导致 OOM
+	DefaultMaxResponseBytes int64 = 1 << 20
 )
 
+// ErrResponseTooLarge 响应体超过大小上限
+var ErrResponseTooLarge = errors.New("response body too large")
+
 var (
 	// RetryBackoffs 重试退避时间（指数退避：1s, 2s, 4s）
 	RetryBackoffs = []time.Duration{
@@ -60,6 +68,7 @@ type ErrorResponse struct {
 type OpenAIService interface {
 	// API Key 验证
 	ValidateAPIKey(ctx context.Context, baseAPI, apiKey, proxyURL string) error
+	ValidateAzureAPIKey(ctx context.Context, deployment AzureDeployment, apiKey, proxyURL string) error
 
 	// OAuth 授权流程
 	GenerateAuthURL(pkce *PKCEParams) string
@@ -69,30 +78,89 @@ type OpenAIService interface {
 	// Token 验证
 	ValidateAccessToken(ctx context.Context, baseAPI string, accessToken string, proxyURL string) error
 	ValidateIDToken(idToken string) (*IDTokenClaims, error)
+
+	// 请求元数据透传
+	SetPassthroughHeaders(names []string) error
+
+	// OAuth 端点
+	SetOAuthEndpoints(endpoints OAuthEndpoints)
 }
 
 // openAIService OpenAI 服务实现
 type openAIService struct {
-	timeout    time.Duration
-	maxRetries int
+	timeout          time.Duration
+	maxRetries       int
+	maxResponseBytes int64          // 响应体大小上限
+	oauthEndpoints   OAuthEndpoints // OAuth 端点（未配置的字段使用默认值）
+
+	passthroughAllowlist map[string]struct{} // 允许透传到上游的 Header（nil 使用默认白名单）
 }
 
 // NewOpenAIService 创建 OpenAI 服务
 func NewOpenAIService() OpenAIService {
 	return &openAIService{
-		timeout:    DefaultTimeout,
-		maxRetries: DefaultMaxRetries,
+		timeout:          DefaultTimeout,
+		maxRetries:       DefaultMaxRetries,
+		maxResponseBytes: DefaultMaxResponseBytes,
 	}
 }
 
 // NewOpenAIServiceWithConfig 创建带自定义配置的 OpenAI 服务
 func NewOpenAIServiceWithConfig(timeout time.Duration, maxRetries int) OpenAIService {
 	return &openAIService{
-		timeout:    timeout,
-		maxRetries: maxRetries,
+		timeout:          timeout,
+		maxRetries:       maxRetries,
+		maxResponseBytes: DefaultMaxResponseBytes,
+	}
+}
+
+// NewOpenAIServiceWithResponseLimit 创建带自定义配置和响应体大小上限的 OpenAI 服务
+// maxResponseBytes <= 0 时使用默认上限
+func NewOpenAIServiceWithResponseLimit(timeout time.Duration, maxRetries int, maxResponseBytes int64) OpenAIService {
+	if maxResponseBytes <= 0 {
+		maxResponseBytes = DefaultMaxResponseBytes
+	}
+	return &openAIService{
+		timeout:          timeout,
+		maxRetries:       maxRetries,
+		maxResponseBytes: maxResponseBytes,
+	}
+}
+
+// NewOpenAIServiceWithOAuthEndpoints 创建使用自定义 OAuth 端点的 OpenAI 服务（Codex 兼容网关或镜像）
+// 未配置的字段使用默认值
+func NewOpenAIServiceWithOAuthEndpoints(endpoints OAuthEndpoints) OpenAIService {
+	return &openAIService{
+		timeout:          DefaultTimeout,
+		maxRetries:       DefaultMaxRetries,
+		maxResponseBytes: DefaultMaxResponseBytes,
+		oauthEndpoints:   endpoints,
 	}
 }
 
+// readBody 读取响应体，超过 maxResponseBytes 时返回 ErrResponseTooLarge
+func (s *openAIService) readBody(r io.Reader) ([]byte, error) {
+	return ReadLimitedBody(r, s.maxResponseBytes)
+}
+
+// ReadLimitedBody 读取最多 limit 字节的响应体，超过时返回 ErrResponseTooLarge
+// limit <= 0 时使用默认上限 DefaultMaxResponseBytes
+func ReadLimitedBody(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		limit = DefaultMaxResponseBytes
+	}
+
+	// 多读 1 字节用于判断是否超限
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrResponseTooLarge, limit)
+	}
+	return body, nil
+}
+
 // ValidateAPIKey 验证 OpenAI API Key
 // baseAPI: API 基础地址，如 "https://api.codex.openai.com"
 // apiKey: OpenAI API Key（sk-... 格式）
@@ -112,12 +180,35 @@ func (s *openAIService) ValidateAPIKey(ctx context.Context, baseAPI, apiKey, pro
 	endpoint := fmt.Sprintf("%s/v1/models", baseAPI)
 
 	// 创建 HTTP 客户端（支持代理）
-	client, err := s.createHTTPClient(proxyURL, s.timeout)
+	client, err := s.createHTTPClient(ctx, proxyURL, s.timeout)
 	if err != nil {
 		return fmt.Errorf("failed to create HTTP client: %w", err)
 	}
 
-	// 带重试的请求
+	body, err := s.doWithRetry(ctx, client, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 验证响应格式
+	var modelsResp ModelsResponse
+	if err := json.Unmarshal(body, &modelsResp); err != nil {
+		return fmt.Errorf("invalid response format: %w", err)
+	}
+	return nil
+}
+
+// doWithRetry 发送验证请求并按退避策略重试，返回 200 响应体
+// newRequest 每次尝试构建一个新请求（请求体不可复用）；User-Agent 与透传 Header 在此统一设置
+// 401/403 等 4xx 错误不重试，429、5xx 与网络错误重试至 maxRetries 次
+func (s *openAIService) doWithRetry(ctx context.Context, client *http.Client, newRequest func() (*http.Request, error)) ([]byte, error) {
 	var lastErr error
 	for attempt := 0; attempt < s.maxRetries; attempt++ {
 		// 如果是重试，先等待退避时间
@@ -125,43 +216,41 @@ func (s *openAIService) ValidateAPIKey(ctx context.Context, baseAPI, apiKey, pro
 			backoff := RetryBackoffs[attempt-1]
 			select {
 			case <-ctx.Done():
-				return ctx.Err()
+				return nil, ctx.Err()
 			case <-time.After(backoff):
 			}
 		}
 
 		// 创建请求
-		req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+		req, err := newRequest()
 		if err != nil {
-			return fmt.Errorf("failed to create request: %w", err)
+			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
-		req.Header.Set("Authorization", "Bearer "+apiKey)
 		req.Header.Set("User-Agent", UserAgent)
+		s.applyPassthroughHeaders(req)
 
 		// 发送请求
 		resp, err := client.Do(req)
 		if err != nil {
 			// 网络错误，可重试
-			lastErr = fmt.Errorf("attempt %d: request failed: %w", attempt+1, err)
+			lastErr = fmt.Errorf("attempt %d: %w", attempt+1, &NetworkError{Err: err})
 			continue
 		}
 
 		// 读取响应
-		body, err := io.ReadAll(resp.Body)
+		body, err := s.readBody(resp.Body)
 		_ = resp.Body.Close() // 忽略 Close 错误，因为已经读取了 body
+		if errors.Is(err, ErrResponseTooLarge) {
+			return nil, fmt.Errorf("attempt %d: %w", attempt+1, err)
+		}
 		if err != nil {
-			lastErr = fmt.Errorf("attempt %d: failed to read response: %w", attempt+1, err)
+			lastErr = fmt.Errorf("attempt %d: failed to read response: %w", attempt+1, &NetworkError{Err: err})
 			continue
 		}
 
 		// 成功响应（API Key 有效）
 		if resp.StatusCode == 200 {
-			// 验证响应格式
-			var modelsResp ModelsResponse
-			if err := json.Unmarshal(body, &modelsResp); err != nil {
-				return fmt.Errorf("invalid response format: %w", err)
-			}
-			return nil
+			return body, nil
 		}
 
 		// 401 Unauthorized（API Key 无效，不重试）
@@ -172,24 +261,20 @@ func (s *openAIService) ValidateAPIKey(ctx context.Context, baseAPI, apiKey, pro
 			if errResp.Error.Message != "" {
 				errMsg = errResp.Error.Message
 			}
-			return fmt.Errorf("invalid API key (HTTP 401): %s", errMsg)
+			return nil, &AuthError{StatusCode: 401, Reason: "invalid API key", Message: errMsg}
 		}
 
-		// 429 Too Many Requests（限流，可重试）
-		if resp.StatusCode == 429 {
-			lastErr = fmt.Errorf("attempt %d: rate limited (HTTP 429): %s", attempt+1, string(body))
-			continue
-		}
+		httpErr := NewHTTPError(resp.StatusCode, resp.Header, string(body))
 
-		// 5xx 服务器错误（可重试）
-		if resp.StatusCode >= 500 {
-			lastErr = fmt.Errorf("attempt %d: server error (HTTP %d): %s", attempt+1, resp.StatusCode, string(body))
+		// 429 Too Many Requests（限流）、5xx 服务器错误：可重试
+		if resp.StatusCode == 429 || resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("attempt %d: %w", attempt+1, httpErr)
 			continue
 		}
 
-		// 其他 4xx 客户端错误（不重试）
+		// 其他 4xx 客户端错误（不重试，403 为 AuthError）
 		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-			return fmt.Errorf("client error (HTTP %d): %s", resp.StatusCode, string(body))
+			return nil, httpErr
 		}
 
 		// 其他状态码
@@ -197,11 +282,12 @@ func (s *openAIService) ValidateAPIKey(ctx context.Context, baseAPI, apiKey, pro
 	}
 
 	// 所有重试都失败
-	return fmt.Errorf("all retry attempts exhausted: %w", lastErr)
+	return nil, fmt.Errorf("all retry attempts exhausted: %w", lastErr)
 }
 
 // createHTTPClient 创建 HTTP 客户端（支持代理和自定义超时）
-func (s *openAIService) createHTTPClient(proxyURL string, timeout time.Duration) (*http.Client, error) {
+// ctx 中挂载了客户端证书（WithClientCertificate）时启用 mTLS
+func (s *openAIService) createHTTPClient(ctx context.Context, proxyURL string, timeout time.Duration) (*http.Client, error) {
 	// 如果未指定超时，使用默认超时
 	if timeout == 0 {
 		timeout = s.timeout
@@ -218,6 +304,14 @@ func (s *openAIService) createHTTPClient(proxyURL string, timeout time.Duration)
 		ExpectContinueTimeout: 1 * time.Second,
 	}
 
+	// 配置 mTLS 客户端证书
+	if cert := ClientCertificateFromContext(ctx); cert != nil {
+		transport.TLSClientConfig = &tls.Config{
+			Certificates: []tls.Certificate{*cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+
 	// 配置代理
 	if proxyURL != "" {
 		parsed, err := url.Parse(proxyURL)
@@ -0,0 +1,173 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 上游 Provider 错误分类
+// 调用方通过 errors.As 区分错误类型，决定是否重试以及是否扣减账户健康分：
+//   - AuthError：凭证无效/无权限，应扣减健康分
+//   - RateLimitError：上游限流，可在 RetryAfter 后重试
+//   - ServerError：上游 5xx，可重试
+//   - ClientError：其他 4xx 请求错误，不重试
+//   - NetworkError：网络/代理故障，与账户本身无关，不应扣减健康分
+
+// AuthError 认证失败（HTTP 401/403，或 OAuth invalid_grant）
+type AuthError struct {
+	StatusCode int
+	Reason     string // 错误摘要，为空时使用 "authentication failed"
+	Message    string // 上游返回的错误信息
+}
+
+func (e *AuthError) Error() string {
+	return formatHTTPError(e.Reason, "authentication failed", e.StatusCode, e.Message)
+}
+
+// RateLimitError 上游限流（HTTP 429）
+type RateLimitError struct {
+	StatusCode int
+	Reason     string
+	Message    string
+	RetryAfter time.Duration // 上游 Retry-After 头，未提供时为 0
+}
+
+func (e *RateLimitError) Error() string {
+	msg := formatHTTPError(e.Reason, "rate limited", e.StatusCode, e.Message)
+	if e.RetryAfter > 0 {
+		msg = fmt.Sprintf("%s (retry after %s)", msg, e.RetryAfter)
+	}
+	return msg
+}
+
+// ServerError 上游服务端错误（HTTP 5xx）
+type ServerError struct {
+	StatusCode int
+	Reason     string
+	Message    string
+}
+
+func (e *ServerError) Error() string {
+	return formatHTTPError(e.Reason, "server error", e.StatusCode, e.Message)
+}
+
+// ClientError 其他客户端错误（HTTP 4xx，不含 401/403/429）
+type ClientError struct {
+	StatusCode int
+	Reason     string
+	Message    string
+}
+
+func (e *ClientError) Error() string {
+	return formatHTTPError(e.Reason, "client error", e.StatusCode, e.Message)
+}
+
+// NetworkError 网络层错误（连接失败、超时、代理不可用等），未收到上游响应
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("request failed: %v", e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// NewHTTPError 根据 HTTP 状态码构造对应的类型化错误
+// 401/403 → AuthError，429 → RateLimitError，5xx → ServerError，其他 → ClientError
+func NewHTTPError(statusCode int, header http.Header, body string) error {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return &AuthError{StatusCode: statusCode, Message: body}
+	case statusCode == http.StatusTooManyRequests:
+		return &RateLimitError{StatusCode: statusCode, Message: body, RetryAfter: parseRetryAfter(header, time.Now())}
+	case statusCode >= 500:
+		return &ServerError{StatusCode: statusCode, Message: body}
+	default:
+		return &ClientError{StatusCode: statusCode, Message: body}
+	}
+}
+
+// StatusCode 返回类型化错误中的 HTTP 状态码，非 HTTP 错误返回 0
+func StatusCode(err error) int {
+	var authErr *AuthError
+	var rateLimitErr *RateLimitError
+	var serverErr *ServerError
+	var clientErr *ClientError
+	switch {
+	case errors.As(err, &authErr):
+		return authErr.StatusCode
+	case errors.As(err, &rateLimitErr):
+		return rateLimitErr.StatusCode
+	case errors.As(err, &serverErr):
+		return serverErr.StatusCode
+	case errors.As(err, &clientErr):
+		return clientErr.StatusCode
+	default:
+		return 0
+	}
+}
+
+// IsNetworkError 判断错误是否为网络/代理故障（未收到上游响应）
+func IsNetworkError(err error) bool {
+	var netErr *NetworkError
+	return errors.As(err, &netErr)
+}
+
+// IsRetryable 判断错误是否值得重试（限流、5xx、网络错误）
+func IsRetryable(err error) bool {
+	var rateLimitErr *RateLimitError
+	var serverErr *ServerError
+	return errors.As(err, &rateLimitErr) || errors.As(err, &serverErr) || IsNetworkError(err)
+}
+
+// IsInvalidGrant 判断错误是否为 OAuth invalid_grant（HTTP 400，refresh token 已过期或被撤销）
+// 该错误是永久性的，重试无意义，只能由运维重新授权
+func IsInvalidGrant(err error) bool {
+	var authErr *AuthError
+	var clientErr *ClientError
+	switch {
+	case errors.As(err, &authErr):
+		return authErr.StatusCode == http.StatusBadRequest && strings.Contains(authErr.Message, "invalid_grant")
+	case errors.As(err, &clientErr):
+		return clientErr.StatusCode == http.StatusBadRequest && strings.Contains(clientErr.Message, "invalid_grant")
+	default:
+		return false
+	}
+}
+
+// formatHTTPError 统一格式："<reason> (HTTP <code>): <message>"
+func formatHTTPError(reason, defaultReason string, statusCode int, message string) string {
+	if reason == "" {
+		reason = defaultReason
+	}
+	msg := fmt.Sprintf("%s (HTTP %d)", reason, statusCode)
+	if message != "" {
+		msg += ": " + message
+	}
+	return msg
+}
+
+// parseRetryAfter 解析 Retry-After 头（秒数或 HTTP 日期），无效时返回 0
+func parseRetryAfter(header http.Header, now time.Time) time.Duration {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,170 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewHTTPError_StatusMapping tests that each HTTP status maps to the right typed error
+func TestNewHTTPError_StatusMapping(t *testing.T) {
+	tests := []struct {
+		status int
+		target interface{}
+	}{
+		{status: http.StatusUnauthorized, target: new(*AuthError)},
+		{status: http.StatusForbidden, target: new(*AuthError)},
+		{status: http.StatusTooManyRequests, target: new(*RateLimitError)},
+		{status: http.StatusInternalServerError, target: new(*ServerError)},
+		{status: http.StatusBadGateway, target: new(*ServerError)},
+		{status: http.StatusServiceUnavailable, target: new(*ServerError)},
+		{status: 529, target: new(*ServerError)},
+		{status: http.StatusBadRequest, target: new(*ClientError)},
+		{status: http.StatusNotFound, target: new(*ClientError)},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			err := NewHTTPError(tt.status, nil, "body")
+			assert.True(t, errors.As(err, tt.target), "status %d mapped to %T", tt.status, err)
+			assert.Equal(t, tt.status, StatusCode(err))
+			assert.Contains(t, err.Error(), "body")
+		})
+	}
+}
+
+func TestNewHTTPError_RetryAfter(t *testing.T) {
+	t.Run("seconds", func(t *testing.T) {
+		err := NewHTTPError(http.StatusTooManyRequests, http.Header{"Retry-After": {"30"}}, "")
+		var rateLimitErr *RateLimitError
+		require.ErrorAs(t, err, &rateLimitErr)
+		assert.Equal(t, 30*time.Second, rateLimitErr.RetryAfter)
+		assert.Contains(t, err.Error(), "retry after 30s")
+	})
+
+	t.Run("http date", func(t *testing.T) {
+		now := time.Now()
+		header := http.Header{"Retry-After": {now.Add(2 * time.Minute).UTC().Format(http.TimeFormat)}}
+		got := parseRetryAfter(header, now)
+		assert.InDelta(t, (2 * time.Minute).Seconds(), got.Seconds(), 1)
+	})
+
+	t.Run("missing or invalid", func(t *testing.T) {
+		assert.Zero(t, parseRetryAfter(http.Header{}, time.Now()))
+		assert.Zero(t, parseRetryAfter(http.Header{"Retry-After": {"soon"}}, time.Now()))
+		assert.Zero(t, parseRetryAfter(http.Header{"Retry-After": {"-5"}}, time.Now()))
+	})
+}
+
+// TestIsInvalidGrant tests that only HTTP 400 invalid_grant responses count as permanent refresh failures
+func TestIsInvalidGrant(t *testing.T) {
+	invalidGrant := `{"error":"invalid_grant","error_description":"refresh token revoked"}`
+
+	assert.True(t, IsInvalidGrant(&AuthError{StatusCode: http.StatusBadRequest, Reason: "refresh token invalid or expired", Message: invalidGrant}))
+	assert.True(t, IsInvalidGrant(fmt.Errorf("OAuth error: %w", NewHTTPError(http.StatusBadRequest, nil, invalidGrant))))
+
+	assert.False(t, IsInvalidGrant(NewHTTPError(http.StatusBadRequest, nil, `{"error":"invalid_request"}`)))
+	assert.False(t, IsInvalidGrant(NewHTTPError(http.StatusUnauthorized, nil, invalidGrant)))
+	assert.False(t, IsInvalidGrant(NewHTTPError(http.StatusServiceUnavailable, nil, invalidGrant)))
+	assert.False(t, IsInvalidGrant(errors.New("invalid_grant")))
+}
+
+// TestValidateAPIKey_TypedErrors tests that ValidateAPIKey returns typed errors for each upstream status
+func TestValidateAPIKey_TypedErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		header  http.Header
+		check   func(t *testing.T, err error)
+		retries bool
+	}{
+		{
+			name:   "401 auth error",
+			status: http.StatusUnauthorized,
+			check: func(t *testing.T, err error) {
+				var authErr *AuthError
+				require.ErrorAs(t, err, &authErr)
+				assert.Equal(t, http.StatusUnauthorized, authErr.StatusCode)
+			},
+		},
+		{
+			name:   "403 auth error",
+			status: http.StatusForbidden,
+			check: func(t *testing.T, err error) {
+				var authErr *AuthError
+				require.ErrorAs(t, err, &authErr)
+				assert.Equal(t, http.StatusForbidden, authErr.StatusCode)
+			},
+		},
+		{
+			name:   "429 rate limit error with retry-after",
+			status: http.StatusTooManyRequests,
+			header: http.Header{"Retry-After": {"12"}},
+			check: func(t *testing.T, err error) {
+				var rateLimitErr *RateLimitError
+				require.ErrorAs(t, err, &rateLimitErr)
+				assert.Equal(t, 12*time.Second, rateLimitErr.RetryAfter)
+			},
+		},
+		{
+			name:   "503 server error",
+			status: http.StatusServiceUnavailable,
+			check: func(t *testing.T, err error) {
+				var serverErr *ServerError
+				require.ErrorAs(t, err, &serverErr)
+				assert.Equal(t, http.StatusServiceUnavailable, serverErr.StatusCode)
+			},
+		},
+		{
+			name:   "404 client error",
+			status: http.StatusNotFound,
+			check: func(t *testing.T, err error) {
+				var clientErr *ClientError
+				require.ErrorAs(t, err, &clientErr)
+				assert.False(t, IsRetryable(err))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"error": {"message": "upstream"}}`))
+			}))
+			defer server.Close()
+
+			service := NewOpenAIServiceWithConfig(5*time.Second, 1)
+			err := service.ValidateAPIKey(context.Background(), server.URL, "sk-test-key", "")
+
+			require.Error(t, err)
+			tt.check(t, err)
+			assert.False(t, IsNetworkError(err))
+		})
+	}
+}
+
+// TestValidateAPIKey_NetworkError tests that connection failures are reported as NetworkError
+func TestValidateAPIKey_NetworkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	baseAPI := server.URL
+	server.Close()
+
+	service := NewOpenAIServiceWithConfig(5*time.Second, 1)
+	err := service.ValidateAPIKey(context.Background(), baseAPI, "sk-test-key", "")
+
+	require.Error(t, err)
+	assert.True(t, IsNetworkError(err))
+	assert.True(t, IsRetryable(err))
+	assert.Zero(t, StatusCode(err))
+}
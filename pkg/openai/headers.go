@@ -0,0 +1,128 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+const (
+	// MaxPassthroughHeaders 单次请求最多透传的 Header 数量
+	MaxPassthroughHeaders = 8
+
+	// MaxPassthroughHeaderValueLen 透传 Header 值的最大长度
+	MaxPassthroughHeaderValueLen = 256
+)
+
+// DefaultPassthroughHeaders 默认允许透传到上游的 Header（请求关联 ID）
+var DefaultPassthroughHeaders = []string{"X-Request-Id", "X-Correlation-Id"}
+
+// reservedPassthroughHeaders 由客户端自身设置或携带凭证的 Header，不允许加入透传白名单
+var reservedPassthroughHeaders = map[string]struct{}{
+	"Accept":              {},
+	"Authorization":       {},
+	"Connection":          {},
+	"Content-Length":      {},
+	"Content-Type":        {},
+	"Cookie":              {},
+	"Host":                {},
+	"Proxy-Authorization": {},
+	"Proxy-Connection":    {},
+	"Set-Cookie":          {},
+	"Te":                  {},
+	"Trailer":             {},
+	"Transfer-Encoding":   {},
+	"Upgrade":             {},
+	"User-Agent":          {},
+}
+
+type passthroughHeadersKey struct{}
+
+// WithPassthroughHeaders 返回携带调用方请求元数据（如关联 ID）的 context
+// 发往上游的验证/刷新请求只会附带白名单内且值安全的 Header，其余的直接丢弃；headers 为空时原样返回
+func WithPassthroughHeaders(ctx context.Context, headers map[string]string) context.Context {
+	if len(headers) == 0 {
+		return ctx
+	}
+	merged := make(map[string]string, len(headers))
+	for name, value := range PassthroughHeadersFromContext(ctx) {
+		merged[name] = value
+	}
+	for name, value := range headers {
+		merged[http.CanonicalHeaderKey(name)] = value
+	}
+	return context.WithValue(ctx, passthroughHeadersKey{}, merged)
+}
+
+// PassthroughHeadersFromContext 读取 context 中待透传的 Header（未经白名单过滤），未设置时返回 nil
+func PassthroughHeadersFromContext(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(passthroughHeadersKey{}).(map[string]string)
+	return headers
+}
+
+// SetPassthroughHeaders 设置允许透传到上游的 Header 白名单
+// 名称必须是合法的 Header 字段名，且不能是凭证或由客户端自身管理的 Header（Authorization、Cookie、Host 等）；
+// 空列表恢复默认白名单 DefaultPassthroughHeaders
+func (s *openAIService) SetPassthroughHeaders(names []string) error {
+	if len(names) == 0 {
+		names = DefaultPassthroughHeaders
+	}
+
+	allowlist := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid passthrough header name %q", name)
+		}
+		canonical := http.CanonicalHeaderKey(name)
+		if _, reserved := reservedPassthroughHeaders[canonical]; reserved || strings.HasPrefix(canonical, "Proxy-") {
+			return fmt.Errorf("header %q cannot be passed through to the provider", name)
+		}
+		allowlist[canonical] = struct{}{}
+	}
+
+	s.passthroughAllowlist = allowlist
+	return nil
+}
+
+// applyPassthroughHeaders 将 context 中白名单内的 Header 写入上游请求
+// 不在白名单、值为空、超长或含控制字符（CR/LF 等）的 Header 一律丢弃，不会覆盖请求已设置的 Header
+func (s *openAIService) applyPassthroughHeaders(req *http.Request) {
+	headers := PassthroughHeadersFromContext(req.Context())
+	if len(headers) == 0 {
+		return
+	}
+
+	allowlist := s.passthroughAllowlist
+	if allowlist == nil {
+		allowlist = defaultPassthroughAllowlist()
+	}
+
+	applied := 0
+	for name, value := range headers {
+		if applied >= MaxPassthroughHeaders {
+			return
+		}
+		if _, ok := allowlist[name]; !ok {
+			continue
+		}
+		if value == "" || len(value) > MaxPassthroughHeaderValueLen || !httpguts.ValidHeaderFieldValue(value) {
+			continue
+		}
+		if req.Header.Get(name) != "" {
+			continue
+		}
+		req.Header.Set(name, value)
+		applied++
+	}
+}
+
+func defaultPassthroughAllowlist() map[string]struct{} {
+	allowlist := make(map[string]struct{}, len(DefaultPassthroughHeaders))
+	for _, name := range DefaultPassthroughHeaders {
+		allowlist[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+	return allowlist
+}
@@ -0,0 +1,92 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRefreshToken_PassthroughHeaders tests that an allowlisted header on the context is forwarded
+// to the provider while disallowed and unsafe ones are dropped.
+func TestRefreshToken_PassthroughHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"at","refresh_token":"rt","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	service := NewOpenAIServiceWithOAuthEndpoints(OAuthEndpoints{BaseURL: server.URL})
+	ctx := WithPassthroughHeaders(context.Background(), map[string]string{
+		"x-correlation-id": "corr-123",
+		"X-Request-Id":     "req-1\r\nX-Injected: 1",
+		"X-Internal-Debug": "secret",
+		"Authorization":    "Bearer stolen",
+	})
+
+	_, err := service.RefreshToken(ctx, "refresh", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "corr-123", received.Get("X-Correlation-Id"))
+	assert.Empty(t, received.Get("X-Request-Id"), "values with CR/LF are dropped")
+	assert.Empty(t, received.Get("X-Injected"))
+	assert.Empty(t, received.Get("X-Internal-Debug"), "headers outside the allowlist are dropped")
+	assert.Empty(t, received.Get("Authorization"))
+}
+
+// TestValidateAPIKey_ConfiguredPassthroughHeaders tests that a configured allowlist replaces the default one.
+func TestValidateAPIKey_ConfiguredPassthroughHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	}))
+	defer server.Close()
+
+	service := NewOpenAIService()
+	require.NoError(t, service.SetPassthroughHeaders([]string{"X-Trace-Id"}))
+
+	ctx := WithPassthroughHeaders(context.Background(), map[string]string{
+		"X-Trace-Id":       "trace-9",
+		"X-Correlation-Id": "corr-123",
+	})
+	require.NoError(t, service.ValidateAPIKey(ctx, server.URL, "sk-test", ""))
+
+	assert.Equal(t, "trace-9", received.Get("X-Trace-Id"))
+	assert.Empty(t, received.Get("X-Correlation-Id"))
+	assert.Equal(t, "Bearer sk-test", received.Get("Authorization"))
+}
+
+// TestApplyPassthroughHeaders_ValueLimits tests that empty and over-long values are not forwarded.
+func TestApplyPassthroughHeaders_ValueLimits(t *testing.T) {
+	service := &openAIService{}
+	ctx := WithPassthroughHeaders(context.Background(), map[string]string{
+		"X-Request-Id":     strings.Repeat("a", MaxPassthroughHeaderValueLen+1),
+		"X-Correlation-Id": "",
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+
+	service.applyPassthroughHeaders(req)
+	assert.Empty(t, req.Header)
+}
+
+// TestSetPassthroughHeaders_RejectsUnsafeNames tests that credentials and client-managed headers
+// cannot be allowlisted.
+func TestSetPassthroughHeaders_RejectsUnsafeNames(t *testing.T) {
+	service := &openAIService{}
+
+	for _, name := range []string{"Authorization", "cookie", "Host", "Proxy-Foo", "X Bad", ""} {
+		assert.Error(t, service.SetPassthroughHeaders([]string{name}), name)
+	}
+
+	require.NoError(t, service.SetPassthroughHeaders(nil))
+	assert.Contains(t, service.passthroughAllowlist, "X-Request-Id", "empty list restores the default")
+}
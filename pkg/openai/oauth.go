@@ -6,23 +6,66 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
+
+	"QuotaLane/pkg/oauth/util"
 )
 
 // OpenAI OAuth 配置常量
 const (
-	OAuthBaseURL     = "https://auth.openai.com"
-	OAuthClientID    = "app_EMoamEEZ73f0CkXaXp7hrann"
-	OAuthRedirectURI = "http://localhost:1455/auth/callback"
-	OAuthScope       = "openid profile email offline_access"
+	OAuthBaseURL       = "https://auth.openai.com"
+	OAuthAuthorizePath = "/oauth/authorize"
+	OAuthTokenPath     = "/oauth/token"
+	OAuthClientID      = "app_EMoamEEZ73f0CkXaXp7hrann"
+	OAuthRedirectURI   = "http://localhost:1455/auth/callback"
+	OAuthScope         = "openid profile email offline_access"
 )
 
+// OAuthEndpoints OAuth 服务端点（兼容网关或镜像可覆盖，未配置的字段使用默认值）
+// 路径为完整 URL（http:// 或 https:// 开头）时直接使用，不拼接 BaseURL
+type OAuthEndpoints struct {
+	BaseURL       string // OAuth 服务地址（默认 OAuthBaseURL）
+	AuthorizePath string // 授权路径或完整 URL（默认 OAuthAuthorizePath）
+	TokenPath     string // Token 路径或完整 URL（默认 OAuthTokenPath）
+}
+
+// withDefaults 用默认端点填充未配置的字段
+func (e OAuthEndpoints) withDefaults() OAuthEndpoints {
+	if e.BaseURL == "" {
+		e.BaseURL = OAuthBaseURL
+	}
+	if e.AuthorizePath == "" {
+		e.AuthorizePath = OAuthAuthorizePath
+	}
+	if e.TokenPath == "" {
+		e.TokenPath = OAuthTokenPath
+	}
+	return e
+}
+
+// AuthorizeURL 返回授权端点完整地址
+func (e OAuthEndpoints) AuthorizeURL() string {
+	e = e.withDefaults()
+	return util.JoinEndpoint(e.BaseURL, e.AuthorizePath)
+}
+
+// TokenURL 返回 Token 端点完整地址
+func (e OAuthEndpoints) TokenURL() string {
+	e = e.withDefaults()
+	return util.JoinEndpoint(e.BaseURL, e.TokenPath)
+}
+
+// SetOAuthEndpoints 设置 OAuth 端点（兼容网关或镜像），未配置的字段使用默认值
+func (s *openAIService) SetOAuthEndpoints(endpoints OAuthEndpoints) {
+	s.oauthEndpoints = endpoints
+}
+
 // PKCEParams PKCE 授权码流程参数
 type PKCEParams struct {
 	CodeVerifier  string
@@ -38,7 +81,7 @@ type OAuthTokens struct {
 	TokenType     string   `json:"token_type"`
 	ExpiresIn     int64    `json:"expires_in"` // 秒
 	Scope         string   `json:"scope"`
-	Organizations []string `json:"-"` // 从 ID token 解析
+	Organizations []string `json:"-"` // 组织 ID 列表，从 ID token 的 OpenAI auth claims 解析
 }
 
 // GeneratePKCE 生成 PKCE 参数（RFC 7636）
@@ -92,7 +135,7 @@ func (s *openAIService) GenerateAuthURL(pkce *PKCEParams) string {
 		"codex_cli_simplified_flow":  {"true"}, // Codex CLI 简化流程
 	}
 
-	return fmt.Sprintf("%s/oauth/authorize?%s", OAuthBaseURL, params.Encode())
+	return fmt.Sprintf("%s?%s", s.oauthEndpoints.AuthorizeURL(), params.Encode())
 }
 
 // ExchangeCode 交换授权码获取 token
@@ -138,11 +181,50 @@ func (s *openAIService) ExchangeCode(ctx context.Context, code string, codeVerif
 		url.QueryEscape(codeVerifier),
 	)
 
-	tokenURL := fmt.Sprintf("%s/oauth/token", OAuthBaseURL)
+	tokenURL := s.oauthEndpoints.TokenURL()
 	log.Printf("[DEBUG] Token URL: %s", tokenURL)
 	log.Printf("[DEBUG] Request Body: %s", requestBody)
 
-	// 创建 HTTP 请求
+	// 配置 HTTP 客户端（复用现有代理逻辑）
+	client, err := s.createHTTPClient(ctx, proxyURL, 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+
+	// 授权码只能使用一次：仅在网络错误或 5xx（上游未处理该授权码）时重试，4xx 立即返回
+	attempts := s.maxRetries
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			backoff := RetryBackoffs[min(attempt-1, len(RetryBackoffs)-1)]
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+
+		tokens, err := s.exchangeCodeOnce(ctx, client, tokenURL, requestBody)
+		if err == nil {
+			return tokens, nil
+		}
+		if !isRetryableExchangeError(err) {
+			return nil, err
+		}
+		log.Printf("[DEBUG] Token exchange attempt %d failed: %v", attempt+1, err)
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("token exchange failed after %d attempts: %w", attempts, lastErr)
+}
+
+// exchangeCodeOnce 发送一次 token 交换请求
+func (s *openAIService) exchangeCodeOnce(ctx context.Context, client *http.Client, tokenURL, requestBody string) (*OAuthTokens, error) {
+	// 创建 HTTP 请求（每次重试重新创建，请求体不可复用）
 	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(requestBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -150,24 +232,19 @@ func (s *openAIService) ExchangeCode(ctx context.Context, code string, codeVerif
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Accept", "application/json")
-
-	// 配置 HTTP 客户端（复用现有代理逻辑）
-	client, err := s.createHTTPClient(proxyURL, 30*time.Second)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
-	}
+	s.applyPassthroughHeaders(req)
 
 	// 发送请求
 	log.Printf("[DEBUG] Sending token exchange request...")
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Printf("[DEBUG] Request failed: %v", err)
-		return nil, fmt.Errorf("failed to exchange code: %w", err)
+		return nil, fmt.Errorf("failed to exchange code: %w", &NetworkError{Err: err})
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	// 读取响应
-	body, err := io.ReadAll(resp.Body)
+	body, err := s.readBody(resp.Body)
 	if err != nil {
 		log.Printf("[DEBUG] Failed to read response body: %v", err)
 		return nil, fmt.Errorf("failed to read response: %w", err)
@@ -178,7 +255,7 @@ func (s *openAIService) ExchangeCode(ctx context.Context, code string, codeVerif
 
 	// 检查 HTTP 状态码
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("token exchange failed (HTTP %d): %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("token exchange failed: %w", NewHTTPError(resp.StatusCode, resp.Header, string(body)))
 	}
 
 	// 解析 JSON 响应
@@ -192,9 +269,19 @@ func (s *openAIService) ExchangeCode(ctx context.Context, code string, codeVerif
 		return nil, fmt.Errorf("incomplete token response: missing access_token or refresh_token")
 	}
 
+	// 从 ID token 解析组织信息（解析失败不影响授权结果）
+	tokens.Organizations = organizationsFromIDToken(tokens.IDToken)
+
 	return &tokens, nil
 }
 
+// isRetryableExchangeError 授权码交换是否可重试：仅网络错误与 5xx
+// 429/4xx 可能表示授权码已被消费或无效，重试没有意义
+func isRetryableExchangeError(err error) bool {
+	var serverErr *ServerError
+	return IsNetworkError(err) || errors.As(err, &serverErr)
+}
+
 // RefreshToken 刷新 access token
 func (s *openAIService) RefreshToken(ctx context.Context, refreshToken string, proxyURL string) (*OAuthTokens, error) {
 	if refreshToken == "" {
@@ -208,7 +295,7 @@ func (s *openAIService) RefreshToken(ctx context.Context, refreshToken string, p
 		"client_id":     {OAuthClientID},
 	}
 
-	tokenURL := fmt.Sprintf("%s/oauth/token", OAuthBaseURL)
+	tokenURL := s.oauthEndpoints.TokenURL()
 
 	// 创建 HTTP 请求
 	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
@@ -218,9 +305,10 @@ func (s *openAIService) RefreshToken(ctx context.Context, refreshToken string, p
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Accept", "application/json")
+	s.applyPassthroughHeaders(req)
 
 	// 配置 HTTP 客户端
-	client, err := s.createHTTPClient(proxyURL, 30*time.Second)
+	client, err := s.createHTTPClient(ctx, proxyURL, 30*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
 	}
@@ -230,7 +318,7 @@ func (s *openAIService) RefreshToken(ctx context.Context, refreshToken string, p
 	for attempt := 1; attempt <= 3; attempt++ {
 		resp, err := client.Do(req)
 		if err != nil {
-			lastErr = fmt.Errorf("attempt %d failed: %w", attempt, err)
+			lastErr = fmt.Errorf("attempt %d failed: %w", attempt, &NetworkError{Err: err})
 			if attempt < 3 {
 				time.Sleep(time.Duration(attempt) * time.Second)
 				continue
@@ -240,9 +328,12 @@ func (s *openAIService) RefreshToken(ctx context.Context, refreshToken string, p
 		defer func() { _ = resp.Body.Close() }()
 
 		// 读取响应
-		body, err := io.ReadAll(resp.Body)
+		body, err := s.readBody(resp.Body)
+		if errors.Is(err, ErrResponseTooLarge) {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
 		if err != nil {
-			lastErr = fmt.Errorf("failed to read response: %w", err)
+			lastErr = fmt.Errorf("failed to read response: %w", &NetworkError{Err: err})
 			continue
 		}
 
@@ -250,9 +341,13 @@ func (s *openAIService) RefreshToken(ctx context.Context, refreshToken string, p
 		if resp.StatusCode != http.StatusOK {
 			// 400: invalid_grant（refresh token 已过期或被撤销）
 			if resp.StatusCode == http.StatusBadRequest {
-				return nil, fmt.Errorf("refresh token invalid or expired (HTTP 400): %s", string(body))
+				return nil, &AuthError{StatusCode: resp.StatusCode, Reason: "refresh token invalid or expired", Message: string(body)}
 			}
-			lastErr = fmt.Errorf("token refresh failed (HTTP %d): %s", resp.StatusCode, string(body))
+			httpErr := NewHTTPError(resp.StatusCode, resp.Header, string(body))
+			if !IsRetryable(httpErr) {
+				return nil, fmt.Errorf("token refresh failed: %w", httpErr)
+			}
+			lastErr = fmt.Errorf("token refresh failed: %w", httpErr)
 			if attempt < 3 {
 				time.Sleep(time.Duration(attempt) * time.Second)
 				continue
@@ -277,6 +372,9 @@ func (s *openAIService) RefreshToken(ctx context.Context, refreshToken string, p
 			tokens.RefreshToken = refreshToken
 		}
 
+		// 从新的 ID token 解析组织信息
+		tokens.Organizations = organizationsFromIDToken(tokens.IDToken)
+
 		return &tokens, nil
 	}
 
@@ -308,9 +406,10 @@ func (s *openAIService) ValidateAccessToken(ctx context.Context, baseAPI string,
 	// 设置 OAuth Bearer token 认证头
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 	req.Header.Set("Accept", "application/json")
+	s.applyPassthroughHeaders(req)
 
 	// 配置 HTTP 客户端
-	client, err := s.createHTTPClient(proxyURL, 15*time.Second)
+	client, err := s.createHTTPClient(ctx, proxyURL, 15*time.Second)
 	if err != nil {
 		return fmt.Errorf("failed to create HTTP client: %w", err)
 	}
@@ -320,7 +419,7 @@ func (s *openAIService) ValidateAccessToken(ctx context.Context, baseAPI string,
 	for attempt := 1; attempt <= 3; attempt++ {
 		resp, err := client.Do(req)
 		if err != nil {
-			lastErr = fmt.Errorf("attempt %d failed: %w", attempt, err)
+			lastErr = fmt.Errorf("attempt %d failed: %w", attempt, &NetworkError{Err: err})
 			if attempt < 3 {
 				time.Sleep(time.Duration(attempt) * time.Second)
 				continue
@@ -336,16 +435,16 @@ func (s *openAIService) ValidateAccessToken(ctx context.Context, baseAPI string,
 			return nil
 		case http.StatusUnauthorized:
 			// 401: access token 无效或已过期
-			return fmt.Errorf("invalid or expired access token (HTTP 401)")
+			return &AuthError{StatusCode: resp.StatusCode, Reason: "invalid or expired access token"}
 		case http.StatusForbidden:
 			// 403: 没有权限
-			return fmt.Errorf("access forbidden (HTTP 403)")
+			return &AuthError{StatusCode: resp.StatusCode, Reason: "access forbidden"}
 		case http.StatusTooManyRequests:
 			// 429: 速率限制
-			return fmt.Errorf("rate limited (HTTP 429)")
+			return &RateLimitError{StatusCode: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header, time.Now())}
 		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
 			// 5xx: 服务器错误，可以重试
-			lastErr = fmt.Errorf("server error (HTTP %d)", resp.StatusCode)
+			lastErr = &ServerError{StatusCode: resp.StatusCode}
 			if attempt < 3 {
 				time.Sleep(time.Duration(attempt) * time.Second)
 				continue
@@ -353,8 +452,8 @@ func (s *openAIService) ValidateAccessToken(ctx context.Context, baseAPI string,
 			return lastErr
 		default:
 			// 其他错误
-			body, _ := io.ReadAll(resp.Body)
-			return fmt.Errorf("validation failed (HTTP %d): %s", resp.StatusCode, string(body))
+			body, _ := s.readBody(resp.Body)
+			return fmt.Errorf("validation failed: %w", NewHTTPError(resp.StatusCode, resp.Header, string(body)))
 		}
 	}
 
@@ -374,34 +473,114 @@ type IDTokenClaims struct {
 	AuthClaims    map[string]interface{} `json:"https://api.openai.com/auth"` // OpenAI specific claims
 }
 
-// ValidateIDToken 验证 OpenAI OAuth ID Token
-// 这是验证 OAuth 账户的正确方法（不依赖于 API 端点调用）
-// 参考 claude-relay-service: src/routes/admin.js:7228-7248
-func (s *openAIService) ValidateIDToken(idToken string) (*IDTokenClaims, error) {
-	if idToken == "" {
-		return nil, fmt.Errorf("idToken cannot be empty")
+// IDTokenOrganization ID Token 中的组织信息（位于 OpenAI auth claims 的 organizations 字段）
+type IDTokenOrganization struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Role      string `json:"role"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// Organizations 从 OpenAI auth claims 中提取组织列表
+// 授权时需携带 id_token_add_organizations=true，否则 claims 中不包含组织信息
+func (c *IDTokenClaims) Organizations() []IDTokenOrganization {
+	raw, ok := c.AuthClaims["organizations"].([]interface{})
+	if !ok {
+		return nil
 	}
 
-	// 1. 解析 JWT（格式: header.payload.signature）
+	orgs := make([]IDTokenOrganization, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := m["id"].(string)
+		if id == "" {
+			continue
+		}
+		title, _ := m["title"].(string)
+		role, _ := m["role"].(string)
+		isDefault, _ := m["is_default"].(bool)
+		orgs = append(orgs, IDTokenOrganization{
+			ID:        id,
+			Title:     title,
+			Role:      role,
+			IsDefault: isDefault,
+		})
+	}
+
+	return orgs
+}
+
+// OrganizationIDs 返回组织 ID 列表
+func (c *IDTokenClaims) OrganizationIDs() []string {
+	orgs := c.Organizations()
+	if len(orgs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(orgs))
+	for _, org := range orgs {
+		ids = append(ids, org.ID)
+	}
+	return ids
+}
+
+// decodeIDTokenClaims 解码 ID Token payload（不校验签名和过期时间）
+func decodeIDTokenClaims(idToken string) (*IDTokenClaims, error) {
+	// 解析 JWT（格式: header.payload.signature）
 	parts := strings.Split(idToken, ".")
 	if len(parts) != 3 {
 		return nil, fmt.Errorf("invalid ID token format: expected 3 parts, got %d", len(parts))
 	}
 
-	// 2. 解码 payload（base64url 编码）
+	// 解码 payload（base64url 编码）
 	// 注意：Go 的 base64.RawURLEncoding 对应 Node.js 的 base64url（无填充）
 	payloadBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode ID token payload: %w", err)
 	}
 
-	// 3. 解析 JSON payload
+	// 解析 JSON payload
 	var claims IDTokenClaims
 	if err := json.Unmarshal(payloadBytes, &claims); err != nil {
 		return nil, fmt.Errorf("failed to parse ID token claims: %w", err)
 	}
 
-	// 4. 验证必要字段
+	return &claims, nil
+}
+
+// organizationsFromIDToken 从 ID Token 中提取组织 ID，解析失败时记录警告并返回 nil
+func organizationsFromIDToken(idToken string) []string {
+	if idToken == "" {
+		return nil
+	}
+
+	claims, err := decodeIDTokenClaims(idToken)
+	if err != nil {
+		log.Printf("Warning: failed to parse organizations from ID token: %v", err)
+		return nil
+	}
+
+	return claims.OrganizationIDs()
+}
+
+// ValidateIDToken 验证 OpenAI OAuth ID Token
+// 这是验证 OAuth 账户的正确方法（不依赖于 API 端点调用）
+// 参考 claude-relay-service: src/routes/admin.js:7228-7248
+func (s *openAIService) ValidateIDToken(idToken string) (*IDTokenClaims, error) {
+	if idToken == "" {
+		return nil, fmt.Errorf("idToken cannot be empty")
+	}
+
+	// 1. 解码 JWT payload
+	claims, err := decodeIDTokenClaims(idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 验证必要字段
 	if claims.Sub == "" {
 		return nil, fmt.Errorf("ID token missing 'sub' claim")
 	}
@@ -412,19 +591,19 @@ func (s *openAIService) ValidateIDToken(idToken string) (*IDTokenClaims, error)
 		return nil, fmt.Errorf("ID token missing 'iss' claim")
 	}
 
-	// 5. 验证 token 是否过期
+	// 3. 验证 token 是否过期
 	now := time.Now().Unix()
 	if claims.Exp > 0 && now > claims.Exp {
 		return nil, fmt.Errorf("ID token has expired (exp: %d, now: %d)", claims.Exp, now)
 	}
 
-	// 6. 验证 issuer（可选但推荐）
+	// 4. 验证 issuer（可选但推荐）
 	expectedIssuer := "https://auth.openai.com/"
 	if claims.Iss != expectedIssuer {
 		log.Printf("Warning: ID token issuer mismatch: expected %s, got %s", expectedIssuer, claims.Iss)
 	}
 
-	// 7. 验证 audience（可选但推荐）
+	// 5. 验证 audience（可选但推荐）
 	// aud 是数组，检查是否包含我们的 client ID
 	audValid := false
 	for _, aud := range claims.Aud {
@@ -443,5 +622,5 @@ func (s *openAIService) ValidateIDToken(idToken string) (*IDTokenClaims, error)
 	// 3. claude-relay-service 也没有验证签名
 	// 4. 主要目的是验证 token 格式正确且未过期
 
-	return &claims, nil
+	return claims, nil
 }
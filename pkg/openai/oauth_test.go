@@ -0,0 +1,176 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildTestIDToken builds an unsigned JWT with the given claims payload
+func buildTestIDToken(t *testing.T, claims map[string]interface{}) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	return header + "." + base64.RawURLEncoding.EncodeToString(payload) + ".signature"
+}
+
+func sampleIDTokenClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub": "user-123",
+		"aud": []string{OAuthClientID},
+		"iss": "https://auth.openai.com/",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"https://api.openai.com/auth": map[string]interface{}{
+			"chatgpt_account_id": "acc-456",
+			"organizations": []map[string]interface{}{
+				{"id": "org-default", "title": "Personal", "role": "owner", "is_default": true},
+				{"id": "org-team", "title": "Team", "role": "reader", "is_default": false},
+			},
+		},
+	}
+}
+
+// TestValidateIDToken_ExtractsOrganizations tests organizations parsing from ID token claims
+func TestValidateIDToken_ExtractsOrganizations(t *testing.T) {
+	service := NewOpenAIService()
+	idToken := buildTestIDToken(t, sampleIDTokenClaims())
+
+	claims, err := service.ValidateIDToken(idToken)
+	require.NoError(t, err)
+
+	orgs := claims.Organizations()
+	require.Len(t, orgs, 2)
+	assert.Equal(t, IDTokenOrganization{ID: "org-default", Title: "Personal", Role: "owner", IsDefault: true}, orgs[0])
+	assert.Equal(t, IDTokenOrganization{ID: "org-team", Title: "Team", Role: "reader", IsDefault: false}, orgs[1])
+	assert.Equal(t, []string{"org-default", "org-team"}, claims.OrganizationIDs())
+}
+
+// TestOrganizationsFromIDToken tests organization extraction used by ExchangeCode/RefreshToken
+func TestOrganizationsFromIDToken(t *testing.T) {
+	t.Run("with organizations", func(t *testing.T) {
+		idToken := buildTestIDToken(t, sampleIDTokenClaims())
+		assert.Equal(t, []string{"org-default", "org-team"}, organizationsFromIDToken(idToken))
+	})
+
+	t.Run("without auth claims", func(t *testing.T) {
+		claims := sampleIDTokenClaims()
+		delete(claims, "https://api.openai.com/auth")
+		idToken := buildTestIDToken(t, claims)
+		assert.Nil(t, organizationsFromIDToken(idToken))
+	})
+
+	t.Run("skips malformed entries", func(t *testing.T) {
+		claims := sampleIDTokenClaims()
+		claims["https://api.openai.com/auth"] = map[string]interface{}{
+			"organizations": []interface{}{"not-an-object", map[string]interface{}{"title": "missing id"}, map[string]interface{}{"id": "org-ok"}},
+		}
+		idToken := buildTestIDToken(t, claims)
+		assert.Equal(t, []string{"org-ok"}, organizationsFromIDToken(idToken))
+	})
+
+	t.Run("empty or invalid token", func(t *testing.T) {
+		assert.Nil(t, organizationsFromIDToken(""))
+		assert.Nil(t, organizationsFromIDToken("not-a-jwt"))
+	})
+}
+
+// TestExchangeCode_RetriesOnServerError tests that a 5xx response is retried and the next success is returned
+func TestExchangeCode_RetriesOnServerError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`{"error":"bad_gateway"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"at","refresh_token":"rt","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	service := &openAIService{
+		timeout:        DefaultTimeout,
+		maxRetries:     2,
+		oauthEndpoints: OAuthEndpoints{BaseURL: server.URL},
+	}
+
+	tokens, err := service.ExchangeCode(context.Background(), "code", "verifier", "")
+	require.NoError(t, err)
+	assert.Equal(t, "at", tokens.AccessToken)
+	assert.Equal(t, "rt", tokens.RefreshToken)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+// TestExchangeCode_DoesNotRetryClientError tests that a 400 is returned immediately since the code is single-use
+func TestExchangeCode_DoesNotRetryClientError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+	}))
+	defer server.Close()
+
+	service := &openAIService{
+		timeout:        DefaultTimeout,
+		maxRetries:     3,
+		oauthEndpoints: OAuthEndpoints{BaseURL: server.URL},
+	}
+
+	_, err := service.ExchangeCode(context.Background(), "code", "verifier", "")
+	require.Error(t, err)
+	var clientErr *ClientError
+	assert.ErrorAs(t, err, &clientErr)
+	assert.Equal(t, http.StatusBadRequest, StatusCode(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestOAuthEndpoints_ConfiguredTokenPath tests that refresh and authorize use the configured endpoints
+func TestOAuthEndpoints_ConfiguredTokenPath(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"at","refresh_token":"rt","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	service := NewOpenAIServiceWithOAuthEndpoints(OAuthEndpoints{
+		BaseURL:       server.URL,
+		AuthorizePath: "/codex/authorize",
+		TokenPath:     "/codex/token",
+	})
+
+	tokens, err := service.RefreshToken(context.Background(), "refresh", "")
+	require.NoError(t, err)
+	assert.Equal(t, "at", tokens.AccessToken)
+	assert.Equal(t, "/codex/token", path)
+
+	assert.Contains(t, service.GenerateAuthURL(&PKCEParams{State: "s"}), server.URL+"/codex/authorize?")
+
+	// Unset fields keep the defaults
+	assert.Equal(t, "https://auth.openai.com/oauth/token", OAuthEndpoints{}.TokenURL())
+}
+
+// TestOAuthEndpoints_FullURLPaths tests that paths given as full URLs are used as-is instead of being appended to BaseURL
+func TestOAuthEndpoints_FullURLPaths(t *testing.T) {
+	endpoints := OAuthEndpoints{
+		BaseURL:       "https://gateway.example.com",
+		AuthorizePath: "https://login.example.com/oauth/authorize",
+		TokenPath:     "https://token.example.com/oauth/token",
+	}
+
+	assert.Equal(t, "https://login.example.com/oauth/authorize", endpoints.AuthorizeURL())
+	assert.Equal(t, "https://token.example.com/oauth/token", endpoints.TokenURL())
+
+	// Relative paths are still joined to BaseURL
+	assert.Equal(t, "https://gateway.example.com/oauth/token", OAuthEndpoints{BaseURL: "https://gateway.example.com/"}.TokenURL())
+}
@@ -0,0 +1,23 @@
+package openai
+
+import (
+	"context"
+	"crypto/tls"
+)
+
+type clientCertificateKey struct{}
+
+// WithClientCertificate 返回携带账户 mTLS 客户端证书的 context
+// 企业网关要求双向 TLS 时，调用方在发起请求前挂载证书，createHTTPClient 会将其加载到 Transport；cert 为 nil 时原样返回
+func WithClientCertificate(ctx context.Context, cert *tls.Certificate) context.Context {
+	if cert == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, clientCertificateKey{}, cert)
+}
+
+// ClientCertificateFromContext 读取 context 中的 mTLS 客户端证书，未设置时返回 nil
+func ClientCertificateFromContext(ctx context.Context) *tls.Certificate {
+	cert, _ := ctx.Value(clientCertificateKey{}).(*tls.Certificate)
+	return cert
+}
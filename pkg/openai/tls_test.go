@@ -0,0 +1,42 @@
+package openai
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateHTTPClient_ClientCertificate tests that a client certificate on the context is
+// attached to the transport for mTLS.
+func TestCreateHTTPClient_ClientCertificate(t *testing.T) {
+	service := &openAIService{timeout: DefaultTimeout, maxRetries: DefaultMaxRetries}
+	cert := &tls.Certificate{Certificate: [][]byte{[]byte("client-cert-der")}}
+
+	ctx := WithClientCertificate(context.Background(), cert)
+	client, err := service.createHTTPClient(ctx, "http://proxy.local:8080", DefaultTimeout)
+	require.NoError(t, err)
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	require.NotNil(t, transport.TLSClientConfig)
+	require.Len(t, transport.TLSClientConfig.Certificates, 1)
+	assert.Equal(t, cert.Certificate, transport.TLSClientConfig.Certificates[0].Certificate)
+	assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+	assert.NotNil(t, transport.Proxy, "mTLS is combined with the proxy settings")
+}
+
+// TestCreateHTTPClient_NoClientCertificate tests that the default TLS config is kept without a certificate.
+func TestCreateHTTPClient_NoClientCertificate(t *testing.T) {
+	service := &openAIService{timeout: DefaultTimeout, maxRetries: DefaultMaxRetries}
+
+	client, err := service.createHTTPClient(WithClientCertificate(context.Background(), nil), "", DefaultTimeout)
+	require.NoError(t, err)
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Nil(t, transport.TLSClientConfig)
+}
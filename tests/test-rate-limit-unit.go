@@ -49,6 +49,7 @@ func main() {
 	rateLimiter := biz.NewRateLimiterUseCase(repo, logger)
 
 	const accountID int64 = 99999 // Test account ID
+	const provider = data.ProviderClaudeConsole
 	const rpmLimit int32 = 3
 	const tpmLimit int32 = 100
 
@@ -58,9 +59,9 @@ func main() {
 		fmt.Println("==========================================")
 		fmt.Println("Cleanup")
 		fmt.Println("==========================================")
-		rdb.Del(ctx, fmt.Sprintf("rate:%d:rpm", accountID))
-		rdb.Del(ctx, fmt.Sprintf("rate:%d:tpm", accountID))
-		rdb.Del(ctx, fmt.Sprintf("concurrency:%d", accountID))
+		rdb.Del(ctx, fmt.Sprintf("rate:{%d}:rpm", accountID))
+		rdb.Del(ctx, fmt.Sprintf("rate:{%d}:tpm", accountID))
+		rdb.Del(ctx, fmt.Sprintf("concurrency:{%d}", accountID))
 		fmt.Println("✓ Cleaned up test data")
 	}()
 
@@ -182,7 +183,7 @@ func main() {
 		requestID := fmt.Sprintf("req-%d", i+1)
 		requestIDs[i] = requestID
 
-		err := rateLimiter.AcquireConcurrencySlot(ctx, accountID, requestID)
+		err := rateLimiter.AcquireConcurrencySlot(ctx, accountID, provider, requestID)
 
 		if i < 10 {
 			// Should succeed
@@ -208,13 +209,13 @@ func main() {
 	// Release first 5 slots
 	fmt.Println("Releasing 5 concurrent slots...")
 	for i := 0; i < 5; i++ {
-		_ = rateLimiter.ReleaseConcurrencySlot(ctx, accountID, requestIDs[i])
+		_ = rateLimiter.ReleaseConcurrencySlot(ctx, accountID, provider, requestIDs[i])
 		fmt.Printf("  Released slot for request %d\n", i+1)
 	}
 	fmt.Println()
 
 	// Try to acquire again (should succeed now)
-	err = rateLimiter.AcquireConcurrencySlot(ctx, accountID, "req-13")
+	err = rateLimiter.AcquireConcurrencySlot(ctx, accountID, provider, "req-13")
 	if err == nil {
 		fmt.Println("  Request 13: ✓ ACQUIRED slot (after release)")
 		concurrencyPassed++
@@ -224,9 +225,9 @@ func main() {
 
 	// Release remaining slots
 	for i := 5; i < 10; i++ {
-		_ = rateLimiter.ReleaseConcurrencySlot(ctx, accountID, requestIDs[i])
+		_ = rateLimiter.ReleaseConcurrencySlot(ctx, accountID, provider, requestIDs[i])
 	}
-	_ = rateLimiter.ReleaseConcurrencySlot(ctx, accountID, "req-13")
+	_ = rateLimiter.ReleaseConcurrencySlot(ctx, accountID, provider, "req-13")
 
 	if concurrencyPassed == 13 {
 		fmt.Println()